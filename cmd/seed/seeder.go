@@ -159,52 +159,52 @@ func (s *Seeder) generateTransactions(startDate, endDate time.Time) []model.Tran
 	return transactions
 }
 
-func (s *Seeder) generateExpenseAmount(category model.TransactionCategory) float64 {
+func (s *Seeder) generateExpenseAmount(category model.TransactionCategory) model.Money {
 	// Generate realistic amounts based on category
 	switch category {
 	case model.CategoryGrocery:
-		return gofakeit.Float64Range(10, 150)
+		return model.NewMoney(gofakeit.Float64Range(10, 150))
 	case model.CategoryCar:
-		return gofakeit.Float64Range(20, 200)
+		return model.NewMoney(gofakeit.Float64Range(20, 200))
 	case model.CategoryClothes:
-		return gofakeit.Float64Range(15, 300)
+		return model.NewMoney(gofakeit.Float64Range(15, 300))
 	case model.CategoryHouse:
-		return gofakeit.Float64Range(50, 2000)
+		return model.NewMoney(gofakeit.Float64Range(50, 2000))
 	case model.CategoryBills:
-		return gofakeit.Float64Range(30, 300)
+		return model.NewMoney(gofakeit.Float64Range(30, 300))
 	case model.CategoryEntertainment:
-		return gofakeit.Float64Range(10, 100)
+		return model.NewMoney(gofakeit.Float64Range(10, 100))
 	case model.CategorySport:
-		return gofakeit.Float64Range(20, 150)
+		return model.NewMoney(gofakeit.Float64Range(20, 150))
 	case model.CategoryEatingOut:
-		return gofakeit.Float64Range(15, 80)
+		return model.NewMoney(gofakeit.Float64Range(15, 80))
 	case model.CategoryTransport:
-		return gofakeit.Float64Range(2, 50)
+		return model.NewMoney(gofakeit.Float64Range(2, 50))
 	case model.CategoryLearning:
-		return gofakeit.Float64Range(20, 500)
+		return model.NewMoney(gofakeit.Float64Range(20, 500))
 	case model.CategoryToiletry:
-		return gofakeit.Float64Range(5, 50)
+		return model.NewMoney(gofakeit.Float64Range(5, 50))
 	case model.CategoryHealth:
-		return gofakeit.Float64Range(20, 200)
+		return model.NewMoney(gofakeit.Float64Range(20, 200))
 	case model.CategoryTech:
-		return gofakeit.Float64Range(30, 1000)
+		return model.NewMoney(gofakeit.Float64Range(30, 1000))
 	case model.CategoryGifts:
-		return gofakeit.Float64Range(20, 200)
+		return model.NewMoney(gofakeit.Float64Range(20, 200))
 	case model.CategoryTravel:
-		return gofakeit.Float64Range(50, 1500)
+		return model.NewMoney(gofakeit.Float64Range(50, 1500))
 	default:
-		return gofakeit.Float64Range(10, 100)
+		return model.NewMoney(gofakeit.Float64Range(10, 100))
 	}
 }
 
-func (s *Seeder) generateIncomeAmount(category model.TransactionCategory) float64 {
+func (s *Seeder) generateIncomeAmount(category model.TransactionCategory) model.Money {
 	switch category {
 	case model.CategorySalary:
-		return gofakeit.Float64Range(2000, 5000)
+		return model.NewMoney(gofakeit.Float64Range(2000, 5000))
 	case model.CategoryOtherIncomes:
-		return gofakeit.Float64Range(50, 500)
+		return model.NewMoney(gofakeit.Float64Range(50, 500))
 	default:
-		return gofakeit.Float64Range(100, 1000)
+		return model.NewMoney(gofakeit.Float64Range(100, 1000))
 	}
 }
 
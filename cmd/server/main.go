@@ -12,6 +12,7 @@
 package main
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"net/http"
@@ -22,6 +23,7 @@ import (
 	"cashout/internal/ai"
 	"cashout/internal/client"
 	"cashout/internal/db"
+	"cashout/internal/email"
 	"cashout/internal/logging"
 	"cashout/internal/scheduler"
 	server_health "cashout/internal/server"
@@ -50,12 +52,37 @@ func main() {
 		logger.Fatalln("TELEGRAM_BOT_API_TOKEN environment variable is empty")
 	}
 
-	// OpenAI API Compatible LLM Setup
-	llm := ai.LLM{
-		Logger:   logger,
-		APIKey:   os.Getenv("OPENAI_API_KEY"),
-		Model:    os.Getenv("LLM_MODEL"),
-		Endpoint: fmt.Sprintf("%s/chat/completions", os.Getenv("OPENAI_BASE_URL")),
+	// LLM Setup: OpenAI API compatible by default, local Ollama or offline fake
+	provider, err := ai.NewProvider(os.Getenv("LLM_PROVIDER"), os.Getenv("OPENAI_BASE_URL"), os.Getenv("OPENAI_API_KEY"), os.Getenv("LLM_MODEL"))
+	if err != nil {
+		logger.Fatalf("Failed to set up the LLM: %s\n", err.Error())
+	}
+	llm := ai.NewLLM(provider, logger)
+
+	// Receipts are read by a vision model, on the same API unless configured otherwise
+	if visionModel := os.Getenv("LLM_VISION_MODEL"); visionModel != "" {
+		llm.Vision, err = ai.NewVisionProvider(
+			os.Getenv("LLM_PROVIDER"),
+			cmp.Or(os.Getenv("LLM_VISION_BASE_URL"), os.Getenv("OPENAI_BASE_URL")),
+			cmp.Or(os.Getenv("LLM_VISION_API_KEY"), os.Getenv("OPENAI_API_KEY")),
+			visionModel,
+		)
+		if err != nil {
+			logger.Fatalf("Failed to set up the vision LLM: %s\n", err.Error())
+		}
+	}
+
+	// Voice messages are transcribed by an OpenAI compatible speech to text
+	if speechModel := os.Getenv("LLM_SPEECH_MODEL"); speechModel != "" {
+		llm.Speech, err = ai.NewTranscriber(
+			os.Getenv("LLM_SPEECH_PROVIDER"),
+			cmp.Or(os.Getenv("LLM_SPEECH_BASE_URL"), os.Getenv("OPENAI_BASE_URL")),
+			cmp.Or(os.Getenv("LLM_SPEECH_API_KEY"), os.Getenv("OPENAI_API_KEY")),
+			speechModel,
+		)
+		if err != nil {
+			logger.Fatalf("Failed to set up the speech to text: %s\n", err.Error())
+		}
 	}
 
 	// Initialize database
@@ -89,6 +116,26 @@ func main() {
 	// Initialize client
 	c := client.NewClient(logger, db, llm)
 
+	// Email the budget alerts of the users asking for it, if configured
+	if apiKey := os.Getenv("BREVO_API_KEY"); apiKey != "" {
+		mailer, err := email.NewEmailService(apiKey, os.Getenv("EMAIL_FROM_NAME"), os.Getenv("EMAIL_FROM_ADDRESS"))
+		if err != nil {
+			logger.Errorf("Failed to initialize email service, budget alerts are not emailed: %v", err)
+		} else {
+			c.Mailer = mailer
+		}
+	}
+
+	// Load exchange rates from a local file, if any
+	if ratesFile := os.Getenv("EXCHANGE_RATES_FILE"); ratesFile != "" {
+		n, err := c.Repositories.ExchangeRates.LoadFile(ratesFile)
+		if err != nil {
+			logger.Errorf("Failed to load exchange rates from %s: %v", ratesFile, err)
+		} else {
+			logger.Infof("Loaded %d exchange rates from %s", n, ratesFile)
+		}
+	}
+
 	// Create bot from environment value.
 	b, err := gotgbot.NewBot(token, nil)
 	if err != nil {
@@ -175,7 +222,7 @@ func main() {
 	logger.Infof("%s has been started in %s mode...\n", b.Username, runMode)
 
 	// Initialize scheduler for automated reminders
-	sched := scheduler.NewScheduler(b, c.Repositories, logger)
+	sched := scheduler.NewScheduler(b, c.Repositories, c.Mailer, logger)
 	sched.Start()
 	defer sched.Stop()
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"cashout/internal/ai"
+	"cashout/internal/blobstore"
 	"cashout/internal/db"
 	"cashout/internal/email"
 	"cashout/internal/logging"
@@ -44,13 +45,12 @@ func main() {
 		logger.Fatalf("failed to create new bot: %s\n", err.Error())
 	}
 
-	// OpenAI API Compatible LLM Setup (if needed for dashboard features)
-	llm := ai.LLM{
-		Logger:   logger,
-		APIKey:   os.Getenv("OPENAI_API_KEY"),
-		Model:    os.Getenv("LLM_MODEL"),
-		Endpoint: fmt.Sprintf("%s/chat/completions", os.Getenv("OPENAI_BASE_URL")),
+	// LLM Setup: OpenAI API compatible by default, local Ollama or offline fake (if needed for dashboard features)
+	provider, err := ai.NewProvider(os.Getenv("LLM_PROVIDER"), os.Getenv("OPENAI_BASE_URL"), os.Getenv("OPENAI_API_KEY"), os.Getenv("LLM_MODEL"))
+	if err != nil {
+		logger.Fatalf("Failed to set up the LLM: %s\n", err.Error())
 	}
+	llm := ai.NewLLM(provider, logger)
 
 	// Initialize database
 	postgresURL := os.Getenv("DATABASE_URL")
@@ -71,6 +71,7 @@ func main() {
 	repo := repository.Repository{
 		DB:     database,
 		Logger: logger,
+		Blobs:  blobstore.NewLocal(os.Getenv("ATTACHMENTS_DIR")),
 	}
 
 	// Initialize WebAuthn repository
@@ -80,11 +81,32 @@ func main() {
 	}
 
 	repositories := web.Repositories{
-		Users:        repository.Users{Repository: repo},
-		Transactions: repository.Transactions{Repository: repo},
-		Auth:         repository.Auth{Repository: repo},
-		WebAuthn:     webAuthnRepo,
-		Budgets:      repository.Budgets{Repository: repo},
+		Users:         repository.Users{Repository: repo},
+		Transactions:  repository.Transactions{Repository: repo},
+		Auth:          repository.Auth{Repository: repo},
+		WebAuthn:      webAuthnRepo,
+		Budgets:       repository.Budgets{Repository: repo},
+		ExchangeRates: repository.ExchangeRates{Repository: repo},
+		Categories:    repository.Categories{Repository: repo},
+		Tags:          repository.Tags{Repository: repo},
+		Accounts:      repository.Accounts{Repository: repo},
+		Recurring:     repository.Recurring{Repository: repo},
+		Ledgers:       repository.Ledgers{Repository: repo},
+		Settlements:   repository.Settlements{Repository: repo},
+		Attachments:   repository.Attachments{Repository: repo},
+		Goals:         repository.Goals{Repository: repo},
+		Envelopes:     repository.Envelopes{Repository: repo},
+		NetWorth:      repository.NetWorth{Repository: repo},
+	}
+
+	// Load exchange rates from a local file, if any
+	if ratesFile := os.Getenv("EXCHANGE_RATES_FILE"); ratesFile != "" {
+		n, err := repositories.ExchangeRates.LoadFile(ratesFile)
+		if err != nil {
+			logger.Errorf("Failed to load exchange rates from %s: %v", ratesFile, err)
+		} else {
+			logger.Infof("Loaded %d exchange rates from %s", n, ratesFile)
+		}
 	}
 
 	// Start periodic WebAuthn session cleanup (every hour)
@@ -3,6 +3,9 @@ package ai
 import (
 	"strings"
 	"testing"
+	"time"
+
+	"cashout/internal/model"
 )
 
 func TestGeneratePrompt(t *testing.T) {
@@ -60,3 +63,51 @@ func TestGeneratePrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateTransactionPrompt(t *testing.T) {
+	categories := append(model.DefaultCategories(1),
+		model.Category{Name: "Kids", Type: model.TypeExpense},
+		model.Category{Name: "Archived", Type: model.TypeExpense, Archived: true},
+		model.Category{Name: "Freelance", Type: model.TypeIncome},
+	)
+
+	tests := []struct {
+		name            string
+		transactionType model.TransactionType
+		wantContains    []string
+		wantNotContains []string
+	}{
+		{
+			name:            "expense prompt lists the user expense categories",
+			transactionType: model.TypeExpense,
+			wantContains:    []string{`"Kids"`, `"Grocery"`, `use "OtherExpenses"`, "Today is 10-05-2026"},
+			wantNotContains: []string{`"Archived"`, `"Freelance"`},
+		},
+		{
+			name:            "income prompt lists the user income categories",
+			transactionType: model.TypeIncome,
+			wantContains:    []string{`"Salary", "OtherIncomes", "Freelance"`, `use "OtherIncomes"`},
+			wantNotContains: []string{`"Kids"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateTransactionPrompt("coffee 3.50", tt.transactionType, categories, time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatalf("GenerateTransactionPrompt() error = %v", err)
+			}
+
+			for _, want := range tt.wantContains {
+				if !strings.Contains(got, want) {
+					t.Errorf("GenerateTransactionPrompt() result doesn't contain %q", want)
+				}
+			}
+			for _, notWant := range tt.wantNotContains {
+				if strings.Contains(got, "(use ONLY these):\n"+notWant) || strings.Contains(got, ", "+notWant) {
+					t.Errorf("GenerateTransactionPrompt() result lists %q", notWant)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker is a circuit breaker around a provider: after Threshold
+// consecutive failures the provider is not called for Cooldown, then a single
+// call is let through to probe it again
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+	// now is replaced in tests, time.Now when nil
+	now func() time.Time
+}
+
+// NewBreaker returns a closed circuit breaker
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether the provider can be called. When the cooldown is
+// over only the first caller is allowed, until it reports its outcome.
+func (b *Breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.Threshold <= 0 || b.failures < b.Threshold {
+		return true
+	}
+	if b.probing || b.clock().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Success closes the breaker
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a failed call, opening the breaker once the threshold is
+// reached or when the probe fails
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.Threshold {
+		b.openUntil = b.clock().Add(b.Cooldown)
+	}
+}
+
+func (b *Breaker) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+	return b.now()
+}
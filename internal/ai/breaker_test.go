@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	b := NewBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	if !b.Allow() {
+		t.Fatal("expected the breaker to stay closed below the threshold")
+	}
+	b.Failure()
+	if b.Allow() {
+		t.Fatal("expected the breaker to open at the threshold")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("expected a probe after the cooldown")
+	}
+	if b.Allow() {
+		t.Fatal("expected a single probe at a time")
+	}
+	b.Failure()
+	if b.Allow() {
+		t.Fatal("expected a failed probe to open the breaker again")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("expected a probe after the cooldown")
+	}
+	b.Success()
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("expected a successful probe to close the breaker")
+	}
+
+	var none *Breaker
+	none.Failure()
+	if !none.Allow() {
+		t.Fatal("expected a nil breaker to be always closed")
+	}
+}
@@ -1,12 +1,12 @@
 package ai
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"cashout/internal/model"
@@ -15,19 +15,80 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// maxAnswerTransactions is the most transactions read from an answer
+const maxAnswerTransactions = 20
+
+// maxReceiptItems is the most line items read from a receipt
+const maxReceiptItems = 50
+
+// Defaults of the LLM calls, see NewLLM
+const (
+	DefaultTimeout          = 30 * time.Second
+	DefaultRetries          = 2
+	DefaultBackoff          = 500 * time.Millisecond
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = time.Minute
+)
+
+// LLM extracts transactions and intents from the user messages through a
+// provider. Every call is bounded by Timeout and retried with an exponential
+// backoff when the provider is rate limiting or failing; when it keeps
+// failing the breaker opens and the messages are parsed locally instead.
 type LLM struct {
-	APIKey   string
-	Endpoint string
-	Model    string
+	Provider Provider
 	Logger   *logrus.Logger
+	// Timeout bounds each single call, none when zero
+	Timeout time.Duration
+	// Retries is the number of retries after the first failed call
+	Retries int
+	// Backoff is the wait before the first retry, doubled at each retry
+	Backoff time.Duration
+	// Breaker skips the provider while it is down, none when nil
+	Breaker *Breaker
+	// Vision reads the photos of the receipts, which cannot be read when nil.
+	// It is called with the same timeout and retries, without the breaker.
+	Vision VisionProvider
+	// Speech transcribes the voice messages, which cannot be heard when nil.
+	// It is called like Vision.
+	Speech Transcriber
+}
+
+// Errors of the optional providers
+var (
+	// ErrNoVision is returned reading a receipt without a vision provider
+	ErrNoVision = errors.New("no LLM provider to read images")
+	// ErrNoSpeech is returned transcribing a voice message without a speech to text
+	ErrNoSpeech = errors.New("no speech to text provider")
+)
+
+// NewLLM returns an LLM on the provider with the default timeout, retries
+// and circuit breaker
+func NewLLM(provider Provider, logger *logrus.Logger) *LLM {
+	return &LLM{
+		Provider: provider,
+		Logger:   logger,
+		Timeout:  DefaultTimeout,
+		Retries:  DefaultRetries,
+		Backoff:  DefaultBackoff,
+		Breaker:  NewBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown),
+	}
 }
 
 type ExtractedTransaction struct {
 	Type        model.TransactionType
 	Description string
-	Amount      float64
+	Amount      model.Money
+	Currency    model.CurrencyType // empty when not mentioned by the user
 	Category    string
+	Tags        []string // hashtags of the user text, normalized
 	Date        time.Time
+	Items       []ReceiptItem // line items of a receipt, when they could be read
+}
+
+// ReceiptItem is an item bought, as listed on a receipt
+type ReceiptItem struct {
+	Description string
+	Amount      model.Money
 }
 
 // Intent represents the classified user intent
@@ -46,6 +107,7 @@ const (
 	IntentYearRecap  Intent = "year_recap"
 	IntentExport     Intent = "export"
 	IntentClone      Intent = "clone"
+	IntentQuery      Intent = "query"
 	IntentUnknown    Intent = "unknown"
 )
 
@@ -55,242 +117,372 @@ type ClassifiedIntent struct {
 	Confidence float64 `json:"confidence"`
 }
 
-func (llm *LLM) ExtractTransaction(userText string, transactionType model.TransactionType) (ExtractedTransaction, error) {
+// ExtractTransactions parses the user text into the transactions it lists,
+// usually one, picking their category among the given user's categories. The
+// date defaults to today, the current day of the user. When the provider is
+// down the text is parsed locally.
+func (llm *LLM) ExtractTransactions(ctx context.Context, userText string, transactionType model.TransactionType, categories model.Categories, today time.Time) ([]ExtractedTransaction, error) {
+	// Generate prompt using the template
+	prompt, err := GenerateTransactionPrompt(userText, transactionType, categories, today)
+	if err != nil {
+		llm.Logger.Errorf("Error generating prompt: %v\n", err)
+		return nil, err
+	}
+
+	answers, err := llm.answerTransactions(ctx, prompt)
+	if providerDown(err) {
+		llm.Logger.Warnf("LLM unavailable, parsing the transactions locally: %v", err)
+		answers = parseTransactionsText(userText, categories.OfType(transactionType).Names())
+	} else if err != nil {
+		return nil, err
+	}
+
+	hashtags := model.ParseHashtags(userText)
+	var transactions []ExtractedTransaction
+	for _, data := range answers {
+		transaction := newExtractedTransaction(data, transactionType, categories, today)
+		// A single transaction gets all the hashtags, the ones of several
+		// transactions must have been written by the user
+		transaction.Tags = hashtags
+		if len(answers) > 1 {
+			transaction.Tags = answerTags(data.Tags, hashtags)
+		}
+		if len(transaction.Tags) > 0 {
+			transaction.Description, _, _ = model.ParseTagQuery(transaction.Description)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	// The answers without an amount are noise, unless there is nothing else
+	withAmount := slices.DeleteFunc(slices.Clone(transactions), func(t ExtractedTransaction) bool { return t.Amount == 0 })
+	if len(withAmount) > 0 {
+		return withAmount, nil
+	}
+	if len(transactions) > 0 {
+		return transactions[:1], nil
+	}
+	return []ExtractedTransaction{{Type: transactionType, Tags: hashtags, Date: today}}, nil
+}
+
+// newExtractedTransaction returns the transaction of an answer of the
+// model, checking its values
+func newExtractedTransaction(data transactionData, transactionType model.TransactionType, categories model.Categories, today time.Time) ExtractedTransaction {
 	transaction := ExtractedTransaction{
-		Type: transactionType,
+		Type:        transactionType,
+		Description: data.Description,
+		Date:        answerDate(data.Date, today),
 	}
 
-	tmpl := LLMExpensePromptTemplate
-	if transactionType == model.TypeIncome {
-		tmpl = LLMIncomePromptTemplate
+	if parsed, err := model.ParseMoney(data.Amount.String()); err == nil {
+		transaction.Amount = parsed
 	}
 
-	// Generate prompt using the template
-	prompt, err := GeneratePrompt(userText, tmpl)
-	if err != nil {
-		llm.Logger.Errorf("Error generating prompt: %v\n", err)
-		return transaction, err
-	}
-
-	// Request payload
-	requestBody, err := json.Marshal(map[string]any{
-		"model": llm.Model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"max_tokens": 250,
-	})
-	if err != nil {
-		llm.Logger.Errorf("Error creating request: %v\n", err)
-		return transaction, err
+	currency := strings.ToUpper(strings.TrimSpace(data.Currency))
+	if model.IsValidCurrency(currency) {
+		transaction.Currency = model.CurrencyType(currency)
 	}
 
-	// Create request
-	req, err := http.NewRequest("POST", llm.Endpoint, bytes.NewBuffer(requestBody))
-	if err != nil {
-		llm.Logger.Errorf("Error creating request: %v\n", err)
-		return transaction, err
+	transaction.Category = data.Category
+	// The LLM may still answer with a category the user does not have
+	if _, ok := categories.OfType(transactionType).Find(transaction.Category); !ok {
+		fallback, _ := categories.Fallback(transactionType)
+		transaction.Category = string(fallback)
+	}
+	if transaction.Description == "" {
+		transaction.Description = transaction.Category
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+llm.APIKey)
+	return transaction
+}
+
+// answerDate returns the date answered, today when missing or invalid
+func answerDate(date string, today time.Time) time.Time {
+	switch date {
+	case "", dateToday:
+		return today
+	case dateYesterday:
+		return today.AddDate(0, 0, -1)
+	}
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	parsed, err := utils.ParseDate(date)
 	if err != nil {
-		llm.Logger.Errorf("Error sending request: %v\n", err)
-		return transaction, err
+		return today
+	}
+	return parsed
+}
+
+// answerTags returns the tags answered for a transaction among the hashtags
+// of the user text
+func answerTags(tags, hashtags []string) []string {
+	var res []string
+	for _, tag := range tags {
+		name, err := model.NormalizeTagName(strings.TrimPrefix(tag, "#"))
+		if err == nil && slices.Contains(hashtags, name) && !slices.Contains(res, name) {
+			res = append(res, name)
+		}
 	}
-	defer func() {
-		err = errors.Join(err, resp.Body.Close())
-	}()
+	return res
+}
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+// answerTransactions asks the provider the transactions prompt
+func (llm *LLM) answerTransactions(ctx context.Context, prompt string) ([]transactionData, error) {
+	content, err := llm.complete(ctx, prompt, 250+150*maxAnswerTransactions)
 	if err != nil {
-		llm.Logger.Errorf("Error reading response: %v\n", err)
-		return transaction, err
+		return nil, err
 	}
 
-	// Parse response
-	var result map[string]any
-	if err := json.Unmarshal(body, &result); err != nil {
-		llm.Logger.Errorf("Error parsing response: %v\n", err)
-		llm.Logger.Errorln("Raw response", body)
-		return transaction, err
+	// Numbers are kept as written, so that the amounts are read exactly. The
+	// fields of an unexpected type are ignored.
+	var answer map[string]any
+	if err := decodeAnswer(content, &answer); err != nil {
+		llm.Logger.Errorln("Error parsing LLM response as JSON", err)
+		return nil, err
 	}
 
-	// Extract and print the message content
-	var content string
-	if choices, ok := result["choices"].([]any); ok && len(choices) > 0 {
-		if choice, ok := choices[0].(map[string]any); ok {
-			if message, ok := choice["message"].(map[string]any); ok {
-				llm.Logger.Debugln("LLM Message", message)
-				content = fmt.Sprintf("%v", message["content"])
-			}
+	// A single transaction may still be answered on its own
+	items := []any{answer}
+	if list, ok := answer["transactions"].([]any); ok {
+		items = list
+	}
+
+	var transactions []transactionData
+	for _, item := range items[:min(len(items), maxAnswerTransactions)] {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			continue
 		}
-	} else {
-		llm.Logger.Errorln("Raw response", body)
-		return transaction, fmt.Errorf("invalid response format")
-	}
-
-	// Sometimes the llm returns the ```json``` markdown format, despite being asked no to, so we need to clean it up
-	jsonStart := 0
-	jsonEnd := len(content)
-	// Start parsing char by char until a "{" is found
-	for i, char := range content {
-		if char == '{' {
-			jsonStart = i
-			break
+		var data transactionData
+		data.Category, _ = fields["category"].(string)
+		data.Amount, _ = fields["amount"].(json.Number)
+		data.Currency, _ = fields["currency"].(string)
+		data.Description, _ = fields["description"].(string)
+		data.Date, _ = fields["date"].(string)
+		if tags, ok := fields["tags"].([]any); ok {
+			for _, tag := range tags {
+				if name, ok := tag.(string); ok {
+					data.Tags = append(data.Tags, name)
+				}
+			}
 		}
+		transactions = append(transactions, data)
 	}
-	// Starting from the end do the same until a "}" is found
-	for i := len(content) - 1; i >= 0; i-- {
-		if content[i] == '}' {
-			jsonEnd = i + 1
-			break
-		}
+
+	return transactions, nil
+}
+
+// ExtractReceipt reads the expense of the photo of a receipt: the merchant
+// as description, the total and its currency, the date and the line items,
+// picking the category among the given user's categories. The date defaults
+// to today, the current day of the user.
+func (llm *LLM) ExtractReceipt(ctx context.Context, image []byte, contentType string, categories model.Categories, today time.Time) (ExtractedTransaction, error) {
+	if llm.Vision == nil {
+		return ExtractedTransaction{}, ErrNoVision
 	}
-	// Remove the markdown
-	content = content[jsonStart:jsonEnd]
 
-	// ExtractExpense from the LLM Response text
-	// Parse the LLM JSON response
-	var transactionData map[string]any
-	if err := json.Unmarshal([]byte(content), &transactionData); err != nil {
-		llm.Logger.Errorln("Error parsing LLM response as JSON", err)
-		return transaction, err
+	prompt, err := GenerateReceiptPrompt(categories, today)
+	if err != nil {
+		llm.Logger.Errorf("Error generating receipt prompt: %v\n", err)
+		return ExtractedTransaction{}, err
 	}
 
-	// Extract fields
-	if description, ok := transactionData["description"].(string); ok {
-		transaction.Description = description
+	content, err := llm.retry(ctx, nil, func(ctx context.Context) (string, error) {
+		return llm.Vision.CompleteImage(ctx, prompt, image, contentType, 250+30*maxReceiptItems)
+	})
+	if err != nil {
+		return ExtractedTransaction{}, err
 	}
 
-	if amount, ok := transactionData["amount"].(float64); ok {
-		transaction.Amount = amount
+	var answer struct {
+		Merchant string      `json:"merchant"`
+		Date     string      `json:"date"`
+		Total    json.Number `json:"total"`
+		Currency string      `json:"currency"`
+		Category string      `json:"category"`
+		Items    []struct {
+			Description string      `json:"description"`
+			Amount      json.Number `json:"amount"`
+		} `json:"items"`
+	}
+	if err := decodeAnswer(content, &answer); err != nil {
+		llm.Logger.Errorln("Error parsing receipt response as JSON", err)
+		return ExtractedTransaction{}, err
 	}
 
-	if category, ok := transactionData["category"].(string); ok {
-		transaction.Category = category
+	transaction := newExtractedTransaction(transactionData{
+		Category:    answer.Category,
+		Amount:      answer.Total,
+		Currency:    answer.Currency,
+		Description: strings.TrimSpace(answer.Merchant),
+		Date:        answer.Date,
+	}, model.TypeExpense, categories, today)
+	// A misread date is more likely than a purchase in the future
+	if transaction.Date.After(today) {
+		transaction.Date = today
 	}
 
-	transaction.Date = time.Now()
-	if date, ok := transactionData["date"].(string); ok {
-		transaction.Date, err = utils.ParseDate(date)
-		if err != nil {
-			transaction.Date = time.Now()
+	var itemsTotal model.Money
+	for _, item := range answer.Items[:min(len(answer.Items), maxReceiptItems)] {
+		amount, err := model.ParseMoney(item.Amount.String())
+		description := strings.TrimSpace(item.Description)
+		if err != nil || description == "" {
+			continue
 		}
+		transaction.Items = append(transaction.Items, ReceiptItem{Description: description, Amount: amount})
+		itemsTotal += amount
+	}
+	// The total may be unreadable when the items are not
+	if transaction.Amount == 0 {
+		transaction.Amount = itemsTotal
 	}
 
 	return transaction, nil
 }
 
-// ClassifyIntent classifies the user's intent from their message
-func (llm *LLM) ClassifyIntent(userText string) (ClassifiedIntent, error) {
-	result := ClassifiedIntent{
-		Intent:     IntentUnknown,
-		Confidence: 0,
+// Transcribe returns the text spoken in the audio file with the given name,
+// trimmed
+func (llm *LLM) Transcribe(ctx context.Context, audio []byte, fileName string) (string, error) {
+	if llm.Speech == nil {
+		return "", ErrNoSpeech
 	}
 
-	// Generate prompt using the template
-	prompt, err := GeneratePrompt(userText, LLMIntentClassificationPromptTemplate)
+	text, err := llm.retry(ctx, nil, func(ctx context.Context) (string, error) {
+		return llm.Speech.Transcribe(ctx, audio, fileName)
+	})
+	return strings.TrimSpace(text), err
+}
+
+// ExtractQuery turns a question on the user's transactions into a query
+// spec, validated against the given user's categories: the model only picks
+// the filters, the grouping and the aggregate, and the server runs it. The
+// relative periods are resolved from today, the current day of the user.
+// model.ErrInvalidQuery is returned for an answer that cannot be run.
+func (llm *LLM) ExtractQuery(ctx context.Context, question string, categories model.Categories, today time.Time) (model.QuerySpec, error) {
+	prompt, err := GenerateQueryPrompt(question, categories, today)
 	if err != nil {
-		llm.Logger.Errorf("Error generating intent classification prompt: %v\n", err)
-		return result, err
+		llm.Logger.Errorf("Error generating query prompt: %v\n", err)
+		return model.QuerySpec{}, err
 	}
 
-	// Request payload
-	requestBody, err := json.Marshal(map[string]any{
-		"model": llm.Model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"max_tokens": 100,
-	})
+	content, err := llm.complete(ctx, prompt, 250)
 	if err != nil {
-		llm.Logger.Errorf("Error creating request: %v\n", err)
-		return result, err
+		return model.QuerySpec{}, err
+	}
+
+	var answer struct {
+		Type        string       `json:"type"`
+		Category    string       `json:"category"`
+		Description string       `json:"description"`
+		DateFrom    string       `json:"date_from"`
+		DateTo      string       `json:"date_to"`
+		AmountMin   *json.Number `json:"amount_min"`
+		AmountMax   *json.Number `json:"amount_max"`
+		Tags        []string     `json:"tags"`
+		ExcludeTags []string     `json:"exclude_tags"`
+		GroupBy     string       `json:"group_by"`
+		Aggregate   string       `json:"aggregate"`
+	}
+	if err := decodeAnswer(content, &answer); err != nil {
+		llm.Logger.Errorln("Error parsing query response as JSON", err)
+		return model.QuerySpec{}, fmt.Errorf("%w: %w", model.ErrInvalidQuery, err)
+	}
+
+	spec := model.QuerySpec{
+		Type:        model.TransactionType(answer.Type),
+		Category:    model.TransactionCategory(answer.Category),
+		Query:       answer.Description,
+		Tags:        answer.Tags,
+		ExcludeTags: answer.ExcludeTags,
+		GroupBy:     model.QueryGroupBy(strings.ToLower(answer.GroupBy)),
+		Aggregate:   model.QueryAggregate(strings.ToLower(answer.Aggregate)),
+	}
+	if spec.DateFrom, err = answerQueryDate(answer.DateFrom); err != nil {
+		return model.QuerySpec{}, err
+	}
+	if spec.DateTo, err = answerQueryDate(answer.DateTo); err != nil {
+		return model.QuerySpec{}, err
+	}
+	if spec.AmountMin, err = answerQueryAmount(answer.AmountMin); err != nil {
+		return model.QuerySpec{}, err
+	}
+	if spec.AmountMax, err = answerQueryAmount(answer.AmountMax); err != nil {
+		return model.QuerySpec{}, err
+	}
+
+	if err := spec.Validate(categories); err != nil {
+		return model.QuerySpec{}, err
 	}
+	return spec, nil
+}
 
-	// Create request
-	req, err := http.NewRequest("POST", llm.Endpoint, bytes.NewBuffer(requestBody))
+// answerQueryDate returns the date answered for a query, nil when open
+func answerQueryDate(date string) (*time.Time, error) {
+	if date == "" {
+		return nil, nil
+	}
+	parsed, err := utils.ParseDate(date)
 	if err != nil {
-		llm.Logger.Errorf("Error creating request: %v\n", err)
-		return result, err
+		return nil, fmt.Errorf("%w: %w", model.ErrInvalidQuery, err)
 	}
+	return &parsed, nil
+}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+llm.APIKey)
+// answerQueryAmount returns the amount answered for a query, nil when open
+func answerQueryAmount(amount *json.Number) (*model.Money, error) {
+	if amount == nil {
+		return nil, nil
+	}
+	parsed, err := model.ParseMoney(amount.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", model.ErrInvalidQuery, err)
+	}
+	return &parsed, nil
+}
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// AnswerQuery phrases the answer to the question from the results of its
+// query, rendered as text
+func (llm *LLM) AnswerQuery(ctx context.Context, question, facts string) (string, error) {
+	prompt, err := GeneratePromptWithData(PromptData{UserText: question, Facts: facts}, LLMQueryAnswerPromptTemplate)
 	if err != nil {
-		llm.Logger.Errorf("Error sending request: %v\n", err)
-		return result, err
+		llm.Logger.Errorf("Error generating query answer prompt: %v\n", err)
+		return "", err
 	}
-	defer func() {
-		err = errors.Join(err, resp.Body.Close())
-	}()
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	content, err := llm.complete(ctx, prompt, 200)
 	if err != nil {
-		llm.Logger.Errorf("Error reading response: %v\n", err)
-		return result, err
+		return "", err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", errors.New("empty answer")
+	}
+	return content, nil
+}
+
+// ClassifyIntent classifies the user's intent from their message. When the
+// provider is down the message is classified locally.
+func (llm *LLM) ClassifyIntent(ctx context.Context, userText string) (ClassifiedIntent, error) {
+	result := ClassifiedIntent{
+		Intent:     IntentUnknown,
+		Confidence: 0,
 	}
 
-	// Parse response
-	var apiResponse map[string]any
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		llm.Logger.Errorf("Error parsing response: %v\n", err)
-		llm.Logger.Errorln("Raw response", body)
+	// Generate prompt using the template
+	prompt, err := GeneratePrompt(userText, LLMIntentClassificationPromptTemplate)
+	if err != nil {
+		llm.Logger.Errorf("Error generating intent classification prompt: %v\n", err)
 		return result, err
 	}
 
-	// Extract the message content
-	var content string
-	if choices, ok := apiResponse["choices"].([]any); ok && len(choices) > 0 {
-		if choice, ok := choices[0].(map[string]any); ok {
-			if message, ok := choice["message"].(map[string]any); ok {
-				llm.Logger.Debugln("LLM Intent Classification Message", message)
-				content = fmt.Sprintf("%v", message["content"])
-			}
-		}
-	} else {
-		llm.Logger.Errorln("Raw response", body)
-		return result, fmt.Errorf("invalid response format")
-	}
-
-	// Clean up markdown if present
-	jsonStart := 0
-	jsonEnd := len(content)
-	for i, char := range content {
-		if char == '{' {
-			jsonStart = i
-			break
-		}
+	content, err := llm.complete(ctx, prompt, 100)
+	if providerDown(err) {
+		llm.Logger.Warnf("LLM unavailable, classifying the intent locally: %v", err)
+		return classifyIntentText(userText), nil
 	}
-	for i := len(content) - 1; i >= 0; i-- {
-		if content[i] == '}' {
-			jsonEnd = i + 1
-			break
-		}
+	if err != nil {
+		return result, err
 	}
-	content = content[jsonStart:jsonEnd]
 
-	// Parse the JSON response
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
+	if err := decodeAnswer(content, &result); err != nil {
 		llm.Logger.Errorln("Error parsing intent classification response as JSON", err)
 		return result, err
 	}
@@ -298,7 +490,7 @@ func (llm *LLM) ClassifyIntent(userText string) (ClassifiedIntent, error) {
 	// Validate the intent
 	switch result.Intent {
 	case IntentAddExpense, IntentAddIncome, IntentEdit, IntentDelete, IntentSearch,
-		IntentList, IntentWeekRecap, IntentMonthRecap, IntentYearRecap, IntentExport, IntentClone:
+		IntentList, IntentWeekRecap, IntentMonthRecap, IntentYearRecap, IntentExport, IntentClone, IntentQuery:
 		// Valid intent
 	default:
 		result.Intent = IntentUnknown
@@ -306,3 +498,69 @@ func (llm *LLM) ClassifyIntent(userText string) (ClassifiedIntent, error) {
 
 	return result, nil
 }
+
+// complete asks the prompt to the provider, retrying while it is down
+func (llm *LLM) complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return llm.retry(ctx, llm.Breaker, func(ctx context.Context) (string, error) {
+		return llm.Provider.Complete(ctx, prompt, maxTokens)
+	})
+}
+
+// retry makes the call, each bounded by the timeout, retrying while the
+// provider is down and recording the outcome in the breaker
+func (llm *LLM) retry(ctx context.Context, breaker *Breaker, call func(ctx context.Context) (string, error)) (string, error) {
+	if !breaker.Allow() {
+		return "", ErrUnavailable
+	}
+
+	for retry := 0; ; retry++ {
+		content, err := llm.callOnce(ctx, call)
+		if err == nil || !providerDown(err) {
+			// The provider answered, even if with an error of ours
+			breaker.Success()
+			if err == nil {
+				llm.Logger.Debugln("LLM Message", content)
+			}
+			return content, err
+		}
+
+		if retry >= llm.Retries || ctx.Err() != nil {
+			breaker.Failure()
+			return "", err
+		}
+
+		wait := backoff(llm.Backoff, retry)
+		llm.Logger.Warnf("LLM call failed, retrying in %s: %v", wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			breaker.Failure()
+			return "", err
+		}
+	}
+}
+
+// callOnce makes a single call to the provider, bounded by the timeout
+func (llm *LLM) callOnce(ctx context.Context, call func(ctx context.Context) (string, error)) (string, error) {
+	if llm.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, llm.Timeout)
+		defer cancel()
+	}
+	return call(ctx)
+}
+
+// decodeAnswer decodes the JSON object answered by the model into v.
+// Sometimes the model wraps it in the ```json``` markdown format despite
+// being asked not to, so anything around the object is dropped.
+func decodeAnswer(content string, v any) error {
+	jsonStart := strings.Index(content, "{")
+	jsonEnd := strings.LastIndex(content, "}")
+	if jsonStart < 0 || jsonEnd < jsonStart {
+		return fmt.Errorf("no JSON object in the answer %q", content)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(content[jsonStart : jsonEnd+1]))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -2,9 +2,17 @@ package ai
 
 import (
 	"cashout/internal/model"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 func TestExtractedTransactionJSON(t *testing.T) {
@@ -12,7 +20,7 @@ func TestExtractedTransactionJSON(t *testing.T) {
 	transaction := ExtractedTransaction{
 		Type:        model.TypeExpense,
 		Description: "Coffee at Starbucks",
-		Amount:      3.50,
+		Amount:      model.NewMoney(3.50),
 		Category:    "EatingOut",
 		Date:        time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
 	}
@@ -44,3 +52,279 @@ func TestExtractedTransactionJSON(t *testing.T) {
 		t.Errorf("Category mismatch: got %v, want %v", unmarshaled.Category, transaction.Category)
 	}
 }
+
+// scripted is a provider failing with the given errors before answering
+type scripted struct {
+	errs  []error
+	reply string
+	calls int
+}
+
+func (s *scripted) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	s.calls++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return "", err
+	}
+	return s.reply, nil
+}
+
+func testLLM(provider Provider) *LLM {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &LLM{Provider: provider, Logger: logger, Timeout: time.Second, Retries: 2, Backoff: time.Millisecond, Breaker: NewBreaker(2, time.Minute)}
+}
+
+func TestExtractTransactions(t *testing.T) {
+	categories := model.DefaultCategories(1)
+	today := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+
+	llm := testLLM(&Fake{Replies: map[string]string{
+		"pizza 12.5 usd #friday": "```json\n{\"category\": \"EatingOut\", \"amount\": 12.50, \"currency\": \"usd\", \"description\": \"Pizza #friday\"}\n```",
+		"mystery 3":              `{"transactions": [{"category": "Mystery", "amount": 3, "description": ""}]}`,
+		"coffee 2.5, nothing, train 4.20 yesterday #work #made-up": `{"transactions": [
+			{"category": "EatingOut", "amount": 2.5, "description": "Coffee", "date": "", "tags": []},
+			{"category": "", "amount": 0, "description": "Nothing"},
+			{"category": "Transport", "amount": 4.2, "description": "Train", "date": "09-05-2026", "tags": ["#Work", "holiday"]}
+		]}`,
+	}})
+
+	tests := []struct {
+		name  string
+		input string
+		want  []ExtractedTransaction
+	}{
+		{
+			name:  "single object in markdown",
+			input: "pizza 12.5 usd #friday",
+			want:  []ExtractedTransaction{{Type: model.TypeExpense, Description: "Pizza", Amount: model.NewMoney(12.5), Currency: model.CurrencyUSD, Category: "EatingOut", Tags: []string{"friday"}, Date: today}},
+		},
+		{
+			name:  "unknown category",
+			input: "mystery 3",
+			want:  []ExtractedTransaction{{Type: model.TypeExpense, Description: "OtherExpenses", Amount: model.NewMoney(3), Category: "OtherExpenses", Date: today}},
+		},
+		{
+			name:  "several transactions",
+			input: "coffee 2.5, nothing, train 4.20 yesterday #work #made-up",
+			want: []ExtractedTransaction{
+				{Type: model.TypeExpense, Description: "Coffee", Amount: model.NewMoney(2.5), Category: "EatingOut", Date: today},
+				{Type: model.TypeExpense, Description: "Train", Amount: model.NewMoney(4.2), Category: "Transport", Tags: []string{"work"}, Date: today.AddDate(0, 0, -1)},
+			},
+		},
+		{
+			name:  "parsed locally by the fake",
+			input: "bread 2,30 grocery, milk 1 yesterday",
+			want: []ExtractedTransaction{
+				{Type: model.TypeExpense, Description: "Bread", Amount: model.NewMoney(2.3), Category: "Grocery", Date: today},
+				{Type: model.TypeExpense, Description: "Milk", Amount: model.NewMoney(1), Category: "OtherExpenses", Date: today.AddDate(0, 0, -1)},
+			},
+		},
+		{
+			name:  "no amount",
+			input: "hello",
+			want:  []ExtractedTransaction{{Type: model.TypeExpense, Description: "Hello", Category: "OtherExpenses", Date: today}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := llm.ExtractTransactions(context.Background(), tt.input, model.TypeExpense, categories, today)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractTransactions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLLMRetries(t *testing.T) {
+	provider := &scripted{
+		errs:  []error{&StatusError{StatusCode: 503}, &StatusError{StatusCode: 429}},
+		reply: `{"intent": "export", "confidence": 0.9}`,
+	}
+	llm := testLLM(provider)
+
+	got, err := llm.ClassifyIntent(context.Background(), "download everything")
+	if err != nil || got.Intent != IntentExport || got.Confidence != 0.9 || provider.calls != 3 {
+		t.Fatalf("ClassifyIntent() = %+v, %v after %d calls", got, err, provider.calls)
+	}
+
+	// A bad request is not retried, and is not answered locally
+	provider = &scripted{errs: []error{&StatusError{StatusCode: 400}}}
+	llm = testLLM(provider)
+	if _, err := llm.ClassifyIntent(context.Background(), "download everything"); err == nil || provider.calls != 1 {
+		t.Fatalf("expected a single failed call, got %v after %d calls", err, provider.calls)
+	}
+}
+
+func TestLLMFallsBackWhenDown(t *testing.T) {
+	fake := &Fake{Err: ErrUnavailable}
+	llm := testLLM(fake)
+
+	// Each message is retried, then parsed locally
+	for range 2 {
+		got, err := llm.ClassifyIntent(context.Background(), "month recap")
+		if err != nil || got.Intent != IntentMonthRecap {
+			t.Fatalf("ClassifyIntent() = %+v, %v", got, err)
+		}
+	}
+	if fake.Calls() != 6 {
+		t.Fatalf("got %d calls, want 6", fake.Calls())
+	}
+
+	// The breaker is now open: the provider is skipped
+	got, err := llm.ExtractTransactions(context.Background(), "coffee 3", model.TypeExpense, model.DefaultCategories(1), time.Now())
+	if err != nil || len(got) != 1 || got[0].Amount != model.NewMoney(3) || got[0].Description != "Coffee" || got[0].Category != string(model.CategoryOtherExpenses) {
+		t.Fatalf("ExtractTransaction() = %+v, %v", got, err)
+	}
+	if fake.Calls() != 6 {
+		t.Fatalf("got %d calls, want the provider to be skipped", fake.Calls())
+	}
+}
+
+func TestLLMTimeout(t *testing.T) {
+	llm := testLLM(providerFunc(func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+	llm.Timeout = time.Millisecond
+	llm.Retries = 0
+
+	got, err := llm.ClassifyIntent(context.Background(), "list")
+	if err != nil || got.Intent != IntentList {
+		t.Fatalf("ClassifyIntent() = %+v, %v, want the local classification", got, err)
+	}
+}
+
+// providerFunc adapts a function to Provider
+type providerFunc func(ctx context.Context) (string, error)
+
+func (f providerFunc) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return f(ctx)
+}
+
+func TestExtractReceipt(t *testing.T) {
+	categories := model.DefaultCategories(1)
+	today := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		answer string
+		want   ExtractedTransaction
+	}{
+		{
+			name:   "receipt",
+			answer: `{"merchant": "Esselunga", "date": "09-05-2026", "total": 2.19, "currency": "EUR", "category": "Grocery", "items": [{"description": "Pane", "amount": 1.2}, {"description": "Latte", "amount": 0.99}]}`,
+			want: ExtractedTransaction{
+				Type: model.TypeExpense, Description: "Esselunga", Amount: model.NewMoney(2.19), Currency: model.CurrencyEUR, Category: "Grocery",
+				Date:  today.AddDate(0, 0, -1),
+				Items: []ReceiptItem{{Description: "Pane", Amount: model.NewMoney(1.2)}, {Description: "Latte", Amount: model.NewMoney(0.99)}},
+			},
+		},
+		{
+			name:   "unreadable total and merchant",
+			answer: "```json\n{\"merchant\": \"\", \"date\": \"12-05-2026\", \"total\": 0, \"currency\": \"\", \"category\": \"Shopping\", \"items\": [{\"description\": \"Socks\", \"amount\": 7.5}, {\"description\": \"\", \"amount\": 1}]}\n```",
+			want: ExtractedTransaction{
+				Type: model.TypeExpense, Description: "OtherExpenses", Amount: model.NewMoney(7.5), Category: "OtherExpenses",
+				Date:  today,
+				Items: []ReceiptItem{{Description: "Socks", Amount: model.NewMoney(7.5)}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A local stand-in of a vision model
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": tt.answer}}},
+				})
+			}))
+			defer srv.Close()
+
+			llm := testLLM(&Fake{})
+			llm.Vision = &OpenAI{BaseURL: srv.URL, Model: "gpt-4o-mini"}
+
+			got, err := llm.ExtractReceipt(context.Background(), []byte("jpeg"), "image/jpeg", categories, today)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractReceipt() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := testLLM(&Fake{}).ExtractReceipt(context.Background(), nil, "image/jpeg", categories, today); !errors.Is(err, ErrNoVision) {
+		t.Errorf("expected ErrNoVision without a vision provider, got %v", err)
+	}
+}
+
+func TestExtractQuery(t *testing.T) {
+	categories := model.DefaultCategories(1)
+	today := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	hundred := model.NewMoney(100)
+
+	llm := testLLM(&Fake{Replies: map[string]string{
+		"how much did I spend on eating out since June?": `{"type": "Expense", "category": "eatingout", "description": "", "date_from": "01-06-2025", "date_to": "", "amount_min": null, "amount_max": null, "tags": [], "exclude_tags": [], "group_by": "", "aggregate": "sum"}`,
+		"how many big groceries by month?":               `{"type": "Expense", "category": "Grocery", "amount_min": 100, "group_by": "Month", "aggregate": "count"}`,
+		"show me everything":                             `{"aggregate": "SELECT * FROM transactions"}`,
+		"when?":                                          `{"date_from": "yesterday"}`,
+		"what?":                                          `not json`,
+	}})
+
+	tests := []struct {
+		question string
+		want     model.QuerySpec
+		wantErr  bool
+	}{
+		{
+			question: "how much did I spend on eating out since June?",
+			want:     model.QuerySpec{Type: model.TypeExpense, Category: "EatingOut", DateFrom: &june, Tags: []string{}, ExcludeTags: []string{}, Aggregate: model.AggregateSum},
+		},
+		{
+			question: "how many big groceries by month?",
+			want:     model.QuerySpec{Type: model.TypeExpense, Category: "Grocery", AmountMin: &hundred, Tags: []string{}, ExcludeTags: []string{}, GroupBy: model.GroupByMonth, Aggregate: model.AggregateCount},
+		},
+		{question: "show me everything", wantErr: true},
+		{question: "when?", wantErr: true},
+		{question: "what?", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.question, func(t *testing.T) {
+			got, err := llm.ExtractQuery(context.Background(), tt.question, categories, today)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrInvalidQuery) {
+					t.Fatalf("ExtractQuery() = %+v, %v, want ErrInvalidQuery", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswerQuery(t *testing.T) {
+	question := "how much on eating out since June?"
+	llm := testLLM(&Fake{Replies: map[string]string{question: " You spent € 42.00 on EatingOut since June. \n"}})
+
+	got, err := llm.AnswerQuery(context.Background(), question, "Total of the expenses in EatingOut, from 01-06-2025\n€ 42.00 over 3 transactions")
+	if err != nil || got != "You spent € 42.00 on EatingOut since June." {
+		t.Fatalf("AnswerQuery() = %q, %v", got, err)
+	}
+
+	if _, err := testLLM(&Fake{Replies: map[string]string{question: " "}}).AnswerQuery(context.Background(), question, ""); err == nil {
+		t.Error("expected an error for an empty answer")
+	}
+}
@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Fake is a deterministic provider that never touches the network, to run
+// the bot offline and in tests. It answers the transaction and intent
+// prompts with the local parsing, unless a canned reply is set for the
+// user input.
+type Fake struct {
+	// Replies are the answers by user input, used as they are
+	Replies map[string]string
+	// Err is returned by every call when set
+	Err error
+
+	mu    sync.Mutex
+	calls int
+}
+
+// Complete implements Provider
+func (f *Fake) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	userText := promptUserText(prompt)
+	if reply, ok := f.Replies[userText]; ok {
+		return reply, nil
+	}
+
+	var answer any
+	switch {
+	case strings.HasPrefix(prompt, "You are an intent classifier"):
+		answer = classifyIntentText(userText)
+	case strings.HasPrefix(prompt, "You are a query planner"):
+		// Every question is answered as the total of the expenses
+		answer = map[string]any{}
+	case strings.HasPrefix(prompt, "You are the assistant"):
+		return "Here is what I found.", nil
+	default:
+		answer = map[string]any{"transactions": parseTransactionsText(userText, promptCategories(prompt))}
+	}
+
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Calls returns the number of calls received
+func (f *Fake) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls
+}
+
+// promptUserText returns the user input, which always ends the prompts
+func promptUserText(prompt string) string {
+	i := strings.LastIndex(prompt, "User input:\n")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(prompt[i+len("User input:\n"):], "\n")
+}
+
+// promptSection returns the text on the lines following the header, up to
+// the next empty line
+func promptSection(prompt, header string) string {
+	_, section, ok := strings.Cut(prompt, header+"\n")
+	if !ok {
+		return ""
+	}
+	section, _, _ = strings.Cut(section, "\n\n")
+	return strings.TrimSuffix(section, "\n")
+}
+
+// promptCategories returns the category names listed by the transaction
+// prompts, see GenerateTransactionPrompt
+func promptCategories(prompt string) []string {
+	var names []string
+	for _, quoted := range strings.Split(promptSection(prompt, "Available categories (use ONLY these):"), ", ") {
+		if name, err := strconv.Unquote(quoted); err == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
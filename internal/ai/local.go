@@ -0,0 +1,223 @@
+package ai
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cashout/internal/model"
+	"cashout/internal/utils"
+)
+
+// The deterministic parsing below is used when the model cannot be reached,
+// and by the fake provider. It only understands plain messages like
+// "coffee 3.50", "taxi 35 usd 23-04, lunch 12 yesterday" or "month recap".
+
+// localConfidence is the confidence of the intents classified locally
+const localConfidence = 0.6
+
+// transactionData is the transaction as answered by the model
+type transactionData struct {
+	Category    string      `json:"category"`
+	Amount      json.Number `json:"amount"`
+	Currency    string      `json:"currency"`
+	Description string      `json:"description"`
+	Date        string      `json:"date"`
+	Tags        []string    `json:"tags"`
+}
+
+// Relative dates understood locally, see answerDate
+const (
+	dateToday     = "today"
+	dateYesterday = "yesterday"
+)
+
+// separators split the transactions listed in a message, a comma between
+// digits being a decimal one
+var separators = regexp.MustCompile(`,\s+|;|\n`)
+
+// currencyWords are the currency codes, symbols and names recognized locally
+var currencyWords = map[string]model.CurrencyType{
+	"eur": model.CurrencyEUR, "€": model.CurrencyEUR, "euro": model.CurrencyEUR, "euros": model.CurrencyEUR,
+	"usd": model.CurrencyUSD, "$": model.CurrencyUSD, "dollar": model.CurrencyUSD, "dollars": model.CurrencyUSD, "bucks": model.CurrencyUSD,
+	"gbp": model.CurrencyGBP, "£": model.CurrencyGBP, "pound": model.CurrencyGBP, "pounds": model.CurrencyGBP, "quid": model.CurrencyGBP,
+	"jpy": model.CurrencyJPY, "¥": model.CurrencyJPY, "yen": model.CurrencyJPY,
+	"chf": model.CurrencyCHF, "franc": model.CurrencyCHF, "francs": model.CurrencyCHF,
+}
+
+// parseTransactionsText extracts the transactions listed in the user text,
+// one for each part with an amount: the parts without one, like "grocery" in
+// "bread 5, grocery", belong to the transaction next to them
+func parseTransactionsText(userText string, categoryNames []string) []transactionData {
+	hasAmount := func(part string) bool {
+		return parseTransactionText(part, categoryNames).Amount != "0"
+	}
+
+	var parts []string
+	for _, part := range separators.Split(userText, -1) {
+		switch {
+		case strings.TrimSpace(part) == "":
+		case len(parts) == 0:
+			parts = append(parts, part)
+		case !hasAmount(part) || !hasAmount(parts[len(parts)-1]):
+			parts[len(parts)-1] += " " + part
+		default:
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		parts = []string{userText}
+	}
+
+	transactions := make([]transactionData, len(parts))
+	for i, part := range parts {
+		transactions[i] = parseTransactionText(part, categoryNames)
+	}
+	return transactions
+}
+
+// parseTransactionText extracts a transaction from the user text: the first
+// number is the amount, a date-like token the date, a token matching one of
+// the category names the category, and the remaining words the description
+func parseTransactionText(userText string, categoryNames []string) transactionData {
+	var data transactionData
+	var description []string
+
+	data.Tags = model.ParseHashtags(userText)
+	for _, token := range strings.Fields(userText) {
+		if strings.HasPrefix(token, "#") {
+			continue
+		}
+		word := strings.ToLower(strings.Trim(token, ".,;:!?"))
+
+		if word == dateToday || word == dateYesterday {
+			data.Date = word
+			continue
+		}
+
+		if currency, ok := currencyWords[word]; ok {
+			if data.Currency == "" {
+				data.Currency = string(currency)
+			}
+			continue
+		}
+
+		if data.Amount == "" {
+			number, currency := splitCurrencySymbol(token)
+			if amount, err := model.ParseMoney(number); err == nil {
+				data.Amount = json.Number(amount.String())
+				if currency != "" && data.Currency == "" {
+					data.Currency = string(currency)
+				}
+				continue
+			}
+		}
+		if data.Date == "" {
+			if _, err := utils.ParseDate(word); err == nil {
+				data.Date = word
+				continue
+			}
+		}
+
+		if data.Category == "" {
+			if name, ok := matchCategory(word, categoryNames); ok {
+				data.Category = name
+				continue
+			}
+		}
+
+		description = append(description, token)
+	}
+
+	data.Description = capitalize(strings.Join(description, " "))
+	if data.Amount == "" {
+		data.Amount = "0"
+	}
+	return data
+}
+
+// splitCurrencySymbol splits a leading or trailing currency symbol from an
+// amount, e.g. "5€" or "$12"
+func splitCurrencySymbol(token string) (string, model.CurrencyType) {
+	for _, symbol := range []string{"€", "$", "£", "¥"} {
+		if number, ok := strings.CutPrefix(token, symbol); ok {
+			return number, currencyWords[symbol]
+		}
+		if number, ok := strings.CutSuffix(token, symbol); ok {
+			return number, currencyWords[symbol]
+		}
+	}
+	return token, ""
+}
+
+// matchCategory returns the category name matching the word, ignoring case
+// and spaces
+func matchCategory(word string, categoryNames []string) (string, bool) {
+	for _, name := range categoryNames {
+		if strings.ToLower(strings.ReplaceAll(name, " ", "")) == word {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// capitalize upper cases the first letter of the text
+func capitalize(text string) string {
+	r, size := utf8.DecodeRuneInString(text)
+	if r == utf8.RuneError {
+		return text
+	}
+	return string(unicode.ToUpper(r)) + text[size:]
+}
+
+// intentKeywords are checked in order, the first intent with a keyword in
+// the user text wins
+var intentKeywords = []struct {
+	intent   Intent
+	keywords []string
+}{
+	{IntentExport, []string{"export", "download", "csv", "backup"}},
+	{IntentClone, []string{"clone", "duplicate", "repeat", "copy", "same again", "re-enter"}},
+	{IntentDelete, []string{"delete", "remove", "cancel", "undo"}},
+	{IntentEdit, []string{"edit", "modify", "change", "update", "fix", "correct"}},
+	{IntentQuery, []string{"since", "biggest", "largest", "smallest", "average", "how many"}},
+	{IntentWeekRecap, []string{"week", "weekly"}},
+	{IntentYearRecap, []string{"year", "yearly", "annual"}},
+	{IntentMonthRecap, []string{"recap", "summary", "overview", "total", "how much", "month", "monthly"}},
+	{IntentSearch, []string{"search", "find", "look for", "where is", "show me"}},
+	{IntentList, []string{"list", "show all", "view", "display", "transactions", "history"}},
+	{IntentAddIncome, []string{"salary", "wage", "income", "earned", "received", "got paid", "paycheck", "bonus", "refund", "reimbursement"}},
+	{IntentAddExpense, []string{"bought", "spent", "paid", "cost", "purchase", "expense"}},
+}
+
+// nonWord splits the user text into words
+var nonWord = regexp.MustCompile(`[^\p{L}\p{N}-]+`)
+
+// classifyIntentText classifies the user text with the same rules given to
+// the model in LLMIntentClassificationPromptTemplate
+func classifyIntentText(userText string) ClassifiedIntent {
+	// A question with numbers is about a specific period or amount
+	if utils.IsAQuestionPrompt(userText) && strings.ContainsAny(userText, "0123456789") {
+		return ClassifiedIntent{Intent: IntentQuery, Confidence: localConfidence}
+	}
+	if strings.ContainsAny(userText, "0123456789") {
+		if utils.IsAnIncomeTransactionPrompt(userText) {
+			return ClassifiedIntent{Intent: IntentAddIncome, Confidence: localConfidence}
+		}
+		return ClassifiedIntent{Intent: IntentAddExpense, Confidence: localConfidence}
+	}
+
+	// Words are padded with spaces so that the keywords only match whole words
+	text := " " + strings.Join(nonWord.Split(strings.ToLower(userText), -1), " ") + " "
+	for _, ik := range intentKeywords {
+		for _, keyword := range ik.keywords {
+			if strings.Contains(text, " "+keyword+" ") {
+				return ClassifiedIntent{Intent: ik.intent, Confidence: localConfidence}
+			}
+		}
+	}
+
+	return ClassifiedIntent{Intent: IntentUnknown, Confidence: 0}
+}
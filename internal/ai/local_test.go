@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTransactionText(t *testing.T) {
+	names := []string{"Grocery", "Eating out", "Transport", "OtherExpenses"}
+
+	tests := []struct {
+		input string
+		want  transactionData
+	}{
+		{input: "coffee 3.50", want: transactionData{Amount: "3.50", Description: "Coffee"}},
+		{input: "bread 5,20 euro grocery", want: transactionData{Category: "Grocery", Amount: "5.20", Currency: "EUR", Description: "Bread"}},
+		{input: "taxi $35 23-04 #work", want: transactionData{Amount: "35.00", Currency: "USD", Date: "23-04", Description: "Taxi", Tags: []string{"work"}}},
+		{input: "train 4.20 yesterday", want: transactionData{Amount: "4.20", Date: "yesterday", Description: "Train"}},
+		{input: "ramen 1800 yen eatingout", want: transactionData{Category: "Eating out", Amount: "1800.00", Currency: "JPY", Description: "Ramen"}},
+		{input: "transport", want: transactionData{Category: "Transport", Amount: "0"}},
+		{input: "12.05 lunch 4", want: transactionData{Amount: "12.05", Description: "Lunch 4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := parseTransactionText(tt.input, names); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTransactionText(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTransactionsText(t *testing.T) {
+	names := []string{"Grocery", "Transport"}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "coffee 2.5, lunch 12 #work; train 4,20 yesterday", want: []string{"Coffee 2.50", "Lunch 12.00 #work", "Train 4.20 yesterday"}},
+		{input: "bread 5, grocery", want: []string{"Bread 5.00 Grocery"}},
+		{input: "grocery, bread 5\nmilk 1", want: []string{"Bread 5.00 Grocery", "Milk 1.00"}},
+		{input: "coffee", want: []string{"Coffee 0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var got []string
+			for _, data := range parseTransactionsText(tt.input, names) {
+				line := data.Description + " " + data.Amount.String()
+				if data.Category != "" {
+					line += " " + data.Category
+				}
+				for _, tag := range data.Tags {
+					line += " #" + tag
+				}
+				if data.Date != "" {
+					line += " " + data.Date
+				}
+				got = append(got, line)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTransactionsText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyIntentText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Intent
+	}{
+		{input: "coffee 3", want: IntentAddExpense},
+		{input: "salary 3000", want: IntentAddIncome},
+		{input: "export my transactions", want: IntentExport},
+		{input: "same again", want: IntentClone},
+		{input: "remove the last one", want: IntentDelete},
+		{input: "Fix a transaction", want: IntentEdit},
+		{input: "how much this week?", want: IntentWeekRecap},
+		{input: "year summary", want: IntentYearRecap},
+		{input: "recap", want: IntentMonthRecap},
+		{input: "show me the pizza", want: IntentSearch},
+		{input: "transactions", want: IntentList},
+		{input: "got paid", want: IntentAddIncome},
+		{input: "how much did I spend in 2025?", want: IntentQuery},
+		{input: "biggest expense last month", want: IntentQuery},
+		{input: "how many coffees since june", want: IntentQuery},
+		{input: "hello there", want: IntentUnknown},
+		{input: "editorial", want: IntentUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := classifyIntentText(tt.input); got.Intent != tt.want {
+				t.Errorf("classifyIntentText(%q) = %s, want %s", tt.input, got.Intent, tt.want)
+			}
+		})
+	}
+}
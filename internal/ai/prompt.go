@@ -3,41 +3,65 @@ package ai
 
 import (
 	"bytes"
+	"strconv"
+	"strings"
 	"text/template"
+	"time"
+
+	"cashout/internal/model"
 )
 
 // LLMExpensePromptTemplate is the LLM prompt template for expense transactions
 const LLMExpensePromptTemplate = `You are a financial transaction parser. Your task is to analyze the input text and extract the following information:
 - The category of the transaction
 - The amount spent or received
+- The currency of the amount, if any
 - A brief description of the transaction
 
-Format the result as a JSON object with the following structure:
-{ "category": "Category", "amount": 12.34, "description": "Description" }
+The text usually holds a single transaction, but it can list several of them (e.g. "coffee 2.5, lunch 12, train 4.20 yesterday").
+Format the result as a JSON object with one item for each transaction, in the order of the text, with the following structure:
+{ "transactions": [{ "category": "Category", "amount": 12.34, "currency": "EUR", "description": "Description", "date": "", "tags": [] }] }
 
 Available categories (use ONLY these):
-"Car", "Clothes", "Grocery", "House", "Bills", "Entertainment", "Sport", "EatingOut", "Transport", "Learning", "Toiletry", "Health", "Tech", "Gifts", "Travel", "Pets", "OtherExpenses"
+{{.Categories}}
 
 Follow these rules:
 1. For category selection:
    - First try to find the category directly mentioned in the text (accounting for typos/synonyms)
    - If no category is directly mentioned, infer it from the description
-   - If category cannot be determined, use "OtherExpenses"
+   - If category cannot be determined, use "{{.Fallback}}"
 2. For description:
    - Use the main item mentioned in the text
    - Capitalize the first letter of the description
    - If no item is mentioned, use text of the category
+   - Leave out the hashtags (e.g. "#work"), they are tags handled separately, but they can still hint the category
 3. For amount:
    - Convert any amount to standard decimal notation with a period (not comma) as decimal separator
    - Return as a number (not a string) with at most 2 decimal places
    - If no amount is mentioned, use 0
+4. For currency:
+   - Use ONLY one of these ISO codes: "EUR", "USD", "GBP", "JPY", "CHF"
+   - Recognize symbols, names and slang in any language (e.g. "$", "dollars", "bucks" → "USD", "£", "quid", "pounds" → "GBP", "¥", "yen" → "JPY", "francs" → "CHF", "€", "euro" → "EUR")
+   - If no currency is mentioned, or it is not one of the above, use ""
+5. For date:
+   - Today is {{.Today}}, use it to resolve the relative dates (e.g. "yesterday", "last friday")
+   - Return the date as "DD-MM-YYYY" if one is mentioned for the transaction, otherwise use ""
+6. For tags:
+   - List the hashtags written next to the transaction, without the "#" (e.g. "#work" → "work"), otherwise use []
+7. For several transactions:
+   - The category, currency, date and hashtags mentioned for one transaction only apply to it
 
-Examples:
-- "bread 5 euro an 20, grocery" → { "category": "Grocery", "amount": 5.2, "description": "Bread" }
-- "pam 4.31 grocertw" → { "category": "Grocery", "amount": 4.31, "description": "Pam" }
-- "car 25,30" → { "category": "Car", "amount": 25.3, "description": "Car" }
-- "34 usd 23-04" → { "category": "OtherExpenses", "amount": 34, "description": "OtherExpenses" }
-- "Great sea food 12 euro e 25" → { "category": "EatingOut", "amount": 12.25, "description": "Great see food" }
+Examples of single transactions, each answered as the only item of "transactions" (the categories in them may not be available, always pick from the list above):
+- "bread 5 euro an 20, grocery" → { "category": "Grocery", "amount": 5.2, "currency": "EUR", "description": "Bread" }
+- "pam 4.31 grocertw" → { "category": "Grocery", "amount": 4.31, "currency": "", "description": "Pam" }
+- "car 25,30" → { "category": "Car", "amount": 25.3, "currency": "", "description": "Car" }
+- "34 usd 23-04" → { "category": "OtherExpenses", "amount": 34, "currency": "USD", "description": "OtherExpenses" }
+- "Great sea food 12 euro e 25" → { "category": "EatingOut", "amount": 12.25, "currency": "EUR", "description": "Great see food" }
+- "ramen in tokyo 1800 yen" → { "category": "EatingOut", "amount": 1800, "currency": "JPY", "description": "Ramen in tokyo" }
+- "taxi to the airport 35 #work #reimbursable" → { "category": "Transport", "amount": 35, "currency": "", "description": "Taxi to the airport", "tags": ["work", "reimbursable"] }
+
+Example of several transactions, with today being 10-05-2026:
+- "coffee 2.5, lunch 12 #work, train 4.20 yesterday" → { "transactions": [{ "category": "EatingOut", "amount": 2.5, "currency": "", "description": "Coffee", "date": "", "tags": [] }, { "category": "EatingOut", "amount": 12, "currency": "", "description": "Lunch", "date": "", "tags": ["work"] }, { "category": "Transport", "amount": 4.2, "currency": "", "description": "Train", "date": "09-05-2026", "tags": [] }] }
 
 IMPORTANT: Respond with ONLY the JSON object but without markdown syntax. Your answer is plaintext being JSON to be parsed as it is, don't include the triple backticks syntax or anything similar.
 
@@ -49,33 +73,51 @@ User input:
 const LLMIncomePromptTemplate = `You are a financial transaction parser. Your task is to analyze the input text and extract the following information:
 - The category of the transaction
 - The amount spent or received
+- The currency of the amount, if any
 - A brief description of the transaction
 
-Format the result as a JSON object with the following structure:
-{ "category": "Category", "amount": 12.34, "description": "Description" }
+The text usually holds a single transaction, but it can list several of them (e.g. "coffee 2.5, lunch 12, train 4.20 yesterday").
+Format the result as a JSON object with one item for each transaction, in the order of the text, with the following structure:
+{ "transactions": [{ "category": "Category", "amount": 12.34, "currency": "EUR", "description": "Description", "date": "", "tags": [] }] }
 
 Available categories (use ONLY these):
-"Salary", "OtherIncomes"
+{{.Categories}}
 
 Follow these rules:
 1. For category selection:
    - First try to find the category directly mentioned in the text (accounting for typos/synonyms)
    - If no category is directly mentioned, infer it from the description and prefer "Salary" only when the user use it or with a synonym in any language
-   - If category cannot be determined, use "OtherIncomes", for example for "ticket restaurants", "refund amazon", etc.
+   - If category cannot be determined, use "{{.Fallback}}", for example for "ticket restaurants", "refund amazon", etc.
 2. For description:
    - Use the main item mentioned in the text
    - Capitalize the first letter of the description
    - If no item is mentioned, use text of the category
+   - Leave out the hashtags (e.g. "#work"), they are tags handled separately, but they can still hint the category
 3. For amount:
    - Convert any amount to standard decimal notation with a period (not comma) as decimal separator
    - Return as a number (not a string) with at most 2 decimal places
    - If no amount is mentioned, use 0
+4. For currency:
+   - Use ONLY one of these ISO codes: "EUR", "USD", "GBP", "JPY", "CHF"
+   - Recognize symbols, names and slang in any language (e.g. "$", "dollars", "bucks" → "USD", "£", "quid", "pounds" → "GBP", "¥", "yen" → "JPY", "francs" → "CHF", "€", "euro" → "EUR")
+   - If no currency is mentioned, or it is not one of the above, use ""
+5. For date:
+   - Today is {{.Today}}, use it to resolve the relative dates (e.g. "yesterday", "last friday")
+   - Return the date as "DD-MM-YYYY" if one is mentioned for the transaction, otherwise use ""
+6. For tags:
+   - List the hashtags written next to the transaction, without the "#" (e.g. "#work" → "work"), otherwise use []
+7. For several transactions:
+   - The category, currency, date and hashtags mentioned for one transaction only apply to it
 
-Examples:
-- "250k earned from job" → { "category": "Salary", "amount": 250000, "description": "From job" }
-- "salayr 340 and 34 august" → { "category": "Salary", "amount": 340.34, "description": "August" }
-- "ticket reastants 245 dollars" → { "category": "OtherIncomes", "amount": 245, "description": "Ticket restaurants" }
-- "gained income 231 and 32 euro 03-04" → { "category": "Salary", "amount": 231.32, "description": "Salary" }
+Examples of single transactions, each answered as the only item of "transactions" (the categories in them may not be available, always pick from the list above):
+- "250k earned from job" → { "category": "Salary", "amount": 250000, "currency": "", "description": "From job" }
+- "salayr 340 and 34 august" → { "category": "Salary", "amount": 340.34, "currency": "", "description": "August" }
+- "ticket reastants 245 dollars" → { "category": "OtherIncomes", "amount": 245, "currency": "USD", "description": "Ticket restaurants" }
+- "gained income 231 and 32 euro 03-04" → { "category": "Salary", "amount": 231.32, "currency": "EUR", "description": "Salary" }
+- "consulting invoice 800 #freelance" → { "category": "OtherIncomes", "amount": 800, "currency": "", "description": "Consulting invoice", "tags": ["freelance"] }
+
+Example of several transactions, with today being 10-05-2026:
+- "salary 2500, dividends 40 yesterday" → { "transactions": [{ "category": "Salary", "amount": 2500, "currency": "", "description": "Salary", "date": "", "tags": [] }, { "category": "OtherIncomes", "amount": 40, "currency": "", "description": "Dividends", "date": "09-05-2026", "tags": [] }] }
 
 IMPORTANT: Respond with ONLY the JSON object but without markdown syntax. Your answer is plaintext being JSON to be parsed as it is, don't include the triple backticks syntax or anything similar.
 
@@ -83,6 +125,99 @@ User input:
 {{.UserText}}
 `
 
+// LLMReceiptPromptTemplate is the LLM prompt template for the photos of the receipts
+const LLMReceiptPromptTemplate = `You are a receipt reader. Your task is to read the receipt in the image and extract the following information:
+- The merchant, the shop or the business that issued the receipt
+- The date of the purchase
+- The total paid
+- The currency of the total
+- The category of the expense
+- The items bought, if they can be read
+
+Format the result as a JSON object with the following structure:
+{ "merchant": "Merchant", "date": "DD-MM-YYYY", "total": 12.34, "currency": "EUR", "category": "Category", "items": [{ "description": "Item", "amount": 1.23 }] }
+
+Available categories (use ONLY these):
+{{.Categories}}
+
+Follow these rules:
+1. For merchant:
+   - Use the name of the shop as printed on the receipt, capitalizing the first letter, without the legal form (e.g. "S.p.A.", "GmbH", "Ltd")
+   - If it cannot be read, use ""
+2. For date:
+   - Today is {{.Today}}, the purchase cannot be later than today
+   - Return the date as "DD-MM-YYYY", or "" if it cannot be read
+3. For total:
+   - Use the final amount paid, after the discounts and including the taxes, not the cash given or the change
+   - Return as a number (not a string) with at most 2 decimal places and a period as decimal separator
+   - If it cannot be read, use 0
+4. For currency:
+   - Use ONLY one of these ISO codes: "EUR", "USD", "GBP", "JPY", "CHF"
+   - If the currency is not printed or it is not one of the above, use ""
+5. For category:
+   - Infer it from the merchant and the items (e.g. a supermarket → groceries, a restaurant → eating out)
+   - If category cannot be determined, use "{{.Fallback}}"
+6. For items:
+   - List the items with their final amount, in the order of the receipt, leaving out the totals, the taxes and the payments
+   - Use a short description for each item, capitalizing the first letter
+   - If the items cannot be read, use []
+
+Example:
+- A receipt of "ESSELUNGA S.P.A." of 09/05/2026 listing "PANE 1,20" and "LATTE 0,99", "TOTALE EURO 2,19" → { "merchant": "Esselunga", "date": "09-05-2026", "total": 2.19, "currency": "EUR", "category": "Grocery", "items": [{ "description": "Pane", "amount": 1.2 }, { "description": "Latte", "amount": 0.99 }] }
+
+IMPORTANT: Respond with ONLY the JSON object but without markdown syntax. Your answer is plaintext being JSON to be parsed as it is, don't include the triple backticks syntax or anything similar.
+`
+
+// LLMQueryPromptTemplate is the LLM prompt template for turning a question
+// into a query on the transactions
+const LLMQueryPromptTemplate = `You are a query planner for a financial tracking bot. Your task is to turn the user's question about their transactions into a query, that the bot runs on their data.
+
+Format the result as a JSON object with the following structure:
+{ "type": "Expense", "category": "", "description": "", "date_from": "", "date_to": "", "amount_min": null, "amount_max": null, "tags": [], "exclude_tags": [], "group_by": "", "aggregate": "sum" }
+
+Available categories (use ONLY these):
+{{.Categories}}
+
+Follow these rules:
+1. For type: "Expense" for the spending, "Income" for the earnings, "Expense" when unclear
+2. For category: one of the categories above when the question is about it (accounting for typos/synonyms), otherwise ""
+3. For description: a word the description of the transactions contains when the question is about an item or a shop (e.g. "pizza", "amazon"), otherwise ""
+4. For date_from and date_to:
+   - Today is {{.Today}}, use it to resolve the relative periods (e.g. "last month", "since June", "this year")
+   - Return the first and the last day of the period as "DD-MM-YYYY", both inclusive, or "" when open (e.g. "since June" has no date_to)
+5. For amount_min and amount_max: the bounds of the amount of the transactions as numbers (e.g. "over 100" → amount_min 100), otherwise null
+6. For tags and exclude_tags: the hashtags the transactions must have or not have, without the "#", otherwise []
+7. For group_by: "category", "tag" or "month" when the question asks a breakdown (e.g. "by category", "each month", "where does my money go"), otherwise ""
+8. For aggregate: "sum" for the totals, "count" for how many transactions, "avg" for the averages, "max" for the biggest, "min" for the smallest
+
+Examples, with today being 10-05-2026:
+- "how much did I spend on EatingOut since June?" → { "type": "Expense", "category": "EatingOut", "description": "", "date_from": "01-06-2025", "date_to": "", "amount_min": null, "amount_max": null, "tags": [], "exclude_tags": [], "group_by": "", "aggregate": "sum" }
+- "what was my biggest expense last month?" → { "type": "Expense", "category": "", "description": "", "date_from": "01-04-2026", "date_to": "30-04-2026", "amount_min": null, "amount_max": null, "tags": [], "exclude_tags": [], "group_by": "", "aggregate": "max" }
+- "how many pizzas this year?" → { "type": "Expense", "category": "", "description": "pizza", "date_from": "01-01-2026", "date_to": "10-05-2026", "amount_min": null, "amount_max": null, "tags": [], "exclude_tags": [], "group_by": "", "aggregate": "count" }
+- "income by month in 2025" → { "type": "Income", "category": "", "description": "", "date_from": "01-01-2025", "date_to": "31-12-2025", "amount_min": null, "amount_max": null, "tags": [], "exclude_tags": [], "group_by": "month", "aggregate": "sum" }
+
+IMPORTANT: Respond with ONLY the JSON object but without markdown syntax. Your answer is plaintext being JSON to be parsed as it is, don't include the triple backticks syntax or anything similar.
+
+User input:
+{{.UserText}}
+`
+
+// LLMQueryAnswerPromptTemplate is the LLM prompt template for answering a
+// question with the numbers computed by the bot
+const LLMQueryAnswerPromptTemplate = `You are the assistant of a financial tracking bot. The bot ran a query on the user's transactions to answer their question, answer it in one or two short sentences.
+
+Follow these rules:
+- Use ONLY the numbers of the query results below, never compute or guess other ones
+- Answer in the language of the question, in a friendly tone, without markdown
+- If no transaction was found, say so
+
+Query results:
+{{.Facts}}
+
+User input:
+{{.UserText}}
+`
+
 // LLMIntentClassificationPromptTemplate is the LLM prompt template for classifying user intent
 const LLMIntentClassificationPromptTemplate = `You are an intent classifier for a financial tracking bot. Your task is to analyze the user's message and determine what action they want to perform.
 
@@ -98,6 +233,7 @@ Available intents (use ONLY these exact strings):
 - "year_recap": User wants to see a yearly summary/recap
 - "export": User wants to export/download transactions (CSV, file)
 - "clone": User wants to clone/duplicate/repeat/copy an existing transaction
+- "query": User asks a specific question answered from their transactions (e.g. "how much did I spend on EatingOut since June?", "what was my biggest expense last month?")
 - "unknown": Cannot determine the intent or it doesn't match any of the above
 
 Classification rules:
@@ -111,7 +247,8 @@ Classification rules:
 8. Recap-related words: recap, summary, overview, total, how much
 9. Export-related words: export, download, CSV, file, backup
 10. Clone-related words: clone, duplicate, repeat, copy, same again, re-enter
-11. If the message is a greeting, question about the bot, or unrelated to finance, use "unknown"
+11. Questions asking for a total, an average, a count, the biggest or the smallest transactions, filtered by category, description, tag, amount or a period other than the current week, month or year, use "query"; a plain "how much this week/month/year?" stays a recap
+12. If the message is a greeting, question about the bot, or unrelated to finance, use "unknown"
 
 Format the result as a JSON object:
 { "intent": "intent_name", "confidence": 0.95 }
@@ -124,19 +261,81 @@ User input:
 {{.UserText}}
 `
 
+// PromptData holds the values the prompt templates are filled with
+type PromptData struct {
+	UserText string
+	// Categories is the quoted, comma separated list of the user's categories
+	Categories string
+	// Fallback is the category to use when none can be inferred
+	Fallback string
+	// Today is the current day of the user, as DD-MM-YYYY
+	Today string
+	// Facts are the results of a query the answer is based on
+	Facts string
+}
+
 // GeneratePrompt creates the complete prompt by filling in the template with user input
 func GeneratePrompt(userText string, promptTemplate string) (string, error) {
+	return GeneratePromptWithData(PromptData{UserText: userText}, promptTemplate)
+}
+
+// GenerateTransactionPrompt creates the prompt to extract the transactions of
+// the given type, listing the user's own active categories
+func GenerateTransactionPrompt(userText string, transactionType model.TransactionType, categories model.Categories, today time.Time) (string, error) {
+	tmpl := LLMExpensePromptTemplate
+	if transactionType == model.TypeIncome {
+		tmpl = LLMIncomePromptTemplate
+	}
+
+	fallback, _ := categories.Fallback(transactionType)
+
+	return GeneratePromptWithData(PromptData{
+		UserText:   userText,
+		Categories: quotedCategories(categories, transactionType),
+		Fallback:   string(fallback),
+		Today:      today.Format("02-01-2006"),
+	}, tmpl)
+}
+
+// GenerateReceiptPrompt creates the prompt to read a receipt, listing the
+// user's own active expense categories
+func GenerateReceiptPrompt(categories model.Categories, today time.Time) (string, error) {
+	fallback, _ := categories.Fallback(model.TypeExpense)
+
+	return GeneratePromptWithData(PromptData{
+		Categories: quotedCategories(categories, model.TypeExpense),
+		Fallback:   string(fallback),
+		Today:      today.Format("02-01-2006"),
+	}, LLMReceiptPromptTemplate)
+}
+
+// GenerateQueryPrompt creates the prompt to turn a question into a query,
+// listing all the user's own active categories
+func GenerateQueryPrompt(question string, categories model.Categories, today time.Time) (string, error) {
+	return GeneratePromptWithData(PromptData{
+		UserText:   question,
+		Categories: quotedCategories(categories, ""),
+		Today:      today.Format("02-01-2006"),
+	}, LLMQueryPromptTemplate)
+}
+
+// quotedCategories returns the quoted, comma separated list of the active
+// categories of the type, of all types when empty
+func quotedCategories(categories model.Categories, transactionType model.TransactionType) string {
+	names := categories.OfType(transactionType).Names()
+	for i, name := range names {
+		names[i] = strconv.Quote(name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// GeneratePromptWithData creates the complete prompt by filling in the template with the given data
+func GeneratePromptWithData(data PromptData, promptTemplate string) (string, error) {
 	tmpl, err := template.New("prompt").Parse(promptTemplate)
 	if err != nil {
 		return "", err
 	}
 
-	data := struct {
-		UserText string
-	}{
-		UserText: userText,
-	}
-
 	var buffer bytes.Buffer
 	if err := tmpl.Execute(&buffer, data); err != nil {
 		return "", err
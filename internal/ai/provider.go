@@ -0,0 +1,270 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Provider is a language model answering a single user prompt
+type Provider interface {
+	// Complete returns the model answer to the prompt, using at most
+	// maxTokens tokens. The context bounds the whole call.
+	Complete(ctx context.Context, prompt string, maxTokens int) (string, error)
+}
+
+// VisionProvider is a language model that can also read an image, e.g. the
+// photo of a receipt
+type VisionProvider interface {
+	Provider
+	// CompleteImage returns the model answer to the prompt about the image of
+	// the given content type, using at most maxTokens tokens
+	CompleteImage(ctx context.Context, prompt string, image []byte, contentType string, maxTokens int) (string, error)
+}
+
+// Provider kinds, see NewProvider
+const (
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+	ProviderFake   = "fake"
+)
+
+// NewProvider returns the provider of the given kind, an OpenAI compatible
+// one when empty. The base URL is the API root, e.g.
+// https://api.deepseek.com/v1 or http://localhost:11434.
+func NewProvider(kind, baseURL, apiKey, model string) (Provider, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	switch strings.ToLower(kind) {
+	case "", ProviderOpenAI:
+		return &OpenAI{BaseURL: baseURL, APIKey: apiKey, Model: model}, nil
+	case ProviderOllama:
+		return &Ollama{BaseURL: baseURL, Model: model}, nil
+	case ProviderFake:
+		return &Fake{}, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", kind)
+	}
+}
+
+// NewVisionProvider returns the provider of the given kind as NewProvider,
+// failing when it cannot read images
+func NewVisionProvider(kind, baseURL, apiKey, model string) (VisionProvider, error) {
+	provider, err := NewProvider(kind, baseURL, apiKey, model)
+	if err != nil {
+		return nil, err
+	}
+	vision, ok := provider.(VisionProvider)
+	if !ok {
+		return nil, fmt.Errorf("the LLM provider %q cannot read images", kind)
+	}
+	return vision, nil
+}
+
+// ErrUnavailable is returned when the provider cannot be reached, or is not
+// called at all while the circuit breaker is open
+var ErrUnavailable = errors.New("LLM provider unavailable")
+
+// StatusError is returned by the HTTP providers on a non 2xx answer
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("LLM provider answered %d: %s", e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the call may succeed when retried: the provider
+// is rate limiting or failing on its side
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// defaultHTTPClient is shared by the HTTP providers without a client of
+// their own, the timeouts come from the context of each call
+var defaultHTTPClient = &http.Client{}
+
+// chatMessage is a message of the chat APIs
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatPart is a part of a message with several contents, see OpenAI.CompleteImage
+type chatPart struct {
+	Type     string     `json:"type"`
+	Text     string     `json:"text,omitempty"`
+	ImageURL *chatImage `json:"image_url,omitempty"`
+}
+
+// chatImage is the image of a chat part, by URL
+type chatImage struct {
+	URL string `json:"url"`
+}
+
+// OpenAI is a provider for the OpenAI compatible chat completions APIs
+// (OpenAI, DeepSeek, ...)
+type OpenAI struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	// HTTPClient is used for the requests, a shared client when nil
+	HTTPClient *http.Client
+}
+
+// Complete implements Provider
+func (p *OpenAI) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return p.chat(ctx, []chatMessage{{Role: "user", Content: prompt}}, maxTokens)
+}
+
+// CompleteImage implements VisionProvider, the image is sent inline as a data URL
+func (p *OpenAI) CompleteImage(ctx context.Context, prompt string, image []byte, contentType string, maxTokens int) (string, error) {
+	imagePart := chatPart{Type: "image_url", ImageURL: &chatImage{
+		URL: "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(image),
+	}}
+	message := map[string]any{
+		"role":    "user",
+		"content": []chatPart{{Type: "text", Text: prompt}, imagePart},
+	}
+	return p.chat(ctx, []any{message}, maxTokens)
+}
+
+// chat sends the messages to the chat completions API and returns the answer
+func (p *OpenAI) chat(ctx context.Context, messages any, maxTokens int) (string, error) {
+	payload := map[string]any{
+		"model":      p.Model,
+		"messages":   messages,
+		"max_tokens": maxTokens,
+	}
+	headers := map[string]string{"Authorization": "Bearer " + p.APIKey}
+
+	var response struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, p.HTTPClient, p.BaseURL+"/chat/completions", headers, payload, &response); err != nil {
+		return "", err
+	}
+	if len(response.Choices) == 0 {
+		return "", errors.New("invalid response format: no choices")
+	}
+
+	return response.Choices[0].Message.Content, nil
+}
+
+// Ollama is a provider for a local Ollama server, through its chat API
+type Ollama struct {
+	BaseURL string
+	Model   string
+	// HTTPClient is used for the requests, a shared client when nil
+	HTTPClient *http.Client
+}
+
+// Complete implements Provider
+func (p *Ollama) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return p.chat(ctx, map[string]any{"role": "user", "content": prompt}, maxTokens)
+}
+
+// CompleteImage implements VisionProvider, for the models reading images
+// such as llava
+func (p *Ollama) CompleteImage(ctx context.Context, prompt string, image []byte, contentType string, maxTokens int) (string, error) {
+	return p.chat(ctx, map[string]any{
+		"role":    "user",
+		"content": prompt,
+		"images":  []string{base64.StdEncoding.EncodeToString(image)},
+	}, maxTokens)
+}
+
+// chat sends the message to the chat API and returns the answer
+func (p *Ollama) chat(ctx context.Context, message map[string]any, maxTokens int) (string, error) {
+	payload := map[string]any{
+		"model":    p.Model,
+		"messages": []any{message},
+		"stream":   false,
+		"options":  map[string]any{"num_predict": maxTokens},
+	}
+
+	var response struct {
+		Message chatMessage `json:"message"`
+	}
+	if err := postJSON(ctx, p.HTTPClient, p.BaseURL+"/api/chat", nil, payload, &response); err != nil {
+		return "", err
+	}
+
+	return response.Message.Content, nil
+}
+
+// postJSON sends the payload to the URL and decodes the JSON answer into out
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload, out any) error {
+	requestBody, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	return post(ctx, client, url, "application/json", headers, bytes.NewReader(requestBody), out)
+}
+
+// post sends the request body of the content type to the URL and decodes
+// the JSON answer into out
+func post(ctx context.Context, client *http.Client, url, contentType string, headers map[string]string, requestBody io.Reader, out any) (err error) {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response %q: %w", body, err)
+	}
+
+	return nil
+}
+
+// providerDown reports whether a call failed because the provider is down,
+// overloaded or too slow to answer: the call is worth retrying, counts
+// towards opening the circuit breaker and is answered locally
+func providerDown(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// backoff returns the wait before the given retry, doubling each time
+func backoff(base time.Duration, retry int) time.Duration {
+	return base << retry
+}
@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIComplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected request %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var payload struct {
+			Model     string        `json:"model"`
+			Messages  []chatMessage `json:"messages"`
+			MaxTokens int           `json:"max_tokens"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Model != "deepseek-chat" || payload.MaxTokens != 100 || len(payload.Messages) != 1 || payload.Messages[0].Content != "hi" {
+			t.Errorf("unexpected payload %+v", payload)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"intent\":\"list\"}"}}]}`))
+	}))
+	defer srv.Close()
+
+	provider, err := NewProvider("", srv.URL+"/v1/", "sk-test", "deepseek-chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := provider.Complete(context.Background(), "hi", 100)
+	if err != nil || got != `{"intent":"list"}` {
+		t.Fatalf("Complete() = %q, %v", got, err)
+	}
+}
+
+func TestOpenAICompleteImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Messages []struct {
+				Content []chatPart `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if len(payload.Messages) != 1 || len(payload.Messages[0].Content) != 2 {
+			t.Fatalf("unexpected payload %+v", payload)
+		}
+		parts := payload.Messages[0].Content
+		if parts[0].Type != "text" || parts[0].Text != "read it" {
+			t.Errorf("unexpected text part %+v", parts[0])
+		}
+		if parts[1].Type != "image_url" || parts[1].ImageURL == nil || parts[1].ImageURL.URL != "data:image/png;base64,iVBORw==" {
+			t.Errorf("unexpected image part %+v", parts[1])
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	provider, err := NewVisionProvider(ProviderOpenAI, srv.URL, "sk-test", "gpt-4o-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := []byte{0x89, 'P', 'N', 'G'}
+	if got, err := provider.CompleteImage(context.Background(), "read it", image, "image/png", 100); err != nil || got != "ok" {
+		t.Fatalf("CompleteImage() = %q, %v", got, err)
+	}
+
+	if _, err := NewVisionProvider(ProviderFake, "", "", ""); err == nil {
+		t.Error("expected an error for a provider that cannot read images")
+	}
+}
+
+func TestOllamaComplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Stream  bool           `json:"stream"`
+			Options map[string]int `json:"options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if r.URL.Path != "/api/chat" || payload.Stream || payload.Options["num_predict"] != 250 {
+			t.Errorf("unexpected request %s %+v", r.URL.Path, payload)
+		}
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"ok"}}`))
+	}))
+	defer srv.Close()
+
+	provider, err := NewProvider(ProviderOllama, srv.URL, "", "llama3.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := provider.Complete(context.Background(), "hi", 250); err != nil || got != "ok" {
+		t.Fatalf("Complete() = %q, %v", got, err)
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantDown bool
+	}{
+		{name: "rate limited", status: http.StatusTooManyRequests, body: "slow down", wantDown: true},
+		{name: "server error", status: http.StatusBadGateway, wantDown: true},
+		{name: "bad request", status: http.StatusBadRequest, body: "bad model"},
+		{name: "no choices", status: http.StatusOK, body: `{"choices":[]}`},
+		{name: "not json", status: http.StatusOK, body: "<html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := (&OpenAI{BaseURL: srv.URL}).Complete(context.Background(), "hi", 10)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if providerDown(err) != tt.wantDown {
+				t.Errorf("providerDown(%v) = %v, want %v", err, !tt.wantDown, tt.wantDown)
+			}
+		})
+	}
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	if _, err := (&OpenAI{BaseURL: srv.URL}).Complete(context.Background(), "hi", 10); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable when the server is unreachable, got %v", err)
+	}
+
+	if _, err := NewProvider("gemini", "", "", ""); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Transcriber turns a recorded speech into text
+type Transcriber interface {
+	// Transcribe returns the text spoken in the audio file with the given
+	// name, whose extension tells its format (e.g. "voice.ogg"). The context
+	// bounds the whole call.
+	Transcribe(ctx context.Context, audio []byte, fileName string) (string, error)
+}
+
+// NewTranscriber returns the speech to text of the given kind, an OpenAI
+// compatible one when empty. The base URL is the API root, e.g.
+// https://api.openai.com/v1.
+func NewTranscriber(kind, baseURL, apiKey, model string) (Transcriber, error) {
+	switch strings.ToLower(kind) {
+	case "", ProviderOpenAI:
+		return &OpenAITranscriber{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: apiKey, Model: model}, nil
+	case ProviderFake:
+		return &FakeTranscriber{}, nil
+	default:
+		return nil, fmt.Errorf("unknown speech to text provider %q", kind)
+	}
+}
+
+// OpenAITranscriber is a speech to text for the OpenAI compatible audio
+// transcriptions APIs (OpenAI, Groq, a local whisper server, ...)
+type OpenAITranscriber struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	// HTTPClient is used for the requests, a shared client when nil
+	HTTPClient *http.Client
+}
+
+// Transcribe implements Transcriber
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, audio []byte, fileName string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("model", t.Model); err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	if err := form.WriteField("response_format", "json"); err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	file, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	if _, err := file.Write(audio); err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + t.APIKey}
+	var response struct {
+		Text string `json:"text"`
+	}
+	if err := post(ctx, t.HTTPClient, t.BaseURL+"/audio/transcriptions", form.FormDataContentType(), headers, &body, &response); err != nil {
+		return "", err
+	}
+
+	return response.Text, nil
+}
+
+// FakeTranscriber is an offline speech to text for tests and development,
+// hearing the same text in every audio
+type FakeTranscriber struct {
+	// Text is the transcription of every audio
+	Text string
+	// Err fails every call when set
+	Err error
+
+	mu    sync.Mutex
+	calls int
+}
+
+// Transcribe implements Transcriber
+func (t *FakeTranscriber) Transcribe(ctx context.Context, audio []byte, fileName string) (string, error) {
+	t.mu.Lock()
+	t.calls++
+	t.mu.Unlock()
+
+	if t.Err != nil {
+		return "", t.Err
+	}
+	return t.Text, ctx.Err()
+}
+
+// Calls returns the number of transcriptions asked so far
+func (t *FakeTranscriber) Calls() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls
+}
@@ -0,0 +1,70 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAITranscribe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected request %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		if r.FormValue("model") != "whisper-1" || r.FormValue("response_format") != "json" {
+			t.Errorf("unexpected form %v", r.MultipartForm.Value)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = file.Close() }()
+		if audio, _ := io.ReadAll(file); header.Filename != "voice.ogg" || string(audio) != "OggS" {
+			t.Errorf("unexpected file %q with %q", header.Filename, audio)
+		}
+		_, _ = w.Write([]byte(`{"text":"spent forty euros on petrol"}`))
+	}))
+	defer srv.Close()
+
+	transcriber, err := NewTranscriber("", srv.URL+"/v1/", "sk-test", "whisper-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := transcriber.Transcribe(context.Background(), []byte("OggS"), "voice.ogg")
+	if err != nil || got != "spent forty euros on petrol" {
+		t.Fatalf("Transcribe() = %q, %v", got, err)
+	}
+
+	if _, err := NewTranscriber(ProviderOllama, "", "", ""); err == nil {
+		t.Error("expected an error for a provider that cannot transcribe")
+	}
+}
+
+func TestLLMTranscribe(t *testing.T) {
+	llm := testLLM(&Fake{})
+	if _, err := llm.Transcribe(context.Background(), nil, "voice.ogg"); !errors.Is(err, ErrNoSpeech) {
+		t.Errorf("expected ErrNoSpeech without a speech to text, got %v", err)
+	}
+
+	fake := &FakeTranscriber{Text: " coffee 3 \n"}
+	llm.Speech = fake
+	if got, err := llm.Transcribe(context.Background(), []byte("OggS"), "voice.ogg"); err != nil || got != "coffee 3" {
+		t.Errorf("Transcribe() = %q, %v", got, err)
+	}
+
+	// The temporary failures are retried
+	down := &FakeTranscriber{Err: &StatusError{StatusCode: http.StatusServiceUnavailable}}
+	llm.Speech = down
+	if _, err := llm.Transcribe(context.Background(), []byte("OggS"), "voice.ogg"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls := down.Calls(); calls != 1+llm.Retries {
+		t.Errorf("got %d calls, want 1 and %d retries", calls, llm.Retries)
+	}
+}
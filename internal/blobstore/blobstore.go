@@ -0,0 +1,109 @@
+// Package blobstore stores the content of the files attached to the
+// transactions, behind a Store so other backends can replace the local
+// filesystem.
+package blobstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultDir is the directory of the local store when none is configured
+const DefaultDir = "data/attachments"
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps blobs by key, a relative slash-separated path such as
+// "123456789/3f9a0c.pdf"
+type Store interface {
+	// Put stores the content under the key, replacing any previous one, and
+	// returns its size in bytes
+	Put(key string, content io.Reader) (int64, error)
+	// Open returns the content stored under the key or ErrNotFound
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the content stored under the key, missing keys are ignored
+	Delete(key string) error
+}
+
+// Local is a Store keeping each blob in a file under a root directory
+type Local struct {
+	root string
+}
+
+// NewLocal returns a Local store rooted at dir, DefaultDir when empty. The
+// directory is created with the first blob.
+func NewLocal(dir string) *Local {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Local{root: dir}
+}
+
+// path returns the file of the key, refusing keys escaping the root
+func (l *Local) path(key string) (string, error) {
+	p := filepath.FromSlash(key)
+	if key == "" || !filepath.IsLocal(p) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(l.root, p), nil
+}
+
+// Put writes the content to a temporary file renamed once complete, so
+// readers never see a partial blob
+func (l *Local) Put(key string, content io.Reader) (int64, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return 0, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	n, err := io.Copy(tmp, content)
+	if err != nil {
+		_ = tmp.Close()
+		return 0, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return 0, fmt.Errorf("failed to store blob: %w", err)
+	}
+	return n, nil
+}
+
+func (l *Local) Open(key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package blobstore
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	store := NewLocal(t.TempDir())
+
+	n, err := store.Put("42/receipt.pdf", strings.NewReader("%PDF-1.7"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 8 {
+		t.Fatalf("expected 8 bytes, got %d", n)
+	}
+
+	f, err := store.Open("42/receipt.pdf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil || string(content) != "%PDF-1.7" {
+		t.Fatalf("expected the stored content, got %q, %v", content, err)
+	}
+
+	if err := store.Delete("42/receipt.pdf"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Open("42/receipt.pdf"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Delete("42/receipt.pdf"); err != nil {
+		t.Fatalf("deleting a missing blob: %v", err)
+	}
+}
+
+func TestLocalInvalidKeys(t *testing.T) {
+	store := NewLocal(t.TempDir())
+
+	for _, key := range []string{"", "../escape", "42/../../escape", "/etc/passwd"} {
+		t.Run(key, func(t *testing.T) {
+			if _, err := store.Put(key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Put: expected ErrInvalidKey, got %v", err)
+			}
+			if _, err := store.Open(key); !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Open: expected ErrInvalidKey, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,552 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// ParseAccountInput parses the one-line description of a new account typed by
+// the user, e.g. "Revolut card USD 150": the kind, the currency and the
+// opening balance are optional and can be in any order, the rest is the name.
+// The currency falls back to the given one.
+func ParseAccountInput(text string, currency model.CurrencyType) model.Account {
+	account := model.Account{Kind: model.AccountOther, Currency: currency}
+
+	var name []string
+	kindSet, currencySet, balanceSet := false, false, false
+	for _, token := range strings.Fields(text) {
+		if kind, ok := model.ParseAccountKind(token); ok && !kindSet {
+			account.Kind, kindSet = kind, true
+			continue
+		}
+		if upper := strings.ToUpper(token); len(token) == 3 && model.IsValidCurrency(upper) && !currencySet {
+			account.Currency, currencySet = model.CurrencyType(upper), true
+			continue
+		}
+		if amount, err := model.ParseMoney(token); err == nil && !balanceSet {
+			account.OpeningBalance, balanceSet = amount, true
+			continue
+		}
+		name = append(name, token)
+	}
+
+	account.Name = strings.Join(name, " ")
+	if account.Name == "" && kindSet {
+		// A bare kind, e.g. "Cash", names the account
+		account.Name = string(account.Kind)
+	}
+	return account
+}
+
+// buildAccountKeyboard renders the active accounts of the user as buttons with
+// callback data <callbackPrefix>.<ID>, optionally with a "No account" button
+// (<callbackPrefix>.none).
+func (c *Client) buildAccountKeyboard(tgID int64, callbackPrefix, cancelCallback string, includeNone bool) ([][]gotgbot.InlineKeyboardButton, error) {
+	accounts, err := c.Repositories.Accounts.List(tgID)
+	if err != nil {
+		return nil, err
+	}
+	return accountButtons(accounts.Active(), callbackPrefix, cancelCallback, 0, includeNone), nil
+}
+
+// accountButtons lays out the accounts two per row, skipping the one with the excluded ID.
+func accountButtons(accounts model.Accounts, callbackPrefix, cancelCallback string, excludeID int64, includeNone bool) [][]gotgbot.InlineKeyboardButton {
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	var row []gotgbot.InlineKeyboardButton
+	for _, a := range accounts {
+		if a.ID == excludeID {
+			continue
+		}
+		row = append(row, gotgbot.InlineKeyboardButton{
+			Text:         a.Label(),
+			CallbackData: fmt.Sprintf("%s.%d", callbackPrefix, a.ID),
+		})
+		if len(row) == 2 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+
+	if includeNone {
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "No account", CallbackData: callbackPrefix + ".none"}})
+	}
+	return append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "Cancel", CallbackData: cancelCallback}})
+}
+
+// AccountsCommand handles /accounts.
+func (c *Client) AccountsCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	return c.ShowAccounts(b, ctx)
+}
+
+// ShowAccounts renders the accounts of the user with their balances and the actions to manage them.
+func (c *Client) ShowAccounts(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := c.Repositories.Accounts.List(user.TgID)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("👛 <b>Accounts</b>\n\n")
+	if len(accounts) == 0 {
+		sb.WriteString("You have no accounts yet. Add one to track where your money is and to move it between accounts.")
+	}
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for _, a := range accounts {
+		balance, err := c.Repositories.Accounts.Balance(a)
+		if err != nil {
+			return err
+		}
+
+		label := a.Label()
+		if a.Archived {
+			label = "📦 " + label
+		}
+		if user.DefaultAccountID != nil && *user.DefaultAccountID == a.ID {
+			label += " ⭐️"
+		}
+		sb.WriteString(fmt.Sprintf("%s: <b>%s %.2f</b>\n", html.EscapeString(label), a.Currency.Symbol(), balance))
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: label, CallbackData: fmt.Sprintf("accounts.show.%d", a.ID)}})
+	}
+
+	actions := []gotgbot.InlineKeyboardButton{{Text: "➕ New Account", CallbackData: "accounts.new"}}
+	if len(accounts.Active()) > 1 {
+		actions = append(actions, gotgbot.InlineKeyboardButton{Text: "🔁 Transfer", CallbackData: "accounts.transfer"})
+	}
+	keyboard = append(keyboard, actions, []gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}})
+
+	return SendMessage(ctx, b, sb.String(), keyboard)
+}
+
+// AccountDetails handles accounts.show.<ID>.
+func (c *Client) AccountDetails(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	account, err := c.getCallbackAccount(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return c.showAccountDetails(b, ctx, user, account)
+}
+
+func (c *Client) showAccountDetails(b *gotgbot.Bot, ctx *ext.Context, user model.User, account model.Account) error {
+	balance, err := c.Repositories.Accounts.Balance(account)
+	if err != nil {
+		return err
+	}
+
+	isDefault := user.DefaultAccountID != nil && *user.DefaultAccountID == account.ID
+	id := strconv.FormatInt(account.ID, 10)
+
+	archiveText := "📦 Archive"
+	status := "Active"
+	if account.Archived {
+		archiveText = "♻️ Restore"
+		status = "Archived"
+	}
+
+	var row []gotgbot.InlineKeyboardButton
+	switch {
+	case isDefault:
+		status += ", default for new transactions"
+		row = append(row, gotgbot.InlineKeyboardButton{Text: "Unset default", CallbackData: "accounts.default." + id})
+	case !account.Archived:
+		row = append(row, gotgbot.InlineKeyboardButton{Text: "⭐️ Set default", CallbackData: "accounts.default." + id})
+	}
+	row = append(row,
+		gotgbot.InlineKeyboardButton{Text: archiveText, CallbackData: "accounts.archive." + id},
+		gotgbot.InlineKeyboardButton{Text: "🗑 Delete", CallbackData: "accounts.delete." + id},
+	)
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		row,
+		{{Text: "⬅️ Back", CallbackData: "accounts.list"}},
+	}
+
+	text := fmt.Sprintf(
+		"%s\n\nKind: %s\nCurrency: %s\nOpening balance: %s %.2f\nBalance: <b>%s %.2f</b>\nStatus: %s",
+		html.EscapeString(account.Label()), account.Kind, account.Currency,
+		account.Currency.Symbol(), account.OpeningBalance, account.Currency.Symbol(), balance, status,
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// AccountNewPrompt handles accounts.new, waiting for the details of the new account.
+func (c *Client) AccountNewPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	user.Session.State = model.StateAccountNewWaitDetails
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "accounts.cancel"}},
+	}
+	text := fmt.Sprintf(
+		"Enter the name of the new account, optionally followed by its kind (%s), currency and opening balance, e.g. <code>Revolut card USD 150</code>.",
+		strings.Join(model.GetAccountKinds(), ", "),
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// AccountFromMessage receives the details typed after AccountNewPrompt.
+func (c *Client) AccountFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	currency := user.BaseCurrency
+	if currency == "" {
+		currency = model.CurrencyEUR
+	}
+	account := ParseAccountInput(text, currency)
+	account.TgID = user.TgID
+
+	err := c.Repositories.Accounts.Create(&account)
+	if errors.Is(err, model.ErrInvalidAccount) || errors.Is(err, model.ErrAccountExists) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s, please try again.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save account: %w", err)
+	}
+
+	return c.showAccountDetails(b, ctx, user, account)
+}
+
+// AccountDefaultToggle handles accounts.default.<ID>, setting or unsetting the
+// account new incomes and expenses are assigned to.
+func (c *Client) AccountDefaultToggle(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	account, err := c.getCallbackAccount(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	var id *int64
+	if user.DefaultAccountID == nil || *user.DefaultAccountID != account.ID {
+		id = &account.ID
+	}
+	if err := c.Repositories.Accounts.SetDefault(user.TgID, id); err != nil {
+		return fmt.Errorf("failed to set default account: %w", err)
+	}
+	user.DefaultAccountID = id
+
+	return c.showAccountDetails(b, ctx, user, account)
+}
+
+// AccountArchiveToggle handles accounts.archive.<ID>, archiving or restoring the account.
+func (c *Client) AccountArchiveToggle(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	account, err := c.getCallbackAccount(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	account.Archived = !account.Archived
+	if err := c.Repositories.Accounts.Update(&account); err != nil {
+		return fmt.Errorf("failed to update account: %w", err)
+	}
+
+	// Archiving the default account unsets it.
+	user, err = c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	return c.showAccountDetails(b, ctx, user, account)
+}
+
+// AccountDelete handles accounts.delete.<ID>, only unused accounts can be deleted.
+func (c *Client) AccountDelete(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	account, err := c.getCallbackAccount(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	err = c.Repositories.Accounts.Delete(user.TgID, account.ID)
+	if errors.Is(err, model.ErrAccountInUse) {
+		keyboard := [][]gotgbot.InlineKeyboardButton{
+			{{Text: "📦 Archive", CallbackData: fmt.Sprintf("accounts.archive.%d", account.ID)}},
+			{{Text: "⬅️ Back", CallbackData: "accounts.list"}},
+		}
+		text := fmt.Sprintf("%s is used by some of your transactions and cannot be deleted, you can archive it instead.", html.EscapeString(account.Label()))
+		return SendMessage(ctx, b, text, keyboard)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	return c.ShowAccounts(b, ctx)
+}
+
+// AccountTransferStart handles accounts.transfer, asking for the source account.
+func (c *Client) AccountTransferStart(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	keyboard, err := c.buildAccountKeyboard(user.TgID, "accounts.transferfrom", "accounts.cancel", false)
+	if err != nil {
+		return err
+	}
+	return SendMessage(ctx, b, "🔁 <b>New transfer</b>\n\nChoose the account to move the money from:", keyboard)
+}
+
+// AccountTransferFromSelected handles accounts.transferfrom.<ID>, asking for the destination account.
+func (c *Client) AccountTransferFromSelected(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	from, err := c.getCallbackAccount(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := c.Repositories.Accounts.List(user.TgID)
+	if err != nil {
+		return err
+	}
+
+	keyboard := accountButtons(accounts.Active(), fmt.Sprintf("accounts.transferto.%d", from.ID), "accounts.cancel", from.ID, false)
+	text := fmt.Sprintf("🔁 <b>New transfer</b>\n\nFrom: %s\n\nChoose the account to move the money to:", html.EscapeString(from.Label()))
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// AccountTransferToSelected handles accounts.transferto.<FROM ID>.<TO ID>, waiting for the amount.
+func (c *Client) AccountTransferToSelected(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid callback data: %s", ctx.CallbackQuery.Data)
+	}
+	from, to, err := c.getTransferAccounts(user, parts[2]+"."+parts[3])
+	if err != nil {
+		return err
+	}
+
+	user.Session.State = model.StateAccountTransferWaitAmount
+	user.Session.Body = fmt.Sprintf("%d.%d", from.ID, to.ID)
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "accounts.cancel"}},
+	}
+	text := fmt.Sprintf(
+		"🔁 <b>New transfer</b>\n\nFrom: %s\nTo: %s\n\nEnter the amount in %s, optionally followed by a description (e.g. <code>200 monthly savings</code>):",
+		html.EscapeString(from.Label()), html.EscapeString(to.Label()), from.Currency,
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// AccountTransferFromMessage receives the amount typed after AccountTransferToSelected and saves the transfer.
+func (c *Client) AccountTransferFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	from, to, err := c.getTransferAccounts(user, user.Session.Body)
+	if err != nil {
+		return err
+	}
+
+	amountStr, description, _ := strings.Cut(strings.TrimSpace(text), " ")
+	amount, err := model.ParseMoney(amountStr)
+	if err != nil || amount <= 0 {
+		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Invalid amount. Please enter a number greater than zero.", nil)
+		return err
+	}
+
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	transfer, err := model.NewTransfer(from, to, amount, user.Today(), description)
+	if err == nil {
+		err = c.Repositories.Transactions.As(botActor(user)).Add(&transfer)
+	}
+	if errors.Is(err, model.ErrInvalidTransfer) || errors.Is(err, model.ErrAccountArchived) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save transfer: %w", err)
+	}
+
+	reply := fmt.Sprintf(
+		"%s <b>Transfer saved!</b>\n\n%s %.2f from %s to %s, %s on %s",
+		model.TransferEmoji, from.Currency.Symbol(), amount, html.EscapeString(from.Label()), html.EscapeString(to.Label()),
+		html.EscapeString(transfer.Description), transfer.Date.Format("02-01-2006"),
+	)
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "👛 Accounts", CallbackData: "accounts.list"}},
+		{{Text: "🏠 Home", CallbackData: "transactions.home"}},
+	}
+	return SendMessage(ctx, b, reply, keyboard)
+}
+
+// SetNewTransactionAccount handles transactions.setaccount.<ID|none> during the
+// just-inserted transaction edit flow.
+func (c *Client) SetNewTransactionAccount(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	transactionID, err := strconv.ParseInt(user.Session.Body, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse transaction ID from session: %w", err)
+	}
+
+	transaction, err := c.Repositories.Transactions.GetByID(transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction from database: %w", err)
+	}
+
+	var accountID *int64
+	accountLabel := "none"
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	if last := parts[len(parts)-1]; last != "none" {
+		account, err := c.getCallbackAccount(ctx, user)
+		if err != nil {
+			return err
+		}
+		accountID, accountLabel = &account.ID, account.Label()
+	}
+
+	if err := c.Repositories.Transactions.As(botActor(user)).SetAccount(&transaction, accountID); err != nil {
+		return fmt.Errorf("failed to set transaction account: %w", err)
+	}
+
+	user.Session.State = model.StateEditingNewTransaction
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to set user data: %w", err)
+	}
+
+	emoji := "💰"
+	if transaction.Type == model.TypeExpense {
+		emoji = "💸"
+	}
+
+	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (%s), %s on %s", emoji, transaction.Category, FormatTransactionAmount(transaction), transaction.Description, transaction.Date.Format("02-01-2006")) + FormatTransactionTags(transaction)
+	m += fmt.Sprintf("\nAccount: %s", html.EscapeString(accountLabel))
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Edit description", CallbackData: "transactions.edit.description"}},
+		{{Text: "Edit category", CallbackData: "transactions.edit.category"}},
+		{{Text: "Edit date", CallbackData: "transactions.edit.date"}},
+		{{Text: "Edit amount", CallbackData: "transactions.edit.amount"}},
+		{{Text: "Edit account", CallbackData: "transactions.edit.account"}},
+		{
+			{Text: "Delete", CallbackData: fmt.Sprintf("transactions.delete.%d", transaction.ID)},
+			{Text: "Home", CallbackData: "transactions.home"},
+		},
+	}
+	return SendMessage(ctx, b, m, keyboard)
+}
+
+// AccountsCancel resets state and returns to home.
+func (c *Client) AccountsCancel(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+	return c.SendHomeKeyboard(b, ctx, "Operation cancelled.")
+}
+
+// getCallbackAccount returns the account whose ID is the last part of the callback data.
+func (c *Client) getCallbackAccount(ctx *ext.Context, user model.User) (model.Account, error) {
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return model.Account{}, fmt.Errorf("invalid account ID: %w", err)
+	}
+
+	account, err := c.Repositories.Accounts.Get(user.TgID, id)
+	if err != nil {
+		return model.Account{}, fmt.Errorf("failed to get account: %w", err)
+	}
+	return account, nil
+}
+
+// getTransferAccounts returns the accounts of a transfer from "<FROM ID>.<TO ID>".
+func (c *Client) getTransferAccounts(user model.User, ids string) (model.Account, model.Account, error) {
+	fromStr, toStr, _ := strings.Cut(ids, ".")
+	fromID, err := strconv.ParseInt(fromStr, 10, 64)
+	if err != nil {
+		return model.Account{}, model.Account{}, fmt.Errorf("invalid source account ID: %w", err)
+	}
+	toID, err := strconv.ParseInt(toStr, 10, 64)
+	if err != nil {
+		return model.Account{}, model.Account{}, fmt.Errorf("invalid destination account ID: %w", err)
+	}
+
+	from, err := c.Repositories.Accounts.Get(user.TgID, fromID)
+	if err != nil {
+		return model.Account{}, model.Account{}, fmt.Errorf("failed to get source account: %w", err)
+	}
+	to, err := c.Repositories.Accounts.Get(user.TgID, toID)
+	if err != nil {
+		return model.Account{}, model.Account{}, fmt.Errorf("failed to get destination account: %w", err)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"testing"
+
+	"cashout/internal/model"
+)
+
+func TestParseAccountInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  model.Account
+	}{
+		{
+			name:  "name only",
+			input: "Revolut",
+			want:  model.Account{Name: "Revolut", Kind: model.AccountOther, Currency: model.CurrencyEUR},
+		},
+		{
+			name:  "all fields",
+			input: "Revolut card USD 150",
+			want:  model.Account{Name: "Revolut", Kind: model.AccountCard, Currency: model.CurrencyUSD, OpeningBalance: model.NewMoney(150)},
+		},
+		{
+			name:  "any order and decimal comma",
+			input: "1200,50 savings Emergency fund",
+			want:  model.Account{Name: "Emergency fund", Kind: model.AccountSavings, Currency: model.CurrencyEUR, OpeningBalance: model.NewMoney(1200.5)},
+		},
+		{
+			name:  "negative opening balance",
+			input: "Amex card -80",
+			want:  model.Account{Name: "Amex", Kind: model.AccountCard, Currency: model.CurrencyEUR, OpeningBalance: model.NewMoney(-80)},
+		},
+		{
+			name:  "bare kind",
+			input: "Cash",
+			want:  model.Account{Name: "Cash", Kind: model.AccountCash, Currency: model.CurrencyEUR},
+		},
+		{
+			name:  "repeated tokens are part of the name",
+			input: "Cash cash",
+			want:  model.Account{Name: "cash", Kind: model.AccountCash, Currency: model.CurrencyEUR},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseAccountInput(tt.input, model.CurrencyEUR)
+			if got != tt.want {
+				t.Errorf("ParseAccountInput(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
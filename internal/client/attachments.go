@@ -0,0 +1,128 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// telegramFileClient downloads the files sent to the bot
+var telegramFileClient = &http.Client{Timeout: 60 * time.Second}
+
+// attachmentMessage matches the photos and the documents sent to the bot
+func attachmentMessage(msg *gotgbot.Message) bool {
+	return len(msg.Photo) > 0 || msg.Document != nil
+}
+
+// attachmentTarget returns the transaction a file sent by the user is
+// attached to: the one just added or the one selected with /edit
+func attachmentTarget(user model.User) (int64, bool) {
+	if user.Session.State != model.StateEditingNewTransaction && user.Session.State != model.StateEditingTransaction {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(user.Session.Body, 10, 64)
+	return id, err == nil && id > 0
+}
+
+// AttachmentFromMessage stores a photo or a document sent while adding or
+// editing a transaction as an attachment of it, e.g. the receipt.
+func (c *Client) AttachmentFromMessage(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	file := messageFile(ctx.Message)
+	transactionID, ok := attachmentTarget(user)
+	if !ok {
+		// Without a transaction to attach it to, a receipt is read to add one
+		if c.LLM.Vision != nil && receiptState(user.Session.State) && receiptTypes[file.ContentType] {
+			return c.addReceipt(b, ctx, user, file)
+		}
+		return c.SendHomeKeyboard(b, ctx, "📎 To attach a receipt, add a transaction or select one with /edit, then send the photo or the PDF.")
+	}
+
+	if _, ok := model.AttachmentExtension(file.ContentType); !ok {
+		return SendMessage(ctx, b, fmt.Sprintf("❌ %s.", capitalize(model.ErrAttachmentType.Error())), nil)
+	}
+	if file.Size > model.MaxAttachmentSize {
+		return SendMessage(ctx, b, fmt.Sprintf("❌ %s.", capitalize(model.ErrAttachmentTooLarge.Error())), nil)
+	}
+
+	content, err := downloadTelegramFile(b, file.ID)
+	if err != nil {
+		return fmt.Errorf("failed to download attachment: %w", err)
+	}
+	defer func() { _ = content.Close() }()
+
+	_, err = c.Repositories.Attachments.Add(user.TgID, transactionID, file.Name, file.ContentType, content)
+	switch {
+	case errors.Is(err, model.ErrAttachmentType), errors.Is(err, model.ErrAttachmentTooLarge):
+		return SendMessage(ctx, b, fmt.Sprintf("❌ %s.", capitalize(err.Error())), nil)
+	case errors.Is(err, model.ErrTransactionNotFound):
+		return c.SendHomeKeyboard(b, ctx, "❌ The transaction no longer exists, the file has not been attached.")
+	case err != nil:
+		return fmt.Errorf("failed to add attachment: %w", err)
+	}
+
+	count, err := c.Repositories.Attachments.Count(transactionID)
+	if err != nil {
+		c.Logger.Warnf("failed to count attachments: %v", err)
+	}
+	text := "📎 <b>Attached!</b>"
+	if count > 1 {
+		text = fmt.Sprintf("📎 <b>Attached!</b> The transaction has %d files.", count)
+	}
+	return SendMessage(ctx, b, text+" You can download the attachments from the web dashboard.", nil)
+}
+
+// telegramFile is a photo or a document sent to the bot
+type telegramFile struct {
+	ID          string
+	Name        string
+	ContentType string
+	Size        int64
+}
+
+// messageFile returns the file of a message matched by attachmentMessage
+func messageFile(msg *gotgbot.Message) telegramFile {
+	if len(msg.Photo) > 0 {
+		// Telegram sends every size of the photo, the largest is the last one
+		photo := msg.Photo[len(msg.Photo)-1]
+		return telegramFile{
+			ID:          photo.FileId,
+			Name:        fmt.Sprintf("photo_%s.jpg", time.Unix(msg.Date, 0).Format("2006-01-02_150405")),
+			ContentType: "image/jpeg",
+			Size:        photo.FileSize,
+		}
+	}
+	return telegramFile{ID: msg.Document.FileId, Name: msg.Document.FileName, ContentType: msg.Document.MimeType, Size: msg.Document.FileSize}
+}
+
+// downloadTelegramFile returns the content of a file sent to the bot, which
+// the caller must close
+func downloadTelegramFile(b *gotgbot.Bot, fileID string) (io.ReadCloser, error) {
+	file, err := b.GetFile(fileID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	resp, err := telegramFileClient.Get(file.URL(b, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"testing"
+
+	"cashout/internal/model"
+)
+
+func TestAttachmentTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		session model.UserSession
+		want    int64
+		wantOK  bool
+	}{
+		{name: "transaction just added", session: model.UserSession{State: model.StateEditingNewTransaction, Body: "7"}, want: 7, wantOK: true},
+		{name: "transaction selected with /edit", session: model.UserSession{State: model.StateEditingTransaction, Body: "7"}, want: 7, wantOK: true},
+		{name: "edit left behind", session: model.UserSession{State: model.StateNormal, Body: "7"}},
+		{name: "no transaction", session: model.UserSession{State: model.StateEditingTransaction}},
+		{name: "other flow", session: model.UserSession{State: model.StateEnteringSearchQuery, Body: "7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := attachmentTarget(model.User{Session: tt.session})
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("attachmentTarget() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cashout/internal/ai"
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// maxBatchSize is the most transactions added with a single message
+const maxBatchSize = 10
+
+// transactionBatch is the session body of the transactions of a message
+// listing several of them, reviewed before being saved
+type transactionBatch struct {
+	Transactions []model.Transaction `json:"transactions"`
+	// Line is the index of the line being entered again
+	Line int `json:"line"`
+}
+
+// newExtractedTransactions returns the transactions extracted from a message
+// of the user, without a currency the user's base currency is used
+func newExtractedTransactions(user model.User, extracted []ai.ExtractedTransaction) []model.Transaction {
+	transactions := make([]model.Transaction, len(extracted))
+	for i, e := range extracted {
+		transactions[i] = model.Transaction{
+			TgID:        user.TgID,
+			Type:        e.Type,
+			Category:    model.TransactionCategory(e.Category),
+			Amount:      e.Amount,
+			Description: e.Description,
+			Date:        e.Date,
+			Currency:    e.Currency,
+			Tags:        model.TagsFromNames(user.TgID, e.Tags),
+		}
+		if transactions[i].Currency == "" {
+			transactions[i].Currency = user.BaseCurrency
+		}
+	}
+	return transactions
+}
+
+// FormatBatchLines renders the transactions of a batch, one numbered line
+// each, followed by their totals by currency
+func FormatBatchLines(transactions []model.Transaction, categories model.Categories) string {
+	var sb strings.Builder
+	totals := map[model.CurrencyType]model.Money{}
+	var currencies []model.CurrencyType
+	for i, t := range transactions {
+		fmt.Fprintf(&sb, "%d. %s %s, %s: %s on %s", i+1, categories.Emoji(t.Category), t.Category,
+			html.EscapeString(t.Description), FormatTransactionAmount(t), t.Date.Format("02-01-2006"))
+		if len(t.Tags) > 0 {
+			fmt.Fprintf(&sb, " %s", html.EscapeString(t.FormatTags()))
+		}
+		sb.WriteString("\n")
+
+		if _, ok := totals[t.Currency]; !ok {
+			currencies = append(currencies, t.Currency)
+		}
+		totals[t.Currency] += t.Amount
+	}
+
+	parts := make([]string, len(currencies))
+	for i, currency := range currencies {
+		parts[i] = fmt.Sprintf("%s %.2f", currency.Symbol(), totals[currency])
+	}
+	fmt.Fprintf(&sb, "\n<b>Total: %s</b>", strings.Join(parts, " + "))
+	return sb.String()
+}
+
+// batchKeyboard returns the buttons to edit or remove each line of a batch
+// of n transactions, save them all or cancel
+func batchKeyboard(n int) [][]gotgbot.InlineKeyboardButton {
+	keyboard := make([][]gotgbot.InlineKeyboardButton, 0, n+1)
+	for i := range n {
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+			{Text: fmt.Sprintf("✏️ Edit %d", i+1), CallbackData: fmt.Sprintf("transactions.batch.edit.%d", i)},
+			{Text: fmt.Sprintf("❌ Remove %d", i+1), CallbackData: fmt.Sprintf("transactions.batch.remove.%d", i)},
+		})
+	}
+	return append(keyboard, []gotgbot.InlineKeyboardButton{
+		{Text: fmt.Sprintf("✅ Save all (%d)", n), CallbackData: "transactions.batch.save"},
+		{Text: "Cancel", CallbackData: "transactions.cancel"},
+	})
+}
+
+// mergeBudgetProgress merges the budget status after each write of a batch:
+// the last status of each budget, with the alerts fired by any of the writes.
+// The ones approaching the limit are dropped once it is exceeded.
+func mergeBudgetProgress(evaluations ...[]BudgetProgress) []BudgetProgress {
+	type key struct {
+		category model.TransactionCategory
+		period   model.BudgetPeriod
+		start    int64
+	}
+
+	var merged []BudgetProgress
+	index := map[key]int{}
+	for _, progress := range evaluations {
+		for _, p := range progress {
+			k := key{p.Category, p.Period, p.Start.Unix()}
+			i, ok := index[k]
+			if !ok {
+				index[k] = len(merged)
+				merged = append(merged, p)
+				continue
+			}
+
+			alerts := merged[i].NewAlerts
+			merged[i] = p
+			for _, a := range alerts {
+				if !slices.Contains(merged[i].NewAlerts, a) {
+					merged[i].NewAlerts = append(merged[i].NewAlerts, a)
+				}
+			}
+			if p.Spent >= p.Limit {
+				merged[i].NewAlerts = slices.DeleteFunc(merged[i].NewAlerts, func(a int16) bool { return a < 100 })
+			}
+			slices.Sort(merged[i].NewAlerts)
+		}
+	}
+	return merged
+}
+
+// sendBatchPreview stores the batch in the session and shows it to the user
+// with the buttons to review it
+func (c *Client) sendBatchPreview(b *gotgbot.Bot, ctx *ext.Context, user model.User, batch transactionBatch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode the batch: %w", err)
+	}
+	user.Session.State = model.StateBatchPreview
+	user.Session.Body = string(body)
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to set user data: %w", err)
+	}
+
+	categories, err := c.Repositories.Categories.List(user.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	text := fmt.Sprintf("🧾 <b>%d transactions found</b>\n\n", len(batch.Transactions)) +
+		FormatBatchLines(batch.Transactions, categories) +
+		"\n\nCheck them and save them all at once."
+	return SendMessage(ctx, b, text, batchKeyboard(len(batch.Transactions)))
+}
+
+// sessionBatch returns the batch stored in the session of the user, if any
+func sessionBatch(user model.User) (transactionBatch, bool) {
+	var batch transactionBatch
+	if user.Session.State != model.StateBatchPreview && user.Session.State != model.StateBatchEditingLine {
+		return batch, false
+	}
+	if err := json.Unmarshal([]byte(user.Session.Body), &batch); err != nil || len(batch.Transactions) == 0 {
+		return batch, false
+	}
+	return batch, true
+}
+
+// batchLine returns the index of the line of the batch in the callback data,
+// e.g. transactions.batch.remove.2
+func batchLine(data string, batch transactionBatch) (int, bool) {
+	parts := strings.Split(data, ".")
+	line, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil || line < 0 || line >= len(batch.Transactions) {
+		return 0, false
+	}
+	return line, true
+}
+
+// BatchRemoveLine drops a line from the batch being reviewed
+func (c *Client) BatchRemoveLine(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	batch, ok := sessionBatch(user)
+	if !ok {
+		return c.SendHomeKeyboard(b, ctx, "These transactions are no longer pending, send them again.")
+	}
+	line, ok := batchLine(ctx.CallbackQuery.Data, batch)
+	if !ok {
+		return fmt.Errorf("invalid batch line: %s", ctx.CallbackQuery.Data)
+	}
+
+	batch.Transactions = slices.Delete(batch.Transactions, line, line+1)
+	if len(batch.Transactions) == 0 {
+		user.Session.State = model.StateNormal
+		user.Session.Body = ""
+		if err := c.Repositories.Users.Update(&user); err != nil {
+			return fmt.Errorf("failed to set user data: %w", err)
+		}
+		return c.SendHomeKeyboard(b, ctx, "All the transactions have been removed, nothing was saved.")
+	}
+	return c.sendBatchPreview(b, ctx, user, batch)
+}
+
+// BatchEditLine asks the user to enter again a line of the batch being reviewed
+func (c *Client) BatchEditLine(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	batch, ok := sessionBatch(user)
+	if !ok {
+		return c.SendHomeKeyboard(b, ctx, "These transactions are no longer pending, send them again.")
+	}
+	line, ok := batchLine(ctx.CallbackQuery.Data, batch)
+	if !ok {
+		return fmt.Errorf("invalid batch line: %s", ctx.CallbackQuery.Data)
+	}
+
+	batch.Line = line
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode the batch: %w", err)
+	}
+	user.Session.State = model.StateBatchEditingLine
+	user.Session.Body = string(body)
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to set user data: %w", err)
+	}
+
+	t := batch.Transactions[line]
+	text := fmt.Sprintf("Write line %d again, e.g. <code>lunch 12 grocery yesterday</code>\n\nCurrent: %s, %s: %s on %s",
+		line+1, t.Category, html.EscapeString(t.Description), FormatTransactionAmount(t), t.Date.Format("02-01-2006"))
+	return SendMessage(ctx, b, text, [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Back", CallbackData: "transactions.batch.back"}},
+	})
+}
+
+// BatchBack shows again the batch being reviewed
+func (c *Client) BatchBack(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	batch, ok := sessionBatch(user)
+	if !ok {
+		return c.SendHomeKeyboard(b, ctx, "These transactions are no longer pending, send them again.")
+	}
+	return c.sendBatchPreview(b, ctx, user, batch)
+}
+
+// editBatchLine replaces the line being edited with the transaction the user
+// entered
+func (c *Client) editBatchLine(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	batch, ok := sessionBatch(user)
+	if !ok || batch.Line >= len(batch.Transactions) {
+		return c.SendHomeKeyboard(b, ctx, "These transactions are no longer pending, send them again.")
+	}
+	old := batch.Transactions[batch.Line]
+
+	categories, err := c.Repositories.Categories.List(user.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	extracted, err := c.LLM.ExtractTransactions(context.Background(), text, old.Type, categories, user.Today())
+	if err != nil || len(extracted) != 1 || extracted[0].Amount == 0 {
+		_, errm := ctx.EffectiveMessage.Reply(b, "I'm sorry, I couldn't understand the transaction! Write a single one, with its amount.", nil)
+		return errors.Join(err, errm)
+	}
+
+	batch.Transactions[batch.Line] = newExtractedTransactions(user, extracted)[0]
+	return c.sendBatchPreview(b, ctx, user, batch)
+}
+
+// BatchSave saves all the transactions of the batch being reviewed at once
+func (c *Client) BatchSave(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	batch, ok := sessionBatch(user)
+	if !ok {
+		return c.SendHomeKeyboard(b, ctx, "These transactions are no longer pending, send them again.")
+	}
+
+	// Clear the session before saving, so that a second tap on the button
+	// finds the batch gone instead of saving it twice
+	pending := user.Session
+	claimed, err := c.Repositories.Users.ClaimSession(&user, model.UserSession{State: model.StateNormal})
+	if err != nil {
+		return fmt.Errorf("failed to set user data: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	transactions := make([]*model.Transaction, len(batch.Transactions))
+	for i := range batch.Transactions {
+		transactions[i] = &batch.Transactions[i]
+	}
+	if err := c.Repositories.Transactions.As(botActor(user)).AddAll(transactions); err != nil {
+		c.Logger.Errorln("failed to add transactions", err)
+		// Give the batch back so that it can be retried
+		user.Session = pending
+		return errors.Join(
+			fmt.Errorf("failed to add transactions: %w", err),
+			c.Repositories.Users.Update(&user),
+			SendMessage(ctx, b, "There has been an error saving your transactions, none was saved. Please retry.", batchKeyboard(len(batch.Transactions))),
+		)
+	}
+
+	categories, err := c.Repositories.Categories.List(user.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	var progress [][]BudgetProgress
+	var lastIncome *model.Transaction
+	for _, t := range batch.Transactions {
+		progress = append(progress, c.EvaluateAfterExpenseInsert(user, t))
+		if t.Type == model.TypeIncome {
+			lastIncome = &t
+		}
+	}
+
+	emoji := "💰"
+	if batch.Transactions[0].Type == model.TypeExpense {
+		emoji = "💸"
+	}
+	text := fmt.Sprintf("%s <b>%d transactions saved!</b>\n\n", emoji, len(batch.Transactions)) +
+		FormatBatchLines(batch.Transactions, categories) +
+		FormatBudgetSuffix(mergeBudgetProgress(progress...))
+	if lastIncome != nil {
+		text += c.EnvelopeSuffixForTx(user, *lastIncome)
+	}
+	return SendMessage(ctx, b, text, c.homeKeyboard())
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cashout/internal/ai"
+	"cashout/internal/model"
+)
+
+func TestNewExtractedTransactions(t *testing.T) {
+	user := model.User{TgID: 7, BaseCurrency: model.CurrencyEUR}
+	day := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+
+	got := newExtractedTransactions(user, []ai.ExtractedTransaction{
+		{Type: model.TypeExpense, Description: "Coffee", Amount: model.NewMoney(2.5), Category: "EatingOut", Date: day},
+		{Type: model.TypeExpense, Description: "Train", Amount: model.NewMoney(4.2), Currency: model.CurrencyGBP, Category: "Transport", Tags: []string{"work"}, Date: day},
+	})
+	want := []model.Transaction{
+		{TgID: 7, Type: model.TypeExpense, Category: "EatingOut", Amount: model.NewMoney(2.5), Description: "Coffee", Date: day, Currency: model.CurrencyEUR, Tags: []model.Tag{}},
+		{TgID: 7, Type: model.TypeExpense, Category: "Transport", Amount: model.NewMoney(4.2), Description: "Train", Date: day, Currency: model.CurrencyGBP, Tags: []model.Tag{{TgID: 7, Name: "work"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newExtractedTransactions() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFormatBatchLines(t *testing.T) {
+	categories := model.Categories{{Name: "Grocery", Emoji: "🛒", Type: model.TypeExpense}}
+	day := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+
+	got := FormatBatchLines([]model.Transaction{
+		{Category: "Grocery", Description: "Bread & milk", Amount: model.NewMoney(3.2), Currency: model.CurrencyEUR, Date: day, Tags: []model.Tag{{Name: "home"}}},
+		{Category: "Transport", Description: "Train", Amount: model.NewMoney(4.2), Currency: model.CurrencyEUR, Date: day.AddDate(0, 0, -1)},
+		{Category: "Grocery", Description: "Tea", Amount: model.NewMoney(5), Currency: model.CurrencyGBP, Date: day},
+	}, categories)
+
+	want := "1. 🛒 Grocery, Bread &amp; milk: € 3.20 on 10-05-2026 #home\n" +
+		"2. " + model.DefaultCategoryEmoji + " Transport, Train: € 4.20 on 09-05-2026\n" +
+		"3. 🛒 Grocery, Tea: £ 5.00 on 10-05-2026\n" +
+		"\n<b>Total: € 7.40 + £ 5.00</b>"
+	if got != want {
+		t.Errorf("FormatBatchLines() = %q, want %q", got, want)
+	}
+}
+
+func TestBatchKeyboard(t *testing.T) {
+	keyboard := batchKeyboard(2)
+	if len(keyboard) != 3 {
+		t.Fatalf("got %d rows, want a row per line and the save one", len(keyboard))
+	}
+	if keyboard[1][0].CallbackData != "transactions.batch.edit.1" || keyboard[1][1].CallbackData != "transactions.batch.remove.1" {
+		t.Errorf("unexpected line buttons %+v", keyboard[1])
+	}
+	if last := keyboard[2]; last[0].CallbackData != "transactions.batch.save" || !strings.Contains(last[0].Text, "(2)") {
+		t.Errorf("unexpected save button %+v", last[0])
+	}
+}
+
+func TestBatchLine(t *testing.T) {
+	batch := transactionBatch{Transactions: make([]model.Transaction, 2)}
+	for data, want := range map[string]bool{
+		"transactions.batch.remove.0":  true,
+		"transactions.batch.edit.1":    true,
+		"transactions.batch.edit.2":    false,
+		"transactions.batch.edit.-1":   false,
+		"transactions.batch.edit.next": false,
+	} {
+		if _, ok := batchLine(data, batch); ok != want {
+			t.Errorf("batchLine(%q) = %v, want %v", data, ok, want)
+		}
+	}
+}
+
+func TestMergeBudgetProgress(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	got := mergeBudgetProgress(
+		[]BudgetProgress{
+			{Start: start, Limit: model.NewMoney(1000), Spent: model.NewMoney(850), Pct: 85, NewAlerts: []int16{80}},
+			{Category: "Grocery", Start: start, Limit: model.NewMoney(200), Spent: model.NewMoney(150), Pct: 75, NewAlerts: []int16{50}},
+		},
+		nil,
+		[]BudgetProgress{
+			{Start: start, Limit: model.NewMoney(1000), Spent: model.NewMoney(1010), Pct: 101, NewAlerts: []int16{100}},
+			{Category: "Grocery", Start: start, Limit: model.NewMoney(200), Spent: model.NewMoney(170), Pct: 85, NewAlerts: []int16{80}},
+			{Category: "Grocery", Period: model.PeriodWeekly, Start: start, Spent: model.NewMoney(30), Pct: 30},
+		},
+	)
+	want := []BudgetProgress{
+		{Start: start, Limit: model.NewMoney(1000), Spent: model.NewMoney(1010), Pct: 101, NewAlerts: []int16{100}},
+		{Category: "Grocery", Start: start, Limit: model.NewMoney(200), Spent: model.NewMoney(170), Pct: 85, NewAlerts: []int16{50, 80}},
+		{Category: "Grocery", Period: model.PeriodWeekly, Start: start, Spent: model.NewMoney(30), Pct: 30},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeBudgetProgress() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cashout/internal/email"
+	"cashout/internal/model"
+	"cashout/internal/repository"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// BudgetNotifier evaluates the budgets after the writes of expenses and
+// delivers the alerts they fire, whatever the write came from: the bot, the
+// dashboard, the API or a recurring rule.
+type BudgetNotifier struct {
+	Budgets repository.Budgets
+	Bot     *gotgbot.Bot
+	// Mailer sends the alerts by email to the users who asked for it, nil when
+	// email is not configured
+	Mailer *email.EmailService
+	Logger *logrus.Logger
+}
+
+// Notify evaluates the budgets affected by the write of a transaction and
+// delivers the alerts fired to the user, by email when they asked for it and
+// on Telegram when telegram is set (the bot replies show them already).
+// Returns the evaluations, nil if no budget is affected.
+func (n BudgetNotifier) Notify(user model.User, tx model.Transaction, telegram bool) []model.BudgetEvaluation {
+	evaluations, err := n.Budgets.Evaluate(tx)
+	if err != nil {
+		n.Logger.Warnf("budget evaluation failed: %v", err)
+		return nil
+	}
+
+	text := FormatBudgetAlerts(evaluations)
+	if text == "" {
+		return evaluations
+	}
+
+	if telegram && n.Bot != nil {
+		if _, err := n.Bot.SendMessage(user.TgID, text, nil); err != nil {
+			n.Logger.Warnf("failed to send budget alerts to %d: %v", user.TgID, err)
+		}
+	}
+	if user.BudgetAlertEmail && user.Email != nil && n.Mailer != nil {
+		if err := n.Mailer.SendTransacEmail(*user.Email, "Cashout budget alert", text); err != nil {
+			n.Logger.Warnf("failed to email budget alerts to %d: %v", user.TgID, err)
+		}
+	}
+	return evaluations
+}
+
+// FormatBudgetAlerts builds the plain text of the alerts fired by a write,
+// one line for each budget with the highest threshold it reached. Empty
+// string if none fired.
+func FormatBudgetAlerts(evaluations []model.BudgetEvaluation) string {
+	var lines []string
+	for _, e := range evaluations {
+		if len(e.Fired) == 0 {
+			continue
+		}
+
+		name := fmt.Sprintf("the %s budget", budgetPeriodName(e.Budget.Period))
+		if e.Budget.IsCategory() {
+			name = fmt.Sprintf("the %s budget", e.Budget.Category)
+		}
+		if e.Budget.LedgerID != nil {
+			name = "the shared " + strings.TrimPrefix(name, "the ")
+		}
+		threshold := slices.Max(e.Fired)
+		emoji := "⚠️"
+		if threshold >= 100 {
+			emoji = "🚨"
+		}
+		lines = append(lines, fmt.Sprintf("%s %d%% of %s reached: %.2f / %.2f %s (%d%%)",
+			emoji, threshold, name, e.Spent, e.Limit(), e.Budget.Currency.Symbol(), e.Pct()))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "🔔 Budget alert\n\n" + strings.Join(lines, "\n")
+}
+
+// budgetAlerts returns the alerts of the inline budget status of a write: the
+// highest threshold below 100% fired while still under the limit, the
+// over-budget one on every write while over, and the highest fired above 100%.
+func budgetAlerts(e model.BudgetEvaluation) []int16 {
+	var below, above int16
+	for _, t := range e.Fired {
+		if t < 100 {
+			below = max(below, t)
+		} else if t > 100 {
+			above = max(above, t)
+		}
+	}
+
+	var alerts []int16
+	if e.Spent < e.Limit() {
+		if below > 0 {
+			alerts = append(alerts, below)
+		}
+		return alerts
+	}
+	alerts = append(alerts, 100)
+	if above > 0 {
+		alerts = append(alerts, above)
+	}
+	return alerts
+}
+
+// budgetNotifier returns the BudgetNotifier of the bot
+func (c *Client) budgetNotifier(b *gotgbot.Bot) BudgetNotifier {
+	return BudgetNotifier{
+		Budgets: c.Repositories.Budgets,
+		Bot:     b,
+		Mailer:  c.Mailer,
+		Logger:  c.Logger,
+	}
+}
@@ -3,157 +3,373 @@ package client
 import (
 	"errors"
 	"fmt"
-	"math"
+	"strconv"
 	"strings"
 	"time"
 
 	"cashout/internal/model"
+	"cashout/internal/utils"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"gorm.io/gorm"
 )
 
-// BudgetProgress is the result of evaluating a user's spending against their budget.
+// BudgetProgress is the result of evaluating a user's spending against one of
+// their budgets: the overall one, or the one of Category when set, in its
+// Period from Start to End. Limit is the effective limit of the period, Base
+// plus the Carry of the rollover.
 type BudgetProgress struct {
-	Limit     float64
-	Spent     float64
+	Category  model.TransactionCategory
+	Period    model.BudgetPeriod
+	Start     time.Time
+	End       time.Time
+	Base      model.Money
+	Carry     model.Money
+	Rollover  bool
+	Limit     model.Money
+	Spent     model.Money
 	Pct       int
+	Currency  model.CurrencyType
+	Shared    bool    // the budget of a shared ledger
 	NewAlerts []int16 // subset of threshold percentages that just crossed on this insert
 }
 
+func newBudgetProgress(s model.BudgetSpending) BudgetProgress {
+	return BudgetProgress{
+		Category: s.Budget.Category,
+		Period:   s.Budget.Period,
+		Start:    s.Start,
+		End:      s.End,
+		Base:     s.Budget.Amount,
+		Carry:    s.Carry,
+		Rollover: s.Budget.HasRollover(),
+		Limit:    s.Limit(),
+		Spent:    s.Spent,
+		Pct:      s.Pct(),
+		Currency: s.Budget.Currency,
+		Shared:   s.Budget.LedgerID != nil,
+	}
+}
+
 // EvaluateAfterExpenseInsert computes budget progress and fires any newly-crossed
-// threshold alerts for the calendar month of the inserted transaction.
-// Returns nil if the user has no budget set or the transaction is not an Expense.
+// threshold alerts for the budget periods of the written transaction, for the
+// overall budget and for the budgets of the categories the transaction is in
+// (the ones of its lines when split). The alerts fired are emailed to the user
+// who asked for it, the bot reply showing them on Telegram.
+// Returns nil if the user has no such budget or the transaction is not an Expense.
 //
 // Alert semantics:
-//   - "80% approaching" is a one-shot transition warning (dedup'd in DB per month).
+//   - the thresholds of a budget are one-shot warnings (dedup'd in DB per period).
 //   - ">=100% over budget" is an ongoing condition; surfaced on every expense
 //     while the user remains over, so they don't sleepwalk past the limit.
-func (c *Client) EvaluateAfterExpenseInsert(tx model.Transaction) (*BudgetProgress, error) {
-	if tx.Type != model.TypeExpense {
-		return nil, nil
+func (c *Client) EvaluateAfterExpenseInsert(user model.User, tx model.Transaction) []BudgetProgress {
+	evaluations := c.budgetNotifier(nil).Notify(user, tx, false)
+
+	progress := make([]BudgetProgress, len(evaluations))
+	for i, e := range evaluations {
+		progress[i] = newBudgetProgress(e.BudgetSpending)
+		progress[i].NewAlerts = budgetAlerts(e)
 	}
+	return progress
+}
 
-	budget, err := c.Repositories.Budgets.Get(tx.TgID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+// BudgetSuffixForTx returns the FormatBudgetSuffix string for the budget periods
+// of the given transaction (the relevant ones for any edit/delete impact on a tx),
+// limited to the overall budget and the ones of the categories of the
+// transaction, swallowing internal errors with a log line. Empty string if no budget.
+func (c *Client) BudgetSuffixForTx(tx model.Transaction) string {
+	var progress []BudgetProgress
+	for _, scope := range tx.BudgetScopes() {
+		scoped, err := c.BudgetStatus(scope, tx.Date)
+		if err != nil {
+			c.Logger.Warnf("budget status lookup failed: %v", err)
+			return ""
 		}
-		return nil, err
+		progress = append(progress, scoped...)
 	}
 
-	year := tx.Date.Year()
-	month := int(tx.Date.Month())
+	categories := tx.CategoryAmounts()
+	day := model.DateOf(tx.Date)
+	var relevant []BudgetProgress
+	for _, p := range progress {
+		if day.Before(p.Start) || day.After(p.End) {
+			continue
+		}
+		if _, ok := categories[p.Category]; ok || p.Category == "" {
+			relevant = append(relevant, p)
+		}
+	}
+	return FormatBudgetSuffix(relevant)
+}
 
-	spentAfter, err := c.Repositories.Budgets.TotalExpensesForMonth(tx.TgID, year, month)
+// BudgetStatus returns the current status of every budget in its period
+// containing day without firing any alerts. Used after edit/delete confirmations
+// to keep the over-budget warning visible whenever the user is still over.
+// Returns nil if no budget is set.
+func (c *Client) BudgetStatus(scope model.Scope, day time.Time) ([]BudgetProgress, error) {
+	spending, err := c.Repositories.Budgets.Spending(scope, day)
 	if err != nil {
 		return nil, err
 	}
-	spentBefore := spentAfter - tx.Amount
-	if spentBefore < 0 {
-		spentBefore = 0
-	}
-
-	yearMonth := fmt.Sprintf("%04d-%02d", year, month)
-	progress := &BudgetProgress{
-		Limit: budget.Amount,
-		Spent: spentAfter,
-		Pct:   int(math.Floor(spentAfter / budget.Amount * 100)),
-	}
-
-	// 80% approaching: one-shot transition warning (dedup'd in DB).
-	// Only surface if the user is in the [80,100) band — once they cross 100,
-	// the over-budget message below supersedes it.
-	if spentAfter < budget.Amount {
-		cutoff80 := budget.Amount * 0.80
-		if spentBefore < cutoff80 && spentAfter >= cutoff80 {
-			fired, err := c.Repositories.Budgets.TryMarkAlertFired(tx.TgID, yearMonth, 80)
-			if err != nil {
-				c.Logger.Warnf("failed to mark budget alert fired: %v", err)
-			} else if fired {
-				progress.NewAlerts = append(progress.NewAlerts, 80)
+
+	progress := make([]BudgetProgress, len(spending))
+	for i, s := range spending {
+		progress[i] = newBudgetProgress(s)
+		// Surface the over-budget nag on every refresh while user remains over.
+		if s.Spent >= s.Limit() {
+			progress[i].NewAlerts = []int16{100}
+		}
+	}
+	return progress, nil
+}
+
+// FormatBudgetSuffix builds the trailing message lines appended to a transaction
+// confirmation when budgets exist, one line for each budget followed by its alerts.
+func FormatBudgetSuffix(progress []BudgetProgress) string {
+	if len(progress) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, p := range progress {
+		name := "Budget"
+		switch {
+		case p.Shared && p.Category != "":
+			name = fmt.Sprintf("Shared %s budget", p.Category)
+		case p.Shared:
+			name = "Shared budget"
+		case p.Category != "":
+			name = fmt.Sprintf("%s budget", p.Category)
+		}
+		if p.Period != "" && p.Period != model.PeriodMonthly {
+			name += fmt.Sprintf(" (%s)", FormatBudgetWindow(p.Start, p.End))
+		}
+		b.WriteString(fmt.Sprintf("\n📊 %s: %.2f / %.2f %s (%d%%)", name, p.Spent, p.Limit, p.Currency.Symbol(), p.Pct))
+		if p.Rollover {
+			b.WriteString(fmt.Sprintf("\n🔁 Limit: %s %s", FormatBudgetLimit(p.Base, p.Carry), p.Currency.Symbol()))
+		}
+
+		for _, t := range p.NewAlerts {
+			switch {
+			case t < 100 && p.Category == "":
+				b.WriteString(fmt.Sprintf("\n⚠️ Approaching %s budget (%d%% used)", budgetPeriodName(p.Period), t))
+			case t < 100:
+				b.WriteString(fmt.Sprintf("\n⚠️ Approaching the %s budget (%d%% used)", p.Category, t))
+			case t == 100 && p.Category == "":
+				b.WriteString(fmt.Sprintf("\n🚨 Over budget by %.2f %s", p.Spent-p.Limit, p.Currency.Symbol()))
+			case t == 100:
+				b.WriteString(fmt.Sprintf("\n🚨 Over the %s budget by %.2f %s", p.Category, p.Spent-p.Limit, p.Currency.Symbol()))
+			case p.Category == "":
+				b.WriteString(fmt.Sprintf("\n🚨 %d%% of the %s budget reached", t, budgetPeriodName(p.Period)))
+			default:
+				b.WriteString(fmt.Sprintf("\n🚨 %d%% of the %s budget reached", t, p.Category))
 			}
 		}
 	}
+	return b.String()
+}
+
+// FormatBudgetRecap builds the budget section of the recaps, one line for each
+// budget in the given order with its period when not monthly, followed by
+// what its rollover carried
+func FormatBudgetRecap(budgets []model.BudgetSpending, categories model.Categories, cur string) string {
+	var text strings.Builder
+	for _, sp := range budgets {
+		label := "📊 <b>Budget:</b>"
+		if sp.Budget.IsCategory() {
+			label = fmt.Sprintf("%s <b>%s budget:</b>", categories.Emoji(sp.Budget.Category), sp.Budget.Category)
+		}
+		if p := sp.Budget.Period; p != "" && p != model.PeriodMonthly {
+			label += fmt.Sprintf(" <i>(%s)</i>", FormatBudgetWindow(sp.Start, sp.End))
+		}
+		pct := sp.Pct()
+		fmt.Fprintf(&text, "%s %.2f / %.2f%s (%d%%) %s\n",
+			label, sp.Spent, sp.Limit(), cur, pct, budgetIndicator(pct))
+		if sp.Budget.HasRollover() {
+			fmt.Fprintf(&text, "    🔁 <i>%s%s</i>\n", FormatBudgetLimit(sp.Budget.Amount, sp.Carry), cur)
+		}
+	}
+	return text.String()
+}
+
+// FormatBudgetWindow shows the first and the last day of a budget period
+func FormatBudgetWindow(start, end time.Time) string {
+	return fmt.Sprintf("%s - %s", start.Format("02 Jan"), end.Format("02 Jan 2006"))
+}
+
+// FormatBudgetPeriod describes the period of a budget, e.g. "weekly from Monday"
+func FormatBudgetPeriod(budget model.Budget) string {
+	switch budget.Period {
+	case model.PeriodWeekly:
+		return fmt.Sprintf("weekly from %s", budget.WeekStart)
+	case model.PeriodCustom:
+		start, end := budget.Window(time.Time{})
+		return "from " + FormatBudgetWindow(start, end)
+	case "":
+		return string(model.PeriodMonthly)
+	default:
+		return string(budget.Period)
+	}
+}
+
+// budgetPeriodName returns the adjective of a budget of a period, as in
+// "monthly budget"
+func budgetPeriodName(period model.BudgetPeriod) string {
+	if period == "" {
+		return string(model.PeriodMonthly)
+	}
+	return string(period)
+}
+
+// FormatBudgetLimit shows how the effective limit of a budget with a rollover
+// is made: "base + carry = limit", a negative carry being subtracted.
+func FormatBudgetLimit(base, carry model.Money) string {
+	sign := "+"
+	if carry < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%.2f %s %.2f carried = %.2f", base, sign, carry.Abs(), base+carry)
+}
+
+// FormatBudgetRollover describes the rollover mode of a budget and its cap
+func FormatBudgetRollover(budget model.Budget) string {
+	var text string
+	switch budget.Rollover {
+	case model.RolloverPositive:
+		text = "unspent money rolls over"
+	case model.RolloverNegative:
+		text = "overspending rolls over"
+	case model.RolloverBoth:
+		text = "unspent money and overspending roll over"
+	default:
+		return "no rollover"
+	}
+	if budget.RolloverCap > 0 {
+		text += fmt.Sprintf(", up to %.2f %s", budget.RolloverCap, budget.Currency.Symbol())
+	}
+	return text
+}
+
+// ParseBudgetRolloverInput parses the arguments of /budget rollover: an
+// optional category name, the rollover mode and an optional cap.
+func ParseBudgetRolloverInput(text string) (model.TransactionCategory, model.BudgetRollover, model.Money, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", "", 0, false
+	}
 
-	// Over budget: ongoing condition — always surface while pct >= 100.
-	// Mark fired on the first crossing for record-keeping, but don't gate display.
-	if spentAfter >= budget.Amount {
-		if spentBefore < budget.Amount {
-			if _, err := c.Repositories.Budgets.TryMarkAlertFired(tx.TgID, yearMonth, 100); err != nil {
-				c.Logger.Warnf("failed to mark budget alert fired: %v", err)
+	var rolloverCap model.Money
+	if len(fields) > 1 {
+		if v, err := model.ParseMoney(fields[len(fields)-1]); err == nil {
+			if v < 0 {
+				return "", "", 0, false
 			}
+			rolloverCap = v
+			fields = fields[:len(fields)-1]
 		}
-		progress.NewAlerts = append(progress.NewAlerts, 100)
 	}
 
-	return progress, nil
+	rollover, ok := model.ParseBudgetRollover(fields[len(fields)-1])
+	if !ok {
+		return "", "", 0, false
+	}
+	return model.TransactionCategory(strings.Join(fields[:len(fields)-1], " ")), rollover, rolloverCap, true
 }
 
-// BudgetSuffixForTx returns the FormatBudgetSuffix string for the month of the
-// given transaction (the relevant month for any edit/delete impact on a tx),
-// swallowing internal errors with a log line. Empty string if no budget.
-func (c *Client) BudgetSuffixForTx(tx model.Transaction) string {
-	progress, err := c.BudgetStatusForMonth(tx.TgID, tx.Date.Year(), int(tx.Date.Month()))
-	if err != nil {
-		c.Logger.Warnf("budget status lookup failed: %v", err)
-		return ""
+// BudgetPeriodInput is the period of a budget given to /budget period
+type BudgetPeriodInput struct {
+	Category  model.TransactionCategory
+	Period    model.BudgetPeriod
+	WeekStart time.Weekday
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// ParseBudgetPeriodInput parses the arguments of /budget period: an optional
+// category name followed by a period, "weekly" with an optional week start
+// (Monday by default), or the first and the last day of a custom period.
+func ParseBudgetPeriodInput(text string) (BudgetPeriodInput, bool) {
+	fields := strings.Fields(text)
+	n := len(fields)
+	in := BudgetPeriodInput{WeekStart: time.Monday}
+
+	if n >= 2 {
+		start, errStart := utils.ParseDate(fields[n-2])
+		end, errEnd := utils.ParseDate(fields[n-1])
+		if errStart == nil && errEnd == nil {
+			in.Category = model.TransactionCategory(strings.Join(fields[:n-2], " "))
+			in.Period = model.PeriodCustom
+			in.StartDate, in.EndDate = &start, &end
+			return in, true
+		}
+		if weekday, ok := model.ParseWeekday(fields[n-1]); ok && strings.EqualFold(fields[n-2], string(model.PeriodWeekly)) {
+			in.Category = model.TransactionCategory(strings.Join(fields[:n-2], " "))
+			in.Period = model.PeriodWeekly
+			in.WeekStart = weekday
+			return in, true
+		}
+	}
+	if n == 0 {
+		return in, false
 	}
-	return FormatBudgetSuffix(progress)
+
+	period, ok := model.ParseBudgetPeriod(fields[n-1])
+	if !ok || period == model.PeriodCustom {
+		return in, false
+	}
+	in.Category = model.TransactionCategory(strings.Join(fields[:n-1], " "))
+	in.Period = period
+	return in, true
 }
 
-// BudgetStatusForMonth returns the current budget status for a given month
-// without firing any alerts. Used after edit/delete confirmations to keep the
-// over-budget warning visible whenever the user is still over.
-// Returns nil if no budget is set.
-func (c *Client) BudgetStatusForMonth(tgID int64, year, month int) (*BudgetProgress, error) {
-	budget, err := c.Repositories.Budgets.Get(tgID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+// ParseBudgetAlertsInput parses the arguments of /budget alerts: an optional
+// category name followed by the alert thresholds, e.g. "Grocery 50 75% 100".
+func ParseBudgetAlertsInput(text string) (model.TransactionCategory, model.BudgetThresholds, error) {
+	fields := strings.Fields(text)
+	i := len(fields)
+	for i > 0 {
+		if _, err := strconv.Atoi(strings.TrimSuffix(fields[i-1], "%")); err != nil {
+			break
 		}
-		return nil, err
+		i--
 	}
 
-	spent, err := c.Repositories.Budgets.TotalExpensesForMonth(tgID, year, month)
+	thresholds, err := model.ParseBudgetThresholds(fields[i:])
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
+	return model.TransactionCategory(strings.Join(fields[:i], " ")), thresholds, nil
+}
 
-	progress := &BudgetProgress{
-		Limit: budget.Amount,
-		Spent: spent,
-		Pct:   int(math.Floor(spent / budget.Amount * 100)),
+// ParseBudgetInput parses the arguments of /budget set: an amount for the
+// overall budget, or a category name followed by its amount.
+func ParseBudgetInput(text string) (model.TransactionCategory, model.Money, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", 0, false
 	}
-	// Surface the over-budget nag on every refresh while user remains over.
-	if spent >= budget.Amount {
-		progress.NewAlerts = []int16{100}
+
+	amount, err := model.ParseMoney(fields[len(fields)-1])
+	if err != nil || amount <= 0 {
+		return "", 0, false
 	}
-	return progress, nil
+	return model.TransactionCategory(strings.Join(fields[:len(fields)-1], " ")), amount, true
 }
 
-// FormatBudgetSuffix builds the trailing message lines appended to a transaction
-// confirmation when a budget exists.
-func FormatBudgetSuffix(p *BudgetProgress) string {
-	if p == nil {
-		return ""
-	}
-	var b strings.Builder
-	b.WriteString(fmt.Sprintf("\n\n📊 Budget: %.2f / %.2f € (%d%%)", p.Spent, p.Limit, p.Pct))
-	for _, t := range p.NewAlerts {
-		switch t {
-		case 80:
-			b.WriteString("\n⚠️ Approaching monthly budget (80% used)")
-		case 100:
-			over := p.Spent - p.Limit
-			b.WriteString(fmt.Sprintf("\n🚨 Over budget by %.2f €", over))
-		}
+// budgetIndicator returns the status emoji of a budget used at pct
+func budgetIndicator(pct int) string {
+	switch {
+	case pct >= 100:
+		return "🚨"
+	case pct >= 80:
+		return "⚠️"
+	default:
+		return "✅"
 	}
-	return b.String()
 }
 
-// ShowBudget renders the current budget and progress for the current month.
+// ShowBudget renders the current budgets and their progress in their current periods.
 func (c *Client) ShowBudget(b *gotgbot.Bot, ctx *ext.Context) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
@@ -167,48 +383,71 @@ func (c *Client) ShowBudget(b *gotgbot.Bot, ctx *ext.Context) error {
 		return fmt.Errorf("failed to update user state: %w", err)
 	}
 
-	budget, err := c.Repositories.Budgets.Get(user.TgID)
+	now := user.Today()
+	spending, err := c.Repositories.Budgets.Spending(user.Scope(), now)
+	if err != nil {
+		return fmt.Errorf("failed to get budgets: %w", err)
+	}
+
 	keyboard := [][]gotgbot.InlineKeyboardButton{
 		{{Text: "💰 Set / Update Budget", CallbackData: "budget.setprompt"}},
 	}
 
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			text := "📊 <b>Monthly Budget</b>\n\nYou haven't set a monthly budget yet.\n\nUse <code>/budget set &lt;amount&gt;</code> or tap below."
-			keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}})
-			return SendMessage(ctx, b, text, keyboard)
-		}
-		return fmt.Errorf("failed to get budget: %w", err)
+	if len(spending) == 0 {
+		text := "📊 <b>Budgets</b>\n\nYou haven't set a budget yet.\n\n" +
+			"Use <code>/budget set &lt;amount&gt;</code> for all your expenses or " +
+			"<code>/budget set &lt;category&gt; &lt;amount&gt;</code> for a category, or tap below."
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}})
+		return SendMessage(ctx, b, text, keyboard)
 	}
 
-	now := time.Now()
-	spent, err := c.Repositories.Budgets.TotalExpensesForMonth(user.TgID, now.Year(), int(now.Month()))
-	if err != nil {
-		return fmt.Errorf("failed to compute month total: %w", err)
+	var text strings.Builder
+	text.WriteString("📊 <b>Budgets</b>\n\n")
+	for _, s := range spending {
+		name := "Overall"
+		callback := "budget.delete"
+		if s.Budget.IsCategory() {
+			name = string(s.Budget.Category)
+			callback = fmt.Sprintf("budget.delete.%d", s.Budget.ID)
+		}
+		fmt.Fprintf(&text, "%s <b>%s:</b> %.2f / %.2f %s (%d%%)\n",
+			budgetIndicator(s.Pct()), name, s.Spent, s.Limit(), s.Budget.Currency.Symbol(), s.Pct())
+		switch s.Budget.Period {
+		case model.PeriodMonthly, "":
+		case model.PeriodCustom:
+			fmt.Fprintf(&text, "    📅 %s\n", capitalize(FormatBudgetPeriod(s.Budget)))
+		default:
+			fmt.Fprintf(&text, "    📅 %s, %s\n", capitalize(FormatBudgetPeriod(s.Budget)), FormatBudgetWindow(s.Start, s.End))
+		}
+		if s.Budget.HasRollover() {
+			fmt.Fprintf(&text, "    🔁 %s %s\n    <i>%s</i>\n",
+				FormatBudgetLimit(s.Budget.Amount, s.Carry), s.Budget.Currency.Symbol(), FormatBudgetRollover(s.Budget))
+		}
+		if len(s.Budget.Thresholds) > 0 {
+			fmt.Fprintf(&text, "    🔔 Alerts at %s\n", s.Budget.Thresholds)
+		}
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🗑 Remove " + name, CallbackData: callback}})
 	}
-	pct := int(math.Floor(spent / budget.Amount * 100))
-
-	indicator := "✅"
-	if pct >= 100 {
-		indicator = "🚨"
-	} else if pct >= 80 {
-		indicator = "⚠️"
+	alertsBy := "Telegram"
+	if user.BudgetAlertEmail {
+		alertsBy = "Telegram and email"
 	}
+	fmt.Fprintf(&text, "\nMonth: %s\nAlerts sent by %s\n\n"+
+		"<i>Use <code>/budget period [category] weekly [monday]|monthly|quarterly|yearly</code> "+
+		"or <code>/budget period [category] &lt;from&gt; &lt;to&gt;</code> to change the period of a budget, "+
+		"<code>/budget rollover [category] positive|negative|both|off [cap]</code> "+
+		"to carry unspent money or overspending into the next period, "+
+		"<code>/budget alerts [category] 50 75 100</code> to choose when you are alerted "+
+		"and <code>/budget alerts email on|off</code> to get the alerts by email too.</i>", now.Format("January 2006"), alertsBy)
 
-	text := fmt.Sprintf(
-		"📊 <b>Monthly Budget</b>\n\n%s %.2f / %.2f € (%d%%)\nMonth: %s",
-		indicator, spent, budget.Amount, pct, now.Format("January 2006"),
-	)
-
-	keyboard = append(keyboard,
-		[]gotgbot.InlineKeyboardButton{{Text: "🗑 Remove Budget", CallbackData: "budget.delete"}},
-		[]gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}},
-	)
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}})
 
-	return SendMessage(ctx, b, text, keyboard)
+	return SendMessage(ctx, b, text.String(), keyboard)
 }
 
-// BudgetCommand handles /budget and its subcommands: "set <amount>", "delete", or "" (show).
+// BudgetCommand handles /budget and its subcommands: "set [category] <amount>",
+// "delete [category]", "period [category] <period>", "rollover [category] <mode> [cap]",
+// "alerts [category] <thresholds>", "alerts email on|off", or "" (show).
 func (c *Client) BudgetCommand(b *gotgbot.Bot, ctx *ext.Context) error {
 	if ctx.Message == nil {
 		return c.ShowBudget(b, ctx)
@@ -224,16 +463,25 @@ func (c *Client) BudgetCommand(b *gotgbot.Bot, ctx *ext.Context) error {
 	case "set":
 		if len(parts) < 3 {
 			_, err := b.SendMessage(ctx.EffectiveSender.ChatId,
-				"Usage: <code>/budget set &lt;amount&gt;</code>",
+				"Usage: <code>/budget set [category] &lt;amount&gt;</code>",
 				&gotgbot.SendMessageOpts{ParseMode: "HTML"})
 			return err
 		}
-		return c.budgetSet(b, ctx, parts[2])
+		return c.budgetSet(b, ctx, strings.Join(parts[2:], " "))
 	case "delete", "remove", "clear":
-		return c.budgetDelete(b, ctx)
+		return c.budgetDelete(b, ctx, model.TransactionCategory(strings.Join(parts[2:], " ")))
+	case "period":
+		return c.budgetPeriod(b, ctx, strings.Join(parts[2:], " "))
+	case "rollover":
+		return c.budgetRollover(b, ctx, strings.Join(parts[2:], " "))
+	case "alerts":
+		if len(parts) == 4 && strings.EqualFold(parts[2], "email") {
+			return c.budgetAlertEmail(b, ctx, parts[3])
+		}
+		return c.budgetAlerts(b, ctx, strings.Join(parts[2:], " "))
 	default:
-		// Treat "/budget 400" as "/budget set 400"
-		return c.budgetSet(b, ctx, parts[1])
+		// Treat "/budget 400" as "/budget set 400", "/budget Food 100" likewise
+		return c.budgetSet(b, ctx, strings.Join(parts[1:], " "))
 	}
 }
 
@@ -254,22 +502,54 @@ func (c *Client) BudgetSetPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
 	keyboard := [][]gotgbot.InlineKeyboardButton{
 		{{Text: "Cancel", CallbackData: "budget.cancel"}},
 	}
-	return SendMessage(ctx, b, "Enter your monthly budget amount in € (e.g. <code>1500</code>):", keyboard)
+	text := fmt.Sprintf(
+		"Enter your monthly budget amount in %s (e.g. <code>1500</code>), "+
+			"or a category and its amount (e.g. <code>Grocery 300</code>):",
+		user.BaseCurrency.Symbol(),
+	)
+	return SendMessage(ctx, b, text, keyboard)
 }
 
 // BudgetSetFromMessage receives the amount typed by the user after BudgetSetPrompt.
-func (c *Client) BudgetSetFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User) error {
+func (c *Client) BudgetSetFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
 	user.Session.State = model.StateNormal
 	user.Session.Body = ""
 	if err := c.Repositories.Users.Update(&user); err != nil {
 		return fmt.Errorf("failed to reset user state: %w", err)
 	}
-	return c.budgetSet(b, ctx, strings.TrimSpace(ctx.Message.Text))
+	return c.budgetSet(b, ctx, strings.TrimSpace(text))
 }
 
-// BudgetDeleteCallback handles the inline-keyboard delete button.
+// BudgetDeleteCallback handles the inline-keyboard delete button of the
+// overall budget.
 func (c *Client) BudgetDeleteCallback(b *gotgbot.Bot, ctx *ext.Context) error {
-	return c.budgetDelete(b, ctx)
+	return c.budgetDelete(b, ctx, "")
+}
+
+// BudgetDeleteCategoryCallback handles the inline-keyboard delete button of a
+// category budget, budget.delete.<id>.
+func (c *Client) BudgetDeleteCategoryCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(ctx.CallbackQuery.Data, "budget.delete."), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid budget id: %w", err)
+	}
+
+	budgets, err := c.Repositories.Budgets.List(user.Scope())
+	if err != nil {
+		return err
+	}
+	for _, budget := range budgets {
+		if budget.ID == id && budget.IsCategory() {
+			return c.budgetDelete(b, ctx, budget.Category)
+		}
+	}
+	return c.SendHomeKeyboard(b, ctx, "No budget to remove.")
 }
 
 // BudgetCancel resets state and returns to home.
@@ -287,57 +567,225 @@ func (c *Client) BudgetCancel(b *gotgbot.Bot, ctx *ext.Context) error {
 	return c.SendHomeKeyboard(b, ctx, "Operation cancelled.")
 }
 
-func (c *Client) budgetSet(b *gotgbot.Bot, ctx *ext.Context, amountStr string) error {
+func (c *Client) budgetSet(b *gotgbot.Bot, ctx *ext.Context, input string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
 		return err
 	}
 
-	amountStr = strings.ReplaceAll(amountStr, ",", ".")
-	var amount float64
-	if _, err := fmt.Sscanf(amountStr, "%f", &amount); err != nil || amount <= 0 {
+	category, amount, ok := ParseBudgetInput(input)
+	if !ok {
 		_, sendErr := b.SendMessage(ctx.EffectiveSender.ChatId,
-			"Invalid amount. Please enter a positive number, e.g. <code>1500</code>.",
+			"Invalid amount. Please enter a positive number, e.g. <code>1500</code> or <code>Grocery 300</code>.",
 			&gotgbot.SendMessageOpts{ParseMode: "HTML"})
 		return sendErr
 	}
 
 	budget := model.Budget{
 		TgID:     user.TgID,
+		LedgerID: user.LedgerID,
+		Category: category,
 		Amount:   amount,
-		Currency: model.CurrencyEUR,
+		Currency: user.BaseCurrency,
 	}
 	if err := c.Repositories.Budgets.Upsert(&budget); err != nil {
+		if errors.Is(err, model.ErrLedgerForbidden) {
+			return c.SendHomeKeyboard(b, ctx, "❌ Only the owners of the ledger can change its budget.")
+		}
+		if errors.Is(err, model.ErrBudgetCategory) {
+			return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ <b>%s</b> is not one of your expense categories.", category))
+		}
 		return fmt.Errorf("failed to upsert budget: %w", err)
 	}
 
-	now := time.Now()
-	spent, err := c.Repositories.Budgets.TotalExpensesForMonth(user.TgID, now.Year(), int(now.Month()))
+	now := user.Today()
+	spending, err := c.Repositories.Budgets.Spending(user.Scope(), now)
 	if err != nil {
-		return fmt.Errorf("failed to compute month total: %w", err)
+		return fmt.Errorf("failed to compute period total: %w", err)
+	}
+	current := model.BudgetSpending{Budget: budget}
+	for _, s := range spending {
+		if s.Budget.Category == budget.Category {
+			current = s
+		}
 	}
-	pct := int(math.Floor(spent / amount * 100))
 
+	name := capitalize(budgetPeriodName(current.Budget.Period)) + " budget"
+	if budget.IsCategory() {
+		name = fmt.Sprintf("%s %s budget", capitalize(budgetPeriodName(current.Budget.Period)), budget.Category)
+	}
+	soFar := "This month so far"
+	if p := current.Budget.Period; p != "" && p != model.PeriodMonthly {
+		soFar = fmt.Sprintf("So far in %s", FormatBudgetWindow(current.Start, current.End))
+	}
 	text := fmt.Sprintf(
-		"✅ Monthly budget set to <b>%.2f €</b>.\n\nThis month so far: %.2f € (%d%%).",
-		amount, spent, pct,
+		"✅ %s set to <b>%.2f %s</b>.\n\n%s: %.2f %s (%d%%).",
+		name, amount, user.BaseCurrency.Symbol(), soFar, current.Spent, user.BaseCurrency.Symbol(), current.Pct(),
 	)
+	if current.Budget.HasRollover() {
+		text += fmt.Sprintf("\n🔁 Limit: %s %s", FormatBudgetLimit(current.Budget.Amount, current.Carry), user.BaseCurrency.Symbol())
+	}
 	return c.SendHomeKeyboard(b, ctx, text)
 }
 
-func (c *Client) budgetDelete(b *gotgbot.Bot, ctx *ext.Context) error {
+func (c *Client) budgetPeriod(b *gotgbot.Bot, ctx *ext.Context, input string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	in, ok := ParseBudgetPeriodInput(input)
+	if !ok {
+		_, sendErr := b.SendMessage(ctx.EffectiveSender.ChatId,
+			"Usage: <code>/budget period [category] weekly [monday]|monthly|quarterly|yearly</code>\n"+
+				"or <code>/budget period [category] &lt;from&gt; &lt;to&gt;</code>, e.g. <code>/budget period Travel 01-08-2026 21-08-2026</code>\n\n"+
+				"A weekly budget starts on Monday unless you name another day.",
+			&gotgbot.SendMessageOpts{ParseMode: "HTML"})
+		return sendErr
+	}
+
+	budget, err := c.Repositories.Budgets.SetPeriod(user.Scope(), in.Category, in.Period, in.WeekStart, in.StartDate, in.EndDate)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.SendHomeKeyboard(b, ctx, "No such budget, set it first with <code>/budget set [category] &lt;amount&gt;</code>.")
+		}
+		if errors.Is(err, model.ErrLedgerForbidden) {
+			return c.SendHomeKeyboard(b, ctx, "❌ Only the owners of the ledger can change its budget.")
+		}
+		if errors.Is(err, model.ErrInvalidBudget) {
+			return c.SendHomeKeyboard(b, ctx, "❌ "+capitalize(err.Error())+".")
+		}
+		return fmt.Errorf("failed to set budget period: %w", err)
+	}
+
+	name := "Overall budget"
+	if budget.IsCategory() {
+		name = fmt.Sprintf("%s budget", budget.Category)
+	}
+	return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("📅 %s: %s.", name, FormatBudgetPeriod(*budget)))
+}
+
+func (c *Client) budgetRollover(b *gotgbot.Bot, ctx *ext.Context, input string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
 		return err
 	}
 
-	if err := c.Repositories.Budgets.Delete(user.TgID); err != nil {
+	category, rollover, rolloverCap, ok := ParseBudgetRolloverInput(input)
+	if !ok {
+		_, sendErr := b.SendMessage(ctx.EffectiveSender.ChatId,
+			"Usage: <code>/budget rollover [category] positive|negative|both|off [cap]</code>\n\n"+
+				"<b>positive</b> carries the unspent money into the next month, <b>negative</b> the overspending, "+
+				"<b>both</b> either of them. The optional cap limits the amount carried.",
+			&gotgbot.SendMessageOpts{ParseMode: "HTML"})
+		return sendErr
+	}
+
+	budget, err := c.Repositories.Budgets.SetRollover(user.Scope(), category, rollover, rolloverCap)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.SendHomeKeyboard(b, ctx, "No such budget, set it first with <code>/budget set [category] &lt;amount&gt;</code>.")
+		}
+		if errors.Is(err, model.ErrLedgerForbidden) {
+			return c.SendHomeKeyboard(b, ctx, "❌ Only the owners of the ledger can change its budget.")
+		}
+		return fmt.Errorf("failed to set budget rollover: %w", err)
+	}
+
+	name := "Monthly budget"
+	if budget.IsCategory() {
+		name = fmt.Sprintf("%s budget", budget.Category)
+	}
+	return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("🔁 %s: %s.", name, FormatBudgetRollover(*budget)))
+}
+
+func (c *Client) budgetAlerts(b *gotgbot.Bot, ctx *ext.Context, input string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	category, thresholds, err := ParseBudgetAlertsInput(input)
+	if err != nil {
+		_, sendErr := b.SendMessage(ctx.EffectiveSender.ChatId,
+			fmt.Sprintf("%s.\n\nUsage: <code>/budget alerts [category] 50 75 90 100 120</code>\n\n"+
+				"You are alerted once a month when the spending reaches each percentage of the budget.",
+				capitalize(err.Error())),
+			&gotgbot.SendMessageOpts{ParseMode: "HTML"})
+		return sendErr
+	}
+
+	budget, err := c.Repositories.Budgets.SetThresholds(user.Scope(), category, thresholds)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.SendHomeKeyboard(b, ctx, "No such budget, set it first with <code>/budget set [category] &lt;amount&gt;</code>.")
+		}
+		if errors.Is(err, model.ErrLedgerForbidden) {
+			return c.SendHomeKeyboard(b, ctx, "❌ Only the owners of the ledger can change its budget.")
+		}
+		return fmt.Errorf("failed to set budget alert thresholds: %w", err)
+	}
+
+	name := "Monthly budget"
+	if budget.IsCategory() {
+		name = fmt.Sprintf("%s budget", budget.Category)
+	}
+	return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("🔔 %s: alerts at %s.", name, budget.Thresholds))
+}
+
+func (c *Client) budgetAlertEmail(b *gotgbot.Bot, ctx *ext.Context, value string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	var enabled bool
+	switch strings.ToLower(value) {
+	case "on":
+		enabled = true
+	case "off":
+	default:
+		_, err := b.SendMessage(ctx.EffectiveSender.ChatId,
+			"Usage: <code>/budget alerts email on|off</code>",
+			&gotgbot.SendMessageOpts{ParseMode: "HTML"})
+		return err
+	}
+
+	if err := c.Repositories.Users.SetBudgetAlertEmail(&user, enabled); err != nil {
+		if errors.Is(err, model.ErrNoEmail) {
+			return c.SendHomeKeyboard(b, ctx, "❌ There is no email registered to your account to email the budget alerts to.")
+		}
+		return err
+	}
+	if enabled {
+		return c.SendHomeKeyboard(b, ctx, "📧 Budget alerts will be emailed too.")
+	}
+	return c.SendHomeKeyboard(b, ctx, "🔕 Budget alerts will no longer be emailed.")
+}
+
+func (c *Client) budgetDelete(b *gotgbot.Bot, ctx *ext.Context, category model.TransactionCategory) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Repositories.Budgets.Delete(user.Scope(), category); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return c.SendHomeKeyboard(b, ctx, "No budget to remove.")
 		}
+		if errors.Is(err, model.ErrLedgerForbidden) {
+			return c.SendHomeKeyboard(b, ctx, "❌ Only the owners of the ledger can remove its budget.")
+		}
 		return fmt.Errorf("failed to delete budget: %w", err)
 	}
+	if category != "" {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("🗑 %s budget removed.", category))
+	}
 	return c.SendHomeKeyboard(b, ctx, "🗑 Budget removed.")
 }
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"cashout/internal/model"
+)
+
+func TestParseBudgetInput(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantCategory model.TransactionCategory
+		wantAmount   float64
+		wantOK       bool
+	}{
+		{name: "overall", input: "1500", wantAmount: 1500, wantOK: true},
+		{name: "category", input: "Grocery 300,50", wantCategory: "Grocery", wantAmount: 300.5, wantOK: true},
+		{name: "category with spaces", input: " Eating out  120 ", wantCategory: "Eating out", wantAmount: 120, wantOK: true},
+		{name: "missing amount", input: "Grocery", wantOK: false},
+		{name: "negative amount", input: "Grocery -5", wantOK: false},
+		{name: "empty", input: "  ", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, amount, ok := ParseBudgetInput(tt.input)
+			if ok != tt.wantOK || category != tt.wantCategory || amount != model.NewMoney(tt.wantAmount) {
+				t.Errorf("ParseBudgetInput(%q) = %q, %v, %v, want %q, %v, %v",
+					tt.input, category, amount, ok, tt.wantCategory, tt.wantAmount, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormatBudgetSuffix(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress []BudgetProgress
+		want     []string
+	}{
+		{
+			name: "overall approaching",
+			progress: []BudgetProgress{
+				{Limit: model.NewMoney(1000), Spent: model.NewMoney(850), Pct: 85, Currency: model.CurrencyEUR, NewAlerts: []int16{80}},
+			},
+			want: []string{
+				"\n\n📊 Budget: 850.00 / 1000.00 € (85%)",
+				"\n⚠️ Approaching monthly budget (80% used)",
+			},
+		},
+		{
+			name: "overall and category over",
+			progress: []BudgetProgress{
+				{Limit: model.NewMoney(1000), Spent: model.NewMoney(500), Pct: 50, Currency: model.CurrencyEUR},
+				{Category: "Grocery", Limit: model.NewMoney(200), Spent: model.NewMoney(230), Pct: 115, Currency: model.CurrencyEUR, NewAlerts: []int16{100}},
+			},
+			want: []string{
+				"📊 Budget: 500.00 / 1000.00 € (50%)\n📊 Grocery budget: 230.00 / 200.00 € (115%)",
+				"\n🚨 Over the Grocery budget by 30.00 €",
+			},
+		},
+		{
+			name: "custom thresholds",
+			progress: []BudgetProgress{
+				{Limit: model.NewMoney(1000), Spent: model.NewMoney(1250), Pct: 125, Currency: model.CurrencyEUR, NewAlerts: []int16{100, 120}},
+				{Category: "Grocery", Limit: model.NewMoney(200), Spent: model.NewMoney(110), Pct: 55, Currency: model.CurrencyEUR, NewAlerts: []int16{50}},
+			},
+			want: []string{
+				"\n🚨 Over budget by 250.00 €\n🚨 120% of the monthly budget reached",
+				"\n⚠️ Approaching the Grocery budget (50% used)",
+			},
+		},
+		{
+			name: "weekly",
+			progress: []BudgetProgress{
+				{
+					Category: "Eating out", Period: model.PeriodWeekly, Limit: model.NewMoney(100), Spent: model.NewMoney(85), Pct: 85, Currency: model.CurrencyEUR, NewAlerts: []int16{80},
+					Start: time.Date(2026, time.May, 18, 0, 0, 0, 0, time.UTC), End: time.Date(2026, time.May, 24, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			want: []string{
+				"📊 Eating out budget (18 May - 24 May 2026): 85.00 / 100.00 € (85%)",
+				"\n⚠️ Approaching the Eating out budget (80% used)",
+			},
+		},
+		{
+			name: "ledger expense against the shared and the personal budgets",
+			progress: []BudgetProgress{
+				{Category: "Grocery", Limit: model.NewMoney(400), Spent: model.NewMoney(120), Pct: 30, Currency: model.CurrencyEUR, Shared: true},
+				{Category: "Grocery", Limit: model.NewMoney(100), Spent: model.NewMoney(110), Pct: 110, Currency: model.CurrencyEUR, NewAlerts: []int16{100}},
+			},
+			want: []string{
+				"📊 Shared Grocery budget: 120.00 / 400.00 € (30%)\n📊 Grocery budget: 110.00 / 100.00 € (110%)",
+				"\n🚨 Over the Grocery budget by 10.00 €",
+			},
+		},
+		{
+			name: "rollover",
+			progress: []BudgetProgress{
+				{Base: model.NewMoney(1000), Carry: model.NewMoney(-150), Rollover: true, Limit: model.NewMoney(850), Spent: model.NewMoney(425), Pct: 50, Currency: model.CurrencyEUR},
+			},
+			want: []string{
+				"📊 Budget: 425.00 / 850.00 € (50%)\n🔁 Limit: 1000.00 - 150.00 carried = 850.00 €",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatBudgetSuffix(tt.progress)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("FormatBudgetSuffix() = %q, want it to contain %q", got, w)
+				}
+			}
+		})
+	}
+
+	if got := FormatBudgetSuffix(nil); got != "" {
+		t.Errorf("FormatBudgetSuffix(nil) = %q, want empty", got)
+	}
+}
+
+func TestParseBudgetRolloverInput(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantCategory model.TransactionCategory
+		wantRollover model.BudgetRollover
+		wantCap      float64
+		wantOK       bool
+	}{
+		{name: "overall", input: "both", wantRollover: model.RolloverBoth, wantOK: true},
+		{name: "overall with cap", input: "positive 200,50", wantRollover: model.RolloverPositive, wantCap: 200.5, wantOK: true},
+		{name: "category", input: "Eating out negative", wantCategory: "Eating out", wantRollover: model.RolloverNegative, wantOK: true},
+		{name: "category with cap", input: "Grocery both 100", wantCategory: "Grocery", wantRollover: model.RolloverBoth, wantCap: 100, wantOK: true},
+		{name: "off", input: "Grocery off", wantCategory: "Grocery", wantRollover: model.RolloverNone, wantOK: true},
+		{name: "missing mode", input: "Grocery 100", wantOK: false},
+		{name: "negative cap", input: "both -5", wantOK: false},
+		{name: "empty", input: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, rollover, rolloverCap, ok := ParseBudgetRolloverInput(tt.input)
+			if ok != tt.wantOK || category != tt.wantCategory || rollover != tt.wantRollover || rolloverCap != model.NewMoney(tt.wantCap) {
+				t.Errorf("ParseBudgetRolloverInput(%q) = %q, %q, %v, %v, want %q, %q, %v, %v",
+					tt.input, category, rollover, rolloverCap, ok, tt.wantCategory, tt.wantRollover, tt.wantCap, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseBudgetPeriodInput(t *testing.T) {
+	start := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, time.December, 24, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		input  string
+		want   BudgetPeriodInput
+		wantOK bool
+	}{
+		{name: "overall monthly", input: "monthly", want: BudgetPeriodInput{Period: model.PeriodMonthly, WeekStart: time.Monday}, wantOK: true},
+		{name: "weekly from Monday", input: "Grocery weekly", want: BudgetPeriodInput{Category: "Grocery", Period: model.PeriodWeekly, WeekStart: time.Monday}, wantOK: true},
+		{name: "weekly from Sunday", input: "Eating out weekly sunday", want: BudgetPeriodInput{Category: "Eating out", Period: model.PeriodWeekly, WeekStart: time.Sunday}, wantOK: true},
+		{name: "quarterly", input: "Travel Quarterly", want: BudgetPeriodInput{Category: "Travel", Period: model.PeriodQuarterly, WeekStart: time.Monday}, wantOK: true},
+		{name: "custom", input: "Gifts 01-12-2026 24-12-2026", want: BudgetPeriodInput{Category: "Gifts", Period: model.PeriodCustom, WeekStart: time.Monday, StartDate: &start, EndDate: &end}, wantOK: true},
+		{name: "custom without dates", input: "Gifts custom", wantOK: false},
+		{name: "unknown period", input: "Grocery daily", wantOK: false},
+		{name: "empty", input: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseBudgetPeriodInput(tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseBudgetPeriodInput(%q) ok = %v, want %v", tt.input, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Category != tt.want.Category || got.Period != tt.want.Period || got.WeekStart != tt.want.WeekStart {
+				t.Errorf("ParseBudgetPeriodInput(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if (got.StartDate == nil) != (tt.want.StartDate == nil) || (got.StartDate != nil && (!got.StartDate.Equal(*tt.want.StartDate) || !got.EndDate.Equal(*tt.want.EndDate))) {
+				t.Errorf("ParseBudgetPeriodInput(%q) dates = %v - %v, want %v - %v", tt.input, got.StartDate, got.EndDate, tt.want.StartDate, tt.want.EndDate)
+			}
+		})
+	}
+}
+
+func TestFormatBudgetLimit(t *testing.T) {
+	tests := []struct {
+		base, carry float64
+		want        string
+	}{
+		{base: 500, carry: 120.5, want: "500.00 + 120.50 carried = 620.50"},
+		{base: 500, carry: -80, want: "500.00 - 80.00 carried = 420.00"},
+		{base: 500, carry: 0, want: "500.00 + 0.00 carried = 500.00"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBudgetLimit(model.NewMoney(tt.base), model.NewMoney(tt.carry)); got != tt.want {
+			t.Errorf("FormatBudgetLimit(%v, %v) = %q, want %q", tt.base, tt.carry, got, tt.want)
+		}
+	}
+}
+
+func TestParseBudgetAlertsInput(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantCategory   model.TransactionCategory
+		wantThresholds model.BudgetThresholds
+		wantErr        bool
+	}{
+		{name: "overall", input: "50 75 90 100 120", wantThresholds: model.BudgetThresholds{50, 75, 90, 100, 120}},
+		{name: "category", input: "Eating out 100% 75%", wantCategory: "Eating out", wantThresholds: model.BudgetThresholds{75, 100}},
+		{name: "no thresholds", input: "Grocery", wantErr: true},
+		{name: "out of range", input: "Grocery 0 100", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, thresholds, err := ParseBudgetAlertsInput(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBudgetAlertsInput(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if category != tt.wantCategory || !slices.Equal(thresholds, tt.wantThresholds) {
+				t.Errorf("ParseBudgetAlertsInput(%q) = %q, %v, want %q, %v",
+					tt.input, category, thresholds, tt.wantCategory, tt.wantThresholds)
+			}
+		})
+	}
+}
+
+func TestBudgetAlerts(t *testing.T) {
+	tests := []struct {
+		name  string
+		spent float64
+		fired model.BudgetThresholds
+		want  []int16
+	}{
+		{name: "nothing fired", spent: 100},
+		{name: "highest below the limit", spent: 800, fired: model.BudgetThresholds{50, 75}, want: []int16{75}},
+		{name: "over without new alerts", spent: 1100, want: []int16{100}},
+		{name: "over supersedes the warnings", spent: 1300, fired: model.BudgetThresholds{75, 100, 120}, want: []int16{100, 120}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := model.BudgetEvaluation{
+				BudgetSpending: model.BudgetSpending{Budget: model.Budget{Amount: model.NewMoney(1000)}, Spent: model.NewMoney(tt.spent)},
+				Fired:          tt.fired,
+			}
+			if got := budgetAlerts(e); !slices.Equal(got, tt.want) {
+				t.Errorf("budgetAlerts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBudgetAlerts(t *testing.T) {
+	evaluations := []model.BudgetEvaluation{
+		{
+			BudgetSpending: model.BudgetSpending{Budget: model.Budget{Amount: model.NewMoney(1000), Currency: model.CurrencyEUR}, Spent: model.NewMoney(900)},
+		},
+		{
+			BudgetSpending: model.BudgetSpending{Budget: model.Budget{Category: "Grocery", Amount: model.NewMoney(200), Currency: model.CurrencyEUR}, Spent: model.NewMoney(250)},
+			Fired:          model.BudgetThresholds{100, 120},
+		},
+	}
+
+	want := "🔔 Budget alert\n\n🚨 120% of the Grocery budget reached: 250.00 / 200.00 € (125%)"
+	if got := FormatBudgetAlerts(evaluations); got != want {
+		t.Errorf("FormatBudgetAlerts() = %q, want %q", got, want)
+	}
+
+	ledgerID := int64(7)
+	evaluations[1].Budget.LedgerID = &ledgerID
+	want = "🔔 Budget alert\n\n🚨 120% of the shared Grocery budget reached: 250.00 / 200.00 € (125%)"
+	if got := FormatBudgetAlerts(evaluations); got != want {
+		t.Errorf("FormatBudgetAlerts() of a ledger budget = %q, want %q", got, want)
+	}
+	if got := FormatBudgetAlerts(evaluations[:1]); got != "" {
+		t.Errorf("FormatBudgetAlerts() without fired alerts = %q, want empty", got)
+	}
+}
@@ -0,0 +1,336 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// buildUserCategoryKeyboard renders BuildCategoryInlineKeyboard with the categories of the user in the context.
+func (c *Client) buildUserCategoryKeyboard(ctx *ext.Context, txType model.TransactionType, callbackPrefix, cancelCallback string, includeAll bool) ([][]gotgbot.InlineKeyboardButton, error) {
+	_, u := c.getUserFromContext(ctx)
+	categories, err := c.Repositories.Categories.List(u.Id)
+	if err != nil {
+		return nil, err
+	}
+	return BuildCategoryInlineKeyboard(categories, txType, callbackPrefix, cancelCallback, includeAll), nil
+}
+
+// ParseCategoryInput splits the text typed by the user into an optional
+// leading emoji and the category name, e.g. "👶 Kids" or just "Kids".
+func ParseCategoryInput(text string) (emoji, name string) {
+	text = strings.TrimSpace(text)
+	first, rest, found := strings.Cut(text, " ")
+	if !found {
+		return "", text
+	}
+
+	for _, r := range first {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return "", text
+		}
+	}
+	return first, strings.TrimSpace(rest)
+}
+
+// CategoriesCommand handles /categories.
+func (c *Client) CategoriesCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	return c.ShowCategories(b, ctx)
+}
+
+// ShowCategories renders the list of the user's categories with the actions to manage them.
+func (c *Client) ShowCategories(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	categories, err := c.Repositories.Categories.List(user.TgID)
+	if err != nil {
+		return err
+	}
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for i := 0; i < len(categories); i += 2 {
+		row := []gotgbot.InlineKeyboardButton{categoryButton(categories[i])}
+		if i+1 < len(categories) {
+			row = append(row, categoryButton(categories[i+1]))
+		}
+		keyboard = append(keyboard, row)
+	}
+	keyboard = append(keyboard,
+		[]gotgbot.InlineKeyboardButton{
+			{Text: "➕ Income Category", CallbackData: "categories.new." + string(model.TypeIncome)},
+			{Text: "➕ Expense Category", CallbackData: "categories.new." + string(model.TypeExpense)},
+		},
+		[]gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}},
+	)
+
+	text := "🗂 <b>Categories</b>\n\nSelect a category to rename, archive or delete it. Archived categories (📦) are kept for your existing transactions but cannot be picked for new ones."
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+func categoryButton(category model.Category) gotgbot.InlineKeyboardButton {
+	text := category.Label()
+	if category.Archived {
+		text = "📦 " + text
+	}
+	return gotgbot.InlineKeyboardButton{
+		Text:         text,
+		CallbackData: fmt.Sprintf("categories.show.%d", category.ID),
+	}
+}
+
+// CategoryDetails handles categories.show.<ID>.
+func (c *Client) CategoryDetails(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	category, err := c.getCallbackCategory(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return c.showCategoryDetails(b, ctx, category)
+}
+
+func (c *Client) showCategoryDetails(b *gotgbot.Bot, ctx *ext.Context, category model.Category) error {
+	archiveText := "📦 Archive"
+	status := "Active"
+	if category.Archived {
+		archiveText = "♻️ Restore"
+		status = "Archived"
+	}
+
+	id := strconv.FormatInt(category.ID, 10)
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{
+			{Text: "✏️ Rename", CallbackData: "categories.rename." + id},
+			{Text: archiveText, CallbackData: "categories.archive." + id},
+			{Text: "🗑 Delete", CallbackData: "categories.delete." + id},
+		},
+		{{Text: "⬅️ Back", CallbackData: "categories.list"}},
+	}
+
+	text := fmt.Sprintf(
+		"%s <b>%s</b>\n\nType: %s\nColour: %s\nStatus: %s",
+		category.Emoji, html.EscapeString(category.Name), category.Type, category.Color, status,
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// CategoryNewPrompt handles categories.new.<TYPE>, waiting for the name of the new category.
+func (c *Client) CategoryNewPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	txType := model.TransactionType(parts[len(parts)-1])
+	if txType != model.TypeIncome && txType != model.TypeExpense {
+		return fmt.Errorf("invalid category type: %s", txType)
+	}
+
+	user.Session.State = model.StateCategoryNewWaitName
+	user.Session.Body = string(txType)
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "categories.cancel"}},
+	}
+	text := fmt.Sprintf("Enter the name of the new %s category, optionally preceded by an emoji (e.g. <code>👶 Kids</code>):", strings.ToLower(string(txType)))
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// CategoryRenamePrompt handles categories.rename.<ID>, waiting for the new name.
+func (c *Client) CategoryRenamePrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	category, err := c.getCallbackCategory(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	user.Session.State = model.StateCategoryRenameWaitName
+	user.Session.Body = strconv.FormatInt(category.ID, 10)
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "categories.cancel"}},
+	}
+	text := fmt.Sprintf(
+		"Enter the new name for %s, optionally preceded by a new emoji (e.g. <code>👶 Kids</code>).\n\nYour existing transactions will be moved to the new name.",
+		html.EscapeString(category.Label()),
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// CategoryFromMessage receives the name typed after CategoryNewPrompt or CategoryRenamePrompt.
+func (c *Client) CategoryFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	state, body := user.Session.State, user.Session.Body
+
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	emoji, name := ParseCategoryInput(text)
+
+	var category model.Category
+	var err error
+	if state == model.StateCategoryNewWaitName {
+		category = model.Category{
+			TgID:  user.TgID,
+			Name:  name,
+			Type:  model.TransactionType(body),
+			Emoji: emoji,
+		}
+		err = c.Repositories.Categories.Create(&category)
+	} else {
+		id, perr := strconv.ParseInt(body, 10, 64)
+		if perr != nil {
+			return fmt.Errorf("invalid category ID in session: %w", perr)
+		}
+		category, err = c.Repositories.Categories.Get(user.TgID, id)
+		if err != nil {
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+		category.Name = name
+		if emoji != "" {
+			category.Emoji = emoji
+		}
+		err = c.Repositories.Categories.As(botActor(user)).Update(&category)
+	}
+
+	if errors.Is(err, model.ErrInvalidCategory) || errors.Is(err, model.ErrCategoryExists) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s, please try again.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save category: %w", err)
+	}
+
+	return c.showCategoryDetails(b, ctx, category)
+}
+
+// CategoryArchiveToggle handles categories.archive.<ID>, archiving or restoring the category.
+func (c *Client) CategoryArchiveToggle(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	category, err := c.getCallbackCategory(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	category.Archived = !category.Archived
+	if err := c.Repositories.Categories.As(botActor(user)).Update(&category); err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+
+	return c.showCategoryDetails(b, ctx, category)
+}
+
+// CategoryDelete handles categories.delete.<ID>, only unused categories can be deleted.
+func (c *Client) CategoryDelete(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	category, err := c.getCallbackCategory(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	err = c.Repositories.Categories.Delete(user.TgID, category.ID)
+	if errors.Is(err, model.ErrCategoryInUse) {
+		keyboard := [][]gotgbot.InlineKeyboardButton{
+			{{Text: "📦 Archive", CallbackData: fmt.Sprintf("categories.archive.%d", category.ID)}},
+			{{Text: "⬅️ Back", CallbackData: "categories.list"}},
+		}
+		text := fmt.Sprintf("%s is used by some of your transactions and cannot be deleted, you can archive it instead.", html.EscapeString(category.Label()))
+		return SendMessage(ctx, b, text, keyboard)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	return c.ShowCategories(b, ctx)
+}
+
+// CategoriesCancel resets state and returns to home.
+func (c *Client) CategoriesCancel(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+	return c.SendHomeKeyboard(b, ctx, "Operation cancelled.")
+}
+
+// getCallbackCategory returns the category whose ID is the last part of the callback data.
+func (c *Client) getCallbackCategory(ctx *ext.Context, user model.User) (model.Category, error) {
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return model.Category{}, fmt.Errorf("invalid category ID: %w", err)
+	}
+
+	category, err := c.Repositories.Categories.Get(user.TgID, id)
+	if err != nil {
+		return model.Category{}, fmt.Errorf("failed to get category: %w", err)
+	}
+	return category, nil
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// displayCategories returns the user's categories to render the emojis of the
+// recaps and of the transaction lists, which fall back to the default emoji
+// when they cannot be loaded.
+func (c *Client) displayCategories(tgID int64) model.Categories {
+	categories, err := c.Repositories.Categories.List(tgID)
+	if err != nil {
+		c.Logger.Warnf("failed to get categories for display: %v", err)
+	}
+	return categories
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"testing"
+
+	"cashout/internal/model"
+)
+
+func TestParseCategoryInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantEmoji string
+		wantName  string
+	}{
+		{name: "name only", input: "Kids", wantName: "Kids"},
+		{name: "emoji and name", input: "👶 Kids", wantEmoji: "👶", wantName: "Kids"},
+		{name: "surrounding spaces", input: "  👶   Kids  ", wantEmoji: "👶", wantName: "Kids"},
+		{name: "multi word name", input: "Car Insurance", wantName: "Car Insurance"},
+		{name: "emoji and multi word name", input: "🛡️ Car Insurance", wantEmoji: "🛡️", wantName: "Car Insurance"},
+		{name: "leading number is part of the name", input: "2nd Home", wantName: "2nd Home"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emoji, name := ParseCategoryInput(tt.input)
+			if emoji != tt.wantEmoji || name != tt.wantName {
+				t.Errorf("ParseCategoryInput(%q) = (%q, %q), want (%q, %q)", tt.input, emoji, name, tt.wantEmoji, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestBuildCategoryInlineKeyboard_UserCategories(t *testing.T) {
+	categories := model.Categories{
+		{Name: "Salary", Type: model.TypeIncome, Emoji: "💵"},
+		{Name: "Kids", Type: model.TypeExpense, Emoji: "👶"},
+		{Name: "Old", Type: model.TypeExpense, Emoji: "📦", Archived: true},
+		{Name: "Grocery", Type: model.TypeExpense, Emoji: "🛒"},
+	}
+
+	keyboard := BuildCategoryInlineKeyboard(categories, model.TypeExpense, "edit.setcat", "", false)
+
+	if len(keyboard) != 1 || len(keyboard[0]) != 2 {
+		t.Fatalf("expected a single row with 2 expense categories, got %v", keyboard)
+	}
+	if keyboard[0][0].Text != "👶 Kids" || keyboard[0][0].CallbackData != "edit.setcat.Kids" {
+		t.Errorf("unexpected first button: %+v", keyboard[0][0])
+	}
+	if keyboard[0][1].CallbackData != "edit.setcat.Grocery" {
+		t.Errorf("unexpected second button: %+v", keyboard[0][1])
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"strings"
 
 	"cashout/internal/ai"
+	"cashout/internal/blobstore"
 	"cashout/internal/db"
+	"cashout/internal/email"
 	"cashout/internal/repository"
 
 	"github.com/sirupsen/logrus"
@@ -23,18 +25,30 @@ type Config struct {
 type Client struct {
 	Logger       *logrus.Logger
 	Repositories Repositories
-	LLM          ai.LLM
+	LLM          *ai.LLM
 	Config       Config
+	// Mailer sends the budget alerts by email, nil when email is not configured
+	Mailer *email.EmailService
 }
 
 type Repositories struct {
-	Users        repository.Users
-	Transactions repository.Transactions
-	Reminders    repository.Reminders
-	Budgets      repository.Budgets
+	Users         repository.Users
+	Transactions  repository.Transactions
+	Reminders     repository.Reminders
+	Budgets       repository.Budgets
+	ExchangeRates repository.ExchangeRates
+	Categories    repository.Categories
+	Accounts      repository.Accounts
+	Recurring     repository.Recurring
+	Ledgers       repository.Ledgers
+	Settlements   repository.Settlements
+	Attachments   repository.Attachments
+	Goals         repository.Goals
+	Envelopes     repository.Envelopes
+	NetWorth      repository.NetWorth
 }
 
-func NewClient(logger *logrus.Logger, db *db.DB, llm ai.LLM) *Client {
+func NewClient(logger *logrus.Logger, db *db.DB, llm *ai.LLM) *Client {
 	config := Config{
 		AllowedUsers: make(map[string]struct{}),
 	}
@@ -53,16 +67,27 @@ func NewClient(logger *logrus.Logger, db *db.DB, llm ai.LLM) *Client {
 	repo := repository.Repository{
 		DB:     db,
 		Logger: logger,
+		Blobs:  blobstore.NewLocal(os.Getenv("ATTACHMENTS_DIR")),
 	}
 
 	return &Client{
 		Logger: logger,
 		Config: config,
 		Repositories: Repositories{
-			Users:        repository.Users{Repository: repo},
-			Transactions: repository.Transactions{Repository: repo},
-			Reminders:    repository.Reminders{Repository: repo},
-			Budgets:      repository.Budgets{Repository: repo},
+			Users:         repository.Users{Repository: repo},
+			Transactions:  repository.Transactions{Repository: repo},
+			Reminders:     repository.Reminders{Repository: repo},
+			Budgets:       repository.Budgets{Repository: repo},
+			ExchangeRates: repository.ExchangeRates{Repository: repo},
+			Categories:    repository.Categories{Repository: repo},
+			Accounts:      repository.Accounts{Repository: repo},
+			Recurring:     repository.Recurring{Repository: repo},
+			Ledgers:       repository.Ledgers{Repository: repo},
+			Settlements:   repository.Settlements{Repository: repo},
+			Attachments:   repository.Attachments{Repository: repo},
+			Goals:         repository.Goals{Repository: repo},
+			Envelopes:     repository.Envelopes{Repository: repo},
+			NetWorth:      repository.NetWorth{Repository: repo},
 		},
 		LLM: llm,
 	}
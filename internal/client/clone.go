@@ -4,10 +4,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"cashout/internal/model"
-	"cashout/internal/utils"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -91,7 +89,7 @@ func (c *Client) showRecentExpensesForClone(b *gotgbot.Bot, ctx *ext.Context, us
 	}
 
 	// Format transactions and build keyboard
-	msg := formatCloneRecentExpenses(transactions, offset, int(total))
+	msg := formatCloneRecentExpenses(transactions, c.displayCategories(user.TgID), offset, int(total))
 	keyboard := createCloneRecentKeyboard(transactions, offset, limit, int(total))
 
 	return SendMessage(ctx, b, msg, keyboard)
@@ -140,18 +138,25 @@ func (c *Client) cloneAndSaveTransaction(b *gotgbot.Bot, ctx *ext.Context, user
 		return SendMessage(ctx, b, "⚠️ This transaction doesn't belong to you.", keyboard)
 	}
 
-	// Create clone with today's date
+	// Create clone with today's date. The amount as entered is copied and is
+	// converted into the base currency at the current exchange rate on insert.
 	clone := model.Transaction{
-		TgID:        user.TgID,
-		Date:        time.Now(),
-		Type:        source.Type,
-		Category:    source.Category,
-		Amount:      source.Amount,
-		Currency:    source.Currency,
-		Description: source.Description,
-	}
-
-	err = c.Repositories.Transactions.Add(&clone)
+		TgID:             user.TgID,
+		Date:             user.Today(),
+		Type:             source.Type,
+		Category:         source.Category,
+		Amount:           source.Amount,
+		Currency:         source.Currency,
+		OriginalAmount:   source.OriginalAmount,
+		OriginalCurrency: source.OriginalCurrency,
+		Description:      source.Description,
+		Tags:             model.TagsFromNames(user.TgID, source.TagNames()),
+		Splits:           source.CopySplits(),
+		AccountID:        source.AccountID,
+		ToAccountID:      source.ToAccountID,
+	}
+
+	err = c.Repositories.Transactions.As(botActor(user)).Add(&clone)
 	if err != nil {
 		return fmt.Errorf("failed to save cloned transaction: %w", err)
 	}
@@ -169,8 +174,9 @@ func (c *Client) cloneAndSaveTransaction(b *gotgbot.Bot, ctx *ext.Context, user
 		emoji = "💸"
 	}
 
-	msg := fmt.Sprintf("%s <b>Transaction cloned!</b>\n\n%s (€ %.2f), %s on %s",
-		emoji, clone.Category, clone.Amount, clone.Description, clone.Date.Format("02-01-2006"))
+	msg := fmt.Sprintf("%s <b>Transaction cloned!</b>\n\n%s (%s), %s on %s",
+		emoji, clone.Category, FormatTransactionAmount(clone), clone.Description, clone.Date.Format("02-01-2006")) + FormatTransactionTags(clone) + FormatTransactionSplits(clone) +
+		FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, clone))
 
 	keyboard := [][]gotgbot.InlineKeyboardButton{
 		{{Text: "Edit description", CallbackData: "transactions.edit.description"}},
@@ -273,7 +279,10 @@ func (c *Client) showCloneSearchCategorySelection(b *gotgbot.Bot, ctx *ext.Conte
 		txType = model.TypeExpense
 	}
 
-	keyboard := BuildCategoryInlineKeyboard(txType, "clone.search.category", "clone.search.cancel", true)
+	keyboard, err := c.buildUserCategoryKeyboard(ctx, txType, "clone.search.category", "clone.search.cancel", true)
+	if err != nil {
+		return err
+	}
 	return SendMessage(ctx, b, "📋 <b>Clone Transaction</b>\n\nSelect a category to search in:", keyboard)
 }
 
@@ -304,7 +313,7 @@ func (c *Client) CloneSearchCategorySelected(b *gotgbot.Bot, ctx *ext.Context) e
 
 	categoryText := "all categories"
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := c.displayCategories(user.TgID).Emoji(model.TransactionCategory(category))
 		categoryText = fmt.Sprintf("%s %s", emoji, category)
 	}
 
@@ -317,14 +326,14 @@ func (c *Client) CloneSearchCategorySelected(b *gotgbot.Bot, ctx *ext.Context) e
 }
 
 // CloneSearchQueryEntered handles the free-text search query input
-func (c *Client) CloneSearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context) error {
+func (c *Client) CloneSearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context, text string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
 		return err
 	}
 
-	searchQuery := strings.TrimSpace(ctx.Message.Text)
+	searchQuery := strings.TrimSpace(text)
 	if searchQuery == "" {
 		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Search query cannot be empty. Please try again.", nil)
 		return err
@@ -359,10 +368,12 @@ func (c *Client) showCloneSearchResults(b *gotgbot.Bot, ctx *ext.Context, user m
 		return fmt.Errorf("failed to search transactions: %w", err)
 	}
 
+	categories := c.displayCategories(user.TgID)
+
 	if total == 0 {
 		message := fmt.Sprintf("🔍 No transactions found matching \"%s\"", searchQuery)
 		if category != "all" {
-			emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+			emoji := categories.Emoji(model.TransactionCategory(category))
 			message = fmt.Sprintf("🔍 No transactions found matching \"%s\" in %s %s", searchQuery, emoji, category)
 		}
 		keyboard := [][]gotgbot.InlineKeyboardButton{
@@ -374,7 +385,7 @@ func (c *Client) showCloneSearchResults(b *gotgbot.Bot, ctx *ext.Context, user m
 		return SendMessage(ctx, b, message, keyboard)
 	}
 
-	message := formatCloneSearchResults(transactions, searchQuery, category, offset, int(total))
+	message := formatCloneSearchResults(transactions, categories, searchQuery, category, offset, int(total))
 	keyboard := createCloneSearchKeyboard(transactions, category, searchQuery, offset, limit, int(total))
 
 	return SendMessage(ctx, b, message, keyboard)
@@ -487,13 +498,13 @@ func (c *Client) CloneSearchHome(b *gotgbot.Bot, ctx *ext.Context) error {
 // --- Extracted pure functions for formatting and keyboard building (testable) ---
 
 // formatCloneRecentExpenses formats the recent expenses list for the clone UI
-func formatCloneRecentExpenses(transactions []model.Transaction, offset, total int) string {
+func formatCloneRecentExpenses(transactions []model.Transaction, categories model.Categories, offset, total int) string {
 	var msg strings.Builder
 	msg.WriteString("📋 <b>Clone Transaction</b>\n")
 	fmt.Fprintf(&msg, "Recent expenses — %d–%d of %d\n\n", offset+1, offset+len(transactions), total)
 
 	for i, t := range transactions {
-		emoji := utils.GetCategoryEmoji(t.Category)
+		emoji := categories.Emoji(t.Category)
 		fmt.Fprintf(&msg, "%d. %s %s · €%.2f · %s\n",
 			i+1, emoji, t.Description, t.Amount, t.Date.Format("02/01/2006"))
 	}
@@ -558,23 +569,26 @@ func createCloneRecentKeyboard(transactions []model.Transaction, offset, limit,
 }
 
 // formatCloneSearchResults formats the search results for the clone UI
-func formatCloneSearchResults(transactions []model.Transaction, searchQuery, category string, offset, total int) string {
+func formatCloneSearchResults(transactions []model.Transaction, categories model.Categories, searchQuery, category string, offset, total int) string {
 	var msg strings.Builder
 	msg.WriteString("📋 <b>Clone Transaction</b>\n")
 	if searchQuery != "%" {
 		fmt.Fprintf(&msg, "Query: \"%s\"", searchQuery)
 	}
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := categories.Emoji(model.TransactionCategory(category))
 		fmt.Fprintf(&msg, " in %s %s", emoji, category)
 	}
 	fmt.Fprintf(&msg, "\nShowing %d–%d of %d\n\n", offset+1, offset+len(transactions), total)
 
 	for i, t := range transactions {
-		emoji := utils.GetCategoryEmoji(t.Category)
+		emoji := categories.Emoji(t.Category)
 		sign := "-"
-		if t.Type == model.TypeIncome {
+		switch t.Type {
+		case model.TypeIncome:
 			sign = "+"
+		case model.TypeTransfer:
+			sign = "↔"
 		}
 		desc := t.Description
 		if searchQuery != "%" {
@@ -12,7 +12,7 @@ func TestFormatCloneRecentExpenses_Basic(t *testing.T) {
 		{
 			ID:          1,
 			Description: "Coffee Shop",
-			Amount:      3.50,
+			Amount:      model.NewMoney(3.50),
 			Category:    model.CategoryEatingOut,
 			Type:        model.TypeExpense,
 			Date:        time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
@@ -20,14 +20,14 @@ func TestFormatCloneRecentExpenses_Basic(t *testing.T) {
 		{
 			ID:          2,
 			Description: "Lidl",
-			Amount:      45.00,
+			Amount:      model.NewMoney(45.00),
 			Category:    model.CategoryGrocery,
 			Type:        model.TypeExpense,
 			Date:        time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
-	result := formatCloneRecentExpenses(txns, 0, 2)
+	result := formatCloneRecentExpenses(txns, model.DefaultCategories(1), 0, 2)
 
 	if !strings.Contains(result, "📋 <b>Clone Transaction</b>") {
 		t.Error("missing header")
@@ -51,14 +51,14 @@ func TestFormatCloneRecentExpenses_Offset(t *testing.T) {
 		{
 			ID:          5,
 			Description: "Bus Ticket",
-			Amount:      2.00,
+			Amount:      model.NewMoney(2.00),
 			Category:    model.CategoryTransport,
 			Type:        model.TypeExpense,
 			Date:        time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
-	result := formatCloneRecentExpenses(txns, 10, 15)
+	result := formatCloneRecentExpenses(txns, model.DefaultCategories(1), 10, 15)
 	if !strings.Contains(result, "Recent expenses — 11–11 of 15") {
 		t.Errorf("offset counting wrong, got:\n%s", result)
 	}
@@ -195,14 +195,14 @@ func TestFormatCloneSearchResults_WithQuery(t *testing.T) {
 		{
 			ID:          1,
 			Description: "Coffee at Starbucks",
-			Amount:      4.50,
+			Amount:      model.NewMoney(4.50),
 			Category:    model.CategoryEatingOut,
 			Type:        model.TypeExpense,
 			Date:        time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
-	result := formatCloneSearchResults(txns, "coffee", "all", 0, 1)
+	result := formatCloneSearchResults(txns, model.DefaultCategories(1), "coffee", "all", 0, 1)
 
 	if !strings.Contains(result, "📋 <b>Clone Transaction</b>") {
 		t.Error("missing header")
@@ -223,14 +223,14 @@ func TestFormatCloneSearchResults_WithCategory(t *testing.T) {
 		{
 			ID:          1,
 			Description: "Lidl",
-			Amount:      30.00,
+			Amount:      model.NewMoney(30.00),
 			Category:    model.CategoryGrocery,
 			Type:        model.TypeExpense,
 			Date:        time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
-	result := formatCloneSearchResults(txns, "%", "Grocery", 0, 1)
+	result := formatCloneSearchResults(txns, model.DefaultCategories(1), "%", "Grocery", 0, 1)
 
 	if !strings.Contains(result, "🛒 Grocery") {
 		t.Errorf("missing category filter, got:\n%s", result)
@@ -246,14 +246,14 @@ func TestFormatCloneSearchResults_IncomeSign(t *testing.T) {
 		{
 			ID:          1,
 			Description: "March Salary",
-			Amount:      3000.00,
+			Amount:      model.NewMoney(3000.00),
 			Category:    model.CategorySalary,
 			Type:        model.TypeIncome,
 			Date:        time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
-	result := formatCloneSearchResults(txns, "%", "all", 0, 1)
+	result := formatCloneSearchResults(txns, model.DefaultCategories(1), "%", "all", 0, 1)
 
 	if !strings.Contains(result, "+€3000.00") {
 		t.Errorf("income should have + sign, got:\n%s", result)
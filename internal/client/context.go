@@ -14,6 +14,11 @@ func SetUserInContext(ctx context.Context, user *model.User) context.Context {
 	return context.WithValue(ctx, userContextKey, user)
 }
 
+// botActor is the actor of the changes made by the user through the bot
+func botActor(user model.User) model.Actor {
+	return model.Actor{TgID: user.TgID, Source: model.SourceBot}
+}
+
 // GetUserFromContext retrieves a user from the context
 func GetUserFromContext(ctx context.Context) *model.User {
 	user, ok := ctx.Value(userContextKey).(*model.User)
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// FormatTransactionAmount renders the amount in the base currency, preceded by
+// the amount as entered when the transaction was in a foreign currency.
+func FormatTransactionAmount(t model.Transaction) string {
+	base := fmt.Sprintf("%s %.2f", t.Currency.Symbol(), t.Amount)
+	if !t.IsForeign() {
+		return base
+	}
+	return fmt.Sprintf("%s %.2f ≈ %s", t.OriginalCurrency.Symbol(), t.OriginalAmount, base)
+}
+
+// CurrencyCommand handles /currency: with an argument (e.g. "/currency USD") it
+// sets the base currency directly, otherwise it shows the currency selector.
+func (c *Client) CurrencyCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	if ctx.Message != nil {
+		parts := strings.Fields(ctx.Message.Text)
+		if len(parts) > 1 {
+			return c.setBaseCurrency(b, ctx, strings.ToUpper(parts[1]))
+		}
+	}
+	return c.ShowCurrency(b, ctx)
+}
+
+// ShowCurrency renders the current base currency and the selector to change it.
+func (c *Client) ShowCurrency(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	var row []gotgbot.InlineKeyboardButton
+	for _, cur := range model.GetCurrencyTypes() {
+		text := cur
+		if model.CurrencyType(cur) == user.BaseCurrency {
+			text = "✅ " + cur
+		}
+		row = append(row, gotgbot.InlineKeyboardButton{Text: text, CallbackData: "currency.set." + cur})
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		row,
+		{{Text: "🏠 Home", CallbackData: "transactions.home"}},
+	}
+
+	text := fmt.Sprintf(
+		"💱 <b>Base Currency</b>\n\nYour totals, recaps and budget are in <b>%s</b>.\n\nTransactions in other currencies are converted with the latest exchange rates. Changing it converts all your transactions, budgets, goals and envelopes.",
+		user.BaseCurrency,
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// CurrencySelected handles the currency.set.<CODE> callback.
+func (c *Client) CurrencySelected(b *gotgbot.Bot, ctx *ext.Context) error {
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	return c.setBaseCurrency(b, ctx, parts[len(parts)-1])
+}
+
+func (c *Client) setBaseCurrency(b *gotgbot.Bot, ctx *ext.Context, currency string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	if !model.IsValidCurrency(currency) {
+		text := fmt.Sprintf("Invalid currency. Use one of: %s.", strings.Join(model.GetCurrencyTypes(), ", "))
+		return c.SendHomeKeyboard(b, ctx, text)
+	}
+
+	if model.CurrencyType(currency) == user.BaseCurrency {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("Your base currency is already <b>%s</b>.", currency))
+	}
+
+	err = c.Repositories.Users.SetBaseCurrency(user.TgID, model.CurrencyType(currency))
+	if err != nil {
+		if errors.Is(err, model.ErrExchangeRateNotFound) {
+			c.Logger.Warnf("failed to change base currency: %v", err)
+			return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("Exchange rates to <b>%s</b> are not available yet, your base currency has not been changed.", currency))
+		}
+		if errors.Is(err, model.ErrInvalidSplit) {
+			c.Logger.Warnf("failed to change base currency: %v", err)
+			return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("A split transaction has a line too small to be converted to <b>%s</b>, change its split with /edit before changing your base currency.", currency))
+		}
+		if errors.Is(err, model.ErrLedgerCurrency) {
+			return c.SendHomeKeyboard(b, ctx, "Your base currency must match the currency of your shared ledgers, leave them with /ledger before changing it.")
+		}
+		if errors.Is(err, model.ErrOpenBalances) {
+			return c.SendHomeKeyboard(b, ctx, "You have balances to settle with other users in your base currency, settle them with /settle before changing it.")
+		}
+		return fmt.Errorf("failed to set base currency: %w", err)
+	}
+
+	return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("✅ Base currency set to <b>%s</b>, your transactions, budgets, goals and envelopes have been converted.", currency))
+}
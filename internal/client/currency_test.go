@@ -0,0 +1,40 @@
+package client
+
+import (
+	"testing"
+
+	"cashout/internal/model"
+)
+
+func TestFormatTransactionAmount(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   model.Transaction
+		want string
+	}{
+		{
+			name: "base currency",
+			tx: model.Transaction{
+				Amount: model.NewMoney(12.5), Currency: model.CurrencyEUR,
+				OriginalAmount: model.NewMoney(12.5), OriginalCurrency: model.CurrencyEUR,
+			},
+			want: "€ 12.50",
+		},
+		{
+			name: "foreign currency",
+			tx: model.Transaction{
+				Amount: model.NewMoney(31.48), Currency: model.CurrencyEUR,
+				OriginalAmount: model.NewMoney(34), OriginalCurrency: model.CurrencyUSD,
+			},
+			want: "$ 34.00 ≈ € 31.48",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTransactionAmount(tt.tx); got != tt.want {
+				t.Errorf("FormatTransactionAmount() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
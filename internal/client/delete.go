@@ -2,7 +2,6 @@ package client
 
 import (
 	"cashout/internal/model"
-	"cashout/internal/utils"
 	"fmt"
 	"strconv"
 	"strings"
@@ -93,7 +92,7 @@ func (c *Client) ShowDeleteConfirmation(b *gotgbot.Bot, ctx *ext.Context) error
 	}
 
 	// Format transaction details for confirmation message
-	emoji := utils.GetCategoryEmoji(transaction.Category)
+	emoji := c.displayCategories(user.TgID).Emoji(transaction.Category)
 	message := fmt.Sprintf(
 		"Are you sure you want to delete this transaction?\n\n<b>%s</b> - %.2f€\n%s %s\n📅 %s",
 		transaction.Description,
@@ -168,7 +167,7 @@ func (c *Client) DeleteTransactionConfirm(b *gotgbot.Bot, ctx *ext.Context) erro
 	}
 
 	// Delete the transaction
-	err = c.Repositories.Transactions.Delete(transactionID, user.TgID)
+	err = c.Repositories.Transactions.As(botActor(user)).Delete(transactionID, user.TgID)
 	if err != nil {
 		return fmt.Errorf("failed to delete transaction: %w", err)
 	}
@@ -179,7 +178,7 @@ func (c *Client) DeleteTransactionConfirm(b *gotgbot.Bot, ctx *ext.Context) erro
 		emoji = "💸"
 	}
 
-	text := fmt.Sprintf("%s Transaction deleted successfully!\n\n%s: %s - %.2f€ (%s)",
+	text := fmt.Sprintf("%s Transaction moved to the trash!\n\n%s: %s - %.2f€ (%s)",
 		emoji,
 		transaction.Category,
 		transaction.Description,
@@ -197,6 +196,7 @@ func (c *Client) DeleteTransactionConfirm(b *gotgbot.Bot, ctx *ext.Context) erro
 			ParseMode: "HTML",
 			ReplyMarkup: gotgbot.InlineKeyboardMarkup{
 				InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+					{undoButton(transaction.ID)},
 					{
 						{
 							Text:         "Delete Another",
@@ -239,7 +239,7 @@ func (c *Client) showDeletableTransactionPage(b *gotgbot.Bot, ctx *ext.Context,
 	}
 
 	// Format transactions
-	message := formatDeletableTransactions(transactions, offset, int(total))
+	message := formatDeletableTransactions(transactions, c.displayCategories(user.TgID), offset, int(total))
 
 	// Create pagination keyboard with numbered buttons for deletion
 	keyboard := createDeletionPaginationKeyboard(transactions, offset, limit, int(total))
@@ -265,16 +265,19 @@ func (c *Client) showDeletableTransactionPage(b *gotgbot.Bot, ctx *ext.Context,
 }
 
 // formatDeletableTransactions formats the transactions for display in the deletion interface
-func formatDeletableTransactions(transactions []model.Transaction, offset, total int) string {
+func formatDeletableTransactions(transactions []model.Transaction, categories model.Categories, offset, total int) string {
 	var msg strings.Builder
 	msg.WriteString("<b>🗑 Delete Transaction</b>\n")
 	fmt.Fprintf(&msg, "Showing %d–%d of %d\n\n", offset+1, offset+len(transactions), total)
 
 	for i, t := range transactions {
-		emoji := utils.GetCategoryEmoji(t.Category)
+		emoji := categories.Emoji(t.Category)
 		sign := "-"
-		if t.Type == model.TypeIncome {
+		switch t.Type {
+		case model.TypeIncome:
 			sign = "+"
+		case model.TypeTransfer:
+			sign = "↔"
 		}
 		fmt.Fprintf(&msg, "%d. %s %s · %s€%.2f · %s\n",
 			i+1, emoji, t.Description, sign, t.Amount, t.Date.Format("02/01/2006"))
@@ -337,7 +340,10 @@ func createDeletionPaginationKeyboard(transactions []model.Transaction, offset,
 
 // showDeleteSearchCategorySelection displays the category selection keyboard for delete search
 func (c *Client) showDeleteSearchCategorySelection(b *gotgbot.Bot, ctx *ext.Context) error {
-	keyboard := BuildCategoryInlineKeyboard("", "delete.search.category", "delete.search.cancel", true)
+	keyboard, err := c.buildUserCategoryKeyboard(ctx, "", "delete.search.category", "delete.search.cancel", true)
+	if err != nil {
+		return err
+	}
 
 	message := "🗑️ <b>Delete Transaction</b>\n\nFirst, select a category to search in:"
 
@@ -390,7 +396,7 @@ func (c *Client) DeleteSearchCategorySelected(b *gotgbot.Bot, ctx *ext.Context)
 	// Ask for search query
 	categoryText := "all categories"
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := c.displayCategories(user.TgID).Emoji(model.TransactionCategory(category))
 		categoryText = fmt.Sprintf("%s %s", emoji, category)
 	}
 
@@ -422,7 +428,7 @@ func (c *Client) DeleteSearchCategorySelected(b *gotgbot.Bot, ctx *ext.Context)
 }
 
 // DeleteSearchQueryEntered handles the search query input for delete
-func (c *Client) DeleteSearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context) error {
+func (c *Client) DeleteSearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context, text string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
@@ -430,7 +436,7 @@ func (c *Client) DeleteSearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context) erro
 	}
 
 	// Get search query
-	searchQuery := strings.TrimSpace(ctx.Message.Text)
+	searchQuery := strings.TrimSpace(text)
 	if searchQuery == "" {
 		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Search query cannot be empty. Please try again.", nil)
 		return err
@@ -482,10 +488,12 @@ func (c *Client) showDeleteSearchResults(b *gotgbot.Bot, ctx *ext.Context, user
 		return fmt.Errorf("failed to search transactions: %w", err)
 	}
 
+	categories := c.displayCategories(user.TgID)
+
 	if total == 0 {
 		message := fmt.Sprintf("🔍 No transactions found matching \"%s\"", searchQuery)
 		if category != "all" {
-			emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+			emoji := categories.Emoji(model.TransactionCategory(category))
 			message = fmt.Sprintf("🔍 No transactions found matching \"%s\" in %s %s", searchQuery, emoji, category)
 		}
 
@@ -528,7 +536,7 @@ func (c *Client) showDeleteSearchResults(b *gotgbot.Bot, ctx *ext.Context, user
 	}
 
 	// Format delete search results (similar to original delete page format)
-	message := formatDeleteSearchResults(transactions, searchQuery, category, offset, int(total))
+	message := formatDeleteSearchResults(transactions, categories, searchQuery, category, offset, int(total))
 
 	// Create pagination keyboard with numbered buttons for deleting
 	keyboard := createDeleteSearchPaginationKeyboard(transactions, category, searchQuery, offset, limit, int(total))
@@ -554,7 +562,7 @@ func (c *Client) showDeleteSearchResults(b *gotgbot.Bot, ctx *ext.Context, user
 }
 
 // formatDeleteSearchResults formats the search results for delete display
-func formatDeleteSearchResults(transactions []model.Transaction, searchQuery, category string, offset, total int) string {
+func formatDeleteSearchResults(transactions []model.Transaction, categories model.Categories, searchQuery, category string, offset, total int) string {
 	var msg strings.Builder
 
 	msg.WriteString("🗑️ <b>Delete Transaction</b>\n")
@@ -564,17 +572,20 @@ func formatDeleteSearchResults(transactions []model.Transaction, searchQuery, ca
 	}
 
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := categories.Emoji(model.TransactionCategory(category))
 		fmt.Fprintf(&msg, " in %s %s", emoji, category)
 	}
 
 	fmt.Fprintf(&msg, "\nShowing %d–%d of %d\n\n", offset+1, offset+len(transactions), total)
 
 	for i, t := range transactions {
-		emoji := utils.GetCategoryEmoji(t.Category)
+		emoji := categories.Emoji(t.Category)
 		sign := "-"
-		if t.Type == model.TypeIncome {
+		switch t.Type {
+		case model.TypeIncome:
 			sign = "+"
+		case model.TypeTransfer:
+			sign = "↔"
 		}
 
 		desc := t.Description
@@ -708,7 +719,7 @@ func (c *Client) DeleteSearchTransactionSelected(b *gotgbot.Bot, ctx *ext.Contex
 	}
 
 	// Format transaction details for confirmation message
-	emoji := utils.GetCategoryEmoji(transaction.Category)
+	emoji := c.displayCategories(user.TgID).Emoji(transaction.Category)
 	message := fmt.Sprintf(
 		"Are you sure you want to delete this transaction?\n\n<b>%s</b> - %.2f€\n%s %s\n📅 %s",
 		transaction.Description,
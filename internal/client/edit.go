@@ -1,10 +1,10 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"cashout/internal/model"
 	"cashout/internal/utils"
@@ -95,6 +95,7 @@ func (c *Client) EditTransactionSelect(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	// Store transaction in session for later use
+	user.Session.State = model.StateEditingTransaction
 	user.Session.Body = fmt.Sprintf("%d", transactionID)
 	err = c.Repositories.Users.Update(&user)
 	if err != nil {
@@ -179,15 +180,27 @@ func (c *Client) EditTransactionField(b *gotgbot.Bot, ctx *ext.Context) error {
 		return c.editTopLevelTransactionAmount(b, ctx, transaction)
 	case "date":
 		return c.editTopLevelTransactionDate(b, ctx, transaction)
+	case "split":
+		return c.editTopLevelTransactionSplit(b, ctx, transaction)
 	default:
 		return fmt.Errorf("invalid field: %s", field)
 	}
 }
 
 func (c *Client) editTopLevelTransactionCategory(b *gotgbot.Bot, ctx *ext.Context, transaction model.Transaction) error {
-	keyboard := BuildCategoryInlineKeyboard(transaction.Type, "edit.setcat", "transactions.cancel", false)
+	if transaction.IsTransfer() {
+		return c.SendHomeKeyboard(b, ctx, "Transfers between accounts have no category.")
+	}
+	if transaction.IsSplit() {
+		return c.sendSplitOnlyNotice(b, ctx, transaction, "category")
+	}
 
-	_, _, err := ctx.CallbackQuery.Message.EditText(
+	keyboard, err := c.buildUserCategoryKeyboard(ctx, transaction.Type, "edit.setcat", "transactions.cancel", false)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = ctx.CallbackQuery.Message.EditText(
 		b,
 		fmt.Sprintf("Select a new category for the transaction:\n\nCurrent: <b>%s</b> - %.2f€ (%s)",
 			transaction.Category,
@@ -217,10 +230,6 @@ func (c *Client) EditTransactionCategoryConfirm(b *gotgbot.Bot, ctx *ext.Context
 	}
 	newCategory := parts[2]
 
-	if !model.IsValidTransactionCategory(newCategory) {
-		return fmt.Errorf("invalid category: %s", newCategory)
-	}
-
 	transactionID, err := strconv.ParseInt(user.Session.Body, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid transaction ID in session: %v", err)
@@ -231,16 +240,18 @@ func (c *Client) EditTransactionCategoryConfirm(b *gotgbot.Bot, ctx *ext.Context
 		return fmt.Errorf("failed to get transaction: %w", err)
 	}
 
-	// Disallow swap between income/expense categories.
-	isIncome := transaction.Type == model.TypeIncome
-	isIncomeCategory := newCategory == string(model.CategorySalary) || newCategory == string(model.CategoryOtherIncomes)
-	if isIncome != isIncomeCategory {
-		return fmt.Errorf("cannot change between income and expense categories")
+	// Only the active categories of the same type are allowed, no swap between income/expense categories.
+	categories, err := c.Repositories.Categories.Active(user.TgID, transaction.Type)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	if _, ok := categories.Find(newCategory); !ok {
+		return fmt.Errorf("invalid category for a %s transaction: %s", transaction.Type, newCategory)
 	}
 
 	oldCategory := transaction.Category
 	transaction.Category = model.TransactionCategory(newCategory)
-	if err := c.Repositories.Transactions.Update(&transaction); err != nil {
+	if err := c.Repositories.Transactions.As(botActor(user)).Update(&transaction); err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
 
@@ -258,7 +269,7 @@ func (c *Client) EditTransactionCategoryConfirm(b *gotgbot.Bot, ctx *ext.Context
 	_, _, err = query.Message.EditText(
 		b,
 		fmt.Sprintf("%s Category updated successfully!\n\nChanged from <b>%s</b> to <b>%s</b>",
-			emoji, oldCategory, transaction.Category),
+			emoji, oldCategory, transaction.Category)+FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction)),
 		&gotgbot.EditMessageTextOpts{
 			ParseMode: "HTML",
 			ReplyMarkup: gotgbot.InlineKeyboardMarkup{
@@ -312,6 +323,10 @@ func (c *Client) editTopLevelTransactionDescription(b *gotgbot.Bot, ctx *ext.Con
 }
 
 func (c *Client) editTopLevelTransactionAmount(b *gotgbot.Bot, ctx *ext.Context, transaction model.Transaction) error {
+	if transaction.IsSplit() {
+		return c.sendSplitOnlyNotice(b, ctx, transaction, "amount")
+	}
+
 	// Set user state
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
@@ -350,7 +365,7 @@ func (c *Client) editTopLevelTransactionAmount(b *gotgbot.Bot, ctx *ext.Context,
 	return err
 }
 
-func (c *Client) EditTransactionDescriptionConfirm(b *gotgbot.Bot, ctx *ext.Context) error {
+func (c *Client) EditTransactionDescriptionConfirm(b *gotgbot.Bot, ctx *ext.Context, text string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
@@ -370,7 +385,7 @@ func (c *Client) EditTransactionDescriptionConfirm(b *gotgbot.Bot, ctx *ext.Cont
 	}
 
 	oldDescription := transaction.Description
-	transaction.Description = strings.TrimSpace(ctx.Message.Text)
+	transaction.Description = strings.TrimSpace(text)
 	if transaction.Description == "" {
 		_, err = b.SendMessage(
 			ctx.EffectiveSender.ChatId,
@@ -380,7 +395,7 @@ func (c *Client) EditTransactionDescriptionConfirm(b *gotgbot.Bot, ctx *ext.Cont
 		return err
 	}
 
-	err = c.Repositories.Transactions.Update(&transaction)
+	err = c.Repositories.Transactions.As(botActor(user)).Update(&transaction)
 	if err != nil {
 		_, err = b.SendMessage(
 			ctx.EffectiveSender.ChatId,
@@ -430,7 +445,7 @@ func (c *Client) EditTransactionDescriptionConfirm(b *gotgbot.Bot, ctx *ext.Cont
 	return err
 }
 
-func (c *Client) EditTransactionAmountConfirm(b *gotgbot.Bot, ctx *ext.Context) error {
+func (c *Client) EditTransactionAmountConfirm(b *gotgbot.Bot, ctx *ext.Context, text string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
@@ -450,9 +465,9 @@ func (c *Client) EditTransactionAmountConfirm(b *gotgbot.Bot, ctx *ext.Context)
 	}
 
 	// Parse new amount from message
-	amountStr := strings.TrimSpace(ctx.Message.Text)
+	amountStr := strings.TrimSpace(text)
 	amountStr = strings.ReplaceAll(amountStr, ",", ".")
-	newAmount, err := strconv.ParseFloat(amountStr, 64)
+	newAmount, err := model.ParseMoney(amountStr)
 	if err != nil {
 		_, err = b.SendMessage(
 			ctx.EffectiveSender.ChatId,
@@ -473,9 +488,9 @@ func (c *Client) EditTransactionAmountConfirm(b *gotgbot.Bot, ctx *ext.Context)
 
 	// Update the transaction
 	oldAmount := transaction.Amount
-	transaction.Amount = newAmount
+	transaction.SetBaseAmount(newAmount)
 
-	err = c.Repositories.Transactions.Update(&transaction)
+	err = c.Repositories.Transactions.As(botActor(user)).Update(&transaction)
 	if err != nil {
 		_, err = b.SendMessage(
 			ctx.EffectiveSender.ChatId,
@@ -499,14 +514,14 @@ func (c *Client) EditTransactionAmountConfirm(b *gotgbot.Bot, ctx *ext.Context)
 		emoji = "💸"
 	}
 
-	text := fmt.Sprintf("%s Amount updated successfully!\n\nChanged from <b>%.2f€</b> to <b>%.2f€</b>",
+	reply := fmt.Sprintf("%s Amount updated successfully!\n\nChanged from <b>%.2f€</b> to <b>%.2f€</b>",
 		emoji, oldAmount, transaction.Amount)
 	if transaction.Type == model.TypeExpense {
-		text += c.BudgetSuffixForTx(transaction)
+		reply += FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction))
 	}
 	_, err = b.SendMessage(
 		ctx.EffectiveSender.ChatId,
-		text,
+		reply,
 		&gotgbot.SendMessageOpts{
 			ParseMode: "HTML",
 			ReplyMarkup: gotgbot.InlineKeyboardMarkup{
@@ -529,6 +544,157 @@ func (c *Client) EditTransactionAmountConfirm(b *gotgbot.Bot, ctx *ext.Context)
 	return err
 }
 
+// editTopLevelTransactionSplit prompts for the split lines of the transaction
+func (c *Client) editTopLevelTransactionSplit(b *gotgbot.Bot, ctx *ext.Context, transaction model.Transaction) error {
+	if transaction.IsTransfer() {
+		return c.SendHomeKeyboard(b, ctx, "Transfers between accounts cannot be split.")
+	}
+
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	user.Session.State = model.StateTopLevelEditingTransactionSplit
+	err = c.Repositories.Users.Update(&user)
+	if err != nil {
+		return fmt.Errorf("failed to update user data: %w", err)
+	}
+
+	message := fmt.Sprintf(
+		"✂️ Split the transaction across several categories, one line each as <code>category amount [description]</code>. The amounts must sum to <b>%s %.2f</b>, e.g.\n\n<code>Grocery 30\nToiletry 12.50 shampoo</code>\n",
+		transaction.OriginalCurrency.Symbol(), transaction.OriginalAmount,
+	)
+	message += FormatTransactionSplits(transaction)
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{}
+	if transaction.IsSplit() {
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🧩 Remove Split", CallbackData: "edit.unsplit"}})
+	}
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "Cancel", CallbackData: "transactions.cancel"}})
+
+	_, _, err = ctx.CallbackQuery.Message.EditText(b, message, &gotgbot.EditMessageTextOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	})
+	return err
+}
+
+// EditTransactionSplitConfirm receives the split lines typed after editTopLevelTransactionSplit
+func (c *Client) EditTransactionSplitConfirm(b *gotgbot.Bot, ctx *ext.Context, text string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	// Get transaction ID from session
+	transactionID, err := strconv.ParseInt(user.Session.Body, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID in session: %v", err)
+	}
+
+	transaction, err := c.Repositories.Transactions.GetByID(transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	categories, err := c.Repositories.Categories.List(user.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	lines, err := ParseSplitLines(text, categories)
+	if err != nil {
+		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, capitalize(err.Error())+", please try again.", nil)
+		return err
+	}
+
+	err = c.Repositories.Transactions.As(botActor(user)).SetSplits(&transaction, lines)
+	if errors.Is(err, model.ErrInvalidSplit) {
+		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, capitalize(err.Error())+", please try again.", nil)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to split transaction: %w", err)
+	}
+
+	// Reset user state
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	err = c.Repositories.Users.Update(&user)
+	if err != nil {
+		return fmt.Errorf("failed to update user data: %w", err)
+	}
+
+	_, err = b.SendMessage(
+		ctx.EffectiveSender.ChatId,
+		"✂️ Transaction split successfully!\n"+FormatTransactionSplits(transaction)+
+			FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction)),
+		&gotgbot.SendMessageOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: editDoneKeyboard(transaction.ID)},
+		},
+	)
+	return err
+}
+
+// EditTransactionUnsplit handles edit.unsplit, the transaction keeps the category of its largest line
+func (c *Client) EditTransactionUnsplit(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	transactionID, err := strconv.ParseInt(user.Session.Body, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID in session: %v", err)
+	}
+
+	transaction, err := c.Repositories.Transactions.GetByID(transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction.TgID != user.TgID {
+		return fmt.Errorf("transaction %d doesn't belong to user %d", transaction.ID, user.TgID)
+	}
+
+	if err := c.Repositories.Transactions.As(botActor(user)).SetSplits(&transaction, nil); err != nil {
+		return fmt.Errorf("failed to remove transaction split: %w", err)
+	}
+
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user data: %w", err)
+	}
+
+	text := fmt.Sprintf("🧩 Split removed, the whole transaction is now in <b>%s</b>.", transaction.Category)
+	return SendMessage(ctx, b, text, editDoneKeyboard(transaction.ID))
+}
+
+// sendSplitOnlyNotice explains that the given field of a split transaction changes with its lines
+func (c *Client) sendSplitOnlyNotice(b *gotgbot.Bot, ctx *ext.Context, transaction model.Transaction, field string) error {
+	text := fmt.Sprintf("✂️ This transaction is split across several categories, change its split to edit the %s.", field)
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "✂️ Edit Split", CallbackData: "edit.field.split"}},
+		{{Text: "Back", CallbackData: fmt.Sprintf("edit.select.%d", transaction.ID)}},
+	}
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// editDoneKeyboard offers to keep editing the transaction or to stop
+func editDoneKeyboard(transactionID int64) [][]gotgbot.InlineKeyboardButton {
+	return [][]gotgbot.InlineKeyboardButton{
+		{
+			{Text: "Keep Editing", CallbackData: fmt.Sprintf("edit.select.%d", transactionID)},
+			{Text: "Done", CallbackData: "edit.done"},
+		},
+	}
+}
+
 // editTransactionDate prompts for a new date
 func (c *Client) editTopLevelTransactionDate(b *gotgbot.Bot, ctx *ext.Context, transaction model.Transaction) error {
 	// Set user state
@@ -569,7 +735,7 @@ func (c *Client) editTopLevelTransactionDate(b *gotgbot.Bot, ctx *ext.Context, t
 	return err
 }
 
-func (c *Client) EditTransactionDateConfirm(b *gotgbot.Bot, ctx *ext.Context) error {
+func (c *Client) EditTransactionDateConfirm(b *gotgbot.Bot, ctx *ext.Context, text string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
@@ -589,26 +755,26 @@ func (c *Client) EditTransactionDateConfirm(b *gotgbot.Bot, ctx *ext.Context) er
 	}
 
 	// Get date from DD-MM-YYYY to date
-	newDate, err := utils.ParseDate(ctx.Message.Text)
+	newDate, err := utils.ParseDate(text)
 	if err != nil {
 		fmt.Printf("failed to parse date: %v\n", err)
 		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Invalid date, please try again.", nil)
 		return err
 	}
 
-	if newDate.After(time.Now()) {
+	if newDate.After(user.Today()) {
 		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "I don't support future dates, please try again.", nil)
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("invalid date: %s", ctx.Message.Text)
+		return fmt.Errorf("invalid date: %s", text)
 	}
 
 	// Update the transaction
 	oldDate := transaction.Date
 	transaction.Date = newDate
 
-	err = c.Repositories.Transactions.Update(&transaction)
+	err = c.Repositories.Transactions.As(botActor(user)).Update(&transaction)
 	if err != nil {
 		_, err = b.SendMessage(
 			ctx.EffectiveSender.ChatId,
@@ -632,24 +798,24 @@ func (c *Client) EditTransactionDateConfirm(b *gotgbot.Bot, ctx *ext.Context) er
 		emoji = "💸"
 	}
 
-	text := fmt.Sprintf("%s Date updated successfully!\n\nChanged from <b>%s</b> to <b>%s</b>",
+	reply := fmt.Sprintf("%s Date updated successfully!\n\nChanged from <b>%s</b> to <b>%s</b>",
 		emoji,
 		oldDate.Format("02-01-2006"),
 		transaction.Date.Format("02-01-2006"))
 	if transaction.Type == model.TypeExpense {
 		// Show NEW month's budget status — that's where the impact landed.
-		text += c.BudgetSuffixForTx(transaction)
+		reply += FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction))
 		// If the date moved across months, also surface the OLD month's status
 		// (it lost an expense — possibly bringing the user back under budget).
 		if oldDate.Year() != transaction.Date.Year() || oldDate.Month() != transaction.Date.Month() {
 			oldMonthTx := transaction
 			oldMonthTx.Date = oldDate
-			text += c.BudgetSuffixForTx(oldMonthTx)
+			reply += c.BudgetSuffixForTx(oldMonthTx)
 		}
 	}
 	_, err = b.SendMessage(
 		ctx.EffectiveSender.ChatId,
-		text,
+		reply,
 		&gotgbot.SendMessageOpts{
 			ParseMode: "HTML",
 			ReplyMarkup: gotgbot.InlineKeyboardMarkup{
@@ -700,7 +866,7 @@ func (c *Client) showEditableTransactionPage(b *gotgbot.Bot, ctx *ext.Context, u
 	}
 
 	// Format transactions
-	message := formatEditableTransactions(transactions, offset, int(total))
+	message := formatEditableTransactions(transactions, c.displayCategories(user.TgID), offset, int(total))
 
 	// Create pagination keyboard with numbered buttons for editing
 	keyboard := createEditPaginationKeyboard(transactions, offset, limit, int(total))
@@ -726,16 +892,19 @@ func (c *Client) showEditableTransactionPage(b *gotgbot.Bot, ctx *ext.Context, u
 }
 
 // formatEditableTransactions formats the transactions for display in the editing interface
-func formatEditableTransactions(transactions []model.Transaction, offset, total int) string {
+func formatEditableTransactions(transactions []model.Transaction, categories model.Categories, offset, total int) string {
 	var msg strings.Builder
 	msg.WriteString("<b>✏️ Edit Transaction</b>\n")
 	fmt.Fprintf(&msg, "Showing %d–%d of %d\n\n", offset+1, offset+len(transactions), total)
 
 	for i, t := range transactions {
-		emoji := utils.GetCategoryEmoji(t.Category)
+		emoji := categories.Emoji(t.Category)
 		sign := "-"
-		if t.Type == model.TypeIncome {
+		switch t.Type {
+		case model.TypeIncome:
 			sign = "+"
+		case model.TypeTransfer:
+			sign = "↔"
 		}
 		fmt.Fprintf(&msg, "%d. %s %s · %s€%.2f · %s\n",
 			i+1, emoji, t.Description, sign, t.Amount, t.Date.Format("02/01/2006"))
@@ -815,8 +984,15 @@ func (c *Client) showEditOptions(b *gotgbot.Bot, ctx *ext.Context, transaction m
 	if transaction.Description != "" {
 		message += fmt.Sprintf("📝 %s\n", transaction.Description)
 	}
+	if transaction.IsSplit() {
+		message += FormatTransactionSplits(transaction)[1:] + "\n"
+	}
+
+	if count, err := c.Repositories.Attachments.Count(transaction.ID); err == nil && count > 0 {
+		message += fmt.Sprintf("📎 %d attached\n", count)
+	}
 
-	message += "\nSelect what you want to edit:"
+	message += "\nSelect what you want to edit, or send a photo or a PDF to attach it:"
 
 	// Create keyboard with edit options
 	keyboard := [][]gotgbot.InlineKeyboardButton{
@@ -840,6 +1016,16 @@ func (c *Client) showEditOptions(b *gotgbot.Bot, ctx *ext.Context, transaction m
 				CallbackData: "edit.field.date",
 			},
 		},
+		{
+			{
+				Text:         "✂️ Split",
+				CallbackData: "edit.field.split",
+			},
+			{
+				Text:         "🕓 History",
+				CallbackData: fmt.Sprintf("edit.history.%d", transaction.ID),
+			},
+		},
 		{
 			{
 				Text:         "❌ Cancel",
@@ -861,7 +1047,10 @@ func (c *Client) showEditOptions(b *gotgbot.Bot, ctx *ext.Context, transaction m
 
 // showEditSearchCategorySelection displays the category selection keyboard for edit search
 func (c *Client) showEditSearchCategorySelection(b *gotgbot.Bot, ctx *ext.Context) error {
-	keyboard := BuildCategoryInlineKeyboard("", "edit.search.category", "edit.search.cancel", true)
+	keyboard, err := c.buildUserCategoryKeyboard(ctx, "", "edit.search.category", "edit.search.cancel", true)
+	if err != nil {
+		return err
+	}
 
 	message := "✏️ <b>Edit Transaction</b>\n\nFirst, select a category to search in:"
 
@@ -914,7 +1103,7 @@ func (c *Client) EditSearchCategorySelected(b *gotgbot.Bot, ctx *ext.Context) er
 	// Ask for search query
 	categoryText := "all categories"
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := c.displayCategories(user.TgID).Emoji(model.TransactionCategory(category))
 		categoryText = fmt.Sprintf("%s %s", emoji, category)
 	}
 
@@ -946,7 +1135,7 @@ func (c *Client) EditSearchCategorySelected(b *gotgbot.Bot, ctx *ext.Context) er
 }
 
 // EditSearchQueryEntered handles the search query input for edit
-func (c *Client) EditSearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context) error {
+func (c *Client) EditSearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context, text string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
@@ -954,7 +1143,7 @@ func (c *Client) EditSearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context) error
 	}
 
 	// Get search query
-	searchQuery := strings.TrimSpace(ctx.Message.Text)
+	searchQuery := strings.TrimSpace(text)
 	if searchQuery == "" {
 		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Search query cannot be empty. Please try again.", nil)
 		return err
@@ -1006,10 +1195,12 @@ func (c *Client) showEditSearchResults(b *gotgbot.Bot, ctx *ext.Context, user mo
 		return fmt.Errorf("failed to search transactions: %w", err)
 	}
 
+	categories := c.displayCategories(user.TgID)
+
 	if total == 0 {
 		message := fmt.Sprintf("🔍 No transactions found matching \"%s\"", searchQuery)
 		if category != "all" {
-			emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+			emoji := categories.Emoji(model.TransactionCategory(category))
 			message = fmt.Sprintf("🔍 No transactions found matching \"%s\" in %s %s", searchQuery, emoji, category)
 		}
 
@@ -1052,7 +1243,7 @@ func (c *Client) showEditSearchResults(b *gotgbot.Bot, ctx *ext.Context, user mo
 	}
 
 	// Format edit search results (similar to original edit page format)
-	message := formatEditSearchResults(transactions, searchQuery, category, offset, int(total))
+	message := formatEditSearchResults(transactions, categories, searchQuery, category, offset, int(total))
 
 	// Create pagination keyboard with numbered buttons for editing
 	keyboard := createEditSearchPaginationKeyboard(transactions, category, searchQuery, offset, limit, int(total))
@@ -1078,7 +1269,7 @@ func (c *Client) showEditSearchResults(b *gotgbot.Bot, ctx *ext.Context, user mo
 }
 
 // formatEditSearchResults formats the search results for editing display
-func formatEditSearchResults(transactions []model.Transaction, searchQuery, category string, offset, total int) string {
+func formatEditSearchResults(transactions []model.Transaction, categories model.Categories, searchQuery, category string, offset, total int) string {
 	var msg strings.Builder
 
 	msg.WriteString("✏️ <b>Edit Transaction</b>\n")
@@ -1088,17 +1279,20 @@ func formatEditSearchResults(transactions []model.Transaction, searchQuery, cate
 	}
 
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := categories.Emoji(model.TransactionCategory(category))
 		fmt.Fprintf(&msg, " in %s %s", emoji, category)
 	}
 
 	fmt.Fprintf(&msg, "\nShowing %d–%d of %d\n\n", offset+1, offset+len(transactions), total)
 
 	for i, t := range transactions {
-		emoji := utils.GetCategoryEmoji(t.Category)
+		emoji := categories.Emoji(t.Category)
 		sign := "-"
-		if t.Type == model.TypeIncome {
+		switch t.Type {
+		case model.TypeIncome:
 			sign = "+"
+		case model.TypeTransfer:
+			sign = "↔"
 		}
 
 		desc := t.Description
@@ -1232,7 +1426,7 @@ func (c *Client) EditSearchTransactionSelected(b *gotgbot.Bot, ctx *ext.Context)
 	}
 
 	// Store transaction ID in session for editing (same as existing edit flow)
-	user.Session.State = model.StateNormal
+	user.Session.State = model.StateEditingTransaction
 	user.Session.Body = fmt.Sprintf("%d", transactionID)
 
 	err = c.Repositories.Users.Update(&user)
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// envelopesUsage explains the /envelopes subcommands
+const envelopesUsage = "Assign your income to envelopes until nothing is left unassigned:\n" +
+	"<code>/envelopes assign Grocery 300</code> puts 300 in an expense category or a goal, a negative amount takes it back\n" +
+	"<code>/envelopes move 50 Grocery to Eating out</code> moves money available from an envelope to another"
+
+// ParseEnvelopeAssignInput parses the arguments of /envelopes assign: an
+// envelope name followed by a non-zero amount, e.g. "Eating out 120,50".
+func ParseEnvelopeAssignInput(text string) (string, model.Money, bool) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return "", 0, false
+	}
+
+	amount, err := model.ParseMoney(fields[len(fields)-1])
+	if err != nil || amount == 0 {
+		return "", 0, false
+	}
+	return strings.Join(fields[:len(fields)-1], " "), amount, true
+}
+
+// ParseEnvelopeMoveInput parses the arguments of /envelopes move: a positive
+// amount followed by the two envelope names separated by "to", e.g.
+// "50 Grocery to Eating out".
+func ParseEnvelopeMoveInput(text string) (model.Money, string, string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) < 4 {
+		return 0, "", "", false
+	}
+
+	amount, err := model.ParseMoney(fields[0])
+	if err != nil || amount <= 0 {
+		return 0, "", "", false
+	}
+	for i := 2; i < len(fields)-1; i++ {
+		if strings.EqualFold(fields[i], "to") {
+			return amount, strings.Join(fields[1:i], " "), strings.Join(fields[i+1:], " "), true
+		}
+	}
+	return 0, "", "", false
+}
+
+// FormatEnvelopeLedger renders the zero-based budget of a month: the income
+// left to assign, then each envelope with what was assigned to it and spent
+// from it in the month and what is available
+func FormatEnvelopeLedger(ledger model.EnvelopeLedger, categories model.Categories, cur string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✉️ <b>Envelopes</b> - %s\n\n", ledger.Month)
+	fmt.Fprintf(&sb, "Income: %.2f %s\nAssigned: %.2f %s\n", ledger.Income, cur, ledger.Assigned, cur)
+	switch {
+	case ledger.Unassigned > 0:
+		fmt.Fprintf(&sb, "🟢 <b>Ready to assign: %.2f %s</b>\n", ledger.Unassigned, cur)
+	case ledger.Unassigned < 0:
+		fmt.Fprintf(&sb, "🔴 <b>Assigned %.2f %s more than your income</b>\n", -ledger.Unassigned, cur)
+	default:
+		sb.WriteString("✅ Every bit of your income has a job\n")
+	}
+
+	if len(ledger.Envelopes) == 0 {
+		sb.WriteString("\nNo envelopes yet.")
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	for _, e := range ledger.Envelopes {
+		indicator := ""
+		if e.Available < 0 {
+			indicator = " 🚨"
+		}
+		if e.IsGoal() {
+			fmt.Fprintf(&sb, "🎯 <b>%s</b>: %.2f assigned, %.2f %s available%s\n",
+				html.EscapeString(e.Name), e.Assigned, e.Available, cur, indicator)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s <b>%s</b>: %.2f assigned, %.2f spent, %.2f %s available%s\n",
+			categories.Emoji(e.Category), html.EscapeString(e.Name), e.Assigned, e.Spent, e.Available, cur, indicator)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// EnvelopesCommand handles /envelopes, showing the envelopes of the current
+// month or assigning and moving money between them.
+func (c *Client) EnvelopesCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	if ctx.Message == nil {
+		return c.ShowEnvelopes(b, ctx)
+	}
+
+	parts := strings.Fields(ctx.Message.Text)
+	// parts[0] == "/envelopes"
+	if len(parts) < 2 {
+		return c.ShowEnvelopes(b, ctx)
+	}
+
+	switch strings.ToLower(parts[1]) {
+	case "assign":
+		return c.envelopeAssign(b, ctx, strings.Join(parts[2:], " "))
+	case "move":
+		return c.envelopeMove(b, ctx, strings.Join(parts[2:], " "))
+	default:
+		return c.SendHomeKeyboard(b, ctx, envelopesUsage)
+	}
+}
+
+// ShowEnvelopes renders the envelopes of the user in the current month.
+func (c *Client) ShowEnvelopes(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := c.Repositories.Envelopes.Ledger(user.TgID, user.Today().Format("2006-01"))
+	if err != nil {
+		return fmt.Errorf("failed to get envelopes: %w", err)
+	}
+	text := FormatEnvelopeLedger(ledger, c.displayCategories(user.TgID), user.BaseCurrency.Symbol())
+	return c.SendHomeKeyboard(b, ctx, text+"\n\n"+envelopesUsage)
+}
+
+func (c *Client) envelopeAssign(b *gotgbot.Bot, ctx *ext.Context, input string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	input, amount, ok := ParseEnvelopeAssignInput(input)
+	if !ok {
+		return c.SendHomeKeyboard(b, ctx, "Usage: <code>/envelopes assign &lt;category or goal&gt; &lt;amount&gt;</code>")
+	}
+	envelope, name, err := c.Repositories.Envelopes.Find(user.TgID, input)
+	if err != nil {
+		return c.envelopeError(b, ctx, err, input)
+	}
+
+	month := user.Today().Format("2006-01")
+	allocation := model.NewEnvelopeAllocation(user.TgID, month, envelope, amount)
+	if err := c.Repositories.Envelopes.Assign(&allocation); err != nil {
+		return c.envelopeError(b, ctx, err, name)
+	}
+	return c.envelopeDone(b, ctx, user, month, fmt.Sprintf("✉️ %.2f %s assigned to <b>%s</b>.", amount, user.BaseCurrency.Symbol(), html.EscapeString(name)))
+}
+
+func (c *Client) envelopeMove(b *gotgbot.Bot, ctx *ext.Context, input string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	amount, fromInput, toInput, ok := ParseEnvelopeMoveInput(input)
+	if !ok {
+		return c.SendHomeKeyboard(b, ctx, "Usage: <code>/envelopes move &lt;amount&gt; &lt;from&gt; to &lt;to&gt;</code>")
+	}
+	from, fromName, err := c.Repositories.Envelopes.Find(user.TgID, fromInput)
+	if err != nil {
+		return c.envelopeError(b, ctx, err, fromInput)
+	}
+	to, toName, err := c.Repositories.Envelopes.Find(user.TgID, toInput)
+	if err != nil {
+		return c.envelopeError(b, ctx, err, toInput)
+	}
+
+	month := user.Today().Format("2006-01")
+	if err := c.Repositories.Envelopes.Move(user.TgID, month, from, to, amount); err != nil {
+		return c.envelopeError(b, ctx, err, fromName)
+	}
+	return c.envelopeDone(b, ctx, user, month, fmt.Sprintf("✉️ %.2f %s moved from <b>%s</b> to <b>%s</b>.",
+		amount, user.BaseCurrency.Symbol(), html.EscapeString(fromName), html.EscapeString(toName)))
+}
+
+// envelopeDone confirms a change to the envelopes along with the ledger of the month
+func (c *Client) envelopeDone(b *gotgbot.Bot, ctx *ext.Context, user model.User, month, text string) error {
+	ledger, err := c.Repositories.Envelopes.Ledger(user.TgID, month)
+	if err != nil {
+		return fmt.Errorf("failed to get envelopes: %w", err)
+	}
+	return c.SendHomeKeyboard(b, ctx, text+"\n\n"+FormatEnvelopeLedger(ledger, c.displayCategories(user.TgID), user.BaseCurrency.Symbol()))
+}
+
+// envelopeError tells the user why a change to the envelopes failed
+func (c *Client) envelopeError(b *gotgbot.Bot, ctx *ext.Context, err error, name string) error {
+	switch {
+	case errors.Is(err, model.ErrEnvelopeNotFound):
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ <b>%s</b> is not one of your expense categories or goals.", html.EscapeString(name)))
+	case errors.Is(err, model.ErrEnvelopeFunds):
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ Not enough money available in <b>%s</b>.", html.EscapeString(name)))
+	case errors.Is(err, model.ErrInvalidEnvelope):
+		return c.SendHomeKeyboard(b, ctx, "❌ "+html.EscapeString(capitalize(err.Error()))+".")
+	default:
+		return fmt.Errorf("failed to change envelopes: %w", err)
+	}
+}
+
+// EnvelopeSuffixForTx returns the line appended to the confirmation of an
+// income when the user budgets with envelopes, telling how much of it is left
+// to assign. Empty for expenses, or when envelopes are not used.
+func (c *Client) EnvelopeSuffixForTx(user model.User, tx model.Transaction) string {
+	if tx.Type != model.TypeIncome {
+		return ""
+	}
+	used, err := c.Repositories.Envelopes.IsUsed(user.TgID)
+	if err != nil {
+		c.Logger.Warnf("failed to check envelopes: %v", err)
+		return ""
+	}
+	if !used {
+		return ""
+	}
+
+	ledger, err := c.Repositories.Envelopes.Ledger(user.TgID, user.Today().Format("2006-01"))
+	if err != nil {
+		c.Logger.Warnf("failed to get envelopes: %v", err)
+		return ""
+	}
+	if ledger.Unassigned <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\n✉️ %.2f %s ready to assign to your envelopes, e.g. <code>/envelopes assign Grocery %.0f</code>",
+		ledger.Unassigned, user.BaseCurrency.Symbol(), ledger.Unassigned)
+}
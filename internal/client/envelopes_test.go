@@ -0,0 +1,99 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"cashout/internal/model"
+)
+
+func TestParseEnvelopeAssignInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantName   string
+		wantAmount float64
+		wantOK     bool
+	}{
+		{name: "category", input: "Grocery 300", wantName: "Grocery", wantAmount: 300, wantOK: true},
+		{name: "name with spaces and comma", input: "Eating out 120,50", wantName: "Eating out", wantAmount: 120.5, wantOK: true},
+		{name: "taken back", input: "Holiday -50", wantName: "Holiday", wantAmount: -50, wantOK: true},
+		{name: "zero", input: "Grocery 0", wantOK: false},
+		{name: "no amount", input: "Grocery", wantOK: false},
+		{name: "not a number", input: "Grocery lots", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, amount, ok := ParseEnvelopeAssignInput(tt.input)
+			if ok != tt.wantOK || name != tt.wantName || amount != model.NewMoney(tt.wantAmount) {
+				t.Errorf("ParseEnvelopeAssignInput(%q) = %q, %v, %v, want %q, %v, %v",
+					tt.input, name, amount, ok, tt.wantName, tt.wantAmount, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseEnvelopeMoveInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantAmt  float64
+		wantFrom string
+		wantTo   string
+		wantOK   bool
+	}{
+		{name: "simple", input: "50 Grocery to Holiday", wantAmt: 50, wantFrom: "Grocery", wantTo: "Holiday", wantOK: true},
+		{name: "names with spaces", input: "20,5 Eating out TO Summer holiday", wantAmt: 20.5, wantFrom: "Eating out", wantTo: "Summer holiday", wantOK: true},
+		{name: "no to", input: "50 Grocery Holiday", wantOK: false},
+		{name: "missing target", input: "50 Grocery to", wantOK: false},
+		{name: "negative", input: "-50 Grocery to Holiday", wantOK: false},
+		{name: "no amount", input: "Grocery to Holiday", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, from, to, ok := ParseEnvelopeMoveInput(tt.input)
+			if ok != tt.wantOK || amount != model.NewMoney(tt.wantAmt) || from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("ParseEnvelopeMoveInput(%q) = %v, %q, %q, %v, want %v, %q, %q, %v",
+					tt.input, amount, from, to, ok, tt.wantAmt, tt.wantFrom, tt.wantTo, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormatEnvelopeLedger(t *testing.T) {
+	categories := model.Categories{{Name: "Grocery", Emoji: "🛒", Type: model.TypeExpense}}
+	ledger := model.EnvelopeLedger{
+		Month:      "2026-05",
+		Income:     model.NewMoney(2500),
+		Assigned:   model.NewMoney(2000),
+		Unassigned: model.NewMoney(500),
+		Envelopes: []model.EnvelopeBalance{
+			{Envelope: model.Envelope{Category: "Grocery"}, Name: "Grocery", Assigned: model.NewMoney(300), Spent: model.NewMoney(320), Available: model.NewMoney(-20)},
+			{Envelope: model.Envelope{GoalID: 4}, Name: "Holiday", Assigned: model.NewMoney(200), Available: model.NewMoney(700)},
+		},
+	}
+
+	got := FormatEnvelopeLedger(ledger, categories, "€")
+	for _, w := range []string{
+		"✉️ <b>Envelopes</b> - 2026-05",
+		"Income: 2500.00 €\nAssigned: 2000.00 €",
+		"🟢 <b>Ready to assign: 500.00 €</b>",
+		"🛒 <b>Grocery</b>: 300.00 assigned, 320.00 spent, -20.00 € available 🚨",
+		"🎯 <b>Holiday</b>: 200.00 assigned, 700.00 € available",
+	} {
+		if !strings.Contains(got, w) {
+			t.Errorf("FormatEnvelopeLedger() = %q, want it to contain %q", got, w)
+		}
+	}
+
+	ledger.Unassigned = model.NewMoney(-100)
+	if got := FormatEnvelopeLedger(ledger, categories, "€"); !strings.Contains(got, "Assigned 100.00 € more than your income") {
+		t.Errorf("FormatEnvelopeLedger() = %q, want the over-assigned warning", got)
+	}
+	ledger.Unassigned, ledger.Envelopes = 0, nil
+	if got := FormatEnvelopeLedger(ledger, categories, "€"); !strings.Contains(got, "Every bit of your income has a job") || !strings.Contains(got, "No envelopes yet.") {
+		t.Errorf("FormatEnvelopeLedger() = %q, want the zero-based and empty lines", got)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/csv"
 	"fmt"
 	"strconv"
-	"time"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -42,6 +41,8 @@ func (c *Client) ExportTransactions(b *gotgbot.Bot, ctx *ext.Context) error {
 		"category",
 		"amount",
 		"currency",
+		"original_amount",
+		"original_currency",
 		"description",
 		"created_at",
 		"updated_at",
@@ -59,6 +60,8 @@ func (c *Client) ExportTransactions(b *gotgbot.Bot, ctx *ext.Context) error {
 			string(t.Category),
 			fmt.Sprintf("%.2f", t.Amount),
 			string(t.Currency),
+			fmt.Sprintf("%.2f", t.OriginalAmount),
+			string(t.OriginalCurrency),
 			t.Description,
 			t.CreatedAt.Format("2006-01-02 15:04"),
 			t.UpdatedAt.Format("2006-01-02 15:04"),
@@ -74,7 +77,7 @@ func (c *Client) ExportTransactions(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	// Generate filename with current date
-	filename := fmt.Sprintf("cashout_export_%s.csv", time.Now().Format("2006-01-02"))
+	filename := fmt.Sprintf("cashout_export_%s.csv", user.Today().Format("2006-01-02"))
 
 	// Send the CSV file
 	_, err = b.SendDocument(ctx.EffectiveSender.ChatId, gotgbot.InputFileByReader(filename, bytes.NewReader(buf.Bytes())), &gotgbot.SendDocumentOpts{
@@ -0,0 +1,364 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"cashout/internal/model"
+	"cashout/internal/utils"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// goalEntriesShown is the number of the most recent contributions shown in
+// the details of a goal
+const goalEntriesShown = 5
+
+// ParseGoalInput parses the one-line description of a new goal typed by the
+// user, e.g. "Holiday 1500 31-08-2026 #holiday": the first number is the
+// target amount, the deadline and the tag whose transactions contribute to
+// the goal are optional and can be in any order, the rest is the name.
+func ParseGoalInput(text string) model.Goal {
+	var goal model.Goal
+
+	var name []string
+	amountSet := false
+	for _, token := range strings.Fields(text) {
+		if amount, err := model.ParseMoney(token); err == nil && !amountSet {
+			goal.TargetAmount, amountSet = amount, true
+			continue
+		}
+		if strings.HasPrefix(token, "#") && goal.Tag == "" {
+			goal.Tag = token
+			continue
+		}
+		if deadline, err := utils.ParseDate(token); err == nil && goal.Deadline == nil {
+			goal.Deadline = &deadline
+			continue
+		}
+		name = append(name, token)
+	}
+
+	goal.Name = strings.Join(name, " ")
+	return goal
+}
+
+// progressBar renders a percentage as a bar of ten blocks
+func progressBar(pct int) string {
+	filled := min(max(pct, 0), 100) / 10
+	return strings.Repeat("▓", filled) + strings.Repeat("░", 10-filled)
+}
+
+// FormatGoalProgress renders the progress of a goal with a bar, like the
+// budget suffix of the transactions, and whether its deadline will be met
+// at the current contribution rate
+func FormatGoalProgress(goal model.Goal, p model.GoalProgress) string {
+	symbol := goal.Currency.Symbol()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🎯 <b>%s</b>", html.EscapeString(goal.Name))
+	if goal.Tag != "" {
+		fmt.Fprintf(&sb, " #%s", goal.Tag)
+	}
+	fmt.Fprintf(&sb, "\n%s %.2f / %.2f %s (%d%%)\n", progressBar(p.Pct), p.Saved, goal.TargetAmount, symbol, p.Pct)
+
+	switch p.Status {
+	case model.GoalReached:
+		sb.WriteString("🎉 Goal reached!")
+	case model.GoalOnTrack:
+		fmt.Fprintf(&sb, "✅ On track for %s: at %.2f %s/month it is reached by %s",
+			goal.Deadline.Format("02-01-2006"), p.MonthlyRate, symbol, p.Estimated.Format("02-01-2006"))
+	case model.GoalBehind:
+		fmt.Fprintf(&sb, "⚠️ Behind: save %.2f %s/month to make it by %s", p.Required, symbol, goal.Deadline.Format("02-01-2006"))
+		if p.Estimated != nil {
+			fmt.Fprintf(&sb, ", at %.2f %s/month it is reached by %s", p.MonthlyRate, symbol, p.Estimated.Format("02-01-2006"))
+		}
+	case model.GoalOverdue:
+		fmt.Fprintf(&sb, "⏰ The deadline of %s has passed, %.2f %s to go", goal.Deadline.Format("02-01-2006"), p.Remaining, symbol)
+	default:
+		if p.Estimated != nil {
+			fmt.Fprintf(&sb, "📈 At %.2f %s/month it is reached by %s", p.MonthlyRate, symbol, p.Estimated.Format("02-01-2006"))
+		} else {
+			fmt.Fprintf(&sb, "%.2f %s to go", p.Remaining, symbol)
+		}
+	}
+	return sb.String()
+}
+
+// GoalsCommand handles /goals.
+func (c *Client) GoalsCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	return c.ShowGoals(b, ctx)
+}
+
+// ShowGoals renders the goals of the user with their progress and the actions to manage them.
+func (c *Client) ShowGoals(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	goals, err := c.Repositories.Goals.List(user.TgID)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🎯 <b>Savings Goals</b>\n")
+	if len(goals) == 0 {
+		sb.WriteString("\nYou have no goals yet. Add one to track how much you are saving towards a holiday, an emergency fund or anything else.")
+	}
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for _, g := range goals {
+		entries, err := c.Repositories.Goals.Entries(g)
+		if err != nil {
+			return err
+		}
+		sb.WriteString("\n" + FormatGoalProgress(g, g.Progress(entries, user.Today())) + "\n")
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🎯 " + g.Name, CallbackData: fmt.Sprintf("goals.show.%d", g.ID)}})
+	}
+
+	keyboard = append(keyboard,
+		[]gotgbot.InlineKeyboardButton{{Text: "➕ New Goal", CallbackData: "goals.new"}},
+		[]gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}},
+	)
+	return SendMessage(ctx, b, strings.TrimSuffix(sb.String(), "\n"), keyboard)
+}
+
+// GoalDetails handles goals.show.<ID>.
+func (c *Client) GoalDetails(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	goal, err := c.getCallbackGoal(ctx, user)
+	if errors.Is(err, model.ErrGoalNotFound) {
+		return c.SendHomeKeyboard(b, ctx, "⚠️ The goal no longer exists.")
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.showGoalDetails(b, ctx, user, goal)
+}
+
+func (c *Client) showGoalDetails(b *gotgbot.Bot, ctx *ext.Context, user model.User, goal model.Goal) error {
+	entries, err := c.Repositories.Goals.Entries(goal)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(FormatGoalProgress(goal, goal.Progress(entries, user.Today())))
+	if goal.Tag != "" {
+		fmt.Fprintf(&sb, "\n\nTag your transactions with #%s to contribute to this goal.", goal.Tag)
+	}
+
+	if len(entries) > 0 {
+		sb.WriteString("\n\n<b>Latest contributions</b>")
+		for i := len(entries) - 1; i >= 0 && i >= len(entries)-goalEntriesShown; i-- {
+			e := entries[i]
+			fmt.Fprintf(&sb, "\n%s %+.2f %s", e.Date.Format("02-01-2006"), e.Amount, goal.Currency.Symbol())
+			if e.Note != "" {
+				fmt.Fprintf(&sb, " · %s", html.EscapeString(e.Note))
+			}
+		}
+	}
+
+	id := strconv.FormatInt(goal.ID, 10)
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{
+			{Text: "💰 Add Contribution", CallbackData: "goals.add." + id},
+			{Text: "🗑 Delete", CallbackData: "goals.delete." + id},
+		},
+		{{Text: "⬅️ Back", CallbackData: "goals.list"}},
+	}
+	return SendMessage(ctx, b, sb.String(), keyboard)
+}
+
+// GoalNewPrompt handles goals.new, waiting for the details of the new goal.
+func (c *Client) GoalNewPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	user.Session.State = model.StateGoalNewWaitDetails
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "goals.cancel"}},
+	}
+	text := "Enter the name of the goal and its target amount, optionally followed by a deadline and a tag whose transactions contribute to it, e.g. <code>Holiday 1500 31-08-2026 #holiday</code>."
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// GoalFromMessage receives the details typed after GoalNewPrompt.
+func (c *Client) GoalFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	goal := ParseGoalInput(text)
+	goal.TgID = user.TgID
+	goal.Currency = user.BaseCurrency
+	if goal.Currency == "" {
+		goal.Currency = model.CurrencyEUR
+	}
+	if goal.Deadline != nil && goal.Deadline.Before(user.Today()) {
+		return c.SendHomeKeyboard(b, ctx, "❌ The deadline cannot be in the past, please try again.")
+	}
+
+	err := c.Repositories.Goals.Create(&goal)
+	if errors.Is(err, model.ErrInvalidGoal) || errors.Is(err, model.ErrGoalExists) || errors.Is(err, model.ErrGoalTagInUse) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s, please try again.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save goal: %w", err)
+	}
+
+	return c.showGoalDetails(b, ctx, user, goal)
+}
+
+// GoalContributePrompt handles goals.add.<ID>, waiting for the amount of a
+// manual contribution.
+func (c *Client) GoalContributePrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	goal, err := c.getCallbackGoal(ctx, user)
+	if errors.Is(err, model.ErrGoalNotFound) {
+		return c.SendHomeKeyboard(b, ctx, "⚠️ The goal no longer exists.")
+	}
+	if err != nil {
+		return err
+	}
+
+	user.Session.State = model.StateGoalContributeWaitAmount
+	user.Session.Body = strconv.FormatInt(goal.ID, 10)
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "goals.cancel"}},
+	}
+	text := fmt.Sprintf(
+		"How much did you put aside for <b>%s</b>? Enter the amount in %s, optionally followed by a note, e.g. <code>200 June savings</code>. A negative amount withdraws from the goal.",
+		html.EscapeString(goal.Name), goal.Currency,
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// GoalContributionFromMessage receives the amount typed after GoalContributePrompt.
+func (c *Client) GoalContributionFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	goalID, err := strconv.ParseInt(user.Session.Body, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid goal ID: %w", err)
+	}
+
+	amountStr, note, _ := strings.Cut(strings.TrimSpace(text), " ")
+	amount, err := model.ParseMoney(amountStr)
+	if err != nil || amount == 0 {
+		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Invalid amount. Please enter a number other than zero.", nil)
+		return err
+	}
+
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	contribution := model.GoalContribution{
+		GoalID: goalID,
+		TgID:   user.TgID,
+		Amount: amount,
+		Date:   user.Today(),
+		Note:   note,
+	}
+	err = c.Repositories.Goals.Contribute(&contribution)
+	if errors.Is(err, model.ErrGoalNotFound) {
+		return c.SendHomeKeyboard(b, ctx, "⚠️ The goal no longer exists.")
+	}
+	if errors.Is(err, model.ErrInvalidContribution) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s, please try again.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save goal contribution: %w", err)
+	}
+
+	goal, err := c.Repositories.Goals.Get(user.TgID, goalID)
+	if err != nil {
+		return fmt.Errorf("failed to get goal: %w", err)
+	}
+	return c.showGoalDetails(b, ctx, user, goal)
+}
+
+// GoalDelete handles goals.delete.<ID>, the tagged transactions are kept.
+func (c *Client) GoalDelete(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	goal, err := c.getCallbackGoal(ctx, user)
+	if err != nil && !errors.Is(err, model.ErrGoalNotFound) {
+		return err
+	}
+	if err == nil {
+		if err := c.Repositories.Goals.Delete(user.TgID, goal.ID); err != nil {
+			return fmt.Errorf("failed to delete goal: %w", err)
+		}
+	}
+
+	return c.ShowGoals(b, ctx)
+}
+
+// GoalsCancel handles goals.cancel.
+func (c *Client) GoalsCancel(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+	return c.SendHomeKeyboard(b, ctx, "Operation cancelled.")
+}
+
+// getCallbackGoal returns the goal whose ID is the last part of the callback
+// data, or model.ErrGoalNotFound
+func (c *Client) getCallbackGoal(ctx *ext.Context, user model.User) (model.Goal, error) {
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return model.Goal{}, fmt.Errorf("invalid goal ID: %w", err)
+	}
+
+	goal, err := c.Repositories.Goals.Get(user.TgID, id)
+	if err != nil {
+		return model.Goal{}, fmt.Errorf("failed to get goal: %w", err)
+	}
+	return goal, nil
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cashout/internal/model"
+)
+
+func TestParseGoalInput(t *testing.T) {
+	deadline := time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		input string
+		want  model.Goal
+	}{
+		{
+			name:  "name and amount",
+			input: "Emergency fund 5000",
+			want:  model.Goal{Name: "Emergency fund", TargetAmount: model.NewMoney(5000)},
+		},
+		{
+			name:  "every field in any order",
+			input: "#holiday 31-08-2026 Summer holiday 1500,50",
+			want:  model.Goal{Name: "Summer holiday", TargetAmount: model.NewMoney(1500.5), Deadline: &deadline, Tag: "#holiday"},
+		},
+		{
+			name:  "only the first amount",
+			input: "Car 8000 2",
+			want:  model.Goal{Name: "Car 2", TargetAmount: model.NewMoney(8000)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseGoalInput(tt.input)
+			if got.Name != tt.want.Name || got.TargetAmount != tt.want.TargetAmount || got.Tag != tt.want.Tag {
+				t.Errorf("ParseGoalInput(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if (got.Deadline == nil) != (tt.want.Deadline == nil) || got.Deadline != nil && !got.Deadline.Equal(*tt.want.Deadline) {
+				t.Errorf("ParseGoalInput(%q) deadline = %v, want %v", tt.input, got.Deadline, tt.want.Deadline)
+			}
+		})
+	}
+}
+
+func TestFormatGoalProgress(t *testing.T) {
+	deadline := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	estimated := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	goal := model.Goal{Name: "Trip & co", TargetAmount: model.NewMoney(1000), Currency: model.CurrencyEUR, Deadline: &deadline, Tag: "holiday"}
+
+	tests := []struct {
+		name     string
+		goal     model.Goal
+		progress model.GoalProgress
+		want     []string
+	}{
+		{
+			name:     "on track",
+			goal:     goal,
+			progress: model.GoalProgress{Saved: model.NewMoney(600), Remaining: model.NewMoney(400), Pct: 60, MonthlyRate: model.NewMoney(100), Estimated: &estimated, Status: model.GoalOnTrack},
+			want: []string{
+				"🎯 <b>Trip &amp; co</b> #holiday",
+				"▓▓▓▓▓▓░░░░ 600.00 / 1000.00 € (60%)",
+				"✅ On track for 31-12-2026: at 100.00 €/month it is reached by 01-10-2026",
+			},
+		},
+		{
+			name:     "behind without contributions",
+			goal:     goal,
+			progress: model.GoalProgress{Remaining: model.NewMoney(1000), Required: model.NewMoney(250), Status: model.GoalBehind},
+			want:     []string{"░░░░░░░░░░ 0.00", "⚠️ Behind: save 250.00 €/month to make it by 31-12-2026"},
+		},
+		{
+			name:     "reached beyond the target",
+			goal:     goal,
+			progress: model.GoalProgress{Saved: model.NewMoney(1200), Pct: 120, Status: model.GoalReached},
+			want:     []string{"▓▓▓▓▓▓▓▓▓▓ 1200.00", "🎉 Goal reached!"},
+		},
+		{
+			name:     "overdue",
+			goal:     goal,
+			progress: model.GoalProgress{Saved: model.NewMoney(600), Remaining: model.NewMoney(400), Pct: 60, Status: model.GoalOverdue},
+			want:     []string{"⏰ The deadline of 31-12-2026 has passed, 400.00 € to go"},
+		},
+		{
+			name:     "no deadline",
+			goal:     model.Goal{Name: "Fund", TargetAmount: model.NewMoney(1000), Currency: model.CurrencyEUR},
+			progress: model.GoalProgress{Remaining: model.NewMoney(1000), Status: model.GoalNoDeadline},
+			want:     []string{"🎯 <b>Fund</b>\n", "1000.00 € to go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatGoalProgress(tt.goal, tt.progress)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("FormatGoalProgress() = %q, want it to contain %q", got, w)
+				}
+			}
+		})
+	}
+}
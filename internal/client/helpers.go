@@ -1,6 +1,8 @@
 package client
 
 import (
+	"cashout/internal/model"
+
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 )
 
@@ -17,3 +19,11 @@ func GetMessageFromContext(ctx *ext.Context) string {
 
 	return msg
 }
+
+// FormatTransactionTags renders the tags of a transaction on a new line, or "" without tags
+func FormatTransactionTags(t model.Transaction) string {
+	if len(t.Tags) == 0 {
+		return ""
+	}
+	return "\n🏷 " + t.FormatTags()
+}
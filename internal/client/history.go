@@ -0,0 +1,118 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// historyLimit is the number of the most recent revisions shown in the bot,
+// the full history is available in the web dashboard
+const historyLimit = 10
+
+// revisionActionEmoji returns the emoji of a revision action
+func revisionActionEmoji(action model.RevisionAction) string {
+	switch action {
+	case model.RevisionCreated:
+		return "🆕"
+	case model.RevisionUpdated:
+		return "✏️"
+	case model.RevisionDeleted:
+		return "🗑"
+	case model.RevisionRestored:
+		return "♻️"
+	case model.RevisionPurged:
+		return "🔥"
+	default:
+		return "•"
+	}
+}
+
+// revisionSourceLabel returns where a change was made from, for display
+func revisionSourceLabel(source model.RevisionSource) string {
+	switch source {
+	case model.SourceBot:
+		return "bot"
+	case model.SourceWeb:
+		return "web dashboard"
+	case model.SourceAPIToken:
+		return "API token"
+	case model.SourceScheduler:
+		return "scheduler"
+	default:
+		return string(source)
+	}
+}
+
+// FormatTransactionHistory renders the most recent revisions of a transaction,
+// newest first, with the fields each of them changed
+func FormatTransactionHistory(revisions []model.TransactionRevision) string {
+	var sb strings.Builder
+	sb.WriteString("🕓 <b>History</b>\n")
+	if len(revisions) == 0 {
+		sb.WriteString("\nNo changes recorded for this transaction.")
+		return sb.String()
+	}
+	if len(revisions) > historyLimit {
+		fmt.Fprintf(&sb, "Showing the last %d of %d changes.\n", historyLimit, len(revisions))
+	}
+
+	shown := 0
+	for i := len(revisions) - 1; i >= 0 && shown < historyLimit; i-- {
+		r := revisions[i]
+		shown++
+		fmt.Fprintf(&sb, "\n%s <b>%s</b> · %s · %s\n",
+			revisionActionEmoji(r.Action),
+			capitalize(string(r.Action)),
+			r.CreatedAt.Format("02-01-2006 15:04"),
+			revisionSourceLabel(r.Source),
+		)
+		for _, change := range r.Changes() {
+			switch {
+			case change.Before == "":
+				fmt.Fprintf(&sb, "   %s: %s\n", change.Field, html.EscapeString(change.After))
+			case change.After == "":
+				fmt.Fprintf(&sb, "   %s: <s>%s</s>\n", change.Field, html.EscapeString(change.Before))
+			default:
+				fmt.Fprintf(&sb, "   %s: %s → %s\n", change.Field, html.EscapeString(change.Before), html.EscapeString(change.After))
+			}
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// EditTransactionHistory handles edit.history.<transaction ID>, the History
+// button of the edit view.
+func (c *Client) EditTransactionHistory(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %w", err)
+	}
+
+	revisions, err := c.Repositories.Transactions.History(user.TgID, id)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		return c.SendHomeKeyboard(b, ctx, "⚠️ The transaction no longer exists.")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get transaction history: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "⬅️ Back", CallbackData: fmt.Sprintf("edit.select.%d", id)}},
+	}
+	return SendMessage(ctx, b, FormatTransactionHistory(revisions), keyboard)
+}
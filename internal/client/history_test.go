@@ -0,0 +1,102 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cashout/internal/model"
+)
+
+func TestFormatTransactionHistory(t *testing.T) {
+	at := time.Date(2026, 5, 20, 18, 30, 0, 0, time.UTC)
+	transaction := model.Transaction{
+		ID:          7,
+		TgID:        1,
+		Type:        model.TypeExpense,
+		Category:    model.CategoryGrocery,
+		Amount:      model.NewMoney(12.5),
+		Currency:    model.CurrencyEUR,
+		Description: "Milk & eggs",
+		Date:        time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC),
+	}
+	edited := transaction
+	edited.Amount = model.NewMoney(15)
+	edited.Description = "Milk"
+
+	revision := func(action model.RevisionAction, actor model.Actor, before, after *model.Transaction) model.TransactionRevision {
+		r := model.NewTransactionRevision(action, actor, before, after)
+		r.CreatedAt = at
+		return r
+	}
+	bot := model.Actor{TgID: 1, Source: model.SourceBot}
+	web := model.Actor{TgID: 1, Source: model.SourceWeb}
+
+	var many []model.TransactionRevision
+	for i := range historyLimit + 2 {
+		e := edited
+		e.Description = fmt.Sprintf("Edit %d", i)
+		many = append(many, revision(model.RevisionUpdated, web, &edited, &e))
+	}
+
+	tests := []struct {
+		name      string
+		revisions []model.TransactionRevision
+		want      []string
+		notWant   []string
+	}{
+		{
+			name: "empty",
+			want: []string{"No changes recorded"},
+		},
+		{
+			name: "created, updated and deleted",
+			revisions: []model.TransactionRevision{
+				revision(model.RevisionCreated, bot, nil, &transaction),
+				revision(model.RevisionUpdated, web, &transaction, &edited),
+				revision(model.RevisionDeleted, model.SchedulerActor, &edited, nil),
+			},
+			want: []string{
+				"🆕 <b>Created</b> · 20-05-2026 18:30 · bot",
+				"Type: Expense\n   Category: Grocery",
+				"✏️ <b>Updated</b> · 20-05-2026 18:30 · web dashboard",
+				"Amount: 12.50 EUR → 15.00 EUR",
+				"Description: Milk &amp; eggs → Milk",
+				"🗑 <b>Deleted</b> · 20-05-2026 18:30 · scheduler",
+			},
+			notWant: []string{"Date: 2026-05-20 →", "Showing"},
+		},
+		{
+			name:      "newest first and limited",
+			revisions: many,
+			want:      []string{fmt.Sprintf("Showing the last %d of %d changes.", historyLimit, historyLimit+2), fmt.Sprintf("Edit %d", historyLimit+1)},
+			notWant:   []string{"→ Edit 0\n", "→ Edit 1\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatTransactionHistory(tt.revisions)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("FormatTransactionHistory() = %q, want it to contain %q", got, w)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("FormatTransactionHistory() = %q, want it not to contain %q", got, w)
+				}
+			}
+		})
+	}
+
+	// The newest revision comes first
+	got := FormatTransactionHistory([]model.TransactionRevision{
+		revision(model.RevisionCreated, bot, nil, &transaction),
+		revision(model.RevisionUpdated, web, &transaction, &edited),
+	})
+	if strings.Index(got, "Updated") > strings.Index(got, "Created") {
+		t.Errorf("FormatTransactionHistory() = %q, want the newest revision first", got)
+	}
+}
@@ -4,68 +4,44 @@ import (
 	"fmt"
 
 	"cashout/internal/model"
-	"cashout/internal/utils"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 )
 
-var incomeCategories = []model.TransactionCategory{
-	model.CategorySalary,
-	model.CategoryOtherIncomes,
-}
-
-var expenseCategories = []model.TransactionCategory{
-	model.CategoryCar,
-	model.CategoryClothes,
-	model.CategoryGrocery,
-	model.CategoryHouse,
-	model.CategoryBills,
-	model.CategoryEntertainment,
-	model.CategorySport,
-	model.CategoryEatingOut,
-	model.CategoryTransport,
-	model.CategoryLearning,
-	model.CategoryToiletry,
-	model.CategoryHealth,
-	model.CategoryTech,
-	model.CategoryGifts,
-	model.CategoryTravel,
-	model.CategoryPets,
-	model.CategoryOtherExpenses,
-}
-
 // BuildCategoryInlineKeyboard renders the category picker used by every flow.
 //
+//   - categories: the user's categories, archived ones are never shown.
 //   - txType: if "" show both income+expense; if Income/Expense filter accordingly.
 //   - callbackPrefix: e.g. "list.cat" produces buttons "list.cat.<CATEGORY>".
 //   - cancelCallback: full callback string for the Cancel row (empty to omit).
 //   - includeAll: prepend an "🔍 All Categories" row with callback "<prefix>.all".
-func BuildCategoryInlineKeyboard(txType model.TransactionType, callbackPrefix, cancelCallback string, includeAll bool) [][]gotgbot.InlineKeyboardButton {
+func BuildCategoryInlineKeyboard(categories model.Categories, txType model.TransactionType, callbackPrefix, cancelCallback string, includeAll bool) [][]gotgbot.InlineKeyboardButton {
 	var keyboard [][]gotgbot.InlineKeyboardButton
 
 	if txType == "" || txType == model.TypeIncome {
-		for _, cat := range incomeCategories {
+		for _, cat := range categories.OfType(model.TypeIncome) {
 			keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
 				{
-					Text:         fmt.Sprintf("%s %s", utils.GetCategoryEmoji(cat), cat),
-					CallbackData: fmt.Sprintf("%s.%s", callbackPrefix, cat),
+					Text:         cat.Label(),
+					CallbackData: fmt.Sprintf("%s.%s", callbackPrefix, cat.Name),
 				},
 			})
 		}
 	}
 
 	if txType == "" || txType == model.TypeExpense {
+		expenseCategories := categories.OfType(model.TypeExpense)
 		for i := 0; i < len(expenseCategories); i += 2 {
 			row := []gotgbot.InlineKeyboardButton{
 				{
-					Text:         fmt.Sprintf("%s %s", utils.GetCategoryEmoji(expenseCategories[i]), expenseCategories[i]),
-					CallbackData: fmt.Sprintf("%s.%s", callbackPrefix, expenseCategories[i]),
+					Text:         expenseCategories[i].Label(),
+					CallbackData: fmt.Sprintf("%s.%s", callbackPrefix, expenseCategories[i].Name),
 				},
 			}
 			if i+1 < len(expenseCategories) {
 				row = append(row, gotgbot.InlineKeyboardButton{
-					Text:         fmt.Sprintf("%s %s", utils.GetCategoryEmoji(expenseCategories[i+1]), expenseCategories[i+1]),
-					CallbackData: fmt.Sprintf("%s.%s", callbackPrefix, expenseCategories[i+1]),
+					Text:         expenseCategories[i+1].Label(),
+					CallbackData: fmt.Sprintf("%s.%s", callbackPrefix, expenseCategories[i+1].Name),
 				})
 			}
 			keyboard = append(keyboard, row)
@@ -0,0 +1,491 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cashout/internal/model"
+	"cashout/internal/repository"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// FormatLedgerMembers renders the expenses and incomes entered by each member
+// of a ledger, sorted by expenses, members without transactions included
+func FormatLedgerMembers(ledger model.Ledger, totals []repository.MemberTotal, cur string) string {
+	type memberRow struct {
+		name            string
+		expense, income model.Money
+	}
+
+	rows := make(map[int64]*memberRow, len(ledger.Members))
+	order := make([]int64, 0, len(ledger.Members))
+	for _, m := range ledger.Members {
+		rows[m.TgID] = &memberRow{name: m.DisplayName()}
+		order = append(order, m.TgID)
+	}
+	for _, t := range totals {
+		row, ok := rows[t.TgID]
+		if !ok {
+			// A former member, their transactions stay in the ledger
+			row = &memberRow{name: "Former member"}
+			rows[t.TgID] = row
+			order = append(order, t.TgID)
+		}
+		switch t.Type {
+		case model.TypeExpense:
+			row.expense += t.Total
+		case model.TypeIncome:
+			row.income += t.Total
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return rows[order[i]].expense > rows[order[j]].expense
+	})
+
+	var sb strings.Builder
+	sb.WriteString("\n\n👥 <b>By Member:</b>\n")
+	for _, id := range order {
+		row := rows[id]
+		fmt.Fprintf(&sb, "  <b>%s:</b> 💸 %.2f%s", html.EscapeString(row.name), row.expense, cur)
+		if row.income > 0 {
+			fmt.Fprintf(&sb, " 💰 %.2f%s", row.income, cur)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// ledgerRecap returns the title of the user's active ledger for the recap
+// headers and the breakdown of its totals by member over the period, both
+// empty for personal recaps. Failures are logged so the recap is still sent.
+func (c *Client) ledgerRecap(user model.User, startDate, endDate time.Time) (string, string) {
+	if user.LedgerID == nil {
+		return "", ""
+	}
+
+	ledger, err := c.Repositories.Ledgers.Get(user.TgID, *user.LedgerID)
+	if err != nil {
+		c.Logger.Warnf("failed to get ledger for recap: %v", err)
+		return "", ""
+	}
+	title := fmt.Sprintf("👥 <b>%s</b>\n", html.EscapeString(ledger.Name))
+
+	totals, err := c.Repositories.Ledgers.MemberTotals(ledger.ID, startDate, endDate)
+	if err != nil {
+		c.Logger.Warnf("failed to get ledger member totals: %v", err)
+		return title, ""
+	}
+	return title, FormatLedgerMembers(ledger, totals, user.BaseCurrency.Symbol())
+}
+
+// LedgerCommand handles /ledger, "/ledger join <code>" joins a ledger directly.
+func (c *Client) LedgerCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	if ctx.Message != nil {
+		parts := strings.Fields(ctx.Message.Text)
+		if len(parts) > 2 && strings.EqualFold(parts[1], "join") {
+			_, u := c.getUserFromContext(ctx)
+			user, err := c.authAndGetUser(u)
+			if err != nil {
+				return err
+			}
+			return c.joinLedger(b, ctx, user, strings.Join(parts[2:], ""))
+		}
+	}
+	return c.ShowLedgers(b, ctx)
+}
+
+// ShowLedgers renders the ledgers of the user, the active one and the actions to create or join one.
+func (c *Client) ShowLedgers(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	ledgers, err := c.Repositories.Ledgers.List(user.TgID)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("👥 <b>Shared Ledgers</b>\n\n")
+	sb.WriteString("Share your transactions with your partner or housemates: the recaps and the budget of a ledger include the transactions of all its members.\n\n")
+
+	active := "👤 Personal"
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for _, l := range ledgers {
+		member, _ := l.Member(user.TgID)
+		label := fmt.Sprintf("👥 %s (%s)", l.Name, member.Role)
+		if user.LedgerID != nil && *user.LedgerID == l.ID {
+			active = "👥 " + l.Name
+			label += " ✅"
+		}
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: label, CallbackData: fmt.Sprintf("ledger.show.%d", l.ID)}})
+	}
+
+	if len(ledgers) == 0 {
+		sb.WriteString("You are not in any ledger yet, create one and share its invite code or join one with the code you received.")
+	} else {
+		fmt.Fprintf(&sb, "Active: <b>%s</b>, new transactions, recaps and the budget refer to it.", html.EscapeString(active))
+	}
+
+	if user.LedgerID != nil {
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "👤 Use Personal", CallbackData: "ledger.use.0"}})
+	}
+	keyboard = append(keyboard,
+		[]gotgbot.InlineKeyboardButton{
+			{Text: "➕ New Ledger", CallbackData: "ledger.new"},
+			{Text: "🔑 Join", CallbackData: "ledger.join"},
+		},
+		[]gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}},
+	)
+
+	return SendMessage(ctx, b, sb.String(), keyboard)
+}
+
+// LedgerDetails handles ledger.show.<ID>.
+func (c *Client) LedgerDetails(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := c.getCallbackLedger(ctx, user, 1)
+	if err != nil {
+		return err
+	}
+	return c.showLedgerDetails(b, ctx, user, ledger, "")
+}
+
+func (c *Client) showLedgerDetails(b *gotgbot.Bot, ctx *ext.Context, user model.User, ledger model.Ledger, notice string) error {
+	me, _ := ledger.Member(user.TgID)
+	id := strconv.FormatInt(ledger.ID, 10)
+	isActive := user.LedgerID != nil && *user.LedgerID == ledger.ID
+
+	var sb strings.Builder
+	if notice != "" {
+		sb.WriteString(notice + "\n\n")
+	}
+	fmt.Fprintf(&sb, "👥 <b>%s</b>\n\nCurrency: %s\nYour role: %s\n", html.EscapeString(ledger.Name), ledger.Currency, me.Role)
+	if isActive {
+		sb.WriteString("Status: active\n")
+	}
+	sb.WriteString("\n<b>Members:</b>\n")
+	for _, m := range ledger.Members {
+		fmt.Fprintf(&sb, "• %s (%s)\n", html.EscapeString(m.DisplayName()), m.Role)
+	}
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	if me.Role.CanManage() {
+		fmt.Fprintf(&sb, "\nInvite code: <code>%s</code>\nNew members send <code>/ledger join %s</code> to the bot and join as editors.", ledger.InviteCode, ledger.InviteCode)
+
+		// Tapping a member cycles their role, ❌ removes them
+		for _, m := range ledger.Members {
+			if m.TgID == user.TgID {
+				continue
+			}
+			keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+				{Text: fmt.Sprintf("🔄 %s: %s", m.DisplayName(), m.Role), CallbackData: fmt.Sprintf("ledger.role.%s.%d", id, m.TgID)},
+				{Text: "❌", CallbackData: fmt.Sprintf("ledger.remove.%s.%d", id, m.TgID)},
+			})
+		}
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🔑 New Invite Code", CallbackData: "ledger.code." + id}})
+	}
+
+	var row []gotgbot.InlineKeyboardButton
+	if isActive {
+		row = append(row, gotgbot.InlineKeyboardButton{Text: "👤 Use Personal", CallbackData: "ledger.use.0"})
+	} else {
+		row = append(row, gotgbot.InlineKeyboardButton{Text: "✅ Use This Ledger", CallbackData: "ledger.use." + id})
+	}
+	row = append(row, gotgbot.InlineKeyboardButton{Text: "🚪 Leave", CallbackData: "ledger.leave." + id})
+	keyboard = append(keyboard, row, []gotgbot.InlineKeyboardButton{{Text: "⬅️ Back", CallbackData: "ledger.list"}})
+
+	return SendMessage(ctx, b, sb.String(), keyboard)
+}
+
+// LedgerUse handles ledger.use.<ID>, switching the active ledger; 0 switches
+// back to the personal transactions.
+func (c *Client) LedgerUse(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid ledger ID: %w", err)
+	}
+
+	if id == 0 {
+		if err := c.Repositories.Ledgers.Switch(user.TgID, nil); err != nil {
+			return fmt.Errorf("failed to switch ledger: %w", err)
+		}
+		return c.SendHomeKeyboard(b, ctx, "👤 Back to your personal transactions, recaps and budget.")
+	}
+
+	if err := c.Repositories.Ledgers.Switch(user.TgID, &id); err != nil {
+		return fmt.Errorf("failed to switch ledger: %w", err)
+	}
+	ledger, err := c.Repositories.Ledgers.Get(user.TgID, id)
+	if err != nil {
+		return fmt.Errorf("failed to get ledger: %w", err)
+	}
+	return c.SendHomeKeyboard(b, ctx, ledgerActiveText(ledger, user.TgID))
+}
+
+// ledgerActiveText explains what the active ledger changes for the user
+func ledgerActiveText(ledger model.Ledger, tgID int64) string {
+	text := fmt.Sprintf("👥 <b>%s</b> is now active: recaps and the budget include the transactions of all its members.", html.EscapeString(ledger.Name))
+	if member, _ := ledger.Member(tgID); member.Role.CanWrite() {
+		return text + " Your new transactions are recorded in it."
+	}
+	return text + " As a viewer, your new transactions stay personal."
+}
+
+// LedgerNewPrompt handles ledger.new, waiting for the name of the new ledger.
+func (c *Client) LedgerNewPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	return c.ledgerPrompt(b, ctx, model.StateLedgerNewWaitName,
+		"Enter the name of the new ledger (e.g. <code>Home</code>). Its currency will be your base currency.")
+}
+
+// LedgerJoinPrompt handles ledger.join, waiting for an invite code.
+func (c *Client) LedgerJoinPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	return c.ledgerPrompt(b, ctx, model.StateLedgerJoinWaitCode, "Enter the invite code you received (e.g. <code>K7PX2QMA</code>):")
+}
+
+func (c *Client) ledgerPrompt(b *gotgbot.Bot, ctx *ext.Context, state model.StateType, text string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	user.Session.State = state
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "ledger.cancel"}},
+	}
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// LedgerFromMessage receives the name typed after LedgerNewPrompt.
+func (c *Client) LedgerFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	ledger := model.Ledger{Name: text}
+	err := c.Repositories.Ledgers.Create(&ledger, user)
+	if errors.Is(err, model.ErrInvalidLedger) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s, please try again.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create ledger: %w", err)
+	}
+
+	user.LedgerID = &ledger.ID
+	ledger, err = c.Repositories.Ledgers.Get(user.TgID, ledger.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get ledger: %w", err)
+	}
+	return c.showLedgerDetails(b, ctx, user, ledger, "✅ <b>Ledger created!</b> Share the invite code below with the people you want to add.")
+}
+
+// LedgerJoinFromMessage receives the invite code typed after LedgerJoinPrompt.
+func (c *Client) LedgerJoinFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+	return c.joinLedger(b, ctx, user, text)
+}
+
+func (c *Client) joinLedger(b *gotgbot.Bot, ctx *ext.Context, user model.User, code string) error {
+	ledger, err := c.Repositories.Ledgers.Join(user, code)
+	switch {
+	case errors.Is(err, model.ErrInvalidInviteCode):
+		return c.SendHomeKeyboard(b, ctx, "❌ Invalid invite code, please check it with the owner of the ledger.")
+	case errors.Is(err, model.ErrLedgerMember):
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("You are already a member of <b>%s</b>.", html.EscapeString(ledger.Name)))
+	case errors.Is(err, model.ErrLedgerCurrency):
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s. Change your base currency with /currency before joining.", html.EscapeString(capitalize(err.Error()))))
+	case err != nil:
+		return fmt.Errorf("failed to join ledger: %w", err)
+	}
+
+	return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("✅ You joined <b>%s</b>.\n\n%s", html.EscapeString(ledger.Name), ledgerActiveText(ledger, user.TgID)))
+}
+
+// LedgerRoleCycle handles ledger.role.<ID>.<TG ID>, giving the member the next role.
+func (c *Client) LedgerRoleCycle(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := c.getCallbackLedger(ctx, user, 2)
+	if err != nil {
+		return err
+	}
+	member, err := getCallbackMember(ctx, ledger)
+	if err != nil {
+		return err
+	}
+
+	return c.ledgerMemberResult(b, ctx, user, ledger.ID,
+		c.Repositories.Ledgers.SetRole(user.TgID, ledger.ID, member.TgID, member.Role.Next()))
+}
+
+// LedgerRemoveMember handles ledger.remove.<ID>.<TG ID>, only owners remove other members.
+func (c *Client) LedgerRemoveMember(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := c.getCallbackLedger(ctx, user, 2)
+	if err != nil {
+		return err
+	}
+	member, err := getCallbackMember(ctx, ledger)
+	if err != nil {
+		return err
+	}
+
+	return c.ledgerMemberResult(b, ctx, user, ledger.ID,
+		c.Repositories.Ledgers.RemoveMember(user.TgID, ledger.ID, member.TgID))
+}
+
+// ledgerMemberResult shows the ledger again after a change to its members,
+// with the reason when it was refused
+func (c *Client) ledgerMemberResult(b *gotgbot.Bot, ctx *ext.Context, user model.User, id int64, err error) error {
+	notice := ""
+	if errors.Is(err, model.ErrLedgerForbidden) || errors.Is(err, model.ErrLedgerLastOwner) {
+		notice = fmt.Sprintf("❌ %s.", capitalize(err.Error()))
+	} else if err != nil {
+		return fmt.Errorf("failed to update ledger member: %w", err)
+	}
+
+	ledger, err := c.Repositories.Ledgers.Get(user.TgID, id)
+	if err != nil {
+		return fmt.Errorf("failed to get ledger: %w", err)
+	}
+	return c.showLedgerDetails(b, ctx, user, ledger, notice)
+}
+
+// LedgerLeave handles ledger.leave.<ID>, the ledger is deleted with its last member.
+func (c *Client) LedgerLeave(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := c.getCallbackLedger(ctx, user, 1)
+	if err != nil {
+		return err
+	}
+
+	err = c.Repositories.Ledgers.RemoveMember(user.TgID, ledger.ID, user.TgID)
+	if errors.Is(err, model.ErrLedgerLastOwner) {
+		return c.showLedgerDetails(b, ctx, user, ledger, "❌ You are the only owner: make another member an owner before leaving.")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to leave ledger: %w", err)
+	}
+
+	return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("🚪 You left <b>%s</b>, the transactions you recorded in it stay there.", html.EscapeString(ledger.Name)))
+}
+
+// LedgerResetCode handles ledger.code.<ID>, replacing the invite code.
+func (c *Client) LedgerResetCode(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	ledger, err := c.getCallbackLedger(ctx, user, 1)
+	if err != nil {
+		return err
+	}
+
+	updated, err := c.Repositories.Ledgers.ResetInviteCode(user.TgID, ledger.ID)
+	if errors.Is(err, model.ErrLedgerForbidden) {
+		return c.showLedgerDetails(b, ctx, user, ledger, fmt.Sprintf("❌ %s.", capitalize(err.Error())))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reset invite code: %w", err)
+	}
+	return c.showLedgerDetails(b, ctx, user, updated, "🔑 New invite code generated, the previous one no longer works.")
+}
+
+// LedgersCancel resets state and returns to home.
+func (c *Client) LedgersCancel(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+	return c.SendHomeKeyboard(b, ctx, "Operation cancelled.")
+}
+
+// getCallbackLedger returns the ledger whose ID is the part of the callback
+// data at the given position from the end (1 for the last one).
+func (c *Client) getCallbackLedger(ctx *ext.Context, user model.User, fromEnd int) (model.Ledger, error) {
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	if len(parts) < fromEnd+2 {
+		return model.Ledger{}, fmt.Errorf("invalid callback data: %s", ctx.CallbackQuery.Data)
+	}
+	id, err := strconv.ParseInt(parts[len(parts)-fromEnd], 10, 64)
+	if err != nil {
+		return model.Ledger{}, fmt.Errorf("invalid ledger ID: %w", err)
+	}
+
+	ledger, err := c.Repositories.Ledgers.Get(user.TgID, id)
+	if err != nil {
+		return model.Ledger{}, fmt.Errorf("failed to get ledger: %w", err)
+	}
+	return ledger, nil
+}
+
+// getCallbackMember returns the member of the ledger whose Telegram ID is the last part of the callback data.
+func getCallbackMember(ctx *ext.Context, ledger model.Ledger) (model.LedgerMember, error) {
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	tgID, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return model.LedgerMember{}, fmt.Errorf("invalid member ID: %w", err)
+	}
+
+	member, ok := ledger.Member(tgID)
+	if !ok {
+		return model.LedgerMember{}, fmt.Errorf("user %d is not a member of ledger %d", tgID, ledger.ID)
+	}
+	return member, nil
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"cashout/internal/model"
+	"cashout/internal/repository"
+)
+
+func TestFormatLedgerMembers(t *testing.T) {
+	ledger := model.Ledger{
+		Members: []model.LedgerMember{
+			{TgID: 1, Role: model.LedgerOwner, User: &model.User{TgID: 1, Name: "Alice"}},
+			{TgID: 2, Role: model.LedgerEditor, User: &model.User{TgID: 2, TgUsername: "bob"}},
+			{TgID: 3, Role: model.LedgerViewer},
+		},
+	}
+	totals := []repository.MemberTotal{
+		{TgID: 1, Type: model.TypeExpense, Total: model.NewMoney(120)},
+		{TgID: 2, Type: model.TypeExpense, Total: model.NewMoney(300.5)},
+		{TgID: 2, Type: model.TypeIncome, Total: model.NewMoney(1000)},
+		{TgID: 4, Type: model.TypeExpense, Total: model.NewMoney(10)},
+	}
+
+	got := FormatLedgerMembers(ledger, totals, "€")
+
+	want := []string{
+		"👥 <b>By Member:</b>",
+		"  <b>bob:</b> 💸 300.50€ 💰 1000.00€",
+		"  <b>Alice:</b> 💸 120.00€",
+		"  <b>Former member:</b> 💸 10.00€",
+		"  <b>User 3:</b> 💸 0.00€",
+	}
+	if lines := strings.Split(strings.TrimSpace(got), "\n"); strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected breakdown:\n%s", got)
+	}
+}
@@ -2,7 +2,6 @@ package client
 
 import (
 	"cashout/internal/model"
-	"cashout/internal/utils"
 	"fmt"
 	"strconv"
 	"strings"
@@ -25,7 +24,10 @@ func (c *Client) ListTransactions(b *gotgbot.Bot, ctx *ext.Context) error {
 
 // showListCategorySelection displays the category selection keyboard (mirrors search)
 func (c *Client) showListCategorySelection(b *gotgbot.Bot, ctx *ext.Context) error {
-	keyboard := BuildCategoryInlineKeyboard("", "list.cat", "list.cancel", true)
+	keyboard, err := c.buildUserCategoryKeyboard(ctx, "", "list.cat", "list.cancel", true)
+	if err != nil {
+		return err
+	}
 
 	message := "📋 <b>List Transactions</b>\n\nSelect a category to browse:"
 
@@ -39,7 +41,7 @@ func (c *Client) showListCategorySelection(b *gotgbot.Bot, ctx *ext.Context) err
 		return err
 	}
 
-	_, err := b.SendMessage(ctx.EffectiveSender.ChatId, message, &gotgbot.SendMessageOpts{
+	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, message, &gotgbot.SendMessageOpts{
 		ParseMode: "HTML",
 		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
 			InlineKeyboard: keyboard,
@@ -51,7 +53,7 @@ func (c *Client) showListCategorySelection(b *gotgbot.Bot, ctx *ext.Context) err
 // ListCategorySelected handles category selection and shows month picker
 func (c *Client) ListCategorySelected(b *gotgbot.Bot, ctx *ext.Context) error {
 	_, u := c.getUserFromContext(ctx)
-	_, err := c.authAndGetUser(u)
+	user, err := c.authAndGetUser(u)
 	if err != nil {
 		return err
 	}
@@ -63,15 +65,14 @@ func (c *Client) ListCategorySelected(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	category := parts[2] // "all" or a category name
-	currentYear := time.Now().Year()
-	return c.sendMonthSelectionKeyboard(b, ctx, currentYear, category)
+	return c.sendMonthSelectionKeyboard(b, ctx, user, user.Today().Year(), category)
 }
 
 // ListYearNavigation handles year navigation in month selection
 // Callback format: list.year.YYYY.CATEGORY
 func (c *Client) ListYearNavigation(b *gotgbot.Bot, ctx *ext.Context) error {
 	_, u := c.getUserFromContext(ctx)
-	_, err := c.authAndGetUser(u)
+	user, err := c.authAndGetUser(u)
 	if err != nil {
 		return err
 	}
@@ -88,7 +89,7 @@ func (c *Client) ListYearNavigation(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	category := parts[3]
-	return c.sendMonthSelectionKeyboard(b, ctx, year, category)
+	return c.sendMonthSelectionKeyboard(b, ctx, user, year, category)
 }
 
 // ListMonthTransactions displays transactions for selected month
@@ -154,10 +155,12 @@ func (c *Client) ListTransactionPage(b *gotgbot.Bot, ctx *ext.Context) error {
 	return c.showTransactionPage(b, ctx, user, year, month, offset, category)
 }
 
-// sendMonthSelectionKeyboard renders the month picker with category threaded through
-func (c *Client) sendMonthSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Context, year int, category string) error {
-	currentYear := time.Now().Year()
-	currentMonth := time.Now().Month()
+// sendMonthSelectionKeyboard renders the month picker with category threaded
+// through, up to the current month of the user
+func (c *Client) sendMonthSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Context, user model.User, year int, category string) error {
+	today := user.Today()
+	currentYear := today.Year()
+	currentMonth := today.Month()
 
 	var keyboard [][]gotgbot.InlineKeyboardButton
 
@@ -221,7 +224,7 @@ func (c *Client) sendMonthSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Context, ye
 	// Header text
 	headerCategory := "all categories"
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := c.displayCategories(user.TgID).Emoji(model.TransactionCategory(category))
 		headerCategory = fmt.Sprintf("%s %s", emoji, category)
 	}
 
@@ -261,7 +264,7 @@ func (c *Client) showTransactionPage(b *gotgbot.Bot, ctx *ext.Context, user mode
 		return fmt.Errorf("failed to get transactions: %w", err)
 	}
 
-	message := formatTransactions(year, month, transactions, offset, int(total), category)
+	message := formatTransactions(year, month, transactions, c.displayCategories(user.TgID), offset, int(total), category)
 	keyboard := createPaginationKeyboard(year, month, offset, limit, int(total), category)
 
 	if ctx.CallbackQuery != nil {
@@ -284,11 +287,11 @@ func (c *Client) showTransactionPage(b *gotgbot.Bot, ctx *ext.Context, user mode
 }
 
 // formatTransactions formats the compact transaction list
-func formatTransactions(year, month int, transactions []model.Transaction, offset, total int, category string) string {
+func formatTransactions(year, month int, transactions []model.Transaction, categories model.Categories, offset, total int, category string) string {
 	if len(transactions) == 0 {
 		msg := fmt.Sprintf("No transactions found for %s %d", time.Month(month).String(), year)
 		if category != "all" {
-			emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+			emoji := categories.Emoji(model.TransactionCategory(category))
 			msg += fmt.Sprintf(" in %s %s", emoji, category)
 		}
 		return msg
@@ -299,7 +302,7 @@ func formatTransactions(year, month int, transactions []model.Transaction, offse
 	// Header with optional category
 	headerCategory := ""
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := categories.Emoji(model.TransactionCategory(category))
 		headerCategory = fmt.Sprintf(" · %s %s", emoji, category)
 	}
 
@@ -307,10 +310,13 @@ func formatTransactions(year, month int, transactions []model.Transaction, offse
 	fmt.Fprintf(&msg, "Showing %d–%d of %d\n\n", offset+1, offset+len(transactions), total)
 
 	for _, t := range transactions {
-		emoji := utils.GetCategoryEmoji(t.Category)
+		emoji := categories.Emoji(t.Category)
 		sign := "-"
-		if t.Type == model.TypeIncome {
+		switch t.Type {
+		case model.TypeIncome:
 			sign = "+"
+		case model.TypeTransfer:
+			sign = "↔"
 		}
 		fmt.Fprintf(&msg, "%s <b>%s</b> · %s€%.2f · %s\n",
 			emoji, t.Description, sign, t.Amount, t.Date.Format("02/01/2006"))
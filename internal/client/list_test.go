@@ -8,7 +8,7 @@ import (
 )
 
 func TestFormatTransactions_Empty(t *testing.T) {
-	result := formatTransactions(2026, 2, nil, 0, 0, "all")
+	result := formatTransactions(2026, 2, nil, model.DefaultCategories(1), 0, 0, "all")
 	expected := "No transactions found for February 2026"
 	if result != expected {
 		t.Errorf("expected %q, got %q", expected, result)
@@ -16,7 +16,7 @@ func TestFormatTransactions_Empty(t *testing.T) {
 }
 
 func TestFormatTransactions_EmptyWithCategory(t *testing.T) {
-	result := formatTransactions(2026, 2, nil, 0, 0, "Grocery")
+	result := formatTransactions(2026, 2, nil, model.DefaultCategories(1), 0, 0, "Grocery")
 	if !strings.Contains(result, "No transactions found for February 2026") {
 		t.Error("missing base message")
 	}
@@ -29,21 +29,21 @@ func TestFormatTransactions_CompactSingleLine(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			Description: "Grocery Shopping",
-			Amount:      45.00,
+			Amount:      model.NewMoney(45.00),
 			Category:    model.CategoryGrocery,
 			Type:        model.TypeExpense,
 			Date:        time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC),
 		},
 		{
 			Description: "January Salary",
-			Amount:      3000.00,
+			Amount:      model.NewMoney(3000.00),
 			Category:    model.CategorySalary,
 			Type:        model.TypeIncome,
 			Date:        time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
-	result := formatTransactions(2026, 2, txns, 0, 2, "all")
+	result := formatTransactions(2026, 2, txns, model.DefaultCategories(1), 0, 2, "all")
 
 	if !strings.Contains(result, "<b>February 2026</b>") {
 		t.Error("missing month/year header")
@@ -65,31 +65,54 @@ func TestFormatTransactions_WithCategoryHeader(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			Description: "Lidl",
-			Amount:      25.00,
+			Amount:      model.NewMoney(25.00),
 			Category:    model.CategoryGrocery,
 			Type:        model.TypeExpense,
 			Date:        time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
-	result := formatTransactions(2026, 2, txns, 0, 1, "Grocery")
+	result := formatTransactions(2026, 2, txns, model.DefaultCategories(1), 0, 1, "Grocery")
 	if !strings.Contains(result, "🛒 Grocery") {
 		t.Errorf("missing category in header, got:\n%s", result)
 	}
 }
 
+func TestFormatTransactions_UserCategoryEmoji(t *testing.T) {
+	categories := model.Categories{
+		{TgID: 1, Name: "Coffee", Type: model.TypeExpense, Emoji: "☕"},
+	}
+	txns := []model.Transaction{
+		{
+			Description: "Espresso",
+			Amount:      model.NewMoney(1.20),
+			Category:    "Coffee",
+			Type:        model.TypeExpense,
+			Date:        time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	result := formatTransactions(2026, 2, txns, categories, 0, 1, "Coffee")
+	if !strings.Contains(result, "☕ Coffee") {
+		t.Errorf("missing user category emoji in header, got:\n%s", result)
+	}
+	if !strings.Contains(result, "☕ <b>Espresso</b>") {
+		t.Errorf("missing user category emoji in line, got:\n%s", result)
+	}
+}
+
 func TestFormatTransactions_Offset(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			Description: "Test",
-			Amount:      10.00,
+			Amount:      model.NewMoney(10.00),
 			Category:    model.CategoryBills,
 			Type:        model.TypeExpense,
 			Date:        time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
-	result := formatTransactions(2026, 2, txns, 10, 15, "all")
+	result := formatTransactions(2026, 2, txns, model.DefaultCategories(1), 10, 15, "all")
 	if !strings.Contains(result, "Showing 11–11 of 15") {
 		t.Errorf("offset counting wrong, got:\n%s", result)
 	}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"cashout/internal/model"
-	"cashout/internal/utils"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -29,14 +28,14 @@ func (c *Client) MonthRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	// Start with current year
-	currentYear := time.Now().Year()
-	return c.sendMonthRecapSelectionKeyboard(b, ctx, currentYear)
+	today := user.Today()
+	return c.sendMonthRecapSelectionKeyboard(b, ctx, today, today.Year())
 }
 
 // MonthRecapYearNavigation handles year navigation in month selection for recap
 func (c *Client) MonthRecapYearNavigation(b *gotgbot.Bot, ctx *ext.Context) error {
 	_, u := c.getUserFromContext(ctx)
-	_, err := c.authAndGetUser(u)
+	user, err := c.authAndGetUser(u)
 	if err != nil {
 		return err
 	}
@@ -54,7 +53,7 @@ func (c *Client) MonthRecapYearNavigation(b *gotgbot.Bot, ctx *ext.Context) erro
 		return fmt.Errorf("invalid year: %v", err)
 	}
 
-	return c.sendMonthRecapSelectionKeyboard(b, ctx, year)
+	return c.sendMonthRecapSelectionKeyboard(b, ctx, user.Today(), year)
 }
 
 // MonthRecapSelected displays the recap for selected month
@@ -86,10 +85,11 @@ func (c *Client) MonthRecapSelected(b *gotgbot.Bot, ctx *ext.Context) error {
 	return c.showMonthRecap(b, ctx, user, year, month)
 }
 
-// Helper function to send month selection keyboard for recap
-func (c *Client) sendMonthRecapSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Context, year int) error {
-	currentYear := time.Now().Year()
-	currentMonth := time.Now().Month()
+// Helper function to send month selection keyboard for recap, today is the
+// current day of the user
+func (c *Client) sendMonthRecapSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Context, today time.Time, year int) error {
+	currentYear := today.Year()
+	currentMonth := today.Month()
 
 	var keyboard [][]gotgbot.InlineKeyboardButton
 
@@ -171,13 +171,13 @@ func (c *Client) sendMonthRecapSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Contex
 // Helper function to show the month recap for a specific month
 func (c *Client) showMonthRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User, year int, month int) error {
 	// Get monthly totals
-	totals, err := c.Repositories.Transactions.GetMonthlyTotalsInYear(user.TgID, year)
+	totals, err := c.Repositories.Transactions.GetMonthlyTotalsInYear(user.Scope(), year)
 	if err != nil {
 		return err
 	}
 
 	// Get category breakdown
-	categoryTotals, err := c.Repositories.Transactions.GetMonthCategorizedTotals(user.TgID, year, month)
+	categoryTotals, err := c.Repositories.Transactions.GetMonthCategorizedTotals(user.Scope(), year, month)
 	if err != nil {
 		return err
 	}
@@ -186,20 +186,25 @@ func (c *Client) showMonthRecap(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 
 	if !ok {
 		txt := fmt.Sprintf("No transactions for %s %d", time.Month(month).String(), year)
-		return c.sendRecapWithNavigation(b, ctx, txt, "month", year, month)
+		return c.sendRecapWithNavigation(b, ctx, user.Today(), txt, "month", year, month)
 	}
 
 	// Format the message
 	var text strings.Builder
-	var monthTotal float64
+	cur := user.BaseCurrency.Symbol()
+	userCategories := c.displayCategories(user.TgID)
+	var monthTotal model.Money
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	ledgerTitle, ledgerMembers := c.ledgerRecap(user, start, start.AddDate(0, 1, -1))
 
 	// Header with month name
-	fmt.Fprintf(&text, "📊 <b>%s %d Summary</b>\n\n", time.Month(month).String(), year)
+	fmt.Fprintf(&text, "📊 <b>%s %d Summary</b>\n%s\n", time.Month(month).String(), year, ledgerTitle)
 
 	// --- EXPENSES SECTION ---
 	if expenseAmount, ok := t[model.TypeExpense]; ok && expenseAmount > 0 {
 		monthTotal -= expenseAmount
-		fmt.Fprintf(&text, "💸 <b>Expenses:</b> %.2f€\n", expenseAmount)
+		fmt.Fprintf(&text, "💸 <b>Expenses:</b> %.2f%s\n", expenseAmount, cur)
 
 		// Add category breakdown for expenses
 		if expenseCats, ok := categoryTotals[model.TypeExpense]; ok && len(expenseCats) > 0 {
@@ -208,13 +213,13 @@ func (c *Client) showMonthRecap(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 			// Sort categories by amount (descending)
 			categories := make([]struct {
 				Category model.TransactionCategory
-				Amount   float64
+				Amount   model.Money
 			}, 0, len(expenseCats))
 
 			for cat, amount := range expenseCats {
 				categories = append(categories, struct {
 					Category model.TransactionCategory
-					Amount   float64
+					Amount   model.Money
 				}{cat, amount})
 			}
 
@@ -224,10 +229,10 @@ func (c *Client) showMonthRecap(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 
 			// Display each category with emoji
 			for _, entry := range categories {
-				emoji := utils.GetCategoryEmoji(entry.Category)
-				percentage := (entry.Amount / expenseAmount) * 100
-				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f€ (%.1f%%)\n",
-					emoji, entry.Category, entry.Amount, percentage)
+				emoji := userCategories.Emoji(entry.Category)
+				percentage := entry.Amount.Float64() / expenseAmount.Float64() * 100
+				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f%s (%.1f%%)\n",
+					emoji, entry.Category, entry.Amount, cur, percentage)
 			}
 			text.WriteString("\n")
 		}
@@ -236,7 +241,7 @@ func (c *Client) showMonthRecap(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 	// --- INCOME SECTION ---
 	if incomeAmount, ok := t[model.TypeIncome]; ok && incomeAmount > 0 {
 		monthTotal += incomeAmount
-		fmt.Fprintf(&text, "💰 <b>Income:</b> %.2f€\n", incomeAmount)
+		fmt.Fprintf(&text, "💰 <b>Income:</b> %.2f%s\n", incomeAmount, cur)
 
 		// Add category breakdown for income
 		if incomeCats, ok := categoryTotals[model.TypeIncome]; ok && len(incomeCats) > 0 {
@@ -245,13 +250,13 @@ func (c *Client) showMonthRecap(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 			// Sort categories by amount (descending)
 			categories := make([]struct {
 				Category model.TransactionCategory
-				Amount   float64
+				Amount   model.Money
 			}, 0, len(incomeCats))
 
 			for cat, amount := range incomeCats {
 				categories = append(categories, struct {
 					Category model.TransactionCategory
-					Amount   float64
+					Amount   model.Money
 				}{cat, amount})
 			}
 
@@ -261,10 +266,10 @@ func (c *Client) showMonthRecap(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 
 			// Display each category with emoji
 			for _, entry := range categories {
-				emoji := utils.GetCategoryEmoji(entry.Category)
-				percentage := (entry.Amount / incomeAmount) * 100
-				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f€ (%.1f%%)\n",
-					emoji, entry.Category, entry.Amount, percentage)
+				emoji := userCategories.Emoji(entry.Category)
+				percentage := entry.Amount.Float64() / incomeAmount.Float64() * 100
+				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f%s (%.1f%%)\n",
+					emoji, entry.Category, entry.Amount, cur, percentage)
 			}
 			text.WriteString("\n")
 		}
@@ -278,7 +283,8 @@ func (c *Client) showMonthRecap(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 		balanceEmoji = "❌"
 	}
 
-	fmt.Fprintf(&text, "\n%s <b>Month Balance:</b> %.2f€", balanceEmoji, monthTotal)
+	fmt.Fprintf(&text, "\n%s <b>Month Balance:</b> %.2f%s", balanceEmoji, monthTotal, cur)
+	text.WriteString(ledgerMembers)
 
-	return c.sendRecapWithNavigation(b, ctx, text.String(), "month", year, month)
+	return c.sendRecapWithNavigation(b, ctx, user.Today(), text.String(), "month", year, month)
 }
@@ -0,0 +1,392 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// ParseHoldingInput parses the one-line description of a new asset or
+// liability typed by the user, e.g. "Mortgage 180000" or "ETF brokerage USD
+// 12000": the kind, the currency and the current balance are optional and can
+// be in any order, the rest is the name. The currency falls back to the given
+// one, the balance is nil when not given.
+func ParseHoldingInput(text string, currency model.CurrencyType) (model.Holding, *model.Money) {
+	holding := model.Holding{Kind: model.HoldingAsset, Currency: currency}
+
+	var name []string
+	var balance *model.Money
+	kindSet, currencySet := false, false
+	for _, token := range strings.Fields(text) {
+		if kind, ok := model.ParseHoldingKind(token); ok && !kindSet {
+			holding.Kind, kindSet = kind, true
+			continue
+		}
+		if upper := strings.ToUpper(token); len(token) == 3 && model.IsValidCurrency(upper) && !currencySet {
+			holding.Currency, currencySet = model.CurrencyType(upper), true
+			continue
+		}
+		if amount, err := model.ParseMoney(token); err == nil && balance == nil {
+			balance = &amount
+			continue
+		}
+		name = append(name, token)
+	}
+
+	holding.Name = strings.Join(name, " ")
+	if holding.Name == "" && kindSet {
+		// A bare kind, e.g. "Mortgage", names the holding
+		holding.Name = string(holding.Kind)
+	}
+	return holding, balance
+}
+
+// FormatNetWorth renders the net worth of the user: the totals in their base
+// currency, then each asset and liability with its latest balance
+func FormatNetWorth(balances model.HoldingBalances, cur string) string {
+	total := balances.Total()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "💼 <b>Net worth: %.2f %s</b>\n", total.NetWorth, cur)
+	fmt.Fprintf(&sb, "Assets: %.2f %s\nLiabilities: %.2f %s\n", total.Assets, cur, total.Liabilities, cur)
+	if len(balances) == 0 {
+		sb.WriteString("\nYou have no assets or liabilities yet. Add your bank accounts, investments and property, and what you owe on mortgages and loans, to follow your net worth month after month.")
+		return sb.String()
+	}
+
+	for _, liabilities := range []bool{false, true} {
+		title := "\n<b>Assets</b>\n"
+		if liabilities {
+			title = "\n<b>Liabilities</b>\n"
+		}
+		section := ""
+		for _, b := range balances {
+			if b.Kind.IsLiability() != liabilities {
+				continue
+			}
+			section += "  " + formatHoldingBalance(b) + "\n"
+		}
+		if section != "" {
+			sb.WriteString(title + section)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatHoldingBalance renders a holding with its latest balance and when it
+// was recorded, or that it comes from an account
+func formatHoldingBalance(b model.HoldingBalance) string {
+	label := html.EscapeString(b.Label())
+	if !b.Known {
+		return label + ": no balance yet"
+	}
+	text := fmt.Sprintf("%s: %.2f %s", label, b.Balance, b.Currency.Symbol())
+	if b.IsLinked() {
+		return text + " (account)"
+	}
+	return text + " (" + b.Date.Format("02-01-2006") + ")"
+}
+
+// BalanceCheckInPrompt returns the step of the balance check-in for the first
+// holding recorded by hand after the one with the given ID, 0 to start: its
+// latest balance with the buttons to confirm it is still right, to adjust it
+// or to skip it. False when no holding is left, the ones tracking an account
+// being up to date already.
+func BalanceCheckInPrompt(balances model.HoldingBalances, after int64) (string, [][]gotgbot.InlineKeyboardButton, bool) {
+	var manual model.HoldingBalances
+	for _, b := range balances {
+		if !b.IsLinked() {
+			manual = append(manual, b)
+		}
+	}
+
+	for i, b := range manual {
+		if b.ID <= after {
+			continue
+		}
+
+		id := strconv.FormatInt(b.ID, 10)
+		text := fmt.Sprintf("🔄 <b>Update your balances</b> (%d/%d)\n\n", i+1, len(manual))
+		var row []gotgbot.InlineKeyboardButton
+		if b.Known {
+			text += fmt.Sprintf("%s\n\nIs it still right?", formatHoldingBalance(b))
+			row = append(row, gotgbot.InlineKeyboardButton{Text: "✅ Confirm", CallbackData: "networth.confirm." + id})
+		} else {
+			text += fmt.Sprintf("%s has no balance yet.", html.EscapeString(b.Label()))
+		}
+		row = append(row,
+			gotgbot.InlineKeyboardButton{Text: "✏️ Adjust", CallbackData: "networth.adjust." + id},
+			gotgbot.InlineKeyboardButton{Text: "⏭ Skip", CallbackData: "networth.skip." + id},
+		)
+		keyboard := [][]gotgbot.InlineKeyboardButton{
+			row,
+			{{Text: "Stop", CallbackData: "networth.cancel"}},
+		}
+		return text, keyboard, true
+	}
+	return "", nil, false
+}
+
+// NetWorthCommand handles /networth.
+func (c *Client) NetWorthCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	return c.ShowNetWorth(b, ctx)
+}
+
+// ShowNetWorth renders the net worth of the user with the actions to manage
+// their assets and liabilities.
+func (c *Client) ShowNetWorth(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	return c.showNetWorth(b, ctx, user, "")
+}
+
+func (c *Client) showNetWorth(b *gotgbot.Bot, ctx *ext.Context, user model.User, header string) error {
+	balances, err := c.Repositories.NetWorth.Balances(user)
+	if err != nil {
+		return fmt.Errorf("failed to get net worth: %w", err)
+	}
+
+	actions := []gotgbot.InlineKeyboardButton{{Text: "➕ New", CallbackData: "networth.new"}}
+	if _, _, ok := BalanceCheckInPrompt(balances, 0); ok {
+		actions = append(actions, gotgbot.InlineKeyboardButton{Text: "🔄 Update balances", CallbackData: "networth.checkin"})
+	}
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		actions,
+		{{Text: "🏠 Home", CallbackData: "transactions.home"}},
+	}
+	return SendMessage(ctx, b, header+FormatNetWorth(balances, user.BaseCurrency.Symbol()), keyboard)
+}
+
+// HoldingNewPrompt handles networth.new, waiting for the details of the new
+// asset or liability.
+func (c *Client) HoldingNewPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	user.Session.State = model.StateHoldingNewWaitDetails
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "networth.cancel"}},
+	}
+	text := fmt.Sprintf(
+		"Enter the name of the asset or liability, optionally followed by its kind (%s), currency and current balance, e.g. <code>Home mortgage 180000</code>. The balance of a liability is what you owe.",
+		strings.Join(model.GetHoldingKinds(), ", "),
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// HoldingFromMessage receives the details typed after HoldingNewPrompt.
+func (c *Client) HoldingFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	currency := user.BaseCurrency
+	if currency == "" {
+		currency = model.CurrencyEUR
+	}
+	holding, balance := ParseHoldingInput(text, currency)
+	holding.TgID = user.TgID
+
+	err := c.Repositories.NetWorth.Create(&holding)
+	if errors.Is(err, model.ErrInvalidHolding) || errors.Is(err, model.ErrHoldingExists) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s, please try again.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save holding: %w", err)
+	}
+
+	if balance != nil {
+		_, err := c.Repositories.NetWorth.Record(user.TgID, holding.ID, *balance, user.Today())
+		if errors.Is(err, model.ErrInvalidSnapshot) {
+			return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s, please try again.", html.EscapeString(capitalize(err.Error()))))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save balance: %w", err)
+		}
+	}
+
+	return c.showNetWorth(b, ctx, user, fmt.Sprintf("✅ %s added.\n\n", html.EscapeString(holding.Label())))
+}
+
+// BalanceCheckIn handles networth.checkin, going through the balances
+// recorded by hand one at a time. The monthly recap starts it as well.
+func (c *Client) BalanceCheckIn(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	return c.nextBalanceCheckIn(b, ctx, user, 0)
+}
+
+// BalanceConfirm handles networth.confirm.<ID>, recording the latest balance
+// of the holding again today.
+func (c *Client) BalanceConfirm(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	id, err := callbackHoldingID(ctx)
+	if err != nil {
+		return err
+	}
+	balances, err := c.Repositories.NetWorth.Balances(user)
+	if err != nil {
+		return fmt.Errorf("failed to get net worth: %w", err)
+	}
+	for _, h := range balances {
+		if h.ID != id || !h.Known {
+			continue
+		}
+		_, err := c.Repositories.NetWorth.Record(user.TgID, id, h.Balance, user.Today())
+		if err != nil && !errors.Is(err, model.ErrHoldingNotFound) {
+			return fmt.Errorf("failed to save balance: %w", err)
+		}
+	}
+	return c.nextBalanceCheckIn(b, ctx, user, id)
+}
+
+// BalanceSkip handles networth.skip.<ID>, moving on to the next holding.
+func (c *Client) BalanceSkip(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	id, err := callbackHoldingID(ctx)
+	if err != nil {
+		return err
+	}
+	return c.nextBalanceCheckIn(b, ctx, user, id)
+}
+
+// BalanceAdjustPrompt handles networth.adjust.<ID>, waiting for the balance
+// of the holding.
+func (c *Client) BalanceAdjustPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	id, err := callbackHoldingID(ctx)
+	if err != nil {
+		return err
+	}
+	holding, err := c.Repositories.NetWorth.Get(user.TgID, id)
+	if errors.Is(err, model.ErrHoldingNotFound) {
+		return c.nextBalanceCheckIn(b, ctx, user, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get holding: %w", err)
+	}
+
+	user.Session.State = model.StateHoldingWaitBalance
+	user.Session.Body = strconv.FormatInt(holding.ID, 10)
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "networth.cancel"}},
+	}
+	what := "the balance of"
+	if holding.Kind.IsLiability() {
+		what = "how much you owe on"
+	}
+	text := fmt.Sprintf("Enter %s <b>%s</b> today, in %s:", what, html.EscapeString(holding.Name), holding.Currency)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// HoldingBalanceFromMessage receives the balance typed after
+// BalanceAdjustPrompt and moves on to the next holding.
+func (c *Client) HoldingBalanceFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	id, err := strconv.ParseInt(user.Session.Body, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid holding ID: %w", err)
+	}
+
+	balance, err := model.ParseMoney(strings.TrimSpace(text))
+	if err != nil {
+		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Invalid balance. Please enter a number.", nil)
+		return err
+	}
+
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	_, err = c.Repositories.NetWorth.Record(user.TgID, id, balance, user.Today())
+	if errors.Is(err, model.ErrInvalidSnapshot) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s, please try again.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil && !errors.Is(err, model.ErrHoldingNotFound) {
+		return fmt.Errorf("failed to save balance: %w", err)
+	}
+	return c.nextBalanceCheckIn(b, ctx, user, id)
+}
+
+// NetWorthCancel handles networth.cancel.
+func (c *Client) NetWorthCancel(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+	return c.showNetWorth(b, ctx, user, "")
+}
+
+// nextBalanceCheckIn shows the check-in of the holding after the one with the
+// given ID, or the net worth once every balance was gone through
+func (c *Client) nextBalanceCheckIn(b *gotgbot.Bot, ctx *ext.Context, user model.User, after int64) error {
+	balances, err := c.Repositories.NetWorth.Balances(user)
+	if err != nil {
+		return fmt.Errorf("failed to get net worth: %w", err)
+	}
+
+	text, keyboard, ok := BalanceCheckInPrompt(balances, after)
+	if !ok {
+		return c.showNetWorth(b, ctx, user, "✅ Your balances are up to date.\n\n")
+	}
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// callbackHoldingID returns the holding ID, the last part of the callback data
+func callbackHoldingID(ctx *ext.Context) (int64, error) {
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid holding ID: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cashout/internal/model"
+)
+
+func ptrFloat(v float64) *float64 { return &v }
+
+func TestParseHoldingInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        model.Holding
+		wantBalance *float64
+	}{
+		{
+			name:  "name only",
+			input: "House",
+			want:  model.Holding{Name: "House", Kind: model.HoldingAsset, Currency: model.CurrencyEUR},
+		},
+		{
+			name:        "all fields",
+			input:       "ETF brokerage USD 12000",
+			want:        model.Holding{Name: "ETF", Kind: model.HoldingBrokerage, Currency: model.CurrencyUSD},
+			wantBalance: ptrFloat(12000),
+		},
+		{
+			name:        "any order and decimal comma",
+			input:       "180000,50 Home mortgage",
+			want:        model.Holding{Name: "Home", Kind: model.HoldingMortgage, Currency: model.CurrencyEUR},
+			wantBalance: ptrFloat(180000.5),
+		},
+		{
+			name:        "bare kind",
+			input:       "Loan 5000",
+			want:        model.Holding{Name: "Loan", Kind: model.HoldingLoan, Currency: model.CurrencyEUR},
+			wantBalance: ptrFloat(5000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, balance := ParseHoldingInput(tt.input, model.CurrencyEUR)
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if (balance == nil) != (tt.wantBalance == nil) || (balance != nil && *balance != model.NewMoney(*tt.wantBalance)) {
+				t.Errorf("got balance %v, want %v", balance, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestBalanceCheckInPrompt(t *testing.T) {
+	accountID := int64(7)
+	balances := model.HoldingBalances{
+		{Holding: model.Holding{ID: 1, Name: "Checking", Kind: model.HoldingBank, Currency: model.CurrencyEUR, AccountID: &accountID}, Balance: model.NewMoney(900), Known: true},
+		{Holding: model.Holding{ID: 2, Name: "House", Kind: model.HoldingProperty, Currency: model.CurrencyEUR}, Balance: model.NewMoney(250000), Known: true, Date: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{Holding: model.Holding{ID: 3, Name: "Mortgage", Kind: model.HoldingMortgage, Currency: model.CurrencyEUR}},
+	}
+
+	text, keyboard, ok := BalanceCheckInPrompt(balances, 0)
+	if !ok {
+		t.Fatal("expected a prompt for the first holding")
+	}
+	if !strings.Contains(text, "(1/2)") || !strings.Contains(text, "House: 250000.00") {
+		t.Errorf("unexpected prompt: %q", text)
+	}
+	if got := keyboard[0][0].CallbackData; got != "networth.confirm.2" {
+		t.Errorf("expected the confirm button first, got %q", got)
+	}
+
+	// A holding with no balance yet cannot be confirmed
+	text, keyboard, ok = BalanceCheckInPrompt(balances, 2)
+	if !ok || !strings.Contains(text, "(2/2)") {
+		t.Fatalf("unexpected prompt: %q %v", text, ok)
+	}
+	if got := keyboard[0][0].CallbackData; got != "networth.adjust.3" {
+		t.Errorf("expected the adjust button first, got %q", got)
+	}
+
+	if _, _, ok := BalanceCheckInPrompt(balances, 3); ok {
+		t.Error("expected no prompt after the last holding")
+	}
+}
+
+func TestFormatNetWorth(t *testing.T) {
+	balances := model.HoldingBalances{
+		{Holding: model.Holding{Name: "House", Kind: model.HoldingProperty, Currency: model.CurrencyEUR}, Balance: model.NewMoney(250000), Converted: model.NewMoney(250000), Known: true, Date: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{Holding: model.Holding{Name: "Mortgage", Kind: model.HoldingMortgage, Currency: model.CurrencyEUR}, Balance: model.NewMoney(180000), Converted: model.NewMoney(180000), Known: true, Date: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	got := FormatNetWorth(balances, "€")
+	for _, want := range []string{"Net worth: 70000.00 €", "<b>Assets</b>\n  🏠 House: 250000.00 € (01-04-2026)", "<b>Liabilities</b>\n  🏚 Mortgage: 180000.00 €"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
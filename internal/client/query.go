@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// maxQueryRows is the most groups of a query result listed in the chat
+const maxQueryRows = 10
+
+// answerQuery answers a question on the user's transactions, e.g. "what was
+// my biggest expense last month?": the LLM turns it into a query spec that
+// is run on the transactions, then phrases the answer from the numbers,
+// which are attached as computed.
+func (c *Client) answerQuery(b *gotgbot.Bot, ctx *ext.Context, user model.User, question string) error {
+	categories, err := c.Repositories.Categories.List(user.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	spec, err := c.LLM.ExtractQuery(context.Background(), question, categories, user.Today())
+	if errors.Is(err, model.ErrInvalidQuery) {
+		c.Logger.Warnf("invalid query for %q: %v", question, err)
+		return c.SendHomeKeyboard(b, ctx, "🤔 I couldn't turn your question into a search of your transactions, try to rephrase it.\n\n<i>Examples:</i>\n<code>How much did I spend on EatingOut since June?</code>\n<code>What was my biggest expense last month?</code>")
+	}
+	if err != nil {
+		c.Logger.Errorf("failed to extract query: %v", err)
+		return c.SendHomeKeyboard(b, ctx, "I'm sorry, I can't answer questions right now, please try again later or use the recaps.")
+	}
+
+	result, err := c.Repositories.Transactions.Query(user.Scope(), spec)
+	if errors.Is(err, model.ErrQueryTooBroad) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("🤔 Your question matches more than %d transactions, narrow it down to a period or a category.", model.MaxQueryTransactions))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run query: %w", err)
+	}
+
+	facts := FormatQueryResult(result, user.BaseCurrency)
+	answer, err := c.LLM.AnswerQuery(context.Background(), question, facts)
+	if err != nil {
+		c.Logger.Warnf("failed to answer query, sending the numbers only: %v", err)
+		answer = "Here is what I found."
+	}
+
+	text := fmt.Sprintf("💬 %s\n\n<blockquote>%s</blockquote>", html.EscapeString(answer), html.EscapeString(facts))
+	return c.SendHomeKeyboard(b, ctx, text)
+}
+
+// FormatQueryResult renders the query and its result as plain text: the
+// filters on the first line, then the value of each group
+func FormatQueryResult(result model.QueryResult, currency model.CurrencyType) string {
+	spec := result.Spec
+
+	var sb strings.Builder
+	sb.WriteString(describeQuery(spec))
+	if len(result.Rows) == 0 {
+		sb.WriteString("\nNo transactions found.")
+		return sb.String()
+	}
+
+	for _, row := range result.Rows[:min(len(result.Rows), maxQueryRows)] {
+		sb.WriteString("\n")
+		if spec.GroupBy != model.GroupByNone {
+			fmt.Fprintf(&sb, "%s: ", queryRowLabel(spec.GroupBy, row.Key))
+		}
+
+		switch spec.Aggregate {
+		case model.AggregateCount:
+			fmt.Fprintf(&sb, "%d %s", row.Count, plural(row.Count, "transaction"))
+		case model.AggregateMax, model.AggregateMin:
+			fmt.Fprintf(&sb, "%s %.2f", currency.Symbol(), row.Value)
+			if t := row.Transaction; t != nil {
+				fmt.Fprintf(&sb, ", %s (%s) on %s", t.Description, t.Category, t.Date.Format("02-01-2006"))
+			}
+		default:
+			fmt.Fprintf(&sb, "%s %.2f over %d %s", currency.Symbol(), row.Value, row.Count, plural(row.Count, "transaction"))
+		}
+	}
+	if more := len(result.Rows) - maxQueryRows; more > 0 {
+		fmt.Fprintf(&sb, "\n… and %d more", more)
+	}
+	return sb.String()
+}
+
+// describeQuery returns what the query computes and on which transactions,
+// e.g. "Total of the expenses in EatingOut, from 01-06-2025"
+func describeQuery(spec model.QuerySpec) string {
+	aggregates := map[model.QueryAggregate]string{
+		model.AggregateSum:   "Total",
+		model.AggregateCount: "Number",
+		model.AggregateAvg:   "Average",
+		model.AggregateMax:   "Biggest",
+		model.AggregateMin:   "Smallest",
+	}
+	parts := []string{fmt.Sprintf("%s of the %s", aggregates[spec.Aggregate], strings.ToLower(string(spec.Type))+"s")}
+
+	if spec.Category != "" {
+		parts[0] += " in " + string(spec.Category)
+	}
+	if spec.Query != "" {
+		parts = append(parts, fmt.Sprintf("matching %q", spec.Query))
+	}
+	switch {
+	case spec.DateFrom != nil && spec.DateTo != nil:
+		parts = append(parts, fmt.Sprintf("from %s to %s", spec.DateFrom.Format("02-01-2006"), spec.DateTo.Format("02-01-2006")))
+	case spec.DateFrom != nil:
+		parts = append(parts, "from "+spec.DateFrom.Format("02-01-2006"))
+	case spec.DateTo != nil:
+		parts = append(parts, "until "+spec.DateTo.Format("02-01-2006"))
+	}
+	if spec.AmountMin != nil {
+		parts = append(parts, fmt.Sprintf("of at least %.2f", *spec.AmountMin))
+	}
+	if spec.AmountMax != nil {
+		parts = append(parts, fmt.Sprintf("of at most %.2f", *spec.AmountMax))
+	}
+	for _, tag := range spec.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	for _, tag := range spec.ExcludeTags {
+		parts = append(parts, "without #"+tag)
+	}
+	if spec.GroupBy != model.GroupByNone {
+		parts = append(parts, "by "+string(spec.GroupBy))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// queryRowLabel returns the label of a group of a query result
+func queryRowLabel(groupBy model.QueryGroupBy, key string) string {
+	switch groupBy {
+	case model.GroupByTag:
+		if key == "" {
+			return "No tag"
+		}
+		return "#" + key
+	case model.GroupByMonth:
+		if month, err := time.Parse("2006-01", key); err == nil {
+			return month.Format("January 2006")
+		}
+	}
+	return key
+}
+
+// plural returns the noun for the count, with an "s" unless it is one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return noun
+	}
+	return noun + "s"
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"cashout/internal/model"
+)
+
+func TestFormatQueryResult(t *testing.T) {
+	june := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	biggest := model.Transaction{Category: "EatingOut", Description: "Sushi", Amount: model.NewMoney(48), Date: time.Date(2026, 4, 18, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name   string
+		result model.QueryResult
+		want   string
+	}{
+		{
+			name: "total",
+			result: model.QueryResult{
+				Spec: model.QuerySpec{Type: model.TypeExpense, Category: "EatingOut", DateFrom: &june, Aggregate: model.AggregateSum},
+				Rows: []model.QueryRow{{Value: model.NewMoney(42), Count: 3}},
+			},
+			want: "Total of the expenses in EatingOut, from 01-06-2025\n€ 42.00 over 3 transactions",
+		},
+		{
+			name: "biggest",
+			result: model.QueryResult{
+				Spec: model.QuerySpec{Type: model.TypeExpense, Aggregate: model.AggregateMax},
+				Rows: []model.QueryRow{{Value: biggest.Amount, Count: 9, Transaction: &biggest}},
+			},
+			want: "Biggest of the expenses\n€ 48.00, Sushi (EatingOut) on 18-04-2026",
+		},
+		{
+			name: "count by month",
+			result: model.QueryResult{
+				Spec: model.QuerySpec{Type: model.TypeIncome, Query: "bonus", Tags: []string{"work"}, GroupBy: model.GroupByMonth, Aggregate: model.AggregateCount},
+				Rows: []model.QueryRow{{Key: "2026-04", Count: 1}, {Key: "2026-05", Count: 2}},
+			},
+			want: "Number of the incomes, matching \"bonus\", #work, by month\nApril 2026: 1 transaction\nMay 2026: 2 transactions",
+		},
+		{
+			name: "by tag",
+			result: model.QueryResult{
+				Spec: model.QuerySpec{Type: model.TypeExpense, GroupBy: model.GroupByTag, Aggregate: model.AggregateAvg},
+				Rows: []model.QueryRow{{Key: "travel", Value: model.NewMoney(20), Count: 2}, {Key: "", Value: model.NewMoney(5), Count: 1}},
+			},
+			want: "Average of the expenses, by tag\n#travel: € 20.00 over 2 transactions\nNo tag: € 5.00 over 1 transaction",
+		},
+		{
+			name:   "nothing found",
+			result: model.QueryResult{Spec: model.QuerySpec{Type: model.TypeExpense, Aggregate: model.AggregateSum}},
+			want:   "Total of the expenses\nNo transactions found.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatQueryResult(tt.result, model.CurrencyEUR); got != tt.want {
+				t.Errorf("FormatQueryResult() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
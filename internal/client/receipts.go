@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"cashout/internal/ai"
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// maxShownReceiptItems is the most line items of a receipt listed in the chat
+const maxShownReceiptItems = 10
+
+// receiptTypes are the content types of the photos read by the vision model
+var receiptTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// receiptState reports whether a photo sent in the state is a receipt to
+// read, rather than an answer to another flow
+func receiptState(state model.StateType) bool {
+	return state == model.StateNormal || state == model.StateStart || state == model.StateInsertingExpense
+}
+
+// addReceipt reads the expense of the photo of a receipt and saves it with
+// the photo attached, then offers to edit it as a transaction just added.
+// The hashtags of the caption become the tags of the transaction.
+func (c *Client) addReceipt(b *gotgbot.Bot, ctx *ext.Context, user model.User, file telegramFile) error {
+	if file.Size > model.MaxAttachmentSize {
+		return SendMessage(ctx, b, fmt.Sprintf("❌ %s.", capitalize(model.ErrAttachmentTooLarge.Error())), nil)
+	}
+
+	categories, err := c.Repositories.Categories.List(user.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	if len(categories.OfType(model.TypeExpense)) == 0 {
+		return c.SendHomeKeyboard(b, ctx, "You have no active expense categories, add one with /categories first.")
+	}
+
+	content, err := downloadTelegramFile(b, file.ID)
+	if err != nil {
+		return fmt.Errorf("failed to download receipt: %w", err)
+	}
+	image, err := io.ReadAll(io.LimitReader(content, model.MaxAttachmentSize))
+	_ = content.Close()
+	if err != nil {
+		return fmt.Errorf("failed to download receipt: %w", err)
+	}
+
+	if _, err := b.SendChatAction(ctx.EffectiveChat.Id, "typing", nil); err != nil {
+		c.Logger.Warnf("failed to send chat action: %v", err)
+	}
+
+	extracted, err := c.LLM.ExtractReceipt(context.Background(), image, file.ContentType, categories, user.Today())
+	if err != nil || extracted.Amount == 0 {
+		_, errm := ctx.EffectiveMessage.Reply(b, "🧾 I'm sorry, I couldn't read the total of this receipt! Add the expense by text, then send the photo again to attach it.", nil)
+		return errors.Join(err, errm)
+	}
+	extracted.Tags = model.ParseHashtags(ctx.EffectiveMessage.Caption)
+
+	transaction := newExtractedTransactions(user, []ai.ExtractedTransaction{extracted})[0]
+	err = c.Repositories.Transactions.As(botActor(user)).Add(&transaction)
+	if err != nil {
+		err = errors.Join(err, SendMessage(ctx, b, "There has been an error saving your transaction, please retry", nil))
+		c.Logger.Errorln("failed to add transaction", err)
+		return fmt.Errorf("failed to add transaction: %w", err)
+	}
+
+	// The expense is saved even when the photo cannot be kept
+	attached := "\n\n📎 The photo is attached, you can download it from the web dashboard."
+	if _, err := c.Repositories.Attachments.Add(user.TgID, transaction.ID, file.Name, file.ContentType, bytes.NewReader(image)); err != nil {
+		c.Logger.Errorf("failed to attach receipt to transaction %d: %v", transaction.ID, err)
+		attached = "\n\n⚠️ The photo could not be attached, send it again to retry."
+	}
+
+	// Store the transaction ID in session for potential edits
+	user.Session.State = model.StateEditingNewTransaction
+	user.Session.Body = strconv.FormatInt(transaction.ID, 10)
+	err = c.Repositories.Users.Update(&user)
+	if err != nil {
+		return fmt.Errorf("failed to set user data: %w", err)
+	}
+
+	msg := fmt.Sprintf("🧾 <b>Receipt saved!</b>\n\n%s (%s), %s on %s", transaction.Category, FormatTransactionAmount(transaction), html.EscapeString(transaction.Description), transaction.Date.Format("02-01-2006")) + FormatTransactionTags(transaction)
+	msg += FormatReceiptItems(extracted.Items, transaction.Currency)
+	msg += FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction))
+	msg += c.EnvelopeSuffixForTx(user, transaction)
+	msg += attached
+	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, msg, &gotgbot.SendMessageOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: newTransactionKeyboard(transaction.ID)},
+	})
+	if err != nil {
+		c.Logger.Errorln("failed to send saved message", err)
+		return err
+	}
+
+	return nil
+}
+
+// FormatReceiptItems renders the line items read from a receipt, at most
+// maxShownReceiptItems of them, empty when there are none
+func FormatReceiptItems(items []ai.ReceiptItem, currency model.CurrencyType) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\n<i>Items:</i>")
+	for _, item := range items[:min(len(items), maxShownReceiptItems)] {
+		fmt.Fprintf(&sb, "\n• %s: %s %.2f", html.EscapeString(item.Description), currency.Symbol(), item.Amount)
+	}
+	if more := len(items) - maxShownReceiptItems; more > 0 {
+		fmt.Fprintf(&sb, "\n… and %d more", more)
+	}
+	return sb.String()
+}
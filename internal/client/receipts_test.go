@@ -0,0 +1,46 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cashout/internal/ai"
+	"cashout/internal/model"
+)
+
+func TestFormatReceiptItems(t *testing.T) {
+	if got := FormatReceiptItems(nil, model.CurrencyEUR); got != "" {
+		t.Errorf("FormatReceiptItems(nil) = %q, want empty", got)
+	}
+
+	got := FormatReceiptItems([]ai.ReceiptItem{
+		{Description: "Pane & co", Amount: model.NewMoney(1.2)},
+		{Description: "Latte", Amount: model.NewMoney(0.99)},
+	}, model.CurrencyEUR)
+	want := "\n\n<i>Items:</i>\n• Pane &amp; co: € 1.20\n• Latte: € 0.99"
+	if got != want {
+		t.Errorf("FormatReceiptItems() = %q, want %q", got, want)
+	}
+
+	items := make([]ai.ReceiptItem, maxShownReceiptItems+3)
+	for i := range items {
+		items[i] = ai.ReceiptItem{Description: fmt.Sprintf("Item %d", i+1), Amount: model.NewMoney(1)}
+	}
+	if got := FormatReceiptItems(items, model.CurrencyGBP); !strings.HasSuffix(got, "… and 3 more") {
+		t.Errorf("FormatReceiptItems() = %q, want the count of the items left out", got)
+	}
+}
+
+func TestReceiptState(t *testing.T) {
+	for state, want := range map[model.StateType]bool{
+		model.StateNormal:           true,
+		model.StateInsertingExpense: true,
+		model.StateInsertingIncome:  false,
+		model.StateBatchPreview:     false,
+	} {
+		if got := receiptState(state); got != want {
+			t.Errorf("receiptState(%s) = %v, want %v", state, got, want)
+		}
+	}
+}
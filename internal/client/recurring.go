@@ -0,0 +1,512 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// recurringDraft is the rule being created with the inline keyboards, kept in
+// the session body as "<type>.<frequency>.<day>.<category>"
+type recurringDraft struct {
+	Type      model.TransactionType
+	Frequency model.RecurringFrequency
+	Day       int
+	Category  string
+}
+
+func (d recurringDraft) String() string {
+	return fmt.Sprintf("%s.%s.%d.%s", d.Type, d.Frequency, d.Day, d.Category)
+}
+
+func parseRecurringDraft(body string) (recurringDraft, error) {
+	parts := strings.SplitN(body, ".", 4)
+	if len(parts) != 4 {
+		return recurringDraft{}, fmt.Errorf("invalid recurring rule draft: %q", body)
+	}
+	day, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return recurringDraft{}, fmt.Errorf("invalid recurring rule draft day: %w", err)
+	}
+	return recurringDraft{
+		Type:      model.TransactionType(parts[0]),
+		Frequency: model.RecurringFrequency(parts[1]),
+		Day:       day,
+		Category:  parts[3],
+	}, nil
+}
+
+// Rule returns the recurring rule of the draft starting from the given day.
+// Weekly rules start on the first chosen weekday from then, Day being the
+// weekday, monthly ones repeat on day Day.
+func (d recurringDraft) Rule(tgID int64, today time.Time) model.RecurringRule {
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	rule := model.RecurringRule{
+		TgID:      tgID,
+		Type:      d.Type,
+		Category:  model.TransactionCategory(d.Category),
+		Frequency: d.Frequency,
+		StartDate: start,
+	}
+
+	switch d.Frequency {
+	case model.FrequencyWeekly:
+		rule.StartDate = start.AddDate(0, 0, (d.Day-int(start.Weekday())+7)%7)
+	case model.FrequencyMonthly:
+		rule.DayOfMonth = d.Day
+	}
+	return rule
+}
+
+// ParseRecurringInput splits the text typed by the user into the amount and
+// the description of a recurring rule, e.g. "12.99 Netflix".
+func ParseRecurringInput(text string) (model.Money, string, error) {
+	amountStr, description, _ := strings.Cut(strings.TrimSpace(text), " ")
+	amount, err := model.ParseMoney(amountStr)
+	if err != nil || amount <= 0 {
+		return 0, "", errors.New("the amount must be a number greater than zero")
+	}
+
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return 0, "", errors.New("the description cannot be empty")
+	}
+	return amount, description, nil
+}
+
+// RecurringCommand handles /recurring.
+func (c *Client) RecurringCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	return c.ShowRecurring(b, ctx)
+}
+
+// ShowRecurring renders the recurring rules of the user with the actions to manage them.
+func (c *Client) ShowRecurring(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	rules, err := c.Repositories.Recurring.List(user.TgID)
+	if err != nil {
+		return err
+	}
+	categories := c.displayCategories(user.TgID)
+
+	var sb strings.Builder
+	sb.WriteString("🔁 <b>Recurring transactions</b>\n\n")
+	if len(rules) == 0 {
+		sb.WriteString("You have no recurring transactions yet. Add your rent, subscriptions or salary once and they will be added automatically.")
+	}
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for _, rule := range rules {
+		fmt.Fprintf(&sb, "%s %s: %s %.2f, %s (%s)\n",
+			categories.Emoji(rule.Category), html.EscapeString(rule.Description),
+			rule.Currency.Symbol(), rule.Amount, strings.ToLower(rule.Schedule()), recurringStatus(rule))
+
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{
+			Text:         fmt.Sprintf("%s %s", categories.Emoji(rule.Category), rule.Description),
+			CallbackData: fmt.Sprintf("recurring.show.%d", rule.ID),
+		}})
+	}
+	keyboard = append(keyboard,
+		[]gotgbot.InlineKeyboardButton{{Text: "➕ New Recurring Transaction", CallbackData: "recurring.new"}},
+		[]gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}},
+	)
+
+	return SendMessage(ctx, b, sb.String(), keyboard)
+}
+
+// recurringStatus returns whether the rule is paused, ended or when it runs next
+func recurringStatus(rule model.RecurringRule) string {
+	if rule.Paused {
+		return "paused"
+	}
+	next, ok := rule.NextOccurrence()
+	if !ok {
+		return "ended"
+	}
+	return "next on " + next.Format("02-01-2006")
+}
+
+// RecurringDetails handles recurring.show.<ID>.
+func (c *Client) RecurringDetails(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	rule, err := c.getCallbackRecurringRule(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return c.showRecurringDetails(b, ctx, rule)
+}
+
+func (c *Client) showRecurringDetails(b *gotgbot.Bot, ctx *ext.Context, rule model.RecurringRule) error {
+	pauseText := "⏸ Pause"
+	if rule.Paused {
+		pauseText = "▶️ Resume"
+	}
+
+	id := strconv.FormatInt(rule.ID, 10)
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{
+			{Text: pauseText, CallbackData: "recurring.pause." + id},
+			{Text: "🗑 Delete", CallbackData: "recurring.delete." + id},
+		},
+		{{Text: "⬅️ Back", CallbackData: "recurring.list"}},
+	}
+
+	end := "never"
+	if rule.EndDate != nil {
+		end = rule.EndDate.Format("02-01-2006")
+	}
+
+	text := fmt.Sprintf(
+		"🔁 <b>%s</b>\n\n%s: %s (%s %.2f)\nRepeats: %s\nFrom %s, ends %s\nStatus: %s",
+		html.EscapeString(rule.Description), rule.Type, rule.Category, rule.Currency.Symbol(), rule.Amount,
+		rule.Schedule(), rule.StartDate.Format("02-01-2006"), end, capitalize(recurringStatus(rule)),
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// RecurringNewPrompt handles recurring.new, asking for the type of the new rule.
+func (c *Client) RecurringNewPrompt(b *gotgbot.Bot, ctx *ext.Context) error {
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{
+			{Text: "💸 Expense", CallbackData: "recurring.type." + string(model.TypeExpense)},
+			{Text: "💰 Income", CallbackData: "recurring.type." + string(model.TypeIncome)},
+		},
+		{{Text: "Cancel", CallbackData: "recurring.cancel"}},
+	}
+	return SendMessage(ctx, b, "🔁 <b>New recurring transaction</b>\n\nIs it an expense or an income?", keyboard)
+}
+
+// RecurringTypeSelected handles recurring.type.<TYPE>, asking for the category.
+func (c *Client) RecurringTypeSelected(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	txType := model.TransactionType(parts[len(parts)-1])
+	if txType != model.TypeIncome && txType != model.TypeExpense {
+		return fmt.Errorf("invalid transaction type: %s", txType)
+	}
+
+	user.Session.State = model.StateRecurringNew
+	user.Session.Body = recurringDraft{Type: txType}.String()
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard, err := c.buildUserCategoryKeyboard(ctx, txType, "recurring.cat", "recurring.cancel", false)
+	if err != nil {
+		return err
+	}
+	return SendMessage(ctx, b, "🔁 <b>New recurring transaction</b>\n\nChoose the category:", keyboard)
+}
+
+// RecurringCategorySelected handles recurring.cat.<CATEGORY>, asking for the frequency.
+func (c *Client) RecurringCategorySelected(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	draft, err := c.getRecurringDraft(user)
+	if err != nil {
+		return err
+	}
+	draft.Category = strings.TrimPrefix(ctx.CallbackQuery.Data, "recurring.cat.")
+
+	user.Session.Body = draft.String()
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	var row []gotgbot.InlineKeyboardButton
+	for _, f := range model.GetRecurringFrequencies() {
+		row = append(row, gotgbot.InlineKeyboardButton{Text: f, CallbackData: "recurring.freq." + f})
+	}
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		row,
+		{{Text: "Cancel", CallbackData: "recurring.cancel"}},
+	}
+	return SendMessage(ctx, b, "🔁 <b>New recurring transaction</b>\n\nHow often does it repeat?", keyboard)
+}
+
+// RecurringFrequencySelected handles recurring.freq.<FREQUENCY>, asking for
+// the weekday or the day of the month when needed.
+func (c *Client) RecurringFrequencySelected(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	draft, err := c.getRecurringDraft(user)
+	if err != nil {
+		return err
+	}
+	draft.Frequency = model.RecurringFrequency(strings.TrimPrefix(ctx.CallbackQuery.Data, "recurring.freq."))
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	var text string
+	switch draft.Frequency {
+	case model.FrequencyWeekly:
+		text = "On which day of the week?"
+		var row []gotgbot.InlineKeyboardButton
+		for i := 1; i <= 7; i++ {
+			weekday := time.Weekday(i % 7)
+			row = append(row, gotgbot.InlineKeyboardButton{
+				Text:         weekday.String()[:3],
+				CallbackData: fmt.Sprintf("recurring.day.%d", int(weekday)),
+			})
+		}
+		keyboard = append(keyboard, row)
+	case model.FrequencyMonthly:
+		text = "On which day of the month? Months without that day use their last one."
+		var row []gotgbot.InlineKeyboardButton
+		for day := 1; day <= 31; day++ {
+			row = append(row, gotgbot.InlineKeyboardButton{
+				Text:         strconv.Itoa(day),
+				CallbackData: fmt.Sprintf("recurring.day.%d", day),
+			})
+			if len(row) == 7 {
+				keyboard = append(keyboard, row)
+				row = nil
+			}
+		}
+		keyboard = append(keyboard, row)
+	case model.FrequencyDaily, model.FrequencyYearly:
+		return c.recurringDetailsPrompt(b, ctx, user, draft)
+	default:
+		return fmt.Errorf("invalid recurring frequency: %s", draft.Frequency)
+	}
+
+	user.Session.Body = draft.String()
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "Cancel", CallbackData: "recurring.cancel"}})
+	return SendMessage(ctx, b, "🔁 <b>New recurring transaction</b>\n\n"+text, keyboard)
+}
+
+// RecurringDaySelected handles recurring.day.<DAY>, the weekday (0 is Sunday)
+// of weekly rules or the day of the month of monthly ones.
+func (c *Client) RecurringDaySelected(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	draft, err := c.getRecurringDraft(user)
+	if err != nil {
+		return err
+	}
+	draft.Day, err = strconv.Atoi(strings.TrimPrefix(ctx.CallbackQuery.Data, "recurring.day."))
+	if err != nil {
+		return fmt.Errorf("invalid recurring day: %w", err)
+	}
+
+	return c.recurringDetailsPrompt(b, ctx, user, draft)
+}
+
+// recurringDetailsPrompt waits for the amount and the description of the rule.
+func (c *Client) recurringDetailsPrompt(b *gotgbot.Bot, ctx *ext.Context, user model.User, draft recurringDraft) error {
+	user.Session.State = model.StateRecurringWaitDetails
+	user.Session.Body = draft.String()
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to update user state: %w", err)
+	}
+
+	rule := draft.Rule(user.TgID, user.Today())
+	rule.Normalize()
+
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "Cancel", CallbackData: "recurring.cancel"}},
+	}
+	text := fmt.Sprintf(
+		"🔁 <b>New recurring transaction</b>\n\n%s: %s, %s\n\nEnter the amount and the description, e.g. <code>12.99 Netflix</code>:",
+		draft.Type, html.EscapeString(draft.Category), strings.ToLower(rule.Schedule()),
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// RecurringFromMessage receives the amount and description typed after
+// recurringDetailsPrompt and saves the rule.
+func (c *Client) RecurringFromMessage(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	draft, err := c.getRecurringDraft(user)
+	if err != nil {
+		return err
+	}
+
+	amount, description, err := ParseRecurringInput(text)
+	if err != nil {
+		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, capitalize(err.Error())+", please try again.", nil)
+		return err
+	}
+
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+
+	rule := draft.Rule(user.TgID, user.Today())
+	rule.Amount = amount
+	rule.Description = description
+	rule.Currency = user.BaseCurrency
+	if rule.Currency == "" {
+		rule.Currency = model.CurrencyEUR
+	}
+
+	err = c.Repositories.Recurring.Create(&rule)
+	if errors.Is(err, model.ErrInvalidRecurringRule) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save recurring rule: %w", err)
+	}
+
+	return c.showRecurringDetails(b, ctx, rule)
+}
+
+// RecurringPauseToggle handles recurring.pause.<ID>, pausing or resuming the rule.
+// Occurrences missed while paused are skipped on resume.
+func (c *Client) RecurringPauseToggle(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	rule, err := c.getCallbackRecurringRule(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if rule.Paused {
+		rule, err = c.Repositories.Recurring.Resume(user.TgID, rule.ID, user.Today())
+		if err != nil {
+			return err
+		}
+	} else {
+		rule.Paused = true
+		if err := c.Repositories.Recurring.Update(&rule); err != nil {
+			return fmt.Errorf("failed to pause recurring rule: %w", err)
+		}
+	}
+
+	return c.showRecurringDetails(b, ctx, rule)
+}
+
+// RecurringDelete handles recurring.delete.<ID>, the transactions already added are kept.
+func (c *Client) RecurringDelete(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	rule, err := c.getCallbackRecurringRule(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Repositories.Recurring.Delete(user.TgID, rule.ID); err != nil {
+		return fmt.Errorf("failed to delete recurring rule: %w", err)
+	}
+
+	return c.ShowRecurring(b, ctx)
+}
+
+// RecurringUndo handles recurring.undo.<TRANSACTION ID>, sent along with the
+// notification of a recurring transaction, deleting it.
+func (c *Client) RecurringUndo(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %w", err)
+	}
+
+	text := "↩️ This recurring transaction was already removed."
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "🔁 Recurring", CallbackData: "recurring.list"}},
+	}
+	transaction, err := c.Repositories.Transactions.GetByID(id)
+	if err == nil && transaction.TgID == user.TgID && transaction.RecurringRuleID != nil {
+		if err := c.Repositories.Transactions.As(botActor(user)).Delete(transaction.ID, user.TgID); err != nil {
+			return fmt.Errorf("failed to delete transaction: %w", err)
+		}
+		text = fmt.Sprintf(
+			"↩️ <b>Recurring transaction removed</b>\n\n<s>%s (%s), %s on %s</s>",
+			transaction.Category, FormatTransactionAmount(transaction), html.EscapeString(transaction.Description), transaction.Date.Format("02-01-2006"),
+		)
+		keyboard = append([][]gotgbot.InlineKeyboardButton{{undoButton(transaction.ID)}}, keyboard...)
+	}
+
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// RecurringCancel resets state and returns to home.
+func (c *Client) RecurringCancel(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	user.Session.State = model.StateNormal
+	user.Session.Body = ""
+	if err := c.Repositories.Users.Update(&user); err != nil {
+		return fmt.Errorf("failed to reset user state: %w", err)
+	}
+	return c.SendHomeKeyboard(b, ctx, "Operation cancelled.")
+}
+
+// getRecurringDraft returns the rule being created by the user.
+func (c *Client) getRecurringDraft(user model.User) (recurringDraft, error) {
+	if user.Session.State != model.StateRecurringNew && user.Session.State != model.StateRecurringWaitDetails {
+		return recurringDraft{}, fmt.Errorf("no recurring rule in progress, state is %s", user.Session.State)
+	}
+	return parseRecurringDraft(user.Session.Body)
+}
+
+// getCallbackRecurringRule returns the rule whose ID is the last part of the callback data.
+func (c *Client) getCallbackRecurringRule(ctx *ext.Context, user model.User) (model.RecurringRule, error) {
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return model.RecurringRule{}, fmt.Errorf("invalid recurring rule ID: %w", err)
+	}
+
+	rule, err := c.Repositories.Recurring.Get(user.TgID, id)
+	if err != nil {
+		return model.RecurringRule{}, fmt.Errorf("failed to get recurring rule: %w", err)
+	}
+	return rule, nil
+}
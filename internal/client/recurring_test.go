@@ -0,0 +1,70 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"cashout/internal/model"
+)
+
+func TestParseRecurringInput(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		wantAmount      float64
+		wantDescription string
+		wantErr         bool
+	}{
+		{name: "amount and description", input: "12.99 Netflix", wantAmount: 12.99, wantDescription: "Netflix"},
+		{name: "decimal comma", input: " 800,5  Rent flat ", wantAmount: 800.5, wantDescription: "Rent flat"},
+		{name: "missing description", input: "12.99", wantErr: true},
+		{name: "missing amount", input: "Netflix", wantErr: true},
+		{name: "negative amount", input: "-5 Gym", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, description, err := ParseRecurringInput(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v %q", amount, description)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if amount != model.NewMoney(tt.wantAmount) || description != tt.wantDescription {
+				t.Fatalf("expected %v %q, got %v %q", tt.wantAmount, tt.wantDescription, amount, description)
+			}
+		})
+	}
+}
+
+func TestRecurringDraft(t *testing.T) {
+	draft := recurringDraft{Type: model.TypeExpense, Frequency: model.FrequencyWeekly, Day: 1, Category: "Dining.Out"}
+	parsed, err := parseRecurringDraft(draft.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed != draft {
+		t.Fatalf("expected %+v, got %+v", draft, parsed)
+	}
+
+	if _, err := parseRecurringDraft("Expense"); err == nil {
+		t.Fatal("expected error for an incomplete draft")
+	}
+
+	// Wednesday 2026-05-20, the next Monday is 2026-05-25
+	today := time.Date(2026, 5, 20, 18, 30, 0, 0, time.UTC)
+	rule := draft.Rule(42, today)
+	if !rule.StartDate.Equal(time.Date(2026, 5, 25, 0, 0, 0, 0, time.UTC)) || rule.DayOfMonth != 0 {
+		t.Fatalf("unexpected weekly rule: %+v", rule)
+	}
+
+	draft.Frequency, draft.Day = model.FrequencyMonthly, 31
+	rule = draft.Rule(42, today)
+	if !rule.StartDate.Equal(time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)) || rule.DayOfMonth != 31 || rule.TgID != 42 {
+		t.Fatalf("unexpected monthly rule: %+v", rule)
+	}
+}
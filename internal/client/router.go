@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -11,7 +12,6 @@ import (
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
-	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/message"
 )
 
 // FreeTextRouter is the default router for the bot.
@@ -24,82 +24,151 @@ func (c *Client) FreeTextRouter(b *gotgbot.Bot, ctx *ext.Context) error {
 		return err
 	}
 
-	msg := ctx.Message
-	if message.Text(msg) && strings.ToLower(strings.Trim(msg.Text, " ")) == "cancel" {
+	return c.routeText(b, ctx, user, ctx.EffectiveMessage.Text)
+}
+
+// routeText handles the text of the user according to their session, e.g.
+// the typed message or the transcription of a voice note
+func (c *Client) routeText(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	if strings.ToLower(strings.Trim(text, " ")) == "cancel" {
 		return c.Cancel(b, ctx)
 	}
 
+	// Typing something else leaves the edit options of the transaction
+	// selected with /edit, so that a later photo is not attached to it
+	if user.Session.State == model.StateEditingTransaction {
+		user.Session.State = model.StateNormal
+		user.Session.Body = ""
+		if err := c.Repositories.Users.Update(&user); err != nil {
+			return fmt.Errorf("failed to set user data: %w", err)
+		}
+	}
+
 	// The use pre-selected the adding transaction flow, so we don't need to infer it.
 	if user.Session.State == model.StateInsertingIncome || user.Session.State == model.StateInsertingExpense {
-		return c.addTransaction(b, ctx, user)
+		return c.addTransaction(b, ctx, user, text)
 	}
 
 	// During-insert edit transaction.
 
 	if user.Session.State == model.StateEditingTransactionDate {
-		return c.editTransactionDate(b, ctx, user)
+		return c.editTransactionDate(b, ctx, user, text)
 	}
 
 	if user.Session.State == model.StateEditingTransactionAmount {
-		return c.editTransactionAmount(b, ctx, user)
+		return c.editTransactionAmount(b, ctx, user, text)
 	}
 
 	if user.Session.State == model.StateEditingTransactionDescription {
-		return c.editTransactionDescription(b, ctx, user)
+		return c.editTransactionDescription(b, ctx, user, text)
 	}
 
 	// Top-level edit transaction cases.
 
 	if user.Session.State == model.StateTopLevelEditingTransactionDate {
-		return c.EditTransactionDateConfirm(b, ctx)
+		return c.EditTransactionDateConfirm(b, ctx, text)
 	}
 
 	if user.Session.State == model.StateTopLevelEditingTransactionAmount {
-		return c.EditTransactionAmountConfirm(b, ctx)
+		return c.EditTransactionAmountConfirm(b, ctx, text)
 	}
 
 	if user.Session.State == model.StateTopLevelEditingTransactionDescription {
-		return c.EditTransactionDescriptionConfirm(b, ctx)
+		return c.EditTransactionDescriptionConfirm(b, ctx, text)
+	}
+
+	if user.Session.State == model.StateTopLevelEditingTransactionSplit {
+		return c.EditTransactionSplitConfirm(b, ctx, text)
 	}
 
 	// Search-related states.
 	if user.Session.State == model.StateEnteringSearchQuery {
-		return c.SearchQueryEntered(b, ctx)
+		return c.SearchQueryEntered(b, ctx, text)
 	}
 
 	// Edit search-related states.
 	if user.Session.State == model.StateEnteringEditSearchQuery {
-		return c.EditSearchQueryEntered(b, ctx)
+		return c.EditSearchQueryEntered(b, ctx, text)
 	}
 
 	// Delete search-related states.
 	if user.Session.State == model.StateEnteringDeleteSearchQuery {
-		return c.DeleteSearchQueryEntered(b, ctx)
+		return c.DeleteSearchQueryEntered(b, ctx, text)
 	}
 
 	// Clone search-related states.
 	if user.Session.State == model.StateEnteringCloneSearchQuery {
-		return c.CloneSearchQueryEntered(b, ctx)
+		return c.CloneSearchQueryEntered(b, ctx, text)
 	}
 
 	// Budget set wizard.
 	if user.Session.State == model.StateBudgetSetWaitAmount {
-		return c.BudgetSetFromMessage(b, ctx, user)
+		return c.BudgetSetFromMessage(b, ctx, user, text)
+	}
+
+	// Category create/rename wizard.
+	if user.Session.State == model.StateCategoryNewWaitName || user.Session.State == model.StateCategoryRenameWaitName {
+		return c.CategoryFromMessage(b, ctx, user, text)
+	}
+
+	// Account create and transfer wizards.
+	if user.Session.State == model.StateAccountNewWaitDetails {
+		return c.AccountFromMessage(b, ctx, user, text)
+	}
+
+	if user.Session.State == model.StateAccountTransferWaitAmount {
+		return c.AccountTransferFromMessage(b, ctx, user, text)
+	}
+
+	if user.Session.State == model.StateRecurringWaitDetails {
+		return c.RecurringFromMessage(b, ctx, user, text)
+	}
+
+	// Shared ledger create and join wizards.
+	if user.Session.State == model.StateLedgerNewWaitName {
+		return c.LedgerFromMessage(b, ctx, user, text)
+	}
+
+	if user.Session.State == model.StateLedgerJoinWaitCode {
+		return c.LedgerJoinFromMessage(b, ctx, user, text)
+	}
+
+	// Savings goal create and contribute wizards.
+	if user.Session.State == model.StateGoalNewWaitDetails {
+		return c.GoalFromMessage(b, ctx, user, text)
+	}
+
+	if user.Session.State == model.StateGoalContributeWaitAmount {
+		return c.GoalContributionFromMessage(b, ctx, user, text)
+	}
+
+	// Net worth holding create and balance wizards.
+	if user.Session.State == model.StateHoldingNewWaitDetails {
+		return c.HoldingFromMessage(b, ctx, user, text)
+	}
+
+	if user.Session.State == model.StateBatchEditingLine {
+		return c.editBatchLine(b, ctx, user, text)
+	}
+
+	if user.Session.State == model.StateHoldingWaitBalance {
+		return c.HoldingBalanceFromMessage(b, ctx, user, text)
 	}
 
 	// Free text top level case: use LLM to classify user intent.
-	return c.classifyAndRouteIntent(b, ctx, user)
+	return c.classifyAndRouteIntent(b, ctx, user, text)
 }
 
 // classifyAndRouteIntent uses the LLM to classify the user's intent and routes to the appropriate handler
-func (c *Client) classifyAndRouteIntent(b *gotgbot.Bot, ctx *ext.Context, user model.User) error {
-	// Quick heuristic: if text contains digits, it's likely a transaction
+func (c *Client) classifyAndRouteIntent(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
+	// Quick heuristic: if text contains digits, it's likely a transaction,
+	// unless it is a question like "how much did I spend in 2025?"
 	// Use fast local check before calling LLM.
-	if strings.ContainsAny(ctx.Message.Text, "0123456789") {
+	if strings.ContainsAny(text, "0123456789") && !utils.IsAQuestionPrompt(text) {
 
 		// Default to expense and check for income keywords.
 		user.Session.State = model.StateInsertingExpense
-		if utils.IsAnIncomeTransactionPrompt(ctx.Message.Text) {
+		if utils.IsAnIncomeTransactionPrompt(text) {
 			user.Session.State = model.StateInsertingIncome
 		}
 
@@ -108,11 +177,11 @@ func (c *Client) classifyAndRouteIntent(b *gotgbot.Bot, ctx *ext.Context, user m
 			return fmt.Errorf("failed to set user data: %w", err)
 		}
 
-		return c.addTransaction(b, ctx, user)
+		return c.addTransaction(b, ctx, user, text)
 	}
 
 	// Call LLM to classify intent for any other case.
-	classifiedIntent, err := c.LLM.ClassifyIntent(ctx.Message.Text)
+	classifiedIntent, err := c.LLM.ClassifyIntent(context.Background(), text)
 	if err != nil {
 		c.Logger.Warnf("Failed to classify intent: %v, falling back to unknown", err)
 		classifiedIntent = ai.ClassifiedIntent{Intent: ai.IntentUnknown, Confidence: 0}
@@ -155,6 +224,9 @@ func (c *Client) classifyAndRouteIntent(b *gotgbot.Bot, ctx *ext.Context, user m
 	case ai.IntentClone:
 		return c.CloneTransactions(b, ctx)
 
+	case ai.IntentQuery:
+		return c.answerQuery(b, ctx, user, text)
+
 	default:
 		// Unknown intent - show help
 		err = c.CleanupKeyboard(b, ctx)
@@ -2,7 +2,6 @@ package client
 
 import (
 	"cashout/internal/model"
-	"cashout/internal/utils"
 	"fmt"
 	"strconv"
 	"strings"
@@ -33,7 +32,10 @@ func (c *Client) SearchTransactions(b *gotgbot.Bot, ctx *ext.Context) error {
 
 // showSearchCategorySelection displays the category selection keyboard
 func (c *Client) showSearchCategorySelection(b *gotgbot.Bot, ctx *ext.Context) error {
-	keyboard := BuildCategoryInlineKeyboard("", "search.category", "search.cancel", true)
+	keyboard, err := c.buildUserCategoryKeyboard(ctx, "", "search.category", "search.cancel", true)
+	if err != nil {
+		return err
+	}
 
 	message := "🔍 <b>Search Transactions</b>\n\nSelect a category to search in:"
 
@@ -86,7 +88,7 @@ func (c *Client) SearchCategorySelected(b *gotgbot.Bot, ctx *ext.Context) error
 	// Ask for search query
 	categoryText := "all categories"
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := c.displayCategories(user.TgID).Emoji(model.TransactionCategory(category))
 		categoryText = fmt.Sprintf("%s %s", emoji, category)
 	}
 
@@ -118,7 +120,7 @@ func (c *Client) SearchCategorySelected(b *gotgbot.Bot, ctx *ext.Context) error
 }
 
 // SearchQueryEntered handles the search query input
-func (c *Client) SearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context) error {
+func (c *Client) SearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context, text string) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
 	if err != nil {
@@ -126,7 +128,7 @@ func (c *Client) SearchQueryEntered(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	// Get search query
-	searchQuery := strings.TrimSpace(ctx.Message.Text)
+	searchQuery := strings.TrimSpace(text)
 	if searchQuery == "" {
 		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Search query cannot be empty. Please try again.", nil)
 		return err
@@ -178,10 +180,12 @@ func (c *Client) showSearchResults(b *gotgbot.Bot, ctx *ext.Context, user model.
 		return fmt.Errorf("failed to search transactions: %w", err)
 	}
 
+	categories := c.displayCategories(user.TgID)
+
 	if total == 0 {
 		message := fmt.Sprintf("🔍 No transactions found matching \"%s\"", searchQuery)
 		if category != "all" {
-			emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+			emoji := categories.Emoji(model.TransactionCategory(category))
 			message = fmt.Sprintf("🔍 No transactions found matching \"%s\" in %s %s", searchQuery, emoji, category)
 		}
 
@@ -224,7 +228,7 @@ func (c *Client) showSearchResults(b *gotgbot.Bot, ctx *ext.Context, user model.
 	}
 
 	// Format search results
-	message := formatSearchResults(transactions, searchQuery, category, offset, int(total))
+	message := formatSearchResults(transactions, categories, searchQuery, category, offset, int(total))
 
 	// Create pagination keyboard
 	keyboard := createSearchPaginationKeyboard(category, searchQuery, offset, limit, int(total))
@@ -276,7 +280,7 @@ func (c *Client) SearchResultsPage(b *gotgbot.Bot, ctx *ext.Context) error {
 }
 
 // formatSearchResults formats the search results for display
-func formatSearchResults(transactions []model.Transaction, searchQuery, category string, offset, total int) string {
+func formatSearchResults(transactions []model.Transaction, categories model.Categories, searchQuery, category string, offset, total int) string {
 	var msg strings.Builder
 
 	msg.WriteString("🔍 <b>Search Results</b>\n")
@@ -287,17 +291,20 @@ func formatSearchResults(transactions []model.Transaction, searchQuery, category
 	}
 
 	if category != "all" {
-		emoji := utils.GetCategoryEmoji(model.TransactionCategory(category))
+		emoji := categories.Emoji(model.TransactionCategory(category))
 		fmt.Fprintf(&msg, " in %s %s", emoji, category)
 	}
 
 	fmt.Fprintf(&msg, "\nShowing %d–%d of %d\n\n", offset+1, offset+len(transactions), total)
 
 	for _, t := range transactions {
-		emoji := utils.GetCategoryEmoji(t.Category)
+		emoji := categories.Emoji(t.Category)
 		sign := "-"
-		if t.Type == model.TypeIncome {
+		switch t.Type {
+		case model.TypeIncome:
 			sign = "+"
+		case model.TypeTransfer:
+			sign = "↔"
 		}
 
 		// Highlight the search term in description (skip for wildcard)
@@ -23,7 +23,31 @@ func (c *Client) SendAddTransactionKeyboard(b *gotgbot.Bot, ctx *ext.Context, te
 
 func (c *Client) SendHomeKeyboard(b *gotgbot.Bot, ctx *ext.Context, text string) error {
 	var err error
-	keyboard := [][]gotgbot.InlineKeyboardButton{
+	keyboard := c.homeKeyboard()
+
+	// Send or update message
+	if ctx.CallbackQuery != nil {
+		_, _, err = ctx.CallbackQuery.Message.EditText(b, text, &gotgbot.EditMessageTextOpts{
+			ParseMode: "HTML",
+			ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+				InlineKeyboard: keyboard,
+			},
+		})
+	} else {
+		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, text, &gotgbot.SendMessageOpts{
+			ParseMode: "HTML",
+			ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+				InlineKeyboard: keyboard,
+			},
+		})
+	}
+
+	return err
+}
+
+// homeKeyboard returns the keyboard with the main actions of the bot
+func (c *Client) homeKeyboard() [][]gotgbot.InlineKeyboardButton {
+	return [][]gotgbot.InlineKeyboardButton{
 		{
 			{Text: "💰 Add Income", CallbackData: "transactions.new.income"},
 			{Text: "💸 Add Expense", CallbackData: "transactions.new.expense"},
@@ -47,30 +71,23 @@ func (c *Client) SendHomeKeyboard(b *gotgbot.Bot, ctx *ext.Context, text string)
 		},
 		{
 			{Text: "📤 Export CSV", CallbackData: "home.export"},
+			{Text: "🗂 Categories", CallbackData: "home.categories"},
+		},
+		{
+			{Text: "👛 Accounts", CallbackData: "home.accounts"},
+			{Text: "🔁 Recurring", CallbackData: "home.recurring"},
+		},
+		{
+			{Text: "👥 Ledgers", CallbackData: "home.ledgers"},
+			{Text: "🤝 Balances", CallbackData: "home.balances"},
+		},
+		{
+			{Text: "🎯 Goals", CallbackData: "home.goals"},
 		},
 		{
 			{Text: "🌐 Web Dashboard", Url: c.Config.WebDashboardURL},
 		},
 	}
-
-	// Send or update message
-	if ctx.CallbackQuery != nil {
-		_, _, err = ctx.CallbackQuery.Message.EditText(b, text, &gotgbot.EditMessageTextOpts{
-			ParseMode: "HTML",
-			ReplyMarkup: gotgbot.InlineKeyboardMarkup{
-				InlineKeyboard: keyboard,
-			},
-		})
-	} else {
-		_, err = b.SendMessage(ctx.EffectiveSender.ChatId, text, &gotgbot.SendMessageOpts{
-			ParseMode: "HTML",
-			ReplyMarkup: gotgbot.InlineKeyboardMarkup{
-				InlineKeyboard: keyboard,
-			},
-		})
-	}
-
-	return err
 }
 
 func (c *Client) CleanupKeyboard(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -112,8 +129,9 @@ func SendMessage(ctx *ext.Context, b *gotgbot.Bot, message string, keyboard [][]
 	return err
 }
 
-// sendRecapWithNavigation sends a recap message with navigation buttons for previous/next period
-func (c *Client) sendRecapWithNavigation(b *gotgbot.Bot, ctx *ext.Context, text string, recapType string, year int, month int) error {
+// sendRecapWithNavigation sends a recap message with navigation buttons for
+// previous/next period, up to the period of today, the current day of the user
+func (c *Client) sendRecapWithNavigation(b *gotgbot.Bot, ctx *ext.Context, today time.Time, text string, recapType string, year int, month int) error {
 	var keyboard [][]gotgbot.InlineKeyboardButton
 
 	// Create navigation row with Previous/Next buttons
@@ -145,8 +163,7 @@ func (c *Client) sendRecapWithNavigation(b *gotgbot.Bot, ctx *ext.Context, text
 		}
 
 		// Add Next button if not in the future
-		currentTime := time.Now()
-		if nextYear < currentTime.Year() || (nextYear == currentTime.Year() && nextMonth <= int(currentTime.Month())) {
+		if nextYear < today.Year() || (nextYear == today.Year() && nextMonth <= int(today.Month())) {
 			navRow = append(navRow, gotgbot.InlineKeyboardButton{
 				Text:         "Next Month ➡️",
 				CallbackData: fmt.Sprintf("monthrecap.month.%d.%02d", nextYear, nextMonth),
@@ -162,8 +179,7 @@ func (c *Client) sendRecapWithNavigation(b *gotgbot.Bot, ctx *ext.Context, text
 		}
 
 		// Add Next button if not in the future
-		currentYear := time.Now().Year()
-		if year < currentYear {
+		if year < today.Year() {
 			navRow = append(navRow, gotgbot.InlineKeyboardButton{
 				Text:         "Next Year ➡️",
 				CallbackData: fmt.Sprintf("yearrecap.year.%d", year+1),
@@ -0,0 +1,430 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"cashout/internal/model"
+	"cashout/internal/repository"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// OweParticipant is a participant of a shared expense typed with /owe, Value
+// is their percentage or exact amount, 0 for equal splits
+type OweParticipant struct {
+	Username string
+	Value    float64
+}
+
+// OweInput is a shared expense typed with /owe
+type OweInput struct {
+	Amount       model.Money
+	Description  string
+	Method       model.SplitMethod
+	Participants []OweParticipant
+}
+
+// ParseOweInput reads the arguments of /owe: the amount paid, the participants
+// as @username and an optional description, e.g. "60 Dinner @bob @carol".
+// Participants get a percentage with "@bob:40%" or an exact amount with
+// "@bob:25", all of them the same way, the user's share is what is left.
+func ParseOweInput(text string) (OweInput, error) {
+	var in OweInput
+	var words []string
+	percent, exact := 0, 0
+
+	for _, field := range strings.Fields(text) {
+		if strings.HasPrefix(field, "@") {
+			username, value, hasValue := strings.Cut(strings.TrimPrefix(field, "@"), ":")
+			if username == "" {
+				return OweInput{}, errors.New("a participant is missing the username")
+			}
+
+			p := OweParticipant{Username: username}
+			if hasValue {
+				isPercent := strings.HasSuffix(value, "%")
+				v, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSuffix(value, "%"), ",", "."), 64)
+				if err != nil || v <= 0 {
+					return OweInput{}, fmt.Errorf("invalid share for @%s", username)
+				}
+				p.Value = v
+				if isPercent {
+					percent++
+				} else {
+					exact++
+				}
+			}
+			in.Participants = append(in.Participants, p)
+			continue
+		}
+
+		if in.Amount == 0 {
+			if v, err := model.ParseMoney(field); err == nil {
+				if v <= 0 {
+					return OweInput{}, errors.New("the amount must be greater than 0")
+				}
+				in.Amount = v
+				continue
+			}
+		}
+		words = append(words, field)
+	}
+
+	if in.Amount == 0 {
+		return OweInput{}, errors.New("write the amount you paid")
+	}
+	if len(in.Participants) == 0 {
+		return OweInput{}, errors.New("mention at least a participant with their @username")
+	}
+
+	switch {
+	case percent == 0 && exact == 0:
+		in.Method = model.SplitEqual
+	case percent == len(in.Participants):
+		in.Method = model.SplitPercent
+	case exact == len(in.Participants):
+		in.Method = model.SplitExact
+	default:
+		return OweInput{}, errors.New("give every participant a percentage, an exact amount or nothing for equal shares")
+	}
+
+	in.Description = strings.Join(words, " ")
+	return in, nil
+}
+
+// ParseSettleInput reads the arguments of /settle: the @username of the
+// counterpart and the amount paid, 0 when omitted, e.g. "@bob 20"
+func ParseSettleInput(text string) (string, model.Money, error) {
+	var username string
+	var amount model.Money
+	for _, field := range strings.Fields(text) {
+		if strings.HasPrefix(field, "@") && username == "" {
+			username = strings.TrimPrefix(field, "@")
+			continue
+		}
+		v, err := model.ParseMoney(field)
+		if err != nil || v <= 0 || amount != 0 {
+			return "", 0, errors.New("write the @username of who you paid and optionally the amount")
+		}
+		amount = v
+	}
+	if username == "" {
+		return "", 0, errors.New("write the @username of who you paid and optionally the amount")
+	}
+	return username, amount, nil
+}
+
+// FormatBalances renders what each counterpart owes the user or the user owes them
+func FormatBalances(balances []repository.CounterpartBalance, cur string) string {
+	if len(balances) == 0 {
+		return "You are all settled up, nobody owes you and you owe nobody."
+	}
+
+	var sb strings.Builder
+	for _, b := range balances {
+		name := html.EscapeString(b.Counterpart.DisplayName())
+		if b.Amount > 0 {
+			fmt.Fprintf(&sb, "🟢 <b>%s</b> owes you %.2f%s\n", name, b.Amount, cur)
+		} else {
+			fmt.Fprintf(&sb, "🔴 You owe <b>%s</b> %.2f%s\n", name, -b.Amount, cur)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// OweCommand handles /owe, showing the balances with the other users or, with
+// arguments, recording an expense paid by the user for the participants.
+func (c *Client) OweCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	args := ""
+	if _, rest, ok := strings.Cut(ctx.Message.Text, " "); ok {
+		args = strings.TrimSpace(rest)
+	}
+	if args == "" {
+		return c.showBalances(b, ctx, user)
+	}
+
+	in, err := ParseOweInput(args)
+	if err != nil {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s.\n\n<i>Examples:</i>\n<code>/owe 60 Dinner @bob @carol</code>\n<code>/owe 100 Rent @bob:40%%</code>\n<code>/owe 30 Taxi @bob:10 @carol:12</code>", html.EscapeString(capitalize(err.Error()))))
+	}
+
+	inputs := make([]model.ShareInput, 0, len(in.Participants))
+	usernames := make(map[int64]string, len(in.Participants))
+	for _, p := range in.Participants {
+		participant, ok, err := c.Repositories.Users.GetByUsername(p.Username)
+		if err != nil {
+			return fmt.Errorf("failed to get participant: %w", err)
+		}
+		if !ok {
+			return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ @%s has not started the bot yet, ask them to send /start before sharing expenses with them.", html.EscapeString(p.Username)))
+		}
+		inputs = append(inputs, model.ShareInput{TgID: participant.TgID, Value: p.Value})
+		usernames[participant.TgID] = p.Username
+	}
+
+	categories, err := c.Repositories.Categories.List(user.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	category, ok := categories.Fallback(model.TypeExpense)
+	if !ok {
+		category = model.CategoryOtherExpenses
+	}
+
+	transaction := model.Transaction{
+		TgID:        user.TgID,
+		Date:        user.Today(),
+		Type:        model.TypeExpense,
+		Category:    category,
+		Amount:      in.Amount,
+		Currency:    user.BaseCurrency,
+		Description: in.Description,
+	}
+	expense, err := c.Repositories.Settlements.As(botActor(user)).AddShared(&transaction, in.Method, inputs)
+	if errors.Is(err, model.ErrInvalidSharedExpense) || errors.Is(err, model.ErrSettlementCurrency) || errors.Is(err, model.ErrParticipantNotFound) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add shared expense: %w", err)
+	}
+
+	cur := user.BaseCurrency.Symbol()
+	what := "an expense"
+	if in.Description != "" {
+		what = "<i>" + html.EscapeString(in.Description) + "</i>"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ Shared expense of %.2f%s recorded in %s.\n", expense.Amount, cur, category)
+	for _, share := range expense.Shares {
+		if share.TgID == user.TgID {
+			fmt.Fprintf(&sb, "\n• Your share: %.2f%s", share.Amount, cur)
+			continue
+		}
+		fmt.Fprintf(&sb, "\n• @%s owes you %.2f%s", html.EscapeString(usernames[share.TgID]), share.Amount, cur)
+		c.notifyUser(b, share.TgID, fmt.Sprintf("🧾 <b>%s</b> paid %s of %.2f%s, your share is <b>%.2f%s</b>.\n\nSee your balances with /owe.",
+			html.EscapeString(user.DisplayName()), what, expense.Amount, cur, share.Amount, cur))
+	}
+
+	return c.SendHomeKeyboard(b, ctx, sb.String())
+}
+
+// ShowBalances handles home.balances and settle.balances.
+func (c *Client) ShowBalances(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	return c.showBalances(b, ctx, user)
+}
+
+func (c *Client) showBalances(b *gotgbot.Bot, ctx *ext.Context, user model.User) error {
+	balances, err := c.Repositories.Settlements.Balances(user.TgID)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🤝 <b>Balances</b>\n\n")
+	sb.WriteString(FormatBalances(balances, user.BaseCurrency.Symbol()))
+	sb.WriteString("\n\nShare an expense you paid with <code>/owe 60 Dinner @bob @carol</code>.")
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	if len(balances) > 0 {
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🤝 Settle Up", CallbackData: "settle.plan"}})
+	}
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}})
+
+	return SendMessage(ctx, b, sb.String(), keyboard)
+}
+
+// SettleCommand handles /settle, showing the payments settling the balances
+// or, with "@username [amount]", recording a payment to the counterpart. The
+// amount defaults to what the user owes them.
+func (c *Client) SettleCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	args := ""
+	if _, rest, ok := strings.Cut(ctx.Message.Text, " "); ok {
+		args = strings.TrimSpace(rest)
+	}
+	if args == "" {
+		return c.showSettlePlan(b, ctx, user, "")
+	}
+
+	username, amount, err := ParseSettleInput(args)
+	if err != nil {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s.\n\n<i>Examples:</i>\n<code>/settle @bob</code>\n<code>/settle @bob 20</code>", html.EscapeString(capitalize(err.Error()))))
+	}
+
+	counterpart, ok, err := c.Repositories.Users.GetByUsername(username)
+	if err != nil {
+		return fmt.Errorf("failed to get counterpart: %w", err)
+	}
+	if !ok {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ @%s has not started the bot yet.", html.EscapeString(username)))
+	}
+
+	if amount == 0 {
+		balances, err := c.Repositories.Settlements.Balances(user.TgID)
+		if err != nil {
+			return err
+		}
+		for _, bal := range balances {
+			if bal.Counterpart.TgID == counterpart.TgID && bal.Amount < 0 {
+				amount = -bal.Amount
+			}
+		}
+		if amount == 0 {
+			return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("You owe nothing to @%s, write the amount to record a payment anyway: <code>/settle @%s 20</code>", html.EscapeString(username), html.EscapeString(username)))
+		}
+	}
+
+	settlement := model.Settlement{FromTgID: user.TgID, ToTgID: counterpart.TgID, Amount: amount, Date: user.Today()}
+	return c.recordSettlement(b, ctx, user, &settlement, counterpart)
+}
+
+// ShowSettlePlan handles settle.plan.
+func (c *Client) ShowSettlePlan(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	return c.showSettlePlan(b, ctx, user, "")
+}
+
+func (c *Client) showSettlePlan(b *gotgbot.Bot, ctx *ext.Context, user model.User, notice string) error {
+	transfers, err := c.Repositories.Settlements.Plan(user.TgID)
+	if err != nil {
+		return err
+	}
+
+	cur := user.BaseCurrency.Symbol()
+	var sb strings.Builder
+	if notice != "" {
+		sb.WriteString(notice + "\n\n")
+	}
+	sb.WriteString("🤝 <b>Settle Up</b>\n\n")
+	if len(transfers) == 0 {
+		sb.WriteString("You are all settled up, nobody owes you and you owe nobody.")
+	} else {
+		sb.WriteString("The fewest payments settling the balances of you and your counterparts:\n")
+	}
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for _, t := range transfers {
+		from, to := html.EscapeString(t.From.DisplayName()), html.EscapeString(t.To.DisplayName())
+		cents := int64(t.Amount)
+		switch user.TgID {
+		case t.From.TgID:
+			fmt.Fprintf(&sb, "\n• You pay <b>%s</b> %.2f%s", to, t.Amount, cur)
+			keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{
+				Text:         fmt.Sprintf("✅ I paid %s %.2f%s", t.To.DisplayName(), t.Amount, cur),
+				CallbackData: fmt.Sprintf("settle.paid.%d.%d", t.To.TgID, cents),
+			}})
+		case t.To.TgID:
+			fmt.Fprintf(&sb, "\n• <b>%s</b> pays you %.2f%s", from, t.Amount, cur)
+			keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{
+				Text:         fmt.Sprintf("✅ %s paid me %.2f%s", t.From.DisplayName(), t.Amount, cur),
+				CallbackData: fmt.Sprintf("settle.got.%d.%d", t.From.TgID, cents),
+			}})
+		default:
+			fmt.Fprintf(&sb, "\n• <b>%s</b> pays <b>%s</b> %.2f%s", from, to, t.Amount, cur)
+		}
+	}
+	if len(transfers) > 0 {
+		sb.WriteString("\n\nRecord a different payment with <code>/settle @username amount</code>.")
+	}
+
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+		{Text: "⬅️ Balances", CallbackData: "settle.balances"},
+		{Text: "🏠 Home", CallbackData: "transactions.home"},
+	})
+	return SendMessage(ctx, b, sb.String(), keyboard)
+}
+
+// SettleRecord handles settle.paid.<TG ID>.<cents> and settle.got.<TG ID>.<cents>,
+// recording a payment of the plan made by the user or received by them.
+func (c *Client) SettleRecord(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid callback data: %s", ctx.CallbackQuery.Data)
+	}
+	tgID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid counterpart ID: %w", err)
+	}
+	cents, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+
+	counterpart, err := c.Repositories.Users.GetByTgID(tgID)
+	if err != nil {
+		return fmt.Errorf("failed to get counterpart: %w", err)
+	}
+
+	settlement := model.Settlement{FromTgID: user.TgID, ToTgID: tgID, Amount: model.Money(cents), Date: user.Today()}
+	if parts[1] == "got" {
+		settlement.FromTgID, settlement.ToTgID = tgID, user.TgID
+	}
+	return c.recordSettlement(b, ctx, user, &settlement, counterpart)
+}
+
+// recordSettlement stores a payment between the user and the counterpart,
+// notifies the counterpart and shows the remaining payments
+func (c *Client) recordSettlement(b *gotgbot.Bot, ctx *ext.Context, user model.User, settlement *model.Settlement, counterpart model.User) error {
+	settlement.CreatedBy = user.TgID
+	err := c.Repositories.Settlements.As(botActor(user)).Settle(settlement)
+	if errors.Is(err, model.ErrInvalidSettlement) || errors.Is(err, model.ErrSettlementCurrency) || errors.Is(err, model.ErrParticipantNotFound) {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("❌ %s.", html.EscapeString(capitalize(err.Error()))))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to settle: %w", err)
+	}
+
+	cur := user.BaseCurrency.Symbol()
+	name := html.EscapeString(counterpart.DisplayName())
+	me := html.EscapeString(user.DisplayName())
+	var notice string
+	if settlement.FromTgID == user.TgID {
+		notice = fmt.Sprintf("✅ Payment of %.2f%s to <b>%s</b> recorded.", settlement.Amount, cur, name)
+		c.notifyUser(b, counterpart.TgID, fmt.Sprintf("🤝 <b>%s</b> recorded a payment of %.2f%s to you, it was added to your incomes.\n\nSee your balances with /owe.", me, settlement.Amount, cur))
+	} else {
+		notice = fmt.Sprintf("✅ Payment of %.2f%s from <b>%s</b> recorded.", settlement.Amount, cur, name)
+		c.notifyUser(b, counterpart.TgID, fmt.Sprintf("🤝 <b>%s</b> recorded your payment of %.2f%s to them, it was added to your expenses.\n\nSee your balances with /owe.", me, settlement.Amount, cur))
+	}
+	return c.showSettlePlan(b, ctx, user, notice)
+}
+
+// notifyUser sends a message to another user, failures are only logged as
+// they may have blocked the bot
+func (c *Client) notifyUser(b *gotgbot.Bot, tgID int64, text string) {
+	_, err := b.SendMessage(tgID, text, &gotgbot.SendMessageOpts{ParseMode: "HTML"})
+	if err != nil {
+		c.Logger.Warnf("failed to notify user %d: %v", tgID, err)
+	}
+}
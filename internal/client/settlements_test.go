@@ -0,0 +1,116 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"cashout/internal/model"
+)
+
+func TestParseOweInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    OweInput
+		wantErr bool
+	}{
+		{
+			name:  "equal shares",
+			input: "60 Dinner out @bob @carol",
+			want: OweInput{
+				Amount:       model.NewMoney(60),
+				Description:  "Dinner out",
+				Method:       model.SplitEqual,
+				Participants: []OweParticipant{{Username: "bob"}, {Username: "carol"}},
+			},
+		},
+		{
+			name:  "percentages",
+			input: "@bob:40% Rent 100",
+			want: OweInput{
+				Amount:       model.NewMoney(100),
+				Description:  "Rent",
+				Method:       model.SplitPercent,
+				Participants: []OweParticipant{{Username: "bob", Value: 40}},
+			},
+		},
+		{
+			name:  "exact amounts with comma",
+			input: "30,5 @bob:10 @carol:12,25",
+			want: OweInput{
+				Amount:       model.NewMoney(30.5),
+				Method:       model.SplitExact,
+				Participants: []OweParticipant{{Username: "bob", Value: 10}, {Username: "carol", Value: 12.25}},
+			},
+		},
+		{
+			name:  "numbers after the amount are description",
+			input: "12 Pizza 4 seasons @bob",
+			want: OweInput{
+				Amount:       model.NewMoney(12),
+				Description:  "Pizza 4 seasons",
+				Method:       model.SplitEqual,
+				Participants: []OweParticipant{{Username: "bob"}},
+			},
+		},
+		{name: "mixed methods", input: "60 @bob:40% @carol:10", wantErr: true},
+		{name: "some without share", input: "60 @bob:40% @carol", wantErr: true},
+		{name: "missing amount", input: "Dinner @bob", wantErr: true},
+		{name: "missing participants", input: "60 Dinner", wantErr: true},
+		{name: "invalid share", input: "60 @bob:abc", wantErr: true},
+		{name: "empty username", input: "60 @", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOweInput(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseSettleInput(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantUsername string
+		wantAmount   float64
+		wantErr      bool
+	}{
+		{name: "everything owed", input: "@bob", wantUsername: "bob"},
+		{name: "with amount", input: "@bob 20,50", wantUsername: "bob", wantAmount: 20.5},
+		{name: "amount first", input: "15 @bob", wantUsername: "bob", wantAmount: 15},
+		{name: "missing username", input: "20", wantErr: true},
+		{name: "two amounts", input: "@bob 20 30", wantErr: true},
+		{name: "negative amount", input: "@bob -20", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username, amount, err := ParseSettleInput(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q %v", username, amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if username != tt.wantUsername || amount != model.NewMoney(tt.wantAmount) {
+				t.Fatalf("expected %q %v, got %q %v", tt.wantUsername, tt.wantAmount, username, amount)
+			}
+		})
+	}
+}
@@ -71,15 +71,22 @@ func SetupHandlers(dispatcher *ext.Dispatcher, c *Client) {
 	// Top-level message for LLM goes into AddTransaction and gets the expense/income intent from user session state.
 	dispatcher.AddHandler(handlers.NewMessage(noCommands, c.FreeTextRouter))
 	dispatcher.AddHandler(handlers.NewMessage(cancelText, c.Cancel))
+	dispatcher.AddHandler(handlers.NewMessage(attachmentMessage, c.AttachmentFromMessage))
+	dispatcher.AddHandler(handlers.NewMessage(voiceMessage, c.VoiceFromMessage))
 
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("transactions.new."), c.AddTransactionIntent))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("transactions.edit."), c.EditTransactionIntent))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("transactions.editcat."), c.EditTransactionCategorySelected))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("transactions.delete."), c.DeleteNewTransaction))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("transactions.setaccount."), c.SetNewTransactionAccount))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("transactions.cancel"), c.Cancel))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("transactions.editcancel"), c.EditCancel))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("transactions.home"), c.TransactionHome))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("transactions.confirm"), c.Confirm))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("transactions.batch.edit."), c.BatchEditLine))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("transactions.batch.remove."), c.BatchRemoveLine))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("transactions.batch.back"), c.BatchBack))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("transactions.batch.save"), c.BatchSave))
 
 	dispatcher.AddHandler(handlers.NewCommand("list", c.ListTransactions))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("list.cat."), c.ListCategorySelected))
@@ -95,6 +102,8 @@ func SetupHandlers(dispatcher *ext.Dispatcher, c *Client) {
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("edit.page."), c.EditTransactionPage))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("edit.select."), c.EditTransactionSelect))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("edit.field."), c.EditTransactionField))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("edit.unsplit"), c.EditTransactionUnsplit))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("edit.history."), c.EditTransactionHistory))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("edit.done"), c.EditDone))
 
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("edit.search.category."), c.EditSearchCategorySelected))
@@ -121,6 +130,11 @@ func SetupHandlers(dispatcher *ext.Dispatcher, c *Client) {
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("delete.search.showall"), c.DeleteSearchShowAll))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("delete.noop"), c.DeleteNoop))
 
+	dispatcher.AddHandler(handlers.NewCommand("trash", c.TrashCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("trash.page."), c.TrashPage))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("trash.restore."), c.TrashRestore))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("trash.undo."), c.TrashUndo))
+
 	dispatcher.AddHandler(handlers.NewCommand("clone", c.CloneTransactions))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("home.clone"), c.CloneTransactions))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("clone.entry"), c.CloneTransactions))
@@ -144,8 +158,94 @@ func SetupHandlers(dispatcher *ext.Dispatcher, c *Client) {
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("home.budget"), c.ShowBudget))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("budget.setprompt"), c.BudgetSetPrompt))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("budget.delete"), c.BudgetDeleteCallback))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("budget.delete."), c.BudgetDeleteCategoryCallback))
 	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("budget.cancel"), c.BudgetCancel))
 
+	dispatcher.AddHandler(handlers.NewCommand("currency", c.CurrencyCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("currency.set."), c.CurrencySelected))
+
+	dispatcher.AddHandler(handlers.NewCommand("timezone", c.TimezoneCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("timezone.set."), c.TimezoneSelected))
+
+	dispatcher.AddHandler(handlers.NewCommand("categories", c.CategoriesCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("home.categories"), c.ShowCategories))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("categories.list"), c.ShowCategories))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("categories.show."), c.CategoryDetails))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("categories.new."), c.CategoryNewPrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("categories.rename."), c.CategoryRenamePrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("categories.archive."), c.CategoryArchiveToggle))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("categories.delete."), c.CategoryDelete))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("categories.cancel"), c.CategoriesCancel))
+
+	dispatcher.AddHandler(handlers.NewCommand("accounts", c.AccountsCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("home.accounts"), c.ShowAccounts))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("accounts.list"), c.ShowAccounts))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("accounts.show."), c.AccountDetails))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("accounts.new"), c.AccountNewPrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("accounts.default."), c.AccountDefaultToggle))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("accounts.archive."), c.AccountArchiveToggle))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("accounts.delete."), c.AccountDelete))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("accounts.transfer"), c.AccountTransferStart))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("accounts.transferfrom."), c.AccountTransferFromSelected))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("accounts.transferto."), c.AccountTransferToSelected))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("accounts.cancel"), c.AccountsCancel))
+
+	dispatcher.AddHandler(handlers.NewCommand("recurring", c.RecurringCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("home.recurring"), c.ShowRecurring))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("recurring.list"), c.ShowRecurring))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("recurring.show."), c.RecurringDetails))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("recurring.new"), c.RecurringNewPrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("recurring.type."), c.RecurringTypeSelected))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("recurring.cat."), c.RecurringCategorySelected))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("recurring.freq."), c.RecurringFrequencySelected))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("recurring.day."), c.RecurringDaySelected))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("recurring.pause."), c.RecurringPauseToggle))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("recurring.delete."), c.RecurringDelete))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("recurring.undo."), c.RecurringUndo))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("recurring.cancel"), c.RecurringCancel))
+
+	dispatcher.AddHandler(handlers.NewCommand("ledger", c.LedgerCommand))
+	dispatcher.AddHandler(handlers.NewCommand("ledgers", c.LedgerCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("home.ledgers"), c.ShowLedgers))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("ledger.list"), c.ShowLedgers))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("ledger.show."), c.LedgerDetails))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("ledger.use."), c.LedgerUse))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("ledger.new"), c.LedgerNewPrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("ledger.join"), c.LedgerJoinPrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("ledger.role."), c.LedgerRoleCycle))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("ledger.remove."), c.LedgerRemoveMember))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("ledger.leave."), c.LedgerLeave))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("ledger.code."), c.LedgerResetCode))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("ledger.cancel"), c.LedgersCancel))
+
+	dispatcher.AddHandler(handlers.NewCommand("goals", c.GoalsCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("home.goals"), c.ShowGoals))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("goals.list"), c.ShowGoals))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("goals.show."), c.GoalDetails))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("goals.new"), c.GoalNewPrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("goals.add."), c.GoalContributePrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("goals.delete."), c.GoalDelete))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("goals.cancel"), c.GoalsCancel))
+
+	dispatcher.AddHandler(handlers.NewCommand("envelopes", c.EnvelopesCommand))
+
+	dispatcher.AddHandler(handlers.NewCommand("networth", c.NetWorthCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("networth.list"), c.ShowNetWorth))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("networth.new"), c.HoldingNewPrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("networth.checkin"), c.BalanceCheckIn))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("networth.confirm."), c.BalanceConfirm))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("networth.adjust."), c.BalanceAdjustPrompt))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("networth.skip."), c.BalanceSkip))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("networth.cancel"), c.NetWorthCancel))
+
+	dispatcher.AddHandler(handlers.NewCommand("owe", c.OweCommand))
+	dispatcher.AddHandler(handlers.NewCommand("settle", c.SettleCommand))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("home.balances"), c.ShowBalances))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("settle.balances"), c.ShowBalances))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Equal("settle.plan"), c.ShowSettlePlan))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("settle.paid."), c.SettleRecord))
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix("settle.got."), c.SettleRecord))
+
 	dispatcher.AddHandler(handlers.NewCommand("cancel", c.Cancel))
 	dispatcher.AddHandler(handlers.NewCommand("delete", c.DeleteTransactions))
 	dispatcher.AddHandler(handlers.NewCommand("start", c.Start))
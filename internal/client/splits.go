@@ -0,0 +1,74 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"cashout/internal/model"
+)
+
+// ParseSplitLines reads the lines of a split typed by the user, one per line
+// as "<category> <amount> [description]", e.g. "Grocery 30" or
+// "Toiletry 12,50 shampoo". Category names are matched case-insensitively
+// against the given categories, unknown ones are kept as typed.
+func ParseSplitLines(text string, categories model.Categories) ([]model.TransactionSplit, error) {
+	var lines []model.TransactionSplit
+	for i, row := range strings.Split(text, "\n") {
+		fields := strings.Fields(row)
+		if len(fields) == 0 {
+			continue
+		}
+
+		amountIdx := -1
+		var amount model.Money
+		for j := 1; j < len(fields); j++ {
+			v, err := model.ParseMoney(fields[j])
+			if err == nil {
+				amountIdx, amount = j, v
+				break
+			}
+		}
+		if amountIdx < 0 {
+			return nil, fmt.Errorf("line %d should be the category, the amount and optionally a description", i+1)
+		}
+
+		category := strings.Join(fields[:amountIdx], " ")
+		for _, c := range categories {
+			if strings.EqualFold(c.Name, category) {
+				category = c.Name
+				break
+			}
+		}
+
+		lines = append(lines, model.TransactionSplit{
+			Category:    model.TransactionCategory(category),
+			Amount:      amount,
+			Description: strings.Join(fields[amountIdx+1:], " "),
+		})
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("write at least one line with the category and the amount")
+	}
+	return lines, nil
+}
+
+// FormatTransactionSplits renders the lines of a split transaction, one per
+// line, or "" when it is not split
+func FormatTransactionSplits(t model.Transaction) string {
+	if !t.IsSplit() {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n✂️ Split:")
+	for _, s := range t.Splits {
+		fmt.Fprintf(&sb, "\n• %s: %s %.2f", s.Category, t.OriginalCurrency.Symbol(), s.OriginalAmount)
+		if s.Description != "" {
+			fmt.Fprintf(&sb, " (%s)", html.EscapeString(s.Description))
+		}
+	}
+	return sb.String()
+}
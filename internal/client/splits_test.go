@@ -0,0 +1,64 @@
+package client
+
+import (
+	"testing"
+
+	"cashout/internal/model"
+)
+
+func TestParseSplitLines(t *testing.T) {
+	categories := append(model.DefaultCategories(1), model.Category{Name: "Kids Stuff", Type: model.TypeExpense})
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []model.TransactionSplit
+		wantErr bool
+	}{
+		{
+			name:  "lines with descriptions",
+			input: "grocery 30\nToiletry 12,50 shampoo and soap\n\nhouse 7.5",
+			want: []model.TransactionSplit{
+				{Category: model.CategoryGrocery, Amount: model.NewMoney(30)},
+				{Category: model.CategoryToiletry, Amount: model.NewMoney(12.5), Description: "shampoo and soap"},
+				{Category: model.CategoryHouse, Amount: model.NewMoney(7.5)},
+			},
+		},
+		{
+			name:  "category with spaces",
+			input: "kids stuff 10 crayons",
+			want:  []model.TransactionSplit{{Category: "Kids Stuff", Amount: model.NewMoney(10), Description: "crayons"}},
+		},
+		{
+			name:  "unknown category kept as typed",
+			input: "Garden 5",
+			want:  []model.TransactionSplit{{Category: "Garden", Amount: model.NewMoney(5)}},
+		},
+		{name: "missing amount", input: "Grocery 30\nToiletry", wantErr: true},
+		{name: "missing category", input: "30 Grocery", wantErr: true},
+		{name: "empty", input: " \n ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSplitLines(tt.input, categories)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("line %d: expected %+v, got %+v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
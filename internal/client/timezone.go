@@ -0,0 +1,93 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// commonTimezones are offered as buttons by /timezone, any other IANA
+// timezone can be set by name
+var commonTimezones = []string{
+	"Europe/London", "Europe/Rome", "Europe/Athens",
+	"America/New_York", "America/Chicago", "America/Los_Angeles",
+	"Asia/Kolkata", "Asia/Tokyo", "Australia/Sydney",
+	"UTC",
+}
+
+// TimezoneCommand handles /timezone: with an argument (e.g. "/timezone
+// Europe/Rome") it sets the timezone directly, otherwise it shows the selector.
+func (c *Client) TimezoneCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	if ctx.Message != nil {
+		parts := strings.Fields(ctx.Message.Text)
+		if len(parts) > 1 {
+			return c.setTimezone(b, ctx, parts[1])
+		}
+	}
+	return c.ShowTimezone(b, ctx)
+}
+
+// ShowTimezone renders the current timezone and the selector to change it.
+func (c *Client) ShowTimezone(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	var row []gotgbot.InlineKeyboardButton
+	for _, tz := range commonTimezones {
+		text := tz
+		if tz == user.Location().String() {
+			text = "✅ " + tz
+		}
+		row = append(row, gotgbot.InlineKeyboardButton{Text: text, CallbackData: "timezone.set." + tz})
+		if len(row) == 2 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}})
+
+	text := fmt.Sprintf(
+		"🌍 <b>Timezone</b>\n\nYour timezone is <b>%s</b>, where it is now %s.\n\nIt sets the day of your new transactions, the weeks and months of your recaps and when they are delivered. Pick one below or send its name, e.g. <code>/timezone America/Sao_Paulo</code>.",
+		user.Location(),
+		user.Now().Format("Mon 02 Jan, 15:04"),
+	)
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// TimezoneSelected handles the timezone.set.<NAME> callback.
+func (c *Client) TimezoneSelected(b *gotgbot.Bot, ctx *ext.Context) error {
+	return c.setTimezone(b, ctx, strings.TrimPrefix(ctx.CallbackQuery.Data, "timezone.set."))
+}
+
+func (c *Client) setTimezone(b *gotgbot.Bot, ctx *ext.Context, name string) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	timezone, err := c.Repositories.Users.SetTimezone(user.TgID, name)
+	if errors.Is(err, model.ErrInvalidTimezone) {
+		text := fmt.Sprintf("❌ <b>%s</b> is not a timezone. Use an IANA name like <code>Europe/Rome</code> or <code>America/New_York</code>.", html.EscapeString(name))
+		return c.SendHomeKeyboard(b, ctx, text)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set timezone: %w", err)
+	}
+
+	user.Timezone = timezone
+	return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("✅ Timezone set to <b>%s</b>, where it is now %s.", timezone, user.Now().Format("Mon 02 Jan, 15:04")))
+}
@@ -1,12 +1,12 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"cashout/internal/model"
 	"cashout/internal/utils"
@@ -31,7 +31,7 @@ func (c *Client) AddTransactionExpense(b *gotgbot.Bot, ctx *ext.Context) error {
 		return fmt.Errorf("failed to update user data: %w", err)
 	}
 
-	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Sure! To add a new <b>expense</b>:\nTell me category, amount and description. You can also specify a date and change it later, today is default.\n\n<i>Examples:</i>\n<code>Irish Pub 3.4</code>\n<code>January salary 3k 10/01</code>", &gotgbot.SendMessageOpts{
+	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Sure! To add a new <b>expense</b>:\nTell me category, amount and description. You can also specify a date and change it later, today is default.\n\n<i>Examples:</i>\n<code>Irish Pub 3.4</code>\n<code>January salary 3k 10/01</code>\n\nSeveral at once work too: <code>coffee 2.5, lunch 12, train 4.20 yesterday</code>", &gotgbot.SendMessageOpts{
 		ParseMode: "HTML",
 		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
 			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
@@ -62,7 +62,7 @@ func (c *Client) AddTransactionIncome(b *gotgbot.Bot, ctx *ext.Context) error {
 		return fmt.Errorf("failed to update user data: %w", err)
 	}
 
-	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Sure! To add a new <b>income</b>:\nTell me category, amount and description. You can also specify a date and change it later, today is default.\n\n<i>Examples:</i>\n<code>Irish Pub 3.4</code>\n<code>January salary 3k 10/01</code>", &gotgbot.SendMessageOpts{
+	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, "Sure! To add a new <b>income</b>:\nTell me category, amount and description. You can also specify a date and change it later, today is default.\n\n<i>Examples:</i>\n<code>Irish Pub 3.4</code>\n<code>January salary 3k 10/01</code>\n\nSeveral at once work too: <code>coffee 2.5, lunch 12, train 4.20 yesterday</code>", &gotgbot.SendMessageOpts{
 		ParseMode: "HTML",
 		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
 			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
@@ -104,7 +104,7 @@ func (c *Client) AddTransactionIntent(b *gotgbot.Bot, ctx *ext.Context) error {
 		return fmt.Errorf("failed to set user data: %w", err)
 	}
 
-	_, _, err = msg.EditText(b, fmt.Sprintf("Sure! To add a new <b>%s</b>:\nTell me category, amount and description. You can also specify a date and change it later, today is default.\n\n<i>Examples:</i>\n<code>Irish Pub 3.4</code>\n<code>January salary 3k 10/01</code>", action), &gotgbot.EditMessageTextOpts{
+	_, _, err = msg.EditText(b, fmt.Sprintf("Sure! To add a new <b>%s</b>:\nTell me category, amount and description. You can also specify a date and change it later, today is default.\n\n<i>Examples:</i>\n<code>Irish Pub 3.4</code>\n<code>January salary 3k 10/01</code>\n\nSeveral at once work too: <code>coffee 2.5, lunch 12, train 4.20 yesterday</code>", action), &gotgbot.EditMessageTextOpts{
 		ParseMode: "HTML",
 	})
 	if err != nil {
@@ -125,7 +125,7 @@ func (c *Client) AddTransactionIntent(b *gotgbot.Bot, ctx *ext.Context) error {
 	return ext.ContinueGroups
 }
 
-func (c *Client) addTransaction(b *gotgbot.Bot, ctx *ext.Context, user model.User) error {
+func (c *Client) addTransaction(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
 	var transactionType model.TransactionType
 
 	switch user.Session.State {
@@ -138,8 +138,16 @@ func (c *Client) addTransaction(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 		return err
 	}
 
-	extractedTransaction, err := c.LLM.ExtractTransaction(ctx.Message.Text, transactionType)
+	categories, err := c.Repositories.Categories.List(user.TgID)
 	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	if len(categories.OfType(transactionType)) == 0 {
+		return c.SendHomeKeyboard(b, ctx, fmt.Sprintf("You have no active %s categories, add one with /categories first.", strings.ToLower(string(transactionType))))
+	}
+
+	extracted, err := c.LLM.ExtractTransactions(context.Background(), text, transactionType, categories, user.Today())
+	if err != nil || extracted[0].Amount == 0 {
 		msg := "I'm sorry, I couldn't understand your transaction!"
 		_, errm := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{
 			ParseMode: "HTML",
@@ -148,27 +156,26 @@ func (c *Client) addTransaction(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 		return err
 	}
 
-	if extractedTransaction.Amount == 0 {
-		msg := "I'm sorry, I couldn't understand your transaction!"
-		_, errm := ctx.EffectiveMessage.Reply(b, msg, &gotgbot.SendMessageOpts{
-			ParseMode: "HTML",
-		})
-		err = errors.Join(err, errm)
+	if len(extracted) > maxBatchSize {
+		_, err := ctx.EffectiveMessage.Reply(b, fmt.Sprintf("That's a lot! Send at most %d transactions in a message.", maxBatchSize), nil)
 		return err
 	}
 
-	// Convert to model.Transaction and save immediately
-	transaction := model.Transaction{
-		TgID:        user.TgID,
-		Type:        extractedTransaction.Type,
-		Category:    model.TransactionCategory(extractedTransaction.Category),
-		Amount:      extractedTransaction.Amount,
-		Description: extractedTransaction.Description,
-		Date:        extractedTransaction.Date,
-		Currency:    model.CurrencyEUR,
+	// Several transactions are reviewed before being saved all at once
+	if len(extracted) > 1 {
+		return c.sendBatchPreview(b, ctx, user, transactionBatch{Transactions: newExtractedTransactions(user, extracted)})
 	}
 
-	err = c.Repositories.Transactions.Add(&transaction)
+	// Convert to model.Transaction and save immediately
+	transaction := newExtractedTransactions(user, extracted)[0]
+
+	err = c.Repositories.Transactions.As(botActor(user)).Add(&transaction)
+	if errors.Is(err, model.ErrExchangeRateNotFound) {
+		// Retrying cannot help until the rates are loaded
+		c.Logger.Warnf("failed to add transaction: %v", err)
+		_, err = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("The exchange rate from %s to %s is not available yet, please enter the amount in %s.", transaction.OriginalCurrency, user.BaseCurrency, user.BaseCurrency), nil)
+		return err
+	}
 	if err != nil {
 		err = errors.Join(err, SendMessage(ctx, b, "There has been an error saving your transaction, please retry", nil))
 		c.Logger.Errorln("failed to add transaction", err)
@@ -189,52 +196,13 @@ func (c *Client) addTransaction(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 		emoji = "💸"
 	}
 
-	msg := fmt.Sprintf("%s <b>Transaction saved!</b>\n\n%s (€ %.2f), %s on %s", emoji, transaction.Category, transaction.Amount, transaction.Description, transaction.Date.Format("02-01-2006"))
-	if progress, perr := c.EvaluateAfterExpenseInsert(transaction); perr == nil {
-		msg += FormatBudgetSuffix(progress)
-	} else {
-		c.Logger.Warnf("budget evaluation failed: %v", perr)
-	}
+	msg := fmt.Sprintf("%s <b>Transaction saved!</b>\n\n%s (%s), %s on %s", emoji, transaction.Category, FormatTransactionAmount(transaction), transaction.Description, transaction.Date.Format("02-01-2006")) + FormatTransactionTags(transaction)
+	msg += FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction))
+	msg += c.EnvelopeSuffixForTx(user, transaction)
+	msg += "\n\n📎 Send a photo or a PDF to attach the receipt."
 	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, msg, &gotgbot.SendMessageOpts{
-		ParseMode: "HTML",
-		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
-			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
-				{
-					{
-						Text:         "Edit description",
-						CallbackData: "transactions.edit.description",
-					},
-				},
-				{
-					{
-						Text:         "Edit category",
-						CallbackData: "transactions.edit.category",
-					},
-				},
-				{
-					{
-						Text:         "Edit date",
-						CallbackData: "transactions.edit.date",
-					},
-				},
-				{
-					{
-						Text:         "Edit amount",
-						CallbackData: "transactions.edit.amount",
-					},
-				},
-				{
-					{
-						Text:         "Delete",
-						CallbackData: fmt.Sprintf("transactions.delete.%d", transaction.ID),
-					},
-					{
-						Text:         "Home",
-						CallbackData: "transactions.home",
-					},
-				},
-			},
-		},
+		ParseMode:   "HTML",
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: newTransactionKeyboard(transaction.ID)},
 	})
 	if err != nil {
 		c.Logger.Errorln("failed to send saved message", err)
@@ -244,6 +212,52 @@ func (c *Client) addTransaction(b *gotgbot.Bot, ctx *ext.Context, user model.Use
 	return nil
 }
 
+// newTransactionKeyboard returns the keyboard to edit a transaction just added
+func newTransactionKeyboard(transactionID int64) [][]gotgbot.InlineKeyboardButton {
+	return [][]gotgbot.InlineKeyboardButton{
+		{
+			{
+				Text:         "Edit description",
+				CallbackData: "transactions.edit.description",
+			},
+		},
+		{
+			{
+				Text:         "Edit category",
+				CallbackData: "transactions.edit.category",
+			},
+		},
+		{
+			{
+				Text:         "Edit date",
+				CallbackData: "transactions.edit.date",
+			},
+		},
+		{
+			{
+				Text:         "Edit amount",
+				CallbackData: "transactions.edit.amount",
+			},
+		},
+		{
+			{
+				Text:         "Edit account",
+				CallbackData: "transactions.edit.account",
+			},
+		},
+		{
+			{
+				Text:         "Delete",
+				CallbackData: fmt.Sprintf("transactions.delete.%d", transactionID),
+			},
+			{
+				Text:         "Home",
+				CallbackData: "transactions.home",
+			},
+		},
+	}
+}
+
 func (c *Client) EditTransactionIntent(b *gotgbot.Bot, ctx *ext.Context) error {
 	_, u := c.getUserFromContext(ctx)
 	user, err := c.authAndGetUser(u)
@@ -309,8 +323,27 @@ func (c *Client) EditTransactionIntent(b *gotgbot.Bot, ctx *ext.Context) error {
 		}
 		opts = &gotgbot.SendMessageOpts{ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard}}
 	case "category":
+		if transaction.IsTransfer() {
+			return c.SendHomeKeyboard(b, ctx, "Transfers between accounts have no category.")
+		}
 		text = fmt.Sprintf("Choose a new category for the transaction:\n\nCurrent: <b>%s</b>", transaction.Category)
-		keyboard := BuildCategoryInlineKeyboard(transaction.Type, "transactions.editcat", "transactions.editcancel", false)
+		keyboard, err := c.buildUserCategoryKeyboard(ctx, transaction.Type, "transactions.editcat", "transactions.editcancel", false)
+		if err != nil {
+			return err
+		}
+		opts = &gotgbot.SendMessageOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+		}
+	case "account":
+		if transaction.IsTransfer() {
+			return c.SendHomeKeyboard(b, ctx, "Create a new transfer to move money between other accounts.")
+		}
+		keyboard, err := c.buildAccountKeyboard(user.TgID, "transactions.setaccount", "transactions.editcancel", true)
+		if err != nil {
+			return err
+		}
+		text = "Choose the account of the transaction:"
 		opts = &gotgbot.SendMessageOpts{
 			ParseMode:   "HTML",
 			ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard},
@@ -334,7 +367,7 @@ func (c *Client) EditTransactionIntent(b *gotgbot.Bot, ctx *ext.Context) error {
 	return err
 }
 
-func (c *Client) editTransactionDate(b *gotgbot.Bot, ctx *ext.Context, user model.User) error {
+func (c *Client) editTransactionDate(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
 	// Load transaction from DB using the ID stored in session
 	transactionID, err := strconv.ParseInt(user.Session.Body, 10, 64)
 	if err != nil {
@@ -347,7 +380,7 @@ func (c *Client) editTransactionDate(b *gotgbot.Bot, ctx *ext.Context, user mode
 	}
 
 	// Get date from DD-MM-YYYY to date
-	date, err := utils.ParseDate(ctx.Message.Text)
+	date, err := utils.ParseDate(text)
 	if err != nil {
 		fmt.Printf("failed to parse date: %v\n", err)
 		_, errm := b.SendMessage(ctx.EffectiveSender.ChatId, "Invalid date, please try again.", nil)
@@ -355,14 +388,14 @@ func (c *Client) editTransactionDate(b *gotgbot.Bot, ctx *ext.Context, user mode
 		return err
 	}
 
-	if date.After(time.Now()) {
+	if date.After(user.Today()) {
 		_, err := b.SendMessage(ctx.EffectiveSender.ChatId, "I don't support future dates, please try again.", nil)
-		return errors.Join(err, fmt.Errorf("invalid date: %s", ctx.Message.Text))
+		return errors.Join(err, fmt.Errorf("invalid date: %s", text))
 	}
 
 	// Update the transaction in DB
 	transaction.Date = date
-	err = c.Repositories.Transactions.Update(&transaction)
+	err = c.Repositories.Transactions.As(botActor(user)).Update(&transaction)
 	if err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
@@ -379,9 +412,9 @@ func (c *Client) editTransactionDate(b *gotgbot.Bot, ctx *ext.Context, user mode
 		emoji = "💸"
 	}
 
-	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (€ %.2f), %s on %s", emoji, transaction.Category, transaction.Amount, transaction.Description, transaction.Date.Format("02-01-2006"))
+	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (%s), %s on %s", emoji, transaction.Category, FormatTransactionAmount(transaction), transaction.Description, transaction.Date.Format("02-01-2006")) + FormatTransactionTags(transaction)
 	if transaction.Type == model.TypeExpense {
-		m += c.BudgetSuffixForTx(transaction)
+		m += FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction))
 	}
 	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, m, &gotgbot.SendMessageOpts{
 		ParseMode: "HTML",
@@ -428,7 +461,7 @@ func (c *Client) editTransactionDate(b *gotgbot.Bot, ctx *ext.Context, user mode
 	return err
 }
 
-func (c *Client) editTransactionAmount(b *gotgbot.Bot, ctx *ext.Context, user model.User) error {
+func (c *Client) editTransactionAmount(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
 	// Load transaction from DB using the ID stored in session
 	transactionID, err := strconv.ParseInt(user.Session.Body, 10, 64)
 	if err != nil {
@@ -441,9 +474,9 @@ func (c *Client) editTransactionAmount(b *gotgbot.Bot, ctx *ext.Context, user mo
 	}
 
 	// Parse new amount from message
-	amountStr := strings.TrimSpace(ctx.Message.Text)
+	amountStr := strings.TrimSpace(text)
 	amountStr = strings.ReplaceAll(amountStr, ",", ".")
-	newAmount, err := strconv.ParseFloat(amountStr, 64)
+	newAmount, err := model.ParseMoney(amountStr)
 	if err != nil {
 		_, err = b.SendMessage(
 			ctx.EffectiveSender.ChatId,
@@ -463,8 +496,8 @@ func (c *Client) editTransactionAmount(b *gotgbot.Bot, ctx *ext.Context, user mo
 	}
 
 	// Update the transaction in DB
-	transaction.Amount = newAmount
-	err = c.Repositories.Transactions.Update(&transaction)
+	transaction.SetBaseAmount(newAmount)
+	err = c.updateNewTransaction(user, &transaction)
 	if err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
@@ -481,9 +514,9 @@ func (c *Client) editTransactionAmount(b *gotgbot.Bot, ctx *ext.Context, user mo
 		emoji = "💸"
 	}
 
-	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (€ %.2f), %s on %s", emoji, transaction.Category, transaction.Amount, transaction.Description, transaction.Date.Format("02-01-2006"))
+	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (%s), %s on %s", emoji, transaction.Category, FormatTransactionAmount(transaction), transaction.Description, transaction.Date.Format("02-01-2006")) + FormatTransactionTags(transaction)
 	if transaction.Type == model.TypeExpense {
-		m += c.BudgetSuffixForTx(transaction)
+		m += FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction))
 	}
 	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, m, &gotgbot.SendMessageOpts{
 		ParseMode: "HTML",
@@ -530,7 +563,7 @@ func (c *Client) editTransactionAmount(b *gotgbot.Bot, ctx *ext.Context, user mo
 	return err
 }
 
-func (c *Client) editTransactionDescription(b *gotgbot.Bot, ctx *ext.Context, user model.User) error {
+func (c *Client) editTransactionDescription(b *gotgbot.Bot, ctx *ext.Context, user model.User, text string) error {
 	// Load transaction from DB using the ID stored in session
 	transactionID, err := strconv.ParseInt(user.Session.Body, 10, 64)
 	if err != nil {
@@ -542,7 +575,7 @@ func (c *Client) editTransactionDescription(b *gotgbot.Bot, ctx *ext.Context, us
 		return fmt.Errorf("failed to get transaction from database: %w", err)
 	}
 
-	newDescription := strings.TrimSpace(ctx.Message.Text)
+	newDescription := strings.TrimSpace(text)
 	if newDescription == "" {
 		_, err = b.SendMessage(
 			ctx.EffectiveSender.ChatId,
@@ -554,7 +587,7 @@ func (c *Client) editTransactionDescription(b *gotgbot.Bot, ctx *ext.Context, us
 
 	// Update the transaction in DB
 	transaction.Description = newDescription
-	err = c.Repositories.Transactions.Update(&transaction)
+	err = c.Repositories.Transactions.As(botActor(user)).Update(&transaction)
 	if err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
@@ -571,7 +604,7 @@ func (c *Client) editTransactionDescription(b *gotgbot.Bot, ctx *ext.Context, us
 		emoji = "💸"
 	}
 
-	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (€ %.2f), %s on %s", emoji, transaction.Category, transaction.Amount, transaction.Description, transaction.Date.Format("02-01-2006"))
+	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (%s), %s on %s", emoji, transaction.Category, FormatTransactionAmount(transaction), transaction.Description, transaction.Date.Format("02-01-2006")) + FormatTransactionTags(transaction)
 	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, m, &gotgbot.SendMessageOpts{
 		ParseMode: "HTML",
 		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
@@ -633,10 +666,6 @@ func (c *Client) EditTransactionCategorySelected(b *gotgbot.Bot, ctx *ext.Contex
 	}
 	newCategory := parts[2]
 
-	if !model.IsValidTransactionCategory(newCategory) {
-		return fmt.Errorf("invalid category: %s", newCategory)
-	}
-
 	transactionID, err := strconv.ParseInt(user.Session.Body, 10, 64)
 	if err != nil {
 		return fmt.Errorf("failed to parse transaction ID from session: %w", err)
@@ -647,8 +676,16 @@ func (c *Client) EditTransactionCategorySelected(b *gotgbot.Bot, ctx *ext.Contex
 		return fmt.Errorf("failed to get transaction from database: %w", err)
 	}
 
+	categories, err := c.Repositories.Categories.Active(user.TgID, transaction.Type)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	if _, ok := categories.Find(newCategory); !ok {
+		return fmt.Errorf("invalid category: %s", newCategory)
+	}
+
 	transaction.Category = model.TransactionCategory(newCategory)
-	if err := c.Repositories.Transactions.Update(&transaction); err != nil {
+	if err := c.updateNewTransaction(user, &transaction); err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
 
@@ -662,7 +699,7 @@ func (c *Client) EditTransactionCategorySelected(b *gotgbot.Bot, ctx *ext.Contex
 		emoji = "💸"
 	}
 
-	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (€ %.2f), %s on %s", emoji, transaction.Category, transaction.Amount, transaction.Description, transaction.Date.Format("02-01-2006"))
+	m := fmt.Sprintf("%s <b>Transaction updated!</b>\n\n%s (%s), %s on %s", emoji, transaction.Category, FormatTransactionAmount(transaction), transaction.Description, transaction.Date.Format("02-01-2006")) + FormatTransactionTags(transaction)
 	_, _, err = query.Message.EditText(b, m, &gotgbot.EditMessageTextOpts{
 		ParseMode: "HTML",
 		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
@@ -698,7 +735,7 @@ func (c *Client) Confirm(b *gotgbot.Bot, ctx *ext.Context) error {
 	transaction.TgID = user.TgID
 	transaction.Currency = model.CurrencyEUR
 
-	err = c.Repositories.Transactions.Add(&transaction)
+	err = c.Repositories.Transactions.As(botActor(user)).Add(&transaction)
 	if err != nil {
 		err = errors.Join(err, SendMessage(ctx, b, "There has been an error saving your transaction, please retry", nil))
 		c.Logger.Errorln("failed to add transaction", err)
@@ -741,11 +778,8 @@ func (c *Client) Confirm(b *gotgbot.Bot, ctx *ext.Context) error {
 		emoji = "💸"
 	}
 	text := fmt.Sprintf("%s Your transaction has been saved!", emoji)
-	if progress, perr := c.EvaluateAfterExpenseInsert(transaction); perr == nil {
-		text += FormatBudgetSuffix(progress)
-	} else {
-		c.Logger.Warnf("budget evaluation failed: %v", perr)
-	}
+	text += FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction))
+	text += c.EnvelopeSuffixForTx(user, transaction)
 	return c.SendHomeKeyboard(b, ctx, text)
 }
 
@@ -811,7 +845,7 @@ func (c *Client) DeleteNewTransaction(b *gotgbot.Bot, ctx *ext.Context) error {
 	deletedTx := transaction
 
 	// Delete the transaction
-	err = c.Repositories.Transactions.Delete(transactionID, user.TgID)
+	err = c.Repositories.Transactions.As(botActor(user)).Delete(transactionID, user.TgID)
 	if err != nil {
 		return fmt.Errorf("failed to delete transaction: %w", err)
 	}
@@ -824,12 +858,13 @@ func (c *Client) DeleteNewTransaction(b *gotgbot.Bot, ctx *ext.Context) error {
 		return fmt.Errorf("failed to set user data: %w", err)
 	}
 
-	text := "Transaction deleted!"
+	text := "Transaction moved to the trash, restore it with Undo or /trash."
 	if deletedTx.Type == model.TypeExpense {
 		text += c.BudgetSuffixForTx(deletedTx)
 	}
 	// Send success message and return to home
-	return c.SendHomeKeyboard(b, ctx, text)
+	keyboard := append([][]gotgbot.InlineKeyboardButton{{undoButton(deletedTx.ID)}}, c.homeKeyboard()...)
+	return SendMessage(ctx, b, text, keyboard)
 }
 
 // TransactionHome returns to home after adding/editing a transaction.
@@ -901,7 +936,7 @@ func (c *Client) EditCancel(b *gotgbot.Bot, ctx *ext.Context) error {
 		emoji = "💸"
 	}
 
-	m := fmt.Sprintf("%s <b>Transaction saved!</b>\n\n%s (€ %.2f), %s on %s", emoji, transaction.Category, transaction.Amount, transaction.Description, transaction.Date.Format("02-01-2006"))
+	m := fmt.Sprintf("%s <b>Transaction saved!</b>\n\n%s (%s), %s on %s", emoji, transaction.Category, FormatTransactionAmount(transaction), transaction.Description, transaction.Date.Format("02-01-2006")) + FormatTransactionTags(transaction)
 	_, err = b.SendMessage(ctx.EffectiveSender.ChatId, m, &gotgbot.SendMessageOpts{
 		ParseMode: "HTML",
 		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
@@ -946,3 +981,12 @@ func (c *Client) EditCancel(b *gotgbot.Bot, ctx *ext.Context) error {
 
 	return err
 }
+
+// updateNewTransaction stores the changes to a transaction just added, a
+// cloned split transaction is no longer split once its category or amount change
+func (c *Client) updateNewTransaction(user model.User, transaction *model.Transaction) error {
+	if transaction.IsSplit() {
+		return c.Repositories.Transactions.As(botActor(user)).SetSplits(transaction, nil)
+	}
+	return c.Repositories.Transactions.As(botActor(user)).Update(transaction)
+}
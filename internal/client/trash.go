@@ -0,0 +1,219 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"cashout/internal/model"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// trashPageSize is the number of transactions shown in each page of /trash
+const trashPageSize = 5
+
+// undoButton is the button restoring a transaction just moved to the trash,
+// sent along with every delete confirmation
+func undoButton(transactionID int64) gotgbot.InlineKeyboardButton {
+	return gotgbot.InlineKeyboardButton{
+		Text:         "↩️ Undo",
+		CallbackData: fmt.Sprintf("trash.undo.%d", transactionID),
+	}
+}
+
+// FormatTrash renders a page of the transactions in the trash, with the days
+// left before each of them is permanently deleted
+func FormatTrash(transactions []model.Transaction, categories model.Categories, offset, total int, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("🗑 <b>Trash</b>\n\n")
+	if total == 0 {
+		sb.WriteString("The trash is empty.")
+		return sb.String()
+	}
+
+	days := int(model.TrashRetention.Hours() / 24)
+	fmt.Fprintf(&sb, "Deleted transactions are kept for %d days, then permanently deleted.\n", days)
+	fmt.Fprintf(&sb, "Showing %d–%d of %d\n", offset+1, offset+len(transactions), total)
+
+	for i, t := range transactions {
+		left := int(math.Ceil(t.PurgeDate().Sub(now).Hours() / 24))
+		left = max(min(left, days), 1)
+		fmt.Fprintf(&sb, "\n%d. %s %s · %s · %s\n   <i>%s</i>",
+			offset+i+1,
+			categories.Emoji(t.Category),
+			html.EscapeString(t.Description),
+			FormatTransactionAmount(t),
+			t.Date.Format("02-01-2006"),
+			pluralDays(left),
+		)
+	}
+	return sb.String()
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day left"
+	}
+	return fmt.Sprintf("%d days left", n)
+}
+
+// TrashCommand handles /trash, listing the deleted transactions the user can restore.
+func (c *Client) TrashCommand(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+	return c.showTrash(b, ctx, user, 0, "")
+}
+
+// TrashPage handles trash.page.<offset>.
+func (c *Client) TrashPage(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	offset, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return fmt.Errorf("invalid offset: %w", err)
+	}
+	return c.showTrash(b, ctx, user, max(offset, 0), "")
+}
+
+func (c *Client) showTrash(b *gotgbot.Bot, ctx *ext.Context, user model.User, offset int, notice string) error {
+	transactions, total, err := c.Repositories.Transactions.Trash(user.TgID, offset, trashPageSize)
+	if err != nil {
+		return fmt.Errorf("failed to get trash: %w", err)
+	}
+	// The last item of the last page was restored, go back a page
+	if len(transactions) == 0 && offset > 0 {
+		return c.showTrash(b, ctx, user, max(offset-trashPageSize, 0), notice)
+	}
+
+	text := FormatTrash(transactions, c.displayCategories(user.TgID), offset, int(total), time.Now())
+	if notice != "" {
+		text = notice + "\n\n" + text
+	}
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	var row []gotgbot.InlineKeyboardButton
+	for i, t := range transactions {
+		row = append(row, gotgbot.InlineKeyboardButton{
+			Text:         fmt.Sprintf("♻️ %d", offset+i+1),
+			CallbackData: fmt.Sprintf("trash.restore.%d.%d", t.ID, offset),
+		})
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+
+	var nav []gotgbot.InlineKeyboardButton
+	if offset > 0 {
+		nav = append(nav, gotgbot.InlineKeyboardButton{
+			Text:         "⬅️ Previous",
+			CallbackData: fmt.Sprintf("trash.page.%d", max(offset-trashPageSize, 0)),
+		})
+	}
+	if offset+trashPageSize < int(total) {
+		nav = append(nav, gotgbot.InlineKeyboardButton{
+			Text:         "Next ➡️",
+			CallbackData: fmt.Sprintf("trash.page.%d", offset+trashPageSize),
+		})
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: "🏠 Home", CallbackData: "transactions.home"}})
+
+	if len(transactions) > 0 {
+		text += "\n\nTap ♻️ and the number to restore a transaction."
+	}
+	return SendMessage(ctx, b, text, keyboard)
+}
+
+// TrashRestore handles trash.restore.<transaction ID>.<offset>, restoring a
+// transaction from the page of /trash it is shown in.
+func (c *Client) TrashRestore(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid callback data: %s", ctx.CallbackQuery.Data)
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %w", err)
+	}
+	offset, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return fmt.Errorf("invalid offset: %w", err)
+	}
+
+	notice, err := c.restoreTransaction(user, id)
+	if err != nil {
+		return err
+	}
+	return c.showTrash(b, ctx, user, max(offset, 0), notice)
+}
+
+// TrashUndo handles trash.undo.<transaction ID>, the Undo button of the
+// delete confirmations.
+func (c *Client) TrashUndo(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(ctx.CallbackQuery.Data, ".")
+	id, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %w", err)
+	}
+
+	notice, err := c.restoreTransaction(user, id)
+	if err != nil {
+		return err
+	}
+	keyboard := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "🗑 Trash", CallbackData: "trash.page.0"}, {Text: "🏠 Home", CallbackData: "transactions.home"}},
+	}
+	return SendMessage(ctx, b, notice, keyboard)
+}
+
+// restoreTransaction takes a transaction of the user out of the trash and
+// returns the message telling how it went
+func (c *Client) restoreTransaction(user model.User, id int64) (string, error) {
+	err := c.Repositories.Transactions.As(botActor(user)).Restore(id, user.TgID)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		return "⚠️ The transaction is no longer in the trash, it was already restored or permanently deleted.", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to restore transaction: %w", err)
+	}
+
+	transaction, err := c.Repositories.Transactions.GetByID(id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return fmt.Sprintf("♻️ <b>Transaction restored</b>\n\n%s %s (%s), %s on %s",
+		c.displayCategories(user.TgID).Emoji(transaction.Category),
+		transaction.Category,
+		FormatTransactionAmount(transaction),
+		html.EscapeString(transaction.Description),
+		transaction.Date.Format("02-01-2006"),
+	) + FormatBudgetSuffix(c.EvaluateAfterExpenseInsert(user, transaction)), nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cashout/internal/model"
+)
+
+func TestFormatTrash(t *testing.T) {
+	now := time.Date(2026, 5, 21, 12, 0, 0, 0, time.UTC)
+	deletedAt := func(daysAgo int) *time.Time {
+		d := now.AddDate(0, 0, -daysAgo)
+		return &d
+	}
+	transaction := func(description string, daysAgo int) model.Transaction {
+		return model.Transaction{
+			Type:        model.TypeExpense,
+			Category:    model.CategoryGrocery,
+			Amount:      model.NewMoney(12.5),
+			Currency:    model.CurrencyEUR,
+			Description: description,
+			Date:        time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC),
+			DeletedAt:   deletedAt(daysAgo),
+		}
+	}
+
+	tests := []struct {
+		name         string
+		transactions []model.Transaction
+		offset       int
+		total        int
+		want         []string
+		notWant      []string
+	}{
+		{
+			name:    "empty",
+			want:    []string{"The trash is empty."},
+			notWant: []string{"Showing"},
+		},
+		{
+			name:         "days left",
+			transactions: []model.Transaction{transaction("Milk & eggs", 0), transaction("Bread", 29)},
+			total:        2,
+			want:         []string{"Showing 1–2 of 2", "1. ", "Milk &amp; eggs", "30 days left", "2. ", "1 day left", "€ 12.50", "20-05-2026"},
+		},
+		{
+			name:         "second page keeps the numbering",
+			transactions: []model.Transaction{transaction("Bread", 10)},
+			offset:       5,
+			total:        6,
+			want:         []string{"Showing 6–6 of 6", "6. ", "20 days left"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatTrash(tt.transactions, model.DefaultCategories(1), tt.offset, tt.total, now)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("FormatTrash() = %q, want it to contain %q", got, w)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("FormatTrash() = %q, want it not to contain %q", got, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// Limits of the voice messages transcribed
+const (
+	maxVoiceDuration = 120      // seconds
+	maxVoiceSize     = 10 << 20 // bytes
+)
+
+// voiceMessage matches the voice notes sent to the bot
+func voiceMessage(msg *gotgbot.Message) bool {
+	return msg.Voice != nil
+}
+
+// VoiceFromMessage transcribes a voice note, echoes the transcription back
+// and handles it as if the user typed it, e.g. "spent forty euros on petrol".
+func (c *Client) VoiceFromMessage(b *gotgbot.Bot, ctx *ext.Context) error {
+	_, u := c.getUserFromContext(ctx)
+	user, err := c.authAndGetUser(u)
+	if err != nil {
+		return err
+	}
+
+	if c.LLM.Speech == nil {
+		return SendMessage(ctx, b, "🎙️ I can't listen to voice messages yet, please type your message.", nil)
+	}
+
+	voice := ctx.EffectiveMessage.Voice
+	if voice.Duration > maxVoiceDuration || voice.FileSize > maxVoiceSize {
+		return SendMessage(ctx, b, fmt.Sprintf("🎙️ That's a long one! Keep your voice messages under %d minutes, please.", maxVoiceDuration/60), nil)
+	}
+
+	content, err := downloadTelegramFile(b, voice.FileId)
+	if err != nil {
+		return fmt.Errorf("failed to download voice message: %w", err)
+	}
+	audio, err := io.ReadAll(io.LimitReader(content, maxVoiceSize))
+	_ = content.Close()
+	if err != nil {
+		return fmt.Errorf("failed to download voice message: %w", err)
+	}
+
+	// Telegram records the voice notes as OGG with the Opus codec
+	text, err := c.LLM.Transcribe(context.Background(), audio, "voice.ogg")
+	if err != nil || text == "" {
+		_, errm := ctx.EffectiveMessage.Reply(b, "🎙️ I'm sorry, I couldn't understand your voice message, please try again or type it.", nil)
+		return errors.Join(err, errm)
+	}
+
+	_, err = ctx.EffectiveMessage.Reply(b, fmt.Sprintf("🎙️ <i>%s</i>", html.EscapeString(text)), &gotgbot.SendMessageOpts{
+		ParseMode: "HTML",
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.routeText(b, ctx, user, text)
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"cashout/internal/model"
-	"cashout/internal/utils"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -29,8 +28,8 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 		return fmt.Errorf("failed to set user data: %w", err)
 	}
 
-	// Get current week boundaries (Monday to Sunday)
-	now := time.Now()
+	// Get current week boundaries (Monday to Sunday) in the user's timezone
+	now := user.Today()
 	weekday := int(now.Weekday())
 	// If Sunday (0), make it 7 for calculation
 	if weekday == 0 {
@@ -46,7 +45,7 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 	endOfWeek = time.Date(endOfWeek.Year(), endOfWeek.Month(), endOfWeek.Day(), 23, 59, 59, 999999999, endOfWeek.Location())
 
 	// Get transactions for the week
-	transactions, err := c.Repositories.Transactions.GetUserTransactionsByDateRange(user.TgID, startOfWeek, endOfWeek)
+	transactions, err := c.Repositories.Transactions.GetTransactionsByDateRange(user.Scope(), startOfWeek, endOfWeek)
 	if err != nil {
 		return fmt.Errorf("failed to get weekly transactions: %w", err)
 	}
@@ -59,15 +58,20 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	// Calculate totals by type and category
-	typeTotals := make(map[model.TransactionType]float64)
-	categoryTotals := make(map[model.TransactionType]map[model.TransactionCategory]float64)
-	dailyTotals := make(map[string]map[model.TransactionType]float64)
+	typeTotals := make(map[model.TransactionType]model.Money)
+	categoryTotals := make(map[model.TransactionType]map[model.TransactionCategory]model.Money)
+	dailyTotals := make(map[string]map[model.TransactionType]model.Money)
 
 	// Initialize category totals map
-	categoryTotals[model.TypeExpense] = make(map[model.TransactionCategory]float64)
-	categoryTotals[model.TypeIncome] = make(map[model.TransactionCategory]float64)
+	categoryTotals[model.TypeExpense] = make(map[model.TransactionCategory]model.Money)
+	categoryTotals[model.TypeIncome] = make(map[model.TransactionCategory]model.Money)
 
 	for _, t := range transactions {
+		// Transfers move money between accounts, they are neither incomes nor expenses
+		if t.IsTransfer() {
+			continue
+		}
+
 		// Type totals
 		typeTotals[t.Type] += t.Amount
 
@@ -77,19 +81,24 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 		// Daily totals
 		dayKey := t.Date.Format("Mon 02")
 		if dailyTotals[dayKey] == nil {
-			dailyTotals[dayKey] = make(map[model.TransactionType]float64)
+			dailyTotals[dayKey] = make(map[model.TransactionType]model.Money)
 		}
 		dailyTotals[dayKey][t.Type] += t.Amount
 	}
 
 	// Format the message
 	var text strings.Builder
-	var weekTotal float64
+	cur := user.BaseCurrency.Symbol()
+	userCategories := c.displayCategories(user.TgID)
+	var weekTotal model.Money
+
+	ledgerTitle, ledgerMembers := c.ledgerRecap(user, startOfWeek, endOfWeek)
 
 	// Header with week dates
-	fmt.Fprintf(&text, "📊 <b>Week %s - %s</b>\n\n",
+	fmt.Fprintf(&text, "📊 <b>Week %s - %s</b>\n%s\n",
 		startOfWeek.Format("02 Jan"),
-		endOfWeek.Format("02 Jan"))
+		endOfWeek.Format("02 Jan"),
+		ledgerTitle)
 
 	// --- DAILY BREAKDOWN ---
 	text.WriteString("<b>Daily Activity:</b>\n")
@@ -105,17 +114,17 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 	for _, dayKey := range dayKeys {
 		if totals, exists := dailyTotals[dayKey]; exists {
 			hasActivity = true
-			dayBalance := 0.0
+			var dayBalance model.Money
 
 			fmt.Fprintf(&text, "\n📅 <b>%s</b>\n", dayKey)
 
 			if expense, ok := totals[model.TypeExpense]; ok && expense > 0 {
-				fmt.Fprintf(&text, "  💸 %.2f€\n", expense)
+				fmt.Fprintf(&text, "  💸 %.2f%s\n", expense, cur)
 				dayBalance -= expense
 			}
 
 			if income, ok := totals[model.TypeIncome]; ok && income > 0 {
-				fmt.Fprintf(&text, "  💰 %.2f€\n", income)
+				fmt.Fprintf(&text, "  💰 %.2f%s\n", income, cur)
 				dayBalance += income
 			}
 
@@ -125,7 +134,7 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 				if dayBalance < 0 {
 					emoji = "❌"
 				}
-				fmt.Fprintf(&text, "  %s Balance: %.2f€\n", emoji, dayBalance)
+				fmt.Fprintf(&text, "  %s Balance: %.2f%s\n", emoji, dayBalance, cur)
 			}
 		}
 	}
@@ -139,7 +148,7 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 	// --- EXPENSES SECTION ---
 	if expenseAmount, ok := typeTotals[model.TypeExpense]; ok && expenseAmount > 0 {
 		weekTotal -= expenseAmount
-		fmt.Fprintf(&text, "💸 <b>Total Expenses:</b> %.2f€\n", expenseAmount)
+		fmt.Fprintf(&text, "💸 <b>Total Expenses:</b> %.2f%s\n", expenseAmount, cur)
 
 		// Add category breakdown for expenses
 		if expenseCats := categoryTotals[model.TypeExpense]; len(expenseCats) > 0 {
@@ -148,13 +157,13 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 			// Sort categories by amount (descending)
 			categories := make([]struct {
 				Category model.TransactionCategory
-				Amount   float64
+				Amount   model.Money
 			}, 0, len(expenseCats))
 
 			for cat, amount := range expenseCats {
 				categories = append(categories, struct {
 					Category model.TransactionCategory
-					Amount   float64
+					Amount   model.Money
 				}{cat, amount})
 			}
 
@@ -164,10 +173,10 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 
 			// Display each category with emoji
 			for _, entry := range categories {
-				emoji := utils.GetCategoryEmoji(entry.Category)
-				percentage := (entry.Amount / expenseAmount) * 100
-				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f€ (%.1f%%)\n",
-					emoji, entry.Category, entry.Amount, percentage)
+				emoji := userCategories.Emoji(entry.Category)
+				percentage := entry.Amount.Float64() / expenseAmount.Float64() * 100
+				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f%s (%.1f%%)\n",
+					emoji, entry.Category, entry.Amount, cur, percentage)
 			}
 			text.WriteString("\n")
 		}
@@ -176,7 +185,7 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 	// --- INCOME SECTION ---
 	if incomeAmount, ok := typeTotals[model.TypeIncome]; ok && incomeAmount > 0 {
 		weekTotal += incomeAmount
-		fmt.Fprintf(&text, "💰 <b>Total Income:</b> %.2f€\n", incomeAmount)
+		fmt.Fprintf(&text, "💰 <b>Total Income:</b> %.2f%s\n", incomeAmount, cur)
 
 		// Add category breakdown for income
 		if incomeCats := categoryTotals[model.TypeIncome]; len(incomeCats) > 0 {
@@ -185,13 +194,13 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 			// Sort categories by amount (descending)
 			categories := make([]struct {
 				Category model.TransactionCategory
-				Amount   float64
+				Amount   model.Money
 			}, 0, len(incomeCats))
 
 			for cat, amount := range incomeCats {
 				categories = append(categories, struct {
 					Category model.TransactionCategory
-					Amount   float64
+					Amount   model.Money
 				}{cat, amount})
 			}
 
@@ -201,10 +210,10 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 
 			// Display each category with emoji
 			for _, entry := range categories {
-				emoji := utils.GetCategoryEmoji(entry.Category)
-				percentage := (entry.Amount / incomeAmount) * 100
-				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f€ (%.1f%%)\n",
-					emoji, entry.Category, entry.Amount, percentage)
+				emoji := userCategories.Emoji(entry.Category)
+				percentage := entry.Amount.Float64() / incomeAmount.Float64() * 100
+				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f%s (%.1f%%)\n",
+					emoji, entry.Category, entry.Amount, cur, percentage)
 			}
 			text.WriteString("\n")
 		}
@@ -218,13 +227,36 @@ func (c *Client) WeekRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 		balanceEmoji = "❌"
 	}
 
-	fmt.Fprintf(&text, "\n%s <b>Week Balance:</b> %.2f€", balanceEmoji, weekTotal)
+	fmt.Fprintf(&text, "\n%s <b>Week Balance:</b> %.2f%s", balanceEmoji, weekTotal, cur)
+
+	// --- WEEKLY BUDGETS ---
+	if budgets := c.weeklyBudgets(user.Scope(), now); len(budgets) > 0 {
+		text.WriteString("\n\n" + strings.TrimSuffix(FormatBudgetRecap(budgets, userCategories, cur), "\n"))
+	}
 
 	// --- AVERAGE DAILY SPENDING ---
 	if expenseAmount, ok := typeTotals[model.TypeExpense]; ok && expenseAmount > 0 {
-		avgDaily := expenseAmount / 7
-		fmt.Fprintf(&text, "\n📈 <b>Avg Daily Spending:</b> %.2f€", avgDaily)
+		avgDaily := expenseAmount.Float64() / 7
+		fmt.Fprintf(&text, "\n📈 <b>Avg Daily Spending:</b> %.2f%s", avgDaily, cur)
 	}
+	text.WriteString(ledgerMembers)
 
 	return c.SendHomeKeyboard(b, ctx, text.String())
 }
+
+// weeklyBudgets returns the spending against the weekly budgets of a scope in
+// their current week, swallowing errors with a log line
+func (c *Client) weeklyBudgets(scope model.Scope, day time.Time) []model.BudgetSpending {
+	spending, err := c.Repositories.Budgets.Spending(scope, day)
+	if err != nil {
+		c.Logger.Warnf("failed to get budgets for recap: %v", err)
+		return nil
+	}
+	var weekly []model.BudgetSpending
+	for _, sp := range spending {
+		if sp.Budget.Period == model.PeriodWeekly {
+			weekly = append(weekly, sp)
+		}
+	}
+	return weekly
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"cashout/internal/model"
-	"cashout/internal/utils"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -29,7 +28,7 @@ func (c *Client) YearRecap(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	// Show year selection keyboard
-	return c.sendYearRecapSelectionKeyboard(b, ctx)
+	return c.sendYearRecapSelectionKeyboard(b, ctx, user.Today())
 }
 
 // YearRecapSelected displays the recap for selected year
@@ -56,9 +55,9 @@ func (c *Client) YearRecapSelected(b *gotgbot.Bot, ctx *ext.Context) error {
 	return c.showYearRecap(b, ctx, user, year)
 }
 
-// Helper function to send year selection keyboard
-func (c *Client) sendYearRecapSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Context) error {
-	currentYear := time.Now().Year()
+// Helper function to send year selection keyboard, today is the current day of the user
+func (c *Client) sendYearRecapSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Context, today time.Time) error {
+	currentYear := today.Year()
 
 	var keyboard [][]gotgbot.InlineKeyboardButton
 
@@ -115,30 +114,36 @@ func (c *Client) sendYearRecapSelectionKeyboard(b *gotgbot.Bot, ctx *ext.Context
 // Helper function to show the year recap for a specific year
 func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User, year int) error {
 	// Get monthly totals for all months
-	res, err := c.Repositories.Transactions.GetMonthlyTotalsInYear(user.TgID, year)
+	res, err := c.Repositories.Transactions.GetMonthlyTotalsInYear(user.Scope(), year)
 	if err != nil {
 		return err
 	}
 
 	// Get category breakdown for the entire year
-	categoryTotals, err := c.Repositories.Transactions.GetYearCategorizedTotals(user.TgID, year)
+	categoryTotals, err := c.Repositories.Transactions.GetYearCategorizedTotals(user.Scope(), year)
 	if err != nil {
 		return err
 	}
 
 	var msg strings.Builder
-	var yearTotal float64
-	var yearExpense float64
-	var yearIncome float64
+	cur := user.BaseCurrency.Symbol()
+	userCategories := c.displayCategories(user.TgID)
+	var yearTotal model.Money
+	var yearExpense model.Money
+	var yearIncome model.Money
 
 	// Determine which months to show
 	endMonth := 12
-	if year == time.Now().Year() {
-		endMonth = int(time.Now().Month())
+	if today := user.Today(); year == today.Year() {
+		endMonth = int(today.Month())
 	}
 
+	ledgerTitle, ledgerMembers := c.ledgerRecap(user,
+		time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC))
+
 	// Format header
-	fmt.Fprintf(&msg, "📊 <b>%d Year Summary</b>\n\n", year)
+	fmt.Fprintf(&msg, "📊 <b>%d Year Summary</b>\n%s\n", year, ledgerTitle)
 
 	// Check if there are any transactions
 	hasTransactions := false
@@ -151,7 +156,7 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 
 	if !hasTransactions {
 		fmt.Fprintf(&msg, "No transactions recorded for %d", year)
-		return c.sendRecapWithNavigation(b, ctx, msg.String(), "year", year, 0)
+		return c.sendRecapWithNavigation(b, ctx, user.Today(), msg.String(), "year", year, 0)
 	}
 
 	// --- MONTHLY BREAKDOWN SECTION ---
@@ -164,16 +169,16 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 		}
 
 		fmt.Fprintf(&msg, "🗓 <b>%s</b>\n", time.Month(m).String())
-		var monthTotal float64
+		var monthTotal model.Money
 
 		if expenseAmount, ok := monthT[model.TypeExpense]; ok && expenseAmount > 0 {
-			fmt.Fprintf(&msg, "  💸 <b>Expenses:</b> %.2f€\n", expenseAmount)
+			fmt.Fprintf(&msg, "  💸 <b>Expenses:</b> %.2f%s\n", expenseAmount, cur)
 			monthTotal -= expenseAmount
 			yearExpense += expenseAmount
 		}
 
 		if incomeAmount, ok := monthT[model.TypeIncome]; ok && incomeAmount > 0 {
-			fmt.Fprintf(&msg, "  💰 <b>Income:</b> %.2f€\n", incomeAmount)
+			fmt.Fprintf(&msg, "  💰 <b>Income:</b> %.2f%s\n", incomeAmount, cur)
 			monthTotal += incomeAmount
 			yearIncome += incomeAmount
 		}
@@ -187,7 +192,7 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 			balanceEmoji = "❌"
 		}
 
-		fmt.Fprintf(&msg, "  %s <b>Balance:</b> %.2f€\n\n", balanceEmoji, monthTotal)
+		fmt.Fprintf(&msg, "  %s <b>Balance:</b> %.2f%s\n\n", balanceEmoji, monthTotal, cur)
 	}
 
 	// --- YEAR TOTAL SECTION ---
@@ -195,7 +200,7 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 
 	// Add expense summary with category breakdown
 	if yearExpense > 0 {
-		fmt.Fprintf(&msg, "💸 <b>Total Expenses:</b> %.2f€\n", yearExpense)
+		fmt.Fprintf(&msg, "💸 <b>Total Expenses:</b> %.2f%s\n", yearExpense, cur)
 
 		// Add category breakdown for expenses
 		if expenseCats, ok := categoryTotals[model.TypeExpense]; ok && len(expenseCats) > 0 {
@@ -204,13 +209,13 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 			// Sort categories by amount (descending)
 			categories := make([]struct {
 				Category model.TransactionCategory
-				Amount   float64
+				Amount   model.Money
 			}, 0, len(expenseCats))
 
 			for cat, amount := range expenseCats {
 				categories = append(categories, struct {
 					Category model.TransactionCategory
-					Amount   float64
+					Amount   model.Money
 				}{cat, amount})
 			}
 
@@ -223,21 +228,21 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 
 			for i := range maxCategories {
 				entry := categories[i]
-				emoji := utils.GetCategoryEmoji(entry.Category)
-				percentage := (entry.Amount / yearExpense) * 100
-				fmt.Fprintf(&msg, "  %s <b>%s:</b> %.2f€ (%.1f%%)\n",
-					emoji, entry.Category, entry.Amount, percentage)
+				emoji := userCategories.Emoji(entry.Category)
+				percentage := entry.Amount.Float64() / yearExpense.Float64() * 100
+				fmt.Fprintf(&msg, "  %s <b>%s:</b> %.2f%s (%.1f%%)\n",
+					emoji, entry.Category, entry.Amount, cur, percentage)
 			}
 
 			// Show "Other" for remaining categories if more than 5
 			if len(categories) > maxCategories {
-				var otherAmount float64
+				var otherAmount model.Money
 				for i := maxCategories; i < len(categories); i++ {
 					otherAmount += categories[i].Amount
 				}
-				percentage := (otherAmount / yearExpense) * 100
-				fmt.Fprintf(&msg, "  📌 <b>Others:</b> %.2f€ (%.1f%%)\n",
-					otherAmount, percentage)
+				percentage := otherAmount.Float64() / yearExpense.Float64() * 100
+				fmt.Fprintf(&msg, "  📌 <b>Others:</b> %.2f%s (%.1f%%)\n",
+					otherAmount, cur, percentage)
 			}
 
 			msg.WriteString("\n")
@@ -246,7 +251,7 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 
 	// Add income summary with category breakdown
 	if yearIncome > 0 {
-		fmt.Fprintf(&msg, "💰 <b>Total Income:</b> %.2f€\n", yearIncome)
+		fmt.Fprintf(&msg, "💰 <b>Total Income:</b> %.2f%s\n", yearIncome, cur)
 
 		// Add category breakdown for income
 		if incomeCats, ok := categoryTotals[model.TypeIncome]; ok && len(incomeCats) > 0 {
@@ -255,13 +260,13 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 			// Sort categories by amount (descending)
 			categories := make([]struct {
 				Category model.TransactionCategory
-				Amount   float64
+				Amount   model.Money
 			}, 0, len(incomeCats))
 
 			for cat, amount := range incomeCats {
 				categories = append(categories, struct {
 					Category model.TransactionCategory
-					Amount   float64
+					Amount   model.Money
 				}{cat, amount})
 			}
 
@@ -271,10 +276,10 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 
 			// Display all income categories (usually fewer than expenses)
 			for _, entry := range categories {
-				emoji := utils.GetCategoryEmoji(entry.Category)
-				percentage := (entry.Amount / yearIncome) * 100
-				fmt.Fprintf(&msg, "  %s <b>%s:</b> %.2f€ (%.1f%%)\n",
-					emoji, entry.Category, entry.Amount, percentage)
+				emoji := userCategories.Emoji(entry.Category)
+				percentage := entry.Amount.Float64() / yearIncome.Float64() * 100
+				fmt.Fprintf(&msg, "  %s <b>%s:</b> %.2f%s (%.1f%%)\n",
+					emoji, entry.Category, entry.Amount, cur, percentage)
 			}
 
 			msg.WriteString("\n")
@@ -289,8 +294,9 @@ func (c *Client) showYearRecap(b *gotgbot.Bot, ctx *ext.Context, user model.User
 		balanceEmoji = "❌"
 	}
 
-	fmt.Fprintf(&msg, "\n%s <b>Year Balance:</b> %.2f€", balanceEmoji, yearTotal)
+	fmt.Fprintf(&msg, "\n%s <b>Year Balance:</b> %.2f%s", balanceEmoji, yearTotal, cur)
+	msg.WriteString(ledgerMembers)
 
-	return c.sendRecapWithNavigation(b, ctx, msg.String(), "year", year, 0)
+	return c.sendRecapWithNavigation(b, ctx, user.Today(), msg.String(), "year", year, 0)
 	// return c.SendHomeKeyboard(b, ctx, msg.String())
 }
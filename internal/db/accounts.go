@@ -0,0 +1,99 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// GetAccounts returns all the accounts of a user, archived ones included
+func (db *DB) GetAccounts(tgID int64) (model.Accounts, error) {
+	var accounts model.Accounts
+	err := db.conn.Where("tg_id = ?", tgID).Order("id").Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// CreateAccount inserts a single account
+func (db *DB) CreateAccount(account *model.Account) error {
+	return db.conn.Create(account).Error
+}
+
+// GetAccountByID returns an account or model.ErrAccountNotFound
+func (db *DB) GetAccountByID(id int64) (model.Account, error) {
+	var account model.Account
+	err := db.conn.First(&account, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return account, model.ErrAccountNotFound
+	}
+	return account, err
+}
+
+// UpdateAccount saves an account, archiving the default account of the user also unsets it
+func (db *DB) UpdateAccount(account *model.Account) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(account).Error; err != nil {
+			return fmt.Errorf("failed to save account: %w", err)
+		}
+
+		if !account.Archived {
+			return nil
+		}
+
+		err := tx.Model(&model.User{}).
+			Where("tg_id = ? AND default_account_id = ?", account.TgID, account.ID).
+			Update("default_account_id", nil).Error
+		if err != nil {
+			return fmt.Errorf("failed to unset default account: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteAccount removes an account, returning model.ErrAccountInUse if any
+// transaction references it, including the ones in the trash
+func (db *DB) DeleteAccount(account model.Account) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&model.Transaction{}).
+			Where("account_id = ? OR to_account_id = ?", account.ID, account.ID).
+			Count(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to count transactions: %w", err)
+		}
+		if count > 0 {
+			return model.ErrAccountInUse
+		}
+
+		return tx.Delete(&model.Account{}, account.ID).Error
+	})
+}
+
+// SetUserDefaultAccount sets the account new transactions are assigned to, nil unsets it
+func (db *DB) SetUserDefaultAccount(tgID int64, accountID *int64) error {
+	return db.conn.Model(&model.User{}).
+		Where("tg_id = ?", tgID).
+		Update("default_account_id", accountID).Error
+}
+
+// GetAccountTransactions returns the transactions moving money in or out of
+// an account up to the given date (inclusive, nil for all of them), oldest first
+func (db *DB) GetAccountTransactions(tgID, accountID int64, until *time.Time) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+
+	q := db.conn.Where("tg_id = ? AND (account_id = ? OR to_account_id = ?)", tgID, accountID, accountID).Where(notTrashed)
+	if until != nil {
+		q = q.Where("date <= ?", until.Format("2006-01-02"))
+	}
+
+	if err := q.Order("date").Order("id").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
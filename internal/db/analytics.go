@@ -8,23 +8,22 @@ import (
 // CategoryAggregate is the per-category aggregation used by analytics endpoints.
 type CategoryAggregate struct {
 	Category model.TransactionCategory
-	Amount   float64
+	Amount   model.Money
 	Count    int64
 }
 
-// GetCategoryAggregates returns per-category totals and counts for a user/type
-// over a date range (inclusive).
-func (db *DB) GetCategoryAggregates(tgID int64, startDate, endDate time.Time, transactionType model.TransactionType) ([]CategoryAggregate, error) {
+// GetCategoryAggregates returns per-category totals and counts for a scope/type
+// over a date range (inclusive). Totals are in the user's base currency, split
+// transactions are counted in each category of their lines.
+func (db *DB) GetCategoryAggregates(scope model.Scope, startDate, endDate time.Time, transactionType model.TransactionType) ([]CategoryAggregate, error) {
 	var rows []struct {
 		Category model.TransactionCategory
-		Amount   float64
+		Amount   model.Money
 		Count    int64
 	}
 
-	err := db.conn.Table("transactions").
-		Select("category, SUM(amount) as amount, COUNT(*) as count").
-		Where("tg_id = ? AND date BETWEEN ? AND ? AND type = ?",
-			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), transactionType).
+	err := db.conn.Table("(?) AS lines", db.categoryLines(scope, startDate, endDate, transactionType)).
+		Select("category, SUM(amount) as amount, COUNT(DISTINCT id) as count").
 		Group("category").
 		Order("amount DESC").
 		Scan(&rows).Error
@@ -39,26 +38,68 @@ func (db *DB) GetCategoryAggregates(tgID int64, startDate, endDate time.Time, tr
 	return result, nil
 }
 
+// TagAggregate is the per-tag aggregation used by analytics endpoints.
+type TagAggregate struct {
+	Tag    string
+	Amount model.Money
+	Count  int64
+}
+
+// GetTagAggregates returns per-tag totals and counts for a scope/type over a
+// date range (inclusive). A transaction with several tags is counted in each
+// of them, untagged transactions are left out. Totals are in the user's base
+// currency.
+func (db *DB) GetTagAggregates(scope model.Scope, startDate, endDate time.Time, transactionType model.TransactionType) ([]TagAggregate, error) {
+	var rows []struct {
+		Tag    string
+		Amount model.Money
+		Count  int64
+	}
+
+	query := db.conn.Table("transactions").
+		Select("tags.name as tag, SUM(transactions.amount) as amount, COUNT(*) as count").
+		Joins("JOIN transaction_tags ON transaction_tags.transaction_id = transactions.id").
+		Joins("JOIN tags ON tags.id = transaction_tags.tag_id").
+		Where(notTrashed)
+	err := scoped(query, scope).
+		Where("transactions.date BETWEEN ? AND ? AND transactions.type = ?",
+			startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), transactionType).
+		Group("tags.name").
+		Order("amount DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TagAggregate, len(rows))
+	for i, r := range rows {
+		result[i] = TagAggregate{Tag: r.Tag, Amount: r.Amount, Count: r.Count}
+	}
+	return result, nil
+}
+
 // MonthTotal is per-month totals keyed by YYYY-MM string.
 type MonthTotal struct {
 	YM    string
 	Type  model.TransactionType
-	Total float64
+	Total model.Money
 }
 
-// GetMonthlyTotalsByRange returns per (month, type) totals between startDate
-// (inclusive) and endDate (inclusive), ordered by month ascending.
-func (db *DB) GetMonthlyTotalsByRange(tgID int64, startDate, endDate time.Time) ([]MonthTotal, error) {
+// GetMonthlyTotalsByRange returns the per (month, type) totals of a scope
+// between startDate (inclusive) and endDate (inclusive), ordered by month
+// ascending. Totals are in the user's base currency.
+func (db *DB) GetMonthlyTotalsByRange(scope model.Scope, startDate, endDate time.Time) ([]MonthTotal, error) {
 	var rows []struct {
 		YM    string
 		Type  model.TransactionType
-		Total float64
+		Total model.Money
 	}
 
-	err := db.conn.Table("transactions").
+	err := scoped(db.conn.Table("transactions"), scope).
 		Select("to_char(date, 'YYYY-MM') as ym, type, SUM(amount) as total").
-		Where("tg_id = ? AND date BETWEEN ? AND ?",
-			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Where("date BETWEEN ? AND ?",
+			startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Where(notTrashed).
 		Group("ym, type").
 		Order("ym").
 		Scan(&rows).Error
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"errors"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// CreateAttachment inserts a new attachment
+func (db *DB) CreateAttachment(attachment *model.Attachment) error {
+	return db.conn.Create(attachment).Error
+}
+
+// GetAttachment returns an attachment of a transaction or model.ErrAttachmentNotFound
+func (db *DB) GetAttachment(transactionID, id int64) (model.Attachment, error) {
+	var attachment model.Attachment
+	err := db.conn.Where("id = ? AND transaction_id = ?", id, transactionID).First(&attachment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return attachment, model.ErrAttachmentNotFound
+	}
+	return attachment, err
+}
+
+// GetTransactionAttachments returns the attachments of a transaction, oldest first
+func (db *DB) GetTransactionAttachments(transactionID int64) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	err := db.conn.Where("transaction_id = ?", transactionID).Order("created_at, id").Find(&attachments).Error
+	return attachments, err
+}
+
+// CountTransactionAttachments returns the number of attachments of a transaction
+func (db *DB) CountTransactionAttachments(transactionID int64) (int64, error) {
+	var count int64
+	err := db.conn.Model(&model.Attachment{}).Where("transaction_id = ?", transactionID).Count(&count).Error
+	return count, err
+}
@@ -9,19 +9,91 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-// UpsertBudget inserts or updates the single budget row for a user.
+// budgetScoped restricts a query on budgets or budget alerts to the ones of the scope
+func budgetScoped(query *gorm.DB, scope model.Scope) *gorm.DB {
+	if scope.LedgerID != nil {
+		return query.Where("ledger_id = ?", *scope.LedgerID)
+	}
+	return query.Where("tg_id = ? AND ledger_id IS NULL", scope.TgID)
+}
+
+// UpsertBudget inserts or updates the budget row of a category, or the overall
+// one, for a user or for a ledger when LedgerID is set. The rollover of an
+// existing budget, its alert thresholds and its period are kept, see
+// UpdateBudgetRollover, UpdateBudgetThresholds and UpdateBudgetPeriod.
 func (db *DB) UpsertBudget(budget *model.Budget) error {
-	return db.conn.Clauses(clause.OnConflict{
-		Columns: []clause.Column{{Name: "tg_id"}},
+	conflict := clause.OnConflict{
+		Columns:     []clause.Column{{Name: "tg_id"}, {Name: "category"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "ledger_id IS NULL"}}},
 		DoUpdates: clause.AssignmentColumns([]string{
 			"amount", "currency", "updated_at",
 		}),
-	}).Create(budget).Error
+	}
+	if budget.LedgerID != nil {
+		conflict.Columns = []clause.Column{{Name: "ledger_id"}, {Name: "category"}}
+		conflict.TargetWhere = clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "ledger_id IS NOT NULL"}}}
+		conflict.DoUpdates = clause.AssignmentColumns([]string{"tg_id", "amount", "currency", "updated_at"})
+	}
+	return db.conn.Clauses(conflict).Create(budget).Error
+}
+
+// DeleteBudget removes the budget of a category of a scope, the overall one
+// for an empty category. Returns gorm.ErrRecordNotFound if none.
+func (db *DB) DeleteBudget(scope model.Scope, category model.TransactionCategory) error {
+	result := budgetScoped(db.conn, scope).Where("category = ?", category).Delete(&model.Budget{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateBudgetRollover changes the rollover of the budget of a category of a
+// scope, the overall one for an empty category. Returns gorm.ErrRecordNotFound if none.
+func (db *DB) UpdateBudgetRollover(scope model.Scope, category model.TransactionCategory, rollover model.BudgetRollover, rolloverCap model.Money) error {
+	result := budgetScoped(db.conn.Model(&model.Budget{}), scope).
+		Where("category = ?", category).
+		Updates(map[string]any{"rollover": rollover, "rollover_cap": rolloverCap, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateBudgetThresholds changes the alert thresholds of the budget of a
+// category of a scope, the overall one for an empty category. Returns
+// gorm.ErrRecordNotFound if none.
+func (db *DB) UpdateBudgetThresholds(scope model.Scope, category model.TransactionCategory, thresholds model.BudgetThresholds) error {
+	result := budgetScoped(db.conn.Model(&model.Budget{}), scope).
+		Where("category = ?", category).
+		Updates(map[string]any{"thresholds": thresholds, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
-// DeleteBudget removes the budget for a user. Returns gorm.ErrRecordNotFound if none.
-func (db *DB) DeleteBudget(tgID int64) error {
-	result := db.conn.Where("tg_id = ?", tgID).Delete(&model.Budget{})
+// UpdateBudgetPeriod changes the period of the budget of a category of a
+// scope, the overall one for an empty category, to the one of the given
+// budget. Returns gorm.ErrRecordNotFound if none.
+func (db *DB) UpdateBudgetPeriod(scope model.Scope, category model.TransactionCategory, budget *model.Budget) error {
+	result := budgetScoped(db.conn.Model(&model.Budget{}), scope).
+		Where("category = ?", category).
+		Updates(map[string]any{
+			"period":     budget.Period,
+			"week_start": budget.WeekStart,
+			"start_date": budget.StartDate,
+			"end_date":   budget.EndDate,
+			"updated_at": time.Now(),
+		})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -31,30 +103,41 @@ func (db *DB) DeleteBudget(tgID int64) error {
 	return nil
 }
 
-// GetBudget returns the user's budget or gorm.ErrRecordNotFound.
-func (db *DB) GetBudget(tgID int64) (*model.Budget, error) {
+// GetBudget returns the budget of a category of a scope, the overall one for
+// an empty category, or gorm.ErrRecordNotFound.
+func (db *DB) GetBudget(scope model.Scope, category model.TransactionCategory) (*model.Budget, error) {
 	var b model.Budget
-	err := db.conn.Where("tg_id = ?", tgID).First(&b).Error
+	err := budgetScoped(db.conn, scope).Where("category = ?", category).First(&b).Error
 	if err != nil {
 		return nil, err
 	}
 	return &b, nil
 }
 
-// GetTotalExpensesForMonth sums all Expense amounts for a user in a calendar month.
-func (db *DB) GetTotalExpensesForMonth(tgID int64, year, month int) (float64, error) {
-	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	endDate := startDate.AddDate(0, 1, -1)
+// GetBudgets returns all the budgets of a scope, the overall one first and
+// then the ones of the categories by name.
+func (db *DB) GetBudgets(scope model.Scope) ([]model.Budget, error) {
+	var budgets []model.Budget
+	err := budgetScoped(db.conn, scope).Order("category ASC").Find(&budgets).Error
+	if err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
 
-	var total float64
-	err := db.conn.Table("transactions").
+// GetTotalExpenses sums all Expense amounts of a scope from start to end
+// included, in the user's base currency. The lines of a split transaction sum
+// to its amount, so it is counted once as a whole.
+func (db *DB) GetTotalExpenses(scope model.Scope, start, end time.Time) (model.Money, error) {
+	var total model.Money
+	err := scoped(db.conn.Table("transactions"), scope).
 		Select("COALESCE(SUM(amount), 0) as total").
-		Where("tg_id = ? AND date BETWEEN ? AND ? AND type = ?",
-			tgID,
-			startDate.Format("2006-01-02"),
-			endDate.Format("2006-01-02"),
+		Where("date BETWEEN ? AND ? AND type = ?",
+			start.Format("2006-01-02"),
+			end.Format("2006-01-02"),
 			model.TypeExpense,
 		).
+		Where(notTrashed).
 		Scan(&total).Error
 	if err != nil {
 		return 0, err
@@ -62,20 +145,73 @@ func (db *DB) GetTotalExpensesForMonth(tgID int64, year, month int) (float64, er
 	return total, nil
 }
 
+// GetCategoryExpenses sums the Expense amounts of a scope from start to end
+// included by category, in the user's base currency. Split transactions are
+// counted in the categories of their lines.
+func (db *DB) GetCategoryExpenses(scope model.Scope, start, end time.Time) (map[model.TransactionCategory]model.Money, error) {
+	return db.GetTransactionsByCategory(scope, start, end, model.TypeExpense)
+}
+
+// GetDailyExpenses sums the Expense amounts of a scope by day, keyed
+// "2006-01-02", from start to end included, in the user's base currency. With
+// a category only its expenses count, the lines of split transactions
+// included, otherwise all of them do.
+func (db *DB) GetDailyExpenses(scope model.Scope, category model.TransactionCategory, start, end time.Time) (map[string]model.Money, error) {
+	var query *gorm.DB
+	if category != "" {
+		query = db.conn.Table("(?) AS lines", db.categoryLines(scope, start, end, model.TypeExpense)).
+			Where("category = ?", category)
+	} else {
+		query = scoped(db.conn.Table("transactions"), scope).
+			Where("date BETWEEN ? AND ? AND type = ?",
+				start.Format("2006-01-02"),
+				end.Format("2006-01-02"),
+				model.TypeExpense,
+			).
+			Where(notTrashed)
+	}
+
+	var rows []struct {
+		Day   string
+		Total model.Money
+	}
+	err := query.
+		Select("TO_CHAR(date, 'YYYY-MM-DD') AS day, SUM(amount) AS total").
+		Group("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]model.Money, len(rows))
+	for _, r := range rows {
+		totals[r.Day] = r.Total
+	}
+	return totals, nil
+}
+
 // TryMarkAlertFired inserts an alert row; returns true if the insert actually happened
-// (i.e. the alert had not yet fired for this user/month/threshold).
-func (db *DB) TryMarkAlertFired(tgID int64, yearMonth string, threshold int16) (bool, error) {
+// (i.e. the alert had not yet fired for this user or ledger/category/period/threshold).
+func (db *DB) TryMarkAlertFired(scope model.Scope, category model.TransactionCategory, period string, threshold int16) (bool, error) {
 	alert := model.BudgetAlert{
-		TgID:      tgID,
-		YearMonth: yearMonth,
+		TgID:      scope.TgID,
+		LedgerID:  scope.LedgerID,
+		Category:  category,
+		Period:    period,
 		Threshold: threshold,
 	}
 
-	result := db.conn.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "tg_id"}, {Name: "year_month"}, {Name: "threshold"}},
-		DoNothing: true,
-	}).Create(&alert)
+	conflict := clause.OnConflict{
+		Columns:     []clause.Column{{Name: "tg_id"}, {Name: "category"}, {Name: "period"}, {Name: "threshold"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "ledger_id IS NULL"}}},
+		DoNothing:   true,
+	}
+	if scope.LedgerID != nil {
+		conflict.Columns = []clause.Column{{Name: "ledger_id"}, {Name: "category"}, {Name: "period"}, {Name: "threshold"}}
+		conflict.TargetWhere = clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "ledger_id IS NOT NULL"}}}
+	}
 
+	result := db.conn.Clauses(conflict).Create(&alert)
 	if result.Error != nil {
 		return false, result.Error
 	}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetCategories returns all the categories of a user, archived ones included
+func (db *DB) GetCategories(tgID int64) (model.Categories, error) {
+	var categories model.Categories
+	err := db.conn.Where("tg_id = ?", tgID).Order("type, id").Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// CreateCategories inserts the given categories, skipping the ones already existing
+func (db *DB) CreateCategories(categories model.Categories) error {
+	if len(categories) == 0 {
+		return nil
+	}
+	return db.conn.Clauses(clause.OnConflict{DoNothing: true}).Create(&categories).Error
+}
+
+// CreateCategory inserts a single category
+func (db *DB) CreateCategory(category *model.Category) error {
+	return db.conn.Create(category).Error
+}
+
+// GetCategoryByID returns a category or model.ErrCategoryNotFound
+func (db *DB) GetCategoryByID(id int64) (model.Category, error) {
+	var category model.Category
+	err := db.conn.First(&category, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return category, model.ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// UpdateCategory saves a category, renaming the transactions, split lines,
+// recurring rules, budgets and envelopes referencing it in the same database
+// transaction when its name changed
+func (db *DB) UpdateCategory(category *model.Category, oldName string) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			return fmt.Errorf("failed to save category: %w", err)
+		}
+
+		if category.Name == oldName {
+			return nil
+		}
+
+		err := tx.Model(&model.Transaction{}).
+			Where("tg_id = ? AND category = ?", category.TgID, oldName).
+			Update("category", category.Name).Error
+		if err != nil {
+			return fmt.Errorf("failed to rename transactions category: %w", err)
+		}
+
+		err = tx.Model(&model.TransactionSplit{}).
+			Where("category = ? AND transaction_id IN (?)", oldName,
+				tx.Model(&model.Transaction{}).Select("id").Where("tg_id = ?", category.TgID)).
+			Update("category", category.Name).Error
+		if err != nil {
+			return fmt.Errorf("failed to rename split lines category: %w", err)
+		}
+
+		err = tx.Model(&model.RecurringRule{}).
+			Where("tg_id = ? AND category = ?", category.TgID, oldName).
+			Update("category", category.Name).Error
+		if err != nil {
+			return fmt.Errorf("failed to rename recurring rules category: %w", err)
+		}
+
+		for _, m := range []any{&model.Budget{}, &model.BudgetAlert{}, &model.EnvelopeAllocation{}} {
+			err = tx.Model(m).
+				Where("tg_id = ? AND category = ?", category.TgID, oldName).
+				Update("category", category.Name).Error
+			if err != nil {
+				return fmt.Errorf("failed to rename budgets category: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteCategory removes a category with the budgets set on it and the money
+// assigned to it as an envelope, returning model.ErrCategoryInUse if any
+// transaction, split line or recurring rule references it. The transactions in
+// the trash count too, as they may be restored.
+func (db *DB) DeleteCategory(category model.Category) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&model.Transaction{}).
+			Where("tg_id = ? AND category = ?", category.TgID, category.Name).
+			Count(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to count transactions: %w", err)
+		}
+		if count > 0 {
+			return model.ErrCategoryInUse
+		}
+
+		err = tx.Model(&model.TransactionSplit{}).
+			Joins("JOIN transactions ON transactions.id = transaction_splits.transaction_id").
+			Where("transactions.tg_id = ? AND transaction_splits.category = ?", category.TgID, category.Name).
+			Count(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to count split lines: %w", err)
+		}
+		if count > 0 {
+			return model.ErrCategoryInUse
+		}
+
+		err = tx.Model(&model.RecurringRule{}).
+			Where("tg_id = ? AND category = ?", category.TgID, category.Name).
+			Count(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to count recurring rules: %w", err)
+		}
+		if count > 0 {
+			return model.ErrCategoryInUse
+		}
+
+		for _, m := range []any{&model.Budget{}, &model.BudgetAlert{}, &model.EnvelopeAllocation{}} {
+			err = tx.Where("tg_id = ? AND category = ?", category.TgID, category.Name).Delete(m).Error
+			if err != nil {
+				return fmt.Errorf("failed to delete category budgets: %w", err)
+			}
+		}
+
+		return tx.Delete(&model.Category{}, category.ID).Error
+	})
+}
@@ -49,6 +49,14 @@ func (db *DB) Transaction(fn func(tx *gorm.DB) error) error {
 	return db.conn.Transaction(fn)
 }
 
+// InTransaction runs fn with a DB whose methods all run in the same database
+// transaction, committed when fn returns nil
+func (db *DB) InTransaction(fn func(tx *DB) error) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		return fn(&DB{conn: tx})
+	})
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	sqlDB, err := db.conn.DB()
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"time"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// GetEnvelopeAllocations returns the allocations of a user up to a month
+// ("2006-01") included, oldest first
+func (db *DB) GetEnvelopeAllocations(tgID int64, month string) ([]model.EnvelopeAllocation, error) {
+	var allocations []model.EnvelopeAllocation
+	err := db.conn.Where("tg_id = ? AND month <= ?", tgID, month).
+		Order("month").
+		Order("id").
+		Find(&allocations).Error
+	if err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// HasEnvelopeAllocations reports whether the user ever assigned money to an
+// envelope
+func (db *DB) HasEnvelopeAllocations(tgID int64) (bool, error) {
+	var count int64
+	err := db.conn.Model(&model.EnvelopeAllocation{}).Where("tg_id = ?", tgID).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateEnvelopeAllocations inserts allocations in a single database
+// transaction, so that a move between envelopes is never half done
+func (db *DB) CreateEnvelopeAllocations(allocations []model.EnvelopeAllocation) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&allocations).Error
+	})
+}
+
+// GetMonthlyTotals sums the amounts of a type of transactions of a scope by
+// month, keyed "2006-01", from start to end included, in the user's base
+// currency
+func (db *DB) GetMonthlyTotals(scope model.Scope, start, end time.Time, transactionType model.TransactionType) (map[string]model.Money, error) {
+	var rows []struct {
+		Month string
+		Total model.Money
+	}
+	err := scoped(db.conn.Table("transactions"), scope).
+		Select("TO_CHAR(date, 'YYYY-MM') AS month, COALESCE(SUM(amount), 0) AS total").
+		Where("date BETWEEN ? AND ? AND type = ?", start.Format("2006-01-02"), end.Format("2006-01-02"), transactionType).
+		Where(notTrashed).
+		Group("month").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]model.Money, len(rows))
+	for _, r := range rows {
+		totals[r.Month] = r.Total
+	}
+	return totals, nil
+}
+
+// GetMonthlyCategoryTotals sums the amounts of a type of transactions of a
+// scope by month, keyed "2006-01", and category, from start to end included.
+// Split transactions are counted in the categories of their lines.
+func (db *DB) GetMonthlyCategoryTotals(scope model.Scope, start, end time.Time, transactionType model.TransactionType) (map[string]map[model.TransactionCategory]model.Money, error) {
+	var rows []struct {
+		Month    string
+		Category model.TransactionCategory
+		Total    model.Money
+	}
+	err := db.conn.Table("(?) AS lines", db.categoryLines(scope, start, end, transactionType)).
+		Select("TO_CHAR(date, 'YYYY-MM') AS month, category, SUM(amount) AS total").
+		Group("month, category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]map[model.TransactionCategory]model.Money)
+	for _, r := range rows {
+		if totals[r.Month] == nil {
+			totals[r.Month] = make(map[model.TransactionCategory]model.Money)
+		}
+		totals[r.Month][r.Category] = r.Total
+	}
+	return totals, nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"fmt"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetExchangeRates returns every known exchange rate
+func (db *DB) GetExchangeRates() (model.RateTable, error) {
+	var rates []model.ExchangeRate
+	if err := db.conn.Order("base, quote").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// UpsertExchangeRates inserts or updates the given rates, keyed by (base, quote)
+func (db *DB) UpsertExchangeRates(rates []model.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	return db.conn.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(&rates).Error
+}
+
+// SetUserBaseCurrency changes the base currency of a user, converting the
+// amount of every transaction and of its split lines (from their original
+// values), the budget, the goals with their contributions and the envelope
+// allocations into it.
+// Everything happens in a single database transaction, so aggregates never
+// mix currencies.
+func (db *DB) SetUserBaseCurrency(tgID int64, base model.CurrencyType, rates model.RateTable) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		var transactions []model.Transaction
+		if err := tx.Preload("Splits", orderSplits).Where("tg_id = ?", tgID).Find(&transactions).Error; err != nil {
+			return fmt.Errorf("failed to get transactions: %w", err)
+		}
+
+		for _, t := range transactions {
+			// The converted lines are validated, a split that cannot be
+			// converted cancels the change of currency
+			if err := t.ConvertTo(base, rates); err != nil {
+				return fmt.Errorf("failed to convert transaction %d: %w", t.ID, err)
+			}
+			err := tx.Model(&model.Transaction{}).
+				Where("id = ?", t.ID).
+				Updates(map[string]any{"amount": t.Amount, "currency": t.Currency}).Error
+			if err != nil {
+				return fmt.Errorf("failed to convert transaction %d: %w", t.ID, err)
+			}
+
+			for _, s := range t.Splits {
+				err := tx.Model(&model.TransactionSplit{}).Where("id = ?", s.ID).Update("amount", s.Amount).Error
+				if err != nil {
+					return fmt.Errorf("failed to convert split of transaction %d: %w", t.ID, err)
+				}
+			}
+		}
+
+		var budgets []model.Budget
+		if err := tx.Where("tg_id = ? AND ledger_id IS NULL", tgID).Find(&budgets).Error; err != nil {
+			return fmt.Errorf("failed to get budget: %w", err)
+		}
+
+		for _, b := range budgets {
+			amount, err := rates.ConvertAmount(model.NewAmount(b.Amount, b.Currency), base)
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&model.Budget{}).
+				Where("id = ?", b.ID).
+				Updates(map[string]any{"amount": amount.Money, "currency": amount.Currency}).Error
+			if err != nil {
+				return fmt.Errorf("failed to convert budget: %w", err)
+			}
+		}
+
+		var goals []model.Goal
+		if err := tx.Where("tg_id = ?", tgID).Find(&goals).Error; err != nil {
+			return fmt.Errorf("failed to get goals: %w", err)
+		}
+
+		for _, g := range goals {
+			var contributions []model.GoalContribution
+			if err := tx.Where("goal_id = ?", g.ID).Find(&contributions).Error; err != nil {
+				return fmt.Errorf("failed to get contributions of goal %d: %w", g.ID, err)
+			}
+			if err := g.ConvertTo(base, rates, contributions); err != nil {
+				return err
+			}
+
+			err := tx.Model(&model.Goal{}).
+				Where("id = ?", g.ID).
+				Updates(map[string]any{"target_amount": g.TargetAmount, "currency": g.Currency}).Error
+			if err != nil {
+				return fmt.Errorf("failed to convert goal %d: %w", g.ID, err)
+			}
+			for _, c := range contributions {
+				err := tx.Model(&model.GoalContribution{}).Where("id = ?", c.ID).Update("amount", c.Amount).Error
+				if err != nil {
+					return fmt.Errorf("failed to convert contribution of goal %d: %w", g.ID, err)
+				}
+			}
+		}
+
+		// The allocations have no currency, they are in the previous base one
+		var user model.User
+		if err := tx.Where("tg_id = ?", tgID).First(&user).Error; err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		var allocations []model.EnvelopeAllocation
+		if err := tx.Where("tg_id = ?", tgID).Find(&allocations).Error; err != nil {
+			return fmt.Errorf("failed to get envelope allocations: %w", err)
+		}
+
+		for _, a := range allocations {
+			amount, err := rates.ConvertAmount(model.NewAmount(a.Amount, user.BaseCurrency), base)
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&model.EnvelopeAllocation{}).Where("id = ?", a.ID).Update("amount", amount.Money).Error
+			if err != nil {
+				return fmt.Errorf("failed to convert envelope allocation %d: %w", a.ID, err)
+			}
+		}
+
+		return tx.Model(&model.User{}).
+			Where("tg_id = ?", tgID).
+			Update("base_currency", base).Error
+	})
+}
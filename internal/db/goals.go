@@ -0,0 +1,91 @@
+package db
+
+import (
+	"errors"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// GetGoals returns all the goals of a user, oldest first
+func (db *DB) GetGoals(tgID int64) ([]model.Goal, error) {
+	var goals []model.Goal
+	err := db.conn.Where("tg_id = ?", tgID).Order("id").Find(&goals).Error
+	if err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+// GetGoalByID returns a goal or model.ErrGoalNotFound
+func (db *DB) GetGoalByID(id int64) (model.Goal, error) {
+	var goal model.Goal
+	err := db.conn.First(&goal, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return goal, model.ErrGoalNotFound
+	}
+	return goal, err
+}
+
+// CreateGoal inserts a single goal
+func (db *DB) CreateGoal(goal *model.Goal) error {
+	return db.conn.Create(goal).Error
+}
+
+// UpdateGoal saves a goal
+func (db *DB) UpdateGoal(goal *model.Goal) error {
+	return db.conn.Save(goal).Error
+}
+
+// DeleteGoal removes a goal along with its manual contributions, the tagged
+// transactions are kept
+func (db *DB) DeleteGoal(id int64) error {
+	return db.conn.Delete(&model.Goal{}, id).Error
+}
+
+// GetGoalContributions returns the manual contributions of a goal, oldest first
+func (db *DB) GetGoalContributions(goalID int64) ([]model.GoalContribution, error) {
+	var contributions []model.GoalContribution
+	err := db.conn.Where("goal_id = ?", goalID).Order("date").Order("id").Find(&contributions).Error
+	if err != nil {
+		return nil, err
+	}
+	return contributions, nil
+}
+
+// CreateGoalContribution inserts a single manual contribution
+func (db *DB) CreateGoalContribution(contribution *model.GoalContribution) error {
+	return db.conn.Create(contribution).Error
+}
+
+// DeleteGoalContribution removes a manual contribution of a goal, returning
+// model.ErrContributionNotFound if the goal has no such contribution
+func (db *DB) DeleteGoalContribution(goalID, id int64) error {
+	result := db.conn.Where("goal_id = ?", goalID).Delete(&model.GoalContribution{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrContributionNotFound
+	}
+	return nil
+}
+
+// GetTaggedTransactions returns the transactions of a user with the given
+// tag, oldest first. Transactions in the trash are excluded.
+func (db *DB) GetTaggedTransactions(tgID int64, tag string) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+	err := db.conn.
+		Joins("JOIN transaction_tags ON transaction_tags.transaction_id = transactions.id").
+		Joins("JOIN tags ON tags.id = transaction_tags.tag_id").
+		Where("transactions.tg_id = ? AND tags.name = ?", tgID, tag).
+		Where(notTrashed).
+		Order("transactions.date").
+		Order("transactions.id").
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
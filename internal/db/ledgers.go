@@ -0,0 +1,151 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// scoped restricts a query on the transactions table to the transactions of the scope
+func scoped(query *gorm.DB, scope model.Scope) *gorm.DB {
+	if scope.LedgerID != nil {
+		return query.Where("transactions.ledger_id = ?", *scope.LedgerID)
+	}
+	return query.Where("transactions.tg_id = ?", scope.TgID)
+}
+
+// preloadMembers loads the members of a ledger with their user, in the order they joined
+func preloadMembers(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("User").Order("joined_at, tg_id")
+}
+
+// CreateLedger inserts a ledger along with its members
+func (db *DB) CreateLedger(ledger *model.Ledger) error {
+	return db.conn.Omit("Members.User").Create(ledger).Error
+}
+
+// GetLedgerByID returns a ledger with its members or model.ErrLedgerNotFound
+func (db *DB) GetLedgerByID(id int64) (model.Ledger, error) {
+	var ledger model.Ledger
+	err := db.conn.Preload("Members", preloadMembers).First(&ledger, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ledger, model.ErrLedgerNotFound
+	}
+	return ledger, err
+}
+
+// GetLedgerByInviteCode returns a ledger with its members or model.ErrInvalidInviteCode
+func (db *DB) GetLedgerByInviteCode(code string) (model.Ledger, error) {
+	var ledger model.Ledger
+	err := db.conn.Preload("Members", preloadMembers).Where("invite_code = ?", code).First(&ledger).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ledger, model.ErrInvalidInviteCode
+	}
+	return ledger, err
+}
+
+// GetUserLedgers returns the ledgers a user is a member of, with their members
+func (db *DB) GetUserLedgers(tgID int64) ([]model.Ledger, error) {
+	var ledgers []model.Ledger
+	err := db.conn.Preload("Members", preloadMembers).
+		Where("id IN (?)", db.conn.Model(&model.LedgerMember{}).Select("ledger_id").Where("tg_id = ?", tgID)).
+		Order("id").
+		Find(&ledgers).Error
+	if err != nil {
+		return nil, err
+	}
+	return ledgers, nil
+}
+
+// UpdateLedger saves the name and the invite code of a ledger
+func (db *DB) UpdateLedger(ledger *model.Ledger) error {
+	return db.conn.Model(ledger).Select("name", "invite_code", "updated_at").Updates(ledger).Error
+}
+
+// AddLedgerMember adds a user to a ledger
+func (db *DB) AddLedgerMember(member *model.LedgerMember) error {
+	return db.conn.Omit("User").Create(member).Error
+}
+
+// SetLedgerMemberRole changes the role of a member of a ledger
+func (db *DB) SetLedgerMemberRole(ledgerID, tgID int64, role model.LedgerRole) error {
+	return db.conn.Model(&model.LedgerMember{}).
+		Where("ledger_id = ? AND tg_id = ?", ledgerID, tgID).
+		Update("role", role).Error
+}
+
+// DeleteLedgerMember removes a user from a ledger, switching them back to
+// their personal scope if it was their active ledger. The ledger is deleted
+// with its last member, its transactions then go back to the members who
+// entered them.
+func (db *DB) DeleteLedgerMember(ledgerID, tgID int64) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("ledger_id = ? AND tg_id = ?", ledgerID, tgID).Delete(&model.LedgerMember{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete ledger member: %w", err)
+		}
+
+		err = tx.Model(&model.User{}).
+			Where("tg_id = ? AND ledger_id = ?", tgID, ledgerID).
+			Update("ledger_id", nil).Error
+		if err != nil {
+			return fmt.Errorf("failed to unset active ledger: %w", err)
+		}
+
+		var left int64
+		if err := tx.Model(&model.LedgerMember{}).Where("ledger_id = ?", ledgerID).Count(&left).Error; err != nil {
+			return fmt.Errorf("failed to count ledger members: %w", err)
+		}
+		if left > 0 {
+			return nil
+		}
+
+		if err := tx.Delete(&model.Ledger{}, ledgerID).Error; err != nil {
+			return fmt.Errorf("failed to delete ledger: %w", err)
+		}
+		return nil
+	})
+}
+
+// IsLedgerMember reports whether a user is a member of any ledger
+func (db *DB) IsLedgerMember(tgID int64) (bool, error) {
+	var count int64
+	err := db.conn.Model(&model.LedgerMember{}).Where("tg_id = ?", tgID).Count(&count).Error
+	return count > 0, err
+}
+
+// SetUserLedger sets the active ledger of a user, nil switches back to their personal scope
+func (db *DB) SetUserLedger(tgID int64, ledgerID *int64) error {
+	return db.conn.Model(&model.User{}).Where("tg_id = ?", tgID).Update("ledger_id", ledgerID).Error
+}
+
+// MemberTotal is the total of a type of transactions entered by a member of a ledger
+type MemberTotal struct {
+	TgID  int64
+	Type  model.TransactionType
+	Total model.Money
+	Count int64
+}
+
+// GetLedgerMemberTotals returns the income and expense totals of each member
+// of a ledger over a date range (inclusive), in the ledger's currency
+func (db *DB) GetLedgerMemberTotals(ledgerID int64, startDate, endDate time.Time) ([]MemberTotal, error) {
+	var rows []MemberTotal
+	err := db.conn.Table("transactions").
+		Select("tg_id, type, SUM(amount) as total, COUNT(*) as count").
+		Where("ledger_id = ? AND date BETWEEN ? AND ? AND type IN ?",
+			ledgerID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"),
+			[]model.TransactionType{model.TypeIncome, model.TypeExpense}).
+		Where(notTrashed).
+		Group("tg_id, type").
+		Order("total DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
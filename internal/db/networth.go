@@ -0,0 +1,65 @@
+package db
+
+import (
+	"errors"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetHoldings returns all the holdings of a user, oldest first
+func (db *DB) GetHoldings(tgID int64) ([]model.Holding, error) {
+	var holdings []model.Holding
+	err := db.conn.Where("tg_id = ?", tgID).Order("id").Find(&holdings).Error
+	if err != nil {
+		return nil, err
+	}
+	return holdings, nil
+}
+
+// GetHoldingByID returns a holding or model.ErrHoldingNotFound
+func (db *DB) GetHoldingByID(id int64) (model.Holding, error) {
+	var holding model.Holding
+	err := db.conn.First(&holding, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return holding, model.ErrHoldingNotFound
+	}
+	return holding, err
+}
+
+// CreateHolding inserts a single holding
+func (db *DB) CreateHolding(holding *model.Holding) error {
+	return db.conn.Create(holding).Error
+}
+
+// UpdateHolding saves a holding
+func (db *DB) UpdateHolding(holding *model.Holding) error {
+	return db.conn.Save(holding).Error
+}
+
+// DeleteHolding removes a holding along with its snapshots
+func (db *DB) DeleteHolding(id int64) error {
+	return db.conn.Delete(&model.Holding{}, id).Error
+}
+
+// GetHoldingSnapshots returns the snapshots of all the holdings of a user,
+// oldest first
+func (db *DB) GetHoldingSnapshots(tgID int64) ([]model.HoldingSnapshot, error) {
+	var snapshots []model.HoldingSnapshot
+	err := db.conn.Where("tg_id = ?", tgID).Order("date").Order("id").Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// SaveHoldingSnapshot inserts the balance of a holding on a day, replacing the
+// one already recorded that day
+func (db *DB) SaveHoldingSnapshot(snapshot *model.HoldingSnapshot) error {
+	return db.conn.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "holding_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"balance", "created_at"}),
+	}).Create(snapshot).Error
+}
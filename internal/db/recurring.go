@@ -0,0 +1,96 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetRecurringRules returns all the recurring rules of a user
+func (db *DB) GetRecurringRules(tgID int64) ([]model.RecurringRule, error) {
+	var rules []model.RecurringRule
+	err := db.conn.Where("tg_id = ?", tgID).Order("id").Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRecurringRuleByID returns a recurring rule or model.ErrRecurringRuleNotFound
+func (db *DB) GetRecurringRuleByID(id int64) (model.RecurringRule, error) {
+	var rule model.RecurringRule
+	err := db.conn.First(&rule, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rule, model.ErrRecurringRuleNotFound
+	}
+	return rule, err
+}
+
+// CreateRecurringRule inserts a single recurring rule
+func (db *DB) CreateRecurringRule(rule *model.RecurringRule) error {
+	return db.conn.Create(rule).Error
+}
+
+// UpdateRecurringRule saves a recurring rule
+func (db *DB) UpdateRecurringRule(rule *model.RecurringRule) error {
+	return db.conn.Save(rule).Error
+}
+
+// DeleteRecurringRule removes a recurring rule, the transactions it created are kept
+func (db *DB) DeleteRecurringRule(id int64) error {
+	return db.conn.Delete(&model.RecurringRule{}, id).Error
+}
+
+// GetDueRecurringRules returns the active rules of all users not processed up to the given day yet
+func (db *DB) GetDueRecurringRules(day time.Time) ([]model.RecurringRule, error) {
+	var rules []model.RecurringRule
+	err := db.conn.
+		Where("paused = ? AND start_date <= ?", false, day).
+		Where("last_run_on IS NULL OR last_run_on < ?", day).
+		Where("end_date IS NULL OR last_run_on IS NULL OR last_run_on < end_date").
+		Order("id").
+		Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// MaterializeRecurringRule inserts the transactions of the occurrences of a
+// rule and marks it as processed up to the given day, in a single database
+// transaction. Occurrences already stored are skipped thanks to the unique
+// (recurring_rule_id, date) index, only the newly created transactions are
+// returned.
+func (db *DB) MaterializeRecurringRule(rule *model.RecurringRule, transactions []model.Transaction, processedUntil time.Time) ([]model.Transaction, error) {
+	var created []model.Transaction
+	err := db.conn.Transaction(func(tx *gorm.DB) error {
+		for _, t := range transactions {
+			err := tx.Omit("Tags.*").Clauses(clause.OnConflict{DoNothing: true}).Create(&t).Error
+			if err != nil {
+				return fmt.Errorf("failed to create transaction: %w", err)
+			}
+			if t.ID != 0 {
+				created = append(created, t)
+			}
+		}
+
+		err := tx.Model(&model.RecurringRule{}).
+			Where("id = ?", rule.ID).
+			Update("last_run_on", processedUntil).Error
+		if err != nil {
+			return fmt.Errorf("failed to update recurring rule: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rule.LastRunOn = &processedUntil
+	return created, nil
+}
@@ -66,32 +66,26 @@ func (db *DB) UpdateReminderStatusTransaction(reminderID int64, status model.Rem
 
 // CreateOrUpdateWeeklyReminder creates or updates a weekly reminder for a user
 func (db *DB) CreateOrUpdateWeeklyReminder(tgID int64, scheduledFor time.Time) error {
-	reminder := model.Reminder{
-		TgID:         tgID,
-		Type:         model.ReminderTypeWeeklyRecap,
-		Status:       model.ReminderStatusPending,
-		ScheduledFor: scheduledFor,
-	}
-
-	// Use ON CONFLICT to update if exists
-	result := db.conn.Exec(`
-		INSERT INTO reminders (tg_id, type, status, scheduled_for, created_at, updated_at)
-		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-		ON CONFLICT (tg_id, type, scheduled_for) 
-		DO UPDATE SET 
-			status = EXCLUDED.status,
-			updated_at = CURRENT_TIMESTAMP
-		WHERE reminders.status != ?
-	`, reminder.TgID, reminder.Type, reminder.Status, reminder.ScheduledFor, model.ReminderStatusSent)
-
-	return result.Error
+	return db.createOrUpdateReminder(tgID, model.ReminderTypeWeeklyRecap, scheduledFor)
 }
 
 // CreateOrUpdateMonthlyReminder creates or updates a monthly reminder for a user
 func (db *DB) CreateOrUpdateMonthlyReminder(tgID int64, scheduledFor time.Time) error {
+	return db.createOrUpdateReminder(tgID, model.ReminderTypeMonthlyRecap, scheduledFor)
+}
+
+// CreateOrUpdateBalanceCheckInReminder creates or updates a balance check-in
+// reminder for a user
+func (db *DB) CreateOrUpdateBalanceCheckInReminder(tgID int64, scheduledFor time.Time) error {
+	return db.createOrUpdateReminder(tgID, model.ReminderTypeBalanceCheckIn, scheduledFor)
+}
+
+// createOrUpdateReminder creates a pending reminder of a user, or sets it back
+// to pending unless it was already sent
+func (db *DB) createOrUpdateReminder(tgID int64, reminderType model.ReminderType, scheduledFor time.Time) error {
 	reminder := model.Reminder{
 		TgID:         tgID,
-		Type:         model.ReminderTypeMonthlyRecap,
+		Type:         reminderType,
 		Status:       model.ReminderStatusPending,
 		ScheduledFor: scheduledFor,
 	}
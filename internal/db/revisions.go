@@ -0,0 +1,23 @@
+package db
+
+import (
+	"cashout/internal/model"
+)
+
+// CreateTransactionRevisions appends revisions to the history of the transactions
+func (db *DB) CreateTransactionRevisions(revisions ...model.TransactionRevision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+	return db.conn.Create(&revisions).Error
+}
+
+// GetTransactionRevisions returns the history of a transaction, oldest first
+func (db *DB) GetTransactionRevisions(transactionID int64) ([]model.TransactionRevision, error) {
+	var revisions []model.TransactionRevision
+	err := db.conn.Where("transaction_id = ?", transactionID).Order("id").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
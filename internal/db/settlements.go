@@ -0,0 +1,104 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// CreateSharedExpense inserts a shared expense along with its shares. A new
+// expense transaction, if given, is inserted first and all or none of them
+// are stored.
+func (db *DB) CreateSharedExpense(expense *model.SharedExpense, transaction *model.Transaction) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		if transaction != nil {
+			if err := tx.Omit("Tags.*").Create(transaction).Error; err != nil {
+				return fmt.Errorf("failed to create transaction: %w", err)
+			}
+			expense.TransactionID = transaction.ID
+		}
+		if err := tx.Create(expense).Error; err != nil {
+			return fmt.Errorf("failed to create shared expense: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetSharedExpenseByTransaction returns the shared expense of a transaction
+// with its shares or model.ErrSharedExpenseNotFound
+func (db *DB) GetSharedExpenseByTransaction(transactionID int64) (model.SharedExpense, error) {
+	var expense model.SharedExpense
+	err := db.conn.Preload("Shares").Where("transaction_id = ?", transactionID).First(&expense).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return expense, model.ErrSharedExpenseNotFound
+	}
+	return expense, err
+}
+
+// debtsQuery returns the debts of the shared expenses and the settlements,
+// the participants owe the payers and the settlements cancel out what the
+// payer owed the payee. The expenses in the trash are left out.
+const debtsQuery = `
+	SELECT s.tg_id AS from_tg_id, e.paid_by AS to_tg_id, s.amount
+	FROM expense_shares s
+	JOIN shared_expenses e ON e.id = s.shared_expense_id
+	JOIN transactions ON transactions.id = e.transaction_id
+	WHERE s.tg_id <> e.paid_by AND ` + notTrashed + ` AND %[1]s
+	UNION ALL
+	SELECT to_tg_id AS from_tg_id, from_tg_id AS to_tg_id, amount
+	FROM settlements
+	WHERE %[2]s`
+
+// GetUserDebts returns the debts between a user and their counterparts
+func (db *DB) GetUserDebts(tgID int64) ([]model.Debt, error) {
+	var debts []model.Debt
+	query := fmt.Sprintf(debtsQuery, "(s.tg_id = @id OR e.paid_by = @id)", "(from_tg_id = @id OR to_tg_id = @id)")
+	if err := db.conn.Raw(query, map[string]any{"id": tgID}).Scan(&debts).Error; err != nil {
+		return nil, err
+	}
+	return debts, nil
+}
+
+// GetGroupDebts returns the debts among a group of users, leaving out the
+// ones with users outside the group
+func (db *DB) GetGroupDebts(tgIDs []int64) ([]model.Debt, error) {
+	var debts []model.Debt
+	query := fmt.Sprintf(debtsQuery, "s.tg_id IN @ids AND e.paid_by IN @ids", "from_tg_id IN @ids AND to_tg_id IN @ids")
+	if err := db.conn.Raw(query, map[string]any{"ids": tgIDs}).Scan(&debts).Error; err != nil {
+		return nil, err
+	}
+	return debts, nil
+}
+
+// GetUsersByTgIDs returns the users with the given Telegram IDs
+func (db *DB) GetUsersByTgIDs(tgIDs []int64) ([]model.User, error) {
+	var users []model.User
+	if len(tgIDs) == 0 {
+		return users, nil
+	}
+	if err := db.conn.Where("tg_id IN ?", tgIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CreateSettlement inserts a settlement along with the transactions
+// recording it, all or none of them
+func (db *DB) CreateSettlement(settlement *model.Settlement, transactions ...*model.Transaction) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(settlement).Error; err != nil {
+			return fmt.Errorf("failed to create settlement: %w", err)
+		}
+
+		for _, t := range transactions {
+			t.SettlementID = &settlement.ID
+			if err := tx.Omit("Tags.*").Create(t).Error; err != nil {
+				return fmt.Errorf("failed to create settlement transaction: %w", err)
+			}
+		}
+		return nil
+	})
+}
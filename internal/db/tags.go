@@ -0,0 +1,61 @@
+package db
+
+import (
+	"fmt"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetTags returns all the tags of a user ordered by name
+func (db *DB) GetTags(tgID int64) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := db.conn.Where("tg_id = ?", tgID).Order("name").Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetOrCreateTags returns the tags of a user with the given (normalized)
+// names, creating the missing ones. Tags are returned in the order of names.
+func (db *DB) GetOrCreateTags(tgID int64, names []string) ([]model.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var stored []model.Tag
+	err := db.conn.Transaction(func(tx *gorm.DB) error {
+		tags := model.TagsFromNames(tgID, names)
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return fmt.Errorf("failed to create tags: %w", err)
+		}
+		return tx.Where("tg_id = ? AND name IN ?", tgID, names).Find(&stored).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]model.Tag, len(stored))
+	for _, t := range stored {
+		byName[t.Name] = t
+	}
+	tags := make([]model.Tag, 0, len(names))
+	for _, name := range names {
+		if t, ok := byName[name]; ok {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
+// SetTransactionTags replaces the tags of a stored transaction, the tags must already exist
+func (db *DB) SetTransactionTags(transaction *model.Transaction, tags []model.Tag) error {
+	association := db.conn.Model(transaction).Omit("Tags.*").Association("Tags")
+	if len(tags) == 0 {
+		return association.Clear()
+	}
+	return association.Replace(tags)
+}
@@ -4,26 +4,83 @@ import (
 	"cashout/internal/model"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// CreateTransaction creates a new transaction record
+// CreateTransaction creates a new transaction record.
+// When no original value is set, the amount is assumed to be entered in the stored currency.
 func (db *DB) CreateTransaction(transaction *model.Transaction) error {
-	return db.conn.Create(transaction).Error
+	if transaction.OriginalCurrency == "" {
+		transaction.OriginalAmount = transaction.Amount
+		transaction.OriginalCurrency = transaction.Currency
+	}
+	// Tags are linked but never created here, see GetOrCreateTags
+	return db.conn.Omit("Tags.*").Create(transaction).Error
 }
 
-// GetTransactionByID retrieves an transaction by its ID
+// notTrashed is the condition leaving out the transactions in the trash,
+// every query reading the transactions of the users must apply it
+const notTrashed = "transactions.deleted_at IS NULL"
+
+// GetTransactionByID retrieves an transaction by its ID, with its tags and split lines.
+// Transactions in the trash are not found.
 func (db *DB) GetTransactionByID(id int64) (*model.Transaction, error) {
 	var transaction model.Transaction
-	result := db.conn.Where("id = ?", id).First(&transaction)
+	result := db.conn.Preload("Tags").Preload("Splits", orderSplits).Where("id = ?", id).Where(notTrashed).First(&transaction)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &transaction, nil
 }
 
-// UpdateTransaction updates an existing transaction
+// GetCategoryTransactions returns the transactions of a user in a category,
+// including the split ones having a line in it and the ones in the trash
+func (db *DB) GetCategoryTransactions(tgID int64, category string) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+	result := db.conn.Preload("Tags").Preload("Splits", orderSplits).
+		Where("tg_id = ?", tgID).
+		Where(transactionInCategory, category, category).
+		Order("id").
+		Find(&transactions)
+	return transactions, result.Error
+}
+
+// UpdateTransaction updates an existing transaction, its tags are changed with
+// SetTransactionTags and its split lines with UpdateTransactionSplits
 func (db *DB) UpdateTransaction(transaction *model.Transaction) error {
-	return db.conn.Save(transaction).Error
+	return db.conn.Omit("Tags", "Splits").Save(transaction).Error
+}
+
+// UpdateTransactionSplits updates an existing transaction and replaces its
+// split lines in the same database transaction
+func (db *DB) UpdateTransactionSplits(transaction *model.Transaction) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags", "Splits").Save(transaction).Error; err != nil {
+			return fmt.Errorf("failed to save transaction: %w", err)
+		}
+
+		if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&model.TransactionSplit{}).Error; err != nil {
+			return fmt.Errorf("failed to delete split lines: %w", err)
+		}
+		if len(transaction.Splits) == 0 {
+			return nil
+		}
+
+		for i := range transaction.Splits {
+			transaction.Splits[i].ID = 0
+			transaction.Splits[i].TransactionID = transaction.ID
+		}
+		if err := tx.Create(&transaction.Splits).Error; err != nil {
+			return fmt.Errorf("failed to create split lines: %w", err)
+		}
+		return nil
+	})
+}
+
+// orderSplits keeps the split lines in the order they were entered
+func orderSplits(tx *gorm.DB) *gorm.DB {
+	return tx.Order("id")
 }
 
 // DeleteTransaction deletes an transaction by ID (kept for backward compatibility)
@@ -31,35 +88,99 @@ func (db *DB) DeleteTransaction(id int64) error {
 	return db.conn.Delete(&model.Transaction{}, id).Error
 }
 
-// DeleteTransactionByID deletes a transaction by its ID and optionally checks if it belongs to the given user
-// If tgID is 0, the ownership check is skipped
-func (db *DB) DeleteTransactionByID(id int64, tgID int64) error {
-	query := db.conn.Model(&model.Transaction{})
-
-	if tgID != 0 {
-		// Add ownership check if tgID is provided
-		query = query.Where("id = ? AND tg_id = ?", id, tgID)
-	} else {
-		query = query.Where("id = ?", id)
+// TrashTransaction moves a transaction of the user to the trash, it is left
+// out of every query until it is restored or purged
+func (db *DB) TrashTransaction(id int64, tgID int64) error {
+	result := db.conn.Model(&model.Transaction{}).
+		Where("id = ? AND tg_id = ?", id, tgID).
+		Where(notTrashed).
+		Update("deleted_at", time.Now())
+	if result.Error != nil {
+		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return model.ErrTransactionNotFound
+	}
+	return nil
+}
 
-	result := query.Delete(&model.Transaction{})
-
+// RestoreTransaction takes a transaction of the user out of the trash
+func (db *DB) RestoreTransaction(id int64, tgID int64) error {
+	result := db.conn.Model(&model.Transaction{}).
+		Where("id = ? AND tg_id = ? AND deleted_at IS NOT NULL", id, tgID).
+		Update("deleted_at", nil)
 	if result.Error != nil {
 		return result.Error
 	}
-
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("transaction not found or doesn't belong to user")
+		return model.ErrTransactionNotFound
 	}
-
 	return nil
 }
 
+// GetTrashedTransactions retrieves the transactions in the trash of a user
+// with pagination, the most recently deleted first
+func (db *DB) GetTrashedTransactions(tgID int64, offset, limit int) ([]model.Transaction, int64, error) {
+	var transactions []model.Transaction
+	var total int64
+
+	query := db.conn.Model(&model.Transaction{}).Where("tg_id = ? AND deleted_at IS NOT NULL", tgID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Tags").
+		Order("deleted_at DESC").
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
+
+// PurgeTrashedTransactions permanently deletes the transactions moved to the
+// trash before the given time, recording it in their history. It returns the
+// storage keys of their attachments, whose rows are deleted along with them.
+func (db *DB) PurgeTrashedTransactions(before time.Time) (int64, []string, error) {
+	var purged int64
+	var keys []string
+	err := db.conn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Attachment{}).
+			Joins("JOIN transactions ON transactions.id = attachments.transaction_id").
+			Where("transactions.deleted_at < ?", before).
+			Pluck("attachments.storage_key", &keys).Error
+		if err != nil {
+			return fmt.Errorf("failed to get attachments: %w", err)
+		}
+
+		err = tx.Exec(`
+			INSERT INTO transaction_revisions (transaction_id, tg_id, action, source)
+			SELECT id, tg_id, ?, ? FROM transactions WHERE deleted_at < ?`,
+			model.RevisionPurged, model.SourceScheduler, before).Error
+		if err != nil {
+			return fmt.Errorf("failed to create revisions: %w", err)
+		}
+
+		result := tx.Where("deleted_at < ?", before).Delete(&model.Transaction{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete transactions: %w", result.Error)
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return purged, keys, nil
+}
+
 // GetUserTransactions retrieves all transactions for a user
 func (db *DB) GetUserTransactions(tgID int64) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	result := db.conn.Where("tg_id = ?", tgID).Order("date DESC").Find(&transactions)
+	result := db.conn.Where("tg_id = ?", tgID).Where(notTrashed).Order("date DESC").Find(&transactions)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -68,9 +189,15 @@ func (db *DB) GetUserTransactions(tgID int64) ([]model.Transaction, error) {
 
 // GetUserTransactionsByDateRange retrieves transactions for a user within a date range
 func (db *DB) GetUserTransactionsByDateRange(tgID int64, startDate, endDate time.Time) ([]model.Transaction, error) {
+	return db.GetTransactionsByDateRange(model.Scope{TgID: tgID}, startDate, endDate)
+}
+
+// GetTransactionsByDateRange retrieves the transactions of a scope within a date range
+func (db *DB) GetTransactionsByDateRange(scope model.Scope, startDate, endDate time.Time) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	result := db.conn.Where("tg_id = ? AND date BETWEEN ? AND ?",
-		tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+	result := scoped(db.conn, scope).
+		Where("date BETWEEN ? AND ?", startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Where(notTrashed).
 		Order("date DESC").
 		Find(&transactions)
 
@@ -89,6 +216,7 @@ func (db *DB) GetUserTransactionsByDateRangePaginated(tgID int64, startDate, end
 	err := db.conn.Model(&model.Transaction{}).
 		Where("tg_id = ? AND date BETWEEN ? AND ?",
 			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Where(notTrashed).
 		Count(&total).Error
 	if err != nil {
 		return nil, 0, err
@@ -97,6 +225,7 @@ func (db *DB) GetUserTransactionsByDateRangePaginated(tgID int64, startDate, end
 	// Get paginated results
 	result := db.conn.Where("tg_id = ? AND date BETWEEN ? AND ?",
 		tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Where(notTrashed).
 		Order("date DESC").
 		Offset(offset).
 		Limit(limit).
@@ -117,17 +246,31 @@ func (db *DB) GetUserTransactionsByMonth(tgID int64, year int, month int) ([]mod
 	return db.GetUserTransactionsByDateRange(tgID, startDate, endDate)
 }
 
-// GetUserTransactionsByCategory retrieves transactions for a user grouped by category
-func (db *DB) GetUserTransactionsByCategory(tgID int64, startDate, endDate time.Time, transactionType model.TransactionType) (map[model.TransactionCategory]float64, error) {
+// categoryLines is the query of the amounts by category of the transactions
+// of a scope in a date range: one row for each line of the split transactions
+// and one for each of the others, with the id and the date of the transaction
+func (db *DB) categoryLines(scope model.Scope, startDate, endDate time.Time, transactionType model.TransactionType) *gorm.DB {
+	query := db.conn.Table("transactions").
+		Select(`transactions.id, transactions.date,
+			COALESCE(transaction_splits.category, transactions.category) AS category,
+			COALESCE(transaction_splits.amount, transactions.amount) AS amount`).
+		Joins("LEFT JOIN transaction_splits ON transaction_splits.transaction_id = transactions.id").
+		Where(notTrashed)
+	return scoped(query, scope).
+		Where("transactions.date BETWEEN ? AND ? AND transactions.type = ?",
+			startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), transactionType)
+}
+
+// GetTransactionsByCategory retrieves the transactions of a scope grouped by
+// category, split transactions are counted in the categories of their lines
+func (db *DB) GetTransactionsByCategory(scope model.Scope, startDate, endDate time.Time, transactionType model.TransactionType) (map[model.TransactionCategory]model.Money, error) {
 	var results []struct {
 		Category model.TransactionCategory
-		Total    float64
+		Total    model.Money
 	}
 
-	query := db.conn.Table("transactions").
+	query := db.conn.Table("(?) AS lines", db.categoryLines(scope, startDate, endDate, transactionType)).
 		Select("category, SUM(amount) as total").
-		Where("tg_id = ? AND date BETWEEN ? AND ? AND type = ?",
-			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), transactionType).
 		Group("category").
 		Order("total DESC")
 
@@ -136,7 +279,7 @@ func (db *DB) GetUserTransactionsByCategory(tgID int64, startDate, endDate time.
 	}
 
 	// Convert to map
-	categoryTotals := make(map[model.TransactionCategory]float64)
+	categoryTotals := make(map[model.TransactionCategory]model.Money)
 	for _, result := range results {
 		categoryTotals[result.Category] = result.Total
 	}
@@ -145,15 +288,16 @@ func (db *DB) GetUserTransactionsByCategory(tgID int64, startDate, endDate time.
 }
 
 // GetUserBalance calculates the total balance (income - transactions) for a user
-func (db *DB) GetUserBalance(tgID int64, startDate, endDate time.Time) (float64, error) {
-	var income float64
-	var transaction float64
+func (db *DB) GetUserBalance(tgID int64, startDate, endDate time.Time) (model.Money, error) {
+	var income model.Money
+	var transaction model.Money
 
 	// Get total income
 	incomeQuery := db.conn.Table("transactions").
 		Select("COALESCE(SUM(amount), 0) as total").
 		Where("tg_id = ? AND date BETWEEN ? AND ? AND type = ?",
-			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), model.TypeIncome)
+			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), model.TypeIncome).
+		Where(notTrashed)
 
 	if err := incomeQuery.Scan(&income).Error; err != nil {
 		return 0, err
@@ -163,7 +307,8 @@ func (db *DB) GetUserBalance(tgID int64, startDate, endDate time.Time) (float64,
 	transactionQuery := db.conn.Table("transactions").
 		Select("COALESCE(SUM(amount), 0) as total").
 		Where("tg_id = ? AND date BETWEEN ? AND ? AND type = ?",
-			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), model.TypeExpense)
+			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), model.TypeExpense).
+		Where(notTrashed)
 
 	if err := transactionQuery.Scan(&transaction).Error; err != nil {
 		return 0, err
@@ -172,17 +317,18 @@ func (db *DB) GetUserBalance(tgID int64, startDate, endDate time.Time) (float64,
 	return income - transaction, nil
 }
 
-// GetMonthlyTotalsInYear gets monthly totals for a specific year
-func (db *DB) GetMonthlyTotalsInYear(tgID int64, year int) (map[int]map[model.TransactionType]float64, error) {
+// GetMonthlyTotalsInYear gets the monthly totals of a scope for a specific year
+func (db *DB) GetMonthlyTotalsInYear(scope model.Scope, year int) (map[int]map[model.TransactionType]model.Money, error) {
 	var results []struct {
 		Month int
 		Type  model.TransactionType
-		Total float64
+		Total model.Money
 	}
 
-	query := db.conn.Table("transactions").
+	query := scoped(db.conn.Table("transactions"), scope).
 		Select("EXTRACT(MONTH FROM date) as month, type, SUM(amount) as total").
-		Where("tg_id = ? AND EXTRACT(YEAR FROM date) = ?", tgID, year).
+		Where("EXTRACT(YEAR FROM date) = ?", year).
+		Where(notTrashed).
 		Group("month, type").
 		Order("month")
 
@@ -191,11 +337,11 @@ func (db *DB) GetMonthlyTotalsInYear(tgID int64, year int) (map[int]map[model.Tr
 	}
 
 	// Convert to map of maps: month -> type -> amount
-	monthlyTotals := make(map[int]map[model.TransactionType]float64)
+	monthlyTotals := make(map[int]map[model.TransactionType]model.Money)
 
 	for _, result := range results {
 		if _, exists := monthlyTotals[result.Month]; !exists {
-			monthlyTotals[result.Month] = make(map[model.TransactionType]float64)
+			monthlyTotals[result.Month] = make(map[model.TransactionType]model.Money)
 		}
 		monthlyTotals[result.Month][result.Type] = result.Total
 	}
@@ -214,10 +360,11 @@ func (db *DB) GetUserTransactionsByMonthPaginated(tgID int64, year int, month in
 
 	query := db.conn.Model(&model.Transaction{}).
 		Where("tg_id = ? AND date BETWEEN ? AND ?",
-			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+			tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Where(notTrashed)
 
 	if category != "" {
-		query = query.Where("category = ?", category)
+		query = query.Where(transactionInCategory, category, category)
 	}
 
 	// Get total count
@@ -228,10 +375,11 @@ func (db *DB) GetUserTransactionsByMonthPaginated(tgID int64, year int, month in
 
 	// Get paginated results
 	result := db.conn.Where("tg_id = ? AND date BETWEEN ? AND ?",
-		tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+		tgID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).
+		Where(notTrashed)
 
 	if category != "" {
-		result = result.Where("category = ?", category)
+		result = result.Where(transactionInCategory, category, category)
 	}
 
 	result = result.
@@ -255,6 +403,7 @@ func (db *DB) GetUserTransactionsPaginated(tgID int64, offset, limit int) ([]mod
 	// Get total count
 	err := db.conn.Model(&model.Transaction{}).
 		Where("tg_id = ?", tgID).
+		Where(notTrashed).
 		Count(&total).Error
 	if err != nil {
 		return nil, 0, err
@@ -262,6 +411,7 @@ func (db *DB) GetUserTransactionsPaginated(tgID int64, offset, limit int) ([]mod
 
 	// Get paginated results
 	result := db.conn.Where("tg_id = ?", tgID).
+		Where(notTrashed).
 		Order("date DESC").
 		Offset(offset).
 		Limit(limit).
@@ -282,6 +432,7 @@ func (db *DB) GetUserTransactionsByTypePaginated(tgID int64, transactionType mod
 	// Get total count
 	err := db.conn.Model(&model.Transaction{}).
 		Where("tg_id = ? AND type = ?", tgID, transactionType).
+		Where(notTrashed).
 		Count(&total).Error
 	if err != nil {
 		return nil, 0, err
@@ -289,6 +440,7 @@ func (db *DB) GetUserTransactionsByTypePaginated(tgID int64, transactionType mod
 
 	// Get paginated results
 	result := db.conn.Where("tg_id = ? AND type = ?", tgID, transactionType).
+		Where(notTrashed).
 		Order("date DESC").
 		Offset(offset).
 		Limit(limit).
@@ -304,15 +456,32 @@ func (db *DB) GetUserTransactionsByTypePaginated(tgID int64, transactionType mod
 // TransactionFilter bundles the optional filters supported by
 // SearchUserTransactionsFiltered. Nil/zero fields are ignored.
 type TransactionFilter struct {
-	Query     string                // case-insensitive substring on description
-	Category  string                // "" or "all" disables the category filter
-	Type      model.TransactionType // "" disables the type filter
-	DateFrom  *time.Time            // inclusive lower bound
-	DateTo    *time.Time            // inclusive upper bound
-	AmountMin *float64              // inclusive lower bound
-	AmountMax *float64              // inclusive upper bound
+	Query       string                // case-insensitive substring on description
+	Category    string                // "" or "all" disables the category filter
+	Type        model.TransactionType // "" disables the type filter
+	DateFrom    *time.Time            // inclusive lower bound
+	DateTo      *time.Time            // inclusive upper bound
+	AmountMin   *model.Money          // inclusive lower bound
+	AmountMax   *model.Money          // inclusive upper bound
+	Tags        []string              // transactions having all of these tags
+	ExcludeTags []string              // transactions having none of these tags
+	AccountID   *int64                // transactions from or to this account
+	LedgerID    *int64                // the transactions of this shared ledger instead of the user's
 }
 
+// transactionInCategory is the condition matching the transactions of the
+// given category, including the split ones having a line in it
+const transactionInCategory = `(category = ? OR EXISTS (
+	SELECT 1 FROM transaction_splits ts
+	WHERE ts.transaction_id = transactions.id AND ts.category = ?
+))`
+
+// transactionTagExists is the condition matching the transactions having a tag with the given name(s)
+const transactionTagExists = `EXISTS (
+	SELECT 1 FROM transaction_tags tt JOIN tags t ON t.id = tt.tag_id
+	WHERE tt.transaction_id = transactions.id AND t.name IN ?
+)`
+
 // SearchUserTransactionsFiltered runs the full set of optional filters used by
 // the /api/transactions/search and /api/transactions/export endpoints.
 // A limit <= 0 disables the LIMIT clause (used for export).
@@ -320,13 +489,13 @@ func (db *DB) SearchUserTransactionsFiltered(tgID int64, f TransactionFilter, of
 	var transactions []model.Transaction
 	var total int64
 
-	q := db.conn.Model(&model.Transaction{}).Where("tg_id = ?", tgID)
+	q := scoped(db.conn.Model(&model.Transaction{}), model.Scope{TgID: tgID, LedgerID: f.LedgerID}).Where(notTrashed)
 
 	if f.Query != "" {
 		q = q.Where("LOWER(description) LIKE LOWER(?)", "%"+f.Query+"%")
 	}
 	if f.Category != "" && f.Category != "all" {
-		q = q.Where("category = ?", f.Category)
+		q = q.Where(transactionInCategory, f.Category, f.Category)
 	}
 	if f.Type != "" {
 		q = q.Where("type = ?", f.Type)
@@ -343,12 +512,21 @@ func (db *DB) SearchUserTransactionsFiltered(tgID int64, f TransactionFilter, of
 	if f.AmountMax != nil {
 		q = q.Where("amount <= ?", *f.AmountMax)
 	}
+	for _, tag := range f.Tags {
+		q = q.Where(transactionTagExists, []string{tag})
+	}
+	if len(f.ExcludeTags) > 0 {
+		q = q.Where("NOT "+transactionTagExists, f.ExcludeTags)
+	}
+	if f.AccountID != nil {
+		q = q.Where("(account_id = ? OR to_account_id = ?)", *f.AccountID, *f.AccountID)
+	}
 
 	if err := q.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	result := q.Order("date DESC").Order("id DESC").Offset(offset)
+	result := q.Preload("Tags").Preload("Splits", orderSplits).Order("date DESC").Order("id DESC").Offset(offset)
 	if limit > 0 {
 		result = result.Limit(limit)
 	}
@@ -358,38 +536,14 @@ func (db *DB) SearchUserTransactionsFiltered(tgID int64, f TransactionFilter, of
 	return transactions, total, nil
 }
 
-// SearchUserTransactions searches transactions by description with optional category filter
+// SearchUserTransactions searches transactions by description with optional category filter.
+// The hashtags in the search query filter by tag, e.g. "taxi #work -#reimbursed".
 func (db *DB) SearchUserTransactions(tgID int64, searchQuery string, category string, offset, limit int) ([]model.Transaction, int64, error) {
-	var transactions []model.Transaction
-	var total int64
-
-	// Build base query
-	baseQuery := db.conn.Model(&model.Transaction{}).Where("tg_id = ?", tgID)
-
-	// Add search condition (case-insensitive)
-	baseQuery = baseQuery.Where("LOWER(description) LIKE LOWER(?)", "%"+searchQuery+"%")
-
-	// Add category filter if not "all"
-	if category != "" && category != "all" {
-		baseQuery = baseQuery.Where("category = ?", category)
-	}
-
-	// Get total count
-	err := baseQuery.Count(&total).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated results
-	result := baseQuery.
-		Order("date DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&transactions)
-
-	if result.Error != nil {
-		return nil, 0, result.Error
-	}
-
-	return transactions, total, nil
+	query, include, exclude := model.ParseTagQuery(searchQuery)
+	return db.SearchUserTransactionsFiltered(tgID, TransactionFilter{
+		Query:       query,
+		Category:    category,
+		Tags:        include,
+		ExcludeTags: exclude,
+	}, offset, limit)
 }
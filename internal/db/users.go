@@ -1,6 +1,13 @@
 package db
 
-import "cashout/internal/model"
+import (
+	"encoding/json"
+	"time"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
 
 // GetUser retrieves a user by their Telegram ID
 func (db *DB) GetUser(tgID int64) (*model.User, error) {
@@ -38,3 +45,54 @@ func (db *DB) SetUser(user *model.User) error {
 	result := db.conn.Save(user)
 	return result.Error
 }
+
+// SwapUserSession replaces the session of the user only if it is still the
+// given one, telling whether it did. Of two concurrent swaps from the same
+// session only the first succeeds.
+func (db *DB) SwapUserSession(tgID int64, from, to model.UserSession) (bool, error) {
+	current, err := json.Marshal(from)
+	if err != nil {
+		return false, err
+	}
+	result := db.conn.Model(&model.User{}).
+		Where("tg_id = ? AND session = CAST(? AS jsonb)", tgID, string(current)).
+		Update("session", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SetUserBudgetAlertEmail sets whether the budget alerts of the user are emailed too
+func (db *DB) SetUserBudgetAlertEmail(tgID int64, enabled bool) error {
+	return db.conn.Model(&model.User{}).
+		Where("tg_id = ?", tgID).
+		Update("budget_alert_email", enabled).Error
+}
+
+// SetUserTimezone changes the timezone of the user and moves their recaps
+// scheduled after now to the given times
+func (db *DB) SetUserTimezone(tgID int64, timezone string, now, weekly, monthly time.Time) error {
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).
+			Where("tg_id = ?", tgID).
+			Update("timezone", timezone).Error
+		if err != nil {
+			return err
+		}
+
+		// The recaps already due are sent as scheduled
+		err = tx.Where("tg_id = ? AND status = ? AND scheduled_for > ?", tgID, model.ReminderStatusPending, now).
+			Where("type IN ?", []model.ReminderType{model.ReminderTypeWeeklyRecap, model.ReminderTypeMonthlyRecap}).
+			Delete(&model.Reminder{}).Error
+		if err != nil {
+			return err
+		}
+
+		t := &DB{conn: tx}
+		if err := t.CreateOrUpdateWeeklyReminder(tgID, weekly); err != nil {
+			return err
+		}
+		return t.CreateOrUpdateMonthlyReminder(tgID, monthly)
+	})
+}
@@ -0,0 +1,47 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("013", "Add multi-currency support and exchange_rates table", addMultiCurrency, rollbackMultiCurrency)
+}
+
+func addMultiCurrency(tx *gorm.DB) error {
+	return tx.Exec(`
+		-- Per-user base currency, every aggregate is expressed in it
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS base_currency currency_type NOT NULL DEFAULT 'EUR';
+
+		-- Amount and currency as entered by the user, amount/currency hold the base currency value
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_amount DECIMAL(15, 2);
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_currency currency_type;
+
+		UPDATE transactions SET original_amount = amount, original_currency = currency WHERE original_amount IS NULL;
+
+		ALTER TABLE transactions ALTER COLUMN original_amount SET NOT NULL;
+		ALTER TABLE transactions ALTER COLUMN original_currency SET NOT NULL;
+		ALTER TABLE transactions ALTER COLUMN original_currency SET DEFAULT 'EUR';
+
+		-- 1 unit of base = rate units of quote
+		CREATE TABLE IF NOT EXISTS exchange_rates (
+			id          BIGSERIAL PRIMARY KEY,
+			base        currency_type NOT NULL,
+			quote       currency_type NOT NULL,
+			rate        DECIMAL(18, 8) NOT NULL CHECK (rate > 0),
+			updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_exchange_rate_pair UNIQUE (base, quote)
+		);
+	`).Error
+}
+
+func rollbackMultiCurrency(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TABLE IF EXISTS exchange_rates;
+		ALTER TABLE transactions DROP COLUMN IF EXISTS original_currency;
+		ALTER TABLE transactions DROP COLUMN IF EXISTS original_amount;
+		ALTER TABLE users DROP COLUMN IF EXISTS base_currency;
+	`).Error
+}
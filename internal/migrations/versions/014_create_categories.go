@@ -0,0 +1,78 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("014", "Create user-defined categories replacing the transaction_category enum", createCategories, rollbackCategories)
+}
+
+func createCategories(tx *gorm.DB) error {
+	return tx.Exec(`
+		CREATE TABLE IF NOT EXISTS categories (
+			id          BIGSERIAL PRIMARY KEY,
+			tg_id       BIGINT NOT NULL,
+			name        VARCHAR(32) NOT NULL,
+			type        transaction_type NOT NULL,
+			emoji       VARCHAR(16) NOT NULL DEFAULT '📌',
+			color       CHAR(7) NOT NULL DEFAULT '#9e9e9e',
+			archived    BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_user_category_name UNIQUE (tg_id, name),
+			CONSTRAINT fk_categories_tg_id FOREIGN KEY (tg_id) REFERENCES users (tg_id)
+		);
+
+		-- Seed the former enum values for every existing user, new users get them on first use
+		INSERT INTO categories (tg_id, name, type, emoji, color)
+		SELECT u.tg_id, d.name, d.type::transaction_type, d.emoji, d.color
+		FROM users u
+		CROSS JOIN (VALUES
+			('Salary', 'Income', '💵', '#59a14f'),
+			('OtherIncomes', 'Income', '💵', '#8cd17d'),
+			('Car', 'Expense', '🚗', '#4e79a7'),
+			('Clothes', 'Expense', '👕', '#f28e2b'),
+			('Grocery', 'Expense', '🛒', '#e15759'),
+			('House', 'Expense', '🏠', '#76b7b2'),
+			('Bills', 'Expense', '📄', '#edc949'),
+			('Entertainment', 'Expense', '🎭', '#af7aa1'),
+			('Sport', 'Expense', '🏋️', '#ff9da7'),
+			('EatingOut', 'Expense', '🍽️', '#9c755f'),
+			('Transport', 'Expense', '🚆', '#bab0ab'),
+			('Learning', 'Expense', '📚', '#b6992d'),
+			('Toiletry', 'Expense', '🚿', '#d37295'),
+			('Health', 'Expense', '🏥', '#499894'),
+			('Tech', 'Expense', '💻', '#a0cbe8'),
+			('Gifts', 'Expense', '🎁', '#fabfd2'),
+			('Travel', 'Expense', '✈️', '#86bcb6'),
+			('Pets', 'Expense', '🐈', '#e19d9a'),
+			('OtherExpenses', 'Expense', '📌', '#79706e')
+		) AS d (name, type, emoji, color)
+		ON CONFLICT (tg_id, name) DO NOTHING;
+
+		-- Transactions keep referencing the category by name
+		ALTER TABLE transactions ALTER COLUMN category TYPE VARCHAR(32) USING category::text;
+
+		DROP TYPE IF EXISTS transaction_category;
+	`).Error
+}
+
+func rollbackCategories(tx *gorm.DB) error {
+	return tx.Exec(`
+		CREATE TYPE transaction_category AS ENUM (
+			'Salary', 'OtherIncomes', 'Car', 'Clothes', 'Grocery', 'House', 'Bills', 'Entertainment', 'Sport',
+			'EatingOut', 'Transport', 'Learning', 'Toiletry', 'Health', 'Tech', 'Gifts', 'Travel', 'Pets', 'OtherExpenses'
+		);
+
+		-- Custom categories do not exist in the enum, move them to the generic ones
+		UPDATE transactions SET category = CASE WHEN type = 'Income' THEN 'OtherIncomes' ELSE 'OtherExpenses' END
+		WHERE category NOT IN (SELECT unnest(enum_range(NULL::transaction_category))::text);
+
+		ALTER TABLE transactions ALTER COLUMN category TYPE transaction_category USING category::transaction_category;
+
+		DROP TABLE IF EXISTS categories;
+	`).Error
+}
@@ -0,0 +1,40 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("015", "Create tags and transaction_tags tables", createTags, rollbackTags)
+}
+
+func createTags(tx *gorm.DB) error {
+	return tx.Exec(`
+		CREATE TABLE IF NOT EXISTS tags (
+			id          BIGSERIAL PRIMARY KEY,
+			tg_id       BIGINT NOT NULL,
+			name        VARCHAR(32) NOT NULL,
+			created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_user_tag_name UNIQUE (tg_id, name),
+			CONSTRAINT fk_tags_tg_id FOREIGN KEY (tg_id) REFERENCES users (tg_id)
+		);
+
+		-- Many-to-many between transactions and tags
+		CREATE TABLE IF NOT EXISTS transaction_tags (
+			transaction_id  BIGINT NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+			tag_id          BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+			PRIMARY KEY (transaction_id, tag_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_transaction_tags_tag_id ON transaction_tags (tag_id);
+	`).Error
+}
+
+func rollbackTags(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TABLE IF EXISTS transaction_tags;
+		DROP TABLE IF EXISTS tags;
+	`).Error
+}
@@ -0,0 +1,74 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("016", "Create accounts and add the Transfer transaction type", createAccounts, rollbackAccounts)
+}
+
+func createAccounts(tx *gorm.DB) error {
+	db, err := tx.DB()
+	if err != nil {
+		return err
+	}
+
+	// Adding an enum value cannot be used in the same transaction, run it on its own
+	if _, err := db.Exec(`ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'Transfer';`); err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		DROP TYPE IF EXISTS account_kind;
+		CREATE TYPE account_kind AS ENUM ('Cash', 'Checking', 'Card', 'Savings', 'Other');
+
+		CREATE TABLE IF NOT EXISTS accounts (
+			id               BIGSERIAL PRIMARY KEY,
+			tg_id            BIGINT NOT NULL,
+			name             VARCHAR(32) NOT NULL,
+			kind             account_kind NOT NULL DEFAULT 'Other',
+			currency         currency_type NOT NULL DEFAULT 'EUR',
+			opening_balance  DECIMAL(15, 2) NOT NULL DEFAULT 0,
+			archived         BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_user_account_name UNIQUE (tg_id, name),
+			CONSTRAINT fk_accounts_tg_id FOREIGN KEY (tg_id) REFERENCES users (tg_id)
+		);
+
+		-- Accounts used by transactions cannot be deleted, only archived
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS account_id BIGINT REFERENCES accounts (id);
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS to_account_id BIGINT REFERENCES accounts (id);
+
+		CREATE INDEX IF NOT EXISTS idx_transactions_account_id ON transactions (account_id);
+		CREATE INDEX IF NOT EXISTS idx_transactions_to_account_id ON transactions (to_account_id);
+
+		-- Transfers always move money between two different accounts, the others have no destination
+		ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_transfer_accounts;
+		ALTER TABLE transactions ADD CONSTRAINT chk_transactions_transfer_accounts CHECK (
+			(type = 'Transfer' AND account_id IS NOT NULL AND to_account_id IS NOT NULL AND account_id <> to_account_id)
+			OR (type <> 'Transfer' AND to_account_id IS NULL)
+		);
+
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS default_account_id BIGINT REFERENCES accounts (id) ON DELETE SET NULL;
+	`).Error
+}
+
+func rollbackAccounts(tx *gorm.DB) error {
+	// Enum values cannot be dropped, transfers are removed and 'Transfer' stays unused
+	return tx.Exec(`
+		ALTER TABLE users DROP COLUMN IF EXISTS default_account_id;
+
+		DELETE FROM transactions WHERE type = 'Transfer';
+
+		ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_transfer_accounts;
+		ALTER TABLE transactions DROP COLUMN IF EXISTS to_account_id;
+		ALTER TABLE transactions DROP COLUMN IF EXISTS account_id;
+
+		DROP TABLE IF EXISTS accounts;
+		DROP TYPE IF EXISTS account_kind;
+	`).Error
+}
@@ -0,0 +1,56 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("017", "Create recurring_rules table", createRecurringRules, rollbackRecurringRules)
+}
+
+func createRecurringRules(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TYPE IF EXISTS recurring_frequency;
+		CREATE TYPE recurring_frequency AS ENUM ('Daily', 'Weekly', 'Monthly', 'Yearly');
+
+		CREATE TABLE IF NOT EXISTS recurring_rules (
+			id            BIGSERIAL PRIMARY KEY,
+			tg_id         BIGINT NOT NULL,
+			type          transaction_type NOT NULL,
+			category      VARCHAR(32) NOT NULL,
+			amount        DECIMAL(15, 2) NOT NULL,
+			currency      currency_type NOT NULL DEFAULT 'EUR',
+			description   TEXT,
+			account_id    BIGINT REFERENCES accounts (id) ON DELETE SET NULL,
+			frequency     recurring_frequency NOT NULL,
+			day_of_month  INTEGER NOT NULL DEFAULT 0,
+			start_date    DATE NOT NULL,
+			end_date      DATE,
+			last_run_on   DATE,
+			paused        BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT chk_recurring_rules_type CHECK (type <> 'Transfer'),
+			CONSTRAINT fk_recurring_rules_tg_id FOREIGN KEY (tg_id) REFERENCES users (tg_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_recurring_rules_tg_id ON recurring_rules (tg_id);
+
+		-- A rule creates at most one transaction per occurrence, whatever the scheduler retries
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS recurring_rule_id BIGINT REFERENCES recurring_rules (id) ON DELETE SET NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_recurring_occurrence ON transactions (recurring_rule_id, date)
+			WHERE recurring_rule_id IS NOT NULL;
+	`).Error
+}
+
+func rollbackRecurringRules(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP INDEX IF EXISTS idx_transactions_recurring_occurrence;
+		ALTER TABLE transactions DROP COLUMN IF EXISTS recurring_rule_id;
+
+		DROP TABLE IF EXISTS recurring_rules;
+		DROP TYPE IF EXISTS recurring_frequency;
+	`).Error
+}
@@ -0,0 +1,34 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("018", "Create transaction_splits table", createTransactionSplits, rollbackTransactionSplits)
+}
+
+func createTransactionSplits(tx *gorm.DB) error {
+	return tx.Exec(`
+		CREATE TABLE IF NOT EXISTS transaction_splits (
+			id               BIGSERIAL PRIMARY KEY,
+			transaction_id   BIGINT NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+			category         VARCHAR(32) NOT NULL,
+			amount           DECIMAL(15,2) NOT NULL,
+			original_amount  DECIMAL(15,2) NOT NULL,
+			description      TEXT,
+			CONSTRAINT check_split_amount_positive CHECK (amount >= 0 AND original_amount > 0)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_transaction_splits_transaction_id ON transaction_splits (transaction_id);
+		CREATE INDEX IF NOT EXISTS idx_transaction_splits_category ON transaction_splits (category);
+	`).Error
+}
+
+func rollbackTransactionSplits(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TABLE IF EXISTS transaction_splits;
+	`).Error
+}
@@ -0,0 +1,81 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("019", "Create shared ledgers and their members", createLedgers, rollbackLedgers)
+}
+
+func createLedgers(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TYPE IF EXISTS ledger_role;
+		CREATE TYPE ledger_role AS ENUM ('owner', 'editor', 'viewer');
+
+		CREATE TABLE IF NOT EXISTS ledgers (
+			id           BIGSERIAL PRIMARY KEY,
+			name         VARCHAR(32) NOT NULL,
+			currency     currency_type NOT NULL DEFAULT 'EUR',
+			invite_code  VARCHAR(16) NOT NULL,
+			created_by   BIGINT NOT NULL REFERENCES users (tg_id),
+			created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_ledger_invite_code UNIQUE (invite_code)
+		);
+
+		CREATE TABLE IF NOT EXISTS ledger_members (
+			ledger_id  BIGINT NOT NULL REFERENCES ledgers (id) ON DELETE CASCADE,
+			tg_id      BIGINT NOT NULL REFERENCES users (tg_id),
+			role       ledger_role NOT NULL DEFAULT 'editor',
+			joined_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (ledger_id, tg_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_ledger_members_tg_id ON ledger_members (tg_id);
+
+		-- The transactions of a deleted ledger go back to the members who entered them
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS ledger_id BIGINT REFERENCES ledgers (id) ON DELETE SET NULL;
+		CREATE INDEX IF NOT EXISTS idx_transactions_ledger_date ON transactions (ledger_id, date) WHERE ledger_id IS NOT NULL;
+
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS ledger_id BIGINT REFERENCES ledgers (id) ON DELETE SET NULL;
+
+		-- A user has a single personal budget and a ledger a single one
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS ledger_id BIGINT REFERENCES ledgers (id) ON DELETE CASCADE;
+		ALTER TABLE budgets DROP CONSTRAINT IF EXISTS unique_user_budget;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_user_budget ON budgets (tg_id) WHERE ledger_id IS NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_ledger_budget ON budgets (ledger_id) WHERE ledger_id IS NOT NULL;
+
+		ALTER TABLE budget_alerts ADD COLUMN IF NOT EXISTS ledger_id BIGINT REFERENCES ledgers (id) ON DELETE CASCADE;
+		ALTER TABLE budget_alerts DROP CONSTRAINT IF EXISTS unique_user_month_threshold;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_user_month_threshold ON budget_alerts (tg_id, year_month, threshold) WHERE ledger_id IS NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_ledger_month_threshold ON budget_alerts (ledger_id, year_month, threshold) WHERE ledger_id IS NOT NULL;
+	`).Error
+}
+
+func rollbackLedgers(tx *gorm.DB) error {
+	return tx.Exec(`
+		DELETE FROM budget_alerts WHERE ledger_id IS NOT NULL;
+		DROP INDEX IF EXISTS unique_ledger_month_threshold;
+		DROP INDEX IF EXISTS unique_user_month_threshold;
+		ALTER TABLE budget_alerts DROP COLUMN IF EXISTS ledger_id;
+		ALTER TABLE budget_alerts ADD CONSTRAINT unique_user_month_threshold UNIQUE (tg_id, year_month, threshold);
+
+		DELETE FROM budgets WHERE ledger_id IS NOT NULL;
+		DROP INDEX IF EXISTS unique_ledger_budget;
+		DROP INDEX IF EXISTS unique_user_budget;
+		ALTER TABLE budgets DROP COLUMN IF EXISTS ledger_id;
+		ALTER TABLE budgets ADD CONSTRAINT unique_user_budget UNIQUE (tg_id);
+
+		ALTER TABLE users DROP COLUMN IF EXISTS ledger_id;
+
+		DROP INDEX IF EXISTS idx_transactions_ledger_date;
+		ALTER TABLE transactions DROP COLUMN IF EXISTS ledger_id;
+
+		DROP TABLE IF EXISTS ledger_members;
+		DROP TABLE IF EXISTS ledgers;
+		DROP TYPE IF EXISTS ledger_role;
+	`).Error
+}
@@ -0,0 +1,72 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("020", "Create shared expenses and settlements between users", createSettlements, rollbackSettlements)
+}
+
+func createSettlements(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TYPE IF EXISTS split_method;
+		CREATE TYPE split_method AS ENUM ('equal', 'percent', 'exact');
+
+		CREATE TABLE IF NOT EXISTS shared_expenses (
+			id              BIGSERIAL PRIMARY KEY,
+			transaction_id  BIGINT NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+			paid_by         BIGINT NOT NULL REFERENCES users (tg_id),
+			method          split_method NOT NULL,
+			amount          DECIMAL(15, 2) NOT NULL,
+			currency        currency_type NOT NULL DEFAULT 'EUR',
+			created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_shared_expense_transaction UNIQUE (transaction_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_shared_expenses_paid_by ON shared_expenses (paid_by);
+
+		CREATE TABLE IF NOT EXISTS expense_shares (
+			shared_expense_id  BIGINT NOT NULL REFERENCES shared_expenses (id) ON DELETE CASCADE,
+			tg_id              BIGINT NOT NULL REFERENCES users (tg_id),
+			amount             DECIMAL(15, 2) NOT NULL,
+			PRIMARY KEY (shared_expense_id, tg_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_expense_shares_tg_id ON expense_shares (tg_id);
+
+		CREATE TABLE IF NOT EXISTS settlements (
+			id          BIGSERIAL PRIMARY KEY,
+			from_tg_id  BIGINT NOT NULL REFERENCES users (tg_id),
+			to_tg_id    BIGINT NOT NULL REFERENCES users (tg_id),
+			amount      DECIMAL(15, 2) NOT NULL,
+			currency    currency_type NOT NULL DEFAULT 'EUR',
+			date        DATE NOT NULL DEFAULT CURRENT_DATE,
+			created_by  BIGINT NOT NULL REFERENCES users (tg_id),
+			created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT chk_settlements_parties CHECK (from_tg_id <> to_tg_id),
+			CONSTRAINT chk_settlements_amount CHECK (amount > 0)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_settlements_from_tg_id ON settlements (from_tg_id);
+		CREATE INDEX IF NOT EXISTS idx_settlements_to_tg_id ON settlements (to_tg_id);
+
+		-- The expense and the income recording a settlement go away with it
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS settlement_id BIGINT REFERENCES settlements (id) ON DELETE CASCADE;
+		CREATE INDEX IF NOT EXISTS idx_transactions_settlement_id ON transactions (settlement_id) WHERE settlement_id IS NOT NULL;
+	`).Error
+}
+
+func rollbackSettlements(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP INDEX IF EXISTS idx_transactions_settlement_id;
+		ALTER TABLE transactions DROP COLUMN IF EXISTS settlement_id;
+
+		DROP TABLE IF EXISTS settlements;
+		DROP TABLE IF EXISTS expense_shares;
+		DROP TABLE IF EXISTS shared_expenses;
+		DROP TYPE IF EXISTS split_method;
+	`).Error
+}
@@ -0,0 +1,35 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("021", "Create attachments of transactions", createAttachments, rollbackAttachments)
+}
+
+func createAttachments(tx *gorm.DB) error {
+	return tx.Exec(`
+		CREATE TABLE IF NOT EXISTS attachments (
+			id              BIGSERIAL PRIMARY KEY,
+			transaction_id  BIGINT NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+			tg_id           BIGINT NOT NULL REFERENCES users (tg_id),
+			file_name       VARCHAR(255) NOT NULL,
+			content_type    VARCHAR(64) NOT NULL,
+			size            BIGINT NOT NULL,
+			storage_key     TEXT NOT NULL,
+			created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_attachment_storage_key UNIQUE (storage_key),
+			CONSTRAINT chk_attachments_size CHECK (size >= 0)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_attachments_transaction_id ON attachments (transaction_id);
+		CREATE INDEX IF NOT EXISTS idx_attachments_tg_id ON attachments (tg_id);
+	`).Error
+}
+
+func rollbackAttachments(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS attachments;`).Error
+}
@@ -0,0 +1,29 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("022", "Add the trash of deleted transactions", addTransactionsTrash, rollbackTransactionsTrash)
+}
+
+func addTransactionsTrash(tx *gorm.DB) error {
+	return tx.Exec(`
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+		-- Only the trash is looked up by deletion time, the partial index stays small
+		CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON transactions (tg_id, deleted_at)
+			WHERE deleted_at IS NOT NULL;
+	`).Error
+}
+
+func rollbackTransactionsTrash(tx *gorm.DB) error {
+	return tx.Exec(`
+		DELETE FROM transactions WHERE deleted_at IS NOT NULL;
+		DROP INDEX IF EXISTS idx_transactions_deleted_at;
+		ALTER TABLE transactions DROP COLUMN IF EXISTS deleted_at;
+	`).Error
+}
@@ -0,0 +1,51 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("023", "Create the revisions of transactions", createTransactionRevisions, rollbackTransactionRevisions)
+}
+
+func createTransactionRevisions(tx *gorm.DB) error {
+	return tx.Exec(`
+		-- No foreign key on transaction_id, the history outlives the purged transactions
+		CREATE TABLE IF NOT EXISTS transaction_revisions (
+			id              BIGSERIAL PRIMARY KEY,
+			transaction_id  BIGINT NOT NULL,
+			tg_id           BIGINT NOT NULL REFERENCES users (tg_id),
+			action          VARCHAR(16) NOT NULL,
+			source          VARCHAR(16) NOT NULL,
+			actor_tg_id     BIGINT REFERENCES users (tg_id),
+			before          JSONB,
+			after           JSONB,
+			created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT chk_transaction_revisions_action CHECK (action IN ('created', 'updated', 'deleted', 'restored', 'purged')),
+			CONSTRAINT chk_transaction_revisions_source CHECK (source IN ('bot', 'web', 'api_token', 'scheduler'))
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_transaction_revisions_transaction_id ON transaction_revisions (transaction_id, id);
+
+		-- The history is append-only
+		CREATE OR REPLACE FUNCTION forbid_transaction_revision_changes() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'transaction revisions are append-only';
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS trg_transaction_revisions_append_only ON transaction_revisions;
+		CREATE TRIGGER trg_transaction_revisions_append_only
+			BEFORE UPDATE OR DELETE ON transaction_revisions
+			FOR EACH ROW EXECUTE FUNCTION forbid_transaction_revision_changes();
+	`).Error
+}
+
+func rollbackTransactionRevisions(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TABLE IF EXISTS transaction_revisions;
+		DROP FUNCTION IF EXISTS forbid_transaction_revision_changes();
+	`).Error
+}
@@ -0,0 +1,24 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("024", "Add the timezone of the users", addUsersTimezone, rollbackUsersTimezone)
+}
+
+func addUsersTimezone(tx *gorm.DB) error {
+	// Existing users keep the UTC behaviour until they set their timezone
+	return tx.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+	`).Error
+}
+
+func rollbackUsersTimezone(tx *gorm.DB) error {
+	return tx.Exec(`
+		ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+	`).Error
+}
@@ -0,0 +1,53 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("025", "Create savings goals and their contributions", createGoals, rollbackGoals)
+}
+
+func createGoals(tx *gorm.DB) error {
+	return tx.Exec(`
+		CREATE TABLE IF NOT EXISTS goals (
+			id             BIGSERIAL PRIMARY KEY,
+			tg_id          BIGINT NOT NULL REFERENCES users (tg_id),
+			name           VARCHAR(64) NOT NULL,
+			target_amount  DECIMAL(15, 2) NOT NULL,
+			currency       currency_type NOT NULL DEFAULT 'EUR',
+			deadline       DATE,
+			tag            VARCHAR(32) NOT NULL DEFAULT '',
+			created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT chk_goals_target_amount CHECK (target_amount > 0)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_goals_tg_id ON goals (tg_id);
+
+		-- A tag contributes to a single goal, so that transactions are not counted twice
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_goals_tg_id_tag ON goals (tg_id, tag) WHERE tag <> '';
+
+		CREATE TABLE IF NOT EXISTS goal_contributions (
+			id          BIGSERIAL PRIMARY KEY,
+			goal_id     BIGINT NOT NULL REFERENCES goals (id) ON DELETE CASCADE,
+			tg_id       BIGINT NOT NULL REFERENCES users (tg_id),
+			amount      DECIMAL(15, 2) NOT NULL,
+			date        DATE NOT NULL,
+			note        VARCHAR(255) NOT NULL DEFAULT '',
+			created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT chk_goal_contributions_amount CHECK (amount <> 0)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_goal_contributions_goal_id ON goal_contributions (goal_id);
+	`).Error
+}
+
+func rollbackGoals(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TABLE IF EXISTS goal_contributions;
+		DROP TABLE IF EXISTS goals;
+	`).Error
+}
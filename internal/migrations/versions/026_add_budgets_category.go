@@ -0,0 +1,46 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("026", "Add the category of the budgets", addBudgetsCategory, rollbackBudgetsCategory)
+}
+
+func addBudgetsCategory(tx *gorm.DB) error {
+	// The existing budgets have no category, they stay the overall ones
+	return tx.Exec(`
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS category VARCHAR(32) NOT NULL DEFAULT '';
+		DROP INDEX IF EXISTS unique_user_budget;
+		DROP INDEX IF EXISTS unique_ledger_budget;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_user_budget ON budgets (tg_id, category) WHERE ledger_id IS NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_ledger_budget ON budgets (ledger_id, category) WHERE ledger_id IS NOT NULL;
+
+		ALTER TABLE budget_alerts ADD COLUMN IF NOT EXISTS category VARCHAR(32) NOT NULL DEFAULT '';
+		DROP INDEX IF EXISTS unique_user_month_threshold;
+		DROP INDEX IF EXISTS unique_ledger_month_threshold;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_user_month_threshold ON budget_alerts (tg_id, category, year_month, threshold) WHERE ledger_id IS NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_ledger_month_threshold ON budget_alerts (ledger_id, category, year_month, threshold) WHERE ledger_id IS NOT NULL;
+	`).Error
+}
+
+func rollbackBudgetsCategory(tx *gorm.DB) error {
+	return tx.Exec(`
+		DELETE FROM budget_alerts WHERE category <> '';
+		DROP INDEX IF EXISTS unique_ledger_month_threshold;
+		DROP INDEX IF EXISTS unique_user_month_threshold;
+		ALTER TABLE budget_alerts DROP COLUMN IF EXISTS category;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_user_month_threshold ON budget_alerts (tg_id, year_month, threshold) WHERE ledger_id IS NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_ledger_month_threshold ON budget_alerts (ledger_id, year_month, threshold) WHERE ledger_id IS NOT NULL;
+
+		DELETE FROM budgets WHERE category <> '';
+		DROP INDEX IF EXISTS unique_ledger_budget;
+		DROP INDEX IF EXISTS unique_user_budget;
+		ALTER TABLE budgets DROP COLUMN IF EXISTS category;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_user_budget ON budgets (tg_id) WHERE ledger_id IS NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS unique_ledger_budget ON budgets (ledger_id) WHERE ledger_id IS NOT NULL;
+	`).Error
+}
@@ -0,0 +1,33 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("027", "Add the rollover of the budgets", addBudgetsRollover, rollbackBudgetsRollover)
+}
+
+func addBudgetsRollover(tx *gorm.DB) error {
+	// The existing budgets keep evaluating each month on its own
+	return tx.Exec(`
+		DROP TYPE IF EXISTS budget_rollover;
+		CREATE TYPE budget_rollover AS ENUM ('none', 'positive', 'negative', 'both');
+
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS rollover budget_rollover NOT NULL DEFAULT 'none';
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS rollover_cap DECIMAL(15,2) NOT NULL DEFAULT 0;
+		ALTER TABLE budgets DROP CONSTRAINT IF EXISTS chk_budgets_rollover_cap;
+		ALTER TABLE budgets ADD CONSTRAINT chk_budgets_rollover_cap CHECK (rollover_cap >= 0);
+	`).Error
+}
+
+func rollbackBudgetsRollover(tx *gorm.DB) error {
+	return tx.Exec(`
+		ALTER TABLE budgets DROP CONSTRAINT IF EXISTS chk_budgets_rollover_cap;
+		ALTER TABLE budgets DROP COLUMN IF EXISTS rollover_cap;
+		ALTER TABLE budgets DROP COLUMN IF EXISTS rollover;
+		DROP TYPE IF EXISTS budget_rollover;
+	`).Error
+}
@@ -0,0 +1,35 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("028", "Add the alert thresholds of the budgets", addBudgetsThresholds, rollbackBudgetsThresholds)
+}
+
+func addBudgetsThresholds(tx *gorm.DB) error {
+	// The existing budgets keep alerting at 80% and 100%
+	return tx.Exec(`
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS thresholds SMALLINT[] NOT NULL DEFAULT '{80,100}';
+
+		ALTER TABLE budget_alerts DROP CONSTRAINT IF EXISTS budget_alerts_threshold_check;
+		ALTER TABLE budget_alerts ADD CONSTRAINT budget_alerts_threshold_check CHECK (threshold BETWEEN 1 AND 500);
+
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS budget_alert_email BOOLEAN NOT NULL DEFAULT FALSE;
+	`).Error
+}
+
+func rollbackBudgetsThresholds(tx *gorm.DB) error {
+	return tx.Exec(`
+		ALTER TABLE users DROP COLUMN IF EXISTS budget_alert_email;
+
+		DELETE FROM budget_alerts WHERE threshold NOT IN (80, 100);
+		ALTER TABLE budget_alerts DROP CONSTRAINT IF EXISTS budget_alerts_threshold_check;
+		ALTER TABLE budget_alerts ADD CONSTRAINT budget_alerts_threshold_check CHECK (threshold IN (80, 100));
+
+		ALTER TABLE budgets DROP COLUMN IF EXISTS thresholds;
+	`).Error
+}
@@ -0,0 +1,50 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("029", "Add the period of the budgets", addBudgetsPeriod, rollbackBudgetsPeriod)
+}
+
+func addBudgetsPeriod(tx *gorm.DB) error {
+	// The existing budgets stay monthly, and the alerts they fired keep their
+	// month as the key of the period
+	return tx.Exec(`
+		DROP TYPE IF EXISTS budget_period;
+		CREATE TYPE budget_period AS ENUM ('weekly', 'monthly', 'quarterly', 'yearly', 'custom');
+
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS period budget_period NOT NULL DEFAULT 'monthly';
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS week_start SMALLINT NOT NULL DEFAULT 1;
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS start_date DATE;
+		ALTER TABLE budgets ADD COLUMN IF NOT EXISTS end_date DATE;
+		ALTER TABLE budgets DROP CONSTRAINT IF EXISTS chk_budgets_week_start;
+		ALTER TABLE budgets ADD CONSTRAINT chk_budgets_week_start CHECK (week_start BETWEEN 0 AND 6);
+		ALTER TABLE budgets DROP CONSTRAINT IF EXISTS chk_budgets_custom_period;
+		ALTER TABLE budgets ADD CONSTRAINT chk_budgets_custom_period CHECK (
+			period <> 'custom' OR (start_date IS NOT NULL AND end_date IS NOT NULL AND end_date >= start_date)
+		);
+
+		ALTER TABLE budget_alerts RENAME COLUMN year_month TO period;
+		ALTER TABLE budget_alerts ALTER COLUMN period TYPE VARCHAR(10);
+	`).Error
+}
+
+func rollbackBudgetsPeriod(tx *gorm.DB) error {
+	return tx.Exec(`
+		DELETE FROM budget_alerts WHERE period !~ '^[0-9]{4}-[0-9]{2}$';
+		ALTER TABLE budget_alerts ALTER COLUMN period TYPE CHAR(7);
+		ALTER TABLE budget_alerts RENAME COLUMN period TO year_month;
+
+		ALTER TABLE budgets DROP CONSTRAINT IF EXISTS chk_budgets_custom_period;
+		ALTER TABLE budgets DROP CONSTRAINT IF EXISTS chk_budgets_week_start;
+		ALTER TABLE budgets DROP COLUMN IF EXISTS end_date;
+		ALTER TABLE budgets DROP COLUMN IF EXISTS start_date;
+		ALTER TABLE budgets DROP COLUMN IF EXISTS week_start;
+		ALTER TABLE budgets DROP COLUMN IF EXISTS period;
+		DROP TYPE IF EXISTS budget_period;
+	`).Error
+}
@@ -0,0 +1,37 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("030", "Create envelope_allocations table", createEnvelopeAllocationsTable, rollbackEnvelopeAllocationsTable)
+}
+
+func createEnvelopeAllocationsTable(tx *gorm.DB) error {
+	// The money assigned to a goal goes with it, an income leaving the trash
+	// for good leaves the money it brought where it was assigned
+	return tx.Exec(`
+		CREATE TABLE IF NOT EXISTS envelope_allocations (
+			id              BIGSERIAL PRIMARY KEY,
+			tg_id           BIGINT NOT NULL REFERENCES users (tg_id),
+			month           CHAR(7) NOT NULL,
+			category        VARCHAR(32) NOT NULL DEFAULT '',
+			goal_id         BIGINT REFERENCES goals (id) ON DELETE CASCADE,
+			amount          DECIMAL(15, 2) NOT NULL,
+			transaction_id  BIGINT REFERENCES transactions (id) ON DELETE SET NULL,
+			note            VARCHAR(255) NOT NULL DEFAULT '',
+			created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT chk_envelope_allocations_amount CHECK (amount <> 0),
+			CONSTRAINT chk_envelope_allocations_envelope CHECK ((category = '') <> (goal_id IS NULL))
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_envelope_allocations_tg_month ON envelope_allocations (tg_id, month);
+	`).Error
+}
+
+func rollbackEnvelopeAllocationsTable(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS envelope_allocations;`).Error
+}
@@ -0,0 +1,53 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigrationWithRollback("031", "Create holdings and holding_snapshots tables", createHoldingsTables, rollbackHoldingsTables)
+}
+
+func createHoldingsTables(tx *gorm.DB) error {
+	// A holding tracking an account goes with it, as its balance came from the
+	// transactions of the account alone
+	return tx.Exec(`
+		DROP TYPE IF EXISTS holding_kind;
+		CREATE TYPE holding_kind AS ENUM ('Bank', 'Brokerage', 'Property', 'Asset', 'Mortgage', 'Loan', 'Liability');
+
+		CREATE TABLE IF NOT EXISTS holdings (
+			id          BIGSERIAL PRIMARY KEY,
+			tg_id       BIGINT NOT NULL REFERENCES users (tg_id),
+			name        VARCHAR(32) NOT NULL,
+			kind        holding_kind NOT NULL DEFAULT 'Asset',
+			currency    currency_type NOT NULL DEFAULT 'EUR',
+			account_id  BIGINT REFERENCES accounts (id) ON DELETE CASCADE,
+			created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_user_holding_name UNIQUE (tg_id, name),
+			CONSTRAINT unique_holding_account UNIQUE (account_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS holding_snapshots (
+			id          BIGSERIAL PRIMARY KEY,
+			holding_id  BIGINT NOT NULL REFERENCES holdings (id) ON DELETE CASCADE,
+			tg_id       BIGINT NOT NULL REFERENCES users (tg_id),
+			date        DATE NOT NULL,
+			balance     DECIMAL(15, 2) NOT NULL,
+			created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT unique_holding_snapshot_date UNIQUE (holding_id, date)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_holding_snapshots_tg_id ON holding_snapshots (tg_id);
+	`).Error
+}
+
+func rollbackHoldingsTables(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TABLE IF EXISTS holding_snapshots;
+		DROP TABLE IF EXISTS holdings;
+		DROP TYPE IF EXISTS holding_kind;
+	`).Error
+}
@@ -0,0 +1,25 @@
+package versions
+
+import (
+	"cashout/internal/migrations"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrations.RegisterMigration("032", "Add balance_check_in to the reminder types", addBalanceCheckInReminder)
+}
+
+func addBalanceCheckInReminder(tx *gorm.DB) error {
+	db, err := tx.DB()
+	if err != nil {
+		return err
+	}
+
+	// Adding an enum value cannot be used in the same transaction, run it on its own
+	_, err = db.Exec(`
+		ALTER TYPE reminder_type ADD VALUE IF NOT EXISTS 'balance_check_in';
+	`)
+
+	return err
+}
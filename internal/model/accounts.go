@@ -0,0 +1,250 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxAccountNameLength is the maximum length in bytes of an account name
+const MaxAccountNameLength = 32
+
+var (
+	ErrAccountNotFound  = errors.New("account not found")
+	ErrAccountExists    = errors.New("account already exists")
+	ErrAccountInUse     = errors.New("account is used by some transactions")
+	ErrInvalidAccount   = errors.New("invalid account")
+	ErrInvalidTransfer  = errors.New("invalid transfer")
+	ErrAccountCurrency  = errors.New("account currency cannot be changed")
+	ErrAccountArchived  = errors.New("account is archived")
+	ErrAccountNotOwned  = errors.New("account does not belong to the user")
+	errAccountUnrelated = errors.New("transaction does not involve the account")
+)
+
+// AccountKind is the kind of an account, e.g. cash or a savings account
+type AccountKind string
+
+// Account kinds
+const (
+	AccountCash     AccountKind = "Cash"
+	AccountChecking AccountKind = "Checking"
+	AccountCard     AccountKind = "Card"
+	AccountSavings  AccountKind = "Savings"
+	AccountOther    AccountKind = "Other"
+)
+
+// Value implements the driver.Valuer interface for AccountKind
+func (k AccountKind) Value() (driver.Value, error) {
+	return string(k), nil
+}
+
+// Scan implements the sql.Scanner interface for AccountKind
+func (k *AccountKind) Scan(value any) error {
+	if value == nil {
+		return errors.New("account kind cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid account kind")
+	}
+
+	*k = AccountKind(strVal)
+	return nil
+}
+
+// Emoji returns the emoji used to render the account kind
+func (k AccountKind) Emoji() string {
+	switch k {
+	case AccountCash:
+		return "💶"
+	case AccountChecking:
+		return "🏦"
+	case AccountCard:
+		return "💳"
+	case AccountSavings:
+		return "🐷"
+	default:
+		return "👛"
+	}
+}
+
+// GetAccountKinds returns all account kinds
+func GetAccountKinds() []string {
+	return []string{
+		string(AccountCash),
+		string(AccountChecking),
+		string(AccountCard),
+		string(AccountSavings),
+		string(AccountOther),
+	}
+}
+
+// ParseAccountKind returns the account kind matching the given name, ignoring case
+func ParseAccountKind(name string) (AccountKind, bool) {
+	for _, k := range GetAccountKinds() {
+		if strings.EqualFold(k, name) {
+			return AccountKind(k), true
+		}
+	}
+	return "", false
+}
+
+// Account represents the accounts table structure, a wallet the money of a
+// transaction comes from or goes to. OpeningBalance is in the account currency.
+type Account struct {
+	ID             int64        `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID           int64        `gorm:"column:tg_id;not null;uniqueIndex:idx_accounts_tg_id_name"`
+	Name           string       `gorm:"column:name;not null;size:32;uniqueIndex:idx_accounts_tg_id_name"`
+	Kind           AccountKind  `gorm:"column:kind;not null;type:account_kind;default:'Other'"`
+	Currency       CurrencyType `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	OpeningBalance Money        `gorm:"column:opening_balance;not null;type:decimal(15,2);default:0"`
+	Archived       bool         `gorm:"column:archived;not null;default:false"`
+	CreatedAt      time.Time    `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time    `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName overrides the table name
+func (Account) TableName() string {
+	return "accounts"
+}
+
+// Label returns the account name preceded by the emoji of its kind
+func (a Account) Label() string {
+	return fmt.Sprintf("%s %s", a.Kind.Emoji(), a.Name)
+}
+
+// Normalize trims the name and fills the optional fields with their defaults
+func (a *Account) Normalize() {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Kind == "" {
+		a.Kind = AccountOther
+	}
+}
+
+// Validate checks that the account can be stored and used in the bot callbacks
+func (a Account) Validate() error {
+	switch {
+	case a.Name == "":
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidAccount)
+	case len(a.Name) > MaxAccountNameLength:
+		return fmt.Errorf("%w: name cannot be longer than %d characters", ErrInvalidAccount, MaxAccountNameLength)
+	case !slices.Contains(GetAccountKinds(), string(a.Kind)):
+		return fmt.Errorf("%w: kind must be one of %s", ErrInvalidAccount, strings.Join(GetAccountKinds(), ", "))
+	case !IsValidCurrency(string(a.Currency)):
+		return fmt.Errorf("%w: unsupported currency %q", ErrInvalidAccount, a.Currency)
+	}
+	return nil
+}
+
+// Delta returns how much the transaction changes the balance of the account,
+// in the account currency. The value as entered is converted when the
+// transaction was made in another currency.
+func (a Account) Delta(t Transaction, rates RateTable) (Money, error) {
+	var sign Money
+	switch {
+	case t.Type == TypeTransfer && t.AccountID != nil && *t.AccountID == a.ID:
+		sign = -1
+	case t.Type == TypeTransfer && t.ToAccountID != nil && *t.ToAccountID == a.ID:
+		sign = 1
+	case t.Type == TypeTransfer || t.AccountID == nil || *t.AccountID != a.ID:
+		return 0, errAccountUnrelated
+	case t.Type == TypeIncome:
+		sign = 1
+	default:
+		sign = -1
+	}
+
+	converted, err := rates.ConvertAmount(t.EnteredAmount(), a.Currency)
+	if err != nil {
+		return 0, err
+	}
+	return sign * converted.Money, nil
+}
+
+// LedgerEntry is a transaction of an account with the balance right after it
+type LedgerEntry struct {
+	Transaction Transaction
+	Delta       Money
+	Balance     Money
+}
+
+// Ledger computes the running balance of the account over its transactions,
+// which must be sorted by date, starting from the given balance. Transactions
+// not involving the account are skipped.
+func (a Account) Ledger(transactions []Transaction, rates RateTable, start Money) ([]LedgerEntry, error) {
+	entries := make([]LedgerEntry, 0, len(transactions))
+	balance := start
+	for _, t := range transactions {
+		delta, err := a.Delta(t, rates)
+		if errors.Is(err, errAccountUnrelated) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		balance += delta
+		entries = append(entries, LedgerEntry{Transaction: t, Delta: delta, Balance: balance})
+	}
+	return entries, nil
+}
+
+// Accounts is the list of accounts of a user
+type Accounts []Account
+
+// Active returns the accounts that are not archived
+func (as Accounts) Active() Accounts {
+	var res Accounts
+	for _, a := range as {
+		if !a.Archived {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// Find returns the account with the given ID
+func (as Accounts) Find(id int64) (Account, bool) {
+	for _, a := range as {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return Account{}, false
+}
+
+// NewTransfer returns the transaction moving the amount, in the currency of
+// the source account, between two active accounts of the same user
+func NewTransfer(from, to Account, amount Money, date time.Time, description string) (Transaction, error) {
+	switch {
+	case from.TgID != to.TgID:
+		return Transaction{}, fmt.Errorf("%w: %w", ErrInvalidTransfer, ErrAccountNotOwned)
+	case from.ID == to.ID:
+		return Transaction{}, fmt.Errorf("%w: source and destination accounts must differ", ErrInvalidTransfer)
+	case from.Archived || to.Archived:
+		return Transaction{}, fmt.Errorf("%w: %w", ErrInvalidTransfer, ErrAccountArchived)
+	case amount <= 0:
+		return Transaction{}, fmt.Errorf("%w: amount must be greater than 0", ErrInvalidTransfer)
+	}
+
+	description = strings.TrimSpace(description)
+	if description == "" {
+		description = fmt.Sprintf("%s → %s", from.Name, to.Name)
+	}
+
+	fromID, toID := from.ID, to.ID
+	return Transaction{
+		TgID:        from.TgID,
+		Date:        date,
+		Type:        TypeTransfer,
+		Category:    CategoryTransfer,
+		Amount:      amount,
+		Currency:    from.Currency,
+		Description: description,
+		AccountID:   &fromID,
+		ToAccountID: &toID,
+	}, nil
+}
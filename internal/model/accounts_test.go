@@ -0,0 +1,191 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ptrInt64(v int64) *int64 { return &v }
+
+func TestAccountValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		account Account
+		wantErr bool
+	}{
+		{name: "valid", account: Account{Name: "Revolut", Kind: AccountCard, Currency: CurrencyEUR}},
+		{name: "empty name", account: Account{Kind: AccountCash, Currency: CurrencyEUR}, wantErr: true},
+		{name: "name too long", account: Account{Name: strings.Repeat("a", MaxAccountNameLength+1), Kind: AccountCash, Currency: CurrencyEUR}, wantErr: true},
+		{name: "invalid kind", account: Account{Name: "Wallet", Kind: "Crypto", Currency: CurrencyEUR}, wantErr: true},
+		{name: "invalid currency", account: Account{Name: "Wallet", Kind: AccountCash, Currency: "XYZ"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.account.Validate()
+			if tt.wantErr && !errors.Is(err, ErrInvalidAccount) {
+				t.Fatalf("expected ErrInvalidAccount, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAccountNormalize(t *testing.T) {
+	a := Account{Name: "  Wallet "}
+	a.Normalize()
+	if a.Name != "Wallet" || a.Kind != AccountOther {
+		t.Fatalf("unexpected normalized account: %+v", a)
+	}
+}
+
+func TestParseAccountKind(t *testing.T) {
+	if k, ok := ParseAccountKind("savings"); !ok || k != AccountSavings {
+		t.Fatalf("expected Savings, got %q %v", k, ok)
+	}
+	if _, ok := ParseAccountKind("Revolut"); ok {
+		t.Fatal("expected unknown kind")
+	}
+}
+
+func TestAccountDelta(t *testing.T) {
+	rates := RateTable{{Base: CurrencyEUR, Quote: CurrencyUSD, Rate: 1.25}}
+	account := Account{ID: 1, Currency: CurrencyEUR}
+
+	tests := []struct {
+		name    string
+		tx      Transaction
+		want    float64
+		wantErr bool
+	}{
+		{
+			name: "expense",
+			tx:   Transaction{Type: TypeExpense, Amount: NewMoney(10), Currency: CurrencyEUR, AccountID: ptrInt64(1)},
+			want: -10,
+		},
+		{
+			name: "income",
+			tx:   Transaction{Type: TypeIncome, Amount: NewMoney(10), Currency: CurrencyEUR, AccountID: ptrInt64(1)},
+			want: 10,
+		},
+		{
+			name: "foreign expense uses the amount as entered",
+			tx: Transaction{
+				Type: TypeExpense, Amount: NewMoney(9), Currency: CurrencyGBP,
+				OriginalAmount: NewMoney(12.5), OriginalCurrency: CurrencyUSD, AccountID: ptrInt64(1),
+			},
+			want: -10,
+		},
+		{
+			name: "transfer out",
+			tx:   Transaction{Type: TypeTransfer, Amount: NewMoney(20), Currency: CurrencyEUR, AccountID: ptrInt64(1), ToAccountID: ptrInt64(2)},
+			want: -20,
+		},
+		{
+			name: "transfer in",
+			tx:   Transaction{Type: TypeTransfer, Amount: NewMoney(20), Currency: CurrencyEUR, AccountID: ptrInt64(2), ToAccountID: ptrInt64(1)},
+			want: 20,
+		},
+		{
+			name:    "other account",
+			tx:      Transaction{Type: TypeExpense, Amount: NewMoney(10), Currency: CurrencyEUR, AccountID: ptrInt64(2)},
+			wantErr: true,
+		},
+		{
+			name:    "no account",
+			tx:      Transaction{Type: TypeExpense, Amount: NewMoney(10), Currency: CurrencyEUR},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := account.Delta(tt.tx, rates)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != NewMoney(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAccountLedger(t *testing.T) {
+	account := Account{ID: 1, Currency: CurrencyEUR}
+	txs := []Transaction{
+		{ID: 1, Type: TypeIncome, Amount: NewMoney(100), Currency: CurrencyEUR, AccountID: ptrInt64(1)},
+		{ID: 2, Type: TypeExpense, Amount: NewMoney(30.3), Currency: CurrencyEUR, AccountID: ptrInt64(2)},
+		{ID: 3, Type: TypeExpense, Amount: NewMoney(12.1), Currency: CurrencyEUR, AccountID: ptrInt64(1)},
+		{ID: 4, Type: TypeTransfer, Amount: NewMoney(50), Currency: CurrencyEUR, AccountID: ptrInt64(1), ToAccountID: ptrInt64(2)},
+	}
+
+	entries, err := account.Ledger(txs, nil, NewMoney(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		id      int64
+		balance float64
+	}{{1, 110}, {3, 97.9}, {4, 47.9}}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(entries))
+	}
+	for i, w := range want {
+		if entries[i].Transaction.ID != w.id || entries[i].Balance != NewMoney(w.balance) {
+			t.Fatalf("entry %d: expected #%d at %v, got #%d at %v", i, w.id, w.balance, entries[i].Transaction.ID, entries[i].Balance)
+		}
+	}
+}
+
+func TestNewTransfer(t *testing.T) {
+	from := Account{ID: 1, TgID: 42, Name: "Checking", Currency: CurrencyEUR}
+	to := Account{ID: 2, TgID: 42, Name: "Savings", Currency: CurrencyEUR}
+	date := time.Date(2026, 5, 21, 0, 0, 0, 0, time.UTC)
+
+	tx, err := NewTransfer(from, to, NewMoney(200), date, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !tx.IsTransfer() || tx.Category != CategoryTransfer || tx.Currency != CurrencyEUR || tx.TgID != 42 {
+		t.Fatalf("unexpected transfer: %+v", tx)
+	}
+	if *tx.AccountID != 1 || *tx.ToAccountID != 2 || tx.Description != "Checking → Savings" {
+		t.Fatalf("unexpected transfer accounts: %+v", tx)
+	}
+
+	archived := to
+	archived.Archived = true
+	other := to
+	other.TgID = 7
+
+	tests := []struct {
+		name   string
+		from   Account
+		to     Account
+		amount float64
+	}{
+		{name: "same account", from: from, to: from, amount: 10},
+		{name: "archived account", from: from, to: archived, amount: 10},
+		{name: "other user", from: from, to: other, amount: 10},
+		{name: "zero amount", from: from, to: to, amount: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTransfer(tt.from, tt.to, NewMoney(tt.amount), date, ""); !errors.Is(err, ErrInvalidTransfer) {
+				t.Fatalf("expected ErrInvalidTransfer, got %v", err)
+			}
+		})
+	}
+}
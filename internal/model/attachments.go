@@ -0,0 +1,76 @@
+package model
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+)
+
+// MaxAttachmentSize is the maximum size of an attached file, 10 MB
+const MaxAttachmentSize = 10 << 20
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentTooLarge = errors.New("the file is larger than 10 MB")
+	ErrAttachmentType     = errors.New("only photos and PDF documents can be attached")
+)
+
+// attachmentTypes are the content types accepted as attachments by extension
+var attachmentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"image/heic":      ".heic",
+	"application/pdf": ".pdf",
+}
+
+// Attachment represents the attachments table structure, a photo or a
+// document kept as proof of a transaction. The content is in the blob store
+// under StorageKey.
+type Attachment struct {
+	ID            int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	TransactionID int64     `gorm:"column:transaction_id;not null;index"`
+	TgID          int64     `gorm:"column:tg_id;not null;index"`
+	FileName      string    `gorm:"column:file_name;not null;size:255"`
+	ContentType   string    `gorm:"column:content_type;not null;size:64"`
+	Size          int64     `gorm:"column:size;not null"`
+	StorageKey    string    `gorm:"column:storage_key;not null;unique"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName overrides the table name
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// AttachmentExtension returns the file extension of an accepted content
+// type, parameters such as the charset are ignored
+func AttachmentExtension(contentType string) (string, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	ext, ok := attachmentTypes[strings.ToLower(strings.TrimSpace(mediaType))]
+	return ext, ok
+}
+
+// AttachmentFileName returns the base name of the file as uploaded, without
+// control characters and quotes so it is safe in a Content-Disposition
+// header, falling back to "attachment" with the extension of the type
+func AttachmentFileName(name, contentType string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == "/" {
+		ext, _ := AttachmentExtension(contentType)
+		return "attachment" + ext
+	}
+	if r := []rune(name); len(r) > 255 {
+		name = string(r[:255])
+	}
+	return name
+}
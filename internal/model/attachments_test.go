@@ -0,0 +1,50 @@
+package model
+
+import "testing"
+
+func TestAttachmentExtension(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+		wantOK      bool
+	}{
+		{contentType: "image/jpeg", want: ".jpg", wantOK: true},
+		{contentType: "Application/PDF", want: ".pdf", wantOK: true},
+		{contentType: "image/png; charset=binary", want: ".png", wantOK: true},
+		{contentType: "text/html"},
+		{contentType: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, ok := AttachmentExtension(tt.contentType)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("expected %q %v, got %q %v", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestAttachmentFileName(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		want        string
+	}{
+		{name: "kept as is", fileName: "receipt 2026.pdf", contentType: "application/pdf", want: "receipt 2026.pdf"},
+		{name: "directories dropped", fileName: "../../etc/receipt.pdf", contentType: "application/pdf", want: "receipt.pdf"},
+		{name: "windows path", fileName: `C:\Users\me\scan.png`, contentType: "image/png", want: "scan.png"},
+		{name: "quotes and newlines removed", fileName: "a\"b\r\nc.jpg", contentType: "image/jpeg", want: "abc.jpg"},
+		{name: "empty", fileName: "", contentType: "image/jpeg", want: "attachment.jpg"},
+		{name: "only a directory", fileName: "/", contentType: "application/pdf", want: "attachment.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AttachmentFileName(tt.fileName, tt.contentType); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
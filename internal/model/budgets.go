@@ -1,28 +1,502 @@
 package model
 
-import "time"
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
-// Budget represents a single user's total monthly expense limit.
+var (
+	// ErrBudgetCategory is returned for a category budget on a category the user
+	// does not have or that is not an expense one
+	ErrBudgetCategory = errors.New("budgets can only be set on expense categories")
+	ErrInvalidBudget  = errors.New("invalid budget")
+)
+
+// MaxBudgetThresholds is the maximum number of alert thresholds of a budget,
+// MaxBudgetThreshold the highest percentage one can be set at
+const (
+	MaxBudgetThresholds = 8
+	MaxBudgetThreshold  = 500
+)
+
+// DefaultBudgetThresholds are the alert thresholds of a new budget: approaching
+// the limit and over it
+var DefaultBudgetThresholds = BudgetThresholds{80, 100}
+
+// BudgetThresholds are the percentages of the limit of a budget at which an
+// alert fires, at most once a month each, sorted in ascending order
+type BudgetThresholds []int16
+
+// Value implements the driver.Valuer interface for BudgetThresholds, stored as
+// a Postgres array. An empty list stores the defaults.
+func (ts BudgetThresholds) Value() (driver.Value, error) {
+	if len(ts) == 0 {
+		ts = DefaultBudgetThresholds
+	}
+	parts := make([]string, len(ts))
+	for i, t := range ts {
+		parts[i] = strconv.Itoa(int(t))
+	}
+	return "{" + strings.Join(parts, ",") + "}", nil
+}
+
+// Scan implements the sql.Scanner interface for BudgetThresholds
+func (ts *BudgetThresholds) Scan(value any) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		*ts = nil
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return errors.New("invalid budget thresholds")
+	}
+
+	text = strings.Trim(text, "{}")
+	if text == "" {
+		*ts = nil
+		return nil
+	}
+	parts := strings.Split(text, ",")
+	res := make(BudgetThresholds, len(parts))
+	for i, p := range parts {
+		t, err := strconv.ParseInt(strings.TrimSpace(p), 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid budget threshold %q: %w", p, err)
+		}
+		res[i] = int16(t)
+	}
+	*ts = res
+	return nil
+}
+
+// ParseBudgetThresholds parses percentages like "50 75% 90", returning them
+// sorted without duplicates
+func ParseBudgetThresholds(fields []string) (BudgetThresholds, error) {
+	var ts BudgetThresholds
+	for _, f := range fields {
+		t, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(f), "%"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a percentage", ErrInvalidBudget, f)
+		}
+		ts = append(ts, int16(min(max(t, math.MinInt16), math.MaxInt16)))
+	}
+	ts = ts.Normalize()
+	return ts, ts.Validate()
+}
+
+// Normalize sorts the thresholds and removes the duplicates
+func (ts BudgetThresholds) Normalize() BudgetThresholds {
+	res := slices.Clone(ts)
+	slices.Sort(res)
+	return slices.Compact(res)
+}
+
+// Validate checks the thresholds are between 1% and MaxBudgetThreshold, at
+// most MaxBudgetThresholds of them
+func (ts BudgetThresholds) Validate() error {
+	if len(ts) == 0 {
+		return fmt.Errorf("%w: at least one alert threshold is needed", ErrInvalidBudget)
+	}
+	if len(ts) > MaxBudgetThresholds {
+		return fmt.Errorf("%w: at most %d alert thresholds", ErrInvalidBudget, MaxBudgetThresholds)
+	}
+	for _, t := range ts {
+		if t < 1 || t > MaxBudgetThreshold {
+			return fmt.Errorf("%w: alert thresholds go from 1%% to %d%%", ErrInvalidBudget, MaxBudgetThreshold)
+		}
+	}
+	return nil
+}
+
+// Reached returns the thresholds reached by the given spending against a limit
+func (ts BudgetThresholds) Reached(spent, limit Money) BudgetThresholds {
+	var res BudgetThresholds
+	for _, t := range ts {
+		if spent > 0 && spent*100 >= limit*Money(t) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// String lists the thresholds as percentages, like "80%, 100%"
+func (ts BudgetThresholds) String() string {
+	parts := make([]string, len(ts))
+	for i, t := range ts {
+		parts[i] = fmt.Sprintf("%d%%", t)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// BudgetRollover is what a budget carries over from a month to the next one
+type BudgetRollover string
+
+// Rollover modes: the unspent money (positive carry), the overspending
+// (negative carry), both or nothing
+const (
+	RolloverNone     BudgetRollover = "none"
+	RolloverPositive BudgetRollover = "positive"
+	RolloverNegative BudgetRollover = "negative"
+	RolloverBoth     BudgetRollover = "both"
+)
+
+// Value implements the driver.Valuer interface for BudgetRollover
+func (r BudgetRollover) Value() (driver.Value, error) {
+	if r == "" {
+		return string(RolloverNone), nil
+	}
+	return string(r), nil
+}
+
+// Scan implements the sql.Scanner interface for BudgetRollover
+func (r *BudgetRollover) Scan(value any) error {
+	if value == nil {
+		return errors.New("budget rollover cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid budget rollover")
+	}
+
+	*r = BudgetRollover(strVal)
+	return nil
+}
+
+// GetBudgetRollovers returns all rollover modes
+func GetBudgetRollovers() []string {
+	return []string{
+		string(RolloverNone),
+		string(RolloverPositive),
+		string(RolloverNegative),
+		string(RolloverBoth),
+	}
+}
+
+// ParseBudgetRollover matches a rollover mode case-insensitively, "off"
+// standing for none
+func ParseBudgetRollover(s string) (BudgetRollover, bool) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "off") {
+		return RolloverNone, true
+	}
+	for _, r := range GetBudgetRollovers() {
+		if strings.EqualFold(r, s) {
+			return BudgetRollover(r), true
+		}
+	}
+	return "", false
+}
+
+// BudgetPeriod is the span of time the amount of a budget limits the expenses of
+type BudgetPeriod string
+
+// Budget periods: calendar weeks, months, quarters and years, or a custom
+// range of dates
+const (
+	PeriodWeekly    BudgetPeriod = "weekly"
+	PeriodMonthly   BudgetPeriod = "monthly"
+	PeriodQuarterly BudgetPeriod = "quarterly"
+	PeriodYearly    BudgetPeriod = "yearly"
+	PeriodCustom    BudgetPeriod = "custom"
+)
+
+// Value implements the driver.Valuer interface for BudgetPeriod
+func (p BudgetPeriod) Value() (driver.Value, error) {
+	if p == "" {
+		return string(PeriodMonthly), nil
+	}
+	return string(p), nil
+}
+
+// Scan implements the sql.Scanner interface for BudgetPeriod
+func (p *BudgetPeriod) Scan(value any) error {
+	if value == nil {
+		return errors.New("budget period cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid budget period")
+	}
+
+	*p = BudgetPeriod(strVal)
+	return nil
+}
+
+// GetBudgetPeriods returns all budget periods
+func GetBudgetPeriods() []string {
+	return []string{
+		string(PeriodWeekly),
+		string(PeriodMonthly),
+		string(PeriodQuarterly),
+		string(PeriodYearly),
+		string(PeriodCustom),
+	}
+}
+
+// ParseBudgetPeriod matches a budget period case-insensitively
+func ParseBudgetPeriod(s string) (BudgetPeriod, bool) {
+	s = strings.TrimSpace(s)
+	for _, p := range GetBudgetPeriods() {
+		if strings.EqualFold(p, s) {
+			return BudgetPeriod(p), true
+		}
+	}
+	return "", false
+}
+
+// ParseWeekday matches the English name of a weekday or its first three
+// letters, case-insensitively
+func ParseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 3 {
+		return 0, false
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.HasPrefix(strings.ToLower(d.String()), s) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Budget represents a single user's expense limit over a period, a calendar
+// month unless set otherwise, or the one of a shared ledger when LedgerID is
+// set (TgID is then the owner who set it). The overall budget limits all the
+// expenses, it has no Category; a category budget limits the expenses of its
+// category, the lines of split transactions included.
+//
+// Weekly budgets start on WeekStart, custom ones span from StartDate to
+// EndDate, both included, and have no other period.
+//
+// With a rollover the limit of a period is Amount plus what is carried over
+// from the previous ones, at most RolloverCap either way when it is set.
+// An alert fires the first time in a period the spending reaches each of the
+// Thresholds, percentages of that limit.
 type Budget struct {
-	ID        int64        `gorm:"column:id;primaryKey;autoIncrement"`
-	TgID      int64        `gorm:"column:tg_id;not null;uniqueIndex"`
-	Amount    float64      `gorm:"column:amount;not null;type:decimal(15,2)"`
-	Currency  CurrencyType `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
-	CreatedAt time.Time    `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt time.Time    `gorm:"column:updated_at;autoUpdateTime"`
+	ID          int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID        int64               `gorm:"column:tg_id;not null;index"`
+	LedgerID    *int64              `gorm:"column:ledger_id"`
+	Category    TransactionCategory `gorm:"column:category;not null;size:32;default:''"`
+	Amount      Money               `gorm:"column:amount;not null;type:decimal(15,2)"`
+	Currency    CurrencyType        `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	Rollover    BudgetRollover      `gorm:"column:rollover;not null;type:budget_rollover;default:'none'"`
+	RolloverCap Money               `gorm:"column:rollover_cap;not null;type:decimal(15,2);default:0"`
+	Thresholds  BudgetThresholds    `gorm:"column:thresholds;not null;type:smallint[];default:'{80,100}'"`
+	Period      BudgetPeriod        `gorm:"column:period;not null;type:budget_period;default:'monthly'"`
+	WeekStart   time.Weekday        `gorm:"column:week_start;not null;type:smallint;default:1"`
+	StartDate   *time.Time          `gorm:"column:start_date;type:date"`
+	EndDate     *time.Time          `gorm:"column:end_date;type:date"`
+	CreatedAt   time.Time           `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time           `gorm:"column:updated_at;autoUpdateTime"`
 }
 
 func (Budget) TableName() string {
 	return "budgets"
 }
 
-// BudgetAlert tracks one-shot alert firings per (user, month, threshold).
+// IsCategory reports whether the budget limits a single category rather than
+// all the expenses
+func (b Budget) IsCategory() bool {
+	return b.Category != ""
+}
+
+// Validate checks the amount, the period and the rollover settings of the budget
+func (b Budget) Validate() error {
+	switch {
+	case b.Amount <= 0:
+		return fmt.Errorf("%w: the amount must be greater than 0", ErrInvalidBudget)
+	case b.Period != "" && !slices.Contains(GetBudgetPeriods(), string(b.Period)):
+		return fmt.Errorf("%w: unknown period %q", ErrInvalidBudget, b.Period)
+	case b.WeekStart < time.Sunday || b.WeekStart > time.Saturday:
+		return fmt.Errorf("%w: unknown week start %d", ErrInvalidBudget, b.WeekStart)
+	case b.Period == PeriodCustom && (b.StartDate == nil || b.EndDate == nil):
+		return fmt.Errorf("%w: a custom period needs its start and end dates", ErrInvalidBudget)
+	case b.Period == PeriodCustom && b.EndDate.Before(*b.StartDate):
+		return fmt.Errorf("%w: the period ends before it starts", ErrInvalidBudget)
+	case b.Period == PeriodCustom && b.HasRollover():
+		return fmt.Errorf("%w: a custom period has nothing to roll over", ErrInvalidBudget)
+	case b.Rollover != "" && !slices.Contains(GetBudgetRollovers(), string(b.Rollover)):
+		return fmt.Errorf("%w: unknown rollover %q", ErrInvalidBudget, b.Rollover)
+	case b.RolloverCap < 0:
+		return fmt.Errorf("%w: the rollover cap cannot be negative", ErrInvalidBudget)
+	case len(b.Thresholds) > 0:
+		return b.Thresholds.Validate()
+	}
+	return nil
+}
+
+// HasRollover reports whether the budget carries something over between periods
+func (b Budget) HasRollover() bool {
+	return b.Rollover != "" && b.Rollover != RolloverNone
+}
+
+// Window returns the first and the last day of the period of the budget
+// containing day, the dates of a custom budget whatever the day
+func (b Budget) Window(day time.Time) (time.Time, time.Time) {
+	day = DateOf(day)
+	var start time.Time
+	switch b.Period {
+	case PeriodCustom:
+		if b.StartDate != nil && b.EndDate != nil {
+			return DateOf(*b.StartDate), DateOf(*b.EndDate)
+		}
+		return day, day
+	case PeriodWeekly:
+		start = day.AddDate(0, 0, -((int(day.Weekday()) - int(b.WeekStart) + 7) % 7))
+		return start, start.AddDate(0, 0, 6)
+	case PeriodQuarterly:
+		start = time.Date(day.Year(), (day.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 3, -1)
+	case PeriodYearly:
+		start = time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1)
+	default:
+		start = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	}
+}
+
+// PeriodKey identifies the period of the budget starting on start, e.g.
+// "2026-05" for a month, "2026-Q2" for a quarter, "2026" for a year and the
+// start date for the other periods
+func (b Budget) PeriodKey(start time.Time) string {
+	switch b.Period {
+	case PeriodWeekly, PeriodCustom:
+		return start.Format("2006-01-02")
+	case PeriodQuarterly:
+		return fmt.Sprintf("%d-Q%d", start.Year(), (start.Month()-1)/3+1)
+	case PeriodYearly:
+		return start.Format("2006")
+	default:
+		return start.Format("2006-01")
+	}
+}
+
+// Carry returns the amount carried over into a period given the spending of
+// the previous periods the budget applies to, oldest first. The leftover of
+// each period, its limit minus its spending, is carried into the next one as
+// the rollover mode allows, within the cap. The overspending carried never
+// brings the limit below zero.
+func (b Budget) Carry(spent []Money) Money {
+	var carry Money
+	if !b.HasRollover() {
+		return carry
+	}
+	for _, s := range spent {
+		carry = b.Amount + carry - s
+		if b.Rollover == RolloverPositive {
+			carry = max(carry, 0)
+		}
+		if b.Rollover == RolloverNegative {
+			carry = min(carry, 0)
+		}
+		if b.RolloverCap > 0 {
+			carry = max(min(carry, b.RolloverCap), -b.RolloverCap)
+		}
+		carry = max(carry, -b.Amount)
+	}
+	return carry
+}
+
+// Pct returns the percentage of the budget amount used by the given spending
+func (b Budget) Pct(spent Money) int {
+	return pct(spent, b.Amount)
+}
+
+// BudgetSpending is the spending of a period against a budget, from Start to
+// End included, Carry being what its rollover brings over from the previous
+// periods
+type BudgetSpending struct {
+	Budget Budget
+	Start  time.Time
+	End    time.Time
+	Carry  Money
+	Spent  Money
+}
+
+// Contains reports whether day is in the period of the spending
+func (s BudgetSpending) Contains(day time.Time) bool {
+	day = DateOf(day)
+	return !day.Before(s.Start) && !day.After(s.End)
+}
+
+// PeriodKey identifies the period of the spending, see Budget.PeriodKey
+func (s BudgetSpending) PeriodKey() string {
+	return s.Budget.PeriodKey(s.Start)
+}
+
+// Reached returns the alert thresholds of the budget reached by the spending
+func (s BudgetSpending) Reached() BudgetThresholds {
+	thresholds := s.Budget.Thresholds
+	if len(thresholds) == 0 {
+		thresholds = DefaultBudgetThresholds
+	}
+	return thresholds.Reached(s.Spent, s.Limit())
+}
+
+// BudgetEvaluation is the state of a budget after a write, with the alert
+// thresholds it reached for the first time in the month
+type BudgetEvaluation struct {
+	BudgetSpending
+	Fired BudgetThresholds
+}
+
+// Limit returns the effective limit of the month, the budget amount plus the carry
+func (s BudgetSpending) Limit() Money {
+	return s.Budget.Amount + s.Carry
+}
+
+// Pct returns the percentage of the effective limit used, a limit brought
+// to zero by the carry being fully used by any spending
+func (s BudgetSpending) Pct() int {
+	limit := s.Limit()
+	if limit <= 0 {
+		if s.Spent > 0 {
+			return 100
+		}
+		return 0
+	}
+	return pct(s.Spent, limit)
+}
+
+// pct returns the percentage of limit used by spent, rounded down, zero for
+// a limit that is not positive
+func pct(spent, limit Money) int {
+	if limit <= 0 {
+		return 0
+	}
+	used := spent * 100
+	if used < 0 {
+		// Round down towards minus infinity, like math.Floor
+		return int((used - limit + 1) / limit)
+	}
+	return int(used / limit)
+}
+
+// BudgetAlert tracks one-shot alert firings per (user, category, period,
+// threshold), or per (ledger, category, period, threshold) for the budgets of
+// a shared ledger. Category is empty for the overall budget, Period is the
+// key of the budget period (see Budget.PeriodKey).
 type BudgetAlert struct {
-	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
-	TgID      int64     `gorm:"column:tg_id;not null;index"`
-	YearMonth string    `gorm:"column:year_month;not null;size:7"`
-	Threshold int16     `gorm:"column:threshold;not null"`
-	FiredAt   time.Time `gorm:"column:fired_at;autoCreateTime"`
+	ID        int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID      int64               `gorm:"column:tg_id;not null;index"`
+	LedgerID  *int64              `gorm:"column:ledger_id"`
+	Category  TransactionCategory `gorm:"column:category;not null;size:32;default:''"`
+	Period    string              `gorm:"column:period;not null;size:10"`
+	Threshold int16               `gorm:"column:threshold;not null"`
+	FiredAt   time.Time           `gorm:"column:fired_at;autoCreateTime"`
 }
 
 func (BudgetAlert) TableName() string {
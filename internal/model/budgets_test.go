@@ -0,0 +1,260 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBudgetPct(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		spent  float64
+		want   int
+	}{
+		{name: "nothing spent", amount: 400, spent: 0, want: 0},
+		{name: "rounded down", amount: 300, spent: 239.99, want: 79},
+		{name: "over budget", amount: 100, spent: 150, want: 150},
+		{name: "no amount", amount: 0, spent: 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Budget{Amount: NewMoney(tt.amount)}
+			if got := b.Pct(NewMoney(tt.spent)); got != tt.want {
+				t.Errorf("Pct() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetSpendingPct(t *testing.T) {
+	tests := []struct {
+		name      string
+		spending  BudgetSpending
+		wantLimit float64
+		want      int
+	}{
+		{name: "no carry", spending: BudgetSpending{Budget: Budget{Amount: NewMoney(300)}, Spent: NewMoney(240)}, wantLimit: 300, want: 80},
+		{name: "positive carry", spending: BudgetSpending{Budget: Budget{Amount: NewMoney(300)}, Carry: NewMoney(100), Spent: NewMoney(240)}, wantLimit: 400, want: 60},
+		{name: "negative carry", spending: BudgetSpending{Budget: Budget{Amount: NewMoney(300)}, Carry: NewMoney(-60), Spent: NewMoney(240)}, wantLimit: 240, want: 100},
+		{name: "limit used up by the carry", spending: BudgetSpending{Budget: Budget{Amount: NewMoney(300)}, Carry: NewMoney(-300), Spent: NewMoney(5)}, wantLimit: 0, want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spending.Limit(); got != NewMoney(tt.wantLimit) {
+				t.Errorf("Limit() = %v, want %v", got, tt.wantLimit)
+			}
+			if got := tt.spending.Pct(); got != tt.want {
+				t.Errorf("Pct() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetCarry(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollover BudgetRollover
+		cap      float64
+		spent    []float64
+		want     float64
+	}{
+		{name: "no rollover", rollover: RolloverNone, spent: []float64{50, 80}, want: 0},
+		{name: "no previous month", rollover: RolloverBoth, want: 0},
+		{name: "positive accumulates", rollover: RolloverPositive, spent: []float64{60, 70}, want: 70},
+		{name: "positive ignores overspending", rollover: RolloverPositive, spent: []float64{60, 180}, want: 0},
+		{name: "negative ignores savings", rollover: RolloverNegative, spent: []float64{60}, want: 0},
+		{name: "negative carries overspending", rollover: RolloverNegative, spent: []float64{130, 95.5}, want: -25.5},
+		{name: "both nets out", rollover: RolloverBoth, spent: []float64{60, 150}, want: -10},
+		{name: "capped", rollover: RolloverBoth, cap: 50, spent: []float64{0, 0, 0}, want: 50},
+		{name: "capped overspending", rollover: RolloverBoth, cap: 50, spent: []float64{400}, want: -50},
+		{name: "limit not below zero", rollover: RolloverNegative, spent: []float64{350}, want: -100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Budget{Amount: NewMoney(100), Rollover: tt.rollover, RolloverCap: NewMoney(tt.cap)}
+			if got := b.Carry(moneys(tt.spent...)); got != NewMoney(tt.want) {
+				t.Errorf("Carry(%v) = %v, want %v", tt.spent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  Budget
+		wantErr bool
+	}{
+		{name: "plain", budget: Budget{Amount: NewMoney(100)}},
+		{name: "rollover with cap", budget: Budget{Amount: NewMoney(100), Rollover: RolloverBoth, RolloverCap: NewMoney(50)}},
+		{name: "no amount", budget: Budget{Amount: 0}, wantErr: true},
+		{name: "unknown rollover", budget: Budget{Amount: NewMoney(100), Rollover: "sideways"}, wantErr: true},
+		{name: "negative cap", budget: Budget{Amount: NewMoney(100), Rollover: RolloverPositive, RolloverCap: NewMoney(-1)}, wantErr: true},
+		{name: "weekly", budget: Budget{Amount: NewMoney(100), Period: PeriodWeekly, WeekStart: time.Sunday}},
+		{name: "unknown period", budget: Budget{Amount: NewMoney(100), Period: "daily"}, wantErr: true},
+		{name: "custom", budget: Budget{Amount: NewMoney(100), Period: PeriodCustom, StartDate: &may1, EndDate: &may31}},
+		{name: "custom without dates", budget: Budget{Amount: NewMoney(100), Period: PeriodCustom}, wantErr: true},
+		{name: "custom ending before start", budget: Budget{Amount: NewMoney(100), Period: PeriodCustom, StartDate: &may31, EndDate: &may1}, wantErr: true},
+		{name: "custom with rollover", budget: Budget{Amount: NewMoney(100), Period: PeriodCustom, StartDate: &may1, EndDate: &may31, Rollover: RolloverBoth}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.budget.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidBudget) {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+var (
+	may1  = time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	may31 = time.Date(2026, time.May, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func TestBudgetWindow(t *testing.T) {
+	// Thursday 21 May 2026
+	day := time.Date(2026, time.May, 21, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		budget    Budget
+		wantStart string
+		wantEnd   string
+		wantKey   string
+	}{
+		{name: "monthly by default", budget: Budget{}, wantStart: "2026-05-01", wantEnd: "2026-05-31", wantKey: "2026-05"},
+		{name: "weekly from Monday", budget: Budget{Period: PeriodWeekly, WeekStart: time.Monday}, wantStart: "2026-05-18", wantEnd: "2026-05-24", wantKey: "2026-05-18"},
+		{name: "weekly from Sunday", budget: Budget{Period: PeriodWeekly, WeekStart: time.Sunday}, wantStart: "2026-05-17", wantEnd: "2026-05-23", wantKey: "2026-05-17"},
+		{name: "weekly from the same day", budget: Budget{Period: PeriodWeekly, WeekStart: time.Thursday}, wantStart: "2026-05-21", wantEnd: "2026-05-27", wantKey: "2026-05-21"},
+		{name: "quarterly", budget: Budget{Period: PeriodQuarterly}, wantStart: "2026-04-01", wantEnd: "2026-06-30", wantKey: "2026-Q2"},
+		{name: "yearly", budget: Budget{Period: PeriodYearly}, wantStart: "2026-01-01", wantEnd: "2026-12-31", wantKey: "2026"},
+		{name: "custom", budget: Budget{Period: PeriodCustom, StartDate: &may1, EndDate: &may31}, wantStart: "2026-05-01", wantEnd: "2026-05-31", wantKey: "2026-05-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := tt.budget.Window(day)
+			if got := start.Format("2006-01-02"); got != tt.wantStart {
+				t.Errorf("Window() start = %s, want %s", got, tt.wantStart)
+			}
+			if got := end.Format("2006-01-02"); got != tt.wantEnd {
+				t.Errorf("Window() end = %s, want %s", got, tt.wantEnd)
+			}
+			if got := tt.budget.PeriodKey(start); got != tt.wantKey {
+				t.Errorf("PeriodKey() = %s, want %s", got, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestParseBudgetPeriod(t *testing.T) {
+	for input, want := range map[string]BudgetPeriod{"Weekly": PeriodWeekly, " monthly ": PeriodMonthly, "YEARLY": PeriodYearly, "custom": PeriodCustom} {
+		if got, ok := ParseBudgetPeriod(input); !ok || got != want {
+			t.Errorf("ParseBudgetPeriod(%q) = %q, %v, want %q", input, got, ok, want)
+		}
+	}
+	if _, ok := ParseBudgetPeriod("daily"); ok {
+		t.Errorf("ParseBudgetPeriod(%q) should fail", "daily")
+	}
+
+	for input, want := range map[string]time.Weekday{"Sunday": time.Sunday, "mon": time.Monday, "WED": time.Wednesday} {
+		if got, ok := ParseWeekday(input); !ok || got != want {
+			t.Errorf("ParseWeekday(%q) = %v, %v, want %v", input, got, ok, want)
+		}
+	}
+	for _, input := range []string{"mo", "someday", ""} {
+		if _, ok := ParseWeekday(input); ok {
+			t.Errorf("ParseWeekday(%q) should fail", input)
+		}
+	}
+}
+
+func TestParseBudgetRollover(t *testing.T) {
+	for input, want := range map[string]BudgetRollover{"Both": RolloverBoth, " positive ": RolloverPositive, "off": RolloverNone, "none": RolloverNone} {
+		if got, ok := ParseBudgetRollover(input); !ok || got != want {
+			t.Errorf("ParseBudgetRollover(%q) = %q, %v, want %q", input, got, ok, want)
+		}
+	}
+	if _, ok := ParseBudgetRollover("sideways"); ok {
+		t.Errorf("ParseBudgetRollover(%q) should fail", "sideways")
+	}
+}
+
+func TestParseBudgetThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		want    BudgetThresholds
+		wantErr bool
+	}{
+		{name: "sorted", fields: []string{"50", "75", "90", "100", "120"}, want: BudgetThresholds{50, 75, 90, 100, 120}},
+		{name: "percent signs and duplicates", fields: []string{"100%", "80", "80%"}, want: BudgetThresholds{80, 100}},
+		{name: "none", wantErr: true},
+		{name: "not a number", fields: []string{"lots"}, wantErr: true},
+		{name: "zero", fields: []string{"0"}, wantErr: true},
+		{name: "too high", fields: []string{"501"}, wantErr: true},
+		{name: "too many", fields: []string{"10", "20", "30", "40", "50", "60", "70", "80", "90"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBudgetThresholds(tt.fields)
+			if tt.wantErr != errors.Is(err, ErrInvalidBudget) {
+				t.Fatalf("ParseBudgetThresholds(%v) error = %v, wantErr %v", tt.fields, err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("ParseBudgetThresholds(%v) = %v, want %v", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetThresholdsReached(t *testing.T) {
+	thresholds := BudgetThresholds{50, 75, 100, 120}
+	tests := []struct {
+		name  string
+		spent float64
+		limit float64
+		want  BudgetThresholds
+	}{
+		{name: "none", spent: 100, limit: 1000},
+		{name: "exactly at one", spent: 500, limit: 1000, want: BudgetThresholds{50}},
+		{name: "over", spent: 1250, limit: 1000, want: BudgetThresholds{50, 75, 100, 120}},
+		{name: "exactly at one in cents", spent: 9.66, limit: 8.05, want: BudgetThresholds{50, 75, 100, 120}},
+		{name: "limit used up by the carry", spent: 10, limit: 0, want: BudgetThresholds{50, 75, 100, 120}},
+		{name: "nothing spent", limit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thresholds.Reached(NewMoney(tt.spent), NewMoney(tt.limit)); !slices.Equal(got, tt.want) {
+				t.Errorf("Reached(%v, %v) = %v, want %v", tt.spent, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetThresholdsValueScan(t *testing.T) {
+	v, err := BudgetThresholds{50, 100, 120}.Value()
+	if err != nil || v != "{50,100,120}" {
+		t.Fatalf("Value() = %v, %v, want {50,100,120}", v, err)
+	}
+	if v, _ := BudgetThresholds(nil).Value(); v != "{80,100}" {
+		t.Errorf("Value() of no thresholds = %v, want the defaults {80,100}", v)
+	}
+
+	var got BudgetThresholds
+	if err := got.Scan([]byte("{50,100,120}")); err != nil || !slices.Equal(got, BudgetThresholds{50, 100, 120}) {
+		t.Errorf("Scan() = %v, %v, want [50 100 120]", got, err)
+	}
+	if err := got.Scan("{}"); err != nil || got != nil {
+		t.Errorf("Scan(\"{}\") = %v, %v, want nil", got, err)
+	}
+}
@@ -0,0 +1,191 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultCategoryEmoji is used for categories without an emoji
+const DefaultCategoryEmoji = "📌"
+
+// DefaultCategoryColor is used for categories without a colour
+const DefaultCategoryColor = "#9e9e9e"
+
+// MaxCategoryNameLength is the maximum length in bytes of a category name,
+// kept short as the name travels in the bot callback data (64 bytes max)
+const MaxCategoryNameLength = 32
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category already exists")
+	ErrCategoryInUse    = errors.New("category is used by some transactions")
+	ErrInvalidCategory  = errors.New("invalid category")
+)
+
+var categoryColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Category represents the categories table structure, a user-defined
+// transaction category. Transactions reference it by name.
+type Category struct {
+	ID        int64           `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID      int64           `gorm:"column:tg_id;not null;uniqueIndex:idx_categories_tg_id_name"`
+	Name      string          `gorm:"column:name;not null;size:32;uniqueIndex:idx_categories_tg_id_name"`
+	Type      TransactionType `gorm:"column:type;not null;type:transaction_type"`
+	Emoji     string          `gorm:"column:emoji;not null;default:'📌'"`
+	Color     string          `gorm:"column:color;not null;size:7"`
+	Archived  bool            `gorm:"column:archived;not null;default:false"`
+	CreatedAt time.Time       `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time       `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName overrides the table name
+func (Category) TableName() string {
+	return "categories"
+}
+
+// Label returns the category name preceded by its emoji
+func (c Category) Label() string {
+	return fmt.Sprintf("%s %s", c.Emoji, c.Name)
+}
+
+// Normalize trims the fields and fills the optional ones with their defaults
+func (c *Category) Normalize() {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Emoji = strings.TrimSpace(c.Emoji)
+	c.Color = strings.TrimSpace(c.Color)
+	if c.Emoji == "" {
+		c.Emoji = DefaultCategoryEmoji
+	}
+	if c.Color == "" {
+		c.Color = DefaultCategoryColor
+	}
+}
+
+// Validate checks that the category can be stored and used in the bot callbacks
+func (c Category) Validate() error {
+	switch {
+	case c.Name == "":
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidCategory)
+	case len(c.Name) > MaxCategoryNameLength:
+		return fmt.Errorf("%w: name cannot be longer than %d characters", ErrInvalidCategory, MaxCategoryNameLength)
+	case strings.Contains(c.Name, "."):
+		return fmt.Errorf("%w: name cannot contain dots", ErrInvalidCategory)
+	case strings.EqualFold(c.Name, "all"):
+		return fmt.Errorf("%w: name %q is reserved", ErrInvalidCategory, c.Name)
+	case c.Type != TypeIncome && c.Type != TypeExpense:
+		return fmt.Errorf("%w: type must be Income or Expense", ErrInvalidCategory)
+	case len(c.Emoji) > 16:
+		return fmt.Errorf("%w: emoji is too long", ErrInvalidCategory)
+	case !categoryColorRegex.MatchString(c.Color):
+		return fmt.Errorf("%w: colour must be in the #rrggbb format", ErrInvalidCategory)
+	}
+	return nil
+}
+
+// Categories is the list of categories of a user
+type Categories []Category
+
+// OfType returns the active (not archived) categories of the given type,
+// or of both types when it is empty
+func (cs Categories) OfType(t TransactionType) Categories {
+	var res Categories
+	for _, c := range cs {
+		if c.Archived || (t != "" && c.Type != t) {
+			continue
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// Names returns the names of the categories
+func (cs Categories) Names() []string {
+	names := make([]string, len(cs))
+	for i, c := range cs {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Find returns the category with the given name, archived ones included
+func (cs Categories) Find(name string) (Category, bool) {
+	for _, c := range cs {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Category{}, false
+}
+
+// TransferEmoji is the emoji of the transfers between accounts
+const TransferEmoji = "🔁"
+
+// Emoji returns the emoji of the category with the given name, or the default one
+func (cs Categories) Emoji(name TransactionCategory) string {
+	switch name {
+	case CategoryTransfer:
+		return TransferEmoji
+	case CategorySettlement:
+		return SettlementEmoji
+	}
+	if c, ok := cs.Find(string(name)); ok {
+		return c.Emoji
+	}
+	return DefaultCategoryEmoji
+}
+
+// Fallback returns the category used when none can be inferred for a
+// transaction: OtherExpenses/OtherIncomes when still active, otherwise the
+// last active category of the type
+func (cs Categories) Fallback(t TransactionType) (TransactionCategory, bool) {
+	active := cs.OfType(t)
+	if len(active) == 0 {
+		return "", false
+	}
+
+	fallback := CategoryOtherExpenses
+	if t == TypeIncome {
+		fallback = CategoryOtherIncomes
+	}
+	if _, ok := active.Find(string(fallback)); ok {
+		return fallback, true
+	}
+
+	return TransactionCategory(active[len(active)-1].Name), true
+}
+
+// defaultCategories are seeded for every user
+var defaultCategories = Categories{
+	{Name: string(CategorySalary), Type: TypeIncome, Emoji: "💵", Color: "#59a14f"},
+	{Name: string(CategoryOtherIncomes), Type: TypeIncome, Emoji: "💵", Color: "#8cd17d"},
+	{Name: string(CategoryCar), Type: TypeExpense, Emoji: "🚗", Color: "#4e79a7"},
+	{Name: string(CategoryClothes), Type: TypeExpense, Emoji: "👕", Color: "#f28e2b"},
+	{Name: string(CategoryGrocery), Type: TypeExpense, Emoji: "🛒", Color: "#e15759"},
+	{Name: string(CategoryHouse), Type: TypeExpense, Emoji: "🏠", Color: "#76b7b2"},
+	{Name: string(CategoryBills), Type: TypeExpense, Emoji: "📄", Color: "#edc949"},
+	{Name: string(CategoryEntertainment), Type: TypeExpense, Emoji: "🎭", Color: "#af7aa1"},
+	{Name: string(CategorySport), Type: TypeExpense, Emoji: "🏋️", Color: "#ff9da7"},
+	{Name: string(CategoryEatingOut), Type: TypeExpense, Emoji: "🍽️", Color: "#9c755f"},
+	{Name: string(CategoryTransport), Type: TypeExpense, Emoji: "🚆", Color: "#bab0ab"},
+	{Name: string(CategoryLearning), Type: TypeExpense, Emoji: "📚", Color: "#b6992d"},
+	{Name: string(CategoryToiletry), Type: TypeExpense, Emoji: "🚿", Color: "#d37295"},
+	{Name: string(CategoryHealth), Type: TypeExpense, Emoji: "🏥", Color: "#499894"},
+	{Name: string(CategoryTech), Type: TypeExpense, Emoji: "💻", Color: "#a0cbe8"},
+	{Name: string(CategoryGifts), Type: TypeExpense, Emoji: "🎁", Color: "#fabfd2"},
+	{Name: string(CategoryTravel), Type: TypeExpense, Emoji: "✈️", Color: "#86bcb6"},
+	{Name: string(CategoryPets), Type: TypeExpense, Emoji: "🐈", Color: "#e19d9a"},
+	{Name: string(CategoryOtherExpenses), Type: TypeExpense, Emoji: "📌", Color: "#79706e"},
+}
+
+// DefaultCategories returns the default categories for the given user
+func DefaultCategories(tgID int64) Categories {
+	cs := make(Categories, len(defaultCategories))
+	for i, c := range defaultCategories {
+		c.TgID = tgID
+		cs[i] = c
+	}
+	return cs
+}
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCategoryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cat     Category
+		wantErr bool
+	}{
+		{
+			name: "valid category",
+			cat:  Category{Name: "Kids", Type: TypeExpense, Emoji: "👶", Color: "#4e79a7"},
+		},
+		{
+			name:    "empty name",
+			cat:     Category{Name: "", Type: TypeExpense, Emoji: "👶", Color: "#4e79a7"},
+			wantErr: true,
+		},
+		{
+			name:    "name too long",
+			cat:     Category{Name: strings.Repeat("a", MaxCategoryNameLength+1), Type: TypeExpense, Emoji: "👶", Color: "#4e79a7"},
+			wantErr: true,
+		},
+		{
+			name:    "name with dot",
+			cat:     Category{Name: "Kids.School", Type: TypeExpense, Emoji: "👶", Color: "#4e79a7"},
+			wantErr: true,
+		},
+		{
+			name:    "reserved name",
+			cat:     Category{Name: "All", Type: TypeExpense, Emoji: "👶", Color: "#4e79a7"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid type",
+			cat:     Category{Name: "Kids", Type: "Transfer", Emoji: "👶", Color: "#4e79a7"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid colour",
+			cat:     Category{Name: "Kids", Type: TypeExpense, Emoji: "👶", Color: "blue"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cat.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCategory) {
+				t.Errorf("Validate() error = %v, want ErrInvalidCategory", err)
+			}
+		})
+	}
+}
+
+func TestCategoryNormalize(t *testing.T) {
+	c := Category{Name: "  Kids ", Type: TypeExpense}
+	c.Normalize()
+
+	if c.Name != "Kids" {
+		t.Errorf("Name = %q, want %q", c.Name, "Kids")
+	}
+	if c.Emoji != DefaultCategoryEmoji {
+		t.Errorf("Emoji = %q, want %q", c.Emoji, DefaultCategoryEmoji)
+	}
+	if c.Color != DefaultCategoryColor {
+		t.Errorf("Color = %q, want %q", c.Color, DefaultCategoryColor)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate() after Normalize() error = %v", err)
+	}
+}
+
+func TestDefaultCategories(t *testing.T) {
+	categories := DefaultCategories(42)
+
+	if len(categories) != len(GetTransactionCategories()) {
+		t.Fatalf("got %d default categories, want %d", len(categories), len(GetTransactionCategories()))
+	}
+	for _, c := range categories {
+		if c.TgID != 42 {
+			t.Errorf("%s: TgID = %d, want 42", c.Name, c.TgID)
+		}
+		if err := c.Validate(); err != nil {
+			t.Errorf("%s: Validate() error = %v", c.Name, err)
+		}
+	}
+
+	if got := categories.OfType(TypeIncome).Names(); len(got) != len(GetIncomeCategories()) {
+		t.Errorf("income categories = %v, want %v", got, GetIncomeCategories())
+	}
+	if got := categories.OfType(TypeExpense).Names(); len(got) != len(GetExpenseCategories()) {
+		t.Errorf("expense categories = %v, want %v", got, GetExpenseCategories())
+	}
+}
+
+func TestCategoriesOfType(t *testing.T) {
+	categories := Categories{
+		{Name: "Salary", Type: TypeIncome},
+		{Name: "Kids", Type: TypeExpense},
+		{Name: "Old", Type: TypeExpense, Archived: true},
+	}
+
+	tests := []struct {
+		name  string
+		tType TransactionType
+		want  []string
+	}{
+		{name: "all active", tType: "", want: []string{"Salary", "Kids"}},
+		{name: "income", tType: TypeIncome, want: []string{"Salary"}},
+		{name: "expense without archived", tType: TypeExpense, want: []string{"Kids"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := categories.OfType(tt.tType).Names()
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("OfType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategoriesFallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories Categories
+		tType      TransactionType
+		want       TransactionCategory
+		wantOK     bool
+	}{
+		{
+			name:       "default expense fallback",
+			categories: DefaultCategories(1),
+			tType:      TypeExpense,
+			want:       CategoryOtherExpenses,
+			wantOK:     true,
+		},
+		{
+			name:       "default income fallback",
+			categories: DefaultCategories(1),
+			tType:      TypeIncome,
+			want:       CategoryOtherIncomes,
+			wantOK:     true,
+		},
+		{
+			name: "archived generic category",
+			categories: Categories{
+				{Name: "Kids", Type: TypeExpense},
+				{Name: "OtherExpenses", Type: TypeExpense, Archived: true},
+			},
+			tType:  TypeExpense,
+			want:   "Kids",
+			wantOK: true,
+		},
+		{
+			name:       "no active category",
+			categories: Categories{{Name: "Salary", Type: TypeIncome, Archived: true}},
+			tType:      TypeIncome,
+			wantOK:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.categories.Fallback(tt.tType)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Fallback() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCategoriesEmoji(t *testing.T) {
+	categories := append(DefaultCategories(1), Category{Name: "Kids", Type: TypeExpense, Emoji: "👶"})
+
+	tests := []struct {
+		category TransactionCategory
+		want     string
+	}{
+		{CategoryCar, "🚗"},
+		{"Kids", "👶"},
+		{"Unknown", DefaultCategoryEmoji},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.category), func(t *testing.T) {
+			if got := categories.Emoji(tt.category); got != tt.want {
+				t.Errorf("Emoji() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxEnvelopeNoteLength is the maximum length in bytes of the note of an
+// allocation
+const MaxEnvelopeNoteLength = 255
+
+var (
+	ErrInvalidEnvelope  = errors.New("invalid allocation")
+	ErrEnvelopeNotFound = errors.New("envelope not found")
+	ErrEnvelopeFunds    = errors.New("not enough money available in the envelope")
+	ErrEnvelopeIncome   = errors.New("money can only be assigned from an income")
+)
+
+// Envelope is where the income is assigned in zero-based budgeting: one of
+// the expense categories of the user, or one of their goals when GoalID is set
+type Envelope struct {
+	Category TransactionCategory
+	GoalID   int64
+}
+
+// IsGoal reports whether the envelope is a goal rather than a category
+func (e Envelope) IsGoal() bool {
+	return e.GoalID != 0
+}
+
+// EnvelopeAllocation represents the envelope_allocations table structure,
+// money of the income assigned to an envelope in a month ("2006-01"). A
+// negative amount takes money back out of it, and moving money between two
+// envelopes is a pair of allocations with opposite amounts. TransactionID is
+// the income the money comes from, when assigned as it arrived.
+type EnvelopeAllocation struct {
+	ID            int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID          int64               `gorm:"column:tg_id;not null;index"`
+	Month         string              `gorm:"column:month;not null;type:char(7)"`
+	Category      TransactionCategory `gorm:"column:category;not null;size:32;default:''"`
+	GoalID        *int64              `gorm:"column:goal_id"`
+	Amount        Money               `gorm:"column:amount;not null;type:decimal(15,2)"`
+	TransactionID *int64              `gorm:"column:transaction_id"`
+	Note          string              `gorm:"column:note;not null;size:255;default:''"`
+	CreatedAt     time.Time           `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName overrides the table name
+func (EnvelopeAllocation) TableName() string {
+	return "envelope_allocations"
+}
+
+// NewEnvelopeAllocation returns the allocation of amount to an envelope in a
+// month
+func NewEnvelopeAllocation(tgID int64, month string, envelope Envelope, amount Money) EnvelopeAllocation {
+	a := EnvelopeAllocation{TgID: tgID, Month: month, Category: envelope.Category, Amount: amount}
+	if envelope.IsGoal() {
+		goalID := envelope.GoalID
+		a.GoalID = &goalID
+		a.Category = ""
+	}
+	return a
+}
+
+// Envelope returns the envelope the money is assigned to
+func (a EnvelopeAllocation) Envelope() Envelope {
+	if a.GoalID != nil {
+		return Envelope{GoalID: *a.GoalID}
+	}
+	return Envelope{Category: a.Category}
+}
+
+// Validate checks that the allocation can be stored
+func (a EnvelopeAllocation) Validate() error {
+	if _, err := time.Parse("2006-01", a.Month); err != nil {
+		return fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidEnvelope)
+	}
+	switch {
+	case a.Amount == 0:
+		return fmt.Errorf("%w: amount cannot be zero", ErrInvalidEnvelope)
+	case (a.Category == "") == (a.GoalID == nil):
+		return fmt.Errorf("%w: either a category or a goal is required", ErrInvalidEnvelope)
+	case len(a.Note) > MaxEnvelopeNoteLength:
+		return fmt.Errorf("%w: note cannot be longer than %d characters", ErrInvalidEnvelope, MaxEnvelopeNoteLength)
+	}
+	return nil
+}
+
+// EnvelopeBalance is the state of an envelope in a month: what was assigned
+// to it and spent from it in the month, and what is available, all that was
+// ever assigned to it minus all that was spent from it since. Goals are never
+// spent from, their money stays set aside.
+type EnvelopeBalance struct {
+	Envelope
+	// Name is the category or the name of the goal
+	Name      string
+	Assigned  Money
+	Spent     Money
+	Available Money
+}
+
+// EnvelopeLedger is the zero-based budget of a month: the income received in
+// it, the money assigned in it, and the income received so far that has not
+// been assigned yet, zero when every unit of it has a job. Negative when more
+// than the income was assigned.
+type EnvelopeLedger struct {
+	Month      string
+	Income     Money
+	Assigned   Money
+	Unassigned Money
+	Envelopes  []EnvelopeBalance
+}
+
+// Envelope returns the balance of an envelope in the ledger, a zero one if no
+// money was ever assigned to it
+func (l EnvelopeLedger) Envelope(envelope Envelope) EnvelopeBalance {
+	for _, e := range l.Envelopes {
+		if e.Envelope == envelope {
+			return e
+		}
+	}
+	return EnvelopeBalance{Envelope: envelope, Name: string(envelope.Category)}
+}
+
+// NewEnvelopeLedger builds the ledger of a month ("2006-01") from the income
+// of each month, the allocations, and the expenses of each month by category,
+// all keyed by month. Only the months up to the one of the ledger count, and
+// the expenses of a category only from the first month money was assigned to
+// it.
+// Categories come first by name, then the goals in the order they were
+// created.
+func NewEnvelopeLedger(month string, income map[string]Money, allocations []EnvelopeAllocation, spent map[string]map[TransactionCategory]Money) EnvelopeLedger {
+	ledger := EnvelopeLedger{Month: month, Income: income[month]}
+
+	var totalIncome, totalAssigned Money
+	for m, amount := range income {
+		if m <= month {
+			totalIncome += amount
+		}
+	}
+
+	balances := make(map[Envelope]*EnvelopeBalance)
+	first := make(map[Envelope]string)
+	for _, a := range allocations {
+		if a.Month > month {
+			continue
+		}
+		envelope := a.Envelope()
+		balance, ok := balances[envelope]
+		if !ok {
+			balance = &EnvelopeBalance{Envelope: envelope, Name: string(envelope.Category)}
+			balances[envelope] = balance
+		}
+		if f, ok := first[envelope]; !ok || a.Month < f {
+			first[envelope] = a.Month
+		}
+
+		totalAssigned += a.Amount
+		balance.Available += a.Amount
+		if a.Month == month {
+			ledger.Assigned += a.Amount
+			balance.Assigned += a.Amount
+		}
+	}
+
+	for envelope, balance := range balances {
+		if envelope.IsGoal() {
+			continue
+		}
+		for m, categories := range spent {
+			if m >= first[envelope] && m <= month {
+				balance.Available -= categories[envelope.Category]
+			}
+		}
+		balance.Spent = spent[month][envelope.Category]
+	}
+
+	ledger.Envelopes = make([]EnvelopeBalance, 0, len(balances))
+	for _, balance := range balances {
+		ledger.Envelopes = append(ledger.Envelopes, *balance)
+	}
+	sort.Slice(ledger.Envelopes, func(i, j int) bool {
+		a, b := ledger.Envelopes[i], ledger.Envelopes[j]
+		if a.IsGoal() != b.IsGoal() {
+			return !a.IsGoal()
+		}
+		if a.IsGoal() {
+			return a.GoalID < b.GoalID
+		}
+		return a.Category < b.Category
+	})
+
+	ledger.Unassigned = totalIncome - totalAssigned
+	return ledger
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeAllocationValidate(t *testing.T) {
+	goalID := int64(4)
+	tests := []struct {
+		name       string
+		allocation EnvelopeAllocation
+		wantErr    bool
+	}{
+		{name: "category", allocation: EnvelopeAllocation{Month: "2026-05", Category: "Grocery", Amount: NewMoney(300)}},
+		{name: "goal", allocation: EnvelopeAllocation{Month: "2026-05", GoalID: &goalID, Amount: NewMoney(200)}},
+		{name: "taken back", allocation: EnvelopeAllocation{Month: "2026-05", Category: "Grocery", Amount: NewMoney(-50)}},
+		{name: "bad month", allocation: EnvelopeAllocation{Month: "05-2026", Category: "Grocery", Amount: NewMoney(300)}, wantErr: true},
+		{name: "zero amount", allocation: EnvelopeAllocation{Month: "2026-05", Category: "Grocery"}, wantErr: true},
+		{name: "no envelope", allocation: EnvelopeAllocation{Month: "2026-05", Amount: NewMoney(300)}, wantErr: true},
+		{name: "both envelopes", allocation: EnvelopeAllocation{Month: "2026-05", Category: "Grocery", GoalID: &goalID, Amount: NewMoney(300)}, wantErr: true},
+		{name: "long note", allocation: EnvelopeAllocation{Month: "2026-05", Category: "Grocery", Amount: NewMoney(300), Note: strings.Repeat("a", MaxEnvelopeNoteLength+1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.allocation.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidEnvelope) {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewEnvelopeAllocation(t *testing.T) {
+	a := NewEnvelopeAllocation(1, "2026-05", Envelope{Category: "Grocery", GoalID: 4}, NewMoney(100))
+	if a.Category != "" || a.GoalID == nil || *a.GoalID != 4 {
+		t.Errorf("NewEnvelopeAllocation() = %+v, want the goal only", a)
+	}
+	if e := a.Envelope(); e != (Envelope{GoalID: 4}) {
+		t.Errorf("Envelope() = %+v, want goal 4", e)
+	}
+
+	a = NewEnvelopeAllocation(1, "2026-05", Envelope{Category: "Grocery"}, NewMoney(100))
+	if e := a.Envelope(); e != (Envelope{Category: "Grocery"}) || a.GoalID != nil {
+		t.Errorf("Envelope() = %+v, want Grocery", e)
+	}
+}
+
+func TestNewEnvelopeLedger(t *testing.T) {
+	grocery := Envelope{Category: "Grocery"}
+	eating := Envelope{Category: "Eating out"}
+	holiday := Envelope{GoalID: 4}
+
+	income := map[string]Money{"2026-04": NewMoney(2000), "2026-05": NewMoney(2500), "2026-06": NewMoney(2500)}
+	allocations := []EnvelopeAllocation{
+		NewEnvelopeAllocation(1, "2026-04", grocery, NewMoney(300)),
+		NewEnvelopeAllocation(1, "2026-04", holiday, NewMoney(500)),
+		NewEnvelopeAllocation(1, "2026-05", grocery, NewMoney(300)),
+		NewEnvelopeAllocation(1, "2026-05", eating, NewMoney(150)),
+		// Moved from Grocery to Eating out
+		NewEnvelopeAllocation(1, "2026-05", grocery, NewMoney(-50)),
+		NewEnvelopeAllocation(1, "2026-05", eating, NewMoney(50)),
+		NewEnvelopeAllocation(1, "2026-06", grocery, NewMoney(1000)),
+	}
+	spent := map[string]map[TransactionCategory]Money{
+		// Eating out was spent from before it became an envelope
+		"2026-04": {"Grocery": NewMoney(250.5), "Eating out": NewMoney(80)},
+		"2026-05": {"Grocery": NewMoney(320), "Eating out": NewMoney(230), "Rent": NewMoney(900)},
+	}
+
+	ledger := NewEnvelopeLedger("2026-05", income, allocations, spent)
+
+	if ledger.Month != "2026-05" || ledger.Income != NewMoney(2500) || ledger.Assigned != NewMoney(450) {
+		t.Errorf("ledger = %s, income %v, assigned %v, want 2026-05, 2500, 450", ledger.Month, ledger.Income, ledger.Assigned)
+	}
+	// 4500 income - 800 assigned in April - 450 in May
+	if ledger.Unassigned != NewMoney(3250) {
+		t.Errorf("Unassigned = %v, want 3250", ledger.Unassigned)
+	}
+
+	want := []EnvelopeBalance{
+		{Envelope: eating, Name: "Eating out", Assigned: NewMoney(200), Spent: NewMoney(230), Available: NewMoney(-30)},
+		{Envelope: grocery, Name: "Grocery", Assigned: NewMoney(250), Spent: NewMoney(320), Available: NewMoney(-20.5)},
+		{Envelope: holiday, Assigned: 0, Spent: 0, Available: NewMoney(500)},
+	}
+	if len(ledger.Envelopes) != len(want) {
+		t.Fatalf("Envelopes = %+v, want %+v", ledger.Envelopes, want)
+	}
+	for i, w := range want {
+		if ledger.Envelopes[i] != w {
+			t.Errorf("Envelopes[%d] = %+v, want %+v", i, ledger.Envelopes[i], w)
+		}
+	}
+
+	if got := ledger.Envelope(Envelope{Category: "Rent"}); got.Available != 0 || got.Name != "Rent" {
+		t.Errorf("Envelope(Rent) = %+v, want an empty one", got)
+	}
+
+	empty := NewEnvelopeLedger("2026-03", income, allocations, spent)
+	if empty.Unassigned != 0 || len(empty.Envelopes) != 0 {
+		t.Errorf("ledger before the first month = %+v, want empty", empty)
+	}
+}
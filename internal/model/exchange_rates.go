@@ -0,0 +1,144 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrExchangeRateNotFound is returned when no rate (direct, inverse or through a pivot) links two currencies
+var ErrExchangeRateNotFound = errors.New("exchange rate not found")
+
+// ExchangeRate represents the exchange_rates table structure: 1 Base = Rate Quote
+type ExchangeRate struct {
+	ID        int64        `gorm:"column:id;primaryKey;autoIncrement"`
+	Base      CurrencyType `gorm:"column:base;not null;type:currency_type"`
+	Quote     CurrencyType `gorm:"column:quote;not null;type:currency_type"`
+	Rate      float64      `gorm:"column:rate;not null;type:decimal(18,8)"`
+	UpdatedAt time.Time    `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName overrides the table name
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+// RateTable is the set of known exchange rates used to convert amounts
+type RateTable []ExchangeRate
+
+// rate returns how many units of "to" one unit of "from" is worth, looking
+// for a direct pair first and then for the inverse one.
+func (rt RateTable) rate(from, to CurrencyType) (float64, bool) {
+	for _, r := range rt {
+		if r.Base == from && r.Quote == to && r.Rate > 0 {
+			return r.Rate, true
+		}
+	}
+	for _, r := range rt {
+		if r.Base == to && r.Quote == from && r.Rate > 0 {
+			return 1 / r.Rate, true
+		}
+	}
+	return 0, false
+}
+
+// Rate returns the exchange rate between two currencies, going through a
+// single pivot currency when there is no direct or inverse pair.
+func (rt RateTable) Rate(from, to CurrencyType) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	if r, ok := rt.rate(from, to); ok {
+		return r, nil
+	}
+
+	for _, pivot := range GetCurrencyTypes() {
+		p := CurrencyType(pivot)
+		if p == from || p == to {
+			continue
+		}
+		first, ok := rt.rate(from, p)
+		if !ok {
+			continue
+		}
+		second, ok := rt.rate(p, to)
+		if !ok {
+			continue
+		}
+		return first * second, nil
+	}
+
+	return 0, fmt.Errorf("%w: %s to %s", ErrExchangeRateNotFound, from, to)
+}
+
+// Convert converts an amount between two currencies, rounded to 2 decimal places
+func (rt RateTable) Convert(amount Money, from, to CurrencyType) (Money, error) {
+	r, err := rt.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return amount.Mul(r), nil
+}
+
+// ConvertAmount converts an amount into another currency, rounded to 2
+// decimal places
+func (rt RateTable) ConvertAmount(amount Amount, to CurrencyType) (Amount, error) {
+	converted, err := rt.Convert(amount.Money, amount.Currency, to)
+	if err != nil {
+		return Amount{}, err
+	}
+	return NewAmount(converted, to), nil
+}
+
+// RateSheet is the JSON document used to load exchange rates, either from a
+// local file or from the admin endpoint, e.g.:
+//
+//	{ "base": "EUR", "rates": { "USD": 1.08, "GBP": 0.85 } }
+type RateSheet struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// ParseRateSheet decodes and validates a rate sheet, returning one ExchangeRate per quote
+func ParseRateSheet(r io.Reader) ([]ExchangeRate, error) {
+	var sheet RateSheet
+	if err := json.NewDecoder(r).Decode(&sheet); err != nil {
+		return nil, fmt.Errorf("failed to decode rate sheet: %w", err)
+	}
+
+	return sheet.ExchangeRates()
+}
+
+// ExchangeRates validates the sheet and converts it into exchange rates
+func (s RateSheet) ExchangeRates() ([]ExchangeRate, error) {
+	if !IsValidCurrency(s.Base) {
+		return nil, fmt.Errorf("invalid base currency %q", s.Base)
+	}
+
+	for quote := range s.Rates {
+		if !IsValidCurrency(quote) {
+			return nil, fmt.Errorf("invalid quote currency %q", quote)
+		}
+	}
+
+	rates := make([]ExchangeRate, 0, len(s.Rates))
+	for _, quote := range GetCurrencyTypes() {
+		rate, ok := s.Rates[quote]
+		if !ok || quote == s.Base {
+			continue
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid rate %v for %s", rate, quote)
+		}
+		rates = append(rates, ExchangeRate{
+			Base:  CurrencyType(s.Base),
+			Quote: CurrencyType(quote),
+			Rate:  rate,
+		})
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRateTableConvert(t *testing.T) {
+	rates := RateTable{
+		{Base: CurrencyEUR, Quote: CurrencyUSD, Rate: 1.25},
+		{Base: CurrencyEUR, Quote: CurrencyGBP, Rate: 0.8},
+	}
+
+	tests := []struct {
+		name    string
+		amount  float64
+		from    CurrencyType
+		to      CurrencyType
+		want    float64
+		wantErr error
+	}{
+		{
+			name:   "same currency",
+			amount: 10,
+			from:   CurrencyEUR,
+			to:     CurrencyEUR,
+			want:   10,
+		},
+		{
+			name:   "direct pair",
+			amount: 10,
+			from:   CurrencyEUR,
+			to:     CurrencyUSD,
+			want:   12.5,
+		},
+		{
+			name:   "inverse pair",
+			amount: 12.5,
+			from:   CurrencyUSD,
+			to:     CurrencyEUR,
+			want:   10,
+		},
+		{
+			name:   "through pivot",
+			amount: 10,
+			from:   CurrencyUSD,
+			to:     CurrencyGBP,
+			want:   6.4,
+		},
+		{
+			name:   "rounded to cents",
+			amount: 1,
+			from:   CurrencyGBP,
+			to:     CurrencyUSD,
+			want:   1.56,
+		},
+		{
+			name:    "missing rate",
+			amount:  10,
+			from:    CurrencyEUR,
+			to:      CurrencyJPY,
+			wantErr: ErrExchangeRateNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(NewMoney(tt.amount), tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Convert() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != NewMoney(tt.want) {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateTableConvertAmount(t *testing.T) {
+	rates := RateTable{{Base: CurrencyEUR, Quote: CurrencyUSD, Rate: 1.25}}
+
+	got, err := rates.ConvertAmount(NewAmount(1000, CurrencyEUR), CurrencyUSD)
+	if err != nil || got != NewAmount(1250, CurrencyUSD) {
+		t.Fatalf("ConvertAmount() = %v, %v, want 12.50 USD", got, err)
+	}
+	if _, err := rates.ConvertAmount(got, CurrencyJPY); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Fatalf("ConvertAmount() error = %v, want ErrExchangeRateNotFound", err)
+	}
+}
+
+func TestParseRateSheet(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:  "valid sheet",
+			input: `{"base": "EUR", "rates": {"USD": 1.08, "GBP": 0.85}}`,
+			want:  2,
+		},
+		{
+			name:  "base in rates is skipped",
+			input: `{"base": "EUR", "rates": {"EUR": 1, "USD": 1.08}}`,
+			want:  1,
+		},
+		{
+			name:    "invalid base",
+			input:   `{"base": "BTC", "rates": {"USD": 1.08}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid quote",
+			input:   `{"base": "EUR", "rates": {"XYZ": 1.08}}`,
+			wantErr: true,
+		},
+		{
+			name:    "non positive rate",
+			input:   `{"base": "EUR", "rates": {"USD": 0}}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed json",
+			input:   `{"base": "EUR"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateSheet(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRateSheet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("ParseRateSheet() returned %d rates, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrencySymbol(t *testing.T) {
+	tests := []struct {
+		currency CurrencyType
+		want     string
+	}{
+		{CurrencyEUR, "€"},
+		{CurrencyUSD, "$"},
+		{CurrencyGBP, "£"},
+		{CurrencyJPY, "¥"},
+		{CurrencyCHF, "CHF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.currency), func(t *testing.T) {
+			if got := tt.currency.Symbol(); got != tt.want {
+				t.Errorf("Symbol() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxGoalNameLength is the maximum length in bytes of a goal name
+const MaxGoalNameLength = 64
+
+// MaxContributionNoteLength is the maximum length in bytes of the note of a
+// manual contribution
+const MaxContributionNoteLength = 255
+
+// daysPerMonth is the average length of a month, used to express the saving
+// rate of a goal per month
+const daysPerMonth = 365.25 / 12
+
+var (
+	ErrGoalNotFound         = errors.New("goal not found")
+	ErrGoalExists           = errors.New("goal already exists")
+	ErrInvalidGoal          = errors.New("invalid goal")
+	ErrInvalidContribution  = errors.New("invalid contribution")
+	ErrContributionNotFound = errors.New("contribution not found")
+	ErrGoalTagInUse         = errors.New("the tag is already linked to another goal")
+)
+
+// Goal represents the goals table structure, an amount the user saves
+// towards, e.g. a holiday or an emergency fund. TargetAmount is in the base
+// currency of the user, as the contributions, and both are converted when it
+// changes. When Tag is set, every
+// transaction with that tag contributes its amount to the goal, whatever its
+// type, on top of the manual contributions.
+type Goal struct {
+	ID           int64        `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID         int64        `gorm:"column:tg_id;not null;index"`
+	Name         string       `gorm:"column:name;not null;size:64"`
+	TargetAmount Money        `gorm:"column:target_amount;not null;type:decimal(15,2)"`
+	Currency     CurrencyType `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	Deadline     *time.Time   `gorm:"column:deadline;type:date"`
+	Tag          string       `gorm:"column:tag;not null;size:32;default:''"`
+	CreatedAt    time.Time    `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time    `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName overrides the table name
+func (Goal) TableName() string {
+	return "goals"
+}
+
+// Normalize trims the name, normalizes the tag as NormalizeTagName and
+// truncates the deadline to a calendar day
+func (g *Goal) Normalize() {
+	g.Name = strings.TrimSpace(g.Name)
+	g.Tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(g.Tag), "#"))
+	if g.Deadline != nil {
+		d := DateOf(*g.Deadline)
+		g.Deadline = &d
+	}
+}
+
+// Validate checks that the goal can be stored
+func (g Goal) Validate() error {
+	switch {
+	case g.Name == "":
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidGoal)
+	case len(g.Name) > MaxGoalNameLength:
+		return fmt.Errorf("%w: name cannot be longer than %d characters", ErrInvalidGoal, MaxGoalNameLength)
+	case g.TargetAmount <= 0:
+		return fmt.Errorf("%w: target amount must be positive", ErrInvalidGoal)
+	case !IsValidCurrency(string(g.Currency)):
+		return fmt.Errorf("%w: unsupported currency %q", ErrInvalidGoal, g.Currency)
+	}
+	if g.Tag != "" {
+		if _, err := NormalizeTagName(g.Tag); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidGoal, err)
+		}
+	}
+	return nil
+}
+
+// GoalContribution represents the goal_contributions table structure, an
+// amount manually added to a goal. A negative amount withdraws from it.
+type GoalContribution struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	GoalID    int64     `gorm:"column:goal_id;not null;index"`
+	TgID      int64     `gorm:"column:tg_id;not null"`
+	Amount    Money     `gorm:"column:amount;not null;type:decimal(15,2)"`
+	Date      time.Time `gorm:"column:date;not null;type:date"`
+	Note      string    `gorm:"column:note;not null;size:255;default:''"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName overrides the table name
+func (GoalContribution) TableName() string {
+	return "goal_contributions"
+}
+
+// Validate checks that the contribution can be stored
+func (c GoalContribution) Validate() error {
+	switch {
+	case c.Amount == 0:
+		return fmt.Errorf("%w: amount cannot be zero", ErrInvalidContribution)
+	case c.Date.IsZero():
+		return fmt.Errorf("%w: date is required", ErrInvalidContribution)
+	case len(c.Note) > MaxContributionNoteLength:
+		return fmt.Errorf("%w: note cannot be longer than %d characters", ErrInvalidContribution, MaxContributionNoteLength)
+	}
+	return nil
+}
+
+// GoalEntry is a contribution to a goal, either a manual one or a
+// transaction tagged with the tag of the goal
+type GoalEntry struct {
+	Date           time.Time
+	Amount         Money
+	Note           string
+	ContributionID *int64
+	TransactionID  *int64
+}
+
+// Target returns the target amount of the goal in its currency
+func (g Goal) Target() Amount {
+	return NewAmount(g.TargetAmount, g.Currency)
+}
+
+// ConvertTo sets the target amount of the goal and the amounts of its manual
+// contributions in another currency, e.g. the new base currency of the user
+func (g *Goal) ConvertTo(currency CurrencyType, rates RateTable, contributions []GoalContribution) error {
+	target, err := rates.ConvertAmount(g.Target(), currency)
+	if err != nil {
+		return err
+	}
+	amounts := make([]Amount, len(contributions))
+	for i, c := range contributions {
+		if amounts[i], err = rates.ConvertAmount(NewAmount(c.Amount, g.Currency), currency); err != nil {
+			return err
+		}
+	}
+
+	g.TargetAmount = target.Money
+	g.Currency = target.Currency
+	for i := range contributions {
+		contributions[i].Amount = amounts[i].Money
+	}
+	return nil
+}
+
+// GoalEntries merges the manual contributions and the tagged transactions of
+// a goal, sorted by date. The transactions not in the currency of the goal
+// are converted into it from their original amounts with the rates.
+func GoalEntries(goal Goal, contributions []GoalContribution, transactions []Transaction, rates RateTable) ([]GoalEntry, error) {
+	entries := make([]GoalEntry, 0, len(contributions)+len(transactions))
+	for _, c := range contributions {
+		id := c.ID
+		entries = append(entries, GoalEntry{Date: c.Date, Amount: c.Amount, Note: c.Note, ContributionID: &id})
+	}
+	for _, t := range transactions {
+		amount := t.BaseAmount()
+		if amount.Currency != goal.Currency {
+			var err error
+			if amount, err = rates.ConvertAmount(t.EnteredAmount(), goal.Currency); err != nil {
+				return nil, err
+			}
+		}
+		id := t.ID
+		entries = append(entries, GoalEntry{Date: t.Date, Amount: amount.Money, Note: t.Description, TransactionID: &id})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+	return entries, nil
+}
+
+// GoalStatus tells whether a goal is expected to be reached by its deadline
+type GoalStatus string
+
+// Goal statuses
+const (
+	GoalReached    GoalStatus = "reached"
+	GoalOnTrack    GoalStatus = "on_track"
+	GoalBehind     GoalStatus = "behind"
+	GoalOverdue    GoalStatus = "overdue"
+	GoalNoDeadline GoalStatus = "no_deadline"
+)
+
+// GoalProgress is how much of a goal has been saved and the projection of
+// when it will be reached at the current contribution rate
+type GoalProgress struct {
+	Saved     Money
+	Remaining Money
+	Pct       int
+	// MonthlyRate is the average saved per month since the first contribution
+	MonthlyRate Money
+	// Required is the amount to save per month to meet the deadline, 0 when
+	// the goal has no deadline, is reached or overdue
+	Required Money
+	// Estimated is the day the target is reached at MonthlyRate, nil when
+	// the goal is reached or nothing is being saved
+	Estimated *time.Time
+	Status    GoalStatus
+}
+
+// Progress sums the entries of the goal and projects them from today. The
+// rate is averaged over at least a month, so that a single recent
+// contribution does not make any deadline look within reach.
+func (g Goal) Progress(entries []GoalEntry, today time.Time) GoalProgress {
+	var p GoalProgress
+	for _, e := range entries {
+		p.Saved += e.Amount
+	}
+	p.Remaining = max(g.TargetAmount-p.Saved, 0)
+	p.Pct = max(pct(p.Saved, g.TargetAmount), 0)
+
+	if len(entries) > 0 {
+		months := math.Max(today.Sub(entries[0].Date).Hours()/24/daysPerMonth, 1)
+		p.MonthlyRate = p.Saved.Mul(1 / months)
+	}
+
+	if p.Remaining == 0 {
+		p.Status = GoalReached
+		return p
+	}
+
+	if p.MonthlyRate > 0 {
+		days := int(math.Ceil(p.Remaining.Float64() / p.MonthlyRate.Float64() * daysPerMonth))
+		estimated := today.AddDate(0, 0, days)
+		p.Estimated = &estimated
+	}
+
+	switch {
+	case g.Deadline == nil:
+		p.Status = GoalNoDeadline
+	case g.Deadline.Before(today):
+		p.Status = GoalOverdue
+	default:
+		months := math.Max(g.Deadline.Sub(today).Hours()/24/daysPerMonth, 1)
+		p.Required = p.Remaining.Mul(1 / months)
+		if p.Estimated != nil && !p.Estimated.After(*g.Deadline) {
+			p.Status = GoalOnTrack
+		} else {
+			p.Status = GoalBehind
+		}
+	}
+	return p
+}
@@ -0,0 +1,218 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoalValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		goal    Goal
+		wantErr bool
+	}{
+		{name: "valid", goal: Goal{Name: "Holiday", TargetAmount: NewMoney(1500), Currency: CurrencyEUR, Tag: "#Holiday"}},
+		{name: "empty name", goal: Goal{Name: "  ", TargetAmount: NewMoney(1500), Currency: CurrencyEUR}, wantErr: true},
+		{name: "long name", goal: Goal{Name: strings.Repeat("a", MaxGoalNameLength+1), TargetAmount: NewMoney(1500), Currency: CurrencyEUR}, wantErr: true},
+		{name: "zero target", goal: Goal{Name: "Holiday", Currency: CurrencyEUR}, wantErr: true},
+		{name: "invalid currency", goal: Goal{Name: "Holiday", TargetAmount: NewMoney(1500), Currency: "XYZ"}, wantErr: true},
+		{name: "invalid tag", goal: Goal{Name: "Holiday", TargetAmount: NewMoney(1500), Currency: CurrencyEUR, Tag: "two words"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.goal.Normalize()
+			err := tt.goal.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidGoal) {
+				t.Errorf("Validate() error = %v, want ErrInvalidGoal", err)
+			}
+		})
+	}
+
+	goal := Goal{Name: " Holiday ", Tag: " #Holiday"}
+	goal.Normalize()
+	if goal.Name != "Holiday" || goal.Tag != "holiday" {
+		t.Errorf("Normalize() = %q/%q, want Holiday/holiday", goal.Name, goal.Tag)
+	}
+}
+
+func TestGoalEntries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 5, d, 0, 0, 0, 0, time.UTC) }
+	entries, err := GoalEntries(
+		Goal{Currency: CurrencyEUR},
+		[]GoalContribution{{ID: 1, Amount: NewMoney(100), Date: day(10)}, {ID: 2, Amount: NewMoney(-20), Date: day(1)}},
+		[]Transaction{{ID: 7, Amount: NewMoney(50), Currency: CurrencyEUR, Date: day(5), Description: "Savings"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("GoalEntries() unexpected error: %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("GoalEntries() = %d entries, want 3", len(entries))
+	}
+	if *entries[0].ContributionID != 2 || *entries[1].TransactionID != 7 || *entries[2].ContributionID != 1 {
+		t.Errorf("GoalEntries() = %+v, want them sorted by date", entries)
+	}
+	if entries[1].Note != "Savings" {
+		t.Errorf("GoalEntries() note = %q, want the transaction description", entries[1].Note)
+	}
+}
+
+func TestGoalEntriesCurrency(t *testing.T) {
+	rates := RateTable{{Base: CurrencyEUR, Quote: CurrencyUSD, Rate: 1.25}}
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	goal := Goal{Currency: CurrencyEUR}
+	// The first transaction was added after the base currency changed to USD
+	transactions := []Transaction{
+		{ID: 1, Amount: NewMoney(50), Currency: CurrencyUSD, OriginalAmount: NewMoney(50), OriginalCurrency: CurrencyUSD, Date: day},
+		{ID: 2, Amount: NewMoney(30), Currency: CurrencyEUR, OriginalAmount: NewMoney(30), OriginalCurrency: CurrencyEUR, Date: day},
+	}
+
+	entries, err := GoalEntries(goal, nil, transactions, rates)
+	if err != nil {
+		t.Fatalf("GoalEntries() unexpected error: %v", err)
+	}
+	if entries[0].Amount != NewMoney(40) || entries[1].Amount != NewMoney(30) {
+		t.Errorf("GoalEntries() = %v/%v, want 40/30 in the currency of the goal", entries[0].Amount, entries[1].Amount)
+	}
+
+	if _, err := GoalEntries(goal, nil, transactions, nil); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Errorf("GoalEntries() error = %v, want ErrExchangeRateNotFound", err)
+	}
+}
+
+func TestGoalConvertTo(t *testing.T) {
+	rates := RateTable{{Base: CurrencyEUR, Quote: CurrencyUSD, Rate: 1.25}}
+	goal := Goal{TargetAmount: NewMoney(1000), Currency: CurrencyEUR}
+	contributions := []GoalContribution{{ID: 1, Amount: NewMoney(100)}, {ID: 2, Amount: NewMoney(-20)}}
+
+	if err := goal.ConvertTo(CurrencyUSD, rates, contributions); err != nil {
+		t.Fatalf("ConvertTo() unexpected error: %v", err)
+	}
+	if goal.TargetAmount != NewMoney(1250) || goal.Currency != CurrencyUSD {
+		t.Errorf("ConvertTo() goal = %s %v, want USD 1250", goal.Currency, goal.TargetAmount)
+	}
+	if contributions[0].Amount != NewMoney(125) || contributions[1].Amount != NewMoney(-25) {
+		t.Errorf("ConvertTo() contributions = %v/%v, want 125/-25", contributions[0].Amount, contributions[1].Amount)
+	}
+
+	if err := goal.ConvertTo(CurrencyJPY, rates, contributions); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Fatalf("ConvertTo() error = %v, want ErrExchangeRateNotFound", err)
+	}
+	if goal.Currency != CurrencyUSD || contributions[0].Amount != NewMoney(125) {
+		t.Errorf("ConvertTo() changed the goal on error: %+v %+v", goal, contributions)
+	}
+}
+
+func TestGoalProgress(t *testing.T) {
+	today := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	date := func(y int, m time.Month, d int) *time.Time {
+		t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	// 600 saved over the last six months, about 100 per month
+	sixMonths := []GoalEntry{
+		{Date: *date(2025, 12, 1), Amount: NewMoney(300)},
+		{Date: *date(2026, 3, 1), Amount: NewMoney(300)},
+	}
+
+	tests := []struct {
+		name         string
+		goal         Goal
+		entries      []GoalEntry
+		wantStatus   GoalStatus
+		wantPct      int
+		wantRate     float64
+		wantEstimate bool
+		wantRequired bool
+	}{
+		{
+			name:       "no contributions and no deadline",
+			goal:       Goal{TargetAmount: NewMoney(1000)},
+			wantStatus: GoalNoDeadline,
+		},
+		{
+			name:         "on track",
+			goal:         Goal{TargetAmount: NewMoney(1000), Deadline: date(2027, 1, 1)},
+			entries:      sixMonths,
+			wantStatus:   GoalOnTrack,
+			wantPct:      60,
+			wantRate:     100.34,
+			wantEstimate: true,
+			wantRequired: true,
+		},
+		{
+			name:         "behind",
+			goal:         Goal{TargetAmount: NewMoney(1000), Deadline: date(2026, 7, 1)},
+			entries:      sixMonths,
+			wantStatus:   GoalBehind,
+			wantPct:      60,
+			wantRate:     100.34,
+			wantEstimate: true,
+			wantRequired: true,
+		},
+		{
+			name:         "a single contribution is averaged over a month",
+			goal:         Goal{TargetAmount: NewMoney(1000), Deadline: date(2026, 6, 15)},
+			entries:      []GoalEntry{{Date: today, Amount: NewMoney(500)}},
+			wantStatus:   GoalBehind,
+			wantPct:      50,
+			wantRate:     500,
+			wantEstimate: true,
+			wantRequired: true,
+		},
+		{
+			name:         "overdue",
+			goal:         Goal{TargetAmount: NewMoney(1000), Deadline: date(2026, 5, 1)},
+			entries:      sixMonths,
+			wantStatus:   GoalOverdue,
+			wantPct:      60,
+			wantRate:     100.34,
+			wantEstimate: true,
+		},
+		{
+			name:       "reached",
+			goal:       Goal{TargetAmount: NewMoney(500), Deadline: date(2026, 5, 1)},
+			entries:    sixMonths,
+			wantStatus: GoalReached,
+			wantPct:    120,
+			wantRate:   100.34,
+		},
+		{
+			name:       "withdrawn",
+			goal:       Goal{TargetAmount: NewMoney(1000), Deadline: date(2027, 1, 1)},
+			entries:    []GoalEntry{{Date: *date(2026, 1, 1), Amount: NewMoney(100)}, {Date: *date(2026, 2, 1), Amount: NewMoney(-200)}},
+			wantStatus: GoalBehind,
+			wantRate:   -20.16,
+			// Nothing is being saved, the target is never reached
+			wantRequired: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.goal.Progress(tt.entries, today)
+			if p.Status != tt.wantStatus {
+				t.Errorf("Progress() status = %s, want %s", p.Status, tt.wantStatus)
+			}
+			if p.Pct != tt.wantPct {
+				t.Errorf("Progress() pct = %d, want %d", p.Pct, tt.wantPct)
+			}
+			if p.MonthlyRate != NewMoney(tt.wantRate) {
+				t.Errorf("Progress() monthly rate = %.2f, want %.2f", p.MonthlyRate, tt.wantRate)
+			}
+			if (p.Estimated != nil) != tt.wantEstimate {
+				t.Errorf("Progress() estimated = %v, want estimate %v", p.Estimated, tt.wantEstimate)
+			}
+			if (p.Required > 0) != tt.wantRequired {
+				t.Errorf("Progress() required = %.2f, want required %v", p.Required, tt.wantRequired)
+			}
+		})
+	}
+}
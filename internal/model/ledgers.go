@@ -0,0 +1,233 @@
+package model
+
+import (
+	"crypto/rand"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxLedgerNameLength is the maximum length in bytes of a ledger name
+	MaxLedgerNameLength = 32
+	// InviteCodeLength is the number of characters of a ledger invite code
+	InviteCodeLength = 8
+)
+
+// inviteCodeAlphabet leaves out the characters easily mistaken for one another (0/O, 1/I/L)
+const inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+var (
+	ErrLedgerNotFound    = errors.New("ledger not found")
+	ErrInvalidLedger     = errors.New("invalid ledger")
+	ErrInvalidInviteCode = errors.New("invalid invite code")
+	ErrLedgerMember      = errors.New("already a member of the ledger")
+	ErrLedgerForbidden   = errors.New("your role in the ledger does not allow it")
+	ErrLedgerLastOwner   = errors.New("the ledger needs at least another owner")
+	ErrLedgerCurrency    = errors.New("the ledger currency is not your base currency")
+)
+
+// LedgerRole is the role of a member of a shared ledger
+type LedgerRole string
+
+// Ledger roles
+const (
+	// LedgerOwner manages the members, the invite code and the budget of the ledger
+	LedgerOwner LedgerRole = "owner"
+	// LedgerEditor records transactions in the ledger
+	LedgerEditor LedgerRole = "editor"
+	// LedgerViewer only sees the recaps, analytics and budget of the ledger
+	LedgerViewer LedgerRole = "viewer"
+)
+
+// Value implements the driver.Valuer interface for LedgerRole
+func (r LedgerRole) Value() (driver.Value, error) {
+	return string(r), nil
+}
+
+// Scan implements the sql.Scanner interface for LedgerRole
+func (r *LedgerRole) Scan(value any) error {
+	if value == nil {
+		return errors.New("ledger role cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid ledger role")
+	}
+
+	*r = LedgerRole(strVal)
+	return nil
+}
+
+// GetLedgerRoles returns all ledger roles
+func GetLedgerRoles() []string {
+	return []string{string(LedgerOwner), string(LedgerEditor), string(LedgerViewer)}
+}
+
+// ParseLedgerRole matches a role case-insensitively
+func ParseLedgerRole(s string) (LedgerRole, bool) {
+	for _, r := range GetLedgerRoles() {
+		if strings.EqualFold(r, strings.TrimSpace(s)) {
+			return LedgerRole(r), true
+		}
+	}
+	return "", false
+}
+
+// CanWrite reports whether the role records transactions in the ledger
+func (r LedgerRole) CanWrite() bool {
+	return r == LedgerOwner || r == LedgerEditor
+}
+
+// CanManage reports whether the role manages the members and the budget of the ledger
+func (r LedgerRole) CanManage() bool {
+	return r == LedgerOwner
+}
+
+// Next returns the role following this one, used to cycle through the roles
+// from the inline keyboards: viewer, editor, owner and viewer again
+func (r LedgerRole) Next() LedgerRole {
+	switch r {
+	case LedgerViewer:
+		return LedgerEditor
+	case LedgerEditor:
+		return LedgerOwner
+	default:
+		return LedgerViewer
+	}
+}
+
+// Ledger is a set of transactions shared by several users, e.g. a household.
+// Every member records in the ledger's currency, which is their base currency,
+// so its totals never mix currencies.
+type Ledger struct {
+	ID         int64        `gorm:"column:id;primaryKey;autoIncrement"`
+	Name       string       `gorm:"column:name;not null;size:32"`
+	Currency   CurrencyType `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	InviteCode string       `gorm:"column:invite_code;not null;size:16;uniqueIndex"`
+	CreatedBy  int64        `gorm:"column:created_by;not null"`
+	CreatedAt  time.Time    `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time    `gorm:"column:updated_at;autoUpdateTime"`
+
+	Members []LedgerMember `gorm:"foreignKey:LedgerID"`
+}
+
+// TableName overrides the table name
+func (Ledger) TableName() string {
+	return "ledgers"
+}
+
+// Normalize trims the name of the ledger
+func (l *Ledger) Normalize() {
+	l.Name = strings.Join(strings.Fields(l.Name), " ")
+}
+
+// Validate checks the name and the currency of the ledger
+func (l Ledger) Validate() error {
+	if l.Name == "" {
+		return fmt.Errorf("%w: the name is empty", ErrInvalidLedger)
+	}
+	if len(l.Name) > MaxLedgerNameLength {
+		return fmt.Errorf("%w: the name is longer than %d characters", ErrInvalidLedger, MaxLedgerNameLength)
+	}
+	if !IsValidCurrency(string(l.Currency)) {
+		return fmt.Errorf("%w: unsupported currency %q", ErrInvalidLedger, l.Currency)
+	}
+	return nil
+}
+
+// Member returns the membership of a user in the ledger
+func (l Ledger) Member(tgID int64) (LedgerMember, bool) {
+	for _, m := range l.Members {
+		if m.TgID == tgID {
+			return m, true
+		}
+	}
+	return LedgerMember{}, false
+}
+
+// CheckRoleChange reports whether the member can get the role without leaving
+// the ledger without owners, a nil role means the member leaves the ledger.
+// The last member can always leave, the ledger is then deleted.
+func (l Ledger) CheckRoleChange(tgID int64, role *LedgerRole) error {
+	member, ok := l.Member(tgID)
+	if !ok {
+		return ErrLedgerNotFound
+	}
+	if member.Role != LedgerOwner || (role != nil && *role == LedgerOwner) {
+		return nil
+	}
+	if role == nil && len(l.Members) == 1 {
+		return nil
+	}
+
+	for _, m := range l.Members {
+		if m.TgID != tgID && m.Role == LedgerOwner {
+			return nil
+		}
+	}
+	return ErrLedgerLastOwner
+}
+
+// LedgerMember is a user taking part in a ledger with a role
+type LedgerMember struct {
+	LedgerID int64      `gorm:"column:ledger_id;primaryKey"`
+	TgID     int64      `gorm:"column:tg_id;primaryKey"`
+	Role     LedgerRole `gorm:"column:role;not null;type:ledger_role;default:'editor'"`
+	JoinedAt time.Time  `gorm:"column:joined_at;autoCreateTime"`
+
+	User *User `gorm:"foreignKey:TgID;references:TgID"`
+}
+
+// TableName overrides the table name
+func (LedgerMember) TableName() string {
+	return "ledger_members"
+}
+
+// DisplayName returns the name of the member shown in recaps
+func (m LedgerMember) DisplayName() string {
+	if m.User == nil || m.User.WebAuthnDisplayName() == "" {
+		return fmt.Sprintf("User %d", m.TgID)
+	}
+	return m.User.WebAuthnDisplayName()
+}
+
+// NewInviteCode returns a random invite code for a ledger
+func NewInviteCode() (string, error) {
+	buf := make([]byte, InviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// NormalizeInviteCode uppercases an invite code typed by a user, dropping the
+// spaces and dashes used to read it out
+func NormalizeInviteCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+// Scope selects the transactions summed up by recaps, analytics and budgets:
+// the ones entered by a user or, when LedgerID is set, the ones recorded in a
+// shared ledger by any of its members
+type Scope struct {
+	TgID     int64
+	LedgerID *int64
+}
+
+// IsLedger reports whether the scope is a shared ledger
+func (s Scope) IsLedger() bool {
+	return s.LedgerID != nil
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func ledgerWith(roles ...LedgerRole) Ledger {
+	ledger := Ledger{ID: 1, Name: "Home", Currency: CurrencyEUR}
+	for i, r := range roles {
+		ledger.Members = append(ledger.Members, LedgerMember{LedgerID: 1, TgID: int64(i + 1), Role: r})
+	}
+	return ledger
+}
+
+func ptrRole(r LedgerRole) *LedgerRole { return &r }
+
+func TestLedgerCheckRoleChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		ledger  Ledger
+		tgID    int64
+		role    *LedgerRole
+		wantErr error
+	}{
+		{name: "editor becomes viewer", ledger: ledgerWith(LedgerOwner, LedgerEditor), tgID: 2, role: ptrRole(LedgerViewer)},
+		{name: "editor leaves", ledger: ledgerWith(LedgerOwner, LedgerEditor), tgID: 2},
+		{name: "owner stays owner", ledger: ledgerWith(LedgerOwner, LedgerEditor), tgID: 1, role: ptrRole(LedgerOwner)},
+		{name: "last owner demoted", ledger: ledgerWith(LedgerOwner, LedgerEditor), tgID: 1, role: ptrRole(LedgerEditor), wantErr: ErrLedgerLastOwner},
+		{name: "last owner leaves", ledger: ledgerWith(LedgerOwner, LedgerViewer), tgID: 1, wantErr: ErrLedgerLastOwner},
+		{name: "one of two owners leaves", ledger: ledgerWith(LedgerOwner, LedgerOwner), tgID: 1},
+		{name: "last member leaves", ledger: ledgerWith(LedgerOwner), tgID: 1},
+		{name: "not a member", ledger: ledgerWith(LedgerOwner), tgID: 9, wantErr: ErrLedgerNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ledger.CheckRoleChange(tt.tgID, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLedgerValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ledger  Ledger
+		wantErr bool
+	}{
+		{name: "valid", ledger: Ledger{Name: "Home", Currency: CurrencyEUR}},
+		{name: "empty name", ledger: Ledger{Name: "  ", Currency: CurrencyEUR}, wantErr: true},
+		{name: "long name", ledger: Ledger{Name: strings.Repeat("a", MaxLedgerNameLength+1), Currency: CurrencyEUR}, wantErr: true},
+		{name: "unknown currency", ledger: Ledger{Name: "Home", Currency: "XYZ"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.ledger.Normalize()
+			err := tt.ledger.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidLedger) {
+				t.Fatalf("expected ErrInvalidLedger, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLedgerRoleNext(t *testing.T) {
+	role := LedgerViewer
+	for _, want := range []LedgerRole{LedgerEditor, LedgerOwner, LedgerViewer} {
+		role = role.Next()
+		if role != want {
+			t.Fatalf("expected %s, got %s", want, role)
+		}
+	}
+}
+
+func TestLedgerRolePermissions(t *testing.T) {
+	tests := []struct {
+		role                LedgerRole
+		canWrite, canManage bool
+	}{
+		{role: LedgerOwner, canWrite: true, canManage: true},
+		{role: LedgerEditor, canWrite: true},
+		{role: LedgerViewer},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			if tt.role.CanWrite() != tt.canWrite || tt.role.CanManage() != tt.canManage {
+				t.Fatalf("expected write %v manage %v, got %v %v", tt.canWrite, tt.canManage, tt.role.CanWrite(), tt.role.CanManage())
+			}
+		})
+	}
+}
+
+func TestNewInviteCode(t *testing.T) {
+	code, err := NewInviteCode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(code) != InviteCodeLength {
+		t.Fatalf("expected %d characters, got %q", InviteCodeLength, code)
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(inviteCodeAlphabet, r) {
+			t.Fatalf("unexpected character %q in %q", r, code)
+		}
+	}
+	if NormalizeInviteCode(code) != code {
+		t.Fatalf("expected %q to be normalized already", code)
+	}
+}
+
+func TestNormalizeInviteCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "K7PX2QMA", want: "K7PX2QMA"},
+		{input: "k7px-2qma", want: "K7PX2QMA"},
+		{input: " K7PX 2QMA ", want: "K7PX2QMA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := NormalizeInviteCode(tt.input); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidMoney is returned for the values that are not amounts of money
+	ErrInvalidMoney = errors.New("invalid amount")
+	// ErrCurrencyMismatch is returned when combining amounts in different
+	// currencies, which must be converted first
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an exact amount of money in minor units, the hundredths of its
+// currency as stored in the decimal(15,2) columns. The currency is the one of
+// the record holding the amount, e.g. Transaction.Currency, and Amount pairs
+// them wherever the amounts of several records meet. Sums and differences
+// are exact, only the conversions and the percentages round, to the nearest
+// hundredth.
+//
+// Money is stored as a decimal, marshalled to JSON as a number and formatted
+// by fmt like a float64, e.g. with %.2f.
+type Money int64
+
+// MaxMoney is the largest amount the decimal(15,2) columns can hold
+const MaxMoney Money = 1e15 - 1
+
+// NewMoney returns the amount closest to a float64, rounding half away from
+// zero to the nearest hundredth
+func NewMoney(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+// ParseMoney parses a decimal amount, e.g. "12.5", "-3" or "1200,50" with a
+// decimal comma. More than two decimal places are rounded half away from zero.
+func ParseMoney(s string) (Money, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" || strings.ContainsAny(whole+frac, "+-") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > int64(MaxMoney/100) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	cents := int64(0)
+	for i, d := range frac {
+		if d < '0' || d > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+		}
+		switch {
+		case i < 2:
+			cents = cents*10 + int64(d-'0')
+		case i == 2 && d >= '5':
+			cents++
+		}
+	}
+	if len(frac) == 1 {
+		cents *= 10
+	}
+
+	m := Money(units*100 + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// Float64 returns the amount as a float64, e.g. to compute a ratio
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String returns the amount with two decimal places, e.g. "-12.50"
+func (m Money) String() string {
+	sign := ""
+	abs := int64(m)
+	if abs < 0 {
+		sign, abs = "-", -abs
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+// Format implements fmt.Formatter: %s and %v print the amount with two
+// decimal places, and the other verbs format it like a float64, e.g. %.2f
+func (m Money) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), m.String())
+	default:
+		fmt.Fprintf(f, fmt.FormatString(f, verb), m.Float64())
+	}
+}
+
+// Abs returns the absolute value of the amount
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Mul returns the amount multiplied by a factor, e.g. an exchange rate,
+// rounded half away from zero to the nearest hundredth
+func (m Money) Mul(factor float64) Money {
+	return Money(math.Round(float64(m) * factor))
+}
+
+// Percent returns pct percent of the amount, rounded half away from zero to
+// the nearest hundredth
+func (m Money) Percent(pct int) Money {
+	product := int64(m) * int64(pct)
+	if product < 0 {
+		return Money((product - 50) / 100)
+	}
+	return Money((product + 50) / 100)
+}
+
+// Split divides the amount in n parts as equal as possible, the first ones
+// taking the remaining hundredths, so that the parts always sum to the amount
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	parts := make([]Money, n)
+	share, rest := int64(m)/int64(n), int64(m)%int64(n)
+	for i := range parts {
+		parts[i] = Money(share)
+		switch {
+		case rest > 0:
+			parts[i]++
+			rest--
+		case rest < 0:
+			parts[i]--
+			rest++
+		}
+	}
+	return parts
+}
+
+// Amount is an amount of money in a currency. Its arithmetic and comparisons
+// fail with ErrCurrencyMismatch on amounts in different currencies, which are
+// converted with RateTable.ConvertAmount first.
+type Amount struct {
+	Money    Money
+	Currency CurrencyType
+}
+
+// NewAmount returns the amount of money in the currency
+func NewAmount(money Money, currency CurrencyType) Amount {
+	return Amount{Money: money, Currency: currency}
+}
+
+// String returns the amount with its currency, e.g. "12.50 EUR"
+func (a Amount) String() string {
+	return fmt.Sprintf("%s %s", a.Money, a.Currency)
+}
+
+// Add returns the sum of the amounts, in the same currency
+func (a Amount) Add(b Amount) (Amount, error) {
+	if err := a.sameCurrency(b); err != nil {
+		return Amount{}, err
+	}
+	return Amount{Money: a.Money + b.Money, Currency: a.Currency}, nil
+}
+
+// Sub returns the difference of the amounts, in the same currency
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if err := a.sameCurrency(b); err != nil {
+		return Amount{}, err
+	}
+	return Amount{Money: a.Money - b.Money, Currency: a.Currency}, nil
+}
+
+// Cmp compares the amounts, in the same currency, returning -1, 0 or +1 as
+// for cmp.Compare
+func (a Amount) Cmp(b Amount) (int, error) {
+	if err := a.sameCurrency(b); err != nil {
+		return 0, err
+	}
+	return cmp.Compare(a.Money, b.Money), nil
+}
+
+func (a Amount) sameCurrency(b Amount) error {
+	if a.Currency != b.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface for Money, as a decimal
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements the sql.Scanner interface for Money, reading the decimal
+// columns and the sums over them. NULL, e.g. the sum of no rows, is zero.
+func (m *Money) Scan(value any) error {
+	var err error
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v * 100)
+	case float64:
+		*m = NewMoney(v)
+	case []byte:
+		*m, err = ParseMoney(string(v))
+	case string:
+		*m, err = ParseMoney(v)
+	default:
+		err = fmt.Errorf("%w: unsupported type %T", ErrInvalidMoney, value)
+	}
+	return err
+}
+
+// MarshalJSON implements json.Marshaler, the amount is a number, e.g. 12.5
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strings.TrimSuffix(strings.TrimSuffix(m.String(), "0"), ".0")), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading the number exactly as
+// written. A string holding a number is accepted as well.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	// Exponents are valid JSON numbers, e.g. 1e3
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) > MaxMoney.Float64() {
+			return fmt.Errorf("%w: %s", ErrInvalidMoney, s)
+		}
+		*m = NewMoney(f)
+		return nil
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// moneys converts the amounts of a test table
+func moneys(amounts ...float64) []Money {
+	res := make([]Money, len(amounts))
+	for i, a := range amounts {
+		res[i] = NewMoney(a)
+	}
+	return res
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{input: "12.5", want: 1250},
+		{input: "12.50", want: 1250},
+		{input: "-3", want: -300},
+		{input: "+3", want: 300},
+		{input: "1200,05", want: 120005},
+		{input: ".99", want: 99},
+		{input: "0.105", want: 11},
+		{input: "0.1049", want: 10},
+		{input: " 7 ", want: 700},
+		{input: "", wantErr: true},
+		{input: "-", wantErr: true},
+		{input: ".", wantErr: true},
+		{input: "1.2.3", wantErr: true},
+		{input: "1-2", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "10000000000000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMoney(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMoney) {
+					t.Fatalf("expected ErrInvalidMoney, got %v %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ParseMoney(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyExactSums(t *testing.T) {
+	// 0.1 + 0.2 != 0.3 in floating point
+	if NewMoney(0.1)+NewMoney(0.2) != NewMoney(0.3) {
+		t.Fatal("expected exact sums")
+	}
+
+	var total Money
+	for range 1000 {
+		total += NewMoney(0.01)
+	}
+	if total != NewMoney(10) {
+		t.Fatalf("got %v, want 10.00", total)
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		amount Money
+		want   string
+	}{
+		{format: "%.2f", amount: 1250, want: "12.50"},
+		{format: "%+.2f", amount: 1250, want: "+12.50"},
+		{format: "%.0f", amount: -1250, want: "-12"},
+		{format: "%8.2f", amount: 5, want: "    0.05"},
+		{format: "%v", amount: -5, want: "-0.05"},
+		{format: "%s", amount: 100000, want: "1000.00"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprintf(tt.format, tt.amount); got != tt.want {
+			t.Errorf("Sprintf(%q, %v) = %q, want %q", tt.format, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	if got := NewMoney(10).Mul(1.0 / 3); got != 333 {
+		t.Errorf("Mul() = %v, want 333", got)
+	}
+	if got := NewMoney(0.05).Percent(50); got != 3 {
+		t.Errorf("Percent() = %v, want 3", got)
+	}
+	if got := NewMoney(-0.05).Percent(50); got != -3 {
+		t.Errorf("Percent() of a negative amount = %v, want -3", got)
+	}
+	if got := NewMoney(10).Split(3); !slices.Equal(got, []Money{334, 333, 333}) {
+		t.Errorf("Split() = %v, want [3.34 3.33 3.33]", got)
+	}
+	if got := NewMoney(-0.05).Split(2); !slices.Equal(got, []Money{-3, -2}) {
+		t.Errorf("Split() of a negative amount = %v, want [-0.03 -0.02]", got)
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a, b := NewAmount(1250, CurrencyEUR), NewAmount(250, CurrencyEUR)
+	if got, err := a.Add(b); err != nil || got != NewAmount(1500, CurrencyEUR) {
+		t.Errorf("Add() = %v, %v, want 15.00 EUR", got, err)
+	}
+	if got, err := b.Sub(a); err != nil || got != NewAmount(-1000, CurrencyEUR) {
+		t.Errorf("Sub() = %v, %v, want -10.00 EUR", got, err)
+	}
+	if got, err := a.Cmp(b); err != nil || got != 1 {
+		t.Errorf("Cmp() = %v, %v, want 1", got, err)
+	}
+	if got := a.String(); got != "12.50 EUR" {
+		t.Errorf("String() = %q, want %q", got, "12.50 EUR")
+	}
+
+	usd := NewAmount(250, CurrencyUSD)
+	if _, err := a.Add(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add() error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := a.Sub(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub() error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := a.Cmp(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp() error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoneyValueScan(t *testing.T) {
+	v, err := NewMoney(-12.5).Value()
+	if err != nil || v != "-12.50" {
+		t.Fatalf("Value() = %v, %v, want -12.50", v, err)
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		want  Money
+	}{
+		{name: "decimal bytes", value: []byte("1234.56"), want: 123456},
+		{name: "decimal string", value: "0.10", want: 10},
+		{name: "float", value: 0.1 + 0.2, want: 30},
+		{name: "integer", value: int64(7), want: 700},
+		{name: "null", value: nil, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Money(1)
+			if err := m.Scan(tt.value); err != nil || m != tt.want {
+				t.Fatalf("Scan(%v) = %v, %v, want %v", tt.value, m, err, tt.want)
+			}
+		})
+	}
+
+	var m Money
+	if err := m.Scan(true); !errors.Is(err, ErrInvalidMoney) {
+		t.Fatalf("expected ErrInvalidMoney, got %v", err)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	type payload struct {
+		Amount Money `json:"amount"`
+	}
+
+	data, err := json.Marshal(payload{Amount: NewMoney(12.5)})
+	if err != nil || string(data) != `{"amount":12.5}` {
+		t.Fatalf("Marshal() = %s, %v", data, err)
+	}
+	for amount, want := range map[Money]string{100: "1", -5: "-0.05", 0: "0", 1999: "19.99"} {
+		if data, _ := json.Marshal(amount); string(data) != want {
+			t.Errorf("Marshal(%v) = %s, want %s", amount, data, want)
+		}
+	}
+
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{input: `{"amount":19.99}`, want: 1999},
+		{input: `{"amount":"19.99"}`, want: 1999},
+		{input: `{"amount":1e2}`, want: 10000},
+		{input: `{"amount":null}`, want: 0},
+		{input: `{"amount":true}`, wantErr: true},
+		{input: `{"amount":"ten"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var p payload
+			err := json.Unmarshal([]byte(tt.input), &p)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", p.Amount)
+				}
+				return
+			}
+			if err != nil || p.Amount != tt.want {
+				t.Fatalf("Unmarshal() = %v, %v, want %v", p.Amount, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxHoldingNameLength is the maximum length in bytes of the name of a holding
+const MaxHoldingNameLength = 32
+
+var (
+	ErrHoldingNotFound   = errors.New("holding not found")
+	ErrHoldingExists     = errors.New("holding already exists")
+	ErrInvalidHolding    = errors.New("invalid holding")
+	ErrInvalidSnapshot   = errors.New("invalid balance")
+	ErrHoldingLinked     = errors.New("the balance of the holding comes from its account")
+	ErrHoldingAccountSet = errors.New("account is already tracked by another holding")
+)
+
+// HoldingKind is the kind of a holding, an asset like a bank account or a
+// liability like a mortgage
+type HoldingKind string
+
+// Holding kinds, Asset and Liability are the catch-all ones
+const (
+	HoldingBank      HoldingKind = "Bank"
+	HoldingBrokerage HoldingKind = "Brokerage"
+	HoldingProperty  HoldingKind = "Property"
+	HoldingAsset     HoldingKind = "Asset"
+	HoldingMortgage  HoldingKind = "Mortgage"
+	HoldingLoan      HoldingKind = "Loan"
+	HoldingLiability HoldingKind = "Liability"
+)
+
+// Value implements the driver.Valuer interface for HoldingKind
+func (k HoldingKind) Value() (driver.Value, error) {
+	return string(k), nil
+}
+
+// Scan implements the sql.Scanner interface for HoldingKind
+func (k *HoldingKind) Scan(value any) error {
+	if value == nil {
+		return errors.New("holding kind cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid holding kind")
+	}
+
+	*k = HoldingKind(strVal)
+	return nil
+}
+
+// IsLiability reports whether the holding kind is money owed
+func (k HoldingKind) IsLiability() bool {
+	return k == HoldingMortgage || k == HoldingLoan || k == HoldingLiability
+}
+
+// Emoji returns the emoji used to render the holding kind
+func (k HoldingKind) Emoji() string {
+	switch k {
+	case HoldingBank:
+		return "🏦"
+	case HoldingBrokerage:
+		return "📈"
+	case HoldingProperty:
+		return "🏠"
+	case HoldingMortgage:
+		return "🏚"
+	case HoldingLoan:
+		return "🧾"
+	case HoldingLiability:
+		return "📉"
+	default:
+		return "💎"
+	}
+}
+
+// GetHoldingKinds returns all holding kinds, the assets first
+func GetHoldingKinds() []string {
+	return []string{
+		string(HoldingBank),
+		string(HoldingBrokerage),
+		string(HoldingProperty),
+		string(HoldingAsset),
+		string(HoldingMortgage),
+		string(HoldingLoan),
+		string(HoldingLiability),
+	}
+}
+
+// ParseHoldingKind returns the holding kind matching the given name, ignoring case
+func ParseHoldingKind(name string) (HoldingKind, bool) {
+	for _, k := range GetHoldingKinds() {
+		if strings.EqualFold(k, name) {
+			return HoldingKind(k), true
+		}
+	}
+	return "", false
+}
+
+// Holding represents the holdings table structure, something the user owns or
+// owes that makes up their net worth. Its balance is either recorded by hand
+// in snapshots, or derived from the account it tracks when AccountID is set,
+// in which case the currency is the one of the account. Balances are positive
+// for both the value of an asset and the money owed on a liability.
+type Holding struct {
+	ID        int64        `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID      int64        `gorm:"column:tg_id;not null;uniqueIndex:idx_holdings_tg_id_name"`
+	Name      string       `gorm:"column:name;not null;size:32;uniqueIndex:idx_holdings_tg_id_name"`
+	Kind      HoldingKind  `gorm:"column:kind;not null;type:holding_kind;default:'Asset'"`
+	Currency  CurrencyType `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	AccountID *int64       `gorm:"column:account_id;uniqueIndex"`
+	CreatedAt time.Time    `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time    `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName overrides the table name
+func (Holding) TableName() string {
+	return "holdings"
+}
+
+// Label returns the holding name preceded by the emoji of its kind
+func (h Holding) Label() string {
+	return fmt.Sprintf("%s %s", h.Kind.Emoji(), h.Name)
+}
+
+// IsLinked reports whether the balance of the holding comes from an account
+func (h Holding) IsLinked() bool {
+	return h.AccountID != nil
+}
+
+// Normalize trims the name and fills the optional fields with their defaults
+func (h *Holding) Normalize() {
+	h.Name = strings.TrimSpace(h.Name)
+	if h.Kind == "" {
+		h.Kind = HoldingAsset
+	}
+}
+
+// Validate checks that the holding can be stored
+func (h Holding) Validate() error {
+	switch {
+	case h.Name == "":
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidHolding)
+	case len(h.Name) > MaxHoldingNameLength:
+		return fmt.Errorf("%w: name cannot be longer than %d characters", ErrInvalidHolding, MaxHoldingNameLength)
+	case !slices.Contains(GetHoldingKinds(), string(h.Kind)):
+		return fmt.Errorf("%w: kind must be one of %s", ErrInvalidHolding, strings.Join(GetHoldingKinds(), ", "))
+	case !IsValidCurrency(string(h.Currency)):
+		return fmt.Errorf("%w: unsupported currency %q", ErrInvalidHolding, h.Currency)
+	}
+	return nil
+}
+
+// AccountSnapshots returns the balances of a holding tracking an account from
+// the ledger of the account: its opening balance, from the day the account
+// was created or of its first transaction if earlier, then its balance at the
+// end of each day it changed. The balance of an account tracked as a
+// liability is what is owed on it, the opposite of the account balance.
+func (h Holding) AccountSnapshots(account Account, entries []LedgerEntry) []HoldingSnapshot {
+	sign := Money(1)
+	if h.Kind.IsLiability() {
+		sign = -1
+	}
+
+	opened := account.CreatedAt
+	if len(entries) > 0 && entries[0].Transaction.Date.Before(opened) {
+		opened = entries[0].Transaction.Date
+	}
+	snapshots := []HoldingSnapshot{{HoldingID: h.ID, TgID: h.TgID, Date: truncateToDay(opened), Balance: sign * account.OpeningBalance}}
+	for _, e := range entries {
+		date := truncateToDay(e.Transaction.Date)
+		last := &snapshots[len(snapshots)-1]
+		if last.Date.Equal(date) {
+			last.Balance = sign * e.Balance
+			continue
+		}
+		snapshots = append(snapshots, HoldingSnapshot{HoldingID: h.ID, TgID: h.TgID, Date: date, Balance: sign * e.Balance})
+	}
+	return snapshots
+}
+
+// HoldingSnapshot represents the holding_snapshots table structure, the
+// balance of a holding on a day, in the currency of the holding. A holding
+// has at most one snapshot a day.
+type HoldingSnapshot struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	HoldingID int64     `gorm:"column:holding_id;not null;uniqueIndex:idx_holding_snapshots_holding_date"`
+	TgID      int64     `gorm:"column:tg_id;not null;index"`
+	Date      time.Time `gorm:"column:date;not null;type:date;uniqueIndex:idx_holding_snapshots_holding_date"`
+	Balance   Money     `gorm:"column:balance;not null;type:decimal(15,2)"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName overrides the table name
+func (HoldingSnapshot) TableName() string {
+	return "holding_snapshots"
+}
+
+// Validate checks that the snapshot can be stored
+func (s HoldingSnapshot) Validate() error {
+	switch {
+	case s.Balance.Abs() > MaxMoney:
+		return fmt.Errorf("%w: balance is too large", ErrInvalidSnapshot)
+	case s.Date.IsZero():
+		return fmt.Errorf("%w: date is required", ErrInvalidSnapshot)
+	}
+	return nil
+}
+
+// BalanceAt returns the latest of the snapshots, sorted by date, on or before
+// the given day, false if the balance was not known yet
+func BalanceAt(snapshots []HoldingSnapshot, day time.Time) (HoldingSnapshot, bool) {
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].Date.After(day)
+	})
+	if i == 0 {
+		return HoldingSnapshot{}, false
+	}
+	return snapshots[i-1], true
+}
+
+// HoldingBalance is a holding with its latest balance, in the currency of the
+// holding and converted to the base currency of the user. Known is false
+// when no balance was ever recorded.
+type HoldingBalance struct {
+	Holding
+	Balance   Money
+	Converted Money
+	Date      time.Time
+	Known     bool
+}
+
+// HoldingBalances is the list of the holdings of a user with their balances
+type HoldingBalances []HoldingBalance
+
+// Total returns the assets, the liabilities and the net worth of the known
+// balances, in the base currency of the user
+func (bs HoldingBalances) Total() NetWorthPoint {
+	var point NetWorthPoint
+	for _, b := range bs {
+		switch {
+		case !b.Known:
+		case b.Kind.IsLiability():
+			point.Liabilities += b.Converted
+		default:
+			point.Assets += b.Converted
+		}
+	}
+	point.NetWorth = point.Assets - point.Liabilities
+	return point
+}
+
+// NetWorthPoint is the net worth of a user on a day, the assets minus the
+// liabilities, in their base currency
+type NetWorthPoint struct {
+	Date        time.Time
+	Assets      Money
+	Liabilities Money
+	NetWorth    Money
+}
+
+// NetWorthAt returns the net worth on a day from the balances of the holdings,
+// the latest known for each of them, converted to the base currency at the
+// current rates. The snapshots of each holding, keyed by its ID, must be
+// sorted by date; the holdings with no balance known yet do not count.
+func NetWorthAt(holdings []Holding, snapshots map[int64][]HoldingSnapshot, day time.Time, rates RateTable, base CurrencyType) (NetWorthPoint, error) {
+	assets, liabilities := NewAmount(0, base), NewAmount(0, base)
+	for _, h := range holdings {
+		s, ok := BalanceAt(snapshots[h.ID], day)
+		if !ok {
+			continue
+		}
+		converted, err := rates.ConvertAmount(NewAmount(s.Balance, h.Currency), base)
+		if err != nil {
+			return NetWorthPoint{}, err
+		}
+		if h.Kind.IsLiability() {
+			liabilities, err = liabilities.Add(converted)
+		} else {
+			assets, err = assets.Add(converted)
+		}
+		if err != nil {
+			return NetWorthPoint{}, err
+		}
+	}
+
+	netWorth, err := assets.Sub(liabilities)
+	if err != nil {
+		return NetWorthPoint{}, err
+	}
+	return NetWorthPoint{Date: day, Assets: assets.Money, Liabilities: liabilities.Money, NetWorth: netWorth.Money}, nil
+}
+
+// NetWorthSeries returns the net worth at the end of each of the given number
+// of months up to the one of today, the last point being today's
+func NetWorthSeries(holdings []Holding, snapshots map[int64][]HoldingSnapshot, today time.Time, months int, rates RateTable, base CurrencyType) ([]NetWorthPoint, error) {
+	first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	points := make([]NetWorthPoint, 0, months)
+	for i := months - 1; i >= 0; i-- {
+		day := first.AddDate(0, -i+1, -1)
+		if i == 0 {
+			day = truncateToDay(today)
+		}
+		point, err := NetWorthAt(holdings, snapshots, day, rates, base)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
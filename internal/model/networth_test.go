@@ -0,0 +1,157 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHoldingValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		holding Holding
+		wantErr bool
+	}{
+		{name: "valid", holding: Holding{Name: "Mortgage", Kind: HoldingMortgage, Currency: CurrencyEUR}},
+		{name: "empty name", holding: Holding{Kind: HoldingBank, Currency: CurrencyEUR}, wantErr: true},
+		{name: "name too long", holding: Holding{Name: strings.Repeat("a", MaxHoldingNameLength+1), Kind: HoldingBank, Currency: CurrencyEUR}, wantErr: true},
+		{name: "invalid kind", holding: Holding{Name: "Bitcoin", Kind: "Crypto", Currency: CurrencyEUR}, wantErr: true},
+		{name: "invalid currency", holding: Holding{Name: "House", Kind: HoldingProperty, Currency: "XYZ"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.holding.Validate()
+			if tt.wantErr && !errors.Is(err, ErrInvalidHolding) {
+				t.Fatalf("expected ErrInvalidHolding, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseHoldingKind(t *testing.T) {
+	if k, ok := ParseHoldingKind("mortgage"); !ok || k != HoldingMortgage || !k.IsLiability() {
+		t.Fatalf("expected Mortgage liability, got %q %v", k, ok)
+	}
+	if k, _ := ParseHoldingKind("Brokerage"); k.IsLiability() {
+		t.Fatal("expected Brokerage to be an asset")
+	}
+	if _, ok := ParseHoldingKind("Crypto"); ok {
+		t.Fatal("expected unknown kind")
+	}
+}
+
+func TestBalanceAt(t *testing.T) {
+	snapshots := []HoldingSnapshot{
+		{Date: date(2026, 3, 1), Balance: NewMoney(100)},
+		{Date: date(2026, 4, 1), Balance: NewMoney(150)},
+	}
+
+	tests := []struct {
+		name string
+		day  time.Time
+		want float64
+		ok   bool
+	}{
+		{name: "before the first", day: date(2026, 2, 28)},
+		{name: "on the day", day: date(2026, 3, 1), want: 100, ok: true},
+		{name: "carried forward", day: date(2026, 3, 31), want: 100, ok: true},
+		{name: "latest", day: date(2026, 6, 30), want: 150, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := BalanceAt(snapshots, tt.day)
+			if ok != tt.ok || got.Balance != NewMoney(tt.want) {
+				t.Fatalf("got %.2f %v, want %.2f %v", got.Balance, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestHoldingAccountSnapshots(t *testing.T) {
+	account := Account{ID: 2, OpeningBalance: NewMoney(100), CreatedAt: time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)}
+	entries := []LedgerEntry{
+		{Transaction: Transaction{Date: date(2026, 3, 5)}, Balance: NewMoney(80)},
+		{Transaction: Transaction{Date: date(2026, 3, 20)}, Balance: NewMoney(50)},
+		{Transaction: Transaction{Date: date(2026, 3, 20)}, Balance: NewMoney(-40)},
+	}
+
+	asset := Holding{ID: 1, Kind: HoldingBank}
+	got := asset.AccountSnapshots(account, entries)
+	want := []HoldingSnapshot{
+		{HoldingID: 1, Date: date(2026, 3, 5), Balance: NewMoney(80)},
+		{HoldingID: 1, Date: date(2026, 3, 20), Balance: NewMoney(-40)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d snapshots, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("snapshot %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// A card tracked as a liability owes the opposite of its balance
+	liability := Holding{ID: 1, Kind: HoldingLoan}
+	got = liability.AccountSnapshots(account, nil)
+	if len(got) != 1 || got[0].Balance != NewMoney(-100) || !got[0].Date.Equal(date(2026, 3, 10)) {
+		t.Fatalf("unexpected liability snapshots: %+v", got)
+	}
+}
+
+func TestNetWorthSeries(t *testing.T) {
+	rates := RateTable{{Base: CurrencyEUR, Quote: CurrencyUSD, Rate: 1.25}}
+	holdings := []Holding{
+		{ID: 1, Kind: HoldingBank, Currency: CurrencyEUR},
+		{ID: 2, Kind: HoldingBrokerage, Currency: CurrencyUSD},
+		{ID: 3, Kind: HoldingMortgage, Currency: CurrencyEUR},
+	}
+	snapshots := map[int64][]HoldingSnapshot{
+		1: {{Date: date(2026, 3, 15), Balance: NewMoney(1000)}, {Date: date(2026, 5, 2), Balance: NewMoney(1200)}},
+		2: {{Date: date(2026, 4, 30), Balance: NewMoney(500)}},
+		3: {{Date: date(2026, 3, 1), Balance: NewMoney(900)}, {Date: date(2026, 5, 10), Balance: NewMoney(850)}},
+	}
+
+	points, err := NetWorthSeries(holdings, snapshots, date(2026, 5, 20), 4, rates, CurrencyEUR)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []NetWorthPoint{
+		{Date: date(2026, 2, 28)},
+		{Date: date(2026, 3, 31), Assets: NewMoney(1000), Liabilities: NewMoney(900), NetWorth: NewMoney(100)},
+		{Date: date(2026, 4, 30), Assets: NewMoney(1400), Liabilities: NewMoney(900), NetWorth: NewMoney(500)},
+		{Date: date(2026, 5, 20), Assets: NewMoney(1600), Liabilities: NewMoney(850), NetWorth: NewMoney(750)},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i := range want {
+		if points[i] != want[i] {
+			t.Errorf("point %d: got %+v, want %+v", i, points[i], want[i])
+		}
+	}
+
+	if _, err := NetWorthSeries(holdings, snapshots, date(2026, 5, 20), 1, RateTable{}, CurrencyEUR); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Fatalf("expected ErrExchangeRateNotFound, got %v", err)
+	}
+}
+
+func TestHoldingBalancesTotal(t *testing.T) {
+	balances := HoldingBalances{
+		{Holding: Holding{Kind: HoldingProperty}, Converted: NewMoney(250000), Known: true},
+		{Holding: Holding{Kind: HoldingBank}, Converted: NewMoney(5000.1), Known: true},
+		{Holding: Holding{Kind: HoldingMortgage}, Converted: NewMoney(180000), Known: true},
+		{Holding: Holding{Kind: HoldingLoan}},
+	}
+
+	got := balances.Total()
+	if got.Assets != NewMoney(255000.1) || got.Liabilities != NewMoney(180000) || got.NetWorth != NewMoney(75000.1) {
+		t.Fatalf("unexpected total: %+v", got)
+	}
+}
@@ -0,0 +1,242 @@
+package model
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidQuery is returned for a query on the transactions that cannot be run
+	ErrInvalidQuery = errors.New("invalid query")
+	// ErrQueryTooBroad is returned for a query matching more than
+	// MaxQueryTransactions transactions, which must be narrowed down
+	ErrQueryTooBroad = errors.New("query too broad")
+)
+
+const (
+	// MaxQueryText is the maximum length of the description searched by a query
+	MaxQueryText = 100
+	// MaxQueryTransactions is the most transactions a query is run on
+	MaxQueryTransactions = 5000
+)
+
+// QueryGroupBy is how the transactions matched by a query are grouped
+type QueryGroupBy string
+
+const (
+	GroupByNone     QueryGroupBy = ""
+	GroupByCategory QueryGroupBy = "category"
+	GroupByTag      QueryGroupBy = "tag"
+	GroupByMonth    QueryGroupBy = "month"
+)
+
+// GetQueryGroupBys returns the groupings of the queries, none excluded
+func GetQueryGroupBys() []string {
+	return []string{string(GroupByCategory), string(GroupByTag), string(GroupByMonth)}
+}
+
+// QueryAggregate is the value computed over the transactions of each group
+type QueryAggregate string
+
+const (
+	AggregateSum   QueryAggregate = "sum"
+	AggregateCount QueryAggregate = "count"
+	AggregateAvg   QueryAggregate = "avg"
+	AggregateMax   QueryAggregate = "max"
+	AggregateMin   QueryAggregate = "min"
+)
+
+// GetQueryAggregates returns the aggregates of the queries
+func GetQueryAggregates() []string {
+	return []string{string(AggregateSum), string(AggregateCount), string(AggregateAvg), string(AggregateMax), string(AggregateMin)}
+}
+
+// QuerySpec is a question on the transactions of a user, e.g. "how much did
+// I spend on EatingOut since June?": the filters of the transactions, how
+// they are grouped and the value computed for each group. It is built by
+// the language model and run by the server, see Run.
+type QuerySpec struct {
+	// Type is TypeExpense or TypeIncome, transfers are never counted
+	Type TransactionType
+	// Category restricts the transactions to a category, the split ones
+	// counting their line in it only. Empty for all.
+	Category TransactionCategory
+	// Query is a case-insensitive substring of the description
+	Query       string
+	DateFrom    *time.Time // inclusive lower bound
+	DateTo      *time.Time // inclusive upper bound
+	AmountMin   *Money     // inclusive lower bound
+	AmountMax   *Money     // inclusive upper bound
+	Tags        []string   // transactions having all of these tags
+	ExcludeTags []string   // transactions having none of these tags
+	GroupBy     QueryGroupBy
+	Aggregate   QueryAggregate
+}
+
+// Validate checks the spec against the user's categories, filling in the
+// defaults (the expenses, summed) and normalizing the names of the category
+// and of the tags
+func (q *QuerySpec) Validate(categories Categories) error {
+	if q.Category != "" {
+		category, ok := categories.Find(string(q.Category))
+		if !ok {
+			// The model may not keep the case of the name
+			i := slices.IndexFunc(categories, func(c Category) bool { return strings.EqualFold(c.Name, string(q.Category)) })
+			if i < 0 {
+				return fmt.Errorf("%w: unknown category %q", ErrInvalidQuery, q.Category)
+			}
+			category = categories[i]
+		}
+		q.Category = TransactionCategory(category.Name)
+		q.Type = category.Type
+	}
+	if q.Type == "" {
+		q.Type = TypeExpense
+	}
+	if q.Aggregate == "" {
+		q.Aggregate = AggregateSum
+	}
+	q.Query = strings.TrimSpace(q.Query)
+
+	var err error
+	if q.Tags, err = NormalizeTagNames(q.Tags); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+	if q.ExcludeTags, err = NormalizeTagNames(q.ExcludeTags); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+
+	switch {
+	case q.Type != TypeExpense && q.Type != TypeIncome:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidQuery, q.Type)
+	case len(q.Query) > MaxQueryText:
+		return fmt.Errorf("%w: the description is longer than %d characters", ErrInvalidQuery, MaxQueryText)
+	case q.DateFrom != nil && q.DateTo != nil && q.DateTo.Before(*q.DateFrom):
+		return fmt.Errorf("%w: the period ends before it starts", ErrInvalidQuery)
+	case (q.AmountMin != nil && *q.AmountMin < 0) || (q.AmountMax != nil && *q.AmountMax < 0):
+		return fmt.Errorf("%w: the amounts cannot be negative", ErrInvalidQuery)
+	case q.AmountMin != nil && q.AmountMax != nil && *q.AmountMax < *q.AmountMin:
+		return fmt.Errorf("%w: the maximum amount is lower than the minimum", ErrInvalidQuery)
+	case q.GroupBy != GroupByNone && !slices.Contains(GetQueryGroupBys(), string(q.GroupBy)):
+		return fmt.Errorf("%w: unknown grouping %q", ErrInvalidQuery, q.GroupBy)
+	case !slices.Contains(GetQueryAggregates(), string(q.Aggregate)):
+		return fmt.Errorf("%w: unknown aggregate %q", ErrInvalidQuery, q.Aggregate)
+	}
+	return nil
+}
+
+// QueryRow is the value of a group of the transactions of a query
+type QueryRow struct {
+	// Key is the category, the tag (empty for the untagged transactions) or
+	// the month as YYYY-MM, empty when not grouped
+	Key string
+	// Value is the aggregate in the base currency, zero when counting
+	Value Money
+	// Count is the number of transactions of the group
+	Count int
+	// Transaction is the biggest or the smallest of the group, for max and min
+	Transaction *Transaction
+}
+
+// QueryResult is the outcome of a query
+type QueryResult struct {
+	Spec QuerySpec
+	// Rows are the groups sorted by decreasing value, by month when grouped
+	// by month, none when no transaction matched
+	Rows []QueryRow
+}
+
+// Run computes the aggregate of the query over the transactions it matched,
+// as searched with its filters. The amounts are the base currency ones.
+func (q QuerySpec) Run(transactions []Transaction) QueryResult {
+	type group struct {
+		row QueryRow
+		sum Money
+	}
+	groups := map[string]*group{}
+	add := func(key string, amount Money, t Transaction) {
+		g, ok := groups[key]
+		if !ok {
+			g = &group{row: QueryRow{Key: key}}
+			groups[key] = g
+		}
+		g.sum += amount
+		g.row.Count++
+
+		// The biggest or the smallest transaction is kept with its amount
+		pick := g.row.Transaction == nil ||
+			(q.Aggregate == AggregateMax && amount > g.row.Value) ||
+			(q.Aggregate == AggregateMin && amount < g.row.Value)
+		if (q.Aggregate == AggregateMax || q.Aggregate == AggregateMin) && pick {
+			g.row.Value, g.row.Transaction = amount, &t
+		}
+	}
+
+	for _, t := range transactions {
+		if t.Type != q.Type {
+			continue
+		}
+		amounts := t.CategoryAmounts()
+		if q.Category != "" {
+			amounts = map[TransactionCategory]Money{q.Category: amounts[q.Category]}
+		}
+
+		switch q.GroupBy {
+		case GroupByCategory:
+			for category, amount := range amounts {
+				add(string(category), amount, t)
+			}
+		case GroupByTag:
+			var total Money
+			for _, amount := range amounts {
+				total += amount
+			}
+			if len(t.Tags) == 0 {
+				add("", total, t)
+			}
+			for _, name := range t.TagNames() {
+				add(name, total, t)
+			}
+		default:
+			var total Money
+			for _, amount := range amounts {
+				total += amount
+			}
+			key := ""
+			if q.GroupBy == GroupByMonth {
+				key = t.Date.Format("2006-01")
+			}
+			add(key, total, t)
+		}
+	}
+
+	result := QueryResult{Spec: q}
+	for _, g := range groups {
+		switch q.Aggregate {
+		case AggregateSum:
+			g.row.Value = g.sum
+		case AggregateAvg:
+			g.row.Value = Money(math.Round(float64(g.sum) / float64(g.row.Count)))
+		}
+		result.Rows = append(result.Rows, g.row)
+	}
+
+	slices.SortFunc(result.Rows, func(a, b QueryRow) int {
+		if q.GroupBy == GroupByMonth {
+			return strings.Compare(a.Key, b.Key)
+		}
+		if q.Aggregate == AggregateCount {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Key, b.Key))
+		}
+		if q.Aggregate == AggregateMin {
+			return cmp.Or(cmp.Compare(a.Value, b.Value), strings.Compare(a.Key, b.Key))
+		}
+		return cmp.Or(cmp.Compare(b.Value, a.Value), strings.Compare(a.Key, b.Key))
+	})
+	return result
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuerySpecValidate(t *testing.T) {
+	categories := DefaultCategories(1)
+	day := func(d int) *time.Time {
+		t := time.Date(2026, 5, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	money := func(f float64) *Money {
+		m := NewMoney(f)
+		return &m
+	}
+
+	tests := []struct {
+		name    string
+		spec    QuerySpec
+		want    QuerySpec
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: QuerySpec{Type: TypeExpense, Aggregate: AggregateSum, Tags: []string{}, ExcludeTags: []string{}},
+		},
+		{
+			name: "category of another case and its type",
+			spec: QuerySpec{Category: "salary", Tags: []string{"#Work", "work"}, Query: " pizza ", GroupBy: GroupByMonth, Aggregate: AggregateAvg},
+			want: QuerySpec{Type: TypeIncome, Category: "Salary", Tags: []string{"work"}, ExcludeTags: []string{}, Query: "pizza", GroupBy: GroupByMonth, Aggregate: AggregateAvg},
+		},
+		{name: "unknown category", spec: QuerySpec{Category: "Yachts"}, wantErr: true},
+		{name: "transfers", spec: QuerySpec{Type: TypeTransfer}, wantErr: true},
+		{name: "period ending before it starts", spec: QuerySpec{DateFrom: day(10), DateTo: day(9)}, wantErr: true},
+		{name: "negative amount", spec: QuerySpec{AmountMin: money(-1)}, wantErr: true},
+		{name: "amounts swapped", spec: QuerySpec{AmountMin: money(10), AmountMax: money(5)}, wantErr: true},
+		{name: "unknown grouping", spec: QuerySpec{GroupBy: "description"}, wantErr: true},
+		{name: "unknown aggregate", spec: QuerySpec{Aggregate: "median"}, wantErr: true},
+		{name: "invalid tag", spec: QuerySpec{Tags: []string{"#"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec
+			err := spec.Validate(categories)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("Validate() = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(spec, tt.want) {
+				t.Errorf("Validate() = %+v, want %+v", spec, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuerySpecRun(t *testing.T) {
+	may := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)
+	transactions := []Transaction{
+		{ID: 1, Type: TypeExpense, Category: "Grocery", Amount: NewMoney(30), Date: may, Tags: []Tag{{Name: "home"}}},
+		{ID: 2, Type: TypeExpense, Category: "EatingOut", Amount: NewMoney(12), Date: may},
+		{ID: 3, Type: TypeExpense, Category: "Grocery", Amount: NewMoney(50), Date: june, Splits: []TransactionSplit{
+			{Category: "Grocery", Amount: NewMoney(35)},
+			{Category: "House", Amount: NewMoney(15)},
+		}},
+		{ID: 4, Type: TypeIncome, Category: "Salary", Amount: NewMoney(2000), Date: june},
+	}
+
+	tests := []struct {
+		name string
+		spec QuerySpec
+		want []QueryRow
+	}{
+		{
+			name: "total",
+			spec: QuerySpec{Type: TypeExpense, Aggregate: AggregateSum},
+			want: []QueryRow{{Value: NewMoney(92), Count: 3}},
+		},
+		{
+			name: "category of a split transaction",
+			spec: QuerySpec{Type: TypeExpense, Category: "Grocery", Aggregate: AggregateSum},
+			want: []QueryRow{{Value: NewMoney(65), Count: 3}},
+		},
+		{
+			name: "by category",
+			spec: QuerySpec{Type: TypeExpense, GroupBy: GroupByCategory, Aggregate: AggregateSum},
+			want: []QueryRow{
+				{Key: "Grocery", Value: NewMoney(65), Count: 2},
+				{Key: "House", Value: NewMoney(15), Count: 1},
+				{Key: "EatingOut", Value: NewMoney(12), Count: 1},
+			},
+		},
+		{
+			name: "average by month",
+			spec: QuerySpec{Type: TypeExpense, GroupBy: GroupByMonth, Aggregate: AggregateAvg},
+			want: []QueryRow{{Key: "2026-05", Value: NewMoney(21), Count: 2}, {Key: "2026-06", Value: NewMoney(50), Count: 1}},
+		},
+		{
+			name: "count by tag",
+			spec: QuerySpec{Type: TypeExpense, GroupBy: GroupByTag, Aggregate: AggregateCount},
+			want: []QueryRow{{Key: "", Count: 2}, {Key: "home", Count: 1}},
+		},
+		{
+			name: "biggest",
+			spec: QuerySpec{Type: TypeExpense, Aggregate: AggregateMax},
+			want: []QueryRow{{Value: NewMoney(50), Count: 3, Transaction: &transactions[2]}},
+		},
+		{
+			name: "smallest income",
+			spec: QuerySpec{Type: TypeIncome, Aggregate: AggregateMin},
+			want: []QueryRow{{Value: NewMoney(2000), Count: 1, Transaction: &transactions[3]}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.spec.Run(transactions)
+			if !reflect.DeepEqual(got.Rows, tt.want) {
+				t.Errorf("Run() = %+v, want %+v", got.Rows, tt.want)
+			}
+		})
+	}
+
+	if got := (QuerySpec{Type: TypeExpense, Aggregate: AggregateSum}).Run(nil); len(got.Rows) != 0 {
+		t.Errorf("Run(nil) = %+v, want no rows", got.Rows)
+	}
+}
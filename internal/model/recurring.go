@@ -0,0 +1,236 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxRecurringCatchUp is the maximum number of occurrences of a rule
+// materialised at once, e.g. for a daily rule started long ago
+const MaxRecurringCatchUp = 400
+
+var (
+	ErrRecurringRuleNotFound = errors.New("recurring rule not found")
+	ErrInvalidRecurringRule  = errors.New("invalid recurring rule")
+)
+
+// RecurringFrequency is how often a recurring rule creates a transaction
+type RecurringFrequency string
+
+// Recurring frequencies
+const (
+	FrequencyDaily   RecurringFrequency = "Daily"
+	FrequencyWeekly  RecurringFrequency = "Weekly"
+	FrequencyMonthly RecurringFrequency = "Monthly"
+	FrequencyYearly  RecurringFrequency = "Yearly"
+)
+
+// Value implements the driver.Valuer interface for RecurringFrequency
+func (f RecurringFrequency) Value() (driver.Value, error) {
+	return string(f), nil
+}
+
+// Scan implements the sql.Scanner interface for RecurringFrequency
+func (f *RecurringFrequency) Scan(value any) error {
+	if value == nil {
+		return errors.New("recurring frequency cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid recurring frequency")
+	}
+
+	*f = RecurringFrequency(strVal)
+	return nil
+}
+
+// GetRecurringFrequencies returns all recurring frequencies
+func GetRecurringFrequencies() []string {
+	return []string{
+		string(FrequencyDaily),
+		string(FrequencyWeekly),
+		string(FrequencyMonthly),
+		string(FrequencyYearly),
+	}
+}
+
+// RecurringRule represents the recurring_rules table structure, a template
+// the scheduler turns into a transaction on every occurrence. Amount is in
+// Currency, converted to the base currency of the user when materialised.
+// DayOfMonth is only used by monthly rules, occurrences on days the month
+// does not have fall on its last day. Weekly and yearly rules repeat on the
+// weekday and the anniversary of StartDate.
+type RecurringRule struct {
+	ID          int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID        int64               `gorm:"column:tg_id;not null;index"`
+	Type        TransactionType     `gorm:"column:type;not null;type:transaction_type"`
+	Category    TransactionCategory `gorm:"column:category;not null;size:32"`
+	Amount      Money               `gorm:"column:amount;not null;type:decimal(15,2)"`
+	Currency    CurrencyType        `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	Description string              `gorm:"column:description;type:text"`
+	AccountID   *int64              `gorm:"column:account_id"`
+	Frequency   RecurringFrequency  `gorm:"column:frequency;not null;type:recurring_frequency"`
+	DayOfMonth  int                 `gorm:"column:day_of_month;not null;default:0"`
+	StartDate   time.Time           `gorm:"column:start_date;not null;type:date"`
+	EndDate     *time.Time          `gorm:"column:end_date;type:date"`
+	LastRunOn   *time.Time          `gorm:"column:last_run_on;type:date"`
+	Paused      bool                `gorm:"column:paused;not null;default:false"`
+	CreatedAt   time.Time           `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time           `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName overrides the table name
+func (RecurringRule) TableName() string {
+	return "recurring_rules"
+}
+
+// Normalize trims the description, truncates the dates to the day and fills
+// the day of month of monthly rules from their start date
+func (r *RecurringRule) Normalize() {
+	r.Description = strings.TrimSpace(r.Description)
+	r.StartDate = truncateToDay(r.StartDate)
+	if r.EndDate != nil {
+		end := truncateToDay(*r.EndDate)
+		r.EndDate = &end
+	}
+
+	switch r.Frequency {
+	case FrequencyMonthly:
+		if r.DayOfMonth == 0 {
+			r.DayOfMonth = r.StartDate.Day()
+		}
+	default:
+		r.DayOfMonth = 0
+	}
+}
+
+// Validate checks the rule fields, the category is checked against the user's ones separately
+func (r RecurringRule) Validate() error {
+	switch {
+	case r.Type != TypeIncome && r.Type != TypeExpense:
+		return fmt.Errorf("%w: type must be Income or Expense", ErrInvalidRecurringRule)
+	case r.Category == "":
+		return fmt.Errorf("%w: category cannot be empty", ErrInvalidRecurringRule)
+	case r.Amount <= 0:
+		return fmt.Errorf("%w: amount must be greater than 0", ErrInvalidRecurringRule)
+	case !IsValidCurrency(string(r.Currency)):
+		return fmt.Errorf("%w: unsupported currency %q", ErrInvalidRecurringRule, r.Currency)
+	case r.Description == "":
+		return fmt.Errorf("%w: description cannot be empty", ErrInvalidRecurringRule)
+	case !slices.Contains(GetRecurringFrequencies(), string(r.Frequency)):
+		return fmt.Errorf("%w: frequency must be one of %s", ErrInvalidRecurringRule, strings.Join(GetRecurringFrequencies(), ", "))
+	case r.Frequency == FrequencyMonthly && (r.DayOfMonth < 1 || r.DayOfMonth > 31):
+		return fmt.Errorf("%w: day of month must be between 1 and 31", ErrInvalidRecurringRule)
+	case r.StartDate.IsZero():
+		return fmt.Errorf("%w: start date is required", ErrInvalidRecurringRule)
+	case r.EndDate != nil && r.EndDate.Before(r.StartDate):
+		return fmt.Errorf("%w: end date must be on or after the start date", ErrInvalidRecurringRule)
+	}
+	return nil
+}
+
+// Schedule describes the frequency of the rule, e.g. "Monthly on day 5"
+func (r RecurringRule) Schedule() string {
+	switch r.Frequency {
+	case FrequencyWeekly:
+		return fmt.Sprintf("Weekly on %s", r.StartDate.Weekday())
+	case FrequencyMonthly:
+		return fmt.Sprintf("Monthly on day %d", r.DayOfMonth)
+	case FrequencyYearly:
+		return fmt.Sprintf("Yearly on %s", r.StartDate.Format("2 January"))
+	default:
+		return string(r.Frequency)
+	}
+}
+
+// occurrenceAfter returns the first occurrence of the rule strictly after the
+// given day, ignoring the start and end dates
+func (r RecurringRule) occurrenceAfter(day time.Time) time.Time {
+	next := day.AddDate(0, 0, 1)
+	switch r.Frequency {
+	case FrequencyWeekly:
+		offset := (int(r.StartDate.Weekday()) - int(next.Weekday()) + 7) % 7
+		return next.AddDate(0, 0, offset)
+	case FrequencyMonthly:
+		candidate := dayInMonth(next.Year(), next.Month(), r.DayOfMonth)
+		if candidate.Before(next) {
+			candidate = dayInMonth(next.Year(), next.Month()+1, r.DayOfMonth)
+		}
+		return candidate
+	case FrequencyYearly:
+		candidate := dayInMonth(next.Year(), r.StartDate.Month(), r.StartDate.Day())
+		if candidate.Before(next) {
+			candidate = dayInMonth(next.Year()+1, r.StartDate.Month(), r.StartDate.Day())
+		}
+		return candidate
+	default:
+		return next
+	}
+}
+
+// Occurrences returns the days in which the rule creates a transaction, from
+// the first one after the last run (or from the start date) up to the given
+// day included, at most MaxRecurringCatchUp of them
+func (r RecurringRule) Occurrences(until time.Time) []time.Time {
+	until = truncateToDay(until)
+	if r.EndDate != nil && r.EndDate.Before(until) {
+		until = *r.EndDate
+	}
+
+	after := truncateToDay(r.StartDate).AddDate(0, 0, -1)
+	if r.LastRunOn != nil && !r.LastRunOn.Before(after) {
+		after = truncateToDay(*r.LastRunOn)
+	}
+
+	var days []time.Time
+	for day := r.occurrenceAfter(after); !day.After(until) && len(days) < MaxRecurringCatchUp; day = r.occurrenceAfter(day) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// NextOccurrence returns the first occurrence after the last run, if the rule
+// has not ended yet
+func (r RecurringRule) NextOccurrence() (time.Time, bool) {
+	after := truncateToDay(r.StartDate).AddDate(0, 0, -1)
+	if r.LastRunOn != nil && !r.LastRunOn.Before(after) {
+		after = truncateToDay(*r.LastRunOn)
+	}
+
+	next := r.occurrenceAfter(after)
+	if r.EndDate != nil && next.After(*r.EndDate) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// NewTransaction returns the transaction of the rule for the given occurrence, not stored yet
+func (r RecurringRule) NewTransaction(day time.Time) Transaction {
+	ruleID := r.ID
+	return Transaction{
+		TgID:            r.TgID,
+		Date:            day,
+		Type:            r.Type,
+		Category:        r.Category,
+		Amount:          r.Amount,
+		Currency:        r.Currency,
+		Description:     r.Description,
+		AccountID:       r.AccountID,
+		RecurringRuleID: &ruleID,
+	}
+}
+
+// dayInMonth returns the given day of the month, or its last day when shorter
+func dayInMonth(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return time.Date(year, month, min(day, last), 0, 0, 0, 0, time.UTC)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
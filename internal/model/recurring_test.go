@@ -0,0 +1,154 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
+func TestRecurringRuleOccurrences(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  RecurringRule
+		until time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "daily",
+			rule:  RecurringRule{Frequency: FrequencyDaily, StartDate: date(2026, 5, 30)},
+			until: date(2026, 6, 1),
+			want:  []time.Time{date(2026, 5, 30), date(2026, 5, 31), date(2026, 6, 1)},
+		},
+		{
+			name:  "weekly on the start weekday",
+			rule:  RecurringRule{Frequency: FrequencyWeekly, StartDate: date(2026, 5, 4)},
+			until: date(2026, 5, 20),
+			want:  []time.Time{date(2026, 5, 4), date(2026, 5, 11), date(2026, 5, 18)},
+		},
+		{
+			name:  "monthly on day 31 falls on the last day",
+			rule:  RecurringRule{Frequency: FrequencyMonthly, DayOfMonth: 31, StartDate: date(2026, 1, 1)},
+			until: date(2026, 4, 30),
+			want:  []time.Time{date(2026, 1, 31), date(2026, 2, 28), date(2026, 3, 31), date(2026, 4, 30)},
+		},
+		{
+			name:  "yearly on Feb 29",
+			rule:  RecurringRule{Frequency: FrequencyYearly, StartDate: date(2024, 2, 29)},
+			until: date(2028, 3, 1),
+			want:  []time.Time{date(2024, 2, 29), date(2025, 2, 28), date(2026, 2, 28), date(2027, 2, 28), date(2028, 2, 29)},
+		},
+		{
+			name:  "stops at the end date",
+			rule:  RecurringRule{Frequency: FrequencyDaily, StartDate: date(2026, 5, 1), EndDate: ptrTime(date(2026, 5, 2))},
+			until: date(2026, 5, 10),
+			want:  []time.Time{date(2026, 5, 1), date(2026, 5, 2)},
+		},
+		{
+			name:  "resumes after the last run",
+			rule:  RecurringRule{Frequency: FrequencyMonthly, DayOfMonth: 5, StartDate: date(2026, 1, 5), LastRunOn: ptrTime(date(2026, 3, 10))},
+			until: date(2026, 5, 5),
+			want:  []time.Time{date(2026, 4, 5), date(2026, 5, 5)},
+		},
+		{
+			name:  "not started yet",
+			rule:  RecurringRule{Frequency: FrequencyDaily, StartDate: date(2026, 6, 1)},
+			until: date(2026, 5, 31),
+			want:  nil,
+		},
+		{
+			name:  "already run",
+			rule:  RecurringRule{Frequency: FrequencyDaily, StartDate: date(2026, 5, 1), LastRunOn: ptrTime(date(2026, 5, 31))},
+			until: date(2026, 5, 31),
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule.Occurrences(tt.until)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRecurringRuleOccurrencesCatchUpLimit(t *testing.T) {
+	rule := RecurringRule{Frequency: FrequencyDaily, StartDate: date(2020, 1, 1)}
+	if got := rule.Occurrences(date(2026, 1, 1)); len(got) != MaxRecurringCatchUp {
+		t.Fatalf("expected %d occurrences, got %d", MaxRecurringCatchUp, len(got))
+	}
+}
+
+func TestRecurringRuleNextOccurrence(t *testing.T) {
+	rule := RecurringRule{Frequency: FrequencyWeekly, StartDate: date(2026, 5, 4), LastRunOn: ptrTime(date(2026, 5, 12))}
+	if next, ok := rule.NextOccurrence(); !ok || !next.Equal(date(2026, 5, 18)) {
+		t.Fatalf("expected 2026-05-18, got %v %v", next, ok)
+	}
+
+	rule.EndDate = ptrTime(date(2026, 5, 15))
+	if next, ok := rule.NextOccurrence(); ok {
+		t.Fatalf("expected the rule to be ended, got %v", next)
+	}
+}
+
+func TestRecurringRuleNormalize(t *testing.T) {
+	rule := RecurringRule{Description: " Rent ", Frequency: FrequencyMonthly, StartDate: time.Date(2026, 5, 3, 15, 4, 0, 0, time.UTC)}
+	rule.Normalize()
+	if rule.Description != "Rent" || rule.DayOfMonth != 3 || !rule.StartDate.Equal(date(2026, 5, 3)) {
+		t.Fatalf("unexpected normalized rule: %+v", rule)
+	}
+
+	rule.Frequency = FrequencyWeekly
+	rule.Normalize()
+	if rule.DayOfMonth != 0 {
+		t.Fatalf("expected no day of month for weekly rules, got %d", rule.DayOfMonth)
+	}
+}
+
+func TestRecurringRuleValidate(t *testing.T) {
+	valid := RecurringRule{
+		Type: TypeExpense, Category: "Housing", Amount: NewMoney(800), Currency: CurrencyEUR,
+		Description: "Rent", Frequency: FrequencyMonthly, DayOfMonth: 1, StartDate: date(2026, 1, 1),
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(r *RecurringRule)
+		wantErr bool
+	}{
+		{name: "valid", edit: func(r *RecurringRule) {}},
+		{name: "transfer", edit: func(r *RecurringRule) { r.Type = TypeTransfer }, wantErr: true},
+		{name: "zero amount", edit: func(r *RecurringRule) { r.Amount = 0 }, wantErr: true},
+		{name: "invalid currency", edit: func(r *RecurringRule) { r.Currency = "XYZ" }, wantErr: true},
+		{name: "empty description", edit: func(r *RecurringRule) { r.Description = "" }, wantErr: true},
+		{name: "invalid frequency", edit: func(r *RecurringRule) { r.Frequency = "Hourly" }, wantErr: true},
+		{name: "day of month too big", edit: func(r *RecurringRule) { r.DayOfMonth = 32 }, wantErr: true},
+		{name: "end before start", edit: func(r *RecurringRule) { r.EndDate = ptrTime(date(2025, 12, 31)) }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.edit(&rule)
+			err := rule.Validate()
+			if tt.wantErr && !errors.Is(err, ErrInvalidRecurringRule) {
+				t.Fatalf("expected ErrInvalidRecurringRule, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -14,6 +14,9 @@ const (
 	ReminderTypeWeeklyRecap  ReminderType = "weekly_recap"
 	ReminderTypeMonthlyRecap ReminderType = "monthly_recap"
 	ReminderTypeYearlyRecap  ReminderType = "yearly_recap"
+	// ReminderTypeBalanceCheckIn prompts to update the balances of the net
+	// worth, due with the monthly recap
+	ReminderTypeBalanceCheckIn ReminderType = "balance_check_in"
 )
 
 // Value implements the driver.Valuer interface for ReminderType
@@ -87,3 +90,29 @@ type Reminder struct {
 func (Reminder) TableName() string {
 	return "reminders"
 }
+
+// RecapHour is the hour of the day, in the user's timezone, the recaps are delivered at
+const RecapHour = 6
+
+// NextWeeklyRecap returns when the next weekly recap is due after now: on
+// Monday at RecapHour in loc
+func NextWeeklyRecap(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	daysUntilMonday := (8 - int(local.Weekday())) % 7
+	next := time.Date(local.Year(), local.Month(), local.Day()+daysUntilMonday, RecapHour, 0, 0, 0, loc)
+	if !next.After(now) {
+		next = time.Date(next.Year(), next.Month(), next.Day()+7, RecapHour, 0, 0, 0, loc)
+	}
+	return next.UTC()
+}
+
+// NextMonthlyRecap returns when the next monthly recap is due after now: on
+// the first day of the month at RecapHour in loc
+func NextMonthlyRecap(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	next := time.Date(local.Year(), local.Month(), 1, RecapHour, 0, 0, 0, loc)
+	if !next.After(now) {
+		next = time.Date(local.Year(), local.Month()+1, 1, RecapHour, 0, 0, 0, loc)
+	}
+	return next.UTC()
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRecaps(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		loc         *time.Location
+		wantWeekly  time.Time
+		wantMonthly time.Time
+	}{
+		{
+			name:        "sunday afternoon in UTC",
+			now:         time.Date(2026, 5, 17, 15, 0, 0, 0, time.UTC),
+			loc:         time.UTC,
+			wantWeekly:  time.Date(2026, 5, 18, 6, 0, 0, 0, time.UTC),
+			wantMonthly: time.Date(2026, 6, 1, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "monday after the recap",
+			now:         time.Date(2026, 5, 18, 15, 0, 0, 0, time.UTC),
+			loc:         time.UTC,
+			wantWeekly:  time.Date(2026, 5, 25, 6, 0, 0, 0, time.UTC),
+			wantMonthly: time.Date(2026, 6, 1, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "morning in Rome",
+			now:         time.Date(2026, 5, 17, 15, 0, 0, 0, time.UTC),
+			loc:         rome,
+			wantWeekly:  time.Date(2026, 5, 18, 4, 0, 0, 0, time.UTC),
+			wantMonthly: time.Date(2026, 6, 1, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "first of the month still to come in New York",
+			now:         time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC),
+			loc:         newYork,
+			wantWeekly:  time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC),
+			wantMonthly: time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "first of the month already over in UTC",
+			now:         time.Date(2026, 12, 1, 7, 0, 0, 0, time.UTC),
+			loc:         time.UTC,
+			wantWeekly:  time.Date(2026, 12, 7, 6, 0, 0, 0, time.UTC),
+			wantMonthly: time.Date(2027, 1, 1, 6, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextWeeklyRecap(tt.now, tt.loc); !got.Equal(tt.wantWeekly) {
+				t.Errorf("NextWeeklyRecap() = %v, want %v", got, tt.wantWeekly)
+			}
+			if got := NextMonthlyRecap(tt.now, tt.loc); !got.Equal(tt.wantMonthly) {
+				t.Errorf("NextMonthlyRecap() = %v, want %v", got, tt.wantMonthly)
+			}
+		})
+	}
+}
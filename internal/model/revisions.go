@@ -0,0 +1,275 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RevisionAction is the kind of change recorded by a transaction revision
+type RevisionAction string
+
+// Revision actions
+const (
+	RevisionCreated  RevisionAction = "created"
+	RevisionUpdated  RevisionAction = "updated"
+	RevisionDeleted  RevisionAction = "deleted"
+	RevisionRestored RevisionAction = "restored"
+	RevisionPurged   RevisionAction = "purged"
+)
+
+// Value implements the driver.Valuer interface for RevisionAction
+func (a RevisionAction) Value() (driver.Value, error) {
+	return string(a), nil
+}
+
+// Scan implements the sql.Scanner interface for RevisionAction
+func (a *RevisionAction) Scan(value any) error {
+	if value == nil {
+		return errors.New("revision action cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid revision action")
+	}
+
+	*a = RevisionAction(strVal)
+	return nil
+}
+
+// RevisionSource is where a change to a transaction was made from
+type RevisionSource string
+
+// Revision sources
+const (
+	SourceBot       RevisionSource = "bot"
+	SourceWeb       RevisionSource = "web"
+	SourceAPIToken  RevisionSource = "api_token"
+	SourceScheduler RevisionSource = "scheduler"
+)
+
+// Value implements the driver.Valuer interface for RevisionSource
+func (s RevisionSource) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// Scan implements the sql.Scanner interface for RevisionSource
+func (s *RevisionSource) Scan(value any) error {
+	if value == nil {
+		return errors.New("revision source cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid revision source")
+	}
+
+	*s = RevisionSource(strVal)
+	return nil
+}
+
+// Actor is who changes the transactions and from where, TgID is 0 for the scheduler
+type Actor struct {
+	TgID   int64
+	Source RevisionSource
+}
+
+// SchedulerActor is the actor of the changes made by the scheduled jobs
+var SchedulerActor = Actor{Source: SourceScheduler}
+
+// TransactionRevision represents the transaction_revisions table structure,
+// an entry of the append-only history of a transaction. Before is empty for
+// the created ones, After for the deleted and purged ones. TgID is the owner
+// of the transaction, ActorTgID who made the change, nil for the scheduler.
+// Revisions are kept when the transaction is purged.
+type TransactionRevision struct {
+	ID            int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	TransactionID int64               `gorm:"column:transaction_id;not null;index"`
+	TgID          int64               `gorm:"column:tg_id;not null"`
+	Action        RevisionAction      `gorm:"column:action;not null;size:16"`
+	Source        RevisionSource      `gorm:"column:source;not null;size:16"`
+	ActorTgID     *int64              `gorm:"column:actor_tg_id"`
+	Before        TransactionSnapshot `gorm:"column:before;type:jsonb"`
+	After         TransactionSnapshot `gorm:"column:after;type:jsonb"`
+	CreatedAt     time.Time           `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName overrides the table name
+func (TransactionRevision) TableName() string {
+	return "transaction_revisions"
+}
+
+// NewTransactionRevision returns the revision of a change made by the actor,
+// before or after is nil when the transaction did not exist or no longer does
+func NewTransactionRevision(action RevisionAction, actor Actor, before, after *Transaction) TransactionRevision {
+	r := TransactionRevision{Action: action, Source: actor.Source}
+	if actor.TgID != 0 {
+		r.ActorTgID = &actor.TgID
+	}
+	for _, t := range []*Transaction{after, before} {
+		if t != nil {
+			r.TransactionID, r.TgID = t.ID, t.TgID
+		}
+	}
+	if before != nil {
+		r.Before = NewTransactionSnapshot(*before)
+	}
+	if after != nil {
+		r.After = NewTransactionSnapshot(*after)
+	}
+	return r
+}
+
+// SplitSnapshot is a split line in a transaction snapshot
+type SplitSnapshot struct {
+	Category    TransactionCategory `json:"category"`
+	Amount      Money               `json:"amount"`
+	Description string              `json:"description,omitempty"`
+}
+
+// TransactionSnapshot holds the values of the fields of a transaction a user
+// can change, stored as JSON in its revisions
+type TransactionSnapshot struct {
+	Date             string              `json:"date"`
+	Type             TransactionType     `json:"type"`
+	Category         TransactionCategory `json:"category"`
+	Amount           Money               `json:"amount"`
+	Currency         CurrencyType        `json:"currency"`
+	OriginalAmount   Money               `json:"originalAmount"`
+	OriginalCurrency CurrencyType        `json:"originalCurrency"`
+	Description      string              `json:"description"`
+	AccountID        *int64              `json:"accountId,omitempty"`
+	ToAccountID      *int64              `json:"toAccountId,omitempty"`
+	LedgerID         *int64              `json:"ledgerId,omitempty"`
+	Tags             []string            `json:"tags,omitempty"`
+	Splits           []SplitSnapshot     `json:"splits,omitempty"`
+}
+
+// NewTransactionSnapshot returns the current values of a transaction
+func NewTransactionSnapshot(t Transaction) TransactionSnapshot {
+	s := TransactionSnapshot{
+		Date:             t.Date.Format("2006-01-02"),
+		Type:             t.Type,
+		Category:         t.Category,
+		Amount:           t.Amount,
+		Currency:         t.Currency,
+		OriginalAmount:   t.OriginalAmount,
+		OriginalCurrency: t.OriginalCurrency,
+		Description:      t.Description,
+		AccountID:        t.AccountID,
+		ToAccountID:      t.ToAccountID,
+		LedgerID:         t.LedgerID,
+	}
+	if len(t.Tags) > 0 {
+		s.Tags = t.TagNames()
+		slices.Sort(s.Tags)
+	}
+	for _, line := range t.Splits {
+		s.Splits = append(s.Splits, SplitSnapshot{Category: line.Category, Amount: line.Amount, Description: line.Description})
+	}
+	return s
+}
+
+// IsZero reports whether the snapshot is empty, e.g. the one before a transaction was created
+func (s TransactionSnapshot) IsZero() bool {
+	return s.Type == ""
+}
+
+// Value makes the TransactionSnapshot struct implement the driver.Valuer
+// interface, the empty snapshot is stored as NULL
+func (s TransactionSnapshot) Value() (driver.Value, error) {
+	if s.IsZero() {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan makes the TransactionSnapshot struct implement the sql.Scanner interface
+func (s *TransactionSnapshot) Scan(value any) error {
+	*s = TransactionSnapshot{}
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// FieldChange is the value of a field of a transaction before and after a
+// revision, formatted for display
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// fields returns the formatted values of the fields of the snapshot, in display order
+func (s TransactionSnapshot) fields() [][2]string {
+	if s.IsZero() {
+		return nil
+	}
+
+	amount := fmt.Sprintf("%.2f %s", s.Amount, s.Currency)
+	if s.OriginalCurrency != "" && s.OriginalCurrency != s.Currency {
+		amount = fmt.Sprintf("%.2f %s (%.2f %s)", s.OriginalAmount, s.OriginalCurrency, s.Amount, s.Currency)
+	}
+	splits := make([]string, len(s.Splits))
+	for i, line := range s.Splits {
+		splits[i] = fmt.Sprintf("%s %.2f", line.Category, line.Amount)
+	}
+
+	return [][2]string{
+		{"Date", s.Date},
+		{"Type", string(s.Type)},
+		{"Category", string(s.Category)},
+		{"Amount", amount},
+		{"Description", s.Description},
+		{"Account", formatID(s.AccountID)},
+		{"To account", formatID(s.ToAccountID)},
+		{"Ledger", formatID(s.LedgerID)},
+		{"Tags", strings.Join(s.Tags, ", ")},
+		{"Split", strings.Join(splits, ", ")},
+	}
+}
+
+func formatID(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%d", *id)
+}
+
+// Changes returns the fields whose value differs before and after the
+// revision, every set field for the created transactions
+func (r TransactionRevision) Changes() []FieldChange {
+	before, after := r.Before.fields(), r.After.fields()
+	if before == nil || after == nil {
+		// Only what the transaction was created with is worth showing
+		if r.Action != RevisionCreated {
+			return nil
+		}
+		var changes []FieldChange
+		for _, f := range after {
+			if f[1] != "" {
+				changes = append(changes, FieldChange{Field: f[0], After: f[1]})
+			}
+		}
+		return changes
+	}
+
+	var changes []FieldChange
+	for i := range after {
+		if before[i][1] != after[i][1] {
+			changes = append(changes, FieldChange{Field: after[i][0], Before: before[i][1], After: after[i][1]})
+		}
+	}
+	return changes
+}
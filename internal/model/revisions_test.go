@@ -0,0 +1,153 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewTransactionRevision(t *testing.T) {
+	transaction := Transaction{ID: 7, TgID: 1, Type: TypeExpense, Category: CategoryGrocery, Amount: NewMoney(10), Currency: CurrencyEUR}
+
+	tests := []struct {
+		name      string
+		action    RevisionAction
+		actor     Actor
+		before    *Transaction
+		after     *Transaction
+		wantActor *int64
+	}{
+		{
+			name:      "created from the bot",
+			action:    RevisionCreated,
+			actor:     Actor{TgID: 1, Source: SourceBot},
+			after:     &transaction,
+			wantActor: &transaction.TgID,
+		},
+		{
+			name:   "deleted by the scheduler",
+			action: RevisionDeleted,
+			actor:  SchedulerActor,
+			before: &transaction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewTransactionRevision(tt.action, tt.actor, tt.before, tt.after)
+			if r.TransactionID != 7 || r.TgID != 1 {
+				t.Errorf("NewTransactionRevision() transaction = %d/%d, want 7/1", r.TransactionID, r.TgID)
+			}
+			if r.Action != tt.action || r.Source != tt.actor.Source {
+				t.Errorf("NewTransactionRevision() = %s/%s, want %s/%s", r.Action, r.Source, tt.action, tt.actor.Source)
+			}
+			if !reflect.DeepEqual(r.ActorTgID, tt.wantActor) {
+				t.Errorf("NewTransactionRevision() ActorTgID = %v, want %v", r.ActorTgID, tt.wantActor)
+			}
+			if r.Before.IsZero() != (tt.before == nil) || r.After.IsZero() != (tt.after == nil) {
+				t.Errorf("NewTransactionRevision() before/after = %+v/%+v", r.Before, r.After)
+			}
+		})
+	}
+}
+
+func TestTransactionRevisionChanges(t *testing.T) {
+	accountID := int64(3)
+	base := Transaction{
+		Type:        TypeExpense,
+		Category:    CategoryGrocery,
+		Amount:      NewMoney(10),
+		Currency:    CurrencyEUR,
+		Description: "Milk",
+		Date:        time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC),
+	}
+	with := func(change func(t *Transaction)) *Transaction {
+		t := base
+		change(&t)
+		return &t
+	}
+	actor := Actor{TgID: 1, Source: SourceWeb}
+
+	tests := []struct {
+		name   string
+		action RevisionAction
+		before *Transaction
+		after  *Transaction
+		want   []FieldChange
+	}{
+		{
+			name:   "created lists the set fields",
+			action: RevisionCreated,
+			after:  &base,
+			want: []FieldChange{
+				{Field: "Date", After: "2026-05-20"},
+				{Field: "Type", After: "Expense"},
+				{Field: "Category", After: "Grocery"},
+				{Field: "Amount", After: "10.00 EUR"},
+				{Field: "Description", After: "Milk"},
+			},
+		},
+		{
+			name:   "updated lists the changed fields",
+			action: RevisionUpdated,
+			before: &base,
+			after: with(func(t *Transaction) {
+				t.Category = CategoryEatingOut
+				t.AccountID = &accountID
+			}),
+			want: []FieldChange{
+				{Field: "Category", Before: "Grocery", After: "EatingOut"},
+				{Field: "Account", After: "#3"},
+			},
+		},
+		{
+			name:   "tags are compared in order",
+			action: RevisionUpdated,
+			before: with(func(t *Transaction) { t.Tags = []Tag{{Name: "a"}, {Name: "b"}} }),
+			after:  with(func(t *Transaction) { t.Tags = []Tag{{Name: "b"}, {Name: "a"}} }),
+		},
+		{
+			name:   "foreign amount",
+			action: RevisionUpdated,
+			before: &base,
+			after: with(func(t *Transaction) {
+				t.OriginalAmount, t.OriginalCurrency = NewMoney(11), CurrencyUSD
+			}),
+			want: []FieldChange{{Field: "Amount", Before: "10.00 EUR", After: "11.00 USD (10.00 EUR)"}},
+		},
+		{
+			name:   "deleted has no changes",
+			action: RevisionDeleted,
+			before: &base,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewTransactionRevision(tt.action, actor, tt.before, tt.after).Changes()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionSnapshotValue(t *testing.T) {
+	value, err := TransactionSnapshot{}.Value()
+	if err != nil || value != nil {
+		t.Fatalf("empty snapshot Value() = %v, %v, want nil", value, err)
+	}
+
+	want := NewTransactionSnapshot(Transaction{Type: TypeIncome, Category: CategorySalary, Amount: NewMoney(100), Tags: []Tag{{Name: "work"}}})
+	value, err = want.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	var got TransactionSnapshot
+	if err := got.Scan(value); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan(Value()) = %+v, want %+v", got, want)
+	}
+}
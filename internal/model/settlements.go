@@ -0,0 +1,382 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxShareParticipants is the maximum number of participants of a shared expense
+const MaxShareParticipants = 20
+
+var (
+	ErrSharedExpenseNotFound = errors.New("shared expense not found")
+	ErrInvalidSharedExpense  = errors.New("invalid shared expense")
+	ErrInvalidSettlement     = errors.New("invalid settlement")
+	ErrParticipantNotFound   = errors.New("participant not found")
+	ErrSettlementCurrency    = errors.New("the participants do not have the same base currency")
+	ErrOpenBalances          = errors.New("you have balances to settle with other users")
+)
+
+// CategorySettlement is the category of the transactions recording a
+// settlement between two users, it is not one of the user's categories
+const CategorySettlement TransactionCategory = "Settlement"
+
+// SettlementEmoji is the emoji of the settlements between users
+const SettlementEmoji = "🤝"
+
+// IsSettlement reports whether the transaction records a payment settling a
+// debt with another user
+func (t Transaction) IsSettlement() bool {
+	return t.SettlementID != nil
+}
+
+// SplitMethod is how a shared expense is divided among its participants
+type SplitMethod string
+
+// Split methods
+const (
+	SplitEqual   SplitMethod = "equal"
+	SplitPercent SplitMethod = "percent"
+	SplitExact   SplitMethod = "exact"
+)
+
+// Value implements the driver.Valuer interface for SplitMethod
+func (m SplitMethod) Value() (driver.Value, error) {
+	return string(m), nil
+}
+
+// Scan implements the sql.Scanner interface for SplitMethod
+func (m *SplitMethod) Scan(value any) error {
+	if value == nil {
+		return errors.New("split method cannot be null")
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		return errors.New("invalid split method")
+	}
+
+	*m = SplitMethod(strVal)
+	return nil
+}
+
+// GetSplitMethods returns all split methods
+func GetSplitMethods() []string {
+	return []string{
+		string(SplitEqual),
+		string(SplitPercent),
+		string(SplitExact),
+	}
+}
+
+// IsValidSplitMethod reports whether the method is one of the split methods
+func IsValidSplitMethod(method string) bool {
+	return slices.Contains(GetSplitMethods(), method)
+}
+
+// SharedExpense represents the shared_expenses table structure, an expense
+// paid by a user for several participants. Amount is the one of the expense
+// transaction, in the base currency of the payer, which every participant
+// shares. The shares are fixed when the expense is shared.
+type SharedExpense struct {
+	ID            int64        `gorm:"column:id;primaryKey;autoIncrement"`
+	TransactionID int64        `gorm:"column:transaction_id;not null;uniqueIndex"`
+	PaidBy        int64        `gorm:"column:paid_by;not null;index"`
+	Method        SplitMethod  `gorm:"column:method;not null;type:split_method"`
+	Amount        Money        `gorm:"column:amount;not null;type:decimal(15,2)"`
+	Currency      CurrencyType `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	CreatedAt     time.Time    `gorm:"column:created_at;autoCreateTime"`
+
+	Shares []ExpenseShare `gorm:"foreignKey:SharedExpenseID"`
+}
+
+// TableName overrides the table name
+func (SharedExpense) TableName() string {
+	return "shared_expenses"
+}
+
+// ExpenseShare is the part of a shared expense owed by one of its
+// participants, the payer included
+type ExpenseShare struct {
+	SharedExpenseID int64 `gorm:"column:shared_expense_id;primaryKey"`
+	TgID            int64 `gorm:"column:tg_id;primaryKey"`
+	Amount          Money `gorm:"column:amount;not null;type:decimal(15,2)"`
+}
+
+// TableName overrides the table name
+func (ExpenseShare) TableName() string {
+	return "expense_shares"
+}
+
+// ShareInput is a participant of a shared expense as entered: Value is the
+// percentage or the exact amount of their share, ignored by equal splits
+type ShareInput struct {
+	TgID  int64
+	Value float64
+}
+
+// ComputeShares divides the total of an expense paid by payer among the
+// participants. The payer is a participant even when not listed: with equal
+// splits they get a share like everybody else, with percentages and exact
+// amounts they get what the others do not owe. The payer also absorbs the
+// rounding to the cent, so the shares always sum to the total.
+func ComputeShares(total Money, method SplitMethod, payer int64, inputs []ShareInput) ([]ExpenseShare, error) {
+	if total <= 0 {
+		return nil, fmt.Errorf("%w: the amount must be greater than 0", ErrInvalidSharedExpense)
+	}
+	if !IsValidSplitMethod(string(method)) {
+		return nil, fmt.Errorf("%w: unknown split method %q", ErrInvalidSharedExpense, method)
+	}
+	if len(inputs) > MaxShareParticipants {
+		return nil, fmt.Errorf("%w: at most %d participants", ErrInvalidSharedExpense, MaxShareParticipants)
+	}
+
+	// The payer comes first, the others in the order they were entered
+	payerValue, payerListed := 0.0, false
+	others := make([]ShareInput, 0, len(inputs))
+	seen := make(map[int64]bool, len(inputs))
+	for _, in := range inputs {
+		if seen[in.TgID] {
+			return nil, fmt.Errorf("%w: a participant is listed twice", ErrInvalidSharedExpense)
+		}
+		seen[in.TgID] = true
+		if method != SplitEqual && in.Value <= 0 {
+			return nil, fmt.Errorf("%w: every share must be greater than 0", ErrInvalidSharedExpense)
+		}
+		if in.TgID == payer {
+			payerValue, payerListed = in.Value, true
+			continue
+		}
+		others = append(others, in)
+	}
+	if len(others) == 0 {
+		return nil, fmt.Errorf("%w: share it with at least another user", ErrInvalidSharedExpense)
+	}
+
+	cents := make([]Money, len(others))
+	switch method {
+	case SplitEqual:
+		each := total / Money(len(others)+1)
+		for i := range others {
+			cents[i] = each
+		}
+	case SplitPercent:
+		sum := payerValue
+		for i, in := range others {
+			sum += in.Value
+			cents[i] = total.Mul(in.Value / 100)
+		}
+		if sum > 100.0001 || (payerListed && sum < 99.9999) {
+			return nil, fmt.Errorf("%w: the percentages sum to %g%% instead of 100%%", ErrInvalidSharedExpense, sum)
+		}
+	case SplitExact:
+		sum := NewMoney(payerValue)
+		for i, in := range others {
+			cents[i] = NewMoney(in.Value)
+			sum += cents[i]
+		}
+		if sum > total || (payerListed && sum != total) {
+			return nil, fmt.Errorf("%w: the shares sum to %.2f instead of %.2f", ErrInvalidSharedExpense, sum, total)
+		}
+	}
+
+	payerCents := total
+	for _, c := range cents {
+		payerCents -= c
+	}
+	if payerCents < 0 {
+		return nil, fmt.Errorf("%w: the shares exceed the amount", ErrInvalidSharedExpense)
+	}
+
+	shares := make([]ExpenseShare, 0, len(others)+1)
+	if payerCents > 0 {
+		shares = append(shares, ExpenseShare{TgID: payer, Amount: payerCents})
+	}
+	for i, in := range others {
+		if cents[i] <= 0 {
+			return nil, fmt.Errorf("%w: every share must be at least 0.01", ErrInvalidSharedExpense)
+		}
+		shares = append(shares, ExpenseShare{TgID: in.TgID, Amount: cents[i]})
+	}
+	return shares, nil
+}
+
+// Debt is an amount a user owes another, in their common base currency
+type Debt struct {
+	From   int64 `gorm:"column:from_tg_id"`
+	To     int64 `gorm:"column:to_tg_id"`
+	Amount Money `gorm:"column:amount"`
+}
+
+// Debts returns what each participant owes the payer of the expense
+func (e SharedExpense) Debts() []Debt {
+	var debts []Debt
+	for _, s := range e.Shares {
+		if s.TgID != e.PaidBy {
+			debts = append(debts, Debt{From: s.TgID, To: e.PaidBy, Amount: s.Amount})
+		}
+	}
+	return debts
+}
+
+// Settlement represents the settlements table structure, a payment from a
+// user to another settling their debts. Recording it adds an expense for
+// FromTgID and an income for ToTgID, both linked through SettlementID.
+type Settlement struct {
+	ID        int64        `gorm:"column:id;primaryKey;autoIncrement"`
+	FromTgID  int64        `gorm:"column:from_tg_id;not null;index"`
+	ToTgID    int64        `gorm:"column:to_tg_id;not null;index"`
+	Amount    Money        `gorm:"column:amount;not null;type:decimal(15,2)"`
+	Currency  CurrencyType `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	Date      time.Time    `gorm:"column:date;not null;type:date"`
+	CreatedBy int64        `gorm:"column:created_by;not null"`
+	CreatedAt time.Time    `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName overrides the table name
+func (Settlement) TableName() string {
+	return "settlements"
+}
+
+// Validate checks the parties and the amount of the settlement, recorded by one of them
+func (s Settlement) Validate() error {
+	switch {
+	case s.FromTgID == s.ToTgID:
+		return fmt.Errorf("%w: a user cannot pay themselves", ErrInvalidSettlement)
+	case s.CreatedBy != s.FromTgID && s.CreatedBy != s.ToTgID:
+		return fmt.Errorf("%w: only the parties can record it", ErrInvalidSettlement)
+	case s.Amount <= 0:
+		return fmt.Errorf("%w: the amount must be greater than 0", ErrInvalidSettlement)
+	}
+	return nil
+}
+
+// Debt returns the settlement as a debt from the payee to the payer, which
+// cancels out what the payer owed
+func (s Settlement) Debt() Debt {
+	return Debt{From: s.ToTgID, To: s.FromTgID, Amount: s.Amount}
+}
+
+// NewTransactions returns the expense of the payer and the income of the
+// payee recording the settlement, described with the name of the other party
+func (s Settlement) NewTransactions(fromName, toName string) (Transaction, Transaction) {
+	expense := Transaction{
+		TgID:        s.FromTgID,
+		Date:        s.Date,
+		Type:        TypeExpense,
+		Category:    CategorySettlement,
+		Amount:      s.Amount,
+		Currency:    s.Currency,
+		Description: strings.TrimSpace("Settlement to " + toName),
+	}
+	income := Transaction{
+		TgID:        s.ToTgID,
+		Date:        s.Date,
+		Type:        TypeIncome,
+		Category:    CategorySettlement,
+		Amount:      s.Amount,
+		Currency:    s.Currency,
+		Description: strings.TrimSpace("Settlement from " + fromName),
+	}
+	return expense, income
+}
+
+// NetBalances returns the net balance of every user in the debts, positive
+// when the others owe them
+func NetBalances(debts []Debt) map[int64]Money {
+	net := make(map[int64]Money)
+	for _, d := range debts {
+		net[d.From] -= d.Amount
+		net[d.To] += d.Amount
+	}
+	return net
+}
+
+// Balance is what a counterpart owes a user, negative when the user owes them
+type Balance struct {
+	TgID   int64
+	Amount Money
+}
+
+// Balances returns the balance of the user with each of their counterparts
+// in the debts, largest first, leaving out the settled ones
+func Balances(tgID int64, debts []Debt) []Balance {
+	byCounterpart := make(map[int64]Money)
+	for _, d := range debts {
+		switch {
+		case d.To == tgID && d.From != tgID:
+			byCounterpart[d.From] += d.Amount
+		case d.From == tgID && d.To != tgID:
+			byCounterpart[d.To] -= d.Amount
+		}
+	}
+
+	balances := make([]Balance, 0, len(byCounterpart))
+	for id, c := range byCounterpart {
+		if c != 0 {
+			balances = append(balances, Balance{TgID: id, Amount: c})
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		ai, aj := balances[i].Amount.Abs(), balances[j].Amount.Abs()
+		if ai != aj {
+			return ai > aj
+		}
+		return balances[i].TgID < balances[j].TgID
+	})
+	return balances
+}
+
+// MinimalTransfers returns the payments settling every balance of a group,
+// at most one less than the users with a balance: the largest debtor pays
+// the largest creditor until everybody is even. Debts between the same users
+// in both directions cancel out first.
+func MinimalTransfers(debts []Debt) []Debt {
+	type party struct {
+		tgID  int64
+		cents Money
+	}
+
+	var creditors, debtors []party
+	for id, c := range NetBalances(debts) {
+		switch {
+		case c > 0:
+			creditors = append(creditors, party{id, c})
+		case c < 0:
+			debtors = append(debtors, party{id, -c})
+		}
+	}
+	largestFirst := func(ps []party) {
+		sort.Slice(ps, func(i, j int) bool {
+			if ps[i].cents != ps[j].cents {
+				return ps[i].cents > ps[j].cents
+			}
+			return ps[i].tgID < ps[j].tgID
+		})
+	}
+
+	var transfers []Debt
+	for len(creditors) > 0 && len(debtors) > 0 {
+		largestFirst(creditors)
+		largestFirst(debtors)
+
+		c, d := &creditors[0], &debtors[0]
+		amount := min(c.cents, d.cents)
+		transfers = append(transfers, Debt{From: d.tgID, To: c.tgID, Amount: amount})
+		c.cents -= amount
+		d.cents -= amount
+
+		if c.cents == 0 {
+			creditors = creditors[1:]
+		}
+		if d.cents == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestComputeShares(t *testing.T) {
+	const payer = 1
+
+	tests := []struct {
+		name    string
+		total   float64
+		method  SplitMethod
+		inputs  []ShareInput
+		want    []ExpenseShare
+		wantErr bool
+	}{
+		{
+			name:   "equal, payer absorbs the rounding",
+			total:  100,
+			method: SplitEqual,
+			inputs: []ShareInput{{TgID: 2}, {TgID: 3}},
+			want:   []ExpenseShare{{TgID: 1, Amount: NewMoney(33.34)}, {TgID: 2, Amount: NewMoney(33.33)}, {TgID: 3, Amount: NewMoney(33.33)}},
+		},
+		{
+			name:   "equal with the payer listed",
+			total:  60,
+			method: SplitEqual,
+			inputs: []ShareInput{{TgID: 1}, {TgID: 2}},
+			want:   []ExpenseShare{{TgID: 1, Amount: NewMoney(30)}, {TgID: 2, Amount: NewMoney(30)}},
+		},
+		{
+			name:   "percent, payer gets the rest",
+			total:  100,
+			method: SplitPercent,
+			inputs: []ShareInput{{TgID: 2, Value: 40}},
+			want:   []ExpenseShare{{TgID: 1, Amount: NewMoney(60)}, {TgID: 2, Amount: NewMoney(40)}},
+		},
+		{
+			name:   "percent with the payer listed",
+			total:  80,
+			method: SplitPercent,
+			inputs: []ShareInput{{TgID: 1, Value: 25}, {TgID: 2, Value: 75}},
+			want:   []ExpenseShare{{TgID: 1, Amount: NewMoney(20)}, {TgID: 2, Amount: NewMoney(60)}},
+		},
+		{
+			name:   "exact, payer gets the rest",
+			total:  30,
+			method: SplitExact,
+			inputs: []ShareInput{{TgID: 2, Value: 10}, {TgID: 3, Value: 12}},
+			want:   []ExpenseShare{{TgID: 1, Amount: NewMoney(8)}, {TgID: 2, Amount: NewMoney(10)}, {TgID: 3, Amount: NewMoney(12)}},
+		},
+		{
+			name:   "exact, nothing left to the payer",
+			total:  30,
+			method: SplitExact,
+			inputs: []ShareInput{{TgID: 2, Value: 10}, {TgID: 3, Value: 20}},
+			want:   []ExpenseShare{{TgID: 2, Amount: NewMoney(10)}, {TgID: 3, Amount: NewMoney(20)}},
+		},
+		{name: "percent over 100", total: 100, method: SplitPercent, inputs: []ShareInput{{TgID: 2, Value: 60}, {TgID: 3, Value: 50}}, wantErr: true},
+		{name: "percent with payer under 100", total: 100, method: SplitPercent, inputs: []ShareInput{{TgID: 1, Value: 50}, {TgID: 2, Value: 40}}, wantErr: true},
+		{name: "exact over the total", total: 30, method: SplitExact, inputs: []ShareInput{{TgID: 2, Value: 31}}, wantErr: true},
+		{name: "share rounded to zero", total: 0.01, method: SplitPercent, inputs: []ShareInput{{TgID: 2, Value: 10}}, wantErr: true},
+		{name: "zero value", total: 30, method: SplitExact, inputs: []ShareInput{{TgID: 2}}, wantErr: true},
+		{name: "duplicate participant", total: 30, method: SplitEqual, inputs: []ShareInput{{TgID: 2}, {TgID: 2}}, wantErr: true},
+		{name: "only the payer", total: 30, method: SplitEqual, inputs: []ShareInput{{TgID: 1}}, wantErr: true},
+		{name: "no amount", total: 0, method: SplitEqual, inputs: []ShareInput{{TgID: 2}}, wantErr: true},
+		{name: "unknown method", total: 30, method: "shares", inputs: []ShareInput{{TgID: 2}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ComputeShares(NewMoney(tt.total), tt.method, payer, tt.inputs)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSharedExpense) {
+					t.Fatalf("expected ErrInvalidSharedExpense, got %+v, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBalances(t *testing.T) {
+	debts := []Debt{
+		{From: 2, To: 1, Amount: NewMoney(30)},
+		{From: 1, To: 2, Amount: NewMoney(10)},
+		{From: 1, To: 3, Amount: NewMoney(5)},
+		{From: 4, To: 1, Amount: NewMoney(12.5)},
+		{From: 1, To: 4, Amount: NewMoney(12.5)},
+		{From: 2, To: 3, Amount: NewMoney(100)},
+	}
+
+	want := []Balance{{TgID: 2, Amount: NewMoney(20)}, {TgID: 3, Amount: NewMoney(-5)}}
+	if got := Balances(1, debts); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestMinimalTransfers(t *testing.T) {
+	tests := []struct {
+		name  string
+		debts []Debt
+		want  []Debt
+	}{
+		{
+			name: "two expenses among three users",
+			debts: []Debt{
+				{From: 2, To: 1, Amount: NewMoney(30)},
+				{From: 3, To: 1, Amount: NewMoney(30)},
+				{From: 2, To: 3, Amount: NewMoney(15)},
+			},
+			want: []Debt{{From: 2, To: 1, Amount: NewMoney(45)}, {From: 3, To: 1, Amount: NewMoney(15)}},
+		},
+		{
+			name: "a chain collapses into one payment",
+			debts: []Debt{
+				{From: 1, To: 2, Amount: NewMoney(10)},
+				{From: 2, To: 3, Amount: NewMoney(10)},
+			},
+			want: []Debt{{From: 1, To: 3, Amount: NewMoney(10)}},
+		},
+		{
+			name: "a cycle needs no payment",
+			debts: []Debt{
+				{From: 1, To: 2, Amount: NewMoney(10)},
+				{From: 2, To: 3, Amount: NewMoney(10)},
+				{From: 3, To: 1, Amount: NewMoney(10)},
+			},
+		},
+		{
+			name:  "settled debt",
+			debts: []Debt{{From: 1, To: 2, Amount: NewMoney(10)}, {From: 2, To: 1, Amount: NewMoney(10)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MinimalTransfers(tt.debts); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSettlementValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		settlement Settlement
+		wantErr    bool
+	}{
+		{name: "recorded by the payer", settlement: Settlement{FromTgID: 1, ToTgID: 2, Amount: NewMoney(20), CreatedBy: 1}},
+		{name: "recorded by the payee", settlement: Settlement{FromTgID: 1, ToTgID: 2, Amount: NewMoney(20), CreatedBy: 2}},
+		{name: "recorded by someone else", settlement: Settlement{FromTgID: 1, ToTgID: 2, Amount: NewMoney(20), CreatedBy: 3}, wantErr: true},
+		{name: "paying themselves", settlement: Settlement{FromTgID: 1, ToTgID: 1, Amount: NewMoney(20), CreatedBy: 1}, wantErr: true},
+		{name: "no amount", settlement: Settlement{FromTgID: 1, ToTgID: 2, Amount: NewMoney(0.001), CreatedBy: 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settlement.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidSettlement) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// MaxSplitLines is the maximum number of lines of a split transaction
+const MaxSplitLines = 20
+
+var ErrInvalidSplit = errors.New("invalid split")
+
+// TransactionSplit represents the transaction_splits table structure, a line
+// of a transaction split across several categories. Amount is in the base
+// currency like the one of its transaction, OriginalAmount in the currency
+// the transaction was entered in.
+type TransactionSplit struct {
+	ID             int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	TransactionID  int64               `gorm:"column:transaction_id;not null;index"`
+	Category       TransactionCategory `gorm:"column:category;not null;size:32"`
+	Amount         Money               `gorm:"column:amount;not null;type:decimal(15,2)"`
+	OriginalAmount Money               `gorm:"column:original_amount;not null;type:decimal(15,2)"`
+	Description    string              `gorm:"column:description;type:text"`
+}
+
+// TableName overrides the table name
+func (TransactionSplit) TableName() string {
+	return "transaction_splits"
+}
+
+// IsSplit reports whether the transaction is split across several categories,
+// aggregations by category use its lines instead of its own category
+func (t Transaction) IsSplit() bool {
+	return len(t.Splits) > 0
+}
+
+// CategoryAmounts returns the amounts of the transaction by category in the
+// base currency: the ones of its lines when split, or its whole amount
+func (t Transaction) CategoryAmounts() map[TransactionCategory]Money {
+	if !t.IsSplit() {
+		return map[TransactionCategory]Money{t.Category: t.Amount}
+	}
+	amounts := make(map[TransactionCategory]Money, len(t.Splits))
+	for _, s := range t.Splits {
+		amounts[s.Category] += s.Amount
+	}
+	return amounts
+}
+
+// RenameCategory moves the transaction and its lines from a renamed category
+// to its new name
+func (t *Transaction) RenameCategory(from, to TransactionCategory) {
+	if t.Category == from {
+		t.Category = to
+	}
+	for i := range t.Splits {
+		if t.Splits[i].Category == from {
+			t.Splits[i].Category = to
+		}
+	}
+}
+
+// SetSplits splits the transaction across the given lines, an empty list
+// removes the split. The line amounts are taken as entered, in the original
+// currency of the transaction, unless OriginalAmount is already set, and must
+// sum to its original amount. They are converted to the base currency in
+// proportion, the rounding difference spread by splitAmounts. The transaction
+// takes the category of its largest line.
+func (t *Transaction) SetSplits(lines []TransactionSplit) error {
+	if len(lines) == 0 {
+		t.Splits = nil
+		return nil
+	}
+
+	switch {
+	case t.IsTransfer():
+		return fmt.Errorf("%w: transfers cannot be split", ErrInvalidSplit)
+	case len(lines) < 2:
+		return fmt.Errorf("%w: a split needs at least 2 lines", ErrInvalidSplit)
+	case len(lines) > MaxSplitLines:
+		return fmt.Errorf("%w: a split can have at most %d lines", ErrInvalidSplit, MaxSplitLines)
+	}
+
+	original := t.OriginalAmount
+	if original == 0 {
+		original = t.Amount
+	}
+
+	splits := make([]TransactionSplit, len(lines))
+	var total Money
+	for i, line := range lines {
+		amount := line.OriginalAmount
+		if amount == 0 {
+			amount = line.Amount
+		}
+
+		category := TransactionCategory(strings.TrimSpace(string(line.Category)))
+		switch {
+		case category == "" || category == CategoryTransfer:
+			return fmt.Errorf("%w: line %d has no valid category", ErrInvalidSplit, i+1)
+		case amount <= 0:
+			return fmt.Errorf("%w: line %d must have an amount greater than 0", ErrInvalidSplit, i+1)
+		}
+
+		splits[i] = TransactionSplit{
+			TransactionID:  t.ID,
+			Category:       category,
+			OriginalAmount: amount,
+			Description:    strings.TrimSpace(line.Description),
+		}
+		total += amount
+	}
+
+	if total != original {
+		return fmt.Errorf("%w: the lines sum to %.2f instead of %.2f", ErrInvalidSplit, total, original)
+	}
+
+	largest := 0
+	for i := range splits {
+		if splits[i].OriginalAmount > splits[largest].OriginalAmount {
+			largest = i
+		}
+	}
+
+	amounts, err := splitAmounts(splits, t.Amount, original)
+	if err != nil {
+		return err
+	}
+	for i := range splits {
+		splits[i].Amount = amounts[i]
+	}
+
+	t.Splits = splits
+	t.Category = splits[largest].Category
+	return nil
+}
+
+// ConvertSplits sets the base currency amounts of the lines of a split
+// transaction from their original amounts, in proportion to the amount of
+// the transaction, e.g. after it was converted into another base currency.
+// The lines are left unchanged if one of them would be converted to nothing.
+func (t *Transaction) ConvertSplits() error {
+	original := t.OriginalAmount
+	if original == 0 {
+		original = t.Amount
+	}
+
+	amounts, err := splitAmounts(t.Splits, t.Amount, original)
+	if err != nil {
+		return err
+	}
+	for i := range t.Splits {
+		t.Splits[i].Amount = amounts[i]
+	}
+	return nil
+}
+
+// splitAmounts returns the amounts of the lines in proportion to their
+// original amounts, summing to amount. Each line gets the hundredths of its
+// exact share, the ones left over by the rounding go to the lines with the
+// largest fractional parts.
+func splitAmounts(lines []TransactionSplit, amount, original Money) ([]Money, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	amounts := make([]Money, len(lines))
+	fractions := make([]float64, len(lines))
+	var assigned Money
+	for i, line := range lines {
+		exact := float64(line.OriginalAmount) * float64(amount) / float64(original)
+		amounts[i] = Money(math.Floor(exact))
+		fractions[i] = exact - math.Floor(exact)
+		assigned += amounts[i]
+	}
+
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return fractions[order[a]] > fractions[order[b]]
+	})
+	for i := 0; assigned < amount; i++ {
+		amounts[order[i%len(order)]]++
+		assigned++
+	}
+
+	for i, a := range amounts {
+		if a <= 0 {
+			return nil, fmt.Errorf("%w: line %d would amount to %.2f", ErrInvalidSplit, i+1, a)
+		}
+	}
+	return amounts, nil
+}
+
+// ValidateSplits checks that the lines of a split transaction still sum to its
+// amount, e.g. after the amount was changed without changing the split
+func (t Transaction) ValidateSplits() error {
+	if !t.IsSplit() {
+		return nil
+	}
+
+	var total, originalTotal Money
+	for i, s := range t.Splits {
+		if s.Amount <= 0 || s.OriginalAmount <= 0 {
+			return fmt.Errorf("%w: line %d must have an amount greater than 0", ErrInvalidSplit, i+1)
+		}
+		total += s.Amount
+		originalTotal += s.OriginalAmount
+	}
+	if total != t.Amount || originalTotal != t.OriginalAmount {
+		return fmt.Errorf("%w: the lines do not sum to the amount of the transaction, change the split too", ErrInvalidSplit)
+	}
+	return nil
+}
+
+// CopySplits returns the lines of the transaction as entered, ready to be set
+// on another transaction, e.g. a clone
+func (t Transaction) CopySplits() []TransactionSplit {
+	if !t.IsSplit() {
+		return nil
+	}
+
+	lines := make([]TransactionSplit, len(t.Splits))
+	for i, s := range t.Splits {
+		lines[i] = TransactionSplit{Category: s.Category, OriginalAmount: s.OriginalAmount, Description: s.Description}
+	}
+	return lines
+}
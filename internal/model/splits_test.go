@@ -0,0 +1,260 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTransactionSetSplits(t *testing.T) {
+	tx := Transaction{ID: 9, Type: TypeExpense, Category: CategoryGrocery, Amount: NewMoney(50), Currency: CurrencyEUR, OriginalAmount: NewMoney(50), OriginalCurrency: CurrencyEUR}
+
+	err := tx.SetSplits([]TransactionSplit{
+		{Category: CategoryGrocery, Amount: NewMoney(20)},
+		{Category: CategoryToiletry, Amount: NewMoney(12.5), Description: " shampoo "},
+		{Category: CategoryHouse, Amount: NewMoney(17.5)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !tx.IsSplit() || len(tx.Splits) != 3 || tx.Category != CategoryGrocery {
+		t.Fatalf("unexpected split transaction: %+v", tx)
+	}
+	if s := tx.Splits[1]; s.TransactionID != 9 || s.Amount != NewMoney(12.5) || s.OriginalAmount != NewMoney(12.5) || s.Description != "shampoo" {
+		t.Fatalf("unexpected split line: %+v", s)
+	}
+	if err := tx.ValidateSplits(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	tx.SetBaseAmount(NewMoney(60))
+	if err := tx.ValidateSplits(); !errors.Is(err, ErrInvalidSplit) {
+		t.Fatalf("expected ErrInvalidSplit after changing the amount, got %v", err)
+	}
+
+	if err := tx.SetSplits(nil); err != nil || tx.IsSplit() || tx.Category != CategoryGrocery {
+		t.Fatalf("expected the split to be removed, got %+v %v", tx, err)
+	}
+}
+
+func TestTransactionSetSplitsForeign(t *testing.T) {
+	// 10 USD converted to 9.10 EUR, the rounding difference goes to the last line
+	tx := Transaction{Type: TypeExpense, Amount: NewMoney(9.10), Currency: CurrencyEUR, OriginalAmount: NewMoney(10), OriginalCurrency: CurrencyUSD}
+
+	err := tx.SetSplits([]TransactionSplit{
+		{Category: CategoryHealth, Amount: NewMoney(3.33)},
+		{Category: CategoryToiletry, Amount: NewMoney(3.33)},
+		{Category: CategoryGrocery, Amount: NewMoney(3.34)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := moneys(3.03, 3.03, 3.04)
+	for i, s := range tx.Splits {
+		if s.Amount != want[i] {
+			t.Fatalf("line %d: expected %v, got %v", i, want[i], s.Amount)
+		}
+	}
+	if tx.Category != CategoryGrocery {
+		t.Fatalf("expected the category of the largest line, got %s", tx.Category)
+	}
+	if err := tx.ValidateSplits(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	copied := tx.CopySplits()
+	if len(copied) != 3 || copied[0].ID != 0 || copied[0].Amount != 0 || copied[0].OriginalAmount != NewMoney(3.33) {
+		t.Fatalf("unexpected copied lines: %+v", copied)
+	}
+}
+
+func TestTransactionConvertTo(t *testing.T) {
+	rates := RateTable{
+		{Base: CurrencyEUR, Quote: CurrencyUSD, Rate: 1.25},
+		{Base: CurrencyEUR, Quote: CurrencyGBP, Rate: 0.8},
+	}
+
+	// 10 USD entered with EUR as the base currency, then changed to GBP
+	tx := Transaction{Type: TypeExpense, Amount: NewMoney(8), Currency: CurrencyEUR, OriginalAmount: NewMoney(10), OriginalCurrency: CurrencyUSD}
+	err := tx.SetSplits([]TransactionSplit{
+		{Category: CategoryHealth, Amount: NewMoney(3.33)},
+		{Category: CategoryToiletry, Amount: NewMoney(3.33)},
+		{Category: CategoryGrocery, Amount: NewMoney(3.34)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := tx.ConvertTo(CurrencyGBP, rates); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Amount != NewMoney(6.4) || tx.Currency != CurrencyGBP {
+		t.Fatalf("expected GBP 6.40, got %s %v", tx.Currency, tx.Amount)
+	}
+
+	// The lines are converted from their original amounts, the rounding
+	// difference going to the last one
+	want := moneys(2.13, 2.13, 2.14)
+	for i, s := range tx.Splits {
+		if s.Amount != want[i] {
+			t.Fatalf("line %d: expected %v, got %v", i, want[i], s.Amount)
+		}
+		if s.OriginalAmount != moneys(3.33, 3.33, 3.34)[i] {
+			t.Fatalf("line %d: the original amount changed to %v", i, s.OriginalAmount)
+		}
+	}
+	if err := tx.ValidateSplits(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	if err := tx.ConvertTo(CurrencyJPY, rates); !errors.Is(err, ErrExchangeRateNotFound) {
+		t.Fatalf("expected ErrExchangeRateNotFound, got %v", err)
+	}
+	if tx.Amount != NewMoney(6.4) || tx.Currency != CurrencyGBP {
+		t.Fatalf("expected the transaction unchanged on error, got %s %v", tx.Currency, tx.Amount)
+	}
+}
+
+func TestTransactionConvertToLowRate(t *testing.T) {
+	rates := RateTable{{Base: CurrencyEUR, Quote: CurrencyJPY, Rate: 160}}
+
+	// 10 JPY split in four lines with JPY as the base currency, then changed to EUR
+	tx := Transaction{Type: TypeExpense, Amount: NewMoney(10), Currency: CurrencyJPY, OriginalAmount: NewMoney(10), OriginalCurrency: CurrencyJPY}
+	err := tx.SetSplits([]TransactionSplit{
+		{Category: CategoryHealth, Amount: NewMoney(2.5)},
+		{Category: CategoryToiletry, Amount: NewMoney(2.5)},
+		{Category: CategoryGrocery, Amount: NewMoney(2.5)},
+		{Category: CategoryHouse, Amount: NewMoney(2.5)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := tx.ConvertTo(CurrencyEUR, rates); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 10 JPY is 0.0625 EUR, rounded to 0.06: each line is 0.015, the hundredths
+	// left over go to the first lines with the same fractional part
+	want := moneys(0.02, 0.02, 0.01, 0.01)
+	for i, s := range tx.Splits {
+		if s.Amount != want[i] {
+			t.Fatalf("line %d: expected %v, got %v", i, want[i], s.Amount)
+		}
+	}
+	if err := tx.ValidateSplits(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	// 5 JPY is 0.03 EUR, too little for four lines: the transaction is left unchanged
+	tx = Transaction{Type: TypeExpense, Amount: NewMoney(5), Currency: CurrencyJPY, OriginalAmount: NewMoney(5), OriginalCurrency: CurrencyJPY}
+	err = tx.SetSplits([]TransactionSplit{
+		{Category: CategoryHealth, Amount: NewMoney(1.25)},
+		{Category: CategoryToiletry, Amount: NewMoney(1.25)},
+		{Category: CategoryGrocery, Amount: NewMoney(1.25)},
+		{Category: CategoryHouse, Amount: NewMoney(1.25)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before := slices.Clone(tx.Splits)
+	if err := tx.ConvertTo(CurrencyEUR, rates); !errors.Is(err, ErrInvalidSplit) {
+		t.Fatalf("expected ErrInvalidSplit, got %v", err)
+	}
+	if tx.Amount != NewMoney(5) || tx.Currency != CurrencyJPY || !slices.Equal(tx.Splits, before) {
+		t.Fatalf("expected the transaction unchanged on error, got %v %+v", tx.Amount, tx.Splits)
+	}
+}
+
+func TestSplitAmountsLargestRemainder(t *testing.T) {
+	lines := []TransactionSplit{
+		{OriginalAmount: NewMoney(1)},
+		{OriginalAmount: NewMoney(1)},
+		{OriginalAmount: NewMoney(8)},
+	}
+
+	// 10.00 at a rate of 0.333: the exact shares are 0.333, 0.333 and 2.664,
+	// the hundredth left over goes to the largest fraction, the last line
+	got, err := splitAmounts(lines, NewMoney(3.33), NewMoney(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := moneys(0.33, 0.33, 2.67); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestTransactionSetSplitsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		tx    Transaction
+		lines []TransactionSplit
+	}{
+		{
+			name:  "single line",
+			tx:    Transaction{Type: TypeExpense, Amount: NewMoney(10), OriginalAmount: NewMoney(10)},
+			lines: []TransactionSplit{{Category: CategoryGrocery, Amount: NewMoney(10)}},
+		},
+		{
+			name:  "wrong sum",
+			tx:    Transaction{Type: TypeExpense, Amount: NewMoney(10), OriginalAmount: NewMoney(10)},
+			lines: []TransactionSplit{{Category: CategoryGrocery, Amount: NewMoney(5)}, {Category: CategoryHouse, Amount: NewMoney(4.99)}},
+		},
+		{
+			name:  "zero amount",
+			tx:    Transaction{Type: TypeExpense, Amount: NewMoney(10), OriginalAmount: NewMoney(10)},
+			lines: []TransactionSplit{{Category: CategoryGrocery, Amount: NewMoney(10)}, {Category: CategoryHouse}},
+		},
+		{
+			name:  "missing category",
+			tx:    Transaction{Type: TypeExpense, Amount: NewMoney(10), OriginalAmount: NewMoney(10)},
+			lines: []TransactionSplit{{Category: CategoryGrocery, Amount: NewMoney(5)}, {Category: " ", Amount: NewMoney(5)}},
+		},
+		{
+			name:  "transfer",
+			tx:    Transaction{Type: TypeTransfer, Amount: NewMoney(10), OriginalAmount: NewMoney(10)},
+			lines: []TransactionSplit{{Category: CategoryGrocery, Amount: NewMoney(5)}, {Category: CategoryHouse, Amount: NewMoney(5)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tx.SetSplits(tt.lines); !errors.Is(err, ErrInvalidSplit) {
+				t.Fatalf("expected ErrInvalidSplit, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransactionCategoryAmounts(t *testing.T) {
+	tx := Transaction{Type: TypeExpense, Category: CategoryGrocery, Amount: NewMoney(50)}
+	if got := tx.CategoryAmounts(); len(got) != 1 || got[CategoryGrocery] != NewMoney(50) {
+		t.Fatalf("expected the whole amount in its category, got %v", got)
+	}
+
+	tx.Splits = []TransactionSplit{
+		{Category: CategoryGrocery, Amount: NewMoney(20)},
+		{Category: CategoryHouse, Amount: NewMoney(12.5)},
+		{Category: CategoryGrocery, Amount: NewMoney(17.5)},
+	}
+	got := tx.CategoryAmounts()
+	if len(got) != 2 || got[CategoryGrocery] != NewMoney(37.5) || got[CategoryHouse] != NewMoney(12.5) {
+		t.Fatalf("expected the amounts of the lines by category, got %v", got)
+	}
+}
+
+func TestTransactionRenameCategory(t *testing.T) {
+	tx := Transaction{
+		Type:     TypeExpense,
+		Category: CategoryGrocery,
+		Splits: []TransactionSplit{
+			{Category: CategoryGrocery, Amount: NewMoney(20)},
+			{Category: CategoryHouse, Amount: NewMoney(10)},
+		},
+	}
+	tx.RenameCategory(CategoryGrocery, "Food")
+	if tx.Category != "Food" || tx.Splits[0].Category != "Food" || tx.Splits[1].Category != CategoryHouse {
+		t.Fatalf("expected only the renamed category to change, got %s %v", tx.Category, tx.Splits)
+	}
+}
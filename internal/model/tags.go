@@ -0,0 +1,123 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxTagNameLength is the maximum length in bytes of a tag name
+const MaxTagNameLength = 32
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+// tagNameRegex matches the normalized tag names: letters, digits, "_" and "-"
+var tagNameRegex = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// hashtagRegex matches the hashtags in a free text, optionally preceded by a
+// "-" to exclude them in the search queries
+var hashtagRegex = regexp.MustCompile(`(^|\s)(-?)#([\p{L}\p{N}_-]+)`)
+
+// Tag represents the tags table structure, a free-form label the user can
+// put on any number of transactions, across categories
+type Tag struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID      int64     `gorm:"column:tg_id;not null;uniqueIndex:idx_tags_tg_id_name"`
+	Name      string    `gorm:"column:name;not null;size:32;uniqueIndex:idx_tags_tg_id_name"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName overrides the table name
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// NormalizeTagName lowercases the tag and removes the leading "#", returning
+// an error when the result is not a valid tag name
+func NormalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	switch {
+	case name == "":
+		return "", fmt.Errorf("%w: name cannot be empty", ErrInvalidTag)
+	case len(name) > MaxTagNameLength:
+		return "", fmt.Errorf("%w: name cannot be longer than %d characters", ErrInvalidTag, MaxTagNameLength)
+	case !tagNameRegex.MatchString(name):
+		return "", fmt.Errorf("%w: %q can only contain letters, digits, \"_\" and \"-\"", ErrInvalidTag, name)
+	}
+	return name, nil
+}
+
+// NormalizeTagNames normalizes and deduplicates the tag names, keeping their order
+func NormalizeTagNames(names []string) ([]string, error) {
+	res := make([]string, 0, len(names))
+	for _, name := range names {
+		n, err := NormalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(res, n) {
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
+
+// ParseTagQuery splits a free text into the text without hashtags, the tags
+// to include ("#work") and the ones to exclude ("-#work"). Invalid hashtags,
+// e.g. too long ones, are dropped.
+func ParseTagQuery(text string) (rest string, include, exclude []string) {
+	for _, m := range hashtagRegex.FindAllStringSubmatch(text, -1) {
+		name, err := NormalizeTagName(m[3])
+		if err != nil {
+			continue
+		}
+		if m[2] == "-" {
+			if !slices.Contains(exclude, name) {
+				exclude = append(exclude, name)
+			}
+			continue
+		}
+		if !slices.Contains(include, name) {
+			include = append(include, name)
+		}
+	}
+
+	rest = strings.Join(strings.Fields(hashtagRegex.ReplaceAllString(text, "$1")), " ")
+	return rest, include, exclude
+}
+
+// ParseHashtags returns the tags mentioned in a free text with "#name"
+func ParseHashtags(text string) []string {
+	_, include, _ := ParseTagQuery(text)
+	return include
+}
+
+// TagsFromNames returns the tags with the given names, not stored yet
+func TagsFromNames(tgID int64, names []string) []Tag {
+	tags := make([]Tag, len(names))
+	for i, name := range names {
+		tags[i] = Tag{TgID: tgID, Name: name}
+	}
+	return tags
+}
+
+// TagNames returns the names of the tags of the transaction
+func (t Transaction) TagNames() []string {
+	names := make([]string, len(t.Tags))
+	for i, tag := range t.Tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// FormatTags renders the tags of the transaction as hashtags, e.g. "#work #travel"
+func (t Transaction) FormatTags() string {
+	names := t.TagNames()
+	for i, name := range names {
+		names[i] = "#" + name
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTagName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", input: "work", want: "work"},
+		{name: "hashtag", input: "#work", want: "work"},
+		{name: "uppercase and spaces", input: "  #Wedding ", want: "wedding"},
+		{name: "underscore and dash", input: "trip_2026-rome", want: "trip_2026-rome"},
+		{name: "unicode letters", input: "#Città", want: "città"},
+		{name: "empty", input: "#", wantErr: true},
+		{name: "space inside", input: "new york", wantErr: true},
+		{name: "punctuation", input: "work!", wantErr: true},
+		{name: "too long", input: strings.Repeat("a", MaxTagNameLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTagName(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeTagName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTag) {
+				t.Errorf("NormalizeTagName(%q) error = %v, want ErrInvalidTag", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeTagName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTagNames(t *testing.T) {
+	got, err := NormalizeTagNames([]string{"#Work", "travel", "work", "#TRAVEL"})
+	if err != nil {
+		t.Fatalf("NormalizeTagNames() error = %v", err)
+	}
+	if strings.Join(got, ",") != "work,travel" {
+		t.Errorf("NormalizeTagNames() = %v, want [work travel]", got)
+	}
+
+	if _, err := NormalizeTagNames([]string{"work", "not valid"}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("NormalizeTagNames() error = %v, want ErrInvalidTag", err)
+	}
+}
+
+func TestParseTagQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantRest    string
+		wantInclude []string
+		wantExclude []string
+	}{
+		{name: "no tags", input: "taxi 35", wantRest: "taxi 35"},
+		{name: "trailing tags", input: "taxi 35 #work #Reimbursable", wantRest: "taxi 35", wantInclude: []string{"work", "reimbursable"}},
+		{name: "tag in the middle", input: "dinner #wedding 120", wantRest: "dinner 120", wantInclude: []string{"wedding"}},
+		{name: "excluded tag", input: "taxi #work -#reimbursed", wantRest: "taxi", wantInclude: []string{"work"}, wantExclude: []string{"reimbursed"}},
+		{name: "duplicated tags", input: "#work #WORK", wantRest: "", wantInclude: []string{"work"}},
+		{name: "hash inside a word is not a tag", input: "item#3 c#", wantRest: "item#3 c#"},
+		{name: "lone hash", input: "# 5", wantRest: "# 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, include, exclude := ParseTagQuery(tt.input)
+			if rest != tt.wantRest {
+				t.Errorf("rest = %q, want %q", rest, tt.wantRest)
+			}
+			if strings.Join(include, ",") != strings.Join(tt.wantInclude, ",") {
+				t.Errorf("include = %v, want %v", include, tt.wantInclude)
+			}
+			if strings.Join(exclude, ",") != strings.Join(tt.wantExclude, ",") {
+				t.Errorf("exclude = %v, want %v", exclude, tt.wantExclude)
+			}
+		})
+	}
+}
+
+func TestParseHashtags(t *testing.T) {
+	got := ParseHashtags("taxi 35 #work -#personal #Travel")
+	if strings.Join(got, ",") != "work,travel" {
+		t.Errorf("ParseHashtags() = %v, want [work travel]", got)
+	}
+}
+
+func TestTransactionFormatTags(t *testing.T) {
+	tx := Transaction{Tags: TagsFromNames(1, []string{"work", "travel"})}
+	if got := tx.FormatTags(); got != "#work #travel" {
+		t.Errorf("FormatTags() = %q, want %q", got, "#work #travel")
+	}
+	if got := (Transaction{}).FormatTags(); got != "" {
+		t.Errorf("FormatTags() without tags = %q, want empty", got)
+	}
+}
@@ -7,10 +7,18 @@ import (
 	"time"
 )
 
-// TransactionCategory represents the category of an transaction or income
+// ErrTransactionNotFound is returned for missing transactions and the ones of other users
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// TrashRetention is how long deleted transactions stay in the trash, where
+// they can be restored, before being purged
+const TrashRetention = 30 * 24 * time.Hour
+
+// TransactionCategory represents the category of an transaction or income,
+// the name of one of the user's categories
 type TransactionCategory string
 
-// Transaction categories
+// Default transaction categories, seeded for every user
 const (
 	CategorySalary        TransactionCategory = "Salary"
 	CategoryOtherIncomes  TransactionCategory = "OtherIncomes"
@@ -33,6 +41,11 @@ const (
 	CategoryOtherExpenses TransactionCategory = "OtherExpenses"
 )
 
+// CategoryTransfer is the category of every transfer between accounts, it is
+// not one of the user's categories
+const CategoryTransfer TransactionCategory = "Transfer"
+
+// IsValidTransactionCategory reports whether the category is one of the default ones
 func IsValidTransactionCategory(category string) bool {
 	return slices.Contains(GetTransactionCategories(), category)
 }
@@ -64,6 +77,9 @@ type TransactionType string
 const (
 	TypeIncome  TransactionType = "Income"
 	TypeExpense TransactionType = "Expense"
+	// TypeTransfer moves money between two accounts of the user, it is
+	// neither an income nor an expense
+	TypeTransfer TransactionType = "Transfer"
 )
 
 // Value implements the driver.Valuer interface for TransactionType
@@ -118,18 +134,62 @@ func (t *CurrencyType) Scan(value any) error {
 	return nil
 }
 
-// Transaction represents the transactions table structure
+// IsValidCurrency reports whether the currency is one of the supported ones
+func IsValidCurrency(currency string) bool {
+	return slices.Contains(GetCurrencyTypes(), currency)
+}
+
+// Symbol returns the display symbol for the currency, falling back to its code
+func (t CurrencyType) Symbol() string {
+	switch t {
+	case CurrencyEUR:
+		return "€"
+	case CurrencyUSD:
+		return "$"
+	case CurrencyGBP:
+		return "£"
+	case CurrencyJPY:
+		return "¥"
+	default:
+		return string(t)
+	}
+}
+
+// Transaction represents the transactions table structure.
+// Amount and Currency are always expressed in the user's base currency, while
+// OriginalAmount and OriginalCurrency keep the value as entered by the user.
+// AccountID is the optional account the money comes from (expenses and
+// transfers) or goes to (incomes), ToAccountID the destination of a transfer.
+// RecurringRuleID is set on the transactions created by a recurring rule,
+// SettlementID on the ones recording a settlement between users.
+// LedgerID is the shared ledger the transaction is recorded in, TgID is then
+// the member who entered it.
+// DeletedAt is set while the transaction is in the trash, see TrashRetention.
 type Transaction struct {
-	ID          int64               `gorm:"column:id;primaryKey;autoIncrement"`
-	TgID        int64               `gorm:"column:tg_id;not null;index"`
-	Date        time.Time           `gorm:"column:date;not null;type:date;default:CURRENT_DATE;index"`
-	Type        TransactionType     `gorm:"column:type;not null;type:transaction_type;index"`
-	Category    TransactionCategory `gorm:"column:category;not null;type:transaction_category;index"`
-	Amount      float64             `gorm:"column:amount;not null;type:decimal(15,2)"`
-	Currency    CurrencyType        `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
-	Description string              `gorm:"column:description;type:text"`
-	CreatedAt   time.Time           `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt   time.Time           `gorm:"column:updated_at;autoUpdateTime"`
+	ID               int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	TgID             int64               `gorm:"column:tg_id;not null;index"`
+	Date             time.Time           `gorm:"column:date;not null;type:date;default:CURRENT_DATE;index"`
+	Type             TransactionType     `gorm:"column:type;not null;type:transaction_type;index"`
+	Category         TransactionCategory `gorm:"column:category;not null;size:32;index"`
+	Amount           Money               `gorm:"column:amount;not null;type:decimal(15,2)"`
+	Currency         CurrencyType        `gorm:"column:currency;not null;type:currency_type;default:'EUR'"`
+	OriginalAmount   Money               `gorm:"column:original_amount;not null;type:decimal(15,2)"`
+	OriginalCurrency CurrencyType        `gorm:"column:original_currency;not null;type:currency_type;default:'EUR'"`
+	Description      string              `gorm:"column:description;type:text"`
+	AccountID        *int64              `gorm:"column:account_id;index"`
+	ToAccountID      *int64              `gorm:"column:to_account_id;index"`
+	RecurringRuleID  *int64              `gorm:"column:recurring_rule_id"`
+	SettlementID     *int64              `gorm:"column:settlement_id"`
+	LedgerID         *int64              `gorm:"column:ledger_id;index"`
+	DeletedAt        *time.Time          `gorm:"column:deleted_at"`
+	CreatedAt        time.Time           `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt        time.Time           `gorm:"column:updated_at;autoUpdateTime"`
+
+	// Tags are free-form labels shared across categories
+	Tags []Tag `gorm:"many2many:transaction_tags"`
+
+	// Splits are the lines of a transaction split across several categories, see SetSplits
+	Splits []TransactionSplit `gorm:"foreignKey:TransactionID"`
 
 	// Association to User (optional)
 	User *User `gorm:"foreignKey:TgID;references:TgID"`
@@ -140,7 +200,92 @@ func (Transaction) TableName() string {
 	return "transactions"
 }
 
-// GetTransactionCategories returns all categories
+// Scope returns the scope the transaction is recorded in: its ledger if it is
+// recorded in one, its user otherwise
+func (t Transaction) Scope() Scope {
+	return Scope{TgID: t.TgID, LedgerID: t.LedgerID}
+}
+
+// BudgetScopes returns the scopes whose budgets the transaction counts
+// towards: its own, and for the ledger transactions the personal one of
+// their user too, as the personal totals count every transaction of the user
+func (t Transaction) BudgetScopes() []Scope {
+	scopes := []Scope{t.Scope()}
+	if t.LedgerID != nil {
+		scopes = append(scopes, Scope{TgID: t.TgID})
+	}
+	return scopes
+}
+
+// IsTransfer reports whether the transaction moves money between accounts,
+// transfers are left out of balances, recaps and budgets
+func (t Transaction) IsTransfer() bool {
+	return t.Type == TypeTransfer
+}
+
+// IsTrashed reports whether the transaction is in the trash
+func (t Transaction) IsTrashed() bool {
+	return t.DeletedAt != nil
+}
+
+// PurgeDate returns when a transaction in the trash is permanently deleted
+func (t Transaction) PurgeDate() time.Time {
+	if t.DeletedAt == nil {
+		return time.Time{}
+	}
+	return t.DeletedAt.Add(TrashRetention)
+}
+
+// IsForeign reports whether the transaction was entered in a currency other than the base one
+func (t Transaction) IsForeign() bool {
+	return t.OriginalCurrency != "" && t.OriginalCurrency != t.Currency
+}
+
+// SetBaseAmount overwrites the amount in the base currency, which also becomes the original value
+func (t *Transaction) SetBaseAmount(amount Money) {
+	t.Amount = amount
+	t.OriginalAmount = amount
+	t.OriginalCurrency = t.Currency
+}
+
+// BaseAmount returns the amount of the transaction in the base currency
+func (t Transaction) BaseAmount() Amount {
+	return NewAmount(t.Amount, t.Currency)
+}
+
+// EnteredAmount returns the amount of the transaction as entered, the base
+// amount for the transactions with no original value
+func (t Transaction) EnteredAmount() Amount {
+	if t.OriginalCurrency == "" {
+		return t.BaseAmount()
+	}
+	return NewAmount(t.OriginalAmount, t.OriginalCurrency)
+}
+
+// ConvertTo sets the amount of the transaction and of its split lines in
+// another base currency, converted from the original amounts with the rates.
+// The transaction is left unchanged if the converted lines are not valid.
+func (t *Transaction) ConvertTo(base CurrencyType, rates RateTable) error {
+	amount, err := rates.ConvertAmount(t.EnteredAmount(), base)
+	if err != nil {
+		return err
+	}
+
+	converted := *t
+	converted.Amount = amount.Money
+	converted.Currency = amount.Currency
+	converted.Splits = slices.Clone(t.Splits)
+	if err := converted.ConvertSplits(); err != nil {
+		return err
+	}
+	if err := converted.ValidateSplits(); err != nil {
+		return err
+	}
+	*t = converted
+	return nil
+}
+
+// GetTransactionCategories returns all default categories
 func GetTransactionCategories() []string {
 	return []string{
 		string(CategorySalary),
@@ -170,6 +315,7 @@ func GetTransactionTypes() []string {
 	return []string{
 		string(TypeIncome),
 		string(TypeExpense),
+		string(TypeTransfer),
 	}
 }
 
@@ -184,7 +330,7 @@ func GetCurrencyTypes() []string {
 	}
 }
 
-// GetIncomeCategories returns only income categories
+// GetIncomeCategories returns only default income categories
 func GetIncomeCategories() []string {
 	return []string{
 		string(CategorySalary),
@@ -192,7 +338,7 @@ func GetIncomeCategories() []string {
 	}
 }
 
-// GetExpenseCategories returns only expense categories
+// GetExpenseCategories returns only default expense categories
 func GetExpenseCategories() []string {
 	return []string{
 		string(CategoryCar),
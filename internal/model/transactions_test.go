@@ -1,6 +1,10 @@
 package model
 
-import "testing"
+import (
+	"slices"
+	"testing"
+	"time"
+)
 
 func TestIsValidTransactionCategory(t *testing.T) {
 	tests := []struct {
@@ -85,3 +89,53 @@ func TestTransactionTypeValue(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionPurgeDate(t *testing.T) {
+	deletedAt := time.Date(2026, 5, 21, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		transaction Transaction
+		wantTrashed bool
+		want        time.Time
+	}{
+		{
+			name:        "not in the trash",
+			transaction: Transaction{},
+			wantTrashed: false,
+			want:        time.Time{},
+		},
+		{
+			name:        "in the trash",
+			transaction: Transaction{DeletedAt: &deletedAt},
+			wantTrashed: true,
+			want:        time.Date(2026, 6, 20, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.transaction.IsTrashed(); got != tt.wantTrashed {
+				t.Errorf("IsTrashed() = %v, want %v", got, tt.wantTrashed)
+			}
+			if got := tt.transaction.PurgeDate(); !got.Equal(tt.want) {
+				t.Errorf("PurgeDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionBudgetScopes(t *testing.T) {
+	personal := Transaction{TgID: 1, Type: TypeExpense}
+	if got := personal.BudgetScopes(); !slices.Equal(got, []Scope{{TgID: 1}}) {
+		t.Errorf("BudgetScopes() of a personal expense = %+v, want the personal scope only", got)
+	}
+
+	// A ledger expense counts in the personal totals of its user too, so it
+	// is evaluated against their personal budgets as well
+	ledgerID := int64(7)
+	shared := Transaction{TgID: 1, LedgerID: &ledgerID, Type: TypeExpense}
+	if got := shared.BudgetScopes(); !slices.Equal(got, []Scope{{TgID: 1, LedgerID: &ledgerID}, {TgID: 1}}) {
+		t.Errorf("BudgetScopes() of a ledger expense = %+v, want the ledger and the personal scopes", got)
+	}
+}
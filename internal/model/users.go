@@ -5,6 +5,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -29,6 +30,8 @@ const (
 	StateTopLevelEditingTransactionDate        StateType = "top_level_editing_transaction_date"
 	StateTopLevelEditingTransactionAmount      StateType = "top_level_editing_transaction_amount"
 	StateTopLevelEditingTransactionDescription StateType = "top_level_editing_transaction_description"
+	// The user is entering the split lines of the transaction, during an edit flow
+	StateTopLevelEditingTransactionSplit StateType = "top_level_editing_transaction_split"
 	// Search-related states
 	StateSelectingSearchCategory StateType = "selecting_search_category"
 	StateEnteringSearchQuery     StateType = "entering_search_query"
@@ -46,24 +49,98 @@ const (
 	StateWaitingConfirm StateType = "waiting_confirm"
 	// The user is editing a newly added transaction.
 	StateEditingNewTransaction StateType = "editing_new_transaction"
+	// The user selected a transaction with /edit and sees its edit options.
+	// The body holds its ID.
+	StateEditingTransaction StateType = "editing_transaction"
+	// The user is reviewing the transactions of a message listing several of
+	// them, before saving them. The body holds the batch.
+	StateBatchPreview StateType = "batch_preview"
+	// The user is entering again a line of the batch being reviewed.
+	StateBatchEditingLine StateType = "batch_editing_line"
 	// The user is entering the amount for their monthly budget.
 	StateBudgetSetWaitAmount StateType = "budget_set_wait_amount"
+	// The user is entering the name of a new category, the body holds its type.
+	StateCategoryNewWaitName StateType = "category_new_wait_name"
+	// The user is entering the new name of a category, the body holds its ID.
+	StateCategoryRenameWaitName StateType = "category_rename_wait_name"
+	// The user is entering the details of a new account.
+	StateAccountNewWaitDetails StateType = "account_new_wait_details"
+	// The user is entering the amount of a transfer, the body holds "<from ID>.<to ID>".
+	StateAccountTransferWaitAmount StateType = "account_transfer_wait_amount"
+	// The user is creating a recurring rule from the inline keyboards, the body
+	// holds "<type>.<frequency>.<day>.<category>" as the choices are made.
+	StateRecurringNew StateType = "recurring_new"
+	// The user is entering the amount and description of a new recurring rule.
+	StateRecurringWaitDetails StateType = "recurring_wait_details"
+	// The user is entering the name of a new shared ledger.
+	StateLedgerNewWaitName StateType = "ledger_new_wait_name"
+	// The user is entering the invite code of a shared ledger.
+	StateLedgerJoinWaitCode StateType = "ledger_join_wait_code"
+	// The user is entering the details of a new savings goal.
+	StateGoalNewWaitDetails StateType = "goal_new_wait_details"
+	// The user is entering a contribution to a goal, the body holds its ID.
+	StateGoalContributeWaitAmount StateType = "goal_contribute_wait_amount"
+	// The user is entering the details of a new asset or liability.
+	StateHoldingNewWaitDetails StateType = "holding_new_wait_details"
+	// The user is entering the balance of an asset or a liability, the body
+	// holds its ID.
+	StateHoldingWaitBalance StateType = "holding_wait_balance"
 )
 
+// DefaultTimezone is the timezone of the users who have not set theirs
+const DefaultTimezone = "UTC"
+
+// ErrInvalidTimezone is returned for the names that are not IANA timezones
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// ErrNoEmail is returned when emailing a user without a registered email
+var ErrNoEmail = errors.New("no email registered")
+
+// LoadTimezone returns the location of an IANA timezone name, e.g. "Europe/Rome"
+func LoadTimezone(name string) (*time.Location, error) {
+	// "Local" is the timezone of the server, not a timezone a user can be in
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// DateOf returns the calendar day of t at midnight UTC, the way the dates of
+// the transactions are stored
+func DateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
 // CommandType represents the type of command sent by the user
 type CommandType string
 
-// User represents the users table structure
+// User represents the users table structure.
+// BaseCurrency is the currency every aggregate, recap and budget of the user is expressed in.
+// DefaultAccountID is the account new transactions are assigned to, if any.
+// LedgerID is the active shared ledger, if any: new transactions are recorded
+// in it and the recaps and budget are the ledger's ones.
+// Timezone is the IANA name of the user's timezone, which sets their "today",
+// the boundaries of the weeks and months and when the recaps are delivered.
+// BudgetAlertEmail sends the budget alerts to Email too, besides Telegram.
 type User struct {
-	TgID        int64       `gorm:"column:tg_id;primaryKey"`
-	TgUsername  string      `gorm:"column:tg_username;unique"`
-	TgFirstname string      `gorm:"column:tg_firstname"`
-	TgLastname  string      `gorm:"column:tg_lastname"`
-	Name        string      `gorm:"column:name"`
-	Email       *string     `gorm:"column:email;uniqueIndex:idx_users_email,where:email IS NOT NULL"`
-	Session     UserSession `gorm:"column:session;type:jsonb"`
-	CreatedAt   time.Time   `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt   time.Time   `gorm:"column:updated_at;autoUpdateTime"`
+	TgID             int64        `gorm:"column:tg_id;primaryKey"`
+	TgUsername       string       `gorm:"column:tg_username;unique"`
+	TgFirstname      string       `gorm:"column:tg_firstname"`
+	TgLastname       string       `gorm:"column:tg_lastname"`
+	Name             string       `gorm:"column:name"`
+	Email            *string      `gorm:"column:email;uniqueIndex:idx_users_email,where:email IS NOT NULL"`
+	Session          UserSession  `gorm:"column:session;type:jsonb"`
+	BaseCurrency     CurrencyType `gorm:"column:base_currency;not null;type:currency_type;default:'EUR'"`
+	DefaultAccountID *int64       `gorm:"column:default_account_id"`
+	LedgerID         *int64       `gorm:"column:ledger_id"`
+	Timezone         string       `gorm:"column:timezone;not null;size:64;default:'UTC'"`
+	BudgetAlertEmail bool         `gorm:"column:budget_alert_email;not null;default:false"`
+	CreatedAt        time.Time    `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt        time.Time    `gorm:"column:updated_at;autoUpdateTime"`
 
 	// WebAuthn credentials (loaded via preload)
 	// Note: Foreign key constraints are handled in migration files
@@ -125,3 +202,40 @@ func (j *JSONData) Scan(value any) error {
 func (User) TableName() string {
 	return "users"
 }
+
+// Scope returns the scope of the user's recaps and budget, their active ledger if any
+func (u User) Scope() Scope {
+	return Scope{TgID: u.TgID, LedgerID: u.LedgerID}
+}
+
+// Location returns the user's timezone, UTC when it is not set or no longer exists
+func (u User) Location() *time.Location {
+	loc, err := LoadTimezone(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Now returns the current time in the user's timezone
+func (u User) Now() time.Time {
+	return time.Now().In(u.Location())
+}
+
+// Today returns the current day in the user's timezone, see DateOf
+func (u User) Today() time.Time {
+	return DateOf(u.Now())
+}
+
+// DisplayName returns the name of the user shown to other users: their name,
+// their Telegram username or, lacking both, their Telegram ID
+func (u User) DisplayName() string {
+	switch {
+	case u.Name != "":
+		return u.Name
+	case u.TgUsername != "":
+		return "@" + u.TgUsername
+	default:
+		return fmt.Sprintf("User %d", u.TgID)
+	}
+}
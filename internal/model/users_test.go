@@ -1,7 +1,9 @@
 package model
 
 import (
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestUserSessionValueAndScan(t *testing.T) {
@@ -78,3 +80,66 @@ func TestUserSessionValueAndScan(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadTimezone(t *testing.T) {
+	tests := []struct {
+		name    string
+		tz      string
+		wantErr bool
+	}{
+		{name: "IANA name", tz: "Europe/Rome"},
+		{name: "UTC", tz: "UTC"},
+		{name: "empty", tz: "", wantErr: true},
+		{name: "server local time", tz: "Local", wantErr: true},
+		{name: "unknown", tz: "Mars/Olympus_Mons", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := LoadTimezone(tt.tz)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimezone) {
+					t.Fatalf("LoadTimezone(%q) error = %v, want ErrInvalidTimezone", tt.tz, err)
+				}
+				return
+			}
+			if err != nil || loc.String() != tt.tz {
+				t.Fatalf("LoadTimezone(%q) = %v, %v", tt.tz, loc, err)
+			}
+		})
+	}
+}
+
+func TestUserLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		want     string
+	}{
+		{name: "set", timezone: "Asia/Tokyo", want: "Asia/Tokyo"},
+		{name: "not set", timezone: "", want: "UTC"},
+		{name: "no longer exists", timezone: "Nowhere/Gone", want: "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (User{Timezone: tt.timezone}).Location().String(); got != tt.want {
+				t.Errorf("Location() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateOf(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+
+	// 00:30 in Rome is still the previous day in UTC
+	got := DateOf(time.Date(2026, 5, 21, 0, 30, 0, 0, rome))
+	want := time.Date(2026, 5, 21, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("DateOf() = %v, want %v", got, want)
+	}
+}
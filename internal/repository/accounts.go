@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cashout/internal/model"
+)
+
+type Accounts struct {
+	Repository
+}
+
+// List returns all the accounts of a user, archived ones included
+func (r *Accounts) List(tgID int64) (model.Accounts, error) {
+	accounts, err := r.DB.GetAccounts(tgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// Get returns an account of the user or model.ErrAccountNotFound
+func (r *Accounts) Get(tgID, id int64) (model.Account, error) {
+	account, err := r.DB.GetAccountByID(id)
+	if err != nil {
+		return account, err
+	}
+	if account.TgID != tgID {
+		return model.Account{}, model.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// Create validates and stores a new account
+func (r *Accounts) Create(account *model.Account) error {
+	account.Normalize()
+	if err := account.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.checkNameAvailable(account.TgID, 0, account.Name); err != nil {
+		return err
+	}
+
+	return r.DB.CreateAccount(account)
+}
+
+// Update validates and stores the changes to an account, its currency cannot
+// change as the balance would lose its meaning
+func (r *Accounts) Update(account *model.Account) error {
+	existing, err := r.Get(account.TgID, account.ID)
+	if err != nil {
+		return err
+	}
+
+	account.Normalize()
+	if account.Currency != existing.Currency {
+		return fmt.Errorf("%w: %w", model.ErrInvalidAccount, model.ErrAccountCurrency)
+	}
+	if err := account.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.checkNameAvailable(account.TgID, account.ID, account.Name); err != nil {
+		return err
+	}
+
+	account.CreatedAt = existing.CreatedAt
+	return r.DB.UpdateAccount(account)
+}
+
+// Delete removes an account that no transaction uses, used ones can only be archived
+func (r *Accounts) Delete(tgID, id int64) error {
+	account, err := r.Get(tgID, id)
+	if err != nil {
+		return err
+	}
+	return r.DB.DeleteAccount(account)
+}
+
+// SetDefault sets the account new transactions of the user are assigned to,
+// nil unsets it
+func (r *Accounts) SetDefault(tgID int64, id *int64) error {
+	if id != nil {
+		account, err := r.Get(tgID, *id)
+		if err != nil {
+			return err
+		}
+		if account.Archived {
+			return model.ErrAccountArchived
+		}
+	}
+	return r.DB.SetUserDefaultAccount(tgID, id)
+}
+
+// Balance returns the current balance of the account in its currency
+func (r *Accounts) Balance(account model.Account) (model.Money, error) {
+	start, entries, err := r.Ledger(account, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return start, nil
+	}
+	return entries[len(entries)-1].Balance, nil
+}
+
+// Ledger returns the running balance of the account between two dates
+// (inclusive, nil for no bound), along with the balance before the first entry
+func (r *Accounts) Ledger(account model.Account, from, to *time.Time) (model.Money, []model.LedgerEntry, error) {
+	transactions, err := r.DB.GetAccountTransactions(account.TgID, account.ID, to)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get account transactions: %w", err)
+	}
+
+	rates, err := r.DB.GetExchangeRates()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	entries, err := account.Ledger(transactions, rates, account.OpeningBalance)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to compute account balance: %w", err)
+	}
+
+	start := account.OpeningBalance
+	if from == nil {
+		return start, entries, nil
+	}
+
+	i := 0
+	for ; i < len(entries) && entries[i].Transaction.Date.Before(*from); i++ {
+		start = entries[i].Balance
+	}
+	return start, entries[i:], nil
+}
+
+// checkNameAvailable makes sure no other account of the user has the same
+// name, ignoring case as for categories
+func (r *Accounts) checkNameAvailable(tgID, id int64, name string) error {
+	accounts, err := r.List(tgID)
+	if err != nil {
+		return err
+	}
+	for _, a := range accounts {
+		if a.ID != id && strings.EqualFold(a.Name, name) {
+			return model.ErrAccountExists
+		}
+	}
+	return nil
+}
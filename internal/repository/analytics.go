@@ -7,11 +7,16 @@ import (
 )
 
 // GetCategoryAggregates exposes per-category aggregates (amount + count).
-func (r *Transactions) GetCategoryAggregates(tgID int64, startDate, endDate time.Time, t model.TransactionType) ([]db.CategoryAggregate, error) {
-	return r.DB.GetCategoryAggregates(tgID, startDate, endDate, t)
+func (r *Transactions) GetCategoryAggregates(scope model.Scope, startDate, endDate time.Time, t model.TransactionType) ([]db.CategoryAggregate, error) {
+	return r.DB.GetCategoryAggregates(scope, startDate, endDate, t)
+}
+
+// GetTagAggregates exposes per-tag aggregates (amount + count).
+func (r *Transactions) GetTagAggregates(scope model.Scope, startDate, endDate time.Time, t model.TransactionType) ([]db.TagAggregate, error) {
+	return r.DB.GetTagAggregates(scope, startDate, endDate, t)
 }
 
 // GetMonthlyTotalsByRange exposes month/type pivoted totals over a date range.
-func (r *Transactions) GetMonthlyTotalsByRange(tgID int64, startDate, endDate time.Time) ([]db.MonthTotal, error) {
-	return r.DB.GetMonthlyTotalsByRange(tgID, startDate, endDate)
+func (r *Transactions) GetMonthlyTotalsByRange(scope model.Scope, startDate, endDate time.Time) ([]db.MonthTotal, error) {
+	return r.DB.GetMonthlyTotalsByRange(scope, startDate, endDate)
 }
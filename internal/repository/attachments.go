@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type Attachments struct {
+	Repository
+}
+
+// transaction returns the transaction of the user the attachments belong to
+func (r *Attachments) transaction(tgID, transactionID int64) (model.Transaction, error) {
+	transaction, err := r.DB.GetTransactionByID(transactionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Transaction{}, model.ErrTransactionNotFound
+	}
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction.TgID != tgID {
+		return model.Transaction{}, model.ErrTransactionNotFound
+	}
+	return *transaction, nil
+}
+
+// Add stores a photo or a PDF document as an attachment of a transaction of
+// the user, model.ErrAttachmentType and model.ErrAttachmentTooLarge are
+// returned for the other files
+func (r *Attachments) Add(tgID, transactionID int64, fileName, contentType string, content io.Reader) (model.Attachment, error) {
+	if _, err := r.transaction(tgID, transactionID); err != nil {
+		return model.Attachment{}, err
+	}
+	ext, ok := model.AttachmentExtension(contentType)
+	if !ok {
+		return model.Attachment{}, model.ErrAttachmentType
+	}
+
+	token, err := generateRandomToken(32)
+	if err != nil {
+		return model.Attachment{}, fmt.Errorf("failed to generate storage key: %w", err)
+	}
+	key := strconv.FormatInt(tgID, 10) + "/" + token + ext
+
+	// One byte more than the limit tells a file of the maximum size from a larger one
+	size, err := r.Blobs.Put(key, io.LimitReader(content, model.MaxAttachmentSize+1))
+	if err != nil {
+		return model.Attachment{}, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	if size > model.MaxAttachmentSize {
+		r.deleteBlob(key)
+		return model.Attachment{}, model.ErrAttachmentTooLarge
+	}
+
+	attachment := model.Attachment{
+		TransactionID: transactionID,
+		TgID:          tgID,
+		FileName:      model.AttachmentFileName(fileName, contentType),
+		ContentType:   contentType,
+		Size:          size,
+		StorageKey:    key,
+	}
+	if err := r.DB.CreateAttachment(&attachment); err != nil {
+		r.deleteBlob(key)
+		return model.Attachment{}, fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// List returns the attachments of a transaction of the user
+func (r *Attachments) List(tgID, transactionID int64) ([]model.Attachment, error) {
+	if _, err := r.transaction(tgID, transactionID); err != nil {
+		return nil, err
+	}
+	return r.DB.GetTransactionAttachments(transactionID)
+}
+
+// Count returns the number of attachments of a transaction
+func (r *Attachments) Count(transactionID int64) (int64, error) {
+	return r.DB.CountTransactionAttachments(transactionID)
+}
+
+// Open returns an attachment of a transaction of the user with its content,
+// which the caller must close
+func (r *Attachments) Open(tgID, transactionID, id int64) (model.Attachment, io.ReadCloser, error) {
+	if _, err := r.transaction(tgID, transactionID); err != nil {
+		return model.Attachment{}, nil, err
+	}
+	attachment, err := r.DB.GetAttachment(transactionID, id)
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+
+	content, err := r.Blobs.Open(attachment.StorageKey)
+	if err != nil {
+		return model.Attachment{}, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return attachment, content, nil
+}
+
+// deleteBlob removes the content of an attachment, failures are only logged
+// as they leave an unreferenced file behind
+func (r *Repository) deleteBlob(key string) {
+	if err := r.Blobs.Delete(key); err != nil {
+		r.Logger.Warnf("failed to delete attachment %s: %v", key, err)
+	}
+}
@@ -1,27 +1,326 @@
 package repository
 
-import "cashout/internal/model"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cashout/internal/model"
+)
 
 type Budgets struct {
 	Repository
 }
 
+// Upsert sets a budget of the user, or of a ledger when LedgerID is set:
+// only the owners of a ledger manage its budgets, in the ledger's currency.
+// A category budget takes the exact name of the user's expense category, an
+// existing budget keeps its rollover, alert thresholds and period (see
+// SetRollover, SetThresholds and SetPeriod).
 func (r *Budgets) Upsert(budget *model.Budget) error {
+	scope := model.Scope{TgID: budget.TgID, LedgerID: budget.LedgerID}
+	if err := r.checkScope(scope, true); err != nil {
+		return err
+	}
+	if budget.Rollover == "" {
+		budget.Rollover = model.RolloverNone
+	}
+	if err := budget.Validate(); err != nil {
+		return err
+	}
+	if err := r.checkCategory(budget); err != nil {
+		return err
+	}
 	return r.DB.UpsertBudget(budget)
 }
 
-func (r *Budgets) Delete(tgID int64) error {
-	return r.DB.DeleteBudget(tgID)
+// Delete removes a budget of a scope, the overall one for an empty category
+// whose name is matched ignoring case, only the owners of a ledger can remove
+// its budgets
+func (r *Budgets) Delete(scope model.Scope, category model.TransactionCategory) error {
+	if err := r.checkScope(scope, true); err != nil {
+		return err
+	}
+	category, err := r.budgetCategory(scope, category)
+	if err != nil {
+		return err
+	}
+	return r.DB.DeleteBudget(scope, category)
+}
+
+// SetRollover changes what a budget of a scope carries over between periods,
+// the overall one for an empty category whose name is matched ignoring case.
+// Only the owners of a ledger manage the rollover of its budgets.
+func (r *Budgets) SetRollover(scope model.Scope, category model.TransactionCategory, rollover model.BudgetRollover, rolloverCap model.Money) (*model.Budget, error) {
+	if err := r.checkScope(scope, true); err != nil {
+		return nil, err
+	}
+	category, err := r.budgetCategory(scope, category)
+	if err != nil {
+		return nil, err
+	}
+	budget, err := r.DB.GetBudget(scope, category)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.Rollover = rollover
+	budget.RolloverCap = rolloverCap
+	if err := budget.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.DB.UpdateBudgetRollover(scope, category, rollover, rolloverCap); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// SetThresholds changes the alert thresholds of a budget of a scope, the
+// overall one for an empty category whose name is matched ignoring case.
+// Only the owners of a ledger manage the alerts of its budgets.
+func (r *Budgets) SetThresholds(scope model.Scope, category model.TransactionCategory, thresholds model.BudgetThresholds) (*model.Budget, error) {
+	if err := r.checkScope(scope, true); err != nil {
+		return nil, err
+	}
+	thresholds = thresholds.Normalize()
+	if err := thresholds.Validate(); err != nil {
+		return nil, err
+	}
+	category, err := r.budgetCategory(scope, category)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.DB.UpdateBudgetThresholds(scope, category, thresholds); err != nil {
+		return nil, err
+	}
+	return r.DB.GetBudget(scope, category)
+}
+
+// Evaluate checks the budgets a write of an expense affects, the overall one
+// and the ones of its categories, in their periods containing the date of the
+// expense: the ones of its ledger and the personal ones of its user for a
+// ledger expense. Each alert threshold reached fires once a period: the
+// evaluations tell the thresholds that fired with this write, whatever path
+// it came from. Returns nil for the other transactions or when no budget is
+// affected.
+func (r *Budgets) Evaluate(tx model.Transaction) ([]model.BudgetEvaluation, error) {
+	if tx.Type != model.TypeExpense || tx.IsTrashed() {
+		return nil, nil
+	}
+
+	var evaluations []model.BudgetEvaluation
+	for _, scope := range tx.BudgetScopes() {
+		scoped, err := r.evaluate(scope, tx)
+		if err != nil {
+			return nil, err
+		}
+		evaluations = append(evaluations, scoped...)
+	}
+	return evaluations, nil
+}
+
+// evaluate checks the budgets of a scope a write of an expense affects
+func (r *Budgets) evaluate(scope model.Scope, tx model.Transaction) ([]model.BudgetEvaluation, error) {
+	spending, err := r.Spending(scope, tx.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := tx.CategoryAmounts()
+
+	var evaluations []model.BudgetEvaluation
+	for _, s := range spending {
+		if _, ok := categories[s.Budget.Category]; s.Budget.IsCategory() && !ok {
+			continue
+		}
+		// A custom period may not include the expense
+		if !s.Contains(tx.Date) {
+			continue
+		}
+
+		evaluation := model.BudgetEvaluation{BudgetSpending: s}
+		for _, t := range s.Reached() {
+			fired, err := r.DB.TryMarkAlertFired(scope, s.Budget.Category, s.PeriodKey(), t)
+			if err != nil {
+				r.Logger.Warnf("failed to mark budget alert fired: %v", err)
+				continue
+			}
+			if fired {
+				evaluation.Fired = append(evaluation.Fired, t)
+			}
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations, nil
 }
 
-func (r *Budgets) Get(tgID int64) (*model.Budget, error) {
-	return r.DB.GetBudget(tgID)
+// SetPeriod changes the period of a budget of a scope, the overall one for an
+// empty category whose name is matched ignoring case: weekly from weekStart,
+// monthly, quarterly, yearly, or custom from startDate to endDate included.
+// Only the owners of a ledger manage the period of its budgets.
+func (r *Budgets) SetPeriod(scope model.Scope, category model.TransactionCategory, period model.BudgetPeriod, weekStart time.Weekday, startDate, endDate *time.Time) (*model.Budget, error) {
+	if err := r.checkScope(scope, true); err != nil {
+		return nil, err
+	}
+	category, err := r.budgetCategory(scope, category)
+	if err != nil {
+		return nil, err
+	}
+	budget, err := r.DB.GetBudget(scope, category)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.Period = period
+	budget.WeekStart = weekStart
+	budget.StartDate, budget.EndDate = nil, nil
+	if period == model.PeriodCustom {
+		budget.StartDate, budget.EndDate = startDate, endDate
+	}
+	if err := budget.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.DB.UpdateBudgetPeriod(scope, category, budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
 }
 
-func (r *Budgets) TotalExpensesForMonth(tgID int64, year, month int) (float64, error) {
-	return r.DB.GetTotalExpensesForMonth(tgID, year, month)
+// Get returns a budget of a scope, the overall one for an empty category
+func (r *Budgets) Get(scope model.Scope, category model.TransactionCategory) (*model.Budget, error) {
+	if err := r.checkScope(scope, false); err != nil {
+		return nil, err
+	}
+	return r.DB.GetBudget(scope, category)
 }
 
-func (r *Budgets) TryMarkAlertFired(tgID int64, yearMonth string, threshold int16) (bool, error) {
-	return r.DB.TryMarkAlertFired(tgID, yearMonth, threshold)
+// List returns all the budgets of a scope, the overall one first
+func (r *Budgets) List(scope model.Scope) ([]model.Budget, error) {
+	if err := r.checkScope(scope, false); err != nil {
+		return nil, err
+	}
+	budgets, err := r.DB.GetBudgets(scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+// Spending returns the spending against each budget of a scope in its
+// period containing day, in the order of List, with what the rollover of
+// the budgets carries into the period. Empty if the scope has no budget.
+func (r *Budgets) Spending(scope model.Scope, day time.Time) ([]model.BudgetSpending, error) {
+	budgets, err := r.List(scope)
+	if err != nil || len(budgets) == 0 {
+		return nil, err
+	}
+
+	// The category budgets of the same period share the totals by category
+	byCategory := make(map[string]map[model.TransactionCategory]model.Money)
+	spending := make([]model.BudgetSpending, len(budgets))
+	for i, b := range budgets {
+		start, end := b.Window(day)
+		spending[i] = model.BudgetSpending{Budget: b, Start: start, End: end}
+		if !b.IsCategory() {
+			spending[i].Spent, err = r.DB.GetTotalExpenses(scope, start, end)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute period total: %w", err)
+			}
+			continue
+		}
+
+		key := start.Format("2006-01-02") + end.Format("2006-01-02")
+		totals, ok := byCategory[key]
+		if !ok {
+			totals, err = r.DB.GetCategoryExpenses(scope, start, end)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute period category totals: %w", err)
+			}
+			byCategory[key] = totals
+		}
+		spending[i].Spent = totals[b.Category]
+	}
+
+	for i, sp := range spending {
+		if !sp.Budget.HasRollover() {
+			continue
+		}
+		spending[i].Carry, err = r.carry(scope, sp.Budget, sp.Start)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return spending, nil
+}
+
+// carry computes what the rollover of a budget carries into the period
+// starting on start from the expenses of the periods since the one the
+// budget was created in. The past periods are evaluated against the current
+// amount of the budget.
+func (r *Budgets) carry(scope model.Scope, budget model.Budget, start time.Time) (model.Money, error) {
+	first, _ := budget.Window(budget.CreatedAt)
+	if !first.Before(start) {
+		return 0, nil
+	}
+
+	totals, err := r.DB.GetDailyExpenses(scope, budget.Category, first, start.AddDate(0, 0, -1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily expenses: %w", err)
+	}
+
+	var spent []model.Money
+	for p := first; p.Before(start); {
+		_, end := budget.Window(p)
+		var total model.Money
+		for d := p; !d.After(end); d = d.AddDate(0, 0, 1) {
+			total += totals[d.Format("2006-01-02")]
+		}
+		spent = append(spent, total)
+		p = end.AddDate(0, 0, 1)
+	}
+	return budget.Carry(spent), nil
+}
+
+func (r *Budgets) TryMarkAlertFired(scope model.Scope, category model.TransactionCategory, period string, threshold int16) (bool, error) {
+	return r.DB.TryMarkAlertFired(scope, category, period, threshold)
+}
+
+// budgetCategory returns the exact name of the category of a budget of the
+// scope matching the given one ignoring case, or the given one if none does
+func (r *Budgets) budgetCategory(scope model.Scope, category model.TransactionCategory) (model.TransactionCategory, error) {
+	if category == "" {
+		return category, nil
+	}
+	budgets, err := r.DB.GetBudgets(scope)
+	if err != nil {
+		return "", fmt.Errorf("failed to get budgets: %w", err)
+	}
+	for _, b := range budgets {
+		if b.IsCategory() && strings.EqualFold(string(b.Category), string(category)) {
+			return b.Category, nil
+		}
+	}
+	return category, nil
+}
+
+// checkCategory makes sure the category of a budget is one of the expense
+// categories of the user, taking its exact name as the match ignores case
+func (r *Budgets) checkCategory(budget *model.Budget) error {
+	name := strings.TrimSpace(string(budget.Category))
+	if name == "" {
+		budget.Category = ""
+		return nil
+	}
+
+	categories, err := r.DB.GetCategories(budget.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, c := range categories.OfType(model.TypeExpense) {
+		if strings.EqualFold(c.Name, name) {
+			budget.Category = model.TransactionCategory(c.Name)
+			return nil
+		}
+	}
+	return model.ErrBudgetCategory
 }
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cashout/internal/db"
+	"cashout/internal/model"
+)
+
+type Categories struct {
+	Repository
+	// actor is who the renamed transactions are recorded for in the history, see As
+	actor model.Actor
+}
+
+// As returns the repository recording the changes made through it as made by
+// the given actor
+func (r *Categories) As(actor model.Actor) *Categories {
+	c := *r
+	c.actor = actor
+	return &c
+}
+
+// List returns all the categories of a user, seeding the default ones on first use
+func (r *Categories) List(tgID int64) (model.Categories, error) {
+	categories, err := r.DB.GetCategories(tgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	if len(categories) > 0 {
+		return categories, nil
+	}
+
+	if err := r.DB.CreateCategories(model.DefaultCategories(tgID)); err != nil {
+		return nil, fmt.Errorf("failed to seed default categories: %w", err)
+	}
+	return r.DB.GetCategories(tgID)
+}
+
+// Active returns the categories that can be assigned to new transactions of
+// the given type, or of both types when it is empty
+func (r *Categories) Active(tgID int64, t model.TransactionType) (model.Categories, error) {
+	categories, err := r.List(tgID)
+	if err != nil {
+		return nil, err
+	}
+	return categories.OfType(t), nil
+}
+
+// Get returns a category of the user or model.ErrCategoryNotFound
+func (r *Categories) Get(tgID, id int64) (model.Category, error) {
+	category, err := r.DB.GetCategoryByID(id)
+	if err != nil {
+		return category, err
+	}
+	if category.TgID != tgID {
+		return model.Category{}, model.ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// Create validates and stores a new category
+func (r *Categories) Create(category *model.Category) error {
+	category.Normalize()
+	if err := category.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.checkNameAvailable(category.TgID, 0, category.Name); err != nil {
+		return err
+	}
+
+	return r.DB.CreateCategory(category)
+}
+
+// Update validates and stores the changes to a category, its type cannot change
+func (r *Categories) Update(category *model.Category) error {
+	existing, err := r.Get(category.TgID, category.ID)
+	if err != nil {
+		return err
+	}
+
+	category.Normalize()
+	if category.Type != existing.Type {
+		return fmt.Errorf("%w: type cannot be changed", model.ErrInvalidCategory)
+	}
+	if err := category.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.checkNameAvailable(category.TgID, category.ID, category.Name); err != nil {
+		return err
+	}
+
+	category.CreatedAt = existing.CreatedAt
+	if category.Name == existing.Name {
+		return r.DB.UpdateCategory(category, existing.Name)
+	}
+
+	// the renamed transactions get a revision each, so that their history
+	// shows the new name and restoring an older one does not bring back the old
+	transactions := &Transactions{Repository: r.Repository, actor: r.actor}
+	return transactions.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		renamed, err := tx.GetCategoryTransactions(category.TgID, existing.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get category transactions: %w", err)
+		}
+		if err := tx.UpdateCategory(category, existing.Name); err != nil {
+			return nil, err
+		}
+
+		var revisions []model.TransactionRevision
+		for i := range renamed {
+			before := &renamed[i]
+			after := *before
+			after.Splits = slices.Clone(before.Splits)
+			after.RenameCategory(model.TransactionCategory(existing.Name), model.TransactionCategory(category.Name))
+			revisions = append(revisions, transactions.updated(before, &after)...)
+		}
+		return revisions, nil
+	})
+}
+
+// Delete removes a category that no transaction uses, used ones can only be archived
+func (r *Categories) Delete(tgID, id int64) error {
+	category, err := r.Get(tgID, id)
+	if err != nil {
+		return err
+	}
+	return r.DB.DeleteCategory(category)
+}
+
+// checkNameAvailable makes sure no other category of the user has the same
+// name, ignoring case to avoid near duplicates like "Kids" and "kids"
+func (r *Categories) checkNameAvailable(tgID, id int64, name string) error {
+	categories, err := r.List(tgID)
+	if err != nil {
+		return err
+	}
+	for _, c := range categories {
+		if c.ID != id && strings.EqualFold(c.Name, name) {
+			return model.ErrCategoryExists
+		}
+	}
+	return nil
+}
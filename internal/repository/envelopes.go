@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type Envelopes struct {
+	Repository
+}
+
+// Ledger returns the zero-based budget of a user in a month ("2006-01"), the
+// income counting from the first month money was assigned, or from the month
+// itself until then. Envelopes budget the personal transactions of the user,
+// the ones recorded in shared ledgers included.
+func (r *Envelopes) Ledger(tgID int64, month string) (model.EnvelopeLedger, error) {
+	end, err := time.Parse("2006-01", month)
+	if err != nil {
+		return model.EnvelopeLedger{}, fmt.Errorf("%w: month must be YYYY-MM", model.ErrInvalidEnvelope)
+	}
+	end = end.AddDate(0, 1, -1)
+
+	allocations, err := r.DB.GetEnvelopeAllocations(tgID, month)
+	if err != nil {
+		return model.EnvelopeLedger{}, fmt.Errorf("failed to get allocations: %w", err)
+	}
+	first := month
+	if len(allocations) > 0 && allocations[0].Month < first {
+		first = allocations[0].Month
+	}
+	start, _ := time.Parse("2006-01", first)
+
+	scope := model.Scope{TgID: tgID}
+	income, err := r.DB.GetMonthlyTotals(scope, start, end, model.TypeIncome)
+	if err != nil {
+		return model.EnvelopeLedger{}, fmt.Errorf("failed to get income: %w", err)
+	}
+	spent, err := r.DB.GetMonthlyCategoryTotals(scope, start, end, model.TypeExpense)
+	if err != nil {
+		return model.EnvelopeLedger{}, fmt.Errorf("failed to get expenses: %w", err)
+	}
+
+	ledger := model.NewEnvelopeLedger(month, income, allocations, spent)
+
+	goals, err := r.DB.GetGoals(tgID)
+	if err != nil {
+		return model.EnvelopeLedger{}, fmt.Errorf("failed to get goals: %w", err)
+	}
+	for i, e := range ledger.Envelopes {
+		for _, g := range goals {
+			if e.GoalID == g.ID {
+				ledger.Envelopes[i].Name = g.Name
+			}
+		}
+	}
+	return ledger, nil
+}
+
+// IsUsed reports whether the user budgets with envelopes, having assigned
+// money to one at least once
+func (r *Envelopes) IsUsed(tgID int64) (bool, error) {
+	return r.DB.HasEnvelopeAllocations(tgID)
+}
+
+// Find returns the envelope of a user with the given name, ignoring case: one
+// of their expense categories, otherwise one of their goals
+func (r *Envelopes) Find(tgID int64, name string) (model.Envelope, string, error) {
+	envelope, err := r.check(tgID, model.Envelope{Category: model.TransactionCategory(name)})
+	if !errors.Is(err, model.ErrEnvelopeNotFound) {
+		return envelope, string(envelope.Category), err
+	}
+
+	goals, err := r.DB.GetGoals(tgID)
+	if err != nil {
+		return model.Envelope{}, "", fmt.Errorf("failed to get goals: %w", err)
+	}
+	for _, g := range goals {
+		if strings.EqualFold(g.Name, strings.TrimSpace(name)) {
+			return model.Envelope{GoalID: g.ID}, g.Name, nil
+		}
+	}
+	return model.Envelope{}, "", model.ErrEnvelopeNotFound
+}
+
+// Assign validates and stores money assigned to an envelope of the user,
+// taken back out of it when the amount is negative. With a TransactionID the
+// money comes from that income of the user, in its month unless another one
+// is given.
+func (r *Envelopes) Assign(allocation *model.EnvelopeAllocation) error {
+	envelope, err := r.check(allocation.TgID, allocation.Envelope())
+	if err != nil {
+		return err
+	}
+	*allocation = withEnvelope(*allocation, envelope)
+
+	if allocation.TransactionID != nil {
+		tx, err := r.DB.GetTransactionByID(*allocation.TransactionID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && tx.TgID != allocation.TgID) {
+			return fmt.Errorf("%w: no such transaction", model.ErrEnvelopeIncome)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get transaction: %w", err)
+		}
+		if tx.Type != model.TypeIncome {
+			return model.ErrEnvelopeIncome
+		}
+		if allocation.Month == "" {
+			allocation.Month = tx.Date.Format("2006-01")
+		}
+	}
+
+	allocation.Note = strings.TrimSpace(allocation.Note)
+	if err := allocation.Validate(); err != nil {
+		return err
+	}
+	return r.DB.CreateEnvelopeAllocations([]model.EnvelopeAllocation{*allocation})
+}
+
+// Move moves money available in an envelope of the user to another one in a
+// month, returning model.ErrEnvelopeFunds when the first has less than amount
+// available
+func (r *Envelopes) Move(tgID int64, month string, from, to model.Envelope, amount model.Money) error {
+	from, err := r.check(tgID, from)
+	if err != nil {
+		return err
+	}
+	to, err = r.check(tgID, to)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("%w: cannot move money into the same envelope", model.ErrInvalidEnvelope)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", model.ErrInvalidEnvelope)
+	}
+
+	ledger, err := r.Ledger(tgID, month)
+	if err != nil {
+		return err
+	}
+	if ledger.Envelope(from).Available < amount {
+		return model.ErrEnvelopeFunds
+	}
+
+	out := model.NewEnvelopeAllocation(tgID, month, from, -amount)
+	in := model.NewEnvelopeAllocation(tgID, month, to, amount)
+	for _, a := range []model.EnvelopeAllocation{out, in} {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+	}
+	return r.DB.CreateEnvelopeAllocations([]model.EnvelopeAllocation{out, in})
+}
+
+// check makes sure an envelope is one of the expense categories of the user,
+// taking its exact name as the match ignores case, or one of their goals
+func (r *Envelopes) check(tgID int64, envelope model.Envelope) (model.Envelope, error) {
+	if envelope.IsGoal() {
+		goal, err := r.DB.GetGoalByID(envelope.GoalID)
+		if errors.Is(err, model.ErrGoalNotFound) || (err == nil && goal.TgID != tgID) {
+			return envelope, model.ErrEnvelopeNotFound
+		}
+		if err != nil {
+			return envelope, fmt.Errorf("failed to get goal: %w", err)
+		}
+		return model.Envelope{GoalID: goal.ID}, nil
+	}
+
+	categories, err := r.DB.GetCategories(tgID)
+	if err != nil {
+		return envelope, fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, c := range categories.OfType(model.TypeExpense) {
+		if strings.EqualFold(c.Name, strings.TrimSpace(string(envelope.Category))) {
+			return model.Envelope{Category: model.TransactionCategory(c.Name)}, nil
+		}
+	}
+	return envelope, model.ErrEnvelopeNotFound
+}
+
+// withEnvelope returns the allocation assigned to envelope
+func withEnvelope(a model.EnvelopeAllocation, envelope model.Envelope) model.EnvelopeAllocation {
+	assigned := model.NewEnvelopeAllocation(a.TgID, a.Month, envelope, a.Amount)
+	assigned.TransactionID = a.TransactionID
+	assigned.Note = a.Note
+	return assigned
+}
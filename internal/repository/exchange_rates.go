@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"cashout/internal/model"
+)
+
+type ExchangeRates struct {
+	Repository
+}
+
+// Get returns every known exchange rate
+func (r *ExchangeRates) Get() (model.RateTable, error) {
+	return r.DB.GetExchangeRates()
+}
+
+// Store inserts or updates the given exchange rates
+func (r *ExchangeRates) Store(rates []model.ExchangeRate) error {
+	return r.DB.UpsertExchangeRates(rates)
+}
+
+// Load parses a rate sheet and stores its rates, returning how many were stored
+func (r *ExchangeRates) Load(reader io.Reader) (int, error) {
+	rates, err := model.ParseRateSheet(reader)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.Store(rates); err != nil {
+		return 0, fmt.Errorf("failed to store exchange rates: %w", err)
+	}
+
+	return len(rates), nil
+}
+
+// LoadFile loads a rate sheet from a local JSON file
+func (r *ExchangeRates) LoadFile(path string) (n int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open exchange rates file: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	return r.Load(f)
+}
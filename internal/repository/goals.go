@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cashout/internal/model"
+)
+
+type Goals struct {
+	Repository
+}
+
+// List returns all the goals of a user, oldest first
+func (r *Goals) List(tgID int64) ([]model.Goal, error) {
+	goals, err := r.DB.GetGoals(tgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+	return goals, nil
+}
+
+// Get returns a goal of the user or model.ErrGoalNotFound
+func (r *Goals) Get(tgID, id int64) (model.Goal, error) {
+	goal, err := r.DB.GetGoalByID(id)
+	if err != nil {
+		return goal, err
+	}
+	if goal.TgID != tgID {
+		return model.Goal{}, model.ErrGoalNotFound
+	}
+	return goal, nil
+}
+
+// Create validates and stores a new goal
+func (r *Goals) Create(goal *model.Goal) error {
+	if err := r.check(goal); err != nil {
+		return err
+	}
+	return r.DB.CreateGoal(goal)
+}
+
+// Update validates and stores the changes to a goal, its currency cannot
+// change as the contributions would lose their meaning
+func (r *Goals) Update(goal *model.Goal) error {
+	existing, err := r.Get(goal.TgID, goal.ID)
+	if err != nil {
+		return err
+	}
+
+	goal.Currency = existing.Currency
+	if err := r.check(goal); err != nil {
+		return err
+	}
+
+	goal.CreatedAt = existing.CreatedAt
+	return r.DB.UpdateGoal(goal)
+}
+
+// Delete removes a goal and its manual contributions, the tagged
+// transactions are kept
+func (r *Goals) Delete(tgID, id int64) error {
+	if _, err := r.Get(tgID, id); err != nil {
+		return err
+	}
+	return r.DB.DeleteGoal(id)
+}
+
+// Entries returns the contributions of a goal, the manual ones and the
+// transactions tagged with its tag, sorted by date
+func (r *Goals) Entries(goal model.Goal) ([]model.GoalEntry, error) {
+	contributions, err := r.DB.GetGoalContributions(goal.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal contributions: %w", err)
+	}
+
+	var transactions []model.Transaction
+	if goal.Tag != "" {
+		transactions, err = r.DB.GetTaggedTransactions(goal.TgID, goal.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get goal transactions: %w", err)
+		}
+	}
+
+	// The transactions are in the base currency of the user, which may have
+	// changed since the goal was created
+	var rates model.RateTable
+	if slices.ContainsFunc(transactions, func(t model.Transaction) bool { return t.Currency != goal.Currency }) {
+		if rates, err = r.DB.GetExchangeRates(); err != nil {
+			return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+		}
+	}
+	return model.GoalEntries(goal, contributions, transactions, rates)
+}
+
+// Contribute validates and adds a manual contribution to a goal of the user
+func (r *Goals) Contribute(contribution *model.GoalContribution) error {
+	if _, err := r.Get(contribution.TgID, contribution.GoalID); err != nil {
+		return err
+	}
+
+	contribution.Note = strings.TrimSpace(contribution.Note)
+	contribution.Date = model.DateOf(contribution.Date)
+	if err := contribution.Validate(); err != nil {
+		return err
+	}
+	return r.DB.CreateGoalContribution(contribution)
+}
+
+// RemoveContribution deletes a manual contribution of a goal of the user
+func (r *Goals) RemoveContribution(tgID, goalID, id int64) error {
+	if _, err := r.Get(tgID, goalID); err != nil {
+		return err
+	}
+	return r.DB.DeleteGoalContribution(goalID, id)
+}
+
+// check normalizes and validates the goal, making sure no other goal of the
+// user has the same name, ignoring case, or the same tag
+func (r *Goals) check(goal *model.Goal) error {
+	goal.Normalize()
+	if err := goal.Validate(); err != nil {
+		return err
+	}
+
+	goals, err := r.List(goal.TgID)
+	if err != nil {
+		return err
+	}
+	for _, g := range goals {
+		if g.ID == goal.ID {
+			continue
+		}
+		if strings.EqualFold(g.Name, goal.Name) {
+			return model.ErrGoalExists
+		}
+		if goal.Tag != "" && g.Tag == goal.Tag {
+			return model.ErrGoalTagInUse
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"cashout/internal/db"
+	"cashout/internal/model"
+)
+
+type Ledgers struct {
+	Repository
+}
+
+// MemberTotal is re-exported from the db package so callers can read the
+// member totals without importing internal/db directly.
+type MemberTotal = db.MemberTotal
+
+// ledgerMember returns the membership of a user in a ledger or model.ErrLedgerNotFound
+func (r *Repository) ledgerMember(ledgerID, tgID int64) (model.LedgerMember, error) {
+	ledger, err := r.DB.GetLedgerByID(ledgerID)
+	if err != nil {
+		return model.LedgerMember{}, err
+	}
+	member, ok := ledger.Member(tgID)
+	if !ok {
+		return model.LedgerMember{}, model.ErrLedgerNotFound
+	}
+	return member, nil
+}
+
+// checkScope makes sure the user of the scope is a member of its ledger, if
+// any, and when manage is set that they manage it
+func (r *Repository) checkScope(scope model.Scope, manage bool) error {
+	if scope.LedgerID == nil {
+		return nil
+	}
+	member, err := r.ledgerMember(*scope.LedgerID, scope.TgID)
+	if err != nil {
+		return err
+	}
+	if manage && !member.Role.CanManage() {
+		return model.ErrLedgerForbidden
+	}
+	return nil
+}
+
+// List returns the ledgers the user is a member of
+func (r *Ledgers) List(tgID int64) ([]model.Ledger, error) {
+	ledgers, err := r.DB.GetUserLedgers(tgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledgers: %w", err)
+	}
+	return ledgers, nil
+}
+
+// Get returns a ledger the user is a member of or model.ErrLedgerNotFound
+func (r *Ledgers) Get(tgID, id int64) (model.Ledger, error) {
+	ledger, err := r.DB.GetLedgerByID(id)
+	if err != nil {
+		return model.Ledger{}, err
+	}
+	if _, ok := ledger.Member(tgID); !ok {
+		return model.Ledger{}, model.ErrLedgerNotFound
+	}
+	return ledger, nil
+}
+
+// Create stores a new ledger in the base currency of the user, who becomes
+// its owner and switches to it
+func (r *Ledgers) Create(ledger *model.Ledger, user model.User) error {
+	ledger.Currency = user.BaseCurrency
+	ledger.CreatedBy = user.TgID
+	ledger.Normalize()
+	if err := ledger.Validate(); err != nil {
+		return err
+	}
+
+	code, err := model.NewInviteCode()
+	if err != nil {
+		return err
+	}
+	ledger.InviteCode = code
+	ledger.Members = []model.LedgerMember{{TgID: user.TgID, Role: model.LedgerOwner}}
+
+	if err := r.DB.CreateLedger(ledger); err != nil {
+		return fmt.Errorf("failed to create ledger: %w", err)
+	}
+	return r.Switch(user.TgID, &ledger.ID)
+}
+
+// Join adds the user to the ledger of the invite code as an editor and
+// switches to it. The ledger must be in the user's base currency.
+func (r *Ledgers) Join(user model.User, code string) (model.Ledger, error) {
+	ledger, err := r.DB.GetLedgerByInviteCode(model.NormalizeInviteCode(code))
+	if err != nil {
+		return model.Ledger{}, err
+	}
+	if _, ok := ledger.Member(user.TgID); ok {
+		return ledger, model.ErrLedgerMember
+	}
+	if ledger.Currency != user.BaseCurrency {
+		return ledger, fmt.Errorf("%w, it is in %s", model.ErrLedgerCurrency, ledger.Currency)
+	}
+
+	member := model.LedgerMember{LedgerID: ledger.ID, TgID: user.TgID, Role: model.LedgerEditor}
+	if err := r.DB.AddLedgerMember(&member); err != nil {
+		return ledger, fmt.Errorf("failed to add ledger member: %w", err)
+	}
+	if err := r.Switch(user.TgID, &ledger.ID); err != nil {
+		return ledger, err
+	}
+	return r.DB.GetLedgerByID(ledger.ID)
+}
+
+// SetRole changes the role of a member, only owners can do it and the ledger
+// always keeps an owner
+func (r *Ledgers) SetRole(tgID, id, memberTgID int64, role model.LedgerRole) error {
+	ledger, err := r.managedLedger(tgID, id)
+	if err != nil {
+		return err
+	}
+	if err := ledger.CheckRoleChange(memberTgID, &role); err != nil {
+		return err
+	}
+	return r.DB.SetLedgerMemberRole(id, memberTgID, role)
+}
+
+// RemoveMember removes a member from a ledger: members can leave on their
+// own, owners can remove anyone. The transactions the member recorded stay
+// in the ledger, which is deleted along with its last member.
+func (r *Ledgers) RemoveMember(tgID, id, memberTgID int64) error {
+	ledger, err := r.Get(tgID, id)
+	if err != nil {
+		return err
+	}
+	if tgID != memberTgID {
+		if ledger, err = r.managedLedger(tgID, id); err != nil {
+			return err
+		}
+	}
+	if err := ledger.CheckRoleChange(memberTgID, nil); err != nil {
+		return err
+	}
+	return r.DB.DeleteLedgerMember(id, memberTgID)
+}
+
+// ResetInviteCode replaces the invite code of a ledger, the previous one stops working
+func (r *Ledgers) ResetInviteCode(tgID, id int64) (model.Ledger, error) {
+	ledger, err := r.managedLedger(tgID, id)
+	if err != nil {
+		return model.Ledger{}, err
+	}
+
+	if ledger.InviteCode, err = model.NewInviteCode(); err != nil {
+		return model.Ledger{}, err
+	}
+	if err := r.DB.UpdateLedger(&ledger); err != nil {
+		return model.Ledger{}, fmt.Errorf("failed to update ledger: %w", err)
+	}
+	return ledger, nil
+}
+
+// Switch sets the active ledger of the user, nil switches back to their
+// personal transactions
+func (r *Ledgers) Switch(tgID int64, id *int64) error {
+	if id != nil {
+		if _, err := r.Get(tgID, *id); err != nil {
+			return err
+		}
+	}
+	return r.DB.SetUserLedger(tgID, id)
+}
+
+// MemberTotals returns the income and expense totals of each member of a
+// ledger over a date range (inclusive)
+func (r *Ledgers) MemberTotals(ledgerID int64, startDate, endDate time.Time) ([]MemberTotal, error) {
+	totals, err := r.DB.GetLedgerMemberTotals(ledgerID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member totals: %w", err)
+	}
+	return totals, nil
+}
+
+// managedLedger returns a ledger the user is an owner of
+func (r *Ledgers) managedLedger(tgID, id int64) (model.Ledger, error) {
+	ledger, err := r.Get(tgID, id)
+	if err != nil {
+		return model.Ledger{}, err
+	}
+	member, _ := ledger.Member(tgID)
+	if !member.Role.CanManage() {
+		return model.Ledger{}, model.ErrLedgerForbidden
+	}
+	return ledger, nil
+}
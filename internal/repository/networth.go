@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cashout/internal/model"
+)
+
+type NetWorth struct {
+	Repository
+}
+
+// List returns all the holdings of a user, the oldest first
+func (r *NetWorth) List(tgID int64) ([]model.Holding, error) {
+	holdings, err := r.DB.GetHoldings(tgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holdings: %w", err)
+	}
+	return holdings, nil
+}
+
+// Get returns a holding of the user or model.ErrHoldingNotFound
+func (r *NetWorth) Get(tgID, id int64) (model.Holding, error) {
+	holding, err := r.DB.GetHoldingByID(id)
+	if err != nil {
+		return holding, err
+	}
+	if holding.TgID != tgID {
+		return model.Holding{}, model.ErrHoldingNotFound
+	}
+	return holding, nil
+}
+
+// Create validates and stores a new holding, one tracking an account takes
+// its currency
+func (r *NetWorth) Create(holding *model.Holding) error {
+	if err := r.check(holding); err != nil {
+		return err
+	}
+	return r.DB.CreateHolding(holding)
+}
+
+// Update validates and stores the changes to a holding, its currency cannot
+// change as the balances recorded would lose their meaning
+func (r *NetWorth) Update(holding *model.Holding) error {
+	existing, err := r.Get(holding.TgID, holding.ID)
+	if err != nil {
+		return err
+	}
+
+	holding.Currency = existing.Currency
+	if err := r.check(holding); err != nil {
+		return err
+	}
+
+	holding.CreatedAt = existing.CreatedAt
+	return r.DB.UpdateHolding(holding)
+}
+
+// Delete removes a holding of the user along with its balances
+func (r *NetWorth) Delete(tgID, id int64) error {
+	if _, err := r.Get(tgID, id); err != nil {
+		return err
+	}
+	return r.DB.DeleteHolding(id)
+}
+
+// Record stores the balance of a holding of the user on a day, replacing the
+// one recorded that day if any. The balance of a holding tracking an account
+// cannot be recorded, model.ErrHoldingLinked is returned.
+func (r *NetWorth) Record(tgID, id int64, balance model.Money, date time.Time) (model.HoldingSnapshot, error) {
+	holding, err := r.Get(tgID, id)
+	if err != nil {
+		return model.HoldingSnapshot{}, err
+	}
+	if holding.IsLinked() {
+		return model.HoldingSnapshot{}, model.ErrHoldingLinked
+	}
+
+	snapshot := model.HoldingSnapshot{
+		HoldingID: holding.ID,
+		TgID:      tgID,
+		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		Balance:   balance,
+	}
+	if err := snapshot.Validate(); err != nil {
+		return model.HoldingSnapshot{}, err
+	}
+	if err := r.DB.SaveHoldingSnapshot(&snapshot); err != nil {
+		return model.HoldingSnapshot{}, fmt.Errorf("failed to save balance: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Balances returns the holdings of the user with their balance as of today,
+// converted to their base currency
+func (r *NetWorth) Balances(user model.User) (model.HoldingBalances, error) {
+	holdings, snapshots, rates, err := r.load(user.TgID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := user.Today()
+	balances := make(model.HoldingBalances, 0, len(holdings))
+	for _, h := range holdings {
+		balance := model.HoldingBalance{Holding: h}
+		if s, ok := model.BalanceAt(snapshots[h.ID], today); ok {
+			converted, err := rates.ConvertAmount(model.NewAmount(s.Balance, h.Currency), user.BaseCurrency)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert balance of %s: %w", h.Name, err)
+			}
+			balance.Balance, balance.Converted, balance.Date, balance.Known = s.Balance, converted.Money, s.Date, true
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// Series returns the net worth of the user at the end of each of the last
+// months, the current one as of today
+func (r *NetWorth) Series(user model.User, months int) ([]model.NetWorthPoint, error) {
+	holdings, snapshots, rates, err := r.load(user.TgID)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := model.NetWorthSeries(holdings, snapshots, user.Today(), months, rates, user.BaseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute net worth: %w", err)
+	}
+	return points, nil
+}
+
+// load returns the holdings of a user with their balances keyed by holding
+// ID, the ones tracking an account derived from its transactions, along with
+// the exchange rates to convert them
+func (r *NetWorth) load(tgID int64) ([]model.Holding, map[int64][]model.HoldingSnapshot, model.RateTable, error) {
+	holdings, err := r.List(tgID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	recorded, err := r.DB.GetHoldingSnapshots(tgID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	snapshots := make(map[int64][]model.HoldingSnapshot, len(holdings))
+	for _, s := range recorded {
+		snapshots[s.HoldingID] = append(snapshots[s.HoldingID], s)
+	}
+
+	accounts := Accounts{Repository: r.Repository}
+	for _, h := range holdings {
+		if !h.IsLinked() {
+			continue
+		}
+		account, err := accounts.Get(tgID, *h.AccountID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get account of %s: %w", h.Name, err)
+		}
+		_, entries, err := accounts.Ledger(account, nil, nil)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		snapshots[h.ID] = h.AccountSnapshots(account, entries)
+	}
+
+	rates, err := r.DB.GetExchangeRates()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	return holdings, snapshots, rates, nil
+}
+
+// check normalizes and validates a holding of the user: its name must not be
+// taken by another holding, ignoring case, and the account it tracks must be
+// one of the user's not tracked by another holding
+func (r *NetWorth) check(holding *model.Holding) error {
+	holding.Normalize()
+
+	holdings, err := r.List(holding.TgID)
+	if err != nil {
+		return err
+	}
+
+	if holding.IsLinked() {
+		account, err := r.DB.GetAccountByID(*holding.AccountID)
+		if errors.Is(err, model.ErrAccountNotFound) || (err == nil && account.TgID != holding.TgID) {
+			return fmt.Errorf("%w: %w", model.ErrInvalidHolding, model.ErrAccountNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+		holding.Currency = account.Currency
+		for _, h := range holdings {
+			if h.ID != holding.ID && h.AccountID != nil && *h.AccountID == account.ID {
+				return model.ErrHoldingAccountSet
+			}
+		}
+	}
+
+	if err := holding.Validate(); err != nil {
+		return err
+	}
+	for _, h := range holdings {
+		if h.ID != holding.ID && strings.EqualFold(h.Name, holding.Name) {
+			return model.ErrHoldingExists
+		}
+	}
+	return nil
+}
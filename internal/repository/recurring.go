@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"cashout/internal/db"
+	"cashout/internal/model"
+)
+
+type Recurring struct {
+	Repository
+}
+
+// List returns all the recurring rules of a user
+func (r *Recurring) List(tgID int64) ([]model.RecurringRule, error) {
+	rules, err := r.DB.GetRecurringRules(tgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Get returns a recurring rule of the user or model.ErrRecurringRuleNotFound
+func (r *Recurring) Get(tgID, id int64) (model.RecurringRule, error) {
+	rule, err := r.DB.GetRecurringRuleByID(id)
+	if err != nil {
+		return rule, err
+	}
+	if rule.TgID != tgID {
+		return model.RecurringRule{}, model.ErrRecurringRuleNotFound
+	}
+	return rule, nil
+}
+
+// Create validates and stores a new recurring rule
+func (r *Recurring) Create(rule *model.RecurringRule) error {
+	if err := r.check(rule, ""); err != nil {
+		return err
+	}
+	return r.DB.CreateRecurringRule(rule)
+}
+
+// Update validates and stores the changes to a recurring rule. Changing the
+// start date does not materialise the occurrences already processed again.
+func (r *Recurring) Update(rule *model.RecurringRule) error {
+	existing, err := r.Get(rule.TgID, rule.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := r.check(rule, existing.Category); err != nil {
+		return err
+	}
+
+	rule.LastRunOn = existing.LastRunOn
+	rule.CreatedAt = existing.CreatedAt
+	return r.DB.UpdateRecurringRule(rule)
+}
+
+// Delete removes a recurring rule, the transactions it created are kept
+func (r *Recurring) Delete(tgID, id int64) error {
+	if _, err := r.Get(tgID, id); err != nil {
+		return err
+	}
+	return r.DB.DeleteRecurringRule(id)
+}
+
+// Resume unpauses a recurring rule skipping the occurrences missed while it
+// was paused, the ones from the given day on are materialised as usual
+func (r *Recurring) Resume(tgID, id int64, day time.Time) (model.RecurringRule, error) {
+	rule, err := r.Get(tgID, id)
+	if err != nil {
+		return rule, err
+	}
+
+	skipUntil := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	if rule.LastRunOn == nil || rule.LastRunOn.Before(skipUntil) {
+		rule.LastRunOn = &skipUntil
+	}
+	rule.Paused = false
+
+	if err := r.DB.UpdateRecurringRule(&rule); err != nil {
+		return rule, fmt.Errorf("failed to resume recurring rule: %w", err)
+	}
+	return rule, nil
+}
+
+// Due returns the rules of all users with occurrences up to the given day
+// not materialised yet
+func (r *Recurring) Due(day time.Time) ([]model.RecurringRule, error) {
+	rules, err := r.DB.GetDueRecurringRules(day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due recurring rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Materialize creates the transactions of the occurrences of the rule up to
+// the given day included and returns the new ones, recorded in their history
+// as made by the scheduler. Running it again for the same day creates nothing.
+func (r *Recurring) Materialize(rule *model.RecurringRule, until time.Time) ([]model.Transaction, error) {
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+
+	transactions := Transactions{Repository: r.Repository, actor: model.SchedulerActor}
+	var pending []model.Transaction
+	for _, day := range rule.Occurrences(until) {
+		t := rule.NewTransaction(day)
+		if err := transactions.prepare(&t); err != nil {
+			return nil, fmt.Errorf("failed to prepare recurring transaction: %w", err)
+		}
+		pending = append(pending, t)
+	}
+
+	var created []model.Transaction
+	err := transactions.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		var err error
+		created, err = tx.MaterializeRecurringRule(rule, pending, until)
+		if err != nil {
+			return nil, err
+		}
+		revisions := make([]model.TransactionRevision, len(created))
+		for i := range created {
+			revisions[i] = transactions.revision(model.RevisionCreated, nil, &created[i])
+		}
+		return revisions, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to materialize recurring rule: %w", err)
+	}
+	return created, nil
+}
+
+// check normalizes and validates the rule against the categories and the
+// accounts of its user. Archived categories are only allowed when unchanged.
+func (r *Recurring) check(rule *model.RecurringRule, currentCategory model.TransactionCategory) error {
+	rule.Normalize()
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	categories, err := (&Categories{Repository: r.Repository}).List(rule.TgID)
+	if err != nil {
+		return err
+	}
+	category, ok := categories.Find(string(rule.Category))
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: unknown category %q", model.ErrInvalidRecurringRule, rule.Category)
+	case category.Type != rule.Type:
+		return fmt.Errorf("%w: category %q is not an %s category", model.ErrInvalidRecurringRule, rule.Category, rule.Type)
+	case category.Archived && rule.Category != currentCategory:
+		return fmt.Errorf("%w: category %q is archived", model.ErrInvalidRecurringRule, rule.Category)
+	}
+
+	if rule.AccountID != nil {
+		account, err := r.DB.GetAccountByID(*rule.AccountID)
+		if err != nil {
+			return err
+		}
+		if account.TgID != rule.TgID {
+			return model.ErrAccountNotOwned
+		}
+		if account.Archived {
+			return model.ErrAccountArchived
+		}
+	}
+	return nil
+}
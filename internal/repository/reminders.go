@@ -29,6 +29,10 @@ func (r *Reminders) CreateOrUpdateMonthlyReminder(tgID int64, scheduledFor time.
 	return r.DB.CreateOrUpdateMonthlyReminder(tgID, scheduledFor)
 }
 
+func (r *Reminders) CreateOrUpdateBalanceCheckInReminder(tgID int64, scheduledFor time.Time) error {
+	return r.DB.CreateOrUpdateBalanceCheckInReminder(tgID, scheduledFor)
+}
+
 func (r *Reminders) GetAllActiveUsers() ([]model.User, error) {
 	return r.DB.GetAllActiveUsers()
 }
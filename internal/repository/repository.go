@@ -2,6 +2,7 @@
 package repository
 
 import (
+	"cashout/internal/blobstore"
 	"cashout/internal/db"
 
 	"github.com/sirupsen/logrus"
@@ -10,4 +11,6 @@ import (
 type Repository struct {
 	DB     *db.DB
 	Logger *logrus.Logger
+	// Blobs keeps the content of the attachments
+	Blobs blobstore.Store
 }
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cashout/internal/db"
+	"cashout/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type Settlements struct {
+	Repository
+	// actor is who the transactions are recorded for in their history, see As
+	actor model.Actor
+}
+
+// As returns the repository recording the transactions it creates as made by
+// the actor in their history, see Transactions.As
+func (r *Settlements) As(actor model.Actor) *Settlements {
+	s := *r
+	s.actor = actor
+	return &s
+}
+
+// CounterpartBalance is what a counterpart owes the user, negative when the
+// user owes them
+type CounterpartBalance struct {
+	Counterpart model.User
+	Amount      model.Money
+}
+
+// Transfer is a payment suggested to settle the balances of a group
+type Transfer struct {
+	From   model.User
+	To     model.User
+	Amount model.Money
+}
+
+// openBalances reports whether the user owes or is owed money by other users
+func (r *Repository) openBalances(tgID int64) (bool, error) {
+	debts, err := r.DB.GetUserDebts(tgID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get debts: %w", err)
+	}
+	return len(model.Balances(tgID, debts)) > 0, nil
+}
+
+// AddShared stores a new expense paid by the user and shares it among the
+// participants, see Share. Both are stored or none.
+func (r *Settlements) AddShared(transaction *model.Transaction, method model.SplitMethod, inputs []model.ShareInput) (model.SharedExpense, error) {
+	if transaction.Type != model.TypeExpense {
+		return model.SharedExpense{}, fmt.Errorf("%w: only expenses can be shared", model.ErrInvalidSharedExpense)
+	}
+
+	transactions := Transactions{Repository: r.Repository, actor: r.actor}
+	if err := transactions.prepare(transaction); err != nil {
+		return model.SharedExpense{}, err
+	}
+
+	expense, err := r.newSharedExpense(*transaction, method, inputs)
+	if err != nil {
+		return model.SharedExpense{}, err
+	}
+	err = transactions.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		if err := tx.CreateSharedExpense(&expense, transaction); err != nil {
+			return nil, fmt.Errorf("failed to create shared expense: %w", err)
+		}
+		return []model.TransactionRevision{transactions.revision(model.RevisionCreated, nil, transaction)}, nil
+	})
+	if err != nil {
+		return model.SharedExpense{}, err
+	}
+	return expense, nil
+}
+
+// Share marks an expense of the user as paid for the participants, who owe
+// them their share. Percentages and exact amounts are in the base currency of
+// the payer, which the participants must share.
+func (r *Settlements) Share(tgID, transactionID int64, method model.SplitMethod, inputs []model.ShareInput) (model.SharedExpense, error) {
+	transaction, err := r.DB.GetTransactionByID(transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.SharedExpense{}, fmt.Errorf("%w: transaction not found", model.ErrInvalidSharedExpense)
+		}
+		return model.SharedExpense{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction.TgID != tgID {
+		return model.SharedExpense{}, fmt.Errorf("%w: transaction not found", model.ErrInvalidSharedExpense)
+	}
+	if transaction.Type != model.TypeExpense || transaction.IsSettlement() {
+		return model.SharedExpense{}, fmt.Errorf("%w: only expenses can be shared", model.ErrInvalidSharedExpense)
+	}
+
+	if _, err := r.DB.GetSharedExpenseByTransaction(transactionID); err == nil {
+		return model.SharedExpense{}, fmt.Errorf("%w: the expense is already shared", model.ErrInvalidSharedExpense)
+	} else if !errors.Is(err, model.ErrSharedExpenseNotFound) {
+		return model.SharedExpense{}, fmt.Errorf("failed to get shared expense: %w", err)
+	}
+
+	expense, err := r.newSharedExpense(*transaction, method, inputs)
+	if err != nil {
+		return model.SharedExpense{}, err
+	}
+	if err := r.DB.CreateSharedExpense(&expense, nil); err != nil {
+		return model.SharedExpense{}, fmt.Errorf("failed to create shared expense: %w", err)
+	}
+	return expense, nil
+}
+
+// newSharedExpense checks the participants and computes their shares of the
+// amount of the transaction, in the base currency of its user
+func (r *Settlements) newSharedExpense(transaction model.Transaction, method model.SplitMethod, inputs []model.ShareInput) (model.SharedExpense, error) {
+	payer, err := r.DB.GetUser(transaction.TgID)
+	if err != nil {
+		return model.SharedExpense{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if err := r.checkParticipants(*payer, inputs); err != nil {
+		return model.SharedExpense{}, err
+	}
+
+	shares, err := model.ComputeShares(transaction.Amount, method, transaction.TgID, inputs)
+	if err != nil {
+		return model.SharedExpense{}, err
+	}
+	return model.SharedExpense{
+		TransactionID: transaction.ID,
+		PaidBy:        transaction.TgID,
+		Method:        method,
+		Amount:        transaction.Amount,
+		Currency:      transaction.Currency,
+		Shares:        shares,
+	}, nil
+}
+
+// checkParticipants makes sure the participants exist and have the base currency of the payer
+func (r *Settlements) checkParticipants(payer model.User, inputs []model.ShareInput) error {
+	ids := make([]int64, len(inputs))
+	for i, in := range inputs {
+		ids[i] = in.TgID
+	}
+	users, err := r.DB.GetUsersByTgIDs(ids)
+	if err != nil {
+		return fmt.Errorf("failed to get participants: %w", err)
+	}
+
+	found := make(map[int64]model.User, len(users))
+	for _, u := range users {
+		found[u.TgID] = u
+	}
+	for _, id := range ids {
+		u, ok := found[id]
+		if !ok && id != payer.TgID {
+			return model.ErrParticipantNotFound
+		}
+		if ok && u.BaseCurrency != payer.BaseCurrency {
+			return fmt.Errorf("%w, %s uses %s", model.ErrSettlementCurrency, u.DisplayName(), u.BaseCurrency)
+		}
+	}
+	return nil
+}
+
+// GetShared returns the shared expense of a transaction of the user or model.ErrSharedExpenseNotFound
+func (r *Settlements) GetShared(tgID, transactionID int64) (model.SharedExpense, error) {
+	expense, err := r.DB.GetSharedExpenseByTransaction(transactionID)
+	if err != nil {
+		return model.SharedExpense{}, err
+	}
+	if expense.PaidBy != tgID {
+		return model.SharedExpense{}, model.ErrSharedExpenseNotFound
+	}
+	return expense, nil
+}
+
+// Balances returns the balance of the user with each of their counterparts,
+// largest first
+func (r *Settlements) Balances(tgID int64) ([]CounterpartBalance, error) {
+	debts, err := r.DB.GetUserDebts(tgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get debts: %w", err)
+	}
+
+	balances := model.Balances(tgID, debts)
+	ids := make([]int64, len(balances))
+	for i, b := range balances {
+		ids[i] = b.TgID
+	}
+	users, err := r.users(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]CounterpartBalance, len(balances))
+	for i, b := range balances {
+		res[i] = CounterpartBalance{Counterpart: users[b.TgID], Amount: b.Amount}
+	}
+	return res, nil
+}
+
+// Plan returns the fewest payments settling the balances of the group of the
+// user: their counterparts and the debts among them. A counterpart may be
+// asked to pay another one instead of the user, which settles both debts.
+func (r *Settlements) Plan(tgID int64) ([]Transfer, error) {
+	debts, err := r.DB.GetUserDebts(tgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get debts: %w", err)
+	}
+
+	group := []int64{tgID}
+	for _, b := range model.Balances(tgID, debts) {
+		group = append(group, b.TgID)
+	}
+	if len(group) == 1 {
+		return nil, nil
+	}
+
+	if debts, err = r.DB.GetGroupDebts(group); err != nil {
+		return nil, fmt.Errorf("failed to get group debts: %w", err)
+	}
+	users, err := r.users(group)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := model.MinimalTransfers(debts)
+	res := make([]Transfer, len(transfers))
+	for i, t := range transfers {
+		res[i] = Transfer{From: users[t.From], To: users[t.To], Amount: t.Amount}
+	}
+
+	// The payments of the user come first
+	sort.SliceStable(res, func(i, j int) bool {
+		return involves(res[i], tgID) && !involves(res[j], tgID)
+	})
+	return res, nil
+}
+
+func involves(t Transfer, tgID int64) bool {
+	return t.From.TgID == tgID || t.To.TgID == tgID
+}
+
+// Settle records a payment between the user and a counterpart, in their
+// common base currency, along with the matching expense and income
+func (r *Settlements) Settle(settlement *model.Settlement) error {
+	if settlement.Date.IsZero() {
+		settlement.Date = time.Now()
+	}
+	if err := settlement.Validate(); err != nil {
+		return err
+	}
+
+	users, err := r.users([]int64{settlement.FromTgID, settlement.ToTgID})
+	if err != nil {
+		return err
+	}
+	from, okFrom := users[settlement.FromTgID]
+	to, okTo := users[settlement.ToTgID]
+	if !okFrom || !okTo {
+		return model.ErrParticipantNotFound
+	}
+	if from.BaseCurrency != to.BaseCurrency {
+		return fmt.Errorf("%w, %s uses %s", model.ErrSettlementCurrency, to.DisplayName(), to.BaseCurrency)
+	}
+	settlement.Currency = from.BaseCurrency
+
+	transactions := Transactions{Repository: r.Repository, actor: r.actor}
+	if err := transactions.AddSettlement(settlement, from, to); err != nil {
+		return fmt.Errorf("failed to add settlement: %w", err)
+	}
+	return nil
+}
+
+// users returns the users with the given Telegram IDs by ID
+func (r *Settlements) users(ids []int64) (map[int64]model.User, error) {
+	users, err := r.DB.GetUsersByTgIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	byID := make(map[int64]model.User, len(users))
+	for _, u := range users {
+		byID[u.TgID] = u
+	}
+	return byID, nil
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"cashout/internal/model"
+)
+
+type Tags struct {
+	Repository
+}
+
+// List returns all the tags of a user ordered by name
+func (r *Tags) List(tgID int64) ([]model.Tag, error) {
+	return r.DB.GetTags(tgID)
+}
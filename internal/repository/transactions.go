@@ -3,15 +3,311 @@ package repository
 import (
 	"cashout/internal/db"
 	"cashout/internal/model"
+	"errors"
+	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Transactions struct {
 	Repository
+	// actor is who the changes are recorded for in the history, see As
+	actor model.Actor
+}
+
+// As returns the repository recording the changes made through it as made by
+// the actor in the history of the transactions. Every change needs one.
+func (r *Transactions) As(actor model.Actor) *Transactions {
+	t := *r
+	t.actor = actor
+	return &t
+}
+
+// write runs a change of the transactions and appends the revisions it
+// returns to their history, all or none of them are stored
+func (r *Transactions) write(change func(tx *db.DB) ([]model.TransactionRevision, error)) error {
+	if r.actor.Source == "" {
+		return errors.New("the actor of the change is missing")
+	}
+	return r.DB.InTransaction(func(tx *db.DB) error {
+		revisions, err := change(tx)
+		if err != nil {
+			return err
+		}
+		if err := tx.CreateTransactionRevisions(revisions...); err != nil {
+			return fmt.Errorf("failed to create revisions: %w", err)
+		}
+		return nil
+	})
+}
+
+// revision returns the revision of a change made by the actor
+func (r *Transactions) revision(action model.RevisionAction, before, after *model.Transaction) model.TransactionRevision {
+	return model.NewTransactionRevision(action, r.actor, before, after)
 }
 
+// updated returns the revision of an update, none when nothing changed
+func (r *Transactions) updated(before, after *model.Transaction) []model.TransactionRevision {
+	revision := r.revision(model.RevisionUpdated, before, after)
+	if len(revision.Changes()) == 0 {
+		return nil
+	}
+	return []model.TransactionRevision{revision}
+}
+
+// stored returns the stored values of a transaction of the user or model.ErrTransactionNotFound
+func (r *Transactions) stored(id, tgID int64) (*model.Transaction, error) {
+	transaction, err := r.DB.GetTransactionByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && transaction.TgID != tgID) {
+		return nil, model.ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return transaction, nil
+}
+
+// Add stores a new transaction converting it into the user's base currency.
+// Amount and Currency are taken as entered by the user unless OriginalAmount
+// and OriginalCurrency are already set (e.g. when cloning).
+// Tags are matched by name and created when missing. Without an account,
+// incomes and expenses are assigned to the user's default one, if any.
+// Split lines, if any, are taken as entered like the amount. Without a
+// ledger, the transaction is recorded in the user's active one if their role
+// allows it.
 func (r *Transactions) Add(transaction *model.Transaction) error {
-	return r.DB.CreateTransaction(transaction)
+	if err := r.prepare(transaction); err != nil {
+		return err
+	}
+	return r.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		if err := tx.CreateTransaction(transaction); err != nil {
+			return nil, err
+		}
+		return []model.TransactionRevision{r.revision(model.RevisionCreated, nil, transaction)}, nil
+	})
+}
+
+// AddAll adds a batch of new transactions, all or none of them are stored
+func (r *Transactions) AddAll(transactions []*model.Transaction) error {
+	for _, t := range transactions {
+		if err := r.prepare(t); err != nil {
+			return err
+		}
+	}
+	return r.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		revisions := make([]model.TransactionRevision, len(transactions))
+		for i, t := range transactions {
+			if err := tx.CreateTransaction(t); err != nil {
+				return nil, err
+			}
+			revisions[i] = r.revision(model.RevisionCreated, nil, t)
+		}
+		return revisions, nil
+	})
+}
+
+// prepare converts a new transaction into the base currency of its user,
+// assigns the default account and resolves the tags, before it is stored
+func (r *Transactions) prepare(transaction *model.Transaction) error {
+	user, err := r.DB.GetUser(transaction.TgID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := r.toBaseCurrency(transaction, user.BaseCurrency); err != nil {
+		return err
+	}
+	if err := transaction.SetSplits(transaction.Splits); err != nil {
+		return err
+	}
+
+	if transaction.AccountID == nil && !transaction.IsTransfer() {
+		transaction.AccountID = user.DefaultAccountID
+	}
+	if err := r.checkAccounts(*transaction); err != nil {
+		return err
+	}
+
+	if err := r.assignLedger(transaction, *user); err != nil {
+		return err
+	}
+
+	tags, err := r.resolveTags(transaction.TgID, transaction.TagNames())
+	if err != nil {
+		return err
+	}
+	transaction.Tags = tags
+	return nil
+}
+
+// AddSettlement stores a settlement along with the expense of the payer and
+// the income of the payee recording it, prepared like any new transaction.
+// They stay out of the shared ledgers, whose members would otherwise see the
+// money counted twice.
+func (r *Transactions) AddSettlement(settlement *model.Settlement, from, to model.User) error {
+	expense, income := settlement.NewTransactions(from.DisplayName(), to.DisplayName())
+	for _, t := range []*model.Transaction{&expense, &income} {
+		if err := r.prepare(t); err != nil {
+			return err
+		}
+		t.LedgerID = nil
+	}
+
+	return r.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		if err := tx.CreateSettlement(settlement, &expense, &income); err != nil {
+			return nil, err
+		}
+		return []model.TransactionRevision{
+			r.revision(model.RevisionCreated, nil, &expense),
+			r.revision(model.RevisionCreated, nil, &income),
+		}, nil
+	})
+}
+
+// SetTags replaces the tags of a stored transaction with the given names,
+// creating the missing tags
+func (r *Transactions) SetTags(transaction *model.Transaction, names []string) error {
+	before, err := r.stored(transaction.ID, transaction.TgID)
+	if err != nil {
+		return err
+	}
+	tags, err := r.resolveTags(transaction.TgID, names)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		if err := tx.SetTransactionTags(transaction, tags); err != nil {
+			return nil, fmt.Errorf("failed to set transaction tags: %w", err)
+		}
+		after := *before
+		after.Tags = tags
+		return r.updated(before, &after), nil
+	})
+	if err != nil {
+		return err
+	}
+	transaction.Tags = tags
+	return nil
+}
+
+// resolveTags normalizes the tag names and returns the matching stored tags
+func (r *Transactions) resolveTags(tgID int64, names []string) ([]model.Tag, error) {
+	names, err := model.NormalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := r.DB.GetOrCreateTags(tgID, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+// toBaseCurrency fills the original and the base currency values of a transaction
+func (r *Transactions) toBaseCurrency(transaction *model.Transaction, base model.CurrencyType) error {
+	if transaction.OriginalCurrency == "" {
+		transaction.OriginalAmount = transaction.Amount
+		transaction.OriginalCurrency = transaction.Currency
+	}
+
+	if base == "" {
+		base = model.CurrencyEUR
+	}
+	if transaction.OriginalCurrency == "" {
+		transaction.OriginalCurrency = base
+	}
+
+	transaction.Currency = base
+	if transaction.OriginalCurrency == base {
+		transaction.Amount = transaction.OriginalAmount
+		return nil
+	}
+
+	rates, err := r.DB.GetExchangeRates()
+	if err != nil {
+		return fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	amount, err := rates.ConvertAmount(transaction.EnteredAmount(), base)
+	if err != nil {
+		return fmt.Errorf("failed to convert transaction: %w", err)
+	}
+	transaction.Amount = amount.Money
+
+	return nil
+}
+
+// checkAccounts makes sure the accounts of a transaction belong to its user
+// and are active. Only transfers have a destination account.
+func (r *Transactions) checkAccounts(transaction model.Transaction) error {
+	if !transaction.IsTransfer() && transaction.ToAccountID != nil {
+		return fmt.Errorf("%w: only transfers have a destination account", model.ErrInvalidAccount)
+	}
+	if transaction.IsTransfer() && (transaction.AccountID == nil || transaction.ToAccountID == nil) {
+		return fmt.Errorf("%w: transfers need a source and a destination account", model.ErrInvalidTransfer)
+	}
+
+	for _, id := range []*int64{transaction.AccountID, transaction.ToAccountID} {
+		if id == nil {
+			continue
+		}
+		account, err := r.DB.GetAccountByID(*id)
+		if err != nil {
+			return err
+		}
+		if account.TgID != transaction.TgID {
+			return model.ErrAccountNotOwned
+		}
+		if account.Archived {
+			return model.ErrAccountArchived
+		}
+	}
+	return nil
+}
+
+// assignLedger records the transaction in the user's active ledger when it
+// has none, unless the user is only a viewer there. An explicit ledger must
+// be one the user records transactions in.
+func (r *Transactions) assignLedger(transaction *model.Transaction, user model.User) error {
+	explicit := transaction.LedgerID != nil
+	if !explicit {
+		transaction.LedgerID = user.LedgerID
+	}
+	if transaction.LedgerID == nil {
+		return nil
+	}
+
+	member, err := r.ledgerMember(*transaction.LedgerID, transaction.TgID)
+	if err == nil && member.Role.CanWrite() {
+		return nil
+	}
+	if err != nil && !errors.Is(err, model.ErrLedgerNotFound) {
+		return err
+	}
+	if explicit {
+		return model.ErrLedgerForbidden
+	}
+	transaction.LedgerID = nil
+	return nil
+}
+
+// SetAccount moves a stored income or expense to another account, nil removes it
+func (r *Transactions) SetAccount(transaction *model.Transaction, accountID *int64) error {
+	if transaction.IsTransfer() {
+		return fmt.Errorf("%w: use a new transfer to change its accounts", model.ErrInvalidTransfer)
+	}
+
+	updated := *transaction
+	updated.AccountID = accountID
+	if err := r.checkAccounts(updated); err != nil {
+		return err
+	}
+
+	transaction.AccountID = accountID
+	return r.update(transaction)
 }
 
 func (r *Transactions) GetByID(id int64) (model.Transaction, error) {
@@ -22,16 +318,219 @@ func (r *Transactions) GetByID(id int64) (model.Transaction, error) {
 	return *transaction, nil
 }
 
+// Update stores the changes to a transaction, returning model.ErrInvalidSplit
+// when its split lines no longer sum to its amount
 func (r *Transactions) Update(transaction *model.Transaction) error {
-	return r.DB.UpdateTransaction(transaction)
+	if err := transaction.ValidateSplits(); err != nil {
+		return err
+	}
+	return r.update(transaction)
+}
+
+// update stores the changes to a transaction but its tags and split lines
+func (r *Transactions) update(transaction *model.Transaction) error {
+	before, err := r.stored(transaction.ID, transaction.TgID)
+	if err != nil {
+		return err
+	}
+	return r.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		if err := tx.UpdateTransaction(transaction); err != nil {
+			return nil, err
+		}
+		after := *transaction
+		after.Tags, after.Splits = before.Tags, before.Splits
+		return r.updated(before, &after), nil
+	})
+}
+
+// SetSplits splits a stored income or expense across the given lines, as
+// entered in its original currency, or removes its split when empty. The line
+// categories must be active categories of the transaction type, archived
+// ones are only allowed if the transaction already uses them.
+// The other changes to the transaction are stored too.
+func (r *Transactions) SetSplits(transaction *model.Transaction, lines []model.TransactionSplit) error {
+	return r.Edit(transaction, TransactionEdit{Splits: &lines})
 }
 
+// TransactionEdit is what an edit of a stored transaction changes besides its
+// own fields, nil members are left as they are
+type TransactionEdit struct {
+	Splits *[]model.TransactionSplit // the split lines as in SetSplits, empty removes the split
+	Tags   *[]string                 // the names of the tags, created when missing
+}
+
+// Edit stores the changes to a transaction made at once, with a single
+// revision in its history: its fields, its account when it changed, and the
+// split lines and the tags of the edit. Nothing is stored if any of them is
+// not valid, model.ErrInvalidSplit is returned for the split lines.
+func (r *Transactions) Edit(transaction *model.Transaction, edit TransactionEdit) error {
+	before, err := r.stored(transaction.ID, transaction.TgID)
+	if err != nil {
+		return err
+	}
+
+	if !sameID(before.AccountID, transaction.AccountID) {
+		if transaction.IsTransfer() {
+			return fmt.Errorf("%w: use a new transfer to change its accounts", model.ErrInvalidTransfer)
+		}
+		if err := r.checkAccounts(*transaction); err != nil {
+			return err
+		}
+	}
+
+	if edit.Splits != nil {
+		if err := r.checkSplitCategories(*transaction, *edit.Splits); err != nil {
+			return err
+		}
+		if err := transaction.SetSplits(*edit.Splits); err != nil {
+			return err
+		}
+	} else if err := transaction.ValidateSplits(); err != nil {
+		return err
+	}
+
+	tags := before.Tags
+	if edit.Tags != nil {
+		if tags, err = r.resolveTags(transaction.TgID, *edit.Tags); err != nil {
+			return err
+		}
+	}
+
+	err = r.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		if edit.Splits != nil {
+			if err := tx.UpdateTransactionSplits(transaction); err != nil {
+				return nil, fmt.Errorf("failed to update transaction splits: %w", err)
+			}
+		} else if err := tx.UpdateTransaction(transaction); err != nil {
+			return nil, err
+		}
+		if edit.Tags != nil {
+			if err := tx.SetTransactionTags(transaction, tags); err != nil {
+				return nil, fmt.Errorf("failed to set transaction tags: %w", err)
+			}
+		}
+
+		after := *transaction
+		after.Tags = tags
+		if edit.Splits == nil {
+			after.Splits = before.Splits
+		}
+		return r.updated(before, &after), nil
+	})
+	if err != nil {
+		return err
+	}
+	transaction.Tags = tags
+	return nil
+}
+
+// checkSplitCategories checks that the categories of the split lines are
+// active categories of the transaction type, or archived ones it already uses
+func (r *Transactions) checkSplitCategories(transaction model.Transaction, lines []model.TransactionSplit) error {
+	categories, err := (&Categories{Repository: r.Repository}).List(transaction.TgID)
+	if err != nil {
+		return err
+	}
+
+	used := map[model.TransactionCategory]bool{transaction.Category: true}
+	for _, s := range transaction.Splits {
+		used[s.Category] = true
+	}
+	for i, line := range lines {
+		category, ok := categories.Find(string(line.Category))
+		switch {
+		case !ok:
+			return fmt.Errorf("%w: unknown category %q on line %d", model.ErrInvalidSplit, line.Category, i+1)
+		case category.Type != transaction.Type:
+			return fmt.Errorf("%w: category %q is not an %s category", model.ErrInvalidSplit, line.Category, transaction.Type)
+		case category.Archived && !used[line.Category]:
+			return fmt.Errorf("%w: category %q is archived", model.ErrInvalidSplit, line.Category)
+		}
+	}
+	return nil
+}
+
+// sameID reports whether two optional IDs are the same, both missing included
+func sameID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Delete moves a transaction of the user to the trash, see Restore and PurgeTrash
 func (r *Transactions) Delete(id int64, tgID int64) error {
-	return r.DB.DeleteTransactionByID(id, tgID)
+	before, err := r.stored(id, tgID)
+	if err != nil {
+		return err
+	}
+	return r.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		if err := tx.TrashTransaction(id, tgID); err != nil {
+			return nil, err
+		}
+		return []model.TransactionRevision{r.revision(model.RevisionDeleted, before, nil)}, nil
+	})
 }
 
-func (r *Transactions) GetMonthlyTotalsInYear(tgID int64, year int) (map[int]map[model.TransactionType]float64, error) {
-	return r.DB.GetMonthlyTotalsInYear(tgID, year)
+// Trash returns the transactions in the trash of the user, the most recently deleted first
+func (r *Transactions) Trash(tgID int64, offset, limit int) ([]model.Transaction, int64, error) {
+	return r.DB.GetTrashedTransactions(tgID, offset, limit)
+}
+
+// Restore takes a transaction of the user out of the trash, returning
+// model.ErrTransactionNotFound if it is not there
+func (r *Transactions) Restore(id int64, tgID int64) error {
+	return r.write(func(tx *db.DB) ([]model.TransactionRevision, error) {
+		if err := tx.RestoreTransaction(id, tgID); err != nil {
+			return nil, err
+		}
+		after, err := tx.GetTransactionByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transaction: %w", err)
+		}
+		return []model.TransactionRevision{r.revision(model.RevisionRestored, nil, after)}, nil
+	})
+}
+
+// History returns the revisions of a transaction of the user, oldest first,
+// including the ones of the transactions in the trash or purged
+func (r *Transactions) History(tgID, id int64) ([]model.TransactionRevision, error) {
+	revisions, err := r.DB.GetTransactionRevisions(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revisions: %w", err)
+	}
+	if len(revisions) == 0 {
+		// Transactions stored before the history was kept have none
+		if _, err := r.stored(id, tgID); err != nil {
+			return nil, err
+		}
+		return revisions, nil
+	}
+	if revisions[0].TgID != tgID {
+		return nil, model.ErrTransactionNotFound
+	}
+	return revisions, nil
+}
+
+// PurgeTrash permanently deletes the transactions in the trash for longer
+// than model.TrashRetention along with their attachments, returning how many
+// were deleted
+func (r *Transactions) PurgeTrash(now time.Time) (int64, error) {
+	purged, keys, err := r.DB.PurgeTrashedTransactions(now.Add(-model.TrashRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	// The attachment rows go away with the transactions, their content is removed here
+	for _, key := range keys {
+		r.deleteBlob(key)
+	}
+	return purged, nil
+}
+
+// GetMonthlyTotalsInYear returns the totals of a scope by month and type for a specific year
+func (r *Transactions) GetMonthlyTotalsInYear(scope model.Scope, year int) (map[int]map[model.TransactionType]model.Money, error) {
+	return r.DB.GetMonthlyTotalsInYear(scope, year)
 }
 
 func (r *Transactions) GetUserTransactionsByMonthPaginated(tgID int64, year, month, offset, limit int, category string) ([]model.Transaction, int64, error) {
@@ -52,49 +551,49 @@ func (r *Transactions) GetUserTransactionsByTypePaginated(tgID int64, transactio
 	return r.DB.GetUserTransactionsByTypePaginated(tgID, transactionType, offset, limit)
 }
 
-// GetMonthCategorizedTotals returns the transaction totals for each category for a specific month
-func (r *Transactions) GetMonthCategorizedTotals(tgID int64, year int, month int) (map[model.TransactionType]map[model.TransactionCategory]float64, error) {
+// GetMonthCategorizedTotals returns the transaction totals of a scope for each category for a specific month
+func (r *Transactions) GetMonthCategorizedTotals(scope model.Scope, year int, month int) (map[model.TransactionType]map[model.TransactionCategory]model.Money, error) {
 	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	endDate := startDate.AddDate(0, 1, -1) // Last day of the month
 
 	// Get expense categories
-	expenseTotals, err := r.DB.GetUserTransactionsByCategory(tgID, startDate, endDate, model.TypeExpense)
+	expenseTotals, err := r.DB.GetTransactionsByCategory(scope, startDate, endDate, model.TypeExpense)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get income categories
-	incomeTotals, err := r.DB.GetUserTransactionsByCategory(tgID, startDate, endDate, model.TypeIncome)
+	incomeTotals, err := r.DB.GetTransactionsByCategory(scope, startDate, endDate, model.TypeIncome)
 	if err != nil {
 		return nil, err
 	}
 
 	// Return both in a map
-	return map[model.TransactionType]map[model.TransactionCategory]float64{
+	return map[model.TransactionType]map[model.TransactionCategory]model.Money{
 		model.TypeExpense: expenseTotals,
 		model.TypeIncome:  incomeTotals,
 	}, nil
 }
 
-// GetYearCategorizedTotals returns the transaction totals for each category for a specific year
-func (r *Transactions) GetYearCategorizedTotals(tgID int64, year int) (map[model.TransactionType]map[model.TransactionCategory]float64, error) {
+// GetYearCategorizedTotals returns the transaction totals of a scope for each category for a specific year
+func (r *Transactions) GetYearCategorizedTotals(scope model.Scope, year int) (map[model.TransactionType]map[model.TransactionCategory]model.Money, error) {
 	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(year, 12, 31, 23, 59, 59, 0, time.UTC)
 
 	// Get expense categories
-	expenseTotals, err := r.DB.GetUserTransactionsByCategory(tgID, startDate, endDate, model.TypeExpense)
+	expenseTotals, err := r.DB.GetTransactionsByCategory(scope, startDate, endDate, model.TypeExpense)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get income categories
-	incomeTotals, err := r.DB.GetUserTransactionsByCategory(tgID, startDate, endDate, model.TypeIncome)
+	incomeTotals, err := r.DB.GetTransactionsByCategory(scope, startDate, endDate, model.TypeIncome)
 	if err != nil {
 		return nil, err
 	}
 
 	// Return both in a map
-	return map[model.TransactionType]map[model.TransactionCategory]float64{
+	return map[model.TransactionType]map[model.TransactionCategory]model.Money{
 		model.TypeExpense: expenseTotals,
 		model.TypeIncome:  incomeTotals,
 	}, nil
@@ -110,7 +609,13 @@ func (r *Transactions) GetUserTransactionsByDateRange(tgID int64, startDate, end
 	return r.DB.GetUserTransactionsByDateRange(tgID, startDate, endDate)
 }
 
-// SearchUserTransactions searches transactions by description with optional category filter
+// GetTransactionsByDateRange retrieves the transactions of a scope within a date range
+func (r *Transactions) GetTransactionsByDateRange(scope model.Scope, startDate, endDate time.Time) ([]model.Transaction, error) {
+	return r.DB.GetTransactionsByDateRange(scope, startDate, endDate)
+}
+
+// SearchUserTransactions searches transactions by description with optional category filter,
+// the hashtags in the search query filter by tag
 func (r *Transactions) SearchUserTransactions(tgID int64, searchQuery string, category string, offset, limit int) ([]model.Transaction, int64, error) {
 	return r.DB.SearchUserTransactions(tgID, searchQuery, category, offset, limit)
 }
@@ -125,3 +630,29 @@ type TransactionFilter = db.TransactionFilter
 func (r *Transactions) SearchUserTransactionsFiltered(tgID int64, f TransactionFilter, offset, limit int) ([]model.Transaction, int64, error) {
 	return r.DB.SearchUserTransactionsFiltered(tgID, f, offset, limit)
 }
+
+// Query runs a question on the transactions of the scope, searching them with
+// the filters of the spec, which must have been validated, and aggregating
+// them in memory. The questions matching more than model.MaxQueryTransactions
+// transactions get model.ErrQueryTooBroad.
+func (r *Transactions) Query(scope model.Scope, spec model.QuerySpec) (model.QueryResult, error) {
+	transactions, total, err := r.DB.SearchUserTransactionsFiltered(scope.TgID, db.TransactionFilter{
+		Query:       spec.Query,
+		Category:    string(spec.Category),
+		Type:        spec.Type,
+		DateFrom:    spec.DateFrom,
+		DateTo:      spec.DateTo,
+		AmountMin:   spec.AmountMin,
+		AmountMax:   spec.AmountMax,
+		Tags:        spec.Tags,
+		ExcludeTags: spec.ExcludeTags,
+		LedgerID:    scope.LedgerID,
+	}, 0, model.MaxQueryTransactions)
+	if err != nil {
+		return model.QueryResult{}, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	if total > int64(len(transactions)) {
+		return model.QueryResult{}, model.ErrQueryTooBroad
+	}
+	return spec.Run(transactions), nil
+}
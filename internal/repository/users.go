@@ -2,7 +2,9 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"cashout/internal/model"
 
@@ -21,20 +23,42 @@ func (r *Users) UpsertWithContext(user gotgbot.User, session model.UserSession)
 		name = user.Username
 	}
 
-	return r.DB.SetUser(&model.User{
-		TgID:        user.Id,
-		Name:        name,
-		Session:     session,
-		TgUsername:  user.Username,
-		TgFirstname: user.FirstName,
-		TgLastname:  user.LastName,
-	})
+	// Keep the settings of an existing user (e.g. email, base currency)
+	u, err := r.DB.GetUser(user.Id)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		u = &model.User{TgID: user.Id, BaseCurrency: model.CurrencyEUR, Timezone: model.DefaultTimezone}
+	}
+
+	u.Name = name
+	u.Session = session
+	u.TgUsername = user.Username
+	u.TgFirstname = user.FirstName
+	u.TgLastname = user.LastName
+
+	return r.DB.SetUser(u)
 }
 
 func (r *Users) Update(user *model.User) error {
 	return r.DB.SetUser(user)
 }
 
+// ClaimSession moves the user to the given session if they are still in the
+// one loaded with them, returning false when a concurrent update changed it
+// first, e.g. a second tap on the same button
+func (r *Users) ClaimSession(user *model.User, session model.UserSession) (bool, error) {
+	claimed, err := r.DB.SwapUserSession(user.TgID, user.Session, session)
+	if err != nil {
+		return false, err
+	}
+	if claimed {
+		user.Session = session
+	}
+	return claimed, nil
+}
+
 // GetByUsername retrieves a user by their Telegram username
 func (r *Users) GetByUsername(username string) (model.User, bool, error) {
 	user, err := r.DB.GetUserByUsername(username)
@@ -67,3 +91,64 @@ func (r *Users) GetByTgID(tgID int64) (model.User, error) {
 	}
 	return *user, nil
 }
+
+// SetTimezone changes the user's timezone to an IANA one, e.g. "Europe/Rome",
+// and moves their upcoming recaps to the morning of the new timezone. It
+// returns the name of the timezone or model.ErrInvalidTimezone.
+func (r *Users) SetTimezone(tgID int64, name string) (string, error) {
+	loc, err := model.LoadTimezone(strings.TrimSpace(name))
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = r.DB.SetUserTimezone(tgID, loc.String(), now, model.NextWeeklyRecap(now, loc), model.NextMonthlyRecap(now, loc))
+	if err != nil {
+		return "", fmt.Errorf("failed to set timezone: %w", err)
+	}
+	return loc.String(), nil
+}
+
+// SetBudgetAlertEmail sets whether the budget alerts of the user are emailed
+// too, besides Telegram. Enabling them needs an email, model.ErrNoEmail is
+// returned otherwise.
+func (r *Users) SetBudgetAlertEmail(user *model.User, enabled bool) error {
+	if enabled && user.Email == nil {
+		return model.ErrNoEmail
+	}
+	if err := r.DB.SetUserBudgetAlertEmail(user.TgID, enabled); err != nil {
+		return fmt.Errorf("failed to set budget alert email: %w", err)
+	}
+	user.BudgetAlertEmail = enabled
+	return nil
+}
+
+// SetBaseCurrency changes the user's base currency, converting all their
+// transactions, budgets, goals and envelopes with the current exchange
+// rates. Members of a shared ledger keep the ledger's currency,
+// model.ErrLedgerCurrency is returned until they leave it. Users with
+// balances to settle with other users get model.ErrOpenBalances, as the
+// debts are in their base currency.
+func (r *Users) SetBaseCurrency(tgID int64, currency model.CurrencyType) error {
+	member, err := r.DB.IsLedgerMember(tgID)
+	if err != nil {
+		return fmt.Errorf("failed to check ledger membership: %w", err)
+	}
+	if member {
+		return model.ErrLedgerCurrency
+	}
+
+	open, err := r.openBalances(tgID)
+	if err != nil {
+		return err
+	}
+	if open {
+		return model.ErrOpenBalances
+	}
+
+	rates, err := r.DB.GetExchangeRates()
+	if err != nil {
+		return fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	return r.DB.SetUserBaseCurrency(tgID, currency, rates)
+}
@@ -1,27 +1,16 @@
 package scheduler
 
 import (
+	"cashout/internal/client"
 	"cashout/internal/model"
-	"cashout/internal/utils"
-	"errors"
 	"fmt"
-	"math"
 	"sort"
 	"strings"
 	"time"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
-	"gorm.io/gorm"
 )
 
-func getNextFirstDayOfMonth() time.Time {
-	now := time.Now()
-	// Get first day of next month
-	firstOfNextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
-
-	return firstOfNextMonth
-}
-
 // createMonthlyReminders creates reminder records for all active users
 func (s *Scheduler) createMonthlyReminders() error {
 	s.logger.Info("Creating monthly reminders...")
@@ -32,12 +21,12 @@ func (s *Scheduler) createMonthlyReminders() error {
 		return fmt.Errorf("failed to get active users: %w", err)
 	}
 
-	// Schedule for 6:00 GMT on the first day of next month
-	firstDay := getNextFirstDayOfMonth()
-	scheduledFor := time.Date(firstDay.Year(), firstDay.Month(), firstDay.Day(), 6, 0, 0, 0, time.UTC)
+	// Schedule for the morning of the first day of next month in the timezone of each user
+	now := time.Now()
 
 	createdCount := 0
 	for _, user := range users {
+		scheduledFor := model.NextMonthlyRecap(now, user.Location())
 		err := s.repositories.Reminders.CreateOrUpdateMonthlyReminder(user.TgID, scheduledFor)
 		if err != nil {
 			s.logger.Errorf("Failed to create monthly reminder for user %d: %v", user.TgID, err)
@@ -97,6 +86,7 @@ func (s *Scheduler) processMonthlyReminders() error {
 
 		} else {
 			s.logger.Infof("Successfully sent monthly recap to user %d", reminder.TgID)
+
 			err = s.repositories.Reminders.UpdateReminderStatusTransaction(
 				reminder.ID,
 				model.ReminderStatusSent,
@@ -105,7 +95,6 @@ func (s *Scheduler) processMonthlyReminders() error {
 			if err != nil {
 				s.logger.Errorf("Failed to update monthly reminder %d to sent: %v", reminder.ID, err)
 			}
-
 		}
 	}
 
@@ -120,36 +109,46 @@ func (s *Scheduler) sendMonthlyRecap(tgID int64) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Calculate previous month
-	now := time.Now().UTC()
+	// Calculate previous month in the user's timezone
+	today := user.Today()
 	// Go to first day of current month
-	firstOfCurrentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	firstOfCurrentMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
 	// Go back one day to get last day of previous month
 	lastOfPrevMonth := firstOfCurrentMonth.AddDate(0, 0, -1)
 	// Get year and month of previous month
 	prevYear := lastOfPrevMonth.Year()
 	prevMonth := int(lastOfPrevMonth.Month())
 
+	// Scheduled recaps cover the personal transactions, like the weekly one
+	scope := model.Scope{TgID: user.TgID}
+
 	// Get monthly totals
-	totals, err := s.repositories.Transactions.GetMonthlyTotalsInYear(user.TgID, prevYear)
+	totals, err := s.repositories.Transactions.GetMonthlyTotalsInYear(scope, prevYear)
 	if err != nil {
 		return fmt.Errorf("failed to get monthly totals: %w", err)
 	}
 
 	// Get category breakdown
-	categoryTotals, err := s.repositories.Transactions.GetMonthCategorizedTotals(user.TgID, prevYear, prevMonth)
+	categoryTotals, err := s.repositories.Transactions.GetMonthCategorizedTotals(scope, prevYear, prevMonth)
 	if err != nil {
 		return fmt.Errorf("failed to get category totals: %w", err)
 	}
 
-	// Get budget (optional) for budget section
-	budget, err := s.repositories.Budgets.Get(user.TgID)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return fmt.Errorf("failed to get budget: %w", err)
+	// Get budgets (optional) for budget section, with what their rollover carried into the month:
+	// the other periods are as of its last day, the weekly budgets are in the weekly recap
+	spending, err := s.repositories.Budgets.Spending(scope, lastOfPrevMonth)
+	if err != nil {
+		return fmt.Errorf("failed to get budgets: %w", err)
+	}
+	var budgets []model.BudgetSpending
+	for _, sp := range spending {
+		if sp.Budget.Period != model.PeriodWeekly && sp.Contains(lastOfPrevMonth) {
+			budgets = append(budgets, sp)
+		}
 	}
 
 	// Generate the recap message
-	message := s.generateMonthlyRecapMessage(user, totals, categoryTotals, budget, prevYear, prevMonth)
+	message := s.generateMonthlyRecapMessage(user, totals, categoryTotals, budgets, prevYear, prevMonth)
 
 	// Send the message
 	_, err = s.bot.SendMessage(tgID, message, &gotgbot.SendMessageOpts{
@@ -160,9 +159,11 @@ func (s *Scheduler) sendMonthlyRecap(tgID int64) error {
 }
 
 // generateMonthlyRecapMessage generates the monthly recap message
-func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]map[model.TransactionType]float64, categoryTotals map[model.TransactionType]map[model.TransactionCategory]float64, budget *model.Budget, year int, month int) string {
+func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]map[model.TransactionType]model.Money, categoryTotals map[model.TransactionType]map[model.TransactionCategory]model.Money, budgets []model.BudgetSpending, year int, month int) string {
 	var text strings.Builder
-	var monthTotal float64
+	cur := user.BaseCurrency.Symbol()
+	userCategories := s.displayCategories(user.TgID)
+	var monthTotal model.Money
 
 	// Header
 	fmt.Fprintf(&text, "📅 <b>%s, here's your monthly recap!</b>\n\n", user.Name)
@@ -178,7 +179,7 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 	// --- EXPENSES SECTION ---
 	if expenseAmount, ok := t[model.TypeExpense]; ok && expenseAmount > 0 {
 		monthTotal -= expenseAmount
-		fmt.Fprintf(&text, "💸 <b>Expenses:</b> %.2f€\n", expenseAmount)
+		fmt.Fprintf(&text, "💸 <b>Expenses:</b> %.2f%s\n", expenseAmount, cur)
 
 		// Add top expense categories
 		if expenseCats, ok := categoryTotals[model.TypeExpense]; ok && len(expenseCats) > 0 {
@@ -187,13 +188,13 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 			// Sort categories by amount (descending)
 			categories := make([]struct {
 				Category model.TransactionCategory
-				Amount   float64
+				Amount   model.Money
 			}, 0, len(expenseCats))
 
 			for cat, amount := range expenseCats {
 				categories = append(categories, struct {
 					Category model.TransactionCategory
-					Amount   float64
+					Amount   model.Money
 				}{cat, amount})
 			}
 
@@ -206,10 +207,10 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 
 			for i := range limit {
 				entry := categories[i]
-				emoji := utils.GetCategoryEmoji(entry.Category)
-				percentage := (entry.Amount / expenseAmount) * 100
-				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f€ (%.1f%%)\n",
-					emoji, entry.Category, entry.Amount, percentage)
+				emoji := userCategories.Emoji(entry.Category)
+				percentage := entry.Amount.Float64() / expenseAmount.Float64() * 100
+				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f%s (%.1f%%)\n",
+					emoji, entry.Category, entry.Amount, cur, percentage)
 			}
 			text.WriteString("\n")
 		}
@@ -218,7 +219,7 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 	// --- INCOME SECTION ---
 	if incomeAmount, ok := t[model.TypeIncome]; ok && incomeAmount > 0 {
 		monthTotal += incomeAmount
-		fmt.Fprintf(&text, "💰 <b>Income:</b> %.2f€\n", incomeAmount)
+		fmt.Fprintf(&text, "💰 <b>Income:</b> %.2f%s\n", incomeAmount, cur)
 
 		// Add income categories if multiple
 		if incomeCats, ok := categoryTotals[model.TypeIncome]; ok && len(incomeCats) > 1 {
@@ -226,13 +227,13 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 
 			categories := make([]struct {
 				Category model.TransactionCategory
-				Amount   float64
+				Amount   model.Money
 			}, 0, len(incomeCats))
 
 			for cat, amount := range incomeCats {
 				categories = append(categories, struct {
 					Category model.TransactionCategory
-					Amount   float64
+					Amount   model.Money
 				}{cat, amount})
 			}
 
@@ -241,10 +242,10 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 			})
 
 			for _, entry := range categories {
-				emoji := utils.GetCategoryEmoji(entry.Category)
-				percentage := (entry.Amount / incomeAmount) * 100
-				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f€ (%.1f%%)\n",
-					emoji, entry.Category, entry.Amount, percentage)
+				emoji := userCategories.Emoji(entry.Category)
+				percentage := entry.Amount.Float64() / incomeAmount.Float64() * 100
+				fmt.Fprintf(&text, "  %s <b>%s:</b> %.2f%s (%.1f%%)\n",
+					emoji, entry.Category, entry.Amount, cur, percentage)
 			}
 			text.WriteString("\n")
 		}
@@ -258,27 +259,17 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 		balanceEmoji = "❌"
 	}
 
-	fmt.Fprintf(&text, "\n%s <b>Month Balance:</b> %.2f€\n", balanceEmoji, monthTotal)
+	fmt.Fprintf(&text, "\n%s <b>Month Balance:</b> %.2f%s\n", balanceEmoji, monthTotal, cur)
 
 	// --- BUDGET SECTION ---
-	if budget != nil {
-		expenseAmount := t[model.TypeExpense]
-		pct := int(math.Floor(expenseAmount / budget.Amount * 100))
-		indicator := "✅"
-		if pct >= 100 {
-			indicator = "🚨"
-		} else if pct >= 80 {
-			indicator = "⚠️"
-		}
-		fmt.Fprintf(&text, "📊 <b>Budget:</b> %.2f / %.2f€ (%d%%) %s\n",
-			expenseAmount, budget.Amount, pct, indicator)
-	}
+	// The overall budget comes first, then each category budget on its own line
+	text.WriteString(client.FormatBudgetRecap(budgets, userCategories, cur))
 
 	// --- AVERAGE DAILY SPENDING ---
 	if expenseAmount, ok := t[model.TypeExpense]; ok && expenseAmount > 0 {
 		daysInMonth := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
-		avgDaily := expenseAmount / float64(daysInMonth)
-		fmt.Fprintf(&text, "📈 <b>Avg Daily Spending:</b> %.2f€\n", avgDaily)
+		avgDaily := expenseAmount.Float64() / float64(daysInMonth)
+		fmt.Fprintf(&text, "📈 <b>Avg Daily Spending:</b> %.2f%s\n", avgDaily, cur)
 	}
 
 	// --- COMPARISON WITH PREVIOUS MONTH ---
@@ -297,12 +288,12 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 			if currentExpense, ok := t[model.TypeExpense]; ok {
 				if prevExpense, ok := prevTotals[model.TypeExpense]; ok && prevExpense > 0 {
 					diff := currentExpense - prevExpense
-					percentChange := (diff / prevExpense) * 100
+					percentChange := diff.Float64() / prevExpense.Float64() * 100
 
 					if diff > 0 {
-						fmt.Fprintf(&text, "  📈 Expenses: +%.2f€ (+%.1f%%)\n", diff, percentChange)
+						fmt.Fprintf(&text, "  📈 Expenses: +%.2f%s (+%.1f%%)\n", diff, cur, percentChange)
 					} else {
-						fmt.Fprintf(&text, "  📉 Expenses: %.2f€ (%.1f%%)\n", diff, percentChange)
+						fmt.Fprintf(&text, "  📉 Expenses: %.2f%s (%.1f%%)\n", diff, cur, percentChange)
 					}
 				}
 			}
@@ -311,12 +302,12 @@ func (s *Scheduler) generateMonthlyRecapMessage(user model.User, totals map[int]
 			if currentIncome, ok := t[model.TypeIncome]; ok {
 				if prevIncome, ok := prevTotals[model.TypeIncome]; ok && prevIncome > 0 {
 					diff := currentIncome - prevIncome
-					percentChange := (diff / prevIncome) * 100
+					percentChange := diff.Float64() / prevIncome.Float64() * 100
 
 					if diff > 0 {
-						fmt.Fprintf(&text, "  📈 Income: +%.2f€ (+%.1f%%)\n", diff, percentChange)
+						fmt.Fprintf(&text, "  📈 Income: +%.2f%s (+%.1f%%)\n", diff, cur, percentChange)
 					} else {
-						fmt.Fprintf(&text, "  📉 Income: %.2f€ (%.1f%%)\n", diff, percentChange)
+						fmt.Fprintf(&text, "  📉 Income: %.2f%s (%.1f%%)\n", diff, cur, percentChange)
 					}
 				}
 			}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"cashout/internal/client"
+	"cashout/internal/model"
+	"fmt"
+	"time"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// createBalanceCheckInReminders creates the reminder records of the balance
+// check-in for all active users, due with the monthly recap as the start of
+// the month is when the balances of the net worth are updated
+func (s *Scheduler) createBalanceCheckInReminders() error {
+	users, err := s.repositories.Reminders.GetAllActiveUsers()
+	if err != nil {
+		return fmt.Errorf("failed to get active users: %w", err)
+	}
+
+	now := time.Now()
+
+	createdCount := 0
+	for _, user := range users {
+		scheduledFor := model.NextMonthlyRecap(now, user.Location())
+		err := s.repositories.Reminders.CreateOrUpdateBalanceCheckInReminder(user.TgID, scheduledFor)
+		if err != nil {
+			s.logger.Errorf("Failed to create balance check-in reminder for user %d: %v", user.TgID, err)
+			continue
+		}
+		createdCount++
+	}
+
+	s.logger.Infof("Created %d balance check-in reminders for %d active users", createdCount, len(users))
+	return nil
+}
+
+// processBalanceCheckIns sends all the pending balance check-ins
+func (s *Scheduler) processBalanceCheckIns() error {
+	reminders, err := s.repositories.Reminders.GetPendingReminders(
+		model.ReminderTypeBalanceCheckIn,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get pending balance check-ins: %w", err)
+	}
+
+	for _, reminder := range reminders {
+		// Update status to processing (with transaction to prevent double processing)
+		err := s.repositories.Reminders.UpdateReminderStatusTransaction(
+			reminder.ID,
+			model.ReminderStatusProcessing,
+			nil,
+		)
+		if err != nil {
+			s.logger.Errorf("Failed to update balance check-in %d to processing: %v", reminder.ID, err)
+			continue
+		}
+
+		status := model.ReminderStatusSent
+		var errMsg *string
+		if err := s.sendBalanceCheckIn(reminder.TgID); err != nil {
+			s.logger.Errorf("Failed to send balance check-in to user %d: %v", reminder.TgID, err)
+			msg := err.Error()
+			status, errMsg = model.ReminderStatusFailed, &msg
+		}
+
+		err = s.repositories.Reminders.UpdateReminderStatusTransaction(reminder.ID, status, errMsg)
+		if err != nil {
+			s.logger.Errorf("Failed to update balance check-in %d to %s: %v", reminder.ID, status, err)
+		}
+	}
+
+	return nil
+}
+
+// sendBalanceCheckIn prompts the user to update the balances of their assets
+// and liabilities recorded by hand, one at a time, if they have any
+func (s *Scheduler) sendBalanceCheckIn(tgID int64) error {
+	user, err := s.repositories.Users.GetByTgID(tgID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	balances, err := s.repositories.NetWorth.Balances(user)
+	if err != nil {
+		return fmt.Errorf("failed to get net worth: %w", err)
+	}
+	text, keyboard, ok := client.BalanceCheckInPrompt(balances, 0)
+	if !ok {
+		return nil
+	}
+
+	_, err = s.bot.SendMessage(tgID, text, &gotgbot.SendMessageOpts{
+		ParseMode: "HTML",
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: keyboard,
+		},
+	})
+	return err
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"cashout/internal/client"
+	"cashout/internal/model"
+	"fmt"
+	"html"
+	"time"
+
+	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// processRecurringRules materialises the due occurrences of the recurring
+// rules of all users and notifies them of each new transaction and of the
+// budget alerts it fires
+func (s *Scheduler) processRecurringRules() error {
+	// The rules fall due on the day of the timezone of their users: the ones due
+	// anywhere in the world are loaded, then materialised up to their user's day
+	latest := model.DateOf(time.Now().UTC().Add(14 * time.Hour))
+
+	rules, err := s.repositories.Recurring.Due(latest)
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	s.logger.Infof("Processing %d due recurring rules", len(rules))
+
+	users := make(map[int64]model.User)
+	for _, rule := range rules {
+		user, ok := users[rule.TgID]
+		if !ok {
+			user, err = s.repositories.Users.GetByTgID(rule.TgID)
+			if err != nil {
+				s.logger.Errorf("Failed to get user %d of recurring rule %d: %v", rule.TgID, rule.ID, err)
+				continue
+			}
+			users[rule.TgID] = user
+		}
+
+		today := user.Today()
+		if rule.StartDate.After(today) || (rule.LastRunOn != nil && !rule.LastRunOn.Before(today)) {
+			continue
+		}
+
+		created, err := s.repositories.Recurring.Materialize(&rule, today)
+		if err != nil {
+			s.logger.Errorf("Failed to materialize recurring rule %d: %v", rule.ID, err)
+			continue
+		}
+
+		notifier := client.BudgetNotifier{
+			Budgets: s.repositories.Budgets,
+			Bot:     s.bot,
+			Mailer:  s.mailer,
+			Logger:  s.logger,
+		}
+		for _, t := range created {
+			if err := s.sendRecurringNotification(t); err != nil {
+				s.logger.Errorf("Failed to notify user %d of recurring transaction %d: %v", t.TgID, t.ID, err)
+			}
+			notifier.Notify(user, t, true)
+		}
+	}
+
+	return nil
+}
+
+// sendRecurringNotification tells the user a recurring transaction was added, with a button to undo it
+func (s *Scheduler) sendRecurringNotification(t model.Transaction) error {
+	emoji := "💰"
+	if t.Type == model.TypeExpense {
+		emoji = "💸"
+	}
+
+	message := fmt.Sprintf(
+		"🔁 <b>Recurring transaction added</b>\n\n%s %s (%s), %s on %s",
+		emoji, t.Category, client.FormatTransactionAmount(t), html.EscapeString(t.Description), t.Date.Format("02-01-2006"),
+	)
+
+	_, err := s.bot.SendMessage(t.TgID, message, &gotgbot.SendMessageOpts{
+		ParseMode: "HTML",
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+				{{Text: "↩️ Undo", CallbackData: fmt.Sprintf("recurring.undo.%d", t.ID)}},
+			},
+		},
+	})
+	return err
+}
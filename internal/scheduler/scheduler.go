@@ -2,6 +2,8 @@ package scheduler
 
 import (
 	"cashout/internal/client"
+	"cashout/internal/email"
+	"cashout/internal/model"
 	"time"
 
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
@@ -12,16 +14,19 @@ import (
 const (
 	WEEKLY_REMINDER_PROCESSING_MIN  = 60
 	MONTHLY_REMINDER_PROCESSING_MIN = 60
+	BALANCE_CHECK_IN_PROCESSING_MIN = 60
+	RECURRING_PROCESSING_MIN        = 60
 )
 
 type Scheduler struct {
 	scheduler    *gocron.Scheduler
 	bot          *gotgbot.Bot
 	repositories client.Repositories
+	mailer       *email.EmailService // emails the budget alerts, nil if not configured
 	logger       *logrus.Logger
 }
 
-func NewScheduler(bot *gotgbot.Bot, repos client.Repositories, logger *logrus.Logger) *Scheduler {
+func NewScheduler(bot *gotgbot.Bot, repos client.Repositories, mailer *email.EmailService, logger *logrus.Logger) *Scheduler {
 	// Create scheduler with UTC timezone
 	s := gocron.NewScheduler(time.UTC)
 
@@ -29,6 +34,7 @@ func NewScheduler(bot *gotgbot.Bot, repos client.Repositories, logger *logrus.Lo
 		scheduler:    s,
 		bot:          bot,
 		repositories: repos,
+		mailer:       mailer,
 		logger:       logger,
 	}
 }
@@ -57,6 +63,17 @@ func (s *Scheduler) Start() {
 		s.logger.Errorf("Failed to schedule monthly reminders: %v", err)
 	}
 
+	// Schedule the creation of the balance check-ins, on their own so that
+	// they do not depend on the monthly recap being sent
+	_, err = s.scheduler.Every(1).Day().At("10:00").Do(func() {
+		if err := s.createBalanceCheckInReminders(); err != nil {
+			s.logger.Errorf("Failed to create balance check-in reminders: %v", err)
+		}
+	})
+	if err != nil {
+		s.logger.Errorf("Failed to schedule balance check-in reminders: %v", err)
+	}
+
 	// Process weekly reminders
 	_, err = s.scheduler.Every(WEEKLY_REMINDER_PROCESSING_MIN).Minute().Do(func() {
 		if err := s.processWeeklyReminders(); err != nil {
@@ -77,6 +94,36 @@ func (s *Scheduler) Start() {
 		s.logger.Errorf("Failed to schedule monthly reminders: %v", err)
 	}
 
+	// Process balance check-ins
+	_, err = s.scheduler.Every(BALANCE_CHECK_IN_PROCESSING_MIN).Minute().Do(func() {
+		if err := s.processBalanceCheckIns(); err != nil {
+			s.logger.Errorf("Failed to process balance check-ins: %v", err)
+		}
+	})
+	if err != nil {
+		s.logger.Errorf("Failed to schedule balance check-ins: %v", err)
+	}
+
+	// Materialise the recurring transactions
+	_, err = s.scheduler.Every(RECURRING_PROCESSING_MIN).Minute().Do(func() {
+		if err := s.processRecurringRules(); err != nil {
+			s.logger.Errorf("Failed to process recurring rules: %v", err)
+		}
+	})
+	if err != nil {
+		s.logger.Errorf("Failed to schedule recurring rules: %v", err)
+	}
+
+	// Purge the transactions in the trash for longer than the retention
+	_, err = s.scheduler.Every(1).Day().At("03:00").Do(func() {
+		if err := s.purgeTrash(); err != nil {
+			s.logger.Errorf("Failed to purge trash: %v", err)
+		}
+	})
+	if err != nil {
+		s.logger.Errorf("Failed to schedule trash purge: %v", err)
+	}
+
 	// Start the scheduler
 	s.scheduler.StartAsync()
 	s.logger.Info("Scheduler started successfully")
@@ -86,3 +133,13 @@ func (s *Scheduler) Stop() {
 	s.scheduler.Stop()
 	s.logger.Info("Scheduler stopped")
 }
+
+// displayCategories returns the user's categories to render the emojis of the
+// recaps, which fall back to the default emoji when they cannot be loaded.
+func (s *Scheduler) displayCategories(tgID int64) model.Categories {
+	categories, err := s.repositories.Categories.List(tgID)
+	if err != nil {
+		s.logger.Warnf("failed to get categories for recap: %v", err)
+	}
+	return categories
+}
@@ -0,0 +1,17 @@
+package scheduler
+
+import "time"
+
+// purgeTrash permanently deletes the transactions left in the trash of the
+// users for longer than the retention
+func (s *Scheduler) purgeTrash() error {
+	purged, err := s.repositories.Transactions.PurgeTrash(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		s.logger.Infof("Purged %d transactions from the trash", purged)
+	}
+	return nil
+}
@@ -1,8 +1,8 @@
 package scheduler
 
 import (
+	"cashout/internal/client"
 	"cashout/internal/model"
-	"cashout/internal/utils"
 	"errors"
 	"fmt"
 	"strings"
@@ -11,16 +11,6 @@ import (
 	gotgbot "github.com/PaulSonOfLars/gotgbot/v2"
 )
 
-func getNextMonday() time.Time {
-	now := time.Now()
-	daysUntilMonday := (8 - int(now.Weekday())) % 7
-	if daysUntilMonday == 0 {
-		daysUntilMonday = 7
-	}
-	nextMonday := now.AddDate(0, 0, daysUntilMonday)
-	return nextMonday
-}
-
 // createWeeklyReminders creates reminder records for all active users
 func (s *Scheduler) createWeeklyReminders() error {
 	s.logger.Info("Creating weekly reminders...")
@@ -31,12 +21,12 @@ func (s *Scheduler) createWeeklyReminders() error {
 		return fmt.Errorf("failed to get active users: %w", err)
 	}
 
-	// Schedule for 6:00 GMT on Monday
-	monday := getNextMonday()
-	scheduledFor := time.Date(monday.Year(), monday.Month(), monday.Day(), 6, 0, 0, 0, time.UTC)
+	// Schedule for Monday morning in the timezone of each user
+	now := time.Now()
 
 	createdCount := 0
 	for _, user := range users {
+		scheduledFor := model.NextWeeklyRecap(now, user.Location())
 		err := s.repositories.Reminders.CreateOrUpdateWeeklyReminder(user.TgID, scheduledFor)
 		if err != nil {
 			s.logger.Errorf("Failed to create reminder for user %d: %v", user.TgID, err)
@@ -119,19 +109,18 @@ func (s *Scheduler) sendWeeklyRecap(tgID int64) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Calculate previous week boundaries (Monday to Sunday)
-	now := time.Now().UTC()
-	weekday := int(now.Weekday())
+	// Calculate previous week boundaries (Monday to Sunday) in the user's timezone
+	today := user.Today()
+	weekday := int(today.Weekday())
 	if weekday == 0 {
 		weekday = 7
 	}
 	// Get to this week's Monday
 	daysToMonday := weekday - 1
-	thisMonday := now.AddDate(0, 0, -daysToMonday)
+	thisMonday := today.AddDate(0, 0, -daysToMonday)
 
 	// Previous week is 7 days before
 	startOfPrevWeek := thisMonday.AddDate(0, 0, -7)
-	startOfPrevWeek = time.Date(startOfPrevWeek.Year(), startOfPrevWeek.Month(), startOfPrevWeek.Day(), 0, 0, 0, 0, time.UTC)
 
 	endOfPrevWeek := startOfPrevWeek.AddDate(0, 0, 6)
 	endOfPrevWeek = time.Date(endOfPrevWeek.Year(), endOfPrevWeek.Month(), endOfPrevWeek.Day(), 23, 59, 59, 999999999, time.UTC)
@@ -142,8 +131,14 @@ func (s *Scheduler) sendWeeklyRecap(tgID int64) error {
 		return fmt.Errorf("failed to get weekly transactions: %w", err)
 	}
 
+	// Get the weekly budgets (optional), in their week overlapping the most with the recap's one
+	budgets, err := s.weeklyBudgets(model.Scope{TgID: user.TgID}, startOfPrevWeek)
+	if err != nil {
+		return err
+	}
+
 	// Generate the recap message
-	message := s.generateWeeklyRecapMessage(user, transactions, startOfPrevWeek, endOfPrevWeek)
+	message := s.generateWeeklyRecapMessage(user, transactions, budgets, startOfPrevWeek, endOfPrevWeek)
 
 	// Send the message
 	_, err = s.bot.SendMessage(tgID, message, &gotgbot.SendMessageOpts{
@@ -155,8 +150,10 @@ func (s *Scheduler) sendWeeklyRecap(tgID int64) error {
 
 // generateWeeklyRecapMessage generates the weekly recap message
 // This reuses the logic from the WeekRecap function but adapted for previous week
-func (s *Scheduler) generateWeeklyRecapMessage(user model.User, transactions []model.Transaction, startOfWeek, endOfWeek time.Time) string {
+func (s *Scheduler) generateWeeklyRecapMessage(user model.User, transactions []model.Transaction, budgets []model.BudgetSpending, startOfWeek, endOfWeek time.Time) string {
 	var text strings.Builder
+	cur := user.BaseCurrency.Symbol()
+	userCategories := s.displayCategories(user.TgID)
 
 	// Header
 	fmt.Fprintf(&text, "🗓 <b>%s, here's your weekly recap!</b>\n\n", user.Name)
@@ -171,15 +168,20 @@ func (s *Scheduler) generateWeeklyRecapMessage(user model.User, transactions []m
 	}
 
 	// Calculate totals by type and category
-	typeTotals := make(map[model.TransactionType]float64)
-	categoryTotals := make(map[model.TransactionType]map[model.TransactionCategory]float64)
-	dailyTotals := make(map[string]map[model.TransactionType]float64)
+	typeTotals := make(map[model.TransactionType]model.Money)
+	categoryTotals := make(map[model.TransactionType]map[model.TransactionCategory]model.Money)
+	dailyTotals := make(map[string]map[model.TransactionType]model.Money)
 
 	// Initialize category totals map
-	categoryTotals[model.TypeExpense] = make(map[model.TransactionCategory]float64)
-	categoryTotals[model.TypeIncome] = make(map[model.TransactionCategory]float64)
+	categoryTotals[model.TypeExpense] = make(map[model.TransactionCategory]model.Money)
+	categoryTotals[model.TypeIncome] = make(map[model.TransactionCategory]model.Money)
 
 	for _, t := range transactions {
+		// Transfers move money between accounts, they are neither incomes nor expenses
+		if t.IsTransfer() {
+			continue
+		}
+
 		// Type totals
 		typeTotals[t.Type] += t.Amount
 
@@ -189,22 +191,22 @@ func (s *Scheduler) generateWeeklyRecapMessage(user model.User, transactions []m
 		// Daily totals
 		dayKey := t.Date.Format("Mon 02")
 		if dailyTotals[dayKey] == nil {
-			dailyTotals[dayKey] = make(map[model.TransactionType]float64)
+			dailyTotals[dayKey] = make(map[model.TransactionType]model.Money)
 		}
 		dailyTotals[dayKey][t.Type] += t.Amount
 	}
 
-	var weekTotal float64
+	var weekTotal model.Money
 
 	// Summary section
 	if expenseAmount, ok := typeTotals[model.TypeExpense]; ok && expenseAmount > 0 {
 		weekTotal -= expenseAmount
-		fmt.Fprintf(&text, "💸 <b>Total Expenses:</b> %.2f€\n", expenseAmount)
+		fmt.Fprintf(&text, "💸 <b>Total Expenses:</b> %.2f%s\n", expenseAmount, cur)
 	}
 
 	if incomeAmount, ok := typeTotals[model.TypeIncome]; ok && incomeAmount > 0 {
 		weekTotal += incomeAmount
-		fmt.Fprintf(&text, "💰 <b>Total Income:</b> %.2f€\n", incomeAmount)
+		fmt.Fprintf(&text, "💰 <b>Total Income:</b> %.2f%s\n", incomeAmount, cur)
 	}
 
 	// Balance
@@ -214,7 +216,8 @@ func (s *Scheduler) generateWeeklyRecapMessage(user model.User, transactions []m
 	} else {
 		balanceEmoji = "❌"
 	}
-	fmt.Fprintf(&text, "\n%s <b>Week Balance:</b> %.2f€\n", balanceEmoji, weekTotal)
+	fmt.Fprintf(&text, "\n%s <b>Week Balance:</b> %.2f%s\n", balanceEmoji, weekTotal, cur)
+	text.WriteString(client.FormatBudgetRecap(budgets, userCategories, cur))
 
 	// Top expense categories (if any)
 	if expenseCats := categoryTotals[model.TypeExpense]; len(expenseCats) > 0 {
@@ -223,7 +226,7 @@ func (s *Scheduler) generateWeeklyRecapMessage(user model.User, transactions []m
 		// Get top 3 categories
 		type catAmount struct {
 			cat    model.TransactionCategory
-			amount float64
+			amount model.Money
 		}
 		var sorted []catAmount
 		for cat, amount := range expenseCats {
@@ -241,18 +244,34 @@ func (s *Scheduler) generateWeeklyRecapMessage(user model.User, transactions []m
 		// Show top 3
 		limit := min(len(sorted), 3)
 		for i := range limit {
-			emoji := utils.GetCategoryEmoji(sorted[i].cat)
-			fmt.Fprintf(&text, "  %s %s: %.2f€\n", emoji, sorted[i].cat, sorted[i].amount)
+			emoji := userCategories.Emoji(sorted[i].cat)
+			fmt.Fprintf(&text, "  %s %s: %.2f%s\n", emoji, sorted[i].cat, sorted[i].amount, cur)
 		}
 	}
 
 	// Average daily spending
 	if expenseAmount, ok := typeTotals[model.TypeExpense]; ok && expenseAmount > 0 {
-		avgDaily := expenseAmount / 7
-		fmt.Fprintf(&text, "\n📈 <b>Avg Daily Spending:</b> %.2f€\n", avgDaily)
+		avgDaily := expenseAmount.Float64() / 7
+		fmt.Fprintf(&text, "\n📈 <b>Avg Daily Spending:</b> %.2f%s\n", avgDaily, cur)
 	}
 
 	text.WriteString("\n💡 <i>Type /week to see this week's progress!</i>")
 
 	return text.String()
 }
+
+// weeklyBudgets returns the spending against the weekly budgets of a scope in
+// their week containing day
+func (s *Scheduler) weeklyBudgets(scope model.Scope, day time.Time) ([]model.BudgetSpending, error) {
+	spending, err := s.repositories.Budgets.Spending(scope, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	var weekly []model.BudgetSpending
+	for _, sp := range spending {
+		if sp.Budget.Period == model.PeriodWeekly {
+			weekly = append(weekly, sp)
+		}
+	}
+	return weekly, nil
+}
@@ -1,41 +1,10 @@
 package utils
 
 import (
-	"cashout/internal/model"
 	"regexp"
 	"strings"
 )
 
-// GetCategoryEmoji returns the appropriate emoji for a transaction category
-func GetCategoryEmoji(category model.TransactionCategory) string {
-	emojiMap := map[model.TransactionCategory]string{
-		model.CategorySalary:        "💵",
-		model.CategoryOtherIncomes:  "💵",
-		model.CategoryCar:           "🚗",
-		model.CategoryClothes:       "👕",
-		model.CategoryGrocery:       "🛒",
-		model.CategoryHouse:         "🏠",
-		model.CategoryBills:         "📄",
-		model.CategoryEntertainment: "🎭",
-		model.CategorySport:         "🏋️",
-		model.CategoryEatingOut:     "🍽️",
-		model.CategoryTransport:     "🚆",
-		model.CategoryLearning:      "📚",
-		model.CategoryToiletry:      "🚿",
-		model.CategoryHealth:        "🏥",
-		model.CategoryTech:          "💻",
-		model.CategoryGifts:         "🎁",
-		model.CategoryTravel:        "✈️",
-		model.CategoryPets:          "🐈",
-		model.CategoryOtherExpenses: "📌",
-	}
-
-	if emoji, ok := emojiMap[category]; ok {
-		return emoji
-	}
-	return "📌" // Default emoji
-}
-
 // IsAnIncomeTransactionPrompt returns true if the transaction category could be an income category
 func IsAnIncomeTransactionPrompt(text string) bool {
 	incomeWords := []string{"income", "salary", "income", "tip", "stipend", "gratuity", "paycheck", "pay", "earning", "dividend", "payslip", "reward", "refund"}
@@ -49,3 +18,25 @@ func IsAnIncomeTransactionPrompt(text string) bool {
 
 	return matched
 }
+
+// questionOpeners are the openings that only questions start with, the
+// others like "when" or "do" are common in descriptions too, e.g. "when in
+// rome pizza 12"
+var questionOpeners = []string{"how much", "how many", "what", "what's", "whats", "which", "show me"}
+
+// IsAQuestionPrompt returns true if the text is a question, e.g. "how much did I spend in 2025?",
+// rather than a transaction even when it has numbers: it ends with a question
+// mark or starts with one of the questionOpeners
+func IsAQuestionPrompt(text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if strings.HasSuffix(text, "?") {
+		return true
+	}
+
+	for _, opener := range questionOpeners {
+		if rest, ok := strings.CutPrefix(text, opener); ok && (rest == "" || rest[0] == ' ') {
+			return true
+		}
+	}
+	return false
+}
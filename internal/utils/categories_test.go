@@ -1,57 +1,9 @@
 package utils
 
 import (
-	"cashout/internal/model"
 	"testing"
 )
 
-func TestGetCategoryEmoji(t *testing.T) {
-	tests := []struct {
-		name     string
-		category model.TransactionCategory
-		want     string
-	}{
-		{
-			name:     "Salary category",
-			category: model.CategorySalary,
-			want:     "💵",
-		},
-		{
-			name:     "Car category",
-			category: model.CategoryCar,
-			want:     "🚗",
-		},
-		{
-			name:     "Grocery category",
-			category: model.CategoryGrocery,
-			want:     "🛒",
-		},
-		{
-			name:     "Health category",
-			category: model.CategoryHealth,
-			want:     "🏥",
-		},
-		{
-			name:     "Pets category",
-			category: model.CategoryPets,
-			want:     "🐈",
-		},
-		{
-			name:     "Invalid category returns default",
-			category: model.TransactionCategory("InvalidCategory"),
-			want:     "📌",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := GetCategoryEmoji(tt.category); got != tt.want {
-				t.Errorf("GetCategoryEmoji() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestIsAnIncomeTransactionPrompt(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -108,3 +60,32 @@ func TestIsAnIncomeTransactionPrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestIsAQuestionPrompt(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "how much did I spend in 2025", want: true},
+		{input: "What was my biggest expense last month", want: true},
+		{input: "groceries over 100?", want: true},
+		{input: "  did I pay the rent 3 times? ", want: true},
+		{input: "show me the expenses over 50", want: true},
+		{input: "coffee 3", want: false},
+		{input: "however 20 pizza", want: false},
+		{input: "when in rome pizza 12", want: false},
+		{input: "do laundry 10", want: false},
+		{input: "was 20 for the taxi", want: false},
+		{input: "how to train your dragon 15", want: false},
+		{input: "whatever 5", want: false},
+		{input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := IsAQuestionPrompt(tt.input); got != tt.want {
+				t.Errorf("IsAQuestionPrompt(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
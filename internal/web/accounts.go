@@ -0,0 +1,474 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toAccountDTO(a model.Account, balance model.Money, user *model.User) AccountDTO {
+	return AccountDTO{
+		ID:             a.ID,
+		Name:           a.Name,
+		Kind:           string(a.Kind),
+		Currency:       string(a.Currency),
+		OpeningBalance: a.OpeningBalance,
+		Balance:        balance,
+		Archived:       a.Archived,
+		IsDefault:      user.DefaultAccountID != nil && *user.DefaultAccountID == a.ID,
+	}
+}
+
+// isAccountError reports whether the error is one of the account validation errors.
+func isAccountError(err error) bool {
+	for _, target := range []error{
+		model.ErrInvalidAccount, model.ErrInvalidTransfer, model.ErrAccountNotFound, model.ErrAccountNotOwned,
+		model.ErrAccountExists, model.ErrAccountInUse, model.ErrAccountArchived,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendAccountError maps the account validation errors to a 4xx response.
+func (s *Server) sendAccountError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidAccount), errors.Is(err, model.ErrInvalidTransfer), errors.Is(err, model.ErrAccountArchived):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrAccountNotFound), errors.Is(err, model.ErrAccountNotOwned):
+		s.sendJSONError(w, "Account not found", http.StatusNotFound)
+	case errors.Is(err, model.ErrAccountExists):
+		s.sendJSONError(w, "Account already exists", http.StatusConflict)
+	case errors.Is(err, model.ErrAccountInUse):
+		s.sendJSONError(w, "Account is used by some transactions, archive it instead", http.StatusConflict)
+	default:
+		s.logger.Errorf("Failed to %s account: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action+" account", http.StatusInternalServerError)
+	}
+}
+
+// handleAPIAccounts returns the user's accounts with their current balances.
+//
+//	@Summary		List accounts
+//	@Description	Every account is listed, archived ones included, with its current balance in the account currency.
+//	@Tags			accounts
+//	@Produce		json
+//	@Success		200	{object}	AccountsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/accounts [get]
+func (s *Server) handleAPIAccounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accounts, err := s.repositories.Accounts.List(user.TgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get accounts: %v", err)
+		s.sendJSONError(w, "Failed to get accounts", http.StatusInternalServerError)
+		return
+	}
+
+	resp := AccountsResponse{Accounts: make([]AccountDTO, len(accounts))}
+	for i, a := range accounts {
+		balance, err := s.repositories.Accounts.Balance(a)
+		if err != nil {
+			s.logger.Errorf("Failed to get account balance: %v", err)
+			s.sendJSONError(w, "Failed to get accounts", http.StatusInternalServerError)
+			return
+		}
+		resp.Accounts[i] = toAccountDTO(a, balance, user)
+	}
+
+	s.sendJSONSuccess(w, resp)
+}
+
+// handleAPICreateAccount creates a new account.
+//
+//	@Summary		Create account
+//	@Tags			accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		CreateAccountRequest	true	"Account payload"
+//	@Success		200		{object}	AccountDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/accounts/create [post]
+func (s *Server) handleAPICreateAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	currency := user.BaseCurrency
+	if req.Currency != "" {
+		currency = model.CurrencyType(req.Currency)
+	}
+	if currency == "" {
+		currency = model.CurrencyEUR
+	}
+
+	account := model.Account{
+		TgID:           user.TgID,
+		Name:           req.Name,
+		Kind:           model.AccountKind(req.Kind),
+		Currency:       currency,
+		OpeningBalance: req.OpeningBalance,
+	}
+	if err := s.repositories.Accounts.Create(&account); err != nil {
+		s.sendAccountError(w, err, "create")
+		return
+	}
+
+	if req.IsDefault {
+		if err := s.repositories.Accounts.SetDefault(user.TgID, &account.ID); err != nil {
+			s.sendAccountError(w, err, "create")
+			return
+		}
+		user.DefaultAccountID = &account.ID
+	}
+
+	s.sendJSONSuccess(w, toAccountDTO(account, account.OpeningBalance, user))
+}
+
+// handleAPIEditAccount applies a partial update to an account.
+//
+//	@Summary		Edit account (partial)
+//	@Description	Update the name, kind, opening balance, archived flag or default flag of an account. The currency cannot be changed. Archived accounts cannot be used for new transactions and are never the default one.
+//	@Tags			accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		EditAccountRequest	true	"Fields to update; only non-null fields are applied"
+//	@Success		200		{object}	AccountDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/accounts/edit [patch]
+func (s *Server) handleAPIEditAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req EditAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		s.sendJSONError(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	if req.Name == nil && req.Kind == nil && req.OpeningBalance == nil && req.Archived == nil && req.IsDefault == nil {
+		s.sendJSONError(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	account, err := s.repositories.Accounts.Get(user.TgID, req.ID)
+	if err != nil {
+		s.sendAccountError(w, err, "edit")
+		return
+	}
+
+	if req.Name != nil {
+		account.Name = *req.Name
+	}
+	if req.Kind != nil {
+		account.Kind = model.AccountKind(*req.Kind)
+	}
+	if req.OpeningBalance != nil {
+		account.OpeningBalance = *req.OpeningBalance
+	}
+	if req.Archived != nil {
+		account.Archived = *req.Archived
+	}
+
+	if err := s.repositories.Accounts.Update(&account); err != nil {
+		s.sendAccountError(w, err, "edit")
+		return
+	}
+
+	// Archiving the default account unsets it
+	if account.Archived && user.DefaultAccountID != nil && *user.DefaultAccountID == account.ID {
+		user.DefaultAccountID = nil
+	}
+
+	if req.IsDefault != nil {
+		isDefault := user.DefaultAccountID != nil && *user.DefaultAccountID == account.ID
+		switch {
+		case *req.IsDefault && !isDefault:
+			err = s.repositories.Accounts.SetDefault(user.TgID, &account.ID)
+			user.DefaultAccountID = &account.ID
+		case !*req.IsDefault && isDefault:
+			err = s.repositories.Accounts.SetDefault(user.TgID, nil)
+			user.DefaultAccountID = nil
+		}
+		if err != nil {
+			s.sendAccountError(w, err, "edit")
+			return
+		}
+	}
+
+	balance, err := s.repositories.Accounts.Balance(account)
+	if err != nil {
+		s.sendAccountError(w, err, "edit")
+		return
+	}
+
+	s.sendJSONSuccess(w, toAccountDTO(account, balance, user))
+}
+
+// handleAPIDeleteAccount deletes an account no transaction uses.
+//
+//	@Summary		Delete account
+//	@Description	Only accounts without transactions can be deleted, the others can be archived.
+//	@Tags			accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		DeleteAccountRequest	true	"Account ID payload"
+//	@Success		200		{object}	MessageResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/accounts/delete [delete]
+func (s *Server) handleAPIDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		s.sendJSONError(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Accounts.Delete(user.TgID, req.ID); err != nil {
+		s.sendAccountError(w, err, "delete")
+		return
+	}
+
+	s.sendJSONSuccess(w, MessageResponse{Message: "Account deleted successfully"})
+}
+
+// handleAPIAccountLedger returns the running balance of an account.
+//
+//	@Summary		Account ledger
+//	@Description	List the transactions of an account in date order, each with the balance right after it, in the account currency. Transfers count as outgoing for the source account and incoming for the destination one.
+//	@Tags			accounts
+//	@Produce		json
+//	@Param			id			query		int		true	"Account ID"
+//	@Param			dateFrom	query		string	false	"Inclusive lower bound (YYYY-MM-DD)"
+//	@Param			dateTo		query		string	false	"Inclusive upper bound (YYYY-MM-DD)"
+//	@Success		200			{object}	LedgerResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/accounts/ledger [get]
+func (s *Server) handleAPIAccountLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	id, err := strconv.ParseInt(q.Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		s.sendJSONError(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	var from, to *time.Time
+	if v := q.Get("dateFrom"); v != "" {
+		d, err := time.Parse(dateLayout, v)
+		if err != nil {
+			s.sendJSONError(w, "Invalid dateFrom (expected YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		from = &d
+	}
+	if v := q.Get("dateTo"); v != "" {
+		d, err := time.Parse(dateLayout, v)
+		if err != nil {
+			s.sendJSONError(w, "Invalid dateTo (expected YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		// Make dateTo inclusive to the end of the day.
+		end := d.Add(24*time.Hour - time.Nanosecond)
+		to = &end
+	}
+	if from != nil && to != nil && from.After(*to) {
+		s.sendJSONError(w, "dateFrom must be on or before dateTo", http.StatusBadRequest)
+		return
+	}
+
+	account, err := s.repositories.Accounts.Get(user.TgID, id)
+	if err != nil {
+		s.sendAccountError(w, err, "get")
+		return
+	}
+
+	start, entries, err := s.repositories.Accounts.Ledger(account, from, to)
+	if err != nil {
+		s.logger.Errorf("Failed to get account ledger: %v", err)
+		s.sendJSONError(w, "Failed to get account ledger", http.StatusInternalServerError)
+		return
+	}
+
+	resp := LedgerResponse{
+		StartBalance: start,
+		EndBalance:   start,
+		Entries:      make([]LedgerEntryDTO, len(entries)),
+	}
+	for i, e := range entries {
+		resp.Entries[i] = LedgerEntryDTO{
+			Transaction: toTransactionDTO(e.Transaction),
+			Delta:       e.Delta,
+			Balance:     e.Balance,
+		}
+		resp.EndBalance = e.Balance
+	}
+
+	// The ledger is bounded by dateTo, the account balance is the current one
+	balance := resp.EndBalance
+	if to != nil {
+		if balance, err = s.repositories.Accounts.Balance(account); err != nil {
+			s.logger.Errorf("Failed to get account balance: %v", err)
+			s.sendJSONError(w, "Failed to get account ledger", http.StatusInternalServerError)
+			return
+		}
+	}
+	resp.Account = toAccountDTO(account, balance, user)
+
+	s.sendJSONSuccess(w, resp)
+}
+
+// handleAPITransfer moves money between two accounts of the user.
+//
+//	@Summary		Create transfer
+//	@Description	Create a Transfer transaction moving the amount, in the currency of the source account, to the destination account. Transfers are neither income nor expense and are excluded from balances, recaps and budgets.
+//	@Tags			accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		TransferRequest	true	"Transfer payload"
+//	@Success		200		{object}	TransactionDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/accounts/transfer [post]
+func (s *Server) handleAPITransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	date, err := time.Parse(dateLayout, req.Date)
+	if err != nil {
+		s.sendJSONError(w, "Invalid date format (expected YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	if date.After(user.Today()) {
+		s.sendJSONError(w, "Date cannot be in the future", http.StatusBadRequest)
+		return
+	}
+
+	from, err := s.repositories.Accounts.Get(user.TgID, req.FromAccountID)
+	if err != nil {
+		s.sendAccountError(w, err, "transfer from")
+		return
+	}
+	to, err := s.repositories.Accounts.Get(user.TgID, req.ToAccountID)
+	if err != nil {
+		s.sendAccountError(w, err, "transfer to")
+		return
+	}
+
+	transfer, err := model.NewTransfer(from, to, req.Amount, date, req.Description)
+	if err == nil {
+		err = s.repositories.Transactions.As(actor(r, user)).Add(&transfer)
+	}
+	if errors.Is(err, model.ErrExchangeRateNotFound) {
+		s.sendJSONError(w, "Exchange rate not available for this currency", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.sendAccountError(w, err, "transfer between")
+		return
+	}
+
+	s.sendJSONSuccess(w, toTransactionDTO(transfer))
+}
@@ -8,12 +8,13 @@ import (
 	"cashout/internal/client"
 	"cashout/internal/db"
 	"cashout/internal/model"
+	"cashout/internal/repository"
 )
 
-// handleAPIAnalyticsMonthly returns category breakdown + totals for a month.
+// handleAPIAnalyticsMonthly returns category and tag breakdown + totals for a month.
 //
 //	@Summary		Monthly category breakdown
-//	@Description	Returns total income/expenses and per-category aggregates for a given month.
+//	@Description	Returns total income/expenses and per-category and per-tag aggregates for a given month.
 //	@Tags			analytics
 //	@Produce		json
 //	@Param			month	query		string	false	"Month in YYYY-MM (defaults to current month)"
@@ -32,19 +33,19 @@ func (s *Server) handleAPIAnalyticsMonthly(w http.ResponseWriter, r *http.Reques
 	monthStr := r.URL.Query().Get("month")
 	current, err := time.Parse(monthLayout, monthStr)
 	if err != nil {
-		current = time.Now()
+		current = user.Today()
 	}
 
 	startDate := time.Date(current.Year(), current.Month(), 1, 0, 0, 0, 0, time.UTC)
 	endDate := startDate.AddDate(0, 1, -1)
 
-	expense, err := s.repositories.Transactions.GetCategoryAggregates(user.TgID, startDate, endDate, model.TypeExpense)
+	expense, err := s.repositories.Transactions.GetCategoryAggregates(user.Scope(), startDate, endDate, model.TypeExpense)
 	if err != nil {
 		s.logger.Errorf("analytics monthly expense: %v", err)
 		s.sendJSONError(w, "Failed to load analytics", http.StatusInternalServerError)
 		return
 	}
-	income, err := s.repositories.Transactions.GetCategoryAggregates(user.TgID, startDate, endDate, model.TypeIncome)
+	income, err := s.repositories.Transactions.GetCategoryAggregates(user.Scope(), startDate, endDate, model.TypeIncome)
 	if err != nil {
 		s.logger.Errorf("analytics monthly income: %v", err)
 		s.sendJSONError(w, "Failed to load analytics", http.StatusInternalServerError)
@@ -54,6 +55,20 @@ func (s *Server) handleAPIAnalyticsMonthly(w http.ResponseWriter, r *http.Reques
 	expenseEntries, totalExpense := buildCategoryEntries(expense)
 	incomeEntries, totalIncome := buildCategoryEntries(income)
 
+	byTag, err := s.loadTagBreakdown(user.Scope(), startDate, endDate, totalExpense, totalIncome)
+	if err != nil {
+		s.logger.Errorf("analytics monthly tags: %v", err)
+		s.sendJSONError(w, "Failed to load analytics", http.StatusInternalServerError)
+		return
+	}
+
+	byMember, err := s.loadMemberBreakdown(*user, startDate, endDate)
+	if err != nil {
+		s.logger.Errorf("analytics monthly members: %v", err)
+		s.sendJSONError(w, "Failed to load analytics", http.StatusInternalServerError)
+		return
+	}
+
 	s.sendJSONSuccess(w, MonthlyAnalyticsResponse{
 		Month:         current.Format(monthLayout),
 		TotalIncome:   totalIncome,
@@ -63,6 +78,8 @@ func (s *Server) handleAPIAnalyticsMonthly(w http.ResponseWriter, r *http.Reques
 			Expense: expenseEntries,
 			Income:  incomeEntries,
 		},
+		ByTag:    byTag,
+		ByMember: byMember,
 	})
 }
 
@@ -91,11 +108,11 @@ func (s *Server) handleAPIAnalyticsTrend(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	now := time.Now().UTC()
+	now := user.Today()
 	endMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, -1)
 	startMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -(months - 1), 0)
 
-	rows, err := s.repositories.Transactions.GetMonthlyTotalsByRange(user.TgID, startMonth, endMonth)
+	rows, err := s.repositories.Transactions.GetMonthlyTotalsByRange(user.Scope(), startMonth, endMonth)
 	if err != nil {
 		s.logger.Errorf("analytics trend: %v", err)
 		s.sendJSONError(w, "Failed to load trend", http.StatusInternalServerError)
@@ -111,9 +128,9 @@ func (s *Server) handleAPIAnalyticsTrend(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-// handleAPIAnalyticsYear returns per-month totals and category breakdown for a year.
+// handleAPIAnalyticsYear returns per-month totals and category and tag breakdown for a year.
 //
-//	@Summary		Annual breakdown by month and category
+//	@Summary		Annual breakdown by month, category and tag
 //	@Tags			analytics
 //	@Produce		json
 //	@Param			year	query		int	false	"4-digit year (defaults to current year)"
@@ -129,7 +146,7 @@ func (s *Server) handleAPIAnalyticsYear(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	year := time.Now().Year()
+	year := user.Today().Year()
 	if v := r.URL.Query().Get("year"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n >= 1970 && n <= 9999 {
 			year = n
@@ -139,7 +156,7 @@ func (s *Server) handleAPIAnalyticsYear(w http.ResponseWriter, r *http.Request)
 	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
 
-	rows, err := s.repositories.Transactions.GetMonthlyTotalsByRange(user.TgID, startDate, endDate)
+	rows, err := s.repositories.Transactions.GetMonthlyTotalsByRange(user.Scope(), startDate, endDate)
 	if err != nil {
 		s.logger.Errorf("analytics year monthly: %v", err)
 		s.sendJSONError(w, "Failed to load year analytics", http.StatusInternalServerError)
@@ -148,7 +165,7 @@ func (s *Server) handleAPIAnalyticsYear(w http.ResponseWriter, r *http.Request)
 	points := buildMonthPoints(startDate, 12, rows)
 
 	byMonth := make([]YearMonthEntry, 12)
-	var totalIncome, totalExpense float64
+	var totalIncome, totalExpense model.Money
 	for i, p := range points {
 		byMonth[i] = YearMonthEntry{
 			Month:   i + 1,
@@ -160,13 +177,13 @@ func (s *Server) handleAPIAnalyticsYear(w http.ResponseWriter, r *http.Request)
 		totalExpense += p.Expense
 	}
 
-	expense, err := s.repositories.Transactions.GetCategoryAggregates(user.TgID, startDate, endDate, model.TypeExpense)
+	expense, err := s.repositories.Transactions.GetCategoryAggregates(user.Scope(), startDate, endDate, model.TypeExpense)
 	if err != nil {
 		s.logger.Errorf("analytics year expense: %v", err)
 		s.sendJSONError(w, "Failed to load year analytics", http.StatusInternalServerError)
 		return
 	}
-	income, err := s.repositories.Transactions.GetCategoryAggregates(user.TgID, startDate, endDate, model.TypeIncome)
+	income, err := s.repositories.Transactions.GetCategoryAggregates(user.Scope(), startDate, endDate, model.TypeIncome)
 	if err != nil {
 		s.logger.Errorf("analytics year income: %v", err)
 		s.sendJSONError(w, "Failed to load year analytics", http.StatusInternalServerError)
@@ -175,6 +192,20 @@ func (s *Server) handleAPIAnalyticsYear(w http.ResponseWriter, r *http.Request)
 	expenseEntries, _ := buildCategoryEntries(expense)
 	incomeEntries, _ := buildCategoryEntries(income)
 
+	byTag, err := s.loadTagBreakdown(user.Scope(), startDate, endDate, totalExpense, totalIncome)
+	if err != nil {
+		s.logger.Errorf("analytics year tags: %v", err)
+		s.sendJSONError(w, "Failed to load year analytics", http.StatusInternalServerError)
+		return
+	}
+
+	byMember, err := s.loadMemberBreakdown(*user, startDate, endDate)
+	if err != nil {
+		s.logger.Errorf("analytics year members: %v", err)
+		s.sendJSONError(w, "Failed to load year analytics", http.StatusInternalServerError)
+		return
+	}
+
 	s.sendJSONSuccess(w, YearAnalyticsResponse{
 		Year:          year,
 		TotalIncome:   totalIncome,
@@ -185,11 +216,93 @@ func (s *Server) handleAPIAnalyticsYear(w http.ResponseWriter, r *http.Request)
 			Expense: expenseEntries,
 			Income:  incomeEntries,
 		},
+		ByTag:    byTag,
+		ByMember: byMember,
 	})
 }
 
-func buildCategoryEntries(rows []db.CategoryAggregate) ([]CategoryEntry, float64) {
-	var total float64
+// loadTagBreakdown returns the per-tag aggregates over a date range, the
+// percentages are relative to the given expense and income totals.
+func (s *Server) loadTagBreakdown(scope model.Scope, startDate, endDate time.Time, totalExpense, totalIncome model.Money) (TagBreakdown, error) {
+	expense, err := s.repositories.Transactions.GetTagAggregates(scope, startDate, endDate, model.TypeExpense)
+	if err != nil {
+		return TagBreakdown{}, err
+	}
+	income, err := s.repositories.Transactions.GetTagAggregates(scope, startDate, endDate, model.TypeIncome)
+	if err != nil {
+		return TagBreakdown{}, err
+	}
+	return TagBreakdown{
+		Expense: buildTagEntries(expense, totalExpense),
+		Income:  buildTagEntries(income, totalIncome),
+	}, nil
+}
+
+// loadMemberBreakdown returns the totals of each member of the active ledger
+// of the user over a date range, nil for personal analytics.
+func (s *Server) loadMemberBreakdown(user model.User, startDate, endDate time.Time) ([]MemberEntry, error) {
+	if user.LedgerID == nil {
+		return nil, nil
+	}
+	ledger, err := s.repositories.Ledgers.Get(user.TgID, *user.LedgerID)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.repositories.Ledgers.MemberTotals(ledger.ID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return buildMemberEntries(ledger, totals), nil
+}
+
+// buildMemberEntries pivots the member totals by type, every member of the
+// ledger is listed and former members who entered transactions are appended.
+func buildMemberEntries(ledger model.Ledger, totals []repository.MemberTotal) []MemberEntry {
+	entries := make([]MemberEntry, 0, len(ledger.Members))
+	idx := make(map[int64]int, len(ledger.Members))
+	for _, m := range ledger.Members {
+		idx[m.TgID] = len(entries)
+		entries = append(entries, MemberEntry{TgID: m.TgID, Name: m.DisplayName()})
+	}
+	for _, t := range totals {
+		i, ok := idx[t.TgID]
+		if !ok {
+			i = len(entries)
+			idx[t.TgID] = i
+			entries = append(entries, MemberEntry{TgID: t.TgID, Name: "Former member"})
+		}
+		switch t.Type {
+		case model.TypeIncome:
+			entries[i].Income += t.Total
+		case model.TypeExpense:
+			entries[i].Expense += t.Total
+		}
+		entries[i].Count += t.Count
+	}
+	return entries
+}
+
+// buildTagEntries converts the tag aggregates, total is the amount of all the
+// transactions of the type, tagged or not.
+func buildTagEntries(rows []db.TagAggregate, total model.Money) []TagEntry {
+	entries := make([]TagEntry, len(rows))
+	for i, r := range rows {
+		pct := 0.0
+		if total > 0 {
+			pct = r.Amount.Float64() / total.Float64() * 100
+		}
+		entries[i] = TagEntry{
+			Tag:    r.Tag,
+			Amount: r.Amount,
+			Count:  r.Count,
+			Pct:    pct,
+		}
+	}
+	return entries
+}
+
+func buildCategoryEntries(rows []db.CategoryAggregate) ([]CategoryEntry, model.Money) {
+	var total model.Money
 	for _, r := range rows {
 		total += r.Amount
 	}
@@ -197,7 +310,7 @@ func buildCategoryEntries(rows []db.CategoryAggregate) ([]CategoryEntry, float64
 	for i, r := range rows {
 		pct := 0.0
 		if total > 0 {
-			pct = (r.Amount / total) * 100
+			pct = r.Amount.Float64() / total.Float64() * 100
 		}
 		entries[i] = CategoryEntry{
 			Category: string(r.Category),
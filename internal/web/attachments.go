@@ -0,0 +1,240 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toAttachmentDTO(a model.Attachment) AttachmentDTO {
+	return AttachmentDTO{
+		ID:            a.ID,
+		TransactionID: a.TransactionID,
+		FileName:      a.FileName,
+		ContentType:   a.ContentType,
+		Size:          a.Size,
+		CreatedAt:     a.CreatedAt,
+		URL:           fmt.Sprintf("%s/api/transactions/%d/attachments/%d", basePath, a.TransactionID, a.ID),
+	}
+}
+
+// attachmentContentType returns the type of an uploaded file from its first
+// bytes, the declared one is only trusted for the types that cannot be
+// detected, such as HEIC photos
+func attachmentContentType(declared string, head []byte) string {
+	detected := http.DetectContentType(head)
+	if _, ok := model.AttachmentExtension(detected); ok {
+		return detected
+	}
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType == "image/heic" {
+		return mediaType
+	}
+	return detected
+}
+
+// sendAttachmentError maps the attachment errors to a 4xx response.
+func (s *Server) sendAttachmentError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrAttachmentType):
+		s.sendJSONError(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, model.ErrAttachmentTooLarge):
+		s.sendJSONError(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, model.ErrTransactionNotFound):
+		s.sendJSONError(w, "Transaction not found", http.StatusNotFound)
+	case errors.Is(err, model.ErrAttachmentNotFound):
+		s.sendJSONError(w, "Attachment not found", http.StatusNotFound)
+	default:
+		s.logger.Errorf("Failed to %s attachment: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action+" attachment", http.StatusInternalServerError)
+	}
+}
+
+// pathID returns a positive ID from the path of the request
+func pathID(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// handleAPITransactionAttachments lists the attachments of a transaction on
+// GET and adds one on POST.
+//
+//	@Summary		List transaction attachments
+//	@Description	The photos and documents attached to a transaction of the user, oldest first.
+//	@Tags			attachments
+//	@Produce		json
+//	@Param			id	path		int	true	"Transaction ID"
+//	@Success		200	{object}	AttachmentsResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/transactions/{id}/attachments [get]
+func (s *Server) handleAPITransactionAttachments(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		s.handleAPIUploadAttachment(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, ok := pathID(r, "id")
+	if !ok {
+		s.sendJSONError(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	attachments, err := s.repositories.Attachments.List(user.TgID, transactionID)
+	if err != nil {
+		s.sendAttachmentError(w, err, "list")
+		return
+	}
+
+	resp := AttachmentsResponse{Attachments: make([]AttachmentDTO, len(attachments))}
+	for i, a := range attachments {
+		resp.Attachments[i] = toAttachmentDTO(a)
+	}
+	s.sendJSONSuccess(w, resp)
+}
+
+// handleAPIUploadAttachment attaches a file to a transaction.
+//
+//	@Summary		Upload transaction attachment
+//	@Description	Attaches a photo (JPEG, PNG, WebP, HEIC) or a PDF document of at most 10 MB to a transaction of the user, sent as the "file" field of a multipart form.
+//	@Tags			attachments
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			id		path		int		true	"Transaction ID"
+//	@Param			file	formData	file	true	"Photo or PDF document"
+//	@Success		200		{object}	AttachmentDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		413		{object}	ErrorResponse
+//	@Failure		415		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/transactions/{id}/attachments [post]
+func (s *Server) handleAPIUploadAttachment(w http.ResponseWriter, r *http.Request) {
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, ok := pathID(r, "id")
+	if !ok {
+		s.sendJSONError(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	// Room for the multipart headers on top of the file
+	r.Body = http.MaxBytesReader(w, r.Body, model.MaxAttachmentSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			s.sendJSONError(w, "Missing file", http.StatusBadRequest)
+			return
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			s.sendAttachmentError(w, model.ErrAttachmentTooLarge, "upload")
+			return
+		}
+		if err != nil {
+			s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" {
+			_ = part.Close()
+			continue
+		}
+
+		content := bufio.NewReaderSize(part, 512)
+		head, _ := content.Peek(512)
+		contentType := attachmentContentType(part.Header.Get("Content-Type"), head)
+
+		attachment, err := s.repositories.Attachments.Add(user.TgID, transactionID, part.FileName(), contentType, content)
+		if errors.As(err, &maxErr) {
+			err = model.ErrAttachmentTooLarge
+		}
+		if err != nil {
+			s.sendAttachmentError(w, err, "upload")
+			return
+		}
+
+		s.sendJSONSuccess(w, toAttachmentDTO(attachment))
+		return
+	}
+}
+
+// handleAPIDownloadAttachment sends the content of an attachment.
+//
+//	@Summary		Download transaction attachment
+//	@Description	The content of a file attached to a transaction of the user.
+//	@Tags			attachments
+//	@Produce		application/octet-stream
+//	@Param			id				path		int	true	"Transaction ID"
+//	@Param			attachmentId	path		int	true	"Attachment ID"
+//	@Success		200				{file}		binary
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/transactions/{id}/attachments/{attachmentId} [get]
+func (s *Server) handleAPIDownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, okTx := pathID(r, "id")
+	attachmentID, okAtt := pathID(r, "attachmentId")
+	if !okTx || !okAtt {
+		s.sendJSONError(w, "Invalid attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := s.repositories.Attachments.Open(user.TgID, transactionID, attachmentID)
+	if err != nil {
+		s.sendAttachmentError(w, err, "download")
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := io.Copy(w, content); err != nil {
+		s.logger.Warnf("Failed to send attachment %d: %v", attachment.ID, err)
+	}
+}
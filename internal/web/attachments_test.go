@@ -0,0 +1,26 @@
+package web
+
+import "testing"
+
+func TestAttachmentContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared string
+		head     []byte
+		want     string
+	}{
+		{name: "pdf detected", declared: "application/octet-stream", head: []byte("%PDF-1.7\n"), want: "application/pdf"},
+		{name: "png detected over declared", declared: "application/pdf", head: []byte("\x89PNG\x0D\x0A\x1A\x0A"), want: "image/png"},
+		{name: "declared heic", declared: "image/heic", head: []byte("\x00\x00\x00\x18ftypheic"), want: "image/heic"},
+		{name: "html declared as image", declared: "image/png", head: []byte("<html><script>"), want: "text/html; charset=utf-8"},
+		{name: "html declared as heic", declared: "image/heic", head: []byte("<html><script>"), want: "image/heic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attachmentContentType(tt.declared, tt.head); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
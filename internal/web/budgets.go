@@ -3,8 +3,8 @@ package web
 import (
 	"encoding/json"
 	"errors"
-	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"cashout/internal/client"
@@ -15,7 +15,7 @@ import (
 
 // handleAPIBudget multiplexes GET/POST/PUT/DELETE on /api/budget.
 //
-//	@Summary		Get the current monthly budget
+//	@Summary		Get the budgets in their current period
 //	@Tags			budget
 //	@Produce		json
 //	@Success		200	{object}	BudgetResponse
@@ -24,36 +24,40 @@ import (
 //	@Security		BearerAuth
 //	@Router			/api/budget [get]
 //
-//	@Summary		Create or update the monthly budget
+//	@Summary		Create or update the overall or a category budget
 //	@Tags			budget
 //	@Accept			json
 //	@Produce		json
-//	@Param			body	body		BudgetUpsertRequest	true	"Budget amount"
+//	@Param			body	body		BudgetUpsertRequest	true	"Budget amount, optional category, rollover, thresholds and period"
 //	@Success		200		{object}	BudgetResponse
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/api/budget [post]
 //
-//	@Summary		Replace the monthly budget
+//	@Summary		Replace the overall or a category budget
 //	@Tags			budget
 //	@Accept			json
 //	@Produce		json
-//	@Param			body	body		BudgetUpsertRequest	true	"Budget amount"
+//	@Param			body	body		BudgetUpsertRequest	true	"Budget amount, optional category, rollover, thresholds and period"
 //	@Success		200		{object}	BudgetResponse
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/api/budget [put]
 //
-//	@Summary		Delete the monthly budget
+//	@Summary		Delete the overall or a category budget
 //	@Tags			budget
 //	@Produce		json
-//	@Success		200	{object}	BudgetResponse
-//	@Failure		401	{object}	ErrorResponse
-//	@Failure		500	{object}	ErrorResponse
+//	@Param			category	query		string	false	"Category of the budget, the overall one if empty"
+//	@Success		200			{object}	BudgetResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/api/budget [delete]
 func (s *Server) handleAPIBudget(w http.ResponseWriter, r *http.Request) {
@@ -69,44 +73,64 @@ func (s *Server) handleAPIBudget(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost, http.MethodPut:
 		s.budgetUpsert(w, r, user)
 	case http.MethodDelete:
-		s.budgetDelete(w, user)
+		s.budgetDelete(w, r, user)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
 func (s *Server) budgetGet(w http.ResponseWriter, user *model.User) {
-	budget, err := s.repositories.Budgets.Get(user.TgID)
+	now := user.Today()
+	spending, err := s.repositories.Budgets.Spending(user.Scope(), now)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s.sendJSONSuccess(w, BudgetResponse{HasBudget: false})
-			return
-		}
-		s.logger.Errorf("Failed to get budget: %v", err)
+		s.logger.Errorf("Failed to get budgets: %v", err)
 		s.sendJSONError(w, "Failed to get budget", http.StatusInternalServerError)
 		return
 	}
 
-	now := time.Now()
-	spent, err := s.repositories.Budgets.TotalExpensesForMonth(user.TgID, now.Year(), int(now.Month()))
-	if err != nil {
-		s.logger.Errorf("Failed to compute month total: %v", err)
-		s.sendJSONError(w, "Failed to compute progress", http.StatusInternalServerError)
-		return
+	resp := BudgetResponse{AlertEmail: user.BudgetAlertEmail, Categories: []CategoryBudgetDTO{}}
+	if len(spending) > 0 {
+		resp.Month = now.Format("2006-01")
 	}
-	pct := 0
-	if budget.Amount > 0 {
-		pct = int(math.Floor(spent / budget.Amount * 100))
+	for _, sp := range spending {
+		if !sp.Budget.IsCategory() {
+			resp.HasBudget = true
+			resp.Amount = sp.Budget.Amount
+			resp.Carry = sp.Carry
+			resp.Limit = sp.Limit()
+			resp.Rollover = string(sp.Budget.Rollover)
+			resp.RolloverCap = sp.Budget.RolloverCap
+			resp.Currency = string(sp.Budget.Currency)
+			resp.Spent = sp.Spent
+			resp.Pct = sp.Pct()
+			resp.Thresholds = budgetThresholds(sp.Budget)
+			resp.Period, resp.WeekStart, resp.StartDate, resp.EndDate = budgetPeriod(sp.Budget)
+			resp.Start = sp.Start.Format("2006-01-02")
+			resp.End = sp.End.Format("2006-01-02")
+			continue
+		}
+		period, weekStart, startDate, endDate := budgetPeriod(sp.Budget)
+		resp.Categories = append(resp.Categories, CategoryBudgetDTO{
+			Category:    string(sp.Budget.Category),
+			Amount:      sp.Budget.Amount,
+			Carry:       sp.Carry,
+			Limit:       sp.Limit(),
+			Rollover:    string(sp.Budget.Rollover),
+			RolloverCap: sp.Budget.RolloverCap,
+			Currency:    string(sp.Budget.Currency),
+			Spent:       sp.Spent,
+			Pct:         sp.Pct(),
+			Thresholds:  budgetThresholds(sp.Budget),
+			Period:      period,
+			WeekStart:   weekStart,
+			StartDate:   startDate,
+			EndDate:     endDate,
+			Start:       sp.Start.Format("2006-01-02"),
+			End:         sp.End.Format("2006-01-02"),
+		})
 	}
 
-	s.sendJSONSuccess(w, BudgetResponse{
-		HasBudget: true,
-		Amount:    budget.Amount,
-		Currency:  string(budget.Currency),
-		Spent:     spent,
-		Pct:       pct,
-		Month:     now.Format("2006-01"),
-	})
+	s.sendJSONSuccess(w, resp)
 }
 
 func (s *Server) budgetUpsert(w http.ResponseWriter, r *http.Request, user *model.User) {
@@ -119,30 +143,176 @@ func (s *Server) budgetUpsert(w http.ResponseWriter, r *http.Request, user *mode
 		s.sendJSONError(w, "Amount must be greater than 0", http.StatusBadRequest)
 		return
 	}
+	var rollover model.BudgetRollover
+	if req.Rollover != nil {
+		var ok bool
+		if rollover, ok = model.ParseBudgetRollover(*req.Rollover); !ok {
+			s.sendJSONError(w, "Rollover must be one of none, positive, negative or both", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var thresholds model.BudgetThresholds
+	if req.Thresholds != nil {
+		thresholds = model.BudgetThresholds(req.Thresholds).Normalize()
+		if err := thresholds.Validate(); err != nil {
+			s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var period model.BudgetPeriod
+	weekStart := time.Monday
+	var startDate, endDate *time.Time
+	if req.Period != nil {
+		var ok bool
+		if period, ok = model.ParseBudgetPeriod(*req.Period); !ok {
+			s.sendJSONError(w, "Period must be one of weekly, monthly, quarterly, yearly or custom", http.StatusBadRequest)
+			return
+		}
+		if req.WeekStart != nil && *req.WeekStart != "" {
+			if weekStart, ok = model.ParseWeekday(*req.WeekStart); !ok {
+				s.sendJSONError(w, "Week start must be a day of the week", http.StatusBadRequest)
+				return
+			}
+		}
+		if period == model.PeriodCustom {
+			if req.StartDate == nil || req.EndDate == nil {
+				s.sendJSONError(w, "A custom period needs a start and an end date", http.StatusBadRequest)
+				return
+			}
+			start, errStart := time.Parse("2006-01-02", *req.StartDate)
+			end, errEnd := time.Parse("2006-01-02", *req.EndDate)
+			if errStart != nil || errEnd != nil {
+				s.sendJSONError(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			startDate, endDate = &start, &end
+		}
+	}
+
+	if req.AlertEmail != nil {
+		if err := s.repositories.Users.SetBudgetAlertEmail(user, *req.AlertEmail); err != nil {
+			if errors.Is(err, model.ErrNoEmail) {
+				s.sendJSONError(w, "There is no email registered to your account", http.StatusBadRequest)
+				return
+			}
+			s.logger.Errorf("Failed to set budget alert email: %v", err)
+			s.sendJSONError(w, "Failed to save budget", http.StatusInternalServerError)
+			return
+		}
+	}
 
 	budget := model.Budget{
 		TgID:     user.TgID,
+		LedgerID: user.LedgerID,
+		Category: model.TransactionCategory(req.Category),
 		Amount:   req.Amount,
-		Currency: model.CurrencyEUR,
+		Currency: user.BaseCurrency,
 	}
 	if err := s.repositories.Budgets.Upsert(&budget); err != nil {
+		if errors.Is(err, model.ErrLedgerForbidden) {
+			s.sendJSONError(w, "Only the owners of the ledger can change its budget", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, model.ErrBudgetCategory) {
+			s.sendJSONError(w, "Budgets can only be set on your expense categories", http.StatusBadRequest)
+			return
+		}
 		s.logger.Errorf("Failed to upsert budget: %v", err)
 		s.sendJSONError(w, "Failed to save budget", http.StatusInternalServerError)
 		return
 	}
 
+	if req.Rollover != nil {
+		var rolloverCap model.Money
+		if req.RolloverCap != nil {
+			rolloverCap = *req.RolloverCap
+		}
+		if _, err := s.repositories.Budgets.SetRollover(user.Scope(), budget.Category, rollover, rolloverCap); err != nil {
+			if errors.Is(err, model.ErrInvalidBudget) {
+				s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.logger.Errorf("Failed to set budget rollover: %v", err)
+			s.sendJSONError(w, "Failed to save budget", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if thresholds != nil {
+		if _, err := s.repositories.Budgets.SetThresholds(user.Scope(), budget.Category, thresholds); err != nil {
+			s.logger.Errorf("Failed to set budget alert thresholds: %v", err)
+			s.sendJSONError(w, "Failed to save budget", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if period != "" {
+		if _, err := s.repositories.Budgets.SetPeriod(user.Scope(), budget.Category, period, weekStart, startDate, endDate); err != nil {
+			if errors.Is(err, model.ErrInvalidBudget) {
+				s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.logger.Errorf("Failed to set budget period: %v", err)
+			s.sendJSONError(w, "Failed to save budget", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	s.budgetGet(w, user)
 }
 
-func (s *Server) budgetDelete(w http.ResponseWriter, user *model.User) {
-	if err := s.repositories.Budgets.Delete(user.TgID); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s.sendJSONSuccess(w, BudgetResponse{HasBudget: false})
+func (s *Server) budgetDelete(w http.ResponseWriter, r *http.Request, user *model.User) {
+	category := model.TransactionCategory(r.URL.Query().Get("category"))
+	if err := s.repositories.Budgets.Delete(user.Scope(), category); err != nil {
+		if errors.Is(err, model.ErrLedgerForbidden) {
+			s.sendJSONError(w, "Only the owners of the ledger can remove its budget", http.StatusForbidden)
 			return
 		}
-		s.logger.Errorf("Failed to delete budget: %v", err)
-		s.sendJSONError(w, "Failed to delete budget", http.StatusInternalServerError)
-		return
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Errorf("Failed to delete budget: %v", err)
+			s.sendJSONError(w, "Failed to delete budget", http.StatusInternalServerError)
+			return
+		}
+	}
+	s.budgetGet(w, user)
+}
+
+// budgetThresholds returns the alert thresholds of a budget, the default ones
+// when not set
+func budgetThresholds(budget model.Budget) []int16 {
+	if len(budget.Thresholds) == 0 {
+		return model.DefaultBudgetThresholds
+	}
+	return budget.Thresholds
+}
+
+// budgetPeriod returns the period of a budget, its week start lowercase and
+// the dates of a custom one as YYYY-MM-DD
+func budgetPeriod(budget model.Budget) (period, weekStart, startDate, endDate string) {
+	period = string(budget.Period)
+	if period == "" {
+		period = string(model.PeriodMonthly)
+	}
+	weekStart = strings.ToLower(budget.WeekStart.String())
+	if budget.StartDate != nil {
+		startDate = budget.StartDate.Format("2006-01-02")
+	}
+	if budget.EndDate != nil {
+		endDate = budget.EndDate.Format("2006-01-02")
+	}
+	return period, weekStart, startDate, endDate
+}
+
+// notifyBudgets evaluates the budgets after the write of a transaction through
+// the dashboard or the API, delivering the alerts it fires on Telegram and by
+// email when the user asked for it
+func (s *Server) notifyBudgets(user model.User, tx model.Transaction) {
+	notifier := client.BudgetNotifier{
+		Budgets: s.repositories.Budgets,
+		Bot:     s.bot,
+		Mailer:  s.emailService,
+		Logger:  s.logger,
 	}
-	s.sendJSONSuccess(w, BudgetResponse{HasBudget: false})
+	notifier.Notify(user, tx, true)
 }
@@ -0,0 +1,248 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toCategoryDTO(c model.Category) CategoryDTO {
+	return CategoryDTO{
+		ID:       c.ID,
+		Name:     c.Name,
+		Type:     string(c.Type),
+		Emoji:    c.Emoji,
+		Color:    c.Color,
+		Archived: c.Archived,
+	}
+}
+
+// sendCategoryError maps the category validation errors to a 4xx response.
+func (s *Server) sendCategoryError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidCategory):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrCategoryNotFound):
+		s.sendJSONError(w, "Category not found", http.StatusNotFound)
+	case errors.Is(err, model.ErrCategoryExists):
+		s.sendJSONError(w, "Category already exists", http.StatusConflict)
+	case errors.Is(err, model.ErrCategoryInUse):
+		s.sendJSONError(w, "Category is used by some transactions, archive it instead", http.StatusConflict)
+	default:
+		s.logger.Errorf("Failed to %s category: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action+" category", http.StatusInternalServerError)
+	}
+}
+
+// handleAPICategories returns the user's categories, optionally filtered by transaction type.
+//
+//	@Summary		List categories
+//	@Description	Without a type every category is listed, archived ones included. With a type only the active categories of that type are listed.
+//	@Tags			categories
+//	@Produce		json
+//	@Param			type	query		string	false	"Transaction type: Income or Expense"
+//	@Success		200		{object}	CategoriesResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/categories [get]
+func (s *Server) handleAPICategories(w http.ResponseWriter, r *http.Request) {
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	categories, err := s.repositories.Categories.List(user.TgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get categories: %v", err)
+		s.sendJSONError(w, "Failed to get categories", http.StatusInternalServerError)
+		return
+	}
+
+	txType := r.URL.Query().Get("type")
+	switch txType {
+	case "":
+	case string(model.TypeIncome), string(model.TypeExpense):
+		categories = categories.OfType(model.TransactionType(txType))
+	default:
+		s.sendJSONError(w, "Invalid transaction type", http.StatusBadRequest)
+		return
+	}
+
+	resp := CategoriesResponse{
+		Categories: categories.OfType("").Names(),
+		Items:      make([]CategoryDTO, len(categories)),
+	}
+	for i, c := range categories {
+		resp.Items[i] = toCategoryDTO(c)
+	}
+
+	s.sendJSONSuccess(w, resp)
+}
+
+// handleAPICreateCategory creates a new category.
+//
+//	@Summary		Create category
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		CreateCategoryRequest	true	"Category payload"
+//	@Success		200		{object}	CategoryDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/categories/create [post]
+func (s *Server) handleAPICreateCategory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	category := model.Category{
+		TgID:  user.TgID,
+		Name:  req.Name,
+		Type:  model.TransactionType(req.Type),
+		Emoji: req.Emoji,
+		Color: req.Color,
+	}
+	if err := s.repositories.Categories.Create(&category); err != nil {
+		s.sendCategoryError(w, err, "create")
+		return
+	}
+
+	s.sendJSONSuccess(w, toCategoryDTO(category))
+}
+
+// handleAPIEditCategory applies a partial update to a category.
+//
+//	@Summary		Edit category (partial)
+//	@Description	Update the name, emoji, colour or archived flag of a category. Renaming moves all its transactions to the new name. Archived categories cannot be used for new transactions.
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		EditCategoryRequest	true	"Fields to update; only non-null fields are applied"
+//	@Success		200		{object}	CategoryDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/categories/edit [patch]
+func (s *Server) handleAPIEditCategory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req EditCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		s.sendJSONError(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+	if req.Name == nil && req.Emoji == nil && req.Color == nil && req.Archived == nil {
+		s.sendJSONError(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	category, err := s.repositories.Categories.Get(user.TgID, req.ID)
+	if err != nil {
+		s.sendCategoryError(w, err, "edit")
+		return
+	}
+
+	if req.Name != nil {
+		category.Name = *req.Name
+	}
+	if req.Emoji != nil {
+		category.Emoji = *req.Emoji
+	}
+	if req.Color != nil {
+		category.Color = *req.Color
+	}
+	if req.Archived != nil {
+		category.Archived = *req.Archived
+	}
+
+	if err := s.repositories.Categories.As(actor(r, user)).Update(&category); err != nil {
+		s.sendCategoryError(w, err, "edit")
+		return
+	}
+
+	s.sendJSONSuccess(w, toCategoryDTO(category))
+}
+
+// handleAPIDeleteCategory deletes a category no transaction uses.
+//
+//	@Summary		Delete category
+//	@Description	Only categories without transactions can be deleted, the others can be archived.
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		DeleteCategoryRequest	true	"Category ID payload"
+//	@Success		200		{object}	MessageResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/categories/delete [delete]
+func (s *Server) handleAPIDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DeleteCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		s.sendJSONError(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Categories.Delete(user.TgID, req.ID); err != nil {
+		s.sendCategoryError(w, err, "delete")
+		return
+	}
+
+	s.sendJSONSuccess(w, MessageResponse{Message: "Category deleted successfully"})
+}
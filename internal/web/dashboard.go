@@ -2,6 +2,7 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"html/template"
 	"net/http"
 	"time"
@@ -22,11 +23,12 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse month from query, default to current month
+	// Parse month from query, default to the current month of the user
+	now := user.Today()
 	monthStr := r.URL.Query().Get("month")
 	currentMonth, err := time.Parse(monthLayout, monthStr)
-	if err != nil || currentMonth.After(time.Now()) {
-		currentMonth = time.Now()
+	if err != nil || currentMonth.After(now) {
+		currentMonth = now
 	}
 
 	// Calculate previous and next months
@@ -34,7 +36,6 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	nextMonth := currentMonth.AddDate(0, 1, 0)
 
 	// Disable next month button if it's the future
-	now := time.Now()
 	isCurrentMonth := currentMonth.Format(monthLayout) == now.Format(monthLayout)
 
 	t, err := template.ParseFiles("web/templates/dashboard.html")
@@ -51,6 +52,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		PrevMonth         string
 		NextMonth         string
 		IsCurrentMonth    bool
+		Currencies        []string
 	}{
 		User:              user,
 		CurrentMonthTitle: currentMonth.Format("January 2006"),
@@ -58,6 +60,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		PrevMonth:         prevMonth.Format(monthLayout),
 		NextMonth:         nextMonth.Format(monthLayout),
 		IsCurrentMonth:    isCurrentMonth,
+		Currencies:        model.GetCurrencyTypes(),
 	}
 
 	w.Header().Set("Content-Type", "text/html")
@@ -91,7 +94,7 @@ func (s *Server) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 	monthStr := r.URL.Query().Get("month")
 	currentMonth, err := time.Parse(monthLayout, monthStr)
 	if err != nil {
-		currentMonth = time.Now()
+		currentMonth = user.Today()
 	}
 
 	// Get transactions for the month
@@ -104,12 +107,13 @@ func (s *Server) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Calculate statistics
-	var totalIncome, totalExpenses float64
+	var totalIncome, totalExpenses model.Money
 
 	for _, tx := range transactions {
-		if tx.Type == model.TypeIncome {
+		switch tx.Type {
+		case model.TypeIncome:
 			totalIncome += tx.Amount
-		} else {
+		case model.TypeExpense:
 			totalExpenses += tx.Amount
 		}
 	}
@@ -121,6 +125,7 @@ func (s *Server) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 		TotalIncome:       totalIncome,
 		TotalExpenses:     totalExpenses,
 		TotalTransactions: len(transactions),
+		Currency:          string(user.BaseCurrency),
 	})
 }
 
@@ -146,7 +151,7 @@ func (s *Server) handleAPITransactions(w http.ResponseWriter, r *http.Request) {
 	monthStr := r.URL.Query().Get("month")
 	currentMonth, err := time.Parse(monthLayout, monthStr)
 	if err != nil {
-		currentMonth = time.Now()
+		currentMonth = user.Today()
 	}
 
 	// Get transactions for the month
@@ -160,14 +165,7 @@ func (s *Server) handleAPITransactions(w http.ResponseWriter, r *http.Request) {
 
 	transactionResponses := make([]TransactionDTO, len(transactions))
 	for i, tx := range transactions {
-		transactionResponses[i] = TransactionDTO{
-			ID:          tx.ID,
-			Date:        tx.Date,
-			Category:    string(tx.Category),
-			Description: tx.Description,
-			Amount:      tx.Amount,
-			Type:        string(tx.Type),
-		}
+		transactionResponses[i] = toTransactionDTO(tx)
 	}
 
 	s.sendJSONSuccess(w, TransactionsResponse{
@@ -176,40 +174,6 @@ func (s *Server) handleAPITransactions(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleAPICategories returns available categories based on transaction type.
-//
-//	@Summary		List categories
-//	@Tags			transactions
-//	@Produce		json
-//	@Param			type	query		string	true	"Transaction type: Income or Expense"
-//	@Success		200		{object}	CategoriesResponse
-//	@Failure		400		{object}	ErrorResponse
-//	@Failure		401		{object}	ErrorResponse
-//	@Security		BearerAuth
-//	@Router			/api/categories [get]
-func (s *Server) handleAPICategories(w http.ResponseWriter, r *http.Request) {
-	user := client.GetUserFromContext(r.Context())
-	if user == nil {
-		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	txType := r.URL.Query().Get("type")
-
-	var categories []string
-	switch txType {
-	case string(model.TypeIncome):
-		categories = model.GetIncomeCategories()
-	case string(model.TypeExpense):
-		categories = model.GetExpenseCategories()
-	default:
-		s.sendJSONError(w, "Invalid transaction type", http.StatusBadRequest)
-		return
-	}
-
-	s.sendJSONSuccess(w, CategoriesResponse{Categories: categories})
-}
-
 // handleAPICreateTransaction creates a new transaction.
 //
 //	@Summary		Create transaction
@@ -248,8 +212,14 @@ func (s *Server) handleAPICreateTransaction(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Validate category
-	if !model.IsValidTransactionCategory(req.Category) {
+	// Validate category, it must be one of the user's active categories of the same type
+	categories, err := s.repositories.Categories.Active(user.TgID, model.TransactionType(req.Type))
+	if err != nil {
+		s.logger.Errorf("Failed to get categories: %v", err)
+		s.sendJSONError(w, "Failed to create transaction", http.StatusInternalServerError)
+		return
+	}
+	if _, ok := categories.Find(req.Category); !ok {
 		s.sendJSONError(w, "Invalid category", http.StatusBadRequest)
 		return
 	}
@@ -260,6 +230,16 @@ func (s *Server) handleAPICreateTransaction(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Validate currency, defaults to the user's base currency
+	currency := user.BaseCurrency
+	if req.Currency != "" {
+		if !model.IsValidCurrency(req.Currency) {
+			s.sendJSONError(w, "Invalid currency", http.StatusBadRequest)
+			return
+		}
+		currency = model.CurrencyType(req.Currency)
+	}
+
 	// Parse date
 	date, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
@@ -267,7 +247,13 @@ func (s *Server) handleAPICreateTransaction(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Create transaction
+	tags, err := model.NormalizeTagNames(req.Tags)
+	if err != nil {
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Create transaction, converted into the base currency on insert
 	transaction := model.Transaction{
 		TgID:        user.TgID,
 		Type:        model.TransactionType(req.Type),
@@ -275,22 +261,34 @@ func (s *Server) handleAPICreateTransaction(w http.ResponseWriter, r *http.Reque
 		Amount:      req.Amount,
 		Description: req.Description,
 		Date:        date,
-		Currency:    model.CurrencyEUR, // Default to EUR
+		Currency:    currency,
+		Tags:        model.TagsFromNames(user.TgID, tags),
+		AccountID:   req.AccountID,
 	}
 
-	err = s.repositories.Transactions.Add(&transaction)
+	err = s.repositories.Transactions.As(actor(r, user)).Add(&transaction)
+	if errors.Is(err, model.ErrExchangeRateNotFound) {
+		s.sendJSONError(w, "Exchange rate not available for this currency", http.StatusBadRequest)
+		return
+	}
+	if isAccountError(err) {
+		s.sendAccountError(w, err, "create transaction with")
+		return
+	}
 	if err != nil {
 		s.logger.Errorf("Failed to create transaction: %v", err)
 		s.sendJSONError(w, "Failed to create transaction", http.StatusInternalServerError)
 		return
 	}
 
+	s.notifyBudgets(*user, transaction)
 	s.sendJSONSuccess(w, MessageResponse{Message: "Transaction created successfully"})
 }
 
-// handleAPIDeleteTransaction deletes a transaction by ID.
+// handleAPIDeleteTransaction moves a transaction to the trash by ID.
 //
 //	@Summary		Delete transaction
+//	@Description	The transaction goes to the trash, where it can be restored for 30 days.
 //	@Tags			transactions
 //	@Accept			json
 //	@Produce		json
@@ -298,6 +296,7 @@ func (s *Server) handleAPICreateTransaction(w http.ResponseWriter, r *http.Reque
 //	@Success		200		{object}	MessageResponse
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
 //	@Security		BearerAuth
 //	@Router			/api/transactions/delete [delete]
@@ -326,13 +325,17 @@ func (s *Server) handleAPIDeleteTransaction(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Delete transaction
-	err := s.repositories.Transactions.Delete(req.ID, user.TgID)
+	// Move the transaction to the trash
+	err := s.repositories.Transactions.As(actor(r, user)).Delete(req.ID, user.TgID)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		s.sendJSONError(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		s.logger.Errorf("Failed to delete transaction: %v", err)
 		s.sendJSONError(w, "Failed to delete transaction", http.StatusInternalServerError)
 		return
 	}
 
-	s.sendJSONSuccess(w, MessageResponse{Message: "Transaction deleted successfully"})
+	s.sendJSONSuccess(w, MessageResponse{Message: "Transaction moved to the trash"})
 }
@@ -1,6 +1,10 @@
 package web
 
-import "time"
+import (
+	"time"
+
+	"cashout/internal/model"
+)
 
 // ErrorResponse is the body returned by sendJSONError.
 type ErrorResponse struct {
@@ -13,13 +17,42 @@ type MessageResponse struct {
 }
 
 // TransactionDTO is the JSON shape of a transaction returned by the API.
+// Amount is in the user's base currency (Currency), OriginalAmount and
+// OriginalCurrency are the value as entered.
 type TransactionDTO struct {
-	ID          int64     `json:"id"`
-	Date        time.Time `json:"date"`
-	Category    string    `json:"category"`
-	Description string    `json:"description"`
-	Amount      float64   `json:"amount"`
-	Type        string    `json:"type"`
+	ID               int64       `json:"id"`
+	Date             time.Time   `json:"date"`
+	Category         string      `json:"category"`
+	Description      string      `json:"description"`
+	Amount           model.Money `json:"amount"`
+	Currency         string      `json:"currency"`
+	OriginalAmount   model.Money `json:"originalAmount"`
+	OriginalCurrency string      `json:"originalCurrency"`
+	Type             string      `json:"type"`
+	Tags             []string    `json:"tags"`
+	AccountID        *int64      `json:"accountId,omitempty"`
+	ToAccountID      *int64      `json:"toAccountId,omitempty"`
+	RecurringRuleID  *int64      `json:"recurringRuleId,omitempty"`
+	SettlementID     *int64      `json:"settlementId,omitempty"`
+	Splits           []SplitDTO  `json:"splits,omitempty"`
+}
+
+// SplitDTO is a line of a transaction split across several categories.
+// Amount is in the base currency, OriginalAmount in the currency the
+// transaction was entered in.
+type SplitDTO struct {
+	Category       string      `json:"category"              example:"Toiletry"`
+	Amount         model.Money `json:"amount"                example:"12.50"`
+	OriginalAmount model.Money `json:"originalAmount"        example:"12.50"`
+	Description    string      `json:"description,omitempty" example:"shampoo"`
+}
+
+// SplitLineRequest is a line of the split of a transaction, Amount is in the
+// currency the transaction was entered in.
+type SplitLineRequest struct {
+	Category    string      `json:"category"              example:"Toiletry"`
+	Amount      model.Money `json:"amount"                example:"12.50"`
+	Description string      `json:"description,omitempty" example:"shampoo"`
 }
 
 // TransactionsResponse is the body of GET /api/transactions.
@@ -29,17 +62,60 @@ type TransactionsResponse struct {
 }
 
 // CategoriesResponse is the body of GET /api/categories.
+// Categories holds the names of the active ones, Items every category with its details.
 type CategoriesResponse struct {
-	Categories []string `json:"categories"`
+	Categories []string      `json:"categories"`
+	Items      []CategoryDTO `json:"items"`
+}
+
+// CategoryDTO is a user-defined category.
+type CategoryDTO struct {
+	ID       int64  `json:"id"       example:"7"`
+	Name     string `json:"name"     example:"Kids"`
+	Type     string `json:"type"     example:"Expense"`
+	Emoji    string `json:"emoji"    example:"👶"`
+	Color    string `json:"color"    example:"#4e79a7"`
+	Archived bool   `json:"archived" example:"false"`
+}
+
+// CreateCategoryRequest is the body of POST /api/categories/create.
+// Emoji and Color are optional.
+type CreateCategoryRequest struct {
+	Name  string `json:"name"            example:"Kids"`
+	Type  string `json:"type"            example:"Expense"`
+	Emoji string `json:"emoji,omitempty" example:"👶"`
+	Color string `json:"color,omitempty" example:"#4e79a7"`
+}
+
+// EditCategoryRequest is the body of PATCH /api/categories/edit.
+// Only non-nil fields are applied, the type cannot be changed.
+// Renaming a category renames it in all its transactions.
+type EditCategoryRequest struct {
+	ID       int64   `json:"id"                 example:"7"`
+	Name     *string `json:"name,omitempty"     example:"Children"`
+	Emoji    *string `json:"emoji,omitempty"    example:"🧸"`
+	Color    *string `json:"color,omitempty"    example:"#f28e2b"`
+	Archived *bool   `json:"archived,omitempty" example:"true"`
+}
+
+// DeleteCategoryRequest is the body of DELETE /api/categories/delete.
+type DeleteCategoryRequest struct {
+	ID int64 `json:"id" example:"7"`
 }
 
 // CreateTransactionRequest is the body of POST /api/transactions/create.
+// Currency is optional and defaults to the user's base currency.
+// Tags are optional, missing ones are created.
+// AccountID is optional and defaults to the user's default account.
 type CreateTransactionRequest struct {
-	Type        string  `json:"type"        example:"Expense"`
-	Category    string  `json:"category"    example:"Food"`
-	Amount      float64 `json:"amount"      example:"12.50"`
-	Description string  `json:"description" example:"lunch"`
-	Date        string  `json:"date"        example:"2026-05-21"`
+	Type        string      `json:"type"                example:"Expense"`
+	Category    string      `json:"category"            example:"Food"`
+	Amount      model.Money `json:"amount"              example:"12.50"`
+	Currency    string      `json:"currency,omitempty"  example:"USD"`
+	Description string      `json:"description"         example:"lunch"`
+	Date        string      `json:"date"                example:"2026-05-21"`
+	Tags        []string    `json:"tags,omitempty"      example:"work,reimbursable"`
+	AccountID   *int64      `json:"accountId,omitempty" example:"3"`
 }
 
 // DeleteTransactionRequest is the body of DELETE /api/transactions/delete.
@@ -47,15 +123,68 @@ type DeleteTransactionRequest struct {
 	ID int64 `json:"id"`
 }
 
+// TrashedTransactionDTO is a transaction in the trash, permanently deleted at PurgeAt.
+type TrashedTransactionDTO struct {
+	TransactionDTO
+	DeletedAt time.Time `json:"deletedAt" example:"2026-05-21T10:00:00Z"`
+	PurgeAt   time.Time `json:"purgeAt"   example:"2026-06-20T10:00:00Z"`
+}
+
+// TrashResponse is the body of GET /api/transactions/trash.
+type TrashResponse struct {
+	Transactions []TrashedTransactionDTO `json:"transactions"`
+	Total        int64                   `json:"total"`
+	Offset       int                     `json:"offset"`
+	Limit        int                     `json:"limit"`
+}
+
+// RestoreTransactionRequest is the body of POST /api/transactions/trash/restore.
+type RestoreTransactionRequest struct {
+	ID int64 `json:"id" example:"42"`
+}
+
+// FieldChangeDTO is the value of a field of a transaction before and after a
+// revision, empty when the field was not set.
+type FieldChangeDTO struct {
+	Field  string `json:"field"  example:"Amount"`
+	Before string `json:"before" example:"12.50 EUR"`
+	After  string `json:"after"  example:"15.00 EUR"`
+}
+
+// RevisionDTO is an entry of the history of a transaction. Source is one of
+// bot, web, api_token and scheduler, ActorTgID is missing for the scheduler.
+type RevisionDTO struct {
+	ID        int64            `json:"id"                  example:"7"`
+	Action    string           `json:"action"              example:"updated"`
+	Source    string           `json:"source"              example:"web"`
+	ActorTgID *int64           `json:"actorTgId,omitempty" example:"123456789"`
+	CreatedAt time.Time        `json:"createdAt"           example:"2026-05-21T10:00:00Z"`
+	Changes   []FieldChangeDTO `json:"changes"`
+}
+
+// TransactionHistoryResponse is the body of GET /api/transactions/{id}/history.
+type TransactionHistoryResponse struct {
+	TransactionID int64         `json:"transactionId" example:"42"`
+	Revisions     []RevisionDTO `json:"revisions"`
+}
+
 // EditTransactionRequest is the body of PATCH /api/transactions/edit.
 // Only non-nil fields are applied. Type is intentionally not editable —
 // switching between Income/Expense is forbidden, matching the Telegram bot.
+// Tags replaces all the tags of the transaction, an empty list removes them.
+// AccountID moves an income or expense to another account, 0 removes it.
+// Splits replaces the split lines, which must sum to the amount, an empty
+// list removes the split. The category and the amount of a split transaction
+// can only change along with its lines.
 type EditTransactionRequest struct {
-	ID          int64    `json:"id"                    example:"42"`
-	Category    *string  `json:"category,omitempty"    example:"Grocery"`
-	Amount      *float64 `json:"amount,omitempty"      example:"19.90"`
-	Description *string  `json:"description,omitempty" example:"weekly shop"`
-	Date        *string  `json:"date,omitempty"        example:"2026-05-21"`
+	ID          int64               `json:"id"                    example:"42"`
+	Category    *string             `json:"category,omitempty"    example:"Grocery"`
+	Amount      *model.Money        `json:"amount,omitempty"      example:"19.90"`
+	Description *string             `json:"description,omitempty" example:"weekly shop"`
+	Date        *string             `json:"date,omitempty"        example:"2026-05-21"`
+	Tags        *[]string           `json:"tags,omitempty"        example:"work"`
+	AccountID   *int64              `json:"accountId,omitempty"   example:"3"`
+	Splits      *[]SplitLineRequest `json:"splits,omitempty"`
 }
 
 // CloneTransactionRequest is the body of POST /api/transactions/clone.
@@ -66,16 +195,20 @@ type CloneTransactionRequest struct {
 // SearchTransactionsRequest is the body of POST /api/transactions/search.
 // Empty / zero fields are ignored. Type accepts "Income", "Expense" or "".
 // Category "" or "all" disables the category filter.
+// Tags matches the transactions having all of them, ExcludeTags the ones having none of them.
 type SearchTransactionsRequest struct {
-	Query     string   `json:"query,omitempty"     example:"coffee"`
-	Category  string   `json:"category,omitempty"  example:"Grocery"`
-	Type      string   `json:"type,omitempty"      example:"Expense"`
-	DateFrom  string   `json:"dateFrom,omitempty"  example:"2026-01-01"`
-	DateTo    string   `json:"dateTo,omitempty"    example:"2026-05-31"`
-	AmountMin *float64 `json:"amountMin,omitempty" example:"5"`
-	AmountMax *float64 `json:"amountMax,omitempty" example:"100"`
-	Offset    int      `json:"offset,omitempty"    example:"0"`
-	Limit     int      `json:"limit,omitempty"     example:"50"`
+	Query       string       `json:"query,omitempty"       example:"coffee"`
+	Category    string       `json:"category,omitempty"    example:"Grocery"`
+	Type        string       `json:"type,omitempty"        example:"Expense"`
+	DateFrom    string       `json:"dateFrom,omitempty"    example:"2026-01-01"`
+	DateTo      string       `json:"dateTo,omitempty"      example:"2026-05-31"`
+	AmountMin   *model.Money `json:"amountMin,omitempty"   example:"5"`
+	AmountMax   *model.Money `json:"amountMax,omitempty"   example:"100"`
+	Tags        []string     `json:"tags,omitempty"        example:"work"`
+	ExcludeTags []string     `json:"excludeTags,omitempty" example:"reimbursed"`
+	AccountID   *int64       `json:"accountId,omitempty"   example:"3"`
+	Offset      int          `json:"offset,omitempty"      example:"0"`
+	Limit       int          `json:"limit,omitempty"       example:"50"`
 }
 
 // SearchTransactionsResponse is the body of POST /api/transactions/search.
@@ -87,35 +220,124 @@ type SearchTransactionsResponse struct {
 }
 
 // StatsResponse is the body of GET /api/stats.
+// Amounts are in the user's base currency.
 type StatsResponse struct {
-	Balance           float64 `json:"balance"`
-	TotalIncome       float64 `json:"totalIncome"`
-	TotalExpenses     float64 `json:"totalExpenses"`
-	TotalTransactions int     `json:"totalTransactions"`
+	Balance           model.Money `json:"balance"`
+	TotalIncome       model.Money `json:"totalIncome"`
+	TotalExpenses     model.Money `json:"totalExpenses"`
+	TotalTransactions int         `json:"totalTransactions"`
+	Currency          string      `json:"currency"`
 }
 
 // BudgetResponse is the body of GET/POST/PUT/DELETE /api/budget.
-// When HasBudget is false the other fields are zero values.
+// HasBudget and the amounts are about the overall budget: when it is false
+// they are zero values. Amount is the base of the budget and Limit the
+// effective one of the period, Amount plus the Carry of its rollover.
+// Categories lists the budgets of single categories. Thresholds are the
+// percentages of the limit the alerts of a budget fire at, AlertEmail whether
+// the alerts are emailed too. Start and End are the current window of the
+// Period, StartDate and EndDate the range of a custom one (YYYY-MM-DD).
 type BudgetResponse struct {
-	HasBudget bool    `json:"hasBudget"`
-	Amount    float64 `json:"amount,omitempty"`
-	Currency  string  `json:"currency,omitempty"`
-	Spent     float64 `json:"spent,omitempty"`
-	Pct       int     `json:"pct,omitempty"`
-	Month     string  `json:"month,omitempty"`
+	HasBudget   bool                `json:"hasBudget"`
+	Amount      model.Money         `json:"amount,omitempty"`
+	Carry       model.Money         `json:"carry,omitempty"`
+	Limit       model.Money         `json:"limit,omitempty"`
+	Rollover    string              `json:"rollover,omitempty" enums:"none,positive,negative,both"`
+	RolloverCap model.Money         `json:"rolloverCap,omitempty"`
+	Currency    string              `json:"currency,omitempty"`
+	Spent       model.Money         `json:"spent,omitempty"`
+	Pct         int                 `json:"pct,omitempty"`
+	Thresholds  []int16             `json:"thresholds,omitempty" example:"80,100"`
+	Period      string              `json:"period,omitempty" enums:"weekly,monthly,quarterly,yearly,custom"`
+	WeekStart   string              `json:"weekStart,omitempty" example:"monday"`
+	StartDate   string              `json:"startDate,omitempty" example:"2026-05-01"`
+	EndDate     string              `json:"endDate,omitempty" example:"2026-05-31"`
+	Start       string              `json:"start,omitempty" example:"2026-05-18"`
+	End         string              `json:"end,omitempty" example:"2026-05-24"`
+	Month       string              `json:"month,omitempty"`
+	AlertEmail  bool                `json:"alertEmail"`
+	Categories  []CategoryBudgetDTO `json:"categories"`
+}
+
+// CategoryBudgetDTO is the progress of the current period against the budget
+// of a category, split transactions counting in the categories of their lines.
+type CategoryBudgetDTO struct {
+	Category    string      `json:"category" example:"Grocery"`
+	Amount      model.Money `json:"amount"`
+	Carry       model.Money `json:"carry"`
+	Limit       model.Money `json:"limit"`
+	Rollover    string      `json:"rollover" enums:"none,positive,negative,both"`
+	RolloverCap model.Money `json:"rolloverCap"`
+	Currency    string      `json:"currency"`
+	Spent       model.Money `json:"spent"`
+	Pct         int         `json:"pct"`
+	Thresholds  []int16     `json:"thresholds" example:"80,100"`
+	Period      string      `json:"period" enums:"weekly,monthly,quarterly,yearly,custom"`
+	WeekStart   string      `json:"weekStart" example:"monday"`
+	StartDate   string      `json:"startDate,omitempty" example:"2026-05-01"`
+	EndDate     string      `json:"endDate,omitempty" example:"2026-05-31"`
+	Start       string      `json:"start" example:"2026-05-18"`
+	End         string      `json:"end" example:"2026-05-24"`
 }
 
-// BudgetUpsertRequest is the body of POST/PUT /api/budget.
+// BudgetUpsertRequest is the body of POST/PUT /api/budget. Without a
+// category it sets the overall budget. Without a rollover an existing
+// budget keeps its own, RolloverCap 0 meaning no cap. Likewise without
+// thresholds it keeps its alert thresholds, 80% and 100% for a new one.
+// AlertEmail, when given, sets whether all the budget alerts are emailed too.
+// Without a period it keeps its own, monthly for a new one: weekly starts on
+// WeekStart (Monday by default), custom runs from StartDate to EndDate included.
 type BudgetUpsertRequest struct {
-	Amount float64 `json:"amount"`
+	Amount      model.Money  `json:"amount"`
+	Category    string       `json:"category,omitempty" example:"Grocery"`
+	Rollover    *string      `json:"rollover,omitempty" enums:"none,positive,negative,both"`
+	RolloverCap *model.Money `json:"rolloverCap,omitempty"`
+	Thresholds  []int16      `json:"thresholds,omitempty" example:"50,75,90,100,120"`
+	AlertEmail  *bool        `json:"alertEmail,omitempty"`
+	Period      *string      `json:"period,omitempty" enums:"weekly,monthly,quarterly,yearly,custom"`
+	WeekStart   *string      `json:"weekStart,omitempty" example:"monday"`
+	StartDate   *string      `json:"startDate,omitempty" example:"2026-05-01"`
+	EndDate     *string      `json:"endDate,omitempty" example:"2026-05-31"`
+}
+
+// TimezoneResponse is the body of GET/PUT /api/timezone. Today is the
+// current day in the timezone, the default date of new transactions.
+type TimezoneResponse struct {
+	Timezone string `json:"timezone" example:"Europe/Rome"`
+	Today    string `json:"today"    example:"2026-05-21"`
+	Now      string `json:"now"      example:"2026-05-21T10:00:00+02:00"`
+}
+
+// TimezoneRequest is the body of PUT /api/timezone.
+type TimezoneRequest struct {
+	Timezone string `json:"timezone" example:"Europe/Rome"`
+}
+
+// ExchangeRateDTO is one exchange rate: 1 Base = Rate Quote.
+type ExchangeRateDTO struct {
+	Base      string    `json:"base"      example:"EUR"`
+	Quote     string    `json:"quote"     example:"USD"`
+	Rate      float64   `json:"rate"      example:"1.08"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ExchangeRatesResponse is the body of GET /api/exchange-rates.
+type ExchangeRatesResponse struct {
+	Rates []ExchangeRateDTO `json:"rates"`
+}
+
+// ExchangeRatesRequest is the body of POST /api/admin/exchange-rates.
+type ExchangeRatesRequest struct {
+	Base  string             `json:"base"  example:"EUR"`
+	Rates map[string]float64 `json:"rates"`
 }
 
 // CategoryEntry is one row of a category breakdown.
 type CategoryEntry struct {
-	Category string  `json:"category"`
-	Amount   float64 `json:"amount"`
-	Count    int64   `json:"count"`
-	Pct      float64 `json:"pct"`
+	Category string      `json:"category"`
+	Amount   model.Money `json:"amount"`
+	Count    int64       `json:"count"`
+	Pct      float64     `json:"pct"`
 }
 
 // CategoryBreakdown groups category entries by transaction type.
@@ -124,21 +346,185 @@ type CategoryBreakdown struct {
 	Income  []CategoryEntry `json:"Income"`
 }
 
+// TagEntry is one row of a tag breakdown. A transaction with several tags is
+// counted in each of them, so the percentages can sum to more than 100.
+type TagEntry struct {
+	Tag    string      `json:"tag"`
+	Amount model.Money `json:"amount"`
+	Count  int64       `json:"count"`
+	Pct    float64     `json:"pct"`
+}
+
+// MemberEntry is one member of the active ledger in the analytics, with the
+// totals of the transactions they entered.
+type MemberEntry struct {
+	TgID    int64       `json:"tgId"    example:"123456789"`
+	Name    string      `json:"name"    example:"Alice"`
+	Expense model.Money `json:"expense" example:"820.50"`
+	Income  model.Money `json:"income"  example:"2100"`
+	Count   int64       `json:"count"   example:"42"`
+}
+
+// TagBreakdown groups tag entries by transaction type.
+type TagBreakdown struct {
+	Expense []TagEntry `json:"Expense"`
+	Income  []TagEntry `json:"Income"`
+}
+
+// TagsResponse is the body of GET /api/tags.
+type TagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// AccountDTO is an account with its current balance, in the account currency.
+type AccountDTO struct {
+	ID             int64       `json:"id"             example:"3"`
+	Name           string      `json:"name"           example:"Revolut"`
+	Kind           string      `json:"kind"           example:"Card"`
+	Currency       string      `json:"currency"       example:"EUR"`
+	OpeningBalance model.Money `json:"openingBalance" example:"150"`
+	Balance        model.Money `json:"balance"        example:"87.40"`
+	Archived       bool        `json:"archived"       example:"false"`
+	IsDefault      bool        `json:"isDefault"      example:"true"`
+}
+
+// AccountsResponse is the body of GET /api/accounts.
+type AccountsResponse struct {
+	Accounts []AccountDTO `json:"accounts"`
+}
+
+// CreateAccountRequest is the body of POST /api/accounts/create.
+// Kind defaults to Other, Currency to the user's base currency.
+type CreateAccountRequest struct {
+	Name           string      `json:"name"                     example:"Revolut"`
+	Kind           string      `json:"kind,omitempty"           example:"Card"`
+	Currency       string      `json:"currency,omitempty"       example:"EUR"`
+	OpeningBalance model.Money `json:"openingBalance,omitempty" example:"150"`
+	IsDefault      bool        `json:"isDefault,omitempty"      example:"false"`
+}
+
+// EditAccountRequest is the body of PATCH /api/accounts/edit.
+// Only non-nil fields are applied, the currency cannot be changed.
+type EditAccountRequest struct {
+	ID             int64        `json:"id"                       example:"3"`
+	Name           *string      `json:"name,omitempty"           example:"Revolut EUR"`
+	Kind           *string      `json:"kind,omitempty"           example:"Checking"`
+	OpeningBalance *model.Money `json:"openingBalance,omitempty" example:"200"`
+	Archived       *bool        `json:"archived,omitempty"       example:"true"`
+	IsDefault      *bool        `json:"isDefault,omitempty"      example:"true"`
+}
+
+// DeleteAccountRequest is the body of DELETE /api/accounts/delete.
+type DeleteAccountRequest struct {
+	ID int64 `json:"id" example:"3"`
+}
+
+// TransferRequest is the body of POST /api/accounts/transfer.
+// Amount is in the currency of the source account, Description is optional.
+type TransferRequest struct {
+	FromAccountID int64       `json:"fromAccountId"         example:"3"`
+	ToAccountID   int64       `json:"toAccountId"           example:"4"`
+	Amount        model.Money `json:"amount"                example:"200"`
+	Description   string      `json:"description,omitempty" example:"monthly savings"`
+	Date          string      `json:"date"                  example:"2026-05-21"`
+}
+
+// LedgerEntryDTO is a transaction of an account with the balance right after it.
+type LedgerEntryDTO struct {
+	Transaction TransactionDTO `json:"transaction"`
+	Delta       model.Money    `json:"delta"   example:"-12.50"`
+	Balance     model.Money    `json:"balance" example:"87.40"`
+}
+
+// LedgerResponse is the body of GET /api/accounts/ledger.
+// StartBalance is the balance before the first entry, EndBalance after the last one.
+type LedgerResponse struct {
+	Account      AccountDTO       `json:"account"`
+	StartBalance model.Money      `json:"startBalance" example:"150"`
+	EndBalance   model.Money      `json:"endBalance"   example:"87.40"`
+	Entries      []LedgerEntryDTO `json:"entries"`
+}
+
+// RecurringRuleDTO is a recurring transaction rule. NextOccurrence is empty
+// once the rule has ended.
+type RecurringRuleDTO struct {
+	ID             int64       `json:"id"                       example:"5"`
+	Type           string      `json:"type"                     example:"Expense"`
+	Category       string      `json:"category"                 example:"Subscriptions"`
+	Amount         model.Money `json:"amount"                   example:"12.99"`
+	Currency       string      `json:"currency"                 example:"EUR"`
+	Description    string      `json:"description"              example:"Netflix"`
+	AccountID      *int64      `json:"accountId,omitempty"      example:"3"`
+	Frequency      string      `json:"frequency"                example:"Monthly"`
+	DayOfMonth     int         `json:"dayOfMonth,omitempty"     example:"5"`
+	StartDate      string      `json:"startDate"                example:"2026-01-05"`
+	EndDate        string      `json:"endDate,omitempty"        example:"2026-12-31"`
+	LastRunOn      string      `json:"lastRunOn,omitempty"      example:"2026-05-05"`
+	NextOccurrence string      `json:"nextOccurrence,omitempty" example:"2026-06-05"`
+	Schedule       string      `json:"schedule"                 example:"Monthly on day 5"`
+	Paused         bool        `json:"paused"                   example:"false"`
+}
+
+// RecurringRulesResponse is the body of GET /api/recurring.
+type RecurringRulesResponse struct {
+	Rules []RecurringRuleDTO `json:"rules"`
+}
+
+// CreateRecurringRuleRequest is the body of POST /api/recurring/create.
+// Currency defaults to the user's base currency, StartDate to today and
+// DayOfMonth of monthly rules to the day of StartDate.
+type CreateRecurringRuleRequest struct {
+	Type        string      `json:"type"                  example:"Expense"`
+	Category    string      `json:"category"              example:"Subscriptions"`
+	Amount      model.Money `json:"amount"                example:"12.99"`
+	Currency    string      `json:"currency,omitempty"    example:"EUR"`
+	Description string      `json:"description"           example:"Netflix"`
+	AccountID   *int64      `json:"accountId,omitempty"   example:"3"`
+	Frequency   string      `json:"frequency"             example:"Monthly"`
+	DayOfMonth  int         `json:"dayOfMonth,omitempty"  example:"5"`
+	StartDate   string      `json:"startDate,omitempty"   example:"2026-01-05"`
+	EndDate     string      `json:"endDate,omitempty"     example:"2026-12-31"`
+}
+
+// EditRecurringRuleRequest is the body of PATCH /api/recurring/edit.
+// Only non-nil fields are applied, AccountID 0 and an empty EndDate remove them.
+type EditRecurringRuleRequest struct {
+	ID          int64        `json:"id"                    example:"5"`
+	Type        *string      `json:"type,omitempty"        example:"Expense"`
+	Category    *string      `json:"category,omitempty"    example:"Subscriptions"`
+	Amount      *model.Money `json:"amount,omitempty"      example:"15.99"`
+	Currency    *string      `json:"currency,omitempty"    example:"EUR"`
+	Description *string      `json:"description,omitempty" example:"Netflix Premium"`
+	AccountID   *int64       `json:"accountId,omitempty"   example:"3"`
+	Frequency   *string      `json:"frequency,omitempty"   example:"Monthly"`
+	DayOfMonth  *int         `json:"dayOfMonth,omitempty"  example:"10"`
+	StartDate   *string      `json:"startDate,omitempty"   example:"2026-01-10"`
+	EndDate     *string      `json:"endDate,omitempty"     example:"2026-12-31"`
+	Paused      *bool        `json:"paused,omitempty"      example:"true"`
+}
+
+// DeleteRecurringRuleRequest is the body of DELETE /api/recurring/delete.
+type DeleteRecurringRuleRequest struct {
+	ID int64 `json:"id" example:"5"`
+}
+
 // MonthPoint is one month's pivoted totals.
 type MonthPoint struct {
-	Month   string  `json:"month"`
-	Income  float64 `json:"income"`
-	Expense float64 `json:"expense"`
-	Balance float64 `json:"balance"`
+	Month   string      `json:"month"`
+	Income  model.Money `json:"income"`
+	Expense model.Money `json:"expense"`
+	Balance model.Money `json:"balance"`
 }
 
 // MonthlyAnalyticsResponse is the body of GET /api/analytics/monthly.
 type MonthlyAnalyticsResponse struct {
 	Month         string            `json:"month"`
-	TotalIncome   float64           `json:"totalIncome"`
-	TotalExpenses float64           `json:"totalExpenses"`
-	Balance       float64           `json:"balance"`
+	TotalIncome   model.Money       `json:"totalIncome"`
+	TotalExpenses model.Money       `json:"totalExpenses"`
+	Balance       model.Money       `json:"balance"`
 	ByCategory    CategoryBreakdown `json:"byCategory"`
+	ByTag         TagBreakdown      `json:"byTag"`
+	ByMember      []MemberEntry     `json:"byMember,omitempty"`
 }
 
 // TrendResponse is the body of GET /api/analytics/trend.
@@ -148,20 +534,347 @@ type TrendResponse struct {
 	Points []MonthPoint `json:"points"`
 }
 
+// NetWorthPoint is the net worth at the end of a month, as of today for the
+// current one.
+type NetWorthPoint struct {
+	Month       string      `json:"month"       example:"2026-05"`
+	Date        string      `json:"date"        example:"2026-05-31"`
+	Assets      model.Money `json:"assets"      example:"250000"`
+	Liabilities model.Money `json:"liabilities" example:"180000"`
+	NetWorth    model.Money `json:"netWorth"    example:"70000"`
+}
+
+// NetWorthResponse is the body of GET /api/analytics/networth, in the base
+// currency of the user.
+type NetWorthResponse struct {
+	From     string          `json:"from"     example:"2025-06"`
+	To       string          `json:"to"       example:"2026-05"`
+	Currency string          `json:"currency" example:"EUR"`
+	Points   []NetWorthPoint `json:"points"`
+}
+
 // YearMonthEntry is one month inside the year analytics breakdown.
 type YearMonthEntry struct {
-	Month   int     `json:"month"`
-	Income  float64 `json:"income"`
-	Expense float64 `json:"expense"`
-	Balance float64 `json:"balance"`
+	Month   int         `json:"month"`
+	Income  model.Money `json:"income"`
+	Expense model.Money `json:"expense"`
+	Balance model.Money `json:"balance"`
 }
 
 // YearAnalyticsResponse is the body of GET /api/analytics/year.
 type YearAnalyticsResponse struct {
 	Year          int               `json:"year"`
-	TotalIncome   float64           `json:"totalIncome"`
-	TotalExpenses float64           `json:"totalExpenses"`
-	Balance       float64           `json:"balance"`
+	TotalIncome   model.Money       `json:"totalIncome"`
+	TotalExpenses model.Money       `json:"totalExpenses"`
+	Balance       model.Money       `json:"balance"`
 	ByMonth       []YearMonthEntry  `json:"byMonth"`
 	ByCategory    CategoryBreakdown `json:"byCategory"`
+	ByTag         TagBreakdown      `json:"byTag"`
+	ByMember      []MemberEntry     `json:"byMember,omitempty"`
+}
+
+// LedgerMemberDTO is a member of a shared ledger.
+type LedgerMemberDTO struct {
+	TgID     int64  `json:"tgId"     example:"123456789"`
+	Name     string `json:"name"     example:"Alice"`
+	Role     string `json:"role"     example:"editor"`
+	JoinedAt string `json:"joinedAt" example:"2026-03-01"`
+}
+
+// LedgerDTO is a shared ledger. InviteCode is only returned to its owners.
+type LedgerDTO struct {
+	ID         int64             `json:"id"                   example:"3"`
+	Name       string            `json:"name"                 example:"Home"`
+	Currency   string            `json:"currency"             example:"EUR"`
+	Role       string            `json:"role"                 example:"owner"`
+	Active     bool              `json:"active"               example:"true"`
+	InviteCode string            `json:"inviteCode,omitempty" example:"K7PX2QMA"`
+	Members    []LedgerMemberDTO `json:"members"`
+}
+
+// LedgersResponse is the body of GET /api/ledgers. ActiveLedgerID is empty
+// when the user works on their personal transactions.
+type LedgersResponse struct {
+	ActiveLedgerID *int64      `json:"activeLedgerId,omitempty" example:"3"`
+	Ledgers        []LedgerDTO `json:"ledgers"`
+}
+
+// CreateLedgerRequest is the body of POST /api/ledgers/create.
+type CreateLedgerRequest struct {
+	Name string `json:"name" example:"Home"`
+}
+
+// JoinLedgerRequest is the body of POST /api/ledgers/join.
+type JoinLedgerRequest struct {
+	InviteCode string `json:"inviteCode" example:"K7PX2QMA"`
+}
+
+// LedgerIDRequest is the body of the ledger endpoints acting on a whole
+// ledger. For POST /api/ledgers/switch a null ID switches back to the
+// personal transactions.
+type LedgerIDRequest struct {
+	ID *int64 `json:"id" example:"3"`
+}
+
+// LedgerMemberRequest is the body of POST /api/ledgers/members/role and
+// POST /api/ledgers/members/remove, Role is ignored by the latter.
+type LedgerMemberRequest struct {
+	ID   int64  `json:"id"             example:"3"`
+	TgID int64  `json:"tgId"           example:"123456789"`
+	Role string `json:"role,omitempty" example:"viewer"`
+}
+
+// CounterpartBalanceDTO is what a counterpart owes the user, negative when
+// the user owes them.
+type CounterpartBalanceDTO struct {
+	TgID   int64       `json:"tgId"   example:"123456789"`
+	Name   string      `json:"name"   example:"Bob"`
+	Amount model.Money `json:"amount" example:"20.00"`
+}
+
+// TransferDTO is a payment suggested to settle the balances of the group of
+// the user.
+type TransferDTO struct {
+	FromTgID int64       `json:"fromTgId" example:"123456789"`
+	FromName string      `json:"fromName" example:"Bob"`
+	ToTgID   int64       `json:"toTgId"   example:"987654321"`
+	ToName   string      `json:"toName"   example:"Alice"`
+	Amount   model.Money `json:"amount"   example:"20.00"`
+}
+
+// OweResponse is the body of GET /api/owe: the balances of the user with
+// each counterpart and the fewest payments settling them, in the base
+// currency of the user.
+type OweResponse struct {
+	Currency  string                  `json:"currency"  example:"EUR"`
+	Balances  []CounterpartBalanceDTO `json:"balances"`
+	Transfers []TransferDTO           `json:"transfers"`
+}
+
+// ShareParticipantDTO is a participant of a shared expense, by Telegram ID
+// or username. Value is their percentage or exact amount, ignored by equal
+// splits.
+type ShareParticipantDTO struct {
+	TgID     int64   `json:"tgId,omitempty"     example:"123456789"`
+	Username string  `json:"username,omitempty" example:"bob"`
+	Value    float64 `json:"value,omitempty"    example:"40"`
+}
+
+// ShareExpenseRequest is the body of POST /api/owe/share.
+type ShareExpenseRequest struct {
+	TransactionID int64                 `json:"transactionId" example:"42"`
+	Method        string                `json:"method"        example:"equal"`
+	Participants  []ShareParticipantDTO `json:"participants"`
+}
+
+// ExpenseShareDTO is the share of a participant of a shared expense.
+type ExpenseShareDTO struct {
+	TgID   int64       `json:"tgId"   example:"123456789"`
+	Amount model.Money `json:"amount" example:"20.00"`
+}
+
+// SharedExpenseDTO is an expense paid by the user and shared with other users.
+type SharedExpenseDTO struct {
+	ID            int64             `json:"id"            example:"7"`
+	TransactionID int64             `json:"transactionId" example:"42"`
+	Method        string            `json:"method"        example:"equal"`
+	Amount        model.Money       `json:"amount"        example:"60.00"`
+	Currency      string            `json:"currency"      example:"EUR"`
+	Shares        []ExpenseShareDTO `json:"shares"`
+}
+
+// SettleRequest is the body of POST /api/settle. The user paid the
+// counterpart, or received the payment when Received is set.
+type SettleRequest struct {
+	CounterpartTgID int64       `json:"counterpartTgId" example:"123456789"`
+	Amount          model.Money `json:"amount"          example:"20.00"`
+	Received        bool        `json:"received"        example:"false"`
+}
+
+// AttachmentDTO is a photo or a document attached to a transaction, URL
+// downloads its content.
+type AttachmentDTO struct {
+	ID            int64     `json:"id"            example:"5"`
+	TransactionID int64     `json:"transactionId" example:"42"`
+	FileName      string    `json:"fileName"      example:"receipt.pdf"`
+	ContentType   string    `json:"contentType"   example:"application/pdf"`
+	Size          int64     `json:"size"          example:"183204"`
+	CreatedAt     time.Time `json:"createdAt"`
+	URL           string    `json:"url"           example:"/web/api/transactions/42/attachments/5"`
+}
+
+// AttachmentsResponse is the body of GET /api/transactions/{id}/attachments.
+type AttachmentsResponse struct {
+	Attachments []AttachmentDTO `json:"attachments"`
+}
+
+// GoalDTO is a savings goal with its progress. EstimatedDate is when the
+// target is reached at MonthlyRate, Required the amount to save per month to
+// meet the deadline. Status is one of reached, on_track, behind, overdue and
+// no_deadline.
+type GoalDTO struct {
+	ID            int64       `json:"id"                      example:"4"`
+	Name          string      `json:"name"                    example:"Holiday"`
+	TargetAmount  model.Money `json:"targetAmount"            example:"1500"`
+	Currency      string      `json:"currency"                example:"EUR"`
+	Deadline      string      `json:"deadline,omitempty"      example:"2026-08-31"`
+	Tag           string      `json:"tag,omitempty"           example:"holiday"`
+	Saved         model.Money `json:"saved"                   example:"600"`
+	Remaining     model.Money `json:"remaining"               example:"900"`
+	Pct           int         `json:"pct"                     example:"40"`
+	MonthlyRate   model.Money `json:"monthlyRate"             example:"150"`
+	Required      model.Money `json:"required"                example:"300"`
+	EstimatedDate string      `json:"estimatedDate,omitempty" example:"2026-12-01"`
+	Status        string      `json:"status"                  example:"behind"`
+}
+
+// GoalsResponse is the body of GET /api/goals.
+type GoalsResponse struct {
+	Goals []GoalDTO `json:"goals"`
+}
+
+// GoalContributionDTO is a contribution to a goal: a manual one, with its
+// ID, or a transaction tagged with the tag of the goal.
+type GoalContributionDTO struct {
+	ID            *int64      `json:"id,omitempty"            example:"12"`
+	TransactionID *int64      `json:"transactionId,omitempty" example:"42"`
+	Date          string      `json:"date"                    example:"2026-05-21"`
+	Amount        model.Money `json:"amount"                  example:"200"`
+	Note          string      `json:"note"                    example:"May savings"`
+}
+
+// GoalDetailsResponse is the body of GET /api/goals/{id}.
+type GoalDetailsResponse struct {
+	Goal          GoalDTO               `json:"goal"`
+	Contributions []GoalContributionDTO `json:"contributions"`
+}
+
+// CreateGoalRequest is the body of POST /api/goals.
+// Deadline and Tag are optional.
+type CreateGoalRequest struct {
+	Name         string      `json:"name"               example:"Holiday"`
+	TargetAmount model.Money `json:"targetAmount"       example:"1500"`
+	Deadline     string      `json:"deadline,omitempty" example:"2026-08-31"`
+	Tag          string      `json:"tag,omitempty"      example:"holiday"`
+}
+
+// EditGoalRequest is the body of PUT /api/goals/{id}.
+// Only non-nil fields are applied, an empty Deadline or Tag removes it.
+type EditGoalRequest struct {
+	Name         *string      `json:"name,omitempty"         example:"Summer holiday"`
+	TargetAmount *model.Money `json:"targetAmount,omitempty" example:"2000"`
+	Deadline     *string      `json:"deadline,omitempty"     example:"2026-09-30"`
+	Tag          *string      `json:"tag,omitempty"          example:"holiday"`
+}
+
+// GoalContributionRequest is the body of POST /api/goals/{id}/contributions.
+// A negative Amount withdraws from the goal, Date defaults to today.
+type GoalContributionRequest struct {
+	Amount model.Money `json:"amount"         example:"200"`
+	Date   string      `json:"date,omitempty" example:"2026-05-21"`
+	Note   string      `json:"note,omitempty" example:"May savings"`
+}
+
+// EnvelopeRef names an envelope: an expense category, or a goal by its ID.
+// Exactly one of the two is set.
+type EnvelopeRef struct {
+	Category string `json:"category,omitempty" example:"Grocery"`
+	GoalID   *int64 `json:"goalId,omitempty"   example:"4"`
+}
+
+// EnvelopeDTO is the state of an envelope in a month: the money assigned to
+// it and spent from it in the month, and what is available carried from the
+// previous months. Goals are never spent from.
+type EnvelopeDTO struct {
+	EnvelopeRef
+	Name      string      `json:"name"      example:"Grocery"`
+	Assigned  model.Money `json:"assigned"  example:"300"`
+	Spent     model.Money `json:"spent"     example:"120"`
+	Available model.Money `json:"available" example:"180"`
+}
+
+// EnvelopesResponse is the body of GET /api/envelopes and of the changes to
+// the envelopes: the zero-based budget of a month. Unassigned is the income
+// received so far not assigned to any envelope yet, negative when more than
+// the income was assigned.
+type EnvelopesResponse struct {
+	Month      string        `json:"month"      example:"2026-05"`
+	Currency   string        `json:"currency"   example:"EUR"`
+	Income     model.Money   `json:"income"     example:"2500"`
+	Assigned   model.Money   `json:"assigned"   example:"2000"`
+	Unassigned model.Money   `json:"unassigned" example:"500"`
+	Envelopes  []EnvelopeDTO `json:"envelopes"`
+}
+
+// AssignEnvelopeRequest is the body of POST /api/envelopes/assign. A negative
+// Amount takes money back out of the envelope. TransactionID is the income
+// the money comes from, and Month (YYYY-MM) defaults to its month, otherwise
+// to the current one.
+type AssignEnvelopeRequest struct {
+	EnvelopeRef
+	Amount        model.Money `json:"amount"                  example:"300"`
+	Month         string      `json:"month,omitempty"         example:"2026-05"`
+	TransactionID *int64      `json:"transactionId,omitempty" example:"42"`
+	Note          string      `json:"note,omitempty"          example:"May salary"`
+}
+
+// MoveEnvelopeRequest is the body of POST /api/envelopes/move. Month
+// (YYYY-MM) defaults to the current one.
+type MoveEnvelopeRequest struct {
+	From   EnvelopeRef `json:"from"`
+	To     EnvelopeRef `json:"to"`
+	Amount model.Money `json:"amount"          example:"50"`
+	Month  string      `json:"month,omitempty" example:"2026-05"`
+}
+
+// HoldingDTO is an asset or a liability with its latest balance, in the
+// currency of the holding and converted to the base currency of the user.
+// The balances are omitted when none was recorded yet. AccountID is the
+// account the balance comes from, if any.
+type HoldingDTO struct {
+	ID               int64        `json:"id"                         example:"3"`
+	Name             string       `json:"name"                       example:"Mortgage"`
+	Kind             string       `json:"kind"                       example:"Mortgage"`
+	Liability        bool         `json:"liability"                  example:"true"`
+	Currency         string       `json:"currency"                   example:"EUR"`
+	AccountID        *int64       `json:"accountId,omitempty"        example:"2"`
+	Balance          *model.Money `json:"balance,omitempty"          example:"180000"`
+	ConvertedBalance *model.Money `json:"convertedBalance,omitempty" example:"180000"`
+	BalanceDate      string       `json:"balanceDate,omitempty"      example:"2026-05-01"`
+}
+
+// HoldingsResponse is the body of GET /api/holdings: every holding of the
+// user with the totals of their latest balances in the base currency.
+type HoldingsResponse struct {
+	Currency    string       `json:"currency"    example:"EUR"`
+	Assets      model.Money  `json:"assets"      example:"250000"`
+	Liabilities model.Money  `json:"liabilities" example:"180000"`
+	NetWorth    model.Money  `json:"netWorth"    example:"70000"`
+	Holdings    []HoldingDTO `json:"holdings"`
+}
+
+// CreateHoldingRequest is the body of POST /api/holdings. Kind defaults to
+// Asset and Currency to the base currency of the user. With an AccountID the
+// balance comes from that account, in its currency, otherwise Balance is the
+// optional balance recorded today.
+type CreateHoldingRequest struct {
+	Name      string       `json:"name"                example:"Brokerage"`
+	Kind      string       `json:"kind,omitempty"      example:"Brokerage"`
+	Currency  string       `json:"currency,omitempty"  example:"USD"`
+	AccountID *int64       `json:"accountId,omitempty" example:"2"`
+	Balance   *model.Money `json:"balance,omitempty"   example:"12000"`
+}
+
+// EditHoldingRequest is the body of PUT /api/holdings/{id}.
+// Only non-nil fields are applied, the currency cannot be changed.
+type EditHoldingRequest struct {
+	Name *string `json:"name,omitempty" example:"ETF portfolio"`
+	Kind *string `json:"kind,omitempty" example:"Brokerage"`
+}
+
+// HoldingBalanceRequest is the body of POST /api/holdings/{id}/balances.
+// Date defaults to today, a balance recorded the same day is replaced.
+type HoldingBalanceRequest struct {
+	Balance model.Money `json:"balance"        example:"12500"`
+	Date    string      `json:"date,omitempty" example:"2026-05-31"`
 }
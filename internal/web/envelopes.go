@@ -0,0 +1,214 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toEnvelopesResponse(ledger model.EnvelopeLedger, currency model.CurrencyType) EnvelopesResponse {
+	resp := EnvelopesResponse{
+		Month:      ledger.Month,
+		Currency:   string(currency),
+		Income:     ledger.Income,
+		Assigned:   ledger.Assigned,
+		Unassigned: ledger.Unassigned,
+		Envelopes:  make([]EnvelopeDTO, len(ledger.Envelopes)),
+	}
+	for i, e := range ledger.Envelopes {
+		resp.Envelopes[i] = EnvelopeDTO{
+			EnvelopeRef: toEnvelopeRef(e.Envelope),
+			Name:        e.Name,
+			Assigned:    e.Assigned,
+			Spent:       e.Spent,
+			Available:   e.Available,
+		}
+	}
+	return resp
+}
+
+func toEnvelopeRef(e model.Envelope) EnvelopeRef {
+	if e.IsGoal() {
+		goalID := e.GoalID
+		return EnvelopeRef{GoalID: &goalID}
+	}
+	return EnvelopeRef{Category: string(e.Category)}
+}
+
+func (ref EnvelopeRef) envelope() model.Envelope {
+	if ref.GoalID != nil {
+		return model.Envelope{GoalID: *ref.GoalID}
+	}
+	return model.Envelope{Category: model.TransactionCategory(ref.Category)}
+}
+
+// sendEnvelopeError maps the envelope validation errors to a 4xx response.
+func (s *Server) sendEnvelopeError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidEnvelope), errors.Is(err, model.ErrEnvelopeIncome):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrEnvelopeNotFound):
+		s.sendJSONError(w, "Envelope not found, use one of your expense categories or goals", http.StatusNotFound)
+	case errors.Is(err, model.ErrEnvelopeFunds):
+		s.sendJSONError(w, "Not enough money available in the envelope", http.StatusConflict)
+	default:
+		s.logger.Errorf("Failed to %s envelopes: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action+" envelopes", http.StatusInternalServerError)
+	}
+}
+
+// envelopeMonth returns the month of the request, the current one if empty
+func envelopeMonth(value string, user *model.User) (string, bool) {
+	if value == "" {
+		return user.Today().Format("2006-01"), true
+	}
+	if _, err := time.Parse("2006-01", value); err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// sendEnvelopes responds with the envelopes of the user in a month
+func (s *Server) sendEnvelopes(w http.ResponseWriter, user *model.User, month, action string) {
+	ledger, err := s.repositories.Envelopes.Ledger(user.TgID, month)
+	if err != nil {
+		s.sendEnvelopeError(w, err, action)
+		return
+	}
+	s.sendJSONSuccess(w, toEnvelopesResponse(ledger, user.BaseCurrency))
+}
+
+// handleAPIEnvelopes returns the zero-based budget of a month.
+//
+//	@Summary		Get envelopes
+//	@Description	The income of the month, the money assigned in it and the income not assigned yet, with every envelope the money was assigned to.
+//	@Tags			envelopes
+//	@Produce		json
+//	@Param			month	query		string	false	"Month as YYYY-MM, the current one by default"
+//	@Success		200		{object}	EnvelopesResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/envelopes [get]
+func (s *Server) handleAPIEnvelopes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	month, ok := envelopeMonth(r.URL.Query().Get("month"), user)
+	if !ok {
+		s.sendJSONError(w, "Invalid month format. Use YYYY-MM", http.StatusBadRequest)
+		return
+	}
+	s.sendEnvelopes(w, user, month, "get")
+}
+
+// handleAPIAssignEnvelope assigns income to an envelope.
+//
+//	@Summary		Assign money to an envelope
+//	@Description	Assigns income to an expense category or a goal, a negative amount takes it back. With a transaction ID the money comes from that income, in its month by default.
+//	@Tags			envelopes
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		AssignEnvelopeRequest	true	"Allocation payload"
+//	@Success		200		{object}	EnvelopesResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/envelopes/assign [post]
+func (s *Server) handleAPIAssignEnvelope(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req AssignEnvelopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	allocation := model.NewEnvelopeAllocation(user.TgID, req.Month, req.envelope(), req.Amount)
+	allocation.TransactionID = req.TransactionID
+	allocation.Note = req.Note
+	if req.TransactionID == nil || req.Month != "" {
+		month, ok := envelopeMonth(req.Month, user)
+		if !ok {
+			s.sendJSONError(w, "Invalid month format. Use YYYY-MM", http.StatusBadRequest)
+			return
+		}
+		allocation.Month = month
+	}
+
+	if err := s.repositories.Envelopes.Assign(&allocation); err != nil {
+		s.sendEnvelopeError(w, err, "assign")
+		return
+	}
+	s.sendEnvelopes(w, user, allocation.Month, "assign")
+}
+
+// handleAPIMoveEnvelope moves money between two envelopes.
+//
+//	@Summary		Move money between envelopes
+//	@Description	Moves money available in an envelope to another one, in the current month by default.
+//	@Tags			envelopes
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		MoveEnvelopeRequest	true	"Move payload"
+//	@Success		200		{object}	EnvelopesResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/envelopes/move [post]
+func (s *Server) handleAPIMoveEnvelope(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req MoveEnvelopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	month, ok := envelopeMonth(req.Month, user)
+	if !ok {
+		s.sendJSONError(w, "Invalid month format. Use YYYY-MM", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Envelopes.Move(user.TgID, month, req.From.envelope(), req.To.envelope(), req.Amount); err != nil {
+		s.sendEnvelopeError(w, err, "move")
+		return
+	}
+	s.sendEnvelopes(w, user, month, "move")
+}
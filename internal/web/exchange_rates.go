@@ -0,0 +1,104 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+// requireAdmin wraps an authenticated handler, allowing only the users listed in ADMIN_USERS.
+func (s *Server) requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		user := client.GetUserFromContext(r.Context())
+		if user == nil {
+			s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if _, ok := s.adminUsers[user.TgUsername]; !ok || user.TgUsername == "" {
+			s.sendJSONError(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	})
+}
+
+// handleAPIExchangeRates lists the exchange rates used for currency conversion.
+//
+//	@Summary		List exchange rates
+//	@Tags			currency
+//	@Produce		json
+//	@Success		200	{object}	ExchangeRatesResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/exchange-rates [get]
+func (s *Server) handleAPIExchangeRates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rates, err := s.repositories.ExchangeRates.Get()
+	if err != nil {
+		s.logger.Errorf("Failed to get exchange rates: %v", err)
+		s.sendJSONError(w, "Failed to get exchange rates", http.StatusInternalServerError)
+		return
+	}
+
+	resp := ExchangeRatesResponse{Rates: make([]ExchangeRateDTO, len(rates))}
+	for i, rate := range rates {
+		resp.Rates[i] = ExchangeRateDTO{
+			Base:      string(rate.Base),
+			Quote:     string(rate.Quote),
+			Rate:      rate.Rate,
+			UpdatedAt: rate.UpdatedAt,
+		}
+	}
+
+	s.sendJSONSuccess(w, resp)
+}
+
+// handleAPIAdminExchangeRates loads a rate sheet into the exchange rates table.
+//
+//	@Summary		Load exchange rates (admin)
+//	@Description	Insert or update the rates of the sheet, each one meaning 1 base = rate quote. Only available to the users listed in ADMIN_USERS.
+//	@Tags			currency
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		ExchangeRatesRequest	true	"Rate sheet"
+//	@Success		200		{object}	MessageResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/admin/exchange-rates [post]
+func (s *Server) handleAPIAdminExchangeRates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ExchangeRatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	rates, err := model.RateSheet{Base: req.Base, Rates: req.Rates}.ExchangeRates()
+	if err != nil {
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.ExchangeRates.Store(rates); err != nil {
+		s.logger.Errorf("Failed to store exchange rates: %v", err)
+		s.sendJSONError(w, "Failed to store exchange rates", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Infof("Loaded %d exchange rates with base %s", len(rates), req.Base)
+	s.sendJSONSuccess(w, MessageResponse{Message: "Exchange rates loaded successfully"})
+}
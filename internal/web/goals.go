@@ -0,0 +1,450 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toGoalDTO(g model.Goal, p model.GoalProgress) GoalDTO {
+	dto := GoalDTO{
+		ID:           g.ID,
+		Name:         g.Name,
+		TargetAmount: g.TargetAmount,
+		Currency:     string(g.Currency),
+		Tag:          g.Tag,
+		Saved:        p.Saved,
+		Remaining:    p.Remaining,
+		Pct:          p.Pct,
+		MonthlyRate:  p.MonthlyRate,
+		Required:     p.Required,
+		Status:       string(p.Status),
+	}
+	if g.Deadline != nil {
+		dto.Deadline = g.Deadline.Format(dateLayout)
+	}
+	if p.Estimated != nil {
+		dto.EstimatedDate = p.Estimated.Format(dateLayout)
+	}
+	return dto
+}
+
+func toGoalContributionDTO(e model.GoalEntry) GoalContributionDTO {
+	return GoalContributionDTO{
+		ID:            e.ContributionID,
+		TransactionID: e.TransactionID,
+		Date:          e.Date.Format(dateLayout),
+		Amount:        e.Amount,
+		Note:          e.Note,
+	}
+}
+
+// sendGoalError maps the goal validation errors to a 4xx response.
+func (s *Server) sendGoalError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidGoal), errors.Is(err, model.ErrInvalidContribution):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrGoalNotFound):
+		s.sendJSONError(w, "Goal not found", http.StatusNotFound)
+	case errors.Is(err, model.ErrContributionNotFound):
+		s.sendJSONError(w, "Contribution not found", http.StatusNotFound)
+	case errors.Is(err, model.ErrGoalExists):
+		s.sendJSONError(w, "Goal already exists", http.StatusConflict)
+	case errors.Is(err, model.ErrGoalTagInUse):
+		s.sendJSONError(w, "The tag is already linked to another goal", http.StatusConflict)
+	default:
+		s.logger.Errorf("Failed to %s goal: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action+" goal", http.StatusInternalServerError)
+	}
+}
+
+// parseGoalDeadline parses an optional deadline, which cannot be in the past
+func parseGoalDeadline(value string, user *model.User) (*time.Time, string) {
+	if value == "" {
+		return nil, ""
+	}
+	d, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, "Invalid deadline format. Use YYYY-MM-DD"
+	}
+	if d.Before(user.Today()) {
+		return nil, "Deadline cannot be in the past"
+	}
+	return &d, ""
+}
+
+// goalWithProgress returns the DTO of a goal with its progress as of today
+func (s *Server) goalWithProgress(goal model.Goal, user *model.User) (GoalDTO, []model.GoalEntry, error) {
+	entries, err := s.repositories.Goals.Entries(goal)
+	if err != nil {
+		return GoalDTO{}, nil, err
+	}
+	return toGoalDTO(goal, goal.Progress(entries, user.Today())), entries, nil
+}
+
+// handleAPIGoals multiplexes GET/POST on /api/goals.
+//
+//	@Summary		List savings goals
+//	@Description	Every goal of the user with its progress and the projection of when it is reached at the current contribution rate.
+//	@Tags			goals
+//	@Produce		json
+//	@Success		200	{object}	GoalsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/goals [get]
+//
+//	@Summary		Create savings goal
+//	@Description	The target amount is in the user's base currency. When a tag is set, the transactions with that tag contribute to the goal.
+//	@Tags			goals
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		CreateGoalRequest	true	"Goal payload"
+//	@Success		200		{object}	GoalDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/goals [post]
+func (s *Server) handleAPIGoals(w http.ResponseWriter, r *http.Request) {
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.goalsList(w, user)
+	case http.MethodPost:
+		s.goalCreate(w, r, user)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) goalsList(w http.ResponseWriter, user *model.User) {
+	goals, err := s.repositories.Goals.List(user.TgID)
+	if err != nil {
+		s.sendGoalError(w, err, "list")
+		return
+	}
+
+	resp := GoalsResponse{Goals: make([]GoalDTO, len(goals))}
+	for i, g := range goals {
+		dto, _, err := s.goalWithProgress(g, user)
+		if err != nil {
+			s.sendGoalError(w, err, "list")
+			return
+		}
+		resp.Goals[i] = dto
+	}
+	s.sendJSONSuccess(w, resp)
+}
+
+func (s *Server) goalCreate(w http.ResponseWriter, r *http.Request, user *model.User) {
+	var req CreateGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	deadline, msg := parseGoalDeadline(req.Deadline, user)
+	if msg != "" {
+		s.sendJSONError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	currency := user.BaseCurrency
+	if currency == "" {
+		currency = model.CurrencyEUR
+	}
+	goal := model.Goal{
+		TgID:         user.TgID,
+		Name:         req.Name,
+		TargetAmount: req.TargetAmount,
+		Currency:     currency,
+		Deadline:     deadline,
+		Tag:          req.Tag,
+	}
+	if err := s.repositories.Goals.Create(&goal); err != nil {
+		s.sendGoalError(w, err, "create")
+		return
+	}
+
+	dto, _, err := s.goalWithProgress(goal, user)
+	if err != nil {
+		s.sendGoalError(w, err, "create")
+		return
+	}
+	s.sendJSONSuccess(w, dto)
+}
+
+// handleAPIGoal multiplexes GET/PUT/DELETE on /api/goals/{id}.
+//
+//	@Summary		Get savings goal
+//	@Description	A goal with its progress and every contribution, the manual ones and the tagged transactions, oldest first.
+//	@Tags			goals
+//	@Produce		json
+//	@Param			id	path		int	true	"Goal ID"
+//	@Success		200	{object}	GoalDetailsResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/goals/{id} [get]
+//
+//	@Summary		Edit savings goal
+//	@Description	Only non-nil fields are applied, an empty deadline or tag removes it. The currency cannot be changed.
+//	@Tags			goals
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Goal ID"
+//	@Param			body	body		EditGoalRequest	true	"Goal payload"
+//	@Success		200		{object}	GoalDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/goals/{id} [put]
+//
+//	@Summary		Delete savings goal
+//	@Description	The manual contributions are deleted with the goal, the tagged transactions are kept.
+//	@Tags			goals
+//	@Produce		json
+//	@Param			id	path		int	true	"Goal ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/goals/{id} [delete]
+func (s *Server) handleAPIGoal(w http.ResponseWriter, r *http.Request) {
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		s.sendJSONError(w, "Invalid goal ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.goalGet(w, user, id)
+	case http.MethodPut:
+		s.goalEdit(w, r, user, id)
+	case http.MethodDelete:
+		s.goalDelete(w, user, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) goalGet(w http.ResponseWriter, user *model.User, id int64) {
+	goal, err := s.repositories.Goals.Get(user.TgID, id)
+	if err != nil {
+		s.sendGoalError(w, err, "get")
+		return
+	}
+
+	dto, entries, err := s.goalWithProgress(goal, user)
+	if err != nil {
+		s.sendGoalError(w, err, "get")
+		return
+	}
+
+	resp := GoalDetailsResponse{Goal: dto, Contributions: make([]GoalContributionDTO, len(entries))}
+	for i, e := range entries {
+		resp.Contributions[i] = toGoalContributionDTO(e)
+	}
+	s.sendJSONSuccess(w, resp)
+}
+
+func (s *Server) goalEdit(w http.ResponseWriter, r *http.Request, user *model.User, id int64) {
+	var req EditGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	goal, err := s.repositories.Goals.Get(user.TgID, id)
+	if err != nil {
+		s.sendGoalError(w, err, "edit")
+		return
+	}
+
+	if req.Name != nil {
+		goal.Name = *req.Name
+	}
+	if req.TargetAmount != nil {
+		goal.TargetAmount = *req.TargetAmount
+	}
+	if req.Deadline != nil {
+		deadline, msg := parseGoalDeadline(*req.Deadline, user)
+		if msg != "" {
+			s.sendJSONError(w, msg, http.StatusBadRequest)
+			return
+		}
+		goal.Deadline = deadline
+	}
+	if req.Tag != nil {
+		goal.Tag = *req.Tag
+	}
+
+	if err := s.repositories.Goals.Update(&goal); err != nil {
+		s.sendGoalError(w, err, "edit")
+		return
+	}
+
+	dto, _, err := s.goalWithProgress(goal, user)
+	if err != nil {
+		s.sendGoalError(w, err, "edit")
+		return
+	}
+	s.sendJSONSuccess(w, dto)
+}
+
+func (s *Server) goalDelete(w http.ResponseWriter, user *model.User, id int64) {
+	if err := s.repositories.Goals.Delete(user.TgID, id); err != nil {
+		s.sendGoalError(w, err, "delete")
+		return
+	}
+	s.sendJSONSuccess(w, MessageResponse{Message: "Goal deleted successfully"})
+}
+
+// handleAPIGoalContributions adds a manual contribution to a goal.
+//
+//	@Summary		Add goal contribution
+//	@Description	Adds an amount put aside for the goal, a negative amount withdraws from it. Date defaults to today.
+//	@Tags			goals
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Goal ID"
+//	@Param			body	body		GoalContributionRequest	true	"Contribution payload"
+//	@Success		200		{object}	GoalDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/goals/{id}/contributions [post]
+func (s *Server) handleAPIGoalContributions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	goalID, ok := pathID(r, "id")
+	if !ok {
+		s.sendJSONError(w, "Invalid goal ID", http.StatusBadRequest)
+		return
+	}
+
+	var req GoalContributionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	date := user.Today()
+	if req.Date != "" {
+		d, err := time.Parse(dateLayout, req.Date)
+		if err != nil {
+			s.sendJSONError(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if d.After(user.Today()) {
+			s.sendJSONError(w, "Date cannot be in the future", http.StatusBadRequest)
+			return
+		}
+		date = d
+	}
+
+	contribution := model.GoalContribution{
+		GoalID: goalID,
+		TgID:   user.TgID,
+		Amount: req.Amount,
+		Date:   date,
+		Note:   req.Note,
+	}
+	if err := s.repositories.Goals.Contribute(&contribution); err != nil {
+		s.sendGoalError(w, err, "contribute to")
+		return
+	}
+
+	goal, err := s.repositories.Goals.Get(user.TgID, goalID)
+	if err != nil {
+		s.sendGoalError(w, err, "get")
+		return
+	}
+	dto, _, err := s.goalWithProgress(goal, user)
+	if err != nil {
+		s.sendGoalError(w, err, "get")
+		return
+	}
+	s.sendJSONSuccess(w, dto)
+}
+
+// handleAPIDeleteGoalContribution removes a manual contribution of a goal.
+//
+//	@Summary		Delete goal contribution
+//	@Description	Only manual contributions can be deleted, untag a transaction to stop it contributing.
+//	@Tags			goals
+//	@Produce		json
+//	@Param			id				path		int	true	"Goal ID"
+//	@Param			contributionId	path		int	true	"Contribution ID"
+//	@Success		200				{object}	MessageResponse
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/goals/{id}/contributions/{contributionId} [delete]
+func (s *Server) handleAPIDeleteGoalContribution(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	goalID, ok := pathID(r, "id")
+	if !ok {
+		s.sendJSONError(w, "Invalid goal ID", http.StatusBadRequest)
+		return
+	}
+	contributionID, ok := pathID(r, "contributionId")
+	if !ok {
+		s.sendJSONError(w, "Invalid contribution ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Goals.RemoveContribution(user.TgID, goalID, contributionID); err != nil {
+		s.sendGoalError(w, err, "delete contribution of")
+		return
+	}
+	s.sendJSONSuccess(w, MessageResponse{Message: "Contribution deleted successfully"})
+}
@@ -0,0 +1,74 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toRevisionDTO(r model.TransactionRevision) RevisionDTO {
+	dto := RevisionDTO{
+		ID:        r.ID,
+		Action:    string(r.Action),
+		Source:    string(r.Source),
+		ActorTgID: r.ActorTgID,
+		CreatedAt: r.CreatedAt,
+		Changes:   []FieldChangeDTO{},
+	}
+	for _, c := range r.Changes() {
+		dto.Changes = append(dto.Changes, FieldChangeDTO{Field: c.Field, Before: c.Before, After: c.After})
+	}
+	return dto
+}
+
+// handleAPITransactionHistory returns the edit history of a transaction.
+//
+//	@Summary		Transaction history
+//	@Description	Every change made to a transaction of the user, oldest first, with the fields it changed, who made it and from where. The history is kept after the transaction is deleted and purged.
+//	@Tags			transactions
+//	@Produce		json
+//	@Param			id	path		int	true	"Transaction ID"
+//	@Success		200	{object}	TransactionHistoryResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/transactions/{id}/history [get]
+func (s *Server) handleAPITransactionHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, ok := pathID(r, "id")
+	if !ok {
+		s.sendJSONError(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := s.repositories.Transactions.History(user.TgID, transactionID)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		s.sendJSONError(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Errorf("Failed to get transaction history: %v", err)
+		s.sendJSONError(w, "Failed to get transaction history", http.StatusInternalServerError)
+		return
+	}
+
+	resp := TransactionHistoryResponse{TransactionID: transactionID, Revisions: make([]RevisionDTO, len(revisions))}
+	for i, rev := range revisions {
+		resp.Revisions[i] = toRevisionDTO(rev)
+	}
+	s.sendJSONSuccess(w, resp)
+}
@@ -0,0 +1,411 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toLedgerDTO(ledger model.Ledger, user model.User) LedgerDTO {
+	me, _ := ledger.Member(user.TgID)
+	dto := LedgerDTO{
+		ID:       ledger.ID,
+		Name:     ledger.Name,
+		Currency: string(ledger.Currency),
+		Role:     string(me.Role),
+		Active:   user.LedgerID != nil && *user.LedgerID == ledger.ID,
+		Members:  make([]LedgerMemberDTO, len(ledger.Members)),
+	}
+	if me.Role.CanManage() {
+		dto.InviteCode = ledger.InviteCode
+	}
+	for i, m := range ledger.Members {
+		dto.Members[i] = LedgerMemberDTO{
+			TgID:     m.TgID,
+			Name:     m.DisplayName(),
+			Role:     string(m.Role),
+			JoinedAt: m.JoinedAt.Format(dateLayout),
+		}
+	}
+	return dto
+}
+
+// sendLedgerError maps the ledger errors to a 4xx response.
+func (s *Server) sendLedgerError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidLedger), errors.Is(err, model.ErrInvalidInviteCode),
+		errors.Is(err, model.ErrLedgerMember), errors.Is(err, model.ErrLedgerCurrency):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrLedgerForbidden), errors.Is(err, model.ErrLedgerLastOwner):
+		s.sendJSONError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, model.ErrLedgerNotFound):
+		s.sendJSONError(w, "Ledger not found", http.StatusNotFound)
+	default:
+		s.logger.Errorf("Failed to %s ledger: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action+" ledger", http.StatusInternalServerError)
+	}
+}
+
+// sendLedgers replies with the ledgers of the user, reloaded so the active
+// ledger reflects the changes of the request.
+func (s *Server) sendLedgers(w http.ResponseWriter, tgID int64) {
+	user, err := s.repositories.Users.GetByTgID(tgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get user: %v", err)
+		s.sendJSONError(w, "Failed to get ledgers", http.StatusInternalServerError)
+		return
+	}
+
+	ledgers, err := s.repositories.Ledgers.List(tgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get ledgers: %v", err)
+		s.sendJSONError(w, "Failed to get ledgers", http.StatusInternalServerError)
+		return
+	}
+
+	resp := LedgersResponse{ActiveLedgerID: user.LedgerID, Ledgers: make([]LedgerDTO, len(ledgers))}
+	for i, l := range ledgers {
+		resp.Ledgers[i] = toLedgerDTO(l, user)
+	}
+	s.sendJSONSuccess(w, resp)
+}
+
+// handleAPILedgers returns the shared ledgers of the user.
+//
+//	@Summary		List shared ledgers
+//	@Description	Every ledger the user is a member of, with its members. The active ledger is the one new transactions, analytics and the budget refer to.
+//	@Tags			ledgers
+//	@Produce		json
+//	@Success		200	{object}	LedgersResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/ledgers [get]
+func (s *Server) handleAPILedgers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	s.sendLedgers(w, user.TgID)
+}
+
+// handleAPICreateLedger creates a shared ledger.
+//
+//	@Summary		Create shared ledger
+//	@Description	The ledger is in the base currency of the user, who becomes its owner and switches to it.
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		CreateLedgerRequest	true	"Ledger payload"
+//	@Success		200		{object}	LedgersResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/ledgers/create [post]
+func (s *Server) handleAPICreateLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateLedgerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	ledger := model.Ledger{Name: req.Name}
+	if err := s.repositories.Ledgers.Create(&ledger, *user); err != nil {
+		s.sendLedgerError(w, err, "create")
+		return
+	}
+
+	s.sendLedgers(w, user.TgID)
+}
+
+// handleAPIJoinLedger joins a shared ledger with its invite code.
+//
+//	@Summary		Join shared ledger
+//	@Description	The user joins as an editor and switches to the ledger, which must be in their base currency.
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		JoinLedgerRequest	true	"Invite code payload"
+//	@Success		200		{object}	LedgersResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/ledgers/join [post]
+func (s *Server) handleAPIJoinLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req JoinLedgerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.repositories.Ledgers.Join(*user, req.InviteCode); err != nil {
+		s.sendLedgerError(w, err, "join")
+		return
+	}
+
+	s.sendLedgers(w, user.TgID)
+}
+
+// handleAPILeaveLedger removes the user from a shared ledger.
+//
+//	@Summary		Leave shared ledger
+//	@Description	The transactions the user recorded stay in the ledger. The last owner can leave only if they are the last member, the ledger is then deleted.
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		LedgerIDRequest	true	"Ledger ID payload"
+//	@Success		200		{object}	LedgersResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/ledgers/leave [post]
+func (s *Server) handleAPILeaveLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req LedgerIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID == nil || *req.ID <= 0 {
+		s.sendJSONError(w, "Invalid ledger ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Ledgers.RemoveMember(user.TgID, *req.ID, user.TgID); err != nil {
+		s.sendLedgerError(w, err, "leave")
+		return
+	}
+
+	s.sendLedgers(w, user.TgID)
+}
+
+// handleAPISwitchLedger sets the active ledger of the user.
+//
+//	@Summary		Switch active ledger
+//	@Description	New transactions, analytics and the budget refer to the active ledger. A null ID switches back to the personal transactions.
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		LedgerIDRequest	true	"Ledger ID payload"
+//	@Success		200		{object}	LedgersResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/ledgers/switch [post]
+func (s *Server) handleAPISwitchLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req LedgerIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Ledgers.Switch(user.TgID, req.ID); err != nil {
+		s.sendLedgerError(w, err, "switch")
+		return
+	}
+
+	s.sendLedgers(w, user.TgID)
+}
+
+// handleAPIResetLedgerInvite replaces the invite code of a shared ledger.
+//
+//	@Summary		Reset ledger invite code
+//	@Description	Only owners can do it, the previous code stops working.
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		LedgerIDRequest	true	"Ledger ID payload"
+//	@Success		200		{object}	LedgersResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/ledgers/invite [post]
+func (s *Server) handleAPIResetLedgerInvite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req LedgerIDRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID == nil || *req.ID <= 0 {
+		s.sendJSONError(w, "Invalid ledger ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.repositories.Ledgers.ResetInviteCode(user.TgID, *req.ID); err != nil {
+		s.sendLedgerError(w, err, "update")
+		return
+	}
+
+	s.sendLedgers(w, user.TgID)
+}
+
+// handleAPISetLedgerMemberRole changes the role of a member of a shared ledger.
+//
+//	@Summary		Set ledger member role
+//	@Description	Only owners can do it and the ledger always keeps an owner. Editors record transactions in the ledger, viewers only see it.
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		LedgerMemberRequest	true	"Member and role payload"
+//	@Success		200		{object}	LedgersResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/ledgers/members/role [post]
+func (s *Server) handleAPISetLedgerMemberRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req LedgerMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 || req.TgID == 0 {
+		s.sendJSONError(w, "Invalid ledger member", http.StatusBadRequest)
+		return
+	}
+	role, ok := model.ParseLedgerRole(req.Role)
+	if !ok {
+		s.sendJSONError(w, "Invalid role", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Ledgers.SetRole(user.TgID, req.ID, req.TgID, role); err != nil {
+		s.sendLedgerError(w, err, "update")
+		return
+	}
+
+	s.sendLedgers(w, user.TgID)
+}
+
+// handleAPIRemoveLedgerMember removes a member from a shared ledger.
+//
+//	@Summary		Remove ledger member
+//	@Description	Only owners can remove other members. The transactions the member recorded stay in the ledger.
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		LedgerMemberRequest	true	"Member payload"
+//	@Success		200		{object}	LedgersResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/ledgers/members/remove [post]
+func (s *Server) handleAPIRemoveLedgerMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req LedgerMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 || req.TgID == 0 {
+		s.sendJSONError(w, "Invalid ledger member", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Ledgers.RemoveMember(user.TgID, req.ID, req.TgID); err != nil {
+		s.sendLedgerError(w, err, "update")
+		return
+	}
+
+	s.sendLedgers(w, user.TgID)
+}
@@ -0,0 +1,376 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toHoldingDTO(b model.HoldingBalance) HoldingDTO {
+	dto := HoldingDTO{
+		ID:        b.ID,
+		Name:      b.Name,
+		Kind:      string(b.Kind),
+		Liability: b.Kind.IsLiability(),
+		Currency:  string(b.Currency),
+		AccountID: b.AccountID,
+	}
+	if b.Known {
+		balance, converted := b.Balance, b.Converted
+		dto.Balance = &balance
+		dto.ConvertedBalance = &converted
+		dto.BalanceDate = b.Date.Format(dateLayout)
+	}
+	return dto
+}
+
+// sendHoldingError maps the holding validation errors to a 4xx response.
+func (s *Server) sendHoldingError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidHolding), errors.Is(err, model.ErrInvalidSnapshot):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrHoldingNotFound):
+		s.sendJSONError(w, "Holding not found", http.StatusNotFound)
+	case errors.Is(err, model.ErrHoldingExists):
+		s.sendJSONError(w, "Holding already exists", http.StatusConflict)
+	case errors.Is(err, model.ErrHoldingAccountSet):
+		s.sendJSONError(w, "The account is already tracked by another holding", http.StatusConflict)
+	case errors.Is(err, model.ErrHoldingLinked):
+		s.sendJSONError(w, "The balance of the holding comes from its account", http.StatusConflict)
+	default:
+		s.logger.Errorf("Failed to %s holding: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action+" holding", http.StatusInternalServerError)
+	}
+}
+
+// sendHolding responds with a holding of the user and its latest balance
+func (s *Server) sendHolding(w http.ResponseWriter, user *model.User, id int64, action string) {
+	balances, err := s.repositories.NetWorth.Balances(*user)
+	if err != nil {
+		s.sendHoldingError(w, err, action)
+		return
+	}
+	for _, b := range balances {
+		if b.ID == id {
+			s.sendJSONSuccess(w, toHoldingDTO(b))
+			return
+		}
+	}
+	s.sendHoldingError(w, model.ErrHoldingNotFound, action)
+}
+
+// handleAPIHoldings multiplexes GET/POST on /api/holdings.
+//
+//	@Summary		List assets and liabilities
+//	@Description	Every holding of the user with its latest balance, and the total assets, liabilities and net worth in the base currency.
+//	@Tags			networth
+//	@Produce		json
+//	@Success		200	{object}	HoldingsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/holdings [get]
+//
+//	@Summary		Create asset or liability
+//	@Description	With an account ID the balance is derived from the transactions of the account, otherwise it is recorded by hand, optionally starting from today's balance.
+//	@Tags			networth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		CreateHoldingRequest	true	"Holding payload"
+//	@Success		200		{object}	HoldingDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/holdings [post]
+func (s *Server) handleAPIHoldings(w http.ResponseWriter, r *http.Request) {
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.holdingsList(w, user)
+	case http.MethodPost:
+		s.holdingCreate(w, r, user)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) holdingsList(w http.ResponseWriter, user *model.User) {
+	balances, err := s.repositories.NetWorth.Balances(*user)
+	if err != nil {
+		s.sendHoldingError(w, err, "list")
+		return
+	}
+
+	total := balances.Total()
+	resp := HoldingsResponse{
+		Currency:    string(user.BaseCurrency),
+		Assets:      total.Assets,
+		Liabilities: total.Liabilities,
+		NetWorth:    total.NetWorth,
+		Holdings:    make([]HoldingDTO, len(balances)),
+	}
+	for i, b := range balances {
+		resp.Holdings[i] = toHoldingDTO(b)
+	}
+	s.sendJSONSuccess(w, resp)
+}
+
+func (s *Server) holdingCreate(w http.ResponseWriter, r *http.Request, user *model.User) {
+	var req CreateHoldingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.AccountID != nil && req.Balance != nil {
+		s.sendJSONError(w, "The balance of a holding tracking an account comes from the account", http.StatusBadRequest)
+		return
+	}
+
+	currency := model.CurrencyType(strings.ToUpper(req.Currency))
+	if currency == "" {
+		currency = user.BaseCurrency
+	}
+	if currency == "" {
+		currency = model.CurrencyEUR
+	}
+	holding := model.Holding{
+		TgID:      user.TgID,
+		Name:      req.Name,
+		Kind:      model.HoldingKind(req.Kind),
+		Currency:  currency,
+		AccountID: req.AccountID,
+	}
+	if kind, ok := model.ParseHoldingKind(req.Kind); ok {
+		holding.Kind = kind
+	}
+	if err := s.repositories.NetWorth.Create(&holding); err != nil {
+		s.sendHoldingError(w, err, "create")
+		return
+	}
+
+	if req.Balance != nil {
+		if _, err := s.repositories.NetWorth.Record(user.TgID, holding.ID, *req.Balance, user.Today()); err != nil {
+			s.sendHoldingError(w, err, "create")
+			return
+		}
+	}
+	s.sendHolding(w, user, holding.ID, "create")
+}
+
+// handleAPIHolding multiplexes PUT/DELETE on /api/holdings/{id}.
+//
+//	@Summary		Edit asset or liability
+//	@Description	Only non-nil fields are applied. The currency cannot be changed.
+//	@Tags			networth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Holding ID"
+//	@Param			body	body		EditHoldingRequest	true	"Holding payload"
+//	@Success		200		{object}	HoldingDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/holdings/{id} [put]
+//
+//	@Summary		Delete asset or liability
+//	@Description	The balances recorded are deleted with the holding, which no longer counts in the net worth of any month.
+//	@Tags			networth
+//	@Produce		json
+//	@Param			id	path		int	true	"Holding ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/holdings/{id} [delete]
+func (s *Server) handleAPIHolding(w http.ResponseWriter, r *http.Request) {
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		s.sendJSONError(w, "Invalid holding ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.holdingEdit(w, r, user, id)
+	case http.MethodDelete:
+		s.holdingDelete(w, user, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) holdingEdit(w http.ResponseWriter, r *http.Request, user *model.User, id int64) {
+	var req EditHoldingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	holding, err := s.repositories.NetWorth.Get(user.TgID, id)
+	if err != nil {
+		s.sendHoldingError(w, err, "edit")
+		return
+	}
+
+	if req.Name != nil {
+		holding.Name = *req.Name
+	}
+	if req.Kind != nil {
+		holding.Kind = model.HoldingKind(*req.Kind)
+		if kind, ok := model.ParseHoldingKind(*req.Kind); ok {
+			holding.Kind = kind
+		}
+	}
+
+	if err := s.repositories.NetWorth.Update(&holding); err != nil {
+		s.sendHoldingError(w, err, "edit")
+		return
+	}
+	s.sendHolding(w, user, holding.ID, "edit")
+}
+
+func (s *Server) holdingDelete(w http.ResponseWriter, user *model.User, id int64) {
+	if err := s.repositories.NetWorth.Delete(user.TgID, id); err != nil {
+		s.sendHoldingError(w, err, "delete")
+		return
+	}
+	s.sendJSONSuccess(w, MessageResponse{Message: "Holding deleted successfully"})
+}
+
+// handleAPIHoldingBalance records the balance of an asset or a liability.
+//
+//	@Summary		Record balance
+//	@Description	Records the balance of a holding on a day, today by default, replacing the one recorded that day. The balance of a holding tracking an account comes from the account and cannot be recorded.
+//	@Tags			networth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Holding ID"
+//	@Param			body	body		HoldingBalanceRequest	true	"Balance payload"
+//	@Success		200		{object}	HoldingDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/holdings/{id}/balances [post]
+func (s *Server) handleAPIHoldingBalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		s.sendJSONError(w, "Invalid holding ID", http.StatusBadRequest)
+		return
+	}
+
+	var req HoldingBalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	date := user.Today()
+	if req.Date != "" {
+		d, err := time.Parse(dateLayout, req.Date)
+		if err != nil {
+			s.sendJSONError(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if d.After(user.Today()) {
+			s.sendJSONError(w, "Date cannot be in the future", http.StatusBadRequest)
+			return
+		}
+		date = d
+	}
+
+	if _, err := s.repositories.NetWorth.Record(user.TgID, id, req.Balance, date); err != nil {
+		s.sendHoldingError(w, err, "record balance of")
+		return
+	}
+	s.sendHolding(w, user, id, "get")
+}
+
+// handleAPIAnalyticsNetWorth returns the trailing N-month net worth.
+//
+//	@Summary		Net worth over the trailing N months
+//	@Description	The assets, liabilities and net worth at the end of each month, as of today for the current one, from the latest balance of each holding known by then.
+//	@Tags			analytics
+//	@Produce		json
+//	@Param			months	query		int	false	"Number of trailing months (1..60, default 12)"
+//	@Success		200		{object}	NetWorthResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/analytics/networth [get]
+func (s *Server) handleAPIAnalyticsNetWorth(w http.ResponseWriter, r *http.Request) {
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	months := 12
+	if v := r.URL.Query().Get("months"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 60 {
+			months = n
+		}
+	}
+
+	series, err := s.repositories.NetWorth.Series(*user, months)
+	if err != nil {
+		s.logger.Errorf("analytics net worth: %v", err)
+		s.sendJSONError(w, "Failed to load net worth", http.StatusInternalServerError)
+		return
+	}
+
+	points := make([]NetWorthPoint, len(series))
+	for i, p := range series {
+		points[i] = NetWorthPoint{
+			Month:       p.Date.Format(monthLayout),
+			Date:        p.Date.Format(dateLayout),
+			Assets:      p.Assets,
+			Liabilities: p.Liabilities,
+			NetWorth:    p.NetWorth,
+		}
+	}
+
+	s.sendJSONSuccess(w, NetWorthResponse{
+		From:     points[0].Month,
+		To:       points[len(points)-1].Month,
+		Currency: string(user.BaseCurrency),
+		Points:   points,
+	})
+}
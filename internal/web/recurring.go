@@ -0,0 +1,326 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toRecurringRuleDTO(rule model.RecurringRule) RecurringRuleDTO {
+	dto := RecurringRuleDTO{
+		ID:          rule.ID,
+		Type:        string(rule.Type),
+		Category:    string(rule.Category),
+		Amount:      rule.Amount,
+		Currency:    string(rule.Currency),
+		Description: rule.Description,
+		AccountID:   rule.AccountID,
+		Frequency:   string(rule.Frequency),
+		DayOfMonth:  rule.DayOfMonth,
+		StartDate:   rule.StartDate.Format(dateLayout),
+		Schedule:    rule.Schedule(),
+		Paused:      rule.Paused,
+	}
+	if rule.EndDate != nil {
+		dto.EndDate = rule.EndDate.Format(dateLayout)
+	}
+	if rule.LastRunOn != nil {
+		dto.LastRunOn = rule.LastRunOn.Format(dateLayout)
+	}
+	if next, ok := rule.NextOccurrence(); ok {
+		dto.NextOccurrence = next.Format(dateLayout)
+	}
+	return dto
+}
+
+// sendRecurringError maps the recurring rule validation errors to a 4xx response.
+func (s *Server) sendRecurringError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidRecurringRule), errors.Is(err, model.ErrAccountArchived):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrAccountNotFound), errors.Is(err, model.ErrAccountNotOwned):
+		s.sendJSONError(w, "Invalid account", http.StatusBadRequest)
+	case errors.Is(err, model.ErrRecurringRuleNotFound):
+		s.sendJSONError(w, "Recurring rule not found", http.StatusNotFound)
+	default:
+		s.logger.Errorf("Failed to %s recurring rule: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action+" recurring rule", http.StatusInternalServerError)
+	}
+}
+
+// handleAPIRecurringRules returns the user's recurring rules.
+//
+//	@Summary		List recurring rules
+//	@Description	Every recurring rule is listed, paused and ended ones included, with its next occurrence.
+//	@Tags			recurring
+//	@Produce		json
+//	@Success		200	{object}	RecurringRulesResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/recurring [get]
+func (s *Server) handleAPIRecurringRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rules, err := s.repositories.Recurring.List(user.TgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get recurring rules: %v", err)
+		s.sendJSONError(w, "Failed to get recurring rules", http.StatusInternalServerError)
+		return
+	}
+
+	resp := RecurringRulesResponse{Rules: make([]RecurringRuleDTO, len(rules))}
+	for i, rule := range rules {
+		resp.Rules[i] = toRecurringRuleDTO(rule)
+	}
+
+	s.sendJSONSuccess(w, resp)
+}
+
+// handleAPICreateRecurringRule creates a new recurring rule.
+//
+//	@Summary		Create recurring rule
+//	@Description	The scheduler adds a transaction on every occurrence of the rule, starting from the start date. Occurrences in the past are added on its next run.
+//	@Tags			recurring
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		CreateRecurringRuleRequest	true	"Recurring rule payload"
+//	@Success		200		{object}	RecurringRuleDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/recurring/create [post]
+func (s *Server) handleAPICreateRecurringRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateRecurringRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	currency := user.BaseCurrency
+	if req.Currency != "" {
+		currency = model.CurrencyType(req.Currency)
+	}
+	if currency == "" {
+		currency = model.CurrencyEUR
+	}
+
+	rule := model.RecurringRule{
+		TgID:        user.TgID,
+		Type:        model.TransactionType(req.Type),
+		Category:    model.TransactionCategory(req.Category),
+		Amount:      req.Amount,
+		Currency:    currency,
+		Description: req.Description,
+		AccountID:   req.AccountID,
+		Frequency:   model.RecurringFrequency(req.Frequency),
+		DayOfMonth:  req.DayOfMonth,
+		StartDate:   user.Today(),
+	}
+
+	if req.StartDate != "" {
+		d, err := time.Parse(dateLayout, req.StartDate)
+		if err != nil {
+			s.sendJSONError(w, "Invalid start date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		rule.StartDate = d
+	}
+	if req.EndDate != "" {
+		d, err := time.Parse(dateLayout, req.EndDate)
+		if err != nil {
+			s.sendJSONError(w, "Invalid end date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		rule.EndDate = &d
+	}
+
+	if err := s.repositories.Recurring.Create(&rule); err != nil {
+		s.sendRecurringError(w, err, "create")
+		return
+	}
+
+	s.sendJSONSuccess(w, toRecurringRuleDTO(rule))
+}
+
+// handleAPIEditRecurringRule applies a partial update to a recurring rule.
+//
+//	@Summary		Edit recurring rule (partial)
+//	@Description	Update any field of a recurring rule or pause it. The transactions already added are not changed. Occurrences missed while the rule was paused are skipped when it is resumed.
+//	@Tags			recurring
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		EditRecurringRuleRequest	true	"Fields to update; only non-null fields are applied"
+//	@Success		200		{object}	RecurringRuleDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/recurring/edit [patch]
+func (s *Server) handleAPIEditRecurringRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req EditRecurringRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		s.sendJSONError(w, "Invalid recurring rule ID", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := s.repositories.Recurring.Get(user.TgID, req.ID)
+	if err != nil {
+		s.sendRecurringError(w, err, "edit")
+		return
+	}
+
+	if req.Type != nil {
+		rule.Type = model.TransactionType(*req.Type)
+	}
+	if req.Category != nil {
+		rule.Category = model.TransactionCategory(*req.Category)
+	}
+	if req.Amount != nil {
+		rule.Amount = *req.Amount
+	}
+	if req.Currency != nil {
+		rule.Currency = model.CurrencyType(*req.Currency)
+	}
+	if req.Description != nil {
+		rule.Description = *req.Description
+	}
+	if req.AccountID != nil {
+		rule.AccountID = req.AccountID
+		if *req.AccountID == 0 {
+			rule.AccountID = nil
+		}
+	}
+	if req.Frequency != nil {
+		rule.Frequency = model.RecurringFrequency(*req.Frequency)
+		rule.DayOfMonth = 0
+	}
+	if req.DayOfMonth != nil {
+		rule.DayOfMonth = *req.DayOfMonth
+	}
+	if req.StartDate != nil {
+		d, err := time.Parse(dateLayout, *req.StartDate)
+		if err != nil {
+			s.sendJSONError(w, "Invalid start date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		rule.StartDate = d
+	}
+	if req.EndDate != nil {
+		rule.EndDate = nil
+		if *req.EndDate != "" {
+			d, err := time.Parse(dateLayout, *req.EndDate)
+			if err != nil {
+				s.sendJSONError(w, "Invalid end date format. Use YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			rule.EndDate = &d
+		}
+	}
+
+	// Resuming skips the missed occurrences, so it happens after the update
+	resume := req.Paused != nil && !*req.Paused && rule.Paused
+	if req.Paused != nil && *req.Paused {
+		rule.Paused = true
+	}
+
+	if err := s.repositories.Recurring.Update(&rule); err != nil {
+		s.sendRecurringError(w, err, "edit")
+		return
+	}
+
+	if resume {
+		if rule, err = s.repositories.Recurring.Resume(user.TgID, rule.ID, user.Today()); err != nil {
+			s.sendRecurringError(w, err, "edit")
+			return
+		}
+	}
+
+	s.sendJSONSuccess(w, toRecurringRuleDTO(rule))
+}
+
+// handleAPIDeleteRecurringRule deletes a recurring rule.
+//
+//	@Summary		Delete recurring rule
+//	@Description	The transactions already added by the rule are kept.
+//	@Tags			recurring
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		DeleteRecurringRuleRequest	true	"Recurring rule ID payload"
+//	@Success		200		{object}	MessageResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/recurring/delete [delete]
+func (s *Server) handleAPIDeleteRecurringRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DeleteRecurringRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		s.sendJSONError(w, "Invalid recurring rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repositories.Recurring.Delete(user.TgID, req.ID); err != nil {
+		s.sendRecurringError(w, err, "delete")
+		return
+	}
+
+	s.sendJSONSuccess(w, MessageResponse{Message: "Recurring rule deleted successfully"})
+}
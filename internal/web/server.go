@@ -1,7 +1,9 @@
 package web
 
 import (
+	"context"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -18,24 +20,39 @@ import (
 )
 
 type Repositories struct {
-	Users        repository.Users
-	Transactions repository.Transactions
-	Auth         repository.Auth
-	WebAuthn     *repository.WebAuthn
-	Budgets      repository.Budgets
+	Users         repository.Users
+	Transactions  repository.Transactions
+	Auth          repository.Auth
+	WebAuthn      *repository.WebAuthn
+	Budgets       repository.Budgets
+	ExchangeRates repository.ExchangeRates
+	Categories    repository.Categories
+	Tags          repository.Tags
+	Accounts      repository.Accounts
+	Recurring     repository.Recurring
+	Ledgers       repository.Ledgers
+	Settlements   repository.Settlements
+	Attachments   repository.Attachments
+	Goals         repository.Goals
+	Envelopes     repository.Envelopes
+	NetWorth      repository.NetWorth
 }
 
 type Server struct {
 	logger         *logrus.Logger
 	repositories   Repositories
 	bot            *gotgbot.Bot
-	llm            ai.LLM
+	llm            *ai.LLM
 	loginLimiter   map[string]*rate.Limiter
 	loginLimiterMu sync.Mutex
 	emailService   *email.EmailService
+	// Telegram usernames allowed to use the admin endpoints
+	adminUsers map[string]struct{}
 }
 
-func NewServer(logger *logrus.Logger, repos Repositories, bot *gotgbot.Bot, llm ai.LLM, emailService *email.EmailService) *Server {
+func NewServer(logger *logrus.Logger, repos Repositories, bot *gotgbot.Bot, llm *ai.LLM, emailService *email.EmailService) *Server {
+	adminUsers := parseAdminUsers(os.Getenv("ADMIN_USERS"))
+
 	return &Server{
 		logger:         logger,
 		repositories:   repos,
@@ -44,7 +61,22 @@ func NewServer(logger *logrus.Logger, repos Repositories, bot *gotgbot.Bot, llm
 		loginLimiter:   make(map[string]*rate.Limiter),
 		loginLimiterMu: sync.Mutex{},
 		emailService:   emailService,
+		adminUsers:     adminUsers,
+	}
+}
+
+// parseAdminUsers returns the Telegram usernames of the comma separated list,
+// with or without their "@". The empty entries are skipped, as they would
+// match every user without a username.
+func parseAdminUsers(usernames string) map[string]struct{} {
+	adminUsers := make(map[string]struct{})
+	for u := range strings.SplitSeq(usernames, ",") {
+		u = strings.TrimPrefix(strings.TrimSpace(u), "@")
+		if u != "" {
+			adminUsers[u] = struct{}{}
+		}
 	}
+	return adminUsers
 }
 
 func (s *Server) Router() http.Handler {
@@ -134,6 +166,7 @@ func (s *Server) requireAuth(handler http.HandlerFunc) http.HandlerFunc {
 				}
 			}(tok.ID)
 			ctx := client.SetUserInContext(r.Context(), user)
+			ctx = context.WithValue(ctx, actorSourceKey{}, model.SourceAPIToken)
 			handler(w, r.WithContext(ctx))
 			return
 		}
@@ -153,6 +186,20 @@ func (s *Server) requireAuth(handler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// actorSourceKey is the context key of the source of the changes made with
+// a request, when it is not the web session
+type actorSourceKey struct{}
+
+// actor returns the actor of the changes made by the user with the request,
+// through their web session or an API token
+func actor(r *http.Request, user *model.User) model.Actor {
+	source, ok := r.Context().Value(actorSourceKey{}).(model.RevisionSource)
+	if !ok {
+		source = model.SourceWeb
+	}
+	return model.Actor{TgID: user.TgID, Source: source}
+}
+
 // extractBearerToken pulls the token from an `Authorization: Bearer <token>` header.
 // Returns (token, true) only when the scheme matches; an Authorization header with
 // a different scheme returns ("", false) so it can fall through to cookie auth.
@@ -53,3 +53,32 @@ func TestIsAPIRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestParseAdminUsers(t *testing.T) {
+	cases := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"alice", []string{"alice"}},
+		{"alice,", []string{"alice"}},
+		{"alice,,bob", []string{"alice", "bob"}},
+		{" @alice , bob ", []string{"alice", "bob"}},
+		{",, ,@", nil},
+	}
+	for _, c := range cases {
+		got := parseAdminUsers(c.value)
+		if len(got) != len(c.want) {
+			t.Errorf("parseAdminUsers(%q) = %v; want %v", c.value, got, c.want)
+			continue
+		}
+		for _, u := range c.want {
+			if _, ok := got[u]; !ok {
+				t.Errorf("parseAdminUsers(%q) = %v; want %v", c.value, got, c.want)
+			}
+		}
+		if _, ok := got[""]; ok {
+			t.Errorf("parseAdminUsers(%q) allows the users without a username", c.value)
+		}
+	}
+}
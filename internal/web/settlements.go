@@ -0,0 +1,207 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toSharedExpenseDTO(expense model.SharedExpense) SharedExpenseDTO {
+	dto := SharedExpenseDTO{
+		ID:            expense.ID,
+		TransactionID: expense.TransactionID,
+		Method:        string(expense.Method),
+		Amount:        expense.Amount,
+		Currency:      string(expense.Currency),
+		Shares:        make([]ExpenseShareDTO, len(expense.Shares)),
+	}
+	for i, s := range expense.Shares {
+		dto.Shares[i] = ExpenseShareDTO{TgID: s.TgID, Amount: s.Amount}
+	}
+	return dto
+}
+
+// sendSettlementError maps the shared expense and settlement errors to a 4xx response.
+func (s *Server) sendSettlementError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidSharedExpense), errors.Is(err, model.ErrInvalidSettlement),
+		errors.Is(err, model.ErrSettlementCurrency):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrParticipantNotFound):
+		s.sendJSONError(w, "Participant not found", http.StatusNotFound)
+	default:
+		s.logger.Errorf("Failed to %s: %v", action, err)
+		s.sendJSONError(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+// sendOwe replies with the balances of the user and the payments settling them.
+func (s *Server) sendOwe(w http.ResponseWriter, user model.User) {
+	balances, err := s.repositories.Settlements.Balances(user.TgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get balances: %v", err)
+		s.sendJSONError(w, "Failed to get balances", http.StatusInternalServerError)
+		return
+	}
+	transfers, err := s.repositories.Settlements.Plan(user.TgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get settlement plan: %v", err)
+		s.sendJSONError(w, "Failed to get balances", http.StatusInternalServerError)
+		return
+	}
+
+	resp := OweResponse{
+		Currency:  string(user.BaseCurrency),
+		Balances:  make([]CounterpartBalanceDTO, len(balances)),
+		Transfers: make([]TransferDTO, len(transfers)),
+	}
+	for i, b := range balances {
+		resp.Balances[i] = CounterpartBalanceDTO{TgID: b.Counterpart.TgID, Name: b.Counterpart.DisplayName(), Amount: b.Amount}
+	}
+	for i, t := range transfers {
+		resp.Transfers[i] = TransferDTO{
+			FromTgID: t.From.TgID,
+			FromName: t.From.DisplayName(),
+			ToTgID:   t.To.TgID,
+			ToName:   t.To.DisplayName(),
+			Amount:   t.Amount,
+		}
+	}
+	s.sendJSONSuccess(w, resp)
+}
+
+// handleAPIOwe returns the balances of the user with the other users.
+//
+//	@Summary		Balances with other users
+//	@Description	What each counterpart owes the user (negative when the user owes them) from the shared expenses and the settlements, and the fewest payments settling the balances of the user and their counterparts.
+//	@Tags			settlements
+//	@Produce		json
+//	@Success		200	{object}	OweResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/owe [get]
+func (s *Server) handleAPIOwe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	s.sendOwe(w, *user)
+}
+
+// handleAPIShareExpense shares an expense of the user with other users.
+//
+//	@Summary		Share an expense
+//	@Description	Marks an expense paid by the user as shared with the participants, who owe them their share. Shares are equal, percentages or exact amounts in the base currency; the user gets what is left and absorbs the rounding.
+//	@Tags			settlements
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		ShareExpenseRequest	true	"Shared expense payload"
+//	@Success		200		{object}	SharedExpenseDTO
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/owe/share [post]
+func (s *Server) handleAPIShareExpense(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ShareExpenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	inputs := make([]model.ShareInput, len(req.Participants))
+	for i, p := range req.Participants {
+		inputs[i] = model.ShareInput{TgID: p.TgID, Value: p.Value}
+		if p.TgID != 0 {
+			continue
+		}
+
+		participant, ok, err := s.repositories.Users.GetByUsername(strings.TrimPrefix(p.Username, "@"))
+		if err != nil {
+			s.sendSettlementError(w, err, "share expense")
+			return
+		}
+		if !ok {
+			s.sendSettlementError(w, model.ErrParticipantNotFound, "share expense")
+			return
+		}
+		inputs[i].TgID = participant.TgID
+	}
+
+	expense, err := s.repositories.Settlements.Share(user.TgID, req.TransactionID, model.SplitMethod(req.Method), inputs)
+	if err != nil {
+		s.sendSettlementError(w, err, "share expense")
+		return
+	}
+
+	s.sendJSONSuccess(w, toSharedExpenseDTO(expense))
+}
+
+// handleAPISettle records a payment between the user and a counterpart.
+//
+//	@Summary		Settle up
+//	@Description	Records a payment made by the user to the counterpart, or received from them, along with the matching expense and income of both users. Returns the updated balances.
+//	@Tags			settlements
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		SettleRequest	true	"Settlement payload"
+//	@Success		200		{object}	OweResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/settle [post]
+func (s *Server) handleAPISettle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req SettleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	settlement := model.Settlement{FromTgID: user.TgID, ToTgID: req.CounterpartTgID, Amount: req.Amount, Date: user.Today(), CreatedBy: user.TgID}
+	if req.Received {
+		settlement.FromTgID, settlement.ToTgID = req.CounterpartTgID, user.TgID
+	}
+	if err := s.repositories.Settlements.As(actor(r, user)).Settle(&settlement); err != nil {
+		s.sendSettlementError(w, err, "settle")
+		return
+	}
+
+	s.sendOwe(w, *user)
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+
+	"cashout/internal/client"
+)
+
+// handleAPITags returns the names of the user's tags.
+//
+//	@Summary		List tags
+//	@Tags			tags
+//	@Produce		json
+//	@Success		200	{object}	TagsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/tags [get]
+func (s *Server) handleAPITags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tags, err := s.repositories.Tags.List(user.TgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get tags: %v", err)
+		s.sendJSONError(w, "Failed to get tags", http.StatusInternalServerError)
+		return
+	}
+
+	resp := TagsResponse{Tags: make([]string, len(tags))}
+	for i, t := range tags {
+		resp.Tags[i] = t.Name
+	}
+
+	s.sendJSONSuccess(w, resp)
+}
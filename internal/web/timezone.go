@@ -0,0 +1,82 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toTimezoneResponse(user model.User) TimezoneResponse {
+	return TimezoneResponse{
+		Timezone: user.Location().String(),
+		Today:    user.Today().Format(dateLayout),
+		Now:      user.Now().Format(time.RFC3339),
+	}
+}
+
+// handleAPITimezone multiplexes GET/PUT on /api/timezone.
+//
+//	@Summary		Get the timezone
+//	@Description	The user's IANA timezone, which sets the day of new transactions, the boundaries of the weeks and months and when the recaps are delivered.
+//	@Tags			settings
+//	@Produce		json
+//	@Success		200	{object}	TimezoneResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/timezone [get]
+//
+//	@Summary		Set the timezone
+//	@Description	Set the user's IANA timezone, e.g. Europe/Rome. The upcoming recaps are moved to the morning of the new timezone.
+//	@Tags			settings
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		TimezoneRequest	true	"IANA timezone"
+//	@Success		200		{object}	TimezoneResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/timezone [put]
+func (s *Server) handleAPITimezone(w http.ResponseWriter, r *http.Request) {
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.sendJSONSuccess(w, toTimezoneResponse(*user))
+	case http.MethodPost, http.MethodPut:
+		s.timezoneSet(w, r, user)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) timezoneSet(w http.ResponseWriter, r *http.Request, user *model.User) {
+	var req TimezoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	timezone, err := s.repositories.Users.SetTimezone(user.TgID, req.Timezone)
+	if errors.Is(err, model.ErrInvalidTimezone) {
+		s.sendJSONError(w, "Invalid timezone, use an IANA name like Europe/Rome", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.logger.Errorf("Failed to set timezone: %v", err)
+		s.sendJSONError(w, "Failed to set timezone", http.StatusInternalServerError)
+		return
+	}
+
+	updated := *user
+	updated.Timezone = timezone
+	s.sendJSONSuccess(w, toTimezoneResponse(updated))
+}
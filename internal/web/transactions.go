@@ -3,8 +3,10 @@ package web
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,28 +20,59 @@ const dateLayout = "2006-01-02"
 
 func toTransactionDTO(tx model.Transaction) TransactionDTO {
 	return TransactionDTO{
-		ID:          tx.ID,
-		Date:        tx.Date,
-		Category:    string(tx.Category),
-		Description: tx.Description,
-		Amount:      tx.Amount,
-		Type:        string(tx.Type),
+		ID:               tx.ID,
+		Date:             tx.Date,
+		Category:         string(tx.Category),
+		Description:      tx.Description,
+		Amount:           tx.Amount,
+		Currency:         string(tx.Currency),
+		OriginalAmount:   tx.OriginalAmount,
+		OriginalCurrency: string(tx.OriginalCurrency),
+		Type:             string(tx.Type),
+		Tags:             tx.TagNames(),
+		AccountID:        tx.AccountID,
+		ToAccountID:      tx.ToAccountID,
+		RecurringRuleID:  tx.RecurringRuleID,
+		SettlementID:     tx.SettlementID,
+		Splits:           toSplitDTOs(tx.Splits),
 	}
 }
 
-func isIncomeCategory(cat string) bool {
-	for _, c := range model.GetIncomeCategories() {
-		if c == cat {
-			return true
+func toSplitDTOs(splits []model.TransactionSplit) []SplitDTO {
+	if len(splits) == 0 {
+		return nil
+	}
+	dtos := make([]SplitDTO, len(splits))
+	for i, s := range splits {
+		dtos[i] = SplitDTO{
+			Category:       string(s.Category),
+			Amount:         s.Amount,
+			OriginalAmount: s.OriginalAmount,
+			Description:    s.Description,
 		}
 	}
-	return false
+	return dtos
+}
+
+// checkTransactionCategory validates the category of a transaction of the
+// given type, returning the error message to send or "" when it is valid.
+func checkTransactionCategory(categories model.Categories, txType model.TransactionType, cat string) string {
+	category, ok := categories.Find(cat)
+	switch {
+	case !ok:
+		return "Invalid category"
+	case category.Type != txType:
+		return "Cannot change between income and expense categories"
+	case category.Archived:
+		return "Category is archived"
+	}
+	return ""
 }
 
 // handleAPIEditTransaction applies a partial update to a transaction.
 //
 //	@Summary		Edit transaction (partial)
-//	@Description	Update one or more fields of an existing transaction. Type cannot be changed; category must remain within the same type (Income↔Expense swaps are rejected).
+//	@Description	Update one or more fields of an existing transaction. Type cannot be changed; category must remain within the same type (Income↔Expense swaps are rejected). Tags, when given, replace all the tags of the transaction. The account of transfers cannot be changed. Splits, when given, replace the split lines of the transaction: they must sum to its amount and an empty list removes the split. The category and the amount of a split transaction can only be changed along with its lines.
 //	@Tags			transactions
 //	@Accept			json
 //	@Produce		json
@@ -84,20 +117,26 @@ func (s *Server) handleAPIEditTransaction(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if req.Category == nil && req.Amount == nil && req.Description == nil && req.Date == nil {
+	if req.Category == nil && req.Amount == nil && req.Description == nil && req.Date == nil && req.Tags == nil && req.AccountID == nil && req.Splits == nil {
 		s.sendJSONError(w, "No fields to update", http.StatusBadRequest)
 		return
 	}
 
+	if tx.IsSplit() && req.Splits == nil && (req.Category != nil || req.Amount != nil) {
+		s.sendJSONError(w, "The category and the amount of a split transaction change along with its splits", http.StatusBadRequest)
+		return
+	}
+
 	if req.Category != nil {
 		cat := *req.Category
-		if !model.IsValidTransactionCategory(cat) {
-			s.sendJSONError(w, "Invalid category", http.StatusBadRequest)
+		categories, err := s.repositories.Categories.List(user.TgID)
+		if err != nil {
+			s.logger.Errorf("Failed to get categories: %v", err)
+			s.sendJSONError(w, "Failed to update transaction", http.StatusInternalServerError)
 			return
 		}
-		txIsIncome := tx.Type == model.TypeIncome
-		if txIsIncome != isIncomeCategory(cat) {
-			s.sendJSONError(w, "Cannot change between income and expense categories", http.StatusBadRequest)
+		if msg := checkTransactionCategory(categories, tx.Type, cat); msg != "" {
+			s.sendJSONError(w, msg, http.StatusBadRequest)
 			return
 		}
 		tx.Category = model.TransactionCategory(cat)
@@ -108,7 +147,7 @@ func (s *Server) handleAPIEditTransaction(w http.ResponseWriter, r *http.Request
 			s.sendJSONError(w, "Amount must be greater than 0", http.StatusBadRequest)
 			return
 		}
-		tx.Amount = *req.Amount
+		tx.SetBaseAmount(*req.Amount)
 	}
 
 	if req.Description != nil {
@@ -126,26 +165,67 @@ func (s *Server) handleAPIEditTransaction(w http.ResponseWriter, r *http.Request
 			s.sendJSONError(w, "Invalid date format (expected YYYY-MM-DD)", http.StatusBadRequest)
 			return
 		}
-		if d.After(time.Now()) {
+		if d.After(user.Today()) {
 			s.sendJSONError(w, "Date cannot be in the future", http.StatusBadRequest)
 			return
 		}
 		tx.Date = d
 	}
 
-	if err := s.repositories.Transactions.Update(&tx); err != nil {
+	// The fields, the account, the splits and the tags are stored at once,
+	// as a single change in the history of the transaction
+	var edit repository.TransactionEdit
+	if req.Tags != nil {
+		tags, err := model.NormalizeTagNames(*req.Tags)
+		if err != nil {
+			s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		edit.Tags = &tags
+	}
+
+	if req.AccountID != nil {
+		tx.AccountID = req.AccountID
+		if *req.AccountID == 0 {
+			tx.AccountID = nil
+		}
+	}
+
+	if req.Splits != nil {
+		lines := make([]model.TransactionSplit, len(*req.Splits))
+		for i, l := range *req.Splits {
+			lines[i] = model.TransactionSplit{
+				Category:    model.TransactionCategory(l.Category),
+				Amount:      l.Amount,
+				Description: l.Description,
+			}
+		}
+		edit.Splits = &lines
+	}
+
+	err = s.repositories.Transactions.As(actor(r, user)).Edit(&tx, edit)
+	switch {
+	case errors.Is(err, model.ErrInvalidSplit):
+		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, model.ErrInvalidAccount), errors.Is(err, model.ErrInvalidTransfer), errors.Is(err, model.ErrAccountArchived),
+		errors.Is(err, model.ErrAccountNotFound), errors.Is(err, model.ErrAccountNotOwned):
+		s.sendAccountError(w, err, "update transaction of")
+		return
+	case err != nil:
 		s.logger.Errorf("Failed to update transaction: %v", err)
 		s.sendJSONError(w, "Failed to update transaction", http.StatusInternalServerError)
 		return
 	}
 
+	s.notifyBudgets(*user, tx)
 	s.sendJSONSuccess(w, toTransactionDTO(tx))
 }
 
 // handleAPICloneTransaction duplicates an existing transaction with today's date.
 //
 //	@Summary		Clone transaction
-//	@Description	Duplicate an existing transaction; the new transaction copies type, category, amount, description and currency, with date set to today.
+//	@Description	Duplicate an existing transaction; the new transaction copies type, category, amount, description, currency, tags and split lines, with date set to today. Foreign currency amounts are converted again at the current exchange rate.
 //	@Tags			transactions
 //	@Accept			json
 //	@Produce		json
@@ -191,39 +271,46 @@ func (s *Server) handleAPICloneTransaction(w http.ResponseWriter, r *http.Reques
 	}
 
 	clone := model.Transaction{
-		TgID:        user.TgID,
-		Date:        time.Now(),
-		Type:        source.Type,
-		Category:    source.Category,
-		Amount:      source.Amount,
-		Currency:    source.Currency,
-		Description: source.Description,
-	}
-
-	if err := s.repositories.Transactions.Add(&clone); err != nil {
+		TgID:             user.TgID,
+		Date:             user.Today(),
+		Type:             source.Type,
+		Category:         source.Category,
+		Amount:           source.Amount,
+		Currency:         source.Currency,
+		OriginalAmount:   source.OriginalAmount,
+		OriginalCurrency: source.OriginalCurrency,
+		Description:      source.Description,
+		Tags:             model.TagsFromNames(user.TgID, source.TagNames()),
+		Splits:           source.CopySplits(),
+		AccountID:        source.AccountID,
+		ToAccountID:      source.ToAccountID,
+	}
+
+	if err := s.repositories.Transactions.As(actor(r, user)).Add(&clone); err != nil {
 		s.logger.Errorf("Failed to clone transaction: %v", err)
 		s.sendJSONError(w, "Failed to clone transaction", http.StatusInternalServerError)
 		return
 	}
 
+	s.notifyBudgets(*user, clone)
 	s.sendJSONSuccess(w, toTransactionDTO(clone))
 }
 
-// buildSearchFilter validates inputs and assembles a repository.TransactionFilter.
+// buildSearchFilter validates inputs against the user's categories and assembles a repository.TransactionFilter.
 // Returns (filter, httpStatusOnError, errMessageOnError).
-func buildSearchFilter(query, category, txType, dateFrom, dateTo string, amountMin, amountMax *float64) (repository.TransactionFilter, int, string) {
+func buildSearchFilter(categories model.Categories, query, category, txType, dateFrom, dateTo string, amountMin, amountMax *model.Money, tags, excludeTags []string) (repository.TransactionFilter, int, string) {
 	var f repository.TransactionFilter
 	f.Query = strings.TrimSpace(query)
 
 	if category != "" && category != "all" {
-		if !model.IsValidTransactionCategory(category) {
+		if _, ok := categories.Find(category); !ok {
 			return f, http.StatusBadRequest, "Invalid category"
 		}
 		f.Category = category
 	}
 
 	if txType != "" {
-		if txType != string(model.TypeIncome) && txType != string(model.TypeExpense) {
+		if !slices.Contains(model.GetTransactionTypes(), txType) {
 			return f, http.StatusBadRequest, "Invalid transaction type"
 		}
 		f.Type = model.TransactionType(txType)
@@ -265,13 +352,26 @@ func buildSearchFilter(query, category, txType, dateFrom, dateTo string, amountM
 		return f, http.StatusBadRequest, "amountMin must be <= amountMax"
 	}
 
+	var err error
+	if f.Tags, err = model.NormalizeTagNames(tags); err != nil {
+		return f, http.StatusBadRequest, err.Error()
+	}
+	if f.ExcludeTags, err = model.NormalizeTagNames(excludeTags); err != nil {
+		return f, http.StatusBadRequest, err.Error()
+	}
+	for _, tag := range f.Tags {
+		if slices.Contains(f.ExcludeTags, tag) {
+			return f, http.StatusBadRequest, fmt.Sprintf("Tag %q cannot be both included and excluded", tag)
+		}
+	}
+
 	return f, 0, ""
 }
 
 // handleAPISearchTransactions returns transactions matching a filter set.
 //
 //	@Summary		Search transactions
-//	@Description	Search a user's transactions by any combination of text, category, type, date range, amount range. Returns paginated results with a total count.
+//	@Description	Search a user's transactions by any combination of text, category, type, date range, amount range and tags. Returns paginated results with a total count.
 //	@Tags			transactions
 //	@Accept			json
 //	@Produce		json
@@ -300,11 +400,25 @@ func (s *Server) handleAPISearchTransactions(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	filter, code, msg := buildSearchFilter(req.Query, req.Category, req.Type, req.DateFrom, req.DateTo, req.AmountMin, req.AmountMax)
+	categories, err := s.repositories.Categories.List(user.TgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get categories: %v", err)
+		s.sendJSONError(w, "Failed to get categories", http.StatusInternalServerError)
+		return
+	}
+
+	filter, code, msg := buildSearchFilter(categories, req.Query, req.Category, req.Type, req.DateFrom, req.DateTo, req.AmountMin, req.AmountMax, req.Tags, req.ExcludeTags)
 	if code != 0 {
 		s.sendJSONError(w, msg, code)
 		return
 	}
+	if req.AccountID != nil {
+		if _, err := s.repositories.Accounts.Get(user.TgID, *req.AccountID); err != nil {
+			s.sendAccountError(w, err, "search transactions of")
+			return
+		}
+		filter.AccountID = req.AccountID
+	}
 
 	offset := req.Offset
 	if offset < 0 {
@@ -338,13 +452,28 @@ func (s *Server) handleAPISearchTransactions(w http.ResponseWriter, r *http.Requ
 	})
 }
 
-// parseFloatQuery returns a pointer to the parsed value, or nil if absent.
-func parseFloatQuery(r *http.Request, key string) (*float64, error) {
+// parseListQuery returns the comma separated values of a query parameter, or nil if absent.
+func parseListQuery(r *http.Request, key string) []string {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return nil
+	}
+	var values []string
+	for v := range strings.SplitSeq(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseMoneyQuery returns a pointer to the parsed amount, or nil if absent.
+func parseMoneyQuery(r *http.Request, key string) (*model.Money, error) {
 	raw := r.URL.Query().Get(key)
 	if raw == "" {
 		return nil, nil
 	}
-	v, err := strconv.ParseFloat(raw, 64)
+	v, err := model.ParseMoney(raw)
 	if err != nil {
 		return nil, fmt.Errorf("invalid %s", key)
 	}
@@ -354,7 +483,7 @@ func parseFloatQuery(r *http.Request, key string) (*float64, error) {
 // handleAPIExportTransactions streams a CSV of the user's transactions.
 //
 //	@Summary		Export transactions as CSV
-//	@Description	Stream a CSV containing all transactions matching the optional filter set. Columns: date,type,category,amount,currency,description,created_at,updated_at.
+//	@Description	Stream a CSV containing all transactions matching the optional filter set. Columns: tg_id,date,type,category,amount,currency,original_amount,original_currency,description,created_at,updated_at,tags.
 //	@Tags			transactions
 //	@Produce		text/csv
 //	@Param			query		query		string	false	"Substring match on description (case-insensitive)"
@@ -364,6 +493,8 @@ func parseFloatQuery(r *http.Request, key string) (*float64, error) {
 //	@Param			dateTo		query		string	false	"Inclusive upper bound (YYYY-MM-DD)"
 //	@Param			amountMin	query		number	false	"Inclusive lower bound on amount"
 //	@Param			amountMax	query		number	false	"Inclusive upper bound on amount"
+//	@Param			tags		query		string	false	"Comma separated tags, all of them must be on the transaction"
+//	@Param			excludeTags	query		string	false	"Comma separated tags, none of them must be on the transaction"
 //	@Success		200			{file}		file
 //	@Failure		400			{object}	ErrorResponse
 //	@Failure		401			{object}	ErrorResponse
@@ -383,18 +514,25 @@ func (s *Server) handleAPIExportTransactions(w http.ResponseWriter, r *http.Requ
 	}
 
 	q := r.URL.Query()
-	amountMin, err := parseFloatQuery(r, "amountMin")
+	amountMin, err := parseMoneyQuery(r, "amountMin")
 	if err != nil {
 		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	amountMax, err := parseFloatQuery(r, "amountMax")
+	amountMax, err := parseMoneyQuery(r, "amountMax")
 	if err != nil {
 		s.sendJSONError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	filter, code, msg := buildSearchFilter(q.Get("query"), q.Get("category"), q.Get("type"), q.Get("dateFrom"), q.Get("dateTo"), amountMin, amountMax)
+	categories, err := s.repositories.Categories.List(user.TgID)
+	if err != nil {
+		s.logger.Errorf("Failed to get categories: %v", err)
+		s.sendJSONError(w, "Failed to get categories", http.StatusInternalServerError)
+		return
+	}
+
+	filter, code, msg := buildSearchFilter(categories, q.Get("query"), q.Get("category"), q.Get("type"), q.Get("dateFrom"), q.Get("dateTo"), amountMin, amountMax, parseListQuery(r, "tags"), parseListQuery(r, "excludeTags"))
 	if code != 0 {
 		s.sendJSONError(w, msg, code)
 		return
@@ -408,14 +546,14 @@ func (s *Server) handleAPIExportTransactions(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	filename := fmt.Sprintf("cashout_export_%s.csv", time.Now().Format(dateLayout))
+	filename := fmt.Sprintf("cashout_export_%s.csv", user.Today().Format(dateLayout))
 	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
 
 	cw := csv.NewWriter(w)
 	defer cw.Flush()
 
-	header := []string{"tg_id", "date", "type", "category", "amount", "currency", "description", "created_at", "updated_at"}
+	header := []string{"tg_id", "date", "type", "category", "amount", "currency", "original_amount", "original_currency", "description", "created_at", "updated_at", "tags"}
 	if err := cw.Write(header); err != nil {
 		s.logger.Errorf("Failed to write CSV header: %v", err)
 		return
@@ -426,11 +564,14 @@ func (s *Server) handleAPIExportTransactions(w http.ResponseWriter, r *http.Requ
 			tx.Date.Format(dateLayout),
 			string(tx.Type),
 			string(tx.Category),
-			strconv.FormatFloat(tx.Amount, 'f', 2, 64),
+			tx.Amount.String(),
 			string(tx.Currency),
+			tx.OriginalAmount.String(),
+			string(tx.OriginalCurrency),
 			tx.Description,
 			tx.CreatedAt.Format(time.RFC3339),
 			tx.UpdatedAt.Format(time.RFC3339),
+			strings.Join(tx.TagNames(), " "),
 		}
 		if err := cw.Write(row); err != nil {
 			s.logger.Errorf("Failed to write CSV row: %v", err)
@@ -2,27 +2,37 @@ package web
 
 import (
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"cashout/internal/model"
 )
 
-func TestIsIncomeCategory(t *testing.T) {
+func TestCheckTransactionCategory(t *testing.T) {
+	categories := append(model.DefaultCategories(1),
+		model.Category{Name: "Kids", Type: model.TypeExpense},
+		model.Category{Name: "Old", Type: model.TypeExpense, Archived: true},
+	)
+
 	cases := []struct {
-		cat  string
-		want bool
+		txType model.TransactionType
+		cat    string
+		want   string
 	}{
-		{string(model.CategorySalary), true},
-		{string(model.CategoryOtherIncomes), true},
-		{string(model.CategoryGrocery), false},
-		{string(model.CategoryTravel), false},
-		{"NotACategory", false},
-		{"", false},
+		{model.TypeIncome, string(model.CategorySalary), ""},
+		{model.TypeIncome, string(model.CategoryOtherIncomes), ""},
+		{model.TypeExpense, string(model.CategoryGrocery), ""},
+		{model.TypeExpense, "Kids", ""},
+		{model.TypeIncome, string(model.CategoryTravel), "Cannot change between income and expense categories"},
+		{model.TypeExpense, string(model.CategorySalary), "Cannot change between income and expense categories"},
+		{model.TypeExpense, "Old", "Category is archived"},
+		{model.TypeExpense, "NotACategory", "Invalid category"},
+		{model.TypeExpense, "", "Invalid category"},
 	}
 	for _, c := range cases {
-		if got := isIncomeCategory(c.cat); got != c.want {
-			t.Errorf("isIncomeCategory(%q) = %v; want %v", c.cat, got, c.want)
+		if got := checkTransactionCategory(categories, c.txType, c.cat); got != c.want {
+			t.Errorf("checkTransactionCategory(%s, %q) = %q; want %q", c.txType, c.cat, got, c.want)
 		}
 	}
 }
@@ -35,72 +45,102 @@ func TestToTransactionDTO(t *testing.T) {
 		Date:        d,
 		Type:        model.TypeExpense,
 		Category:    model.CategoryGrocery,
-		Amount:      19.90,
+		Amount:      model.NewMoney(19.90),
 		Description: "weekly shop",
+		Tags:        []model.Tag{{Name: "home"}, {Name: "shared"}},
 	}
 	dto := toTransactionDTO(tx)
 	if dto.ID != 7 || dto.Date != d || dto.Category != string(model.CategoryGrocery) ||
-		dto.Description != "weekly shop" || dto.Amount != 19.90 || dto.Type != string(model.TypeExpense) {
+		dto.Description != "weekly shop" || dto.Amount != model.NewMoney(19.90) || dto.Type != string(model.TypeExpense) {
 		t.Fatalf("unexpected DTO: %+v", dto)
 	}
+	if len(dto.Tags) != 2 || dto.Tags[0] != "home" || dto.Tags[1] != "shared" {
+		t.Fatalf("unexpected DTO tags: %v", dto.Tags)
+	}
+	if dto.Splits != nil {
+		t.Fatalf("expected no splits, got %v", dto.Splits)
+	}
+
+	tx.Splits = []model.TransactionSplit{
+		{Category: model.CategoryGrocery, Amount: model.NewMoney(12.40), OriginalAmount: model.NewMoney(12.40)},
+		{Category: model.CategoryToiletry, Amount: model.NewMoney(7.50), OriginalAmount: model.NewMoney(7.50), Description: "shampoo"},
+	}
+	dto = toTransactionDTO(tx)
+	if len(dto.Splits) != 2 || dto.Splits[1].Category != string(model.CategoryToiletry) ||
+		dto.Splits[1].Amount != model.NewMoney(7.50) || dto.Splits[1].Description != "shampoo" {
+		t.Fatalf("unexpected DTO splits: %+v", dto.Splits)
+	}
 }
 
-func TestParseFloatQuery(t *testing.T) {
+func TestParseMoneyQuery(t *testing.T) {
 	t.Run("absent", func(t *testing.T) {
 		r := httptest.NewRequest("GET", "/x", nil)
-		v, err := parseFloatQuery(r, "amountMin")
+		v, err := parseMoneyQuery(r, "amountMin")
 		if err != nil || v != nil {
 			t.Fatalf("want nil/nil, got %v, %v", v, err)
 		}
 	})
 	t.Run("valid", func(t *testing.T) {
 		r := httptest.NewRequest("GET", "/x?amountMin=12.5", nil)
-		v, err := parseFloatQuery(r, "amountMin")
-		if err != nil || v == nil || *v != 12.5 {
+		v, err := parseMoneyQuery(r, "amountMin")
+		if err != nil || v == nil || *v != model.NewMoney(12.5) {
 			t.Fatalf("want 12.5, got %v, %v", v, err)
 		}
 	})
 	t.Run("invalid", func(t *testing.T) {
 		r := httptest.NewRequest("GET", "/x?amountMin=oops", nil)
-		_, err := parseFloatQuery(r, "amountMin")
+		_, err := parseMoneyQuery(r, "amountMin")
 		if err == nil {
 			t.Fatalf("want error")
 		}
 	})
 }
 
-func ptrFloat(v float64) *float64 { return &v }
+func ptrMoney(v float64) *model.Money {
+	m := model.NewMoney(v)
+	return &m
+}
 
 func TestBuildSearchFilter(t *testing.T) {
 	type tc struct {
-		name      string
-		query     string
-		category  string
-		txType    string
-		dateFrom  string
-		dateTo    string
-		amountMin *float64
-		amountMax *float64
-		wantCode  int
+		name        string
+		query       string
+		category    string
+		txType      string
+		dateFrom    string
+		dateTo      string
+		amountMin   *model.Money
+		amountMax   *model.Money
+		tags        []string
+		excludeTags []string
+		wantCode    int
 	}
 
+	categories := append(model.DefaultCategories(1), model.Category{Name: "Kids", Type: model.TypeExpense})
+
 	cases := []tc{
 		{name: "empty is fine"},
 		{name: "category all is no-op", category: "all"},
 		{name: "invalid category", category: "Bogus", wantCode: 400},
 		{name: "valid category", category: string(model.CategoryGrocery)},
+		{name: "valid custom category", category: "Kids"},
 		{name: "invalid type", txType: "Maybe", wantCode: 400},
 		{name: "valid income type", txType: string(model.TypeIncome)},
+		{name: "valid transfer type", txType: string(model.TypeTransfer)},
 		{name: "invalid dateFrom", dateFrom: "21/05/2026", wantCode: 400},
 		{name: "valid dateFrom", dateFrom: "2026-05-21"},
 		{name: "dateFrom after dateTo", dateFrom: "2026-05-21", dateTo: "2026-05-01", wantCode: 400},
-		{name: "amountMin negative", amountMin: ptrFloat(-1), wantCode: 400},
-		{name: "amountMin > amountMax", amountMin: ptrFloat(50), amountMax: ptrFloat(10), wantCode: 400},
-		{name: "valid amount range", amountMin: ptrFloat(5), amountMax: ptrFloat(100)},
+		{name: "amountMin negative", amountMin: ptrMoney(-1), wantCode: 400},
+		{name: "amountMin > amountMax", amountMin: ptrMoney(50), amountMax: ptrMoney(10), wantCode: 400},
+		{name: "valid amount range", amountMin: ptrMoney(5), amountMax: ptrMoney(100)},
+		{name: "valid tags", tags: []string{"#Work", "travel"}, excludeTags: []string{"reimbursed"}},
+		{name: "invalid tag", tags: []string{"not a tag"}, wantCode: 400},
+		{name: "invalid excluded tag", excludeTags: []string{"#"}, wantCode: 400},
+		{name: "tag both included and excluded", tags: []string{"work"}, excludeTags: []string{"#WORK"}, wantCode: 400},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			_, code, msg := buildSearchFilter(c.query, c.category, c.txType, c.dateFrom, c.dateTo, c.amountMin, c.amountMax)
+			_, code, msg := buildSearchFilter(categories, c.query, c.category, c.txType, c.dateFrom, c.dateTo, c.amountMin, c.amountMax, c.tags, c.excludeTags)
 			if code != c.wantCode {
 				t.Fatalf("code=%d msg=%q; want code=%d", code, msg, c.wantCode)
 			}
@@ -108,8 +148,21 @@ func TestBuildSearchFilter(t *testing.T) {
 	}
 }
 
+func TestBuildSearchFilter_NormalizesTags(t *testing.T) {
+	f, code, msg := buildSearchFilter(model.DefaultCategories(1), "", "", "", "", "", nil, nil, []string{"#Work", "work", "Travel"}, []string{"#Reimbursed"})
+	if code != 0 {
+		t.Fatalf("code=%d msg=%q", code, msg)
+	}
+	if strings.Join(f.Tags, ",") != "work,travel" {
+		t.Errorf("Tags = %v, want [work travel]", f.Tags)
+	}
+	if strings.Join(f.ExcludeTags, ",") != "reimbursed" {
+		t.Errorf("ExcludeTags = %v, want [reimbursed]", f.ExcludeTags)
+	}
+}
+
 func TestBuildSearchFilter_DateToIsEndOfDay(t *testing.T) {
-	f, code, _ := buildSearchFilter("", "", "", "", "2026-05-21", nil, nil)
+	f, code, _ := buildSearchFilter(model.DefaultCategories(1), "", "", "", "", "2026-05-21", nil, nil, nil, nil)
 	if code != 0 {
 		t.Fatalf("unexpected error code %d", code)
 	}
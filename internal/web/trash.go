@@ -0,0 +1,127 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"cashout/internal/client"
+	"cashout/internal/model"
+)
+
+func toTrashedTransactionDTO(tx model.Transaction) TrashedTransactionDTO {
+	dto := TrashedTransactionDTO{TransactionDTO: toTransactionDTO(tx)}
+	if tx.DeletedAt != nil {
+		dto.DeletedAt = *tx.DeletedAt
+		dto.PurgeAt = tx.PurgeDate()
+	}
+	return dto
+}
+
+// handleAPITrash lists the transactions in the trash of the user.
+//
+//	@Summary		List deleted transactions
+//	@Description	The transactions deleted by the user in the last 30 days, the most recently deleted first. They are permanently deleted at purgeAt unless restored.
+//	@Tags			transactions
+//	@Produce		json
+//	@Param			offset	query		int	false	"Number of transactions to skip"
+//	@Param			limit	query		int	false	"Page size, 50 by default and at most 200"
+//	@Success		200		{object}	TrashResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/transactions/trash [get]
+func (s *Server) handleAPITrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	offset := 0
+	if n, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && n > 0 {
+		offset = n
+	}
+	limit := 50
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = min(n, 200)
+	}
+
+	txs, total, err := s.repositories.Transactions.Trash(user.TgID, offset, limit)
+	if err != nil {
+		s.logger.Errorf("Failed to get trash: %v", err)
+		s.sendJSONError(w, "Failed to get trash", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]TrashedTransactionDTO, len(txs))
+	for i, tx := range txs {
+		dtos[i] = toTrashedTransactionDTO(tx)
+	}
+	s.sendJSONSuccess(w, TrashResponse{
+		Transactions: dtos,
+		Total:        total,
+		Offset:       offset,
+		Limit:        limit,
+	})
+}
+
+// handleAPIRestoreTransaction takes a transaction out of the trash.
+//
+//	@Summary		Restore a deleted transaction
+//	@Description	Take a transaction of the user out of the trash, it counts again in the balances, recaps and budgets.
+//	@Tags			transactions
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		RestoreTransactionRequest	true	"Transaction to restore"
+//	@Success		200		{object}	MessageResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/api/transactions/trash/restore [post]
+func (s *Server) handleAPIRestoreTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := client.GetUserFromContext(r.Context())
+	if user == nil {
+		s.sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req RestoreTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		s.sendJSONError(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	err := s.repositories.Transactions.As(actor(r, user)).Restore(req.ID, user.TgID)
+	if errors.Is(err, model.ErrTransactionNotFound) {
+		s.sendJSONError(w, "Transaction not found in the trash", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Errorf("Failed to restore transaction: %v", err)
+		s.sendJSONError(w, "Failed to restore transaction", http.StatusInternalServerError)
+		return
+	}
+
+	if tx, err := s.repositories.Transactions.GetByID(req.ID); err == nil {
+		s.notifyBudgets(*user, tx)
+	}
+	s.sendJSONSuccess(w, MessageResponse{Message: "Transaction restored successfully"})
+}
@@ -36,16 +36,62 @@ func Router(s *Server) http.Handler {
 	mux.HandleFunc(basePath+"/api/transactions", s.requireAuth(s.handleAPITransactions))
 	mux.HandleFunc(basePath+"/api/transactions/create", s.requireAuth(s.handleAPICreateTransaction))
 	mux.HandleFunc(basePath+"/api/transactions/delete", s.requireAuth(s.handleAPIDeleteTransaction))
+	mux.HandleFunc(basePath+"/api/transactions/trash", s.requireAuth(s.handleAPITrash))
+	mux.HandleFunc(basePath+"/api/transactions/trash/restore", s.requireAuth(s.handleAPIRestoreTransaction))
 	mux.HandleFunc(basePath+"/api/transactions/edit", s.requireAuth(s.handleAPIEditTransaction))
 	mux.HandleFunc(basePath+"/api/transactions/clone", s.requireAuth(s.handleAPICloneTransaction))
 	mux.HandleFunc(basePath+"/api/transactions/search", s.requireAuth(s.handleAPISearchTransactions))
 	mux.HandleFunc(basePath+"/api/transactions/export", s.requireAuth(s.handleAPIExportTransactions))
+	mux.HandleFunc(basePath+"/api/transactions/{id}/history", s.requireAuth(s.handleAPITransactionHistory))
+	mux.HandleFunc(basePath+"/api/transactions/{id}/attachments", s.requireAuth(s.handleAPITransactionAttachments))
+	mux.HandleFunc(basePath+"/api/transactions/{id}/attachments/{attachmentId}", s.requireAuth(s.handleAPIDownloadAttachment))
 	mux.HandleFunc(basePath+"/api/categories", s.requireAuth(s.handleAPICategories))
+	mux.HandleFunc(basePath+"/api/categories/create", s.requireAuth(s.handleAPICreateCategory))
+	mux.HandleFunc(basePath+"/api/categories/edit", s.requireAuth(s.handleAPIEditCategory))
+	mux.HandleFunc(basePath+"/api/categories/delete", s.requireAuth(s.handleAPIDeleteCategory))
+	mux.HandleFunc(basePath+"/api/tags", s.requireAuth(s.handleAPITags))
+	mux.HandleFunc(basePath+"/api/accounts", s.requireAuth(s.handleAPIAccounts))
+	mux.HandleFunc(basePath+"/api/accounts/create", s.requireAuth(s.handleAPICreateAccount))
+	mux.HandleFunc(basePath+"/api/accounts/edit", s.requireAuth(s.handleAPIEditAccount))
+	mux.HandleFunc(basePath+"/api/accounts/delete", s.requireAuth(s.handleAPIDeleteAccount))
+	mux.HandleFunc(basePath+"/api/accounts/ledger", s.requireAuth(s.handleAPIAccountLedger))
+	mux.HandleFunc(basePath+"/api/accounts/transfer", s.requireAuth(s.handleAPITransfer))
+	mux.HandleFunc(basePath+"/api/recurring", s.requireAuth(s.handleAPIRecurringRules))
+	mux.HandleFunc(basePath+"/api/recurring/create", s.requireAuth(s.handleAPICreateRecurringRule))
+	mux.HandleFunc(basePath+"/api/recurring/edit", s.requireAuth(s.handleAPIEditRecurringRule))
+	mux.HandleFunc(basePath+"/api/recurring/delete", s.requireAuth(s.handleAPIDeleteRecurringRule))
+	mux.HandleFunc(basePath+"/api/ledgers", s.requireAuth(s.handleAPILedgers))
+	mux.HandleFunc(basePath+"/api/ledgers/create", s.requireAuth(s.handleAPICreateLedger))
+	mux.HandleFunc(basePath+"/api/ledgers/join", s.requireAuth(s.handleAPIJoinLedger))
+	mux.HandleFunc(basePath+"/api/ledgers/leave", s.requireAuth(s.handleAPILeaveLedger))
+	mux.HandleFunc(basePath+"/api/ledgers/switch", s.requireAuth(s.handleAPISwitchLedger))
+	mux.HandleFunc(basePath+"/api/ledgers/invite", s.requireAuth(s.handleAPIResetLedgerInvite))
+	mux.HandleFunc(basePath+"/api/ledgers/members/role", s.requireAuth(s.handleAPISetLedgerMemberRole))
+	mux.HandleFunc(basePath+"/api/ledgers/members/remove", s.requireAuth(s.handleAPIRemoveLedgerMember))
+	mux.HandleFunc(basePath+"/api/owe", s.requireAuth(s.handleAPIOwe))
+	mux.HandleFunc(basePath+"/api/owe/share", s.requireAuth(s.handleAPIShareExpense))
+	mux.HandleFunc(basePath+"/api/settle", s.requireAuth(s.handleAPISettle))
 	mux.HandleFunc(basePath+"/api/stats", s.requireAuth(s.handleAPIStats))
 	mux.HandleFunc(basePath+"/api/budget", s.requireAuth(s.handleAPIBudget))
+	mux.HandleFunc(basePath+"/api/goals", s.requireAuth(s.handleAPIGoals))
+	mux.HandleFunc(basePath+"/api/goals/{id}", s.requireAuth(s.handleAPIGoal))
+	mux.HandleFunc(basePath+"/api/goals/{id}/contributions", s.requireAuth(s.handleAPIGoalContributions))
+	mux.HandleFunc(basePath+"/api/goals/{id}/contributions/{contributionId}", s.requireAuth(s.handleAPIDeleteGoalContribution))
+	mux.HandleFunc(basePath+"/api/envelopes", s.requireAuth(s.handleAPIEnvelopes))
+	mux.HandleFunc(basePath+"/api/envelopes/assign", s.requireAuth(s.handleAPIAssignEnvelope))
+	mux.HandleFunc(basePath+"/api/envelopes/move", s.requireAuth(s.handleAPIMoveEnvelope))
+	mux.HandleFunc(basePath+"/api/holdings", s.requireAuth(s.handleAPIHoldings))
+	mux.HandleFunc(basePath+"/api/holdings/{id}", s.requireAuth(s.handleAPIHolding))
+	mux.HandleFunc(basePath+"/api/holdings/{id}/balances", s.requireAuth(s.handleAPIHoldingBalance))
 	mux.HandleFunc(basePath+"/api/analytics/monthly", s.requireAuth(s.handleAPIAnalyticsMonthly))
 	mux.HandleFunc(basePath+"/api/analytics/trend", s.requireAuth(s.handleAPIAnalyticsTrend))
+	mux.HandleFunc(basePath+"/api/analytics/networth", s.requireAuth(s.handleAPIAnalyticsNetWorth))
 	mux.HandleFunc(basePath+"/api/analytics/year", s.requireAuth(s.handleAPIAnalyticsYear))
+	mux.HandleFunc(basePath+"/api/timezone", s.requireAuth(s.handleAPITimezone))
+	mux.HandleFunc(basePath+"/api/exchange-rates", s.requireAuth(s.handleAPIExchangeRates))
+
+	// Admin routes (protected, restricted to ADMIN_USERS)
+	mux.HandleFunc(basePath+"/api/admin/exchange-rates", s.requireAdmin(s.handleAPIAdminExchangeRates))
 
 	// WebAuthn/Passkey management (protected)
 	mux.HandleFunc(basePath+"/api/passkey/begin-register", s.requireAuth(s.handlePasskeyBeginRegister))
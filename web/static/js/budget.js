@@ -1,8 +1,19 @@
-// Budget tab: monthly budget management.
+// Budget tab: overall and per-category budget management, each with its period.
 (function () {
   const statusEl = document.getElementById('budgetStatus');
   const form = document.getElementById('budgetForm');
+  const categorySelect = document.getElementById('budgetCategory');
   const amountInput = document.getElementById('budgetAmount');
+  const rolloverSelect = document.getElementById('budgetRollover');
+  const rolloverCapInput = document.getElementById('budgetRolloverCap');
+  const thresholdsInput = document.getElementById('budgetThresholds');
+  const alertEmailInput = document.getElementById('budgetAlertEmail');
+  const periodSelect = document.getElementById('budgetPeriod');
+  const weekStartGroup = document.getElementById('budgetWeekStartGroup');
+  const weekStartSelect = document.getElementById('budgetWeekStart');
+  const rangeRow = document.getElementById('budgetRangeRow');
+  const startDateInput = document.getElementById('budgetStartDate');
+  const endDateInput = document.getElementById('budgetEndDate');
   const submitBtn = document.getElementById('submitBudgetBtn');
   const deleteBtn = document.getElementById('deleteBudgetBtn');
   const messageEl = document.getElementById('budgetMessage');
@@ -12,7 +23,7 @@
   const fmtEUR = (n) =>
     new Intl.NumberFormat(undefined, {
       style: 'currency',
-      currency: 'EUR',
+      currency: document.body.dataset.baseCurrency || 'EUR',
     }).format(n);
 
   function showMessage(text, kind) {
@@ -24,54 +35,164 @@
     }, 4000);
   }
 
-  function renderStatus(data) {
-    if (!data || !data.hasBudget) {
-      statusEl.innerHTML =
-        '<div class="budget-empty">No monthly budget set yet.</div>';
-      deleteBtn.hidden = true;
-      submitBtn.textContent = 'Save Budget';
-      return;
-    }
+  function esc(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+  }
 
-    const pct = Math.max(0, Math.min(100, data.pct));
+  function budgetCard(b, title) {
+    const pct = Math.max(0, Math.min(100, b.pct));
     let state = 'ok';
-    if (data.pct >= 100) state = 'over';
-    else if (data.pct >= 80) state = 'warn';
+    if (b.pct >= 100) state = 'over';
+    else if (b.pct >= 80) state = 'warn';
 
-    statusEl.innerHTML = `
+    const remove =
+      b.category !== undefined
+        ? `<button type="button" class="btn-secondary-danger budget-remove" data-category="${esc(b.category)}">Remove</button>`
+        : '';
+    return `
       <div class="budget-card">
+        ${title ? `<div class="budget-card-head"><span class="budget-category-name">${esc(title)}</span>${remove}</div>` : ''}
         <div class="budget-card-head">
           <div class="budget-amounts">
-            <span class="budget-spent">${fmtEUR(data.spent)}</span>
+            <span class="budget-spent">${fmtEUR(b.spent || 0)}</span>
             <span class="budget-of">of</span>
-            <span class="budget-total">${fmtEUR(data.amount)}</span>
+            <span class="budget-total">${fmtEUR(b.limit || b.amount)}</span>
           </div>
           <div class="budget-meta">
-            <span class="budget-pct budget-pct--${state}">${data.pct}%</span>
-            <span class="budget-month">${data.month}</span>
+            <span class="budget-pct budget-pct--${state}">${b.pct || 0}%</span>
+            ${periodLabel(b)}
           </div>
         </div>
         <div class="budget-bar">
           <div class="budget-bar-fill budget-bar-fill--${state}" style="width:${pct}%"></div>
         </div>
+        ${rolloverLine(b)}
+        ${b.thresholds ? `<div class="budget-rollover">🔔 Alerts at ${b.thresholds.map((t) => t + '%').join(', ')}</div>` : ''}
       </div>
     `;
-    amountInput.value = data.amount.toString();
-    deleteBtn.hidden = false;
-    submitBtn.textContent = 'Update Budget';
   }
 
-  async function fetchBudget() {
+  // periodLabel shows the month of a monthly budget, the current window of the others.
+  function periodLabel(b) {
+    if (!b.period || b.period === 'monthly') {
+      return b.month ? `<span class="budget-month">${b.month}</span>` : '';
+    }
+    return `<span class="budget-month">${esc(b.period)}, ${esc(b.start)} → ${esc(b.end)}</span>`;
+  }
+
+  // rolloverLine shows how the effective limit is made: base + carry = limit.
+  function rolloverLine(b) {
+    if (!b.rollover || b.rollover === 'none') return '';
+    const sign = b.carry < 0 ? '−' : '+';
+    const cap = b.rolloverCap ? `, cap ${fmtEUR(b.rolloverCap)}` : '';
+    return `
+      <div class="budget-rollover">
+        🔁 ${fmtEUR(b.amount)} ${sign} ${fmtEUR(Math.abs(b.carry || 0))} carried = ${fmtEUR(b.limit)}
+        <span class="budget-month">(${esc(b.rollover)} rollover${cap})</span>
+      </div>
+    `;
+  }
+
+  let current = null;
+
+  // syncPeriod shows the week start of a weekly budget and the dates of a custom one.
+  function syncPeriod() {
+    weekStartGroup.hidden = periodSelect.value !== 'weekly';
+    rangeRow.hidden = periodSelect.value !== 'custom';
+  }
+
+  // syncForm fills the amount, period, rollover and alerts of the budget picked in the select, if any.
+  function syncForm() {
+    const category = categorySelect.value;
+    let budget = null;
+    if (current) {
+      if (!category && current.hasBudget) budget = current;
+      const found = (current.categories || []).find((c) => c.category === category);
+      if (category && found) budget = found;
+    }
+    amountInput.value = budget ? budget.amount.toString() : '';
+    rolloverSelect.value = (budget && budget.rollover) || 'none';
+    rolloverCapInput.value = budget && budget.rolloverCap ? budget.rolloverCap.toString() : '';
+    thresholdsInput.value = budget && budget.thresholds ? budget.thresholds.join(', ') : '';
+    alertEmailInput.checked = !!(current && current.alertEmail);
+    periodSelect.value = (budget && budget.period) || 'monthly';
+    weekStartSelect.value = (budget && budget.weekStart) || 'monday';
+    startDateInput.value = (budget && budget.startDate) || '';
+    endDateInput.value = (budget && budget.endDate) || '';
+    syncPeriod();
+    deleteBtn.hidden = !budget;
+    submitBtn.textContent = budget ? 'Update Budget' : 'Save Budget';
+  }
+
+  function renderStatus(data) {
+    current = data;
+    const categories = (data && data.categories) || [];
+    if (!data || (!data.hasBudget && !categories.length)) {
+      statusEl.innerHTML =
+        '<div class="budget-empty">No budget set yet.</div>';
+      syncForm();
+      return;
+    }
+
+    let html = data.hasBudget ? budgetCard(data, categories.length ? 'All expenses' : '') : '';
+    html += categories
+      .map((c) => budgetCard(Object.assign({ month: data.month }, c), c.category))
+      .join('');
+    statusEl.innerHTML = html;
+    syncForm();
+  }
+
+  async function loadCategories() {
     try {
-      const res = await fetch('/web/api/budget', { credentials: 'same-origin' });
+      const res = await fetch('/web/api/categories?type=Expense', { credentials: 'same-origin' });
       const json = await res.json();
       if (!res.ok) throw new Error(json.error || 'Request failed');
-      renderStatus(json);
+      (json.items || []).forEach((c) => {
+        const option = document.createElement('option');
+        option.value = c.name;
+        option.textContent = `${c.emoji} ${c.name}`;
+        categorySelect.appendChild(option);
+      });
+    } catch (e) {
+      showMessage('Failed to load categories.', 'error');
+    }
+  }
+
+  async function request(options) {
+    const res = await fetch(
+      '/web/api/budget' + (options.query || ''),
+      Object.assign({ credentials: 'same-origin' }, options),
+    );
+    const json = await res.json();
+    if (!res.ok) throw new Error(json.error || 'Request failed');
+    return json;
+  }
+
+  async function fetchBudget() {
+    try {
+      renderStatus(await request({}));
     } catch (e) {
       statusEl.innerHTML = '<div class="error">Failed to load budget.</div>';
     }
   }
 
+  async function removeBudget(category) {
+    const what = category ? `the ${category} budget` : 'your overall budget';
+    if (!confirm(`Remove ${what}?`)) return;
+    try {
+      const query = category ? '?category=' + encodeURIComponent(category) : '';
+      renderStatus(await request({ method: 'DELETE', query }));
+      showMessage('Budget removed.', 'success');
+    } catch (e) {
+      showMessage(e.message || 'Failed to remove budget.', 'error');
+    }
+  }
+
+  categorySelect.addEventListener('change', syncForm);
+  periodSelect.addEventListener('change', syncPeriod);
+
   form.addEventListener('submit', async (e) => {
     e.preventDefault();
     const raw = (amountInput.value || '').replace(',', '.').trim();
@@ -80,43 +201,49 @@
       showMessage('Please enter a positive amount.', 'error');
       return;
     }
+    const rolloverCap = parseFloat((rolloverCapInput.value || '0').replace(',', '.').trim()) || 0;
+    const thresholds = (thresholdsInput.value.match(/[0-9]+/g) || []).map(Number);
+    const period = periodSelect.value;
+    if (period === 'custom' && !(startDateInput.value && endDateInput.value)) {
+      showMessage('Please pick the dates of the custom period.', 'error');
+      return;
+    }
     submitBtn.disabled = true;
     try {
-      const res = await fetch('/web/api/budget', {
+      const json = await request({
         method: 'POST',
-        credentials: 'same-origin',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ amount }),
+        body: JSON.stringify({
+          amount,
+          category: categorySelect.value,
+          rollover: rolloverSelect.value,
+          rolloverCap,
+          thresholds: thresholds.length ? thresholds : undefined,
+          alertEmail: alertEmailInput.checked,
+          period,
+          weekStart: period === 'weekly' ? weekStartSelect.value : undefined,
+          startDate: period === 'custom' ? startDateInput.value : undefined,
+          endDate: period === 'custom' ? endDateInput.value : undefined,
+        }),
       });
-      const json = await res.json();
-      if (!res.ok) throw new Error(json.error || 'Save failed');
       renderStatus(json);
       showMessage('Budget saved.', 'success');
     } catch (e) {
-      showMessage('Failed to save budget.', 'error');
+      showMessage(e.message || 'Failed to save budget.', 'error');
     } finally {
       submitBtn.disabled = false;
     }
   });
 
   deleteBtn.addEventListener('click', async () => {
-    if (!confirm('Remove your monthly budget?')) return;
     deleteBtn.disabled = true;
-    try {
-      const res = await fetch('/web/api/budget', {
-        method: 'DELETE',
-        credentials: 'same-origin',
-      });
-      const json = await res.json();
-      if (!res.ok) throw new Error(json.error || 'Delete failed');
-      amountInput.value = '';
-      renderStatus(json);
-      showMessage('Budget removed.', 'success');
-    } catch (e) {
-      showMessage('Failed to remove budget.', 'error');
-    } finally {
-      deleteBtn.disabled = false;
-    }
+    await removeBudget(categorySelect.value);
+    deleteBtn.disabled = false;
+  });
+
+  statusEl.addEventListener('click', (e) => {
+    if (!e.target.classList.contains('budget-remove')) return;
+    removeBudget(e.target.dataset.category);
   });
 
   // Lazy-load on first tab activation, or immediately if Budget is the persisted current page.
@@ -124,7 +251,7 @@
   function ensureLoaded() {
     if (loaded) return;
     loaded = true;
-    fetchBudget();
+    loadCategories().then(fetchBudget);
   }
   document.querySelectorAll('.nav-tab').forEach((tab) => {
     tab.addEventListener('click', () => {
@@ -8,6 +8,12 @@
     ];
 
     const categoryColorMap = new Map();
+
+    // Use the colours the user picked for their categories
+    function setCategoryColors(categories) {
+        (categories || []).forEach((c) => categoryColorMap.set(c.name, c.color));
+    }
+
     function colorForCategory(cat) {
         if (!categoryColorMap.has(cat)) {
             categoryColorMap.set(cat, PALETTE[categoryColorMap.size % PALETTE.length]);
@@ -17,7 +23,7 @@
 
     function fmtEUR(value) {
         return new Intl.NumberFormat('en-US', {
-            style: 'currency', currency: 'EUR', minimumFractionDigits: 2,
+            style: 'currency', currency: document.body.dataset.baseCurrency || 'EUR', minimumFractionDigits: 2,
         }).format(value);
     }
 
@@ -183,5 +189,6 @@
         renderTrendLine,
         renderYearStacked,
         colorForCategory,
+        setCategoryColors,
     };
 })(window);
@@ -1,8 +1,8 @@
-// Format currency
+// Format currency, amounts are always in the user's base currency
 function formatCurrency(amount) {
     return new Intl.NumberFormat('en-US', {
         style: 'currency',
-        currency: 'EUR',
+        currency: document.body.dataset.baseCurrency || 'EUR',
         minimumFractionDigits: 2
     }).format(amount);
 }
@@ -26,6 +26,22 @@ function formatDateShort(dateString) {
     });
 }
 
+// Format the tags of a transaction as hashtags, empty without tags
+function formatTags(tx) {
+    if (!tx.tags || tx.tags.length === 0) return '';
+    return ` <span class="transaction-tags">${tx.tags.map(t => '#' + t).join(' ')}</span>`;
+}
+
+// Split the hashtags out of a free text, e.g. "taxi #work" -> "taxi", ["work"]
+function splitHashtags(text) {
+    const tags = [];
+    const description = text.replace(/(^|\s)#([\p{L}\p{N}_-]+)/gu, (match, space, tag) => {
+        tags.push(tag.toLowerCase());
+        return space;
+    }).replace(/\s+/g, ' ').trim();
+    return { description, tags: [...new Set(tags)] };
+}
+
 // Load statistics
 async function loadStats(month) {
     try {
@@ -169,7 +185,7 @@ function renderListView() {
         <tr>
             <td>${formatDate(tx.date)}</td>
             <td>${tx.category}</td>
-            <td>${tx.description || '-'}</td>
+            <td>${tx.description || '-'}${formatTags(tx)}</td>
             <td class="amount ${tx.type.toLowerCase()}">${tx.type.toLowerCase() === 'income' ? '+' : '-'}${formatCurrency(Math.abs(tx.amount))}</td>
             <td class="actions">
                 <button class="delete-btn" data-id="${tx.id}" data-description="${tx.description || tx.category}" title="Delete transaction">
@@ -192,7 +208,7 @@ function renderListView() {
             <div class="transaction-card-body">
                 <div class="transaction-card-info">
                     <div class="transaction-card-category">${tx.category}</div>
-                    <div class="transaction-card-description">${tx.description || '-'}</div>
+                    <div class="transaction-card-description">${tx.description || '-'}${formatTags(tx)}</div>
                 </div>
                 <button class="delete-btn" data-id="${tx.id}" data-description="${tx.description || tx.category}" title="Delete transaction">
                     <svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round">
@@ -282,7 +298,7 @@ function renderClusteredView() {
             <tr>
                 <td>${formatDate(tx.date)}</td>
                 <td>${tx.category}</td>
-                <td>${tx.description || '-'}</td>
+                <td>${tx.description || '-'}${formatTags(tx)}</td>
                 <td class="amount ${tx.type.toLowerCase()}">${tx.type.toLowerCase() === 'income' ? '+' : '-'}${formatCurrency(Math.abs(tx.amount))}</td>
                 <td class="actions">
                     <button class="delete-btn" data-id="${tx.id}" data-description="${tx.description || tx.category}" title="Delete transaction">
@@ -305,7 +321,7 @@ function renderClusteredView() {
                 <div class="transaction-card-body">
                     <div class="transaction-card-info">
                         <div class="transaction-card-category">${tx.category}</div>
-                        <div class="transaction-card-description">${tx.description || '-'}</div>
+                        <div class="transaction-card-description">${tx.description || '-'}${formatTags(tx)}</div>
                     </div>
                     <button class="delete-btn" data-id="${tx.id}" data-description="${tx.description || tx.category}" title="Delete transaction">
                         <svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round">
@@ -418,10 +434,10 @@ async function loadCategories(type) {
 
         categorySelect.innerHTML = '';
 
-        data.categories.forEach((category, index) => {
+        data.items.forEach((category, index) => {
             const option = document.createElement('option');
-            option.value = category;
-            option.textContent = category;
+            option.value = category.name;
+            option.textContent = `${category.emoji} ${category.name}`;
             if (index === 0) {
                 option.selected = true; // Auto-select first category
             }
@@ -465,12 +481,17 @@ document.getElementById('addTransactionForm').addEventListener('submit', async f
         return;
     }
 
+    // Hashtags in the description become tags, as in the bot
+    const { description, tags } = splitHashtags(document.getElementById('txDescription').value);
+
     const formData = {
         type: document.getElementById('txType').value,
         category: document.getElementById('txCategory').value,
         amount: amount,
+        currency: document.getElementById('txCurrency').value,
         date: document.getElementById('txDate').value,
-        description: document.getElementById('txDescription').value
+        description: description,
+        tags: tags
     };
 
     try {
@@ -548,6 +569,8 @@ function showPage(pageName) {
         'trends': 'trendsPage',
         'year': 'yearPage',
         'budget': 'budgetPage',
+        'goals': 'goalsPage',
+        'settings': 'settingsPage',
         'security': 'securityPage'
     };
 
@@ -577,11 +600,22 @@ document.querySelectorAll('.nav-tab').forEach(tab => {
 // Initialize page on load
 showPage(currentPage);
 
+// Load the user's category colours for the charts
+async function loadCategoryColors() {
+    try {
+        const response = await fetch('/web/api/categories');
+        const data = await response.json();
+        CashoutCharts.setCategoryColors(data.items);
+    } catch (error) {
+        console.error('Error loading category colours:', error);
+    }
+}
+
 // Load data on page load
 const currentMonth = document.getElementById('currentMonth').value;
 loadStats(currentMonth);
 loadTransactions(currentMonth);
-loadMonthlyCharts(currentMonth);
+loadCategoryColors().then(() => loadMonthlyCharts(currentMonth));
 
 // Monthly charts (donut + income/expense bar)
 async function loadMonthlyCharts(month) {
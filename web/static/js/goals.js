@@ -0,0 +1,201 @@
+// Goals tab: savings goals, their progress and contributions.
+(function () {
+  const listEl = document.getElementById('goalsList');
+  const form = document.getElementById('goalForm');
+  const nameInput = document.getElementById('goalName');
+  const targetInput = document.getElementById('goalTarget');
+  const deadlineInput = document.getElementById('goalDeadline');
+  const tagInput = document.getElementById('goalTag');
+  const submitBtn = document.getElementById('submitGoalBtn');
+  const messageEl = document.getElementById('goalMessage');
+
+  if (!listEl || !form) return;
+
+  const fmtMoney = (n, currency) =>
+    new Intl.NumberFormat(undefined, {
+      style: 'currency',
+      currency: currency || document.body.dataset.baseCurrency || 'EUR',
+    }).format(n);
+
+  const fmtDate = (s) =>
+    new Date(s + 'T00:00:00Z').toLocaleDateString(undefined, { timeZone: 'UTC' });
+
+  function esc(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+  }
+
+  function showMessage(text, kind) {
+    messageEl.textContent = text;
+    messageEl.className = 'message ' + (kind || 'success');
+    setTimeout(() => {
+      messageEl.textContent = '';
+      messageEl.className = 'message';
+    }, 4000);
+  }
+
+  function parseAmount(value) {
+    return parseFloat((value || '').replace(',', '.').trim());
+  }
+
+  // projection describes whether the deadline is met at the current rate.
+  function projection(g) {
+    const rate = `${fmtMoney(g.monthlyRate, g.currency)}/month`;
+    switch (g.status) {
+      case 'reached':
+        return '🎉 Goal reached!';
+      case 'on_track':
+        return `✅ On track for ${fmtDate(g.deadline)}: at ${rate} it is reached by ${fmtDate(g.estimatedDate)}`;
+      case 'behind': {
+        let text = `⚠️ Behind: save ${fmtMoney(g.required, g.currency)}/month to make it by ${fmtDate(g.deadline)}`;
+        if (g.estimatedDate) text += `, at ${rate} it is reached by ${fmtDate(g.estimatedDate)}`;
+        return text;
+      }
+      case 'overdue':
+        return `⏰ The deadline of ${fmtDate(g.deadline)} has passed, ${fmtMoney(g.remaining, g.currency)} to go`;
+      default:
+        if (g.estimatedDate) return `📈 At ${rate} it is reached by ${fmtDate(g.estimatedDate)}`;
+        return `${fmtMoney(g.remaining, g.currency)} to go`;
+    }
+  }
+
+  function renderGoals(goals) {
+    if (!goals.length) {
+      listEl.innerHTML = '<div class="budget-empty">No savings goals yet.</div>';
+      return;
+    }
+
+    listEl.innerHTML = goals
+      .map((g) => {
+        let state = 'ok';
+        if (g.status === 'behind') state = 'warn';
+        else if (g.status === 'overdue') state = 'over';
+        const pct = Math.max(0, Math.min(100, g.pct));
+        const tag = g.tag ? `<span class="goal-tag">#${esc(g.tag)}</span>` : '';
+        return `
+          <div class="budget-card" data-goal-id="${g.id}">
+            <div class="budget-card-head">
+              <div><span class="goal-name">${esc(g.name)}</span>${tag}</div>
+              <div class="budget-meta">
+                <span class="budget-pct budget-pct--${state}">${g.pct}%</span>
+              </div>
+            </div>
+            <div class="budget-amounts">
+              <span class="budget-spent">${fmtMoney(g.saved, g.currency)}</span>
+              <span class="budget-of">of</span>
+              <span class="budget-total">${fmtMoney(g.targetAmount, g.currency)}</span>
+            </div>
+            <div class="budget-bar">
+              <div class="budget-bar-fill budget-bar-fill--${state}" style="width:${pct}%"></div>
+            </div>
+            <div class="goal-projection">${projection(g)}</div>
+            <form class="goal-contribute">
+              <input type="text" name="amount" placeholder="Amount, negative to withdraw" pattern="-?[0-9]+([.,][0-9]{1,2})?" required />
+              <input type="text" name="note" placeholder="Note (optional)" maxlength="255" />
+              <button type="submit" class="btn-secondary">Add</button>
+              <button type="button" class="btn-secondary-danger goal-delete">Delete</button>
+            </form>
+          </div>
+        `;
+      })
+      .join('');
+  }
+
+  async function request(url, options) {
+    const res = await fetch(url, Object.assign({ credentials: 'same-origin' }, options));
+    const json = await res.json();
+    if (!res.ok) throw new Error(json.error || 'Request failed');
+    return json;
+  }
+
+  async function fetchGoals() {
+    try {
+      const json = await request('/web/api/goals');
+      renderGoals(json.goals || []);
+    } catch (e) {
+      listEl.innerHTML = '<div class="error">Failed to load goals.</div>';
+    }
+  }
+
+  listEl.addEventListener('submit', async (e) => {
+    e.preventDefault();
+    const card = e.target.closest('[data-goal-id]');
+    const amount = parseAmount(e.target.elements.amount.value);
+    if (!amount) {
+      showMessage('Please enter an amount other than zero.', 'error');
+      return;
+    }
+    try {
+      await request(`/web/api/goals/${card.dataset.goalId}/contributions`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ amount, note: e.target.elements.note.value.trim() }),
+      });
+      showMessage('Contribution added.', 'success');
+      fetchGoals();
+    } catch (err) {
+      showMessage(err.message || 'Failed to add contribution.', 'error');
+    }
+  });
+
+  listEl.addEventListener('click', async (e) => {
+    if (!e.target.classList.contains('goal-delete')) return;
+    const card = e.target.closest('[data-goal-id]');
+    if (!confirm('Delete this goal and its contributions? Tagged transactions are kept.')) return;
+    try {
+      await request(`/web/api/goals/${card.dataset.goalId}`, { method: 'DELETE' });
+      showMessage('Goal deleted.', 'success');
+      fetchGoals();
+    } catch (err) {
+      showMessage(err.message || 'Failed to delete goal.', 'error');
+    }
+  });
+
+  form.addEventListener('submit', async (e) => {
+    e.preventDefault();
+    const targetAmount = parseAmount(targetInput.value);
+    if (!(targetAmount > 0)) {
+      showMessage('Please enter a positive target amount.', 'error');
+      return;
+    }
+    submitBtn.disabled = true;
+    try {
+      await request('/web/api/goals', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+          name: nameInput.value.trim(),
+          targetAmount,
+          deadline: deadlineInput.value,
+          tag: tagInput.value.trim(),
+        }),
+      });
+      form.reset();
+      showMessage('Goal added.', 'success');
+      fetchGoals();
+    } catch (err) {
+      showMessage(err.message || 'Failed to add goal.', 'error');
+    } finally {
+      submitBtn.disabled = false;
+    }
+  });
+
+  // Lazy-load on first tab activation, or immediately if Goals is the persisted current page.
+  let loaded = false;
+  function ensureLoaded() {
+    if (loaded) return;
+    loaded = true;
+    fetchGoals();
+  }
+  document.querySelectorAll('.nav-tab').forEach((tab) => {
+    tab.addEventListener('click', () => {
+      if (tab.dataset.page === 'goals') ensureLoaded();
+    });
+  });
+  if (
+    (localStorage.getItem('currentPage') || 'transactions') === 'goals'
+  ) {
+    ensureLoaded();
+  }
+})();
@@ -0,0 +1,100 @@
+// Settings tab: the user's timezone.
+(function () {
+  const form = document.getElementById('timezoneForm');
+  const input = document.getElementById('timezoneInput');
+  const options = document.getElementById('timezoneOptions');
+  const nowEl = document.getElementById('timezoneNow');
+  const submitBtn = document.getElementById('submitTimezoneBtn');
+  const detectBtn = document.getElementById('detectTimezoneBtn');
+  const messageEl = document.getElementById('timezoneMessage');
+
+  if (!form) return;
+
+  function showMessage(text, kind) {
+    messageEl.textContent = text;
+    messageEl.className = 'message ' + (kind || 'success');
+    setTimeout(() => {
+      messageEl.textContent = '';
+      messageEl.className = 'message';
+    }, 4000);
+  }
+
+  function render(data) {
+    input.value = data.timezone;
+    const now = new Date(data.now);
+    const time = new Intl.DateTimeFormat(undefined, {
+      timeZone: data.timezone,
+      weekday: 'short',
+      hour: '2-digit',
+      minute: '2-digit',
+    }).format(now);
+    nowEl.textContent = `(now ${time})`;
+  }
+
+  if (Intl.supportedValuesOf) {
+    for (const tz of Intl.supportedValuesOf('timeZone')) {
+      const option = document.createElement('option');
+      option.value = tz;
+      options.appendChild(option);
+    }
+  }
+
+  async function fetchTimezone() {
+    try {
+      const res = await fetch('/web/api/timezone', { credentials: 'same-origin' });
+      const json = await res.json();
+      if (!res.ok) throw new Error(json.error || 'Request failed');
+      render(json);
+    } catch (e) {
+      showMessage('Failed to load timezone.', 'error');
+    }
+  }
+
+  detectBtn.addEventListener('click', () => {
+    input.value = Intl.DateTimeFormat().resolvedOptions().timeZone || 'UTC';
+  });
+
+  form.addEventListener('submit', async (e) => {
+    e.preventDefault();
+    const timezone = (input.value || '').trim();
+    if (!timezone) {
+      showMessage('Please enter a timezone.', 'error');
+      return;
+    }
+    submitBtn.disabled = true;
+    try {
+      const res = await fetch('/web/api/timezone', {
+        method: 'PUT',
+        credentials: 'same-origin',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ timezone }),
+      });
+      const json = await res.json();
+      if (!res.ok) throw new Error(json.error || 'Save failed');
+      render(json);
+      showMessage('Timezone saved, reload the page to see your current month.', 'success');
+    } catch (e) {
+      showMessage(e.message || 'Failed to save timezone.', 'error');
+    } finally {
+      submitBtn.disabled = false;
+    }
+  });
+
+  // Lazy-load on first tab activation, or immediately if Settings is the persisted current page.
+  let loaded = false;
+  function ensureLoaded() {
+    if (loaded) return;
+    loaded = true;
+    fetchTimezone();
+  }
+  document.querySelectorAll('.nav-tab').forEach((tab) => {
+    tab.addEventListener('click', () => {
+      if (tab.dataset.page === 'settings') ensureLoaded();
+    });
+  });
+  if (
+    (localStorage.getItem('currentPage') || 'transactions') === 'settings'
+  ) {
+    ensureLoaded();
+  }
+})();